@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/services/aieval"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/rs/zerolog"
+)
+
+// promptFlags 可重複指定的 -prompt 參數
+type promptFlags []string
+
+func (p *promptFlags) String() string     { return strings.Join(*p, ",") }
+func (p *promptFlags) Set(v string) error { *p = append(*p, v); return nil }
+
+func main() {
+	var (
+		dataset        = flag.String("dataset", "evals/golden.jsonl", "labeled emails (JSONL)")
+		mode           = flag.String("mode", string(aieval.ModeLive), "live | record | replay")
+		recordings     = flag.String("recordings", "evals/recordings.json", "recorded responses used by record/replay modes")
+		baseline       = flag.String("baseline", "evals/baseline.json", "baseline report to compare against (skipped if missing)")
+		out            = flag.String("out", "", "write the full JSON report to this file")
+		updateBaseline = flag.Bool("update-baseline", false, "overwrite the baseline with this run")
+		tolerance      = flag.Float64("tolerance", 0.02, "allowed drop in accuracy / recall / field accuracy before failing")
+		costTolerance  = flag.Float64("cost-tolerance", 0.2, "allowed relative increase in cost per email and p95 latency")
+		verbose        = flag.Bool("v", false, "log every LLM call")
+		prompts        promptFlags
+	)
+	flag.Var(&prompts, "prompt", "evaluate a candidate template instead of the built-in one (name=path, repeatable)")
+	flag.Usage = printUsage
+	flag.Parse()
+
+	m := aieval.Mode(*mode)
+	if m != aieval.ModeLive && m != aieval.ModeRecord && m != aieval.ModeReplay {
+		log.Fatalf("❌ Unknown mode: %s", *mode)
+	}
+
+	cases, err := aieval.LoadDataset(*dataset)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	// 只需要模型後端設定（重播模式完全不連線）
+	cfg, err := config.LoadAI()
+	if err != nil {
+		log.Fatalf("❌ Failed to load config: %v", err)
+	}
+
+	logger := zerolog.New(io.Discard)
+	if *verbose {
+		logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).With().Timestamp().Logger()
+	}
+	svc := openai.NewService(*cfg, &logger, "")
+
+	if len(prompts) > 0 {
+		overrides := aieval.PromptOverrides{}
+		for _, spec := range prompts {
+			if err := overrides.ParsePromptOverride(spec); err != nil {
+				log.Fatalf("❌ %v", err)
+			}
+		}
+		svc.SetPromptSource(overrides)
+	}
+
+	tapePath := *recordings
+	if m == aieval.ModeLive {
+		tapePath = ""
+	}
+	tape, err := aieval.NewTape(m, tapePath)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Fatalf("❌ No recordings at %s; run once with -mode record against a configured provider", tapePath)
+	}
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("Evaluating %d emails (%s mode)...\n\n", len(cases), m)
+	report := aieval.NewRunner(svc, tape).Run(ctx, cases)
+	if err := tape.Save(tapePath); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	report.WriteText(os.Stdout)
+	if *out != "" {
+		if err := report.Save(*out); err != nil {
+			log.Fatalf("❌ Failed to write report: %v", err)
+		}
+	}
+
+	exitCode := 0
+	if report.Errors > 0 {
+		fmt.Printf("\n⚠️  %d emails failed (in replay mode, re-record after changing prompts or the dataset)\n", report.Errors)
+		exitCode = 1
+	}
+
+	base, err := aieval.LoadReport(*baseline)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		fmt.Printf("\nNo baseline at %s\n", *baseline)
+	case err != nil:
+		log.Fatalf("❌ %v", err)
+	default:
+		fmt.Printf("\nCompared with baseline %s (%s):\n", *baseline, base.GeneratedAt.Format("2006-01-02"))
+		cmp := aieval.Compare(base, report, *tolerance, *costTolerance)
+		cmp.WriteText(os.Stdout)
+		if cmp.Regressed() && !*updateBaseline {
+			fmt.Println("\n❌ Regression against baseline")
+			exitCode = 1
+		}
+	}
+
+	if *updateBaseline {
+		if err := report.Save(*baseline); err != nil {
+			log.Fatalf("❌ Failed to write baseline: %v", err)
+		}
+		fmt.Printf("\n✅ Baseline updated: %s\n", *baseline)
+	}
+	stop()
+	os.Exit(exitCode)
+}
+
+// printUsage 印出使用說明
+func printUsage() {
+	fmt.Println("AI Evaluation Tool")
+	fmt.Println()
+	fmt.Println("Runs the labeled email corpus through ClassifyEmail / ExtractInfo and reports per-category")
+	fmt.Println("precision and recall, field-level extraction accuracy, cost and latency against a baseline.")
+	fmt.Println()
+	fmt.Println("Usage:")
+	fmt.Println("  ai-eval [flags]")
+	fmt.Println()
+	flag.PrintDefaults()
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  ai-eval -mode record                        Call the configured provider and record responses")
+	fmt.Println("  ai-eval -mode replay                        Offline run from recordings (CI)")
+	fmt.Println("  ai-eval -prompt extract=extract.tmpl        Evaluate a candidate prompt")
+	fmt.Println("  ai-eval -mode record -update-baseline       Accept this run as the new baseline")
+}
@@ -0,0 +1,104 @@
+// backfill-duplicates 一次性工作：補齊跨帳號去重上線（20260301000000 migration）前同步郵件的 RFC Message-ID，
+// 並重新標記同一使用者各帳號間重複的郵件。可重複執行，已處理的郵件不會再查詢 Gmail。
+//
+//	go run ./cmd/backfill-duplicates [-user <user_id>] [-batch 100]
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/database"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/gmail"
+	"github.com/designcomb/influenter-backend/internal/utils"
+	"github.com/google/uuid"
+)
+
+func main() {
+	var (
+		userFlag  = flag.String("user", "", "only process this user ID (default: all users)")
+		batchSize = flag.Int("batch", 100, "emails loaded per query")
+	)
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	logger := utils.InitLogger(cfg.Env, cfg.LogLevel)
+
+	// 讀取 OAuth token 需要解密
+	if err := utils.InitCrypto(); err != nil {
+		logger.Fatal().Err(err).Msg("Failed to initialize crypto")
+	}
+
+	db, err := database.New(cfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to connect to database")
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	query := db.DB.Where("provider = ?", models.OAuthProviderGoogle)
+	if *userFlag != "" {
+		userID, err := uuid.Parse(*userFlag)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Invalid user ID")
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+	var accounts []models.OAuthAccount
+	if err := query.Find(&accounts).Error; err != nil {
+		logger.Fatal().Err(err).Msg("Failed to list Gmail accounts")
+	}
+
+	// 1. 向 Gmail 補查 Message-ID（已中斷連結的帳號無法查詢，略過）
+	users := make(map[uuid.UUID]bool)
+	failed := 0
+	for i := range accounts {
+		account := &accounts[i]
+		users[account.UserID] = true
+
+		syncSvc, err := gmail.NewSyncService(db.DB, account)
+		if err != nil {
+			failed++
+			logger.Error().Err(err).Str("oauth_account_id", account.ID.String()).Msg("Failed to create Gmail client")
+			continue
+		}
+		n, err := syncSvc.BackfillMessageIDs(ctx, *batchSize)
+		if err != nil {
+			failed++
+			logger.Error().Err(err).Str("oauth_account_id", account.ID.String()).Int("backfilled", n).Msg("Failed to backfill message IDs")
+			if ctx.Err() != nil {
+				os.Exit(1)
+			}
+			continue
+		}
+		logger.Info().Str("oauth_account_id", account.ID.String()).Int("backfilled", n).Msg("Message IDs backfilled")
+	}
+
+	// 2. 依 Message-ID 重新標記各使用者的跨帳號重複郵件
+	for userID := range users {
+		n, err := models.DedupeByMessageID(db.DB, userID)
+		if err != nil {
+			failed++
+			logger.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to mark duplicates")
+			continue
+		}
+		logger.Info().Str("user_id", userID.String()).Int64("updated", n).Msg("Duplicates marked")
+	}
+
+	if failed > 0 {
+		logger.Error().Int("failed", failed).Msg("Backfill finished with errors; re-run to retry")
+		os.Exit(1)
+	}
+	logger.Info().Msg("✅ Backfill completed")
+}
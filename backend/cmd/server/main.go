@@ -8,6 +8,7 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	swaggerFiles "github.com/swaggo/files"
@@ -17,7 +18,15 @@ import (
 	"github.com/designcomb/influenter-backend/internal/config"
 	"github.com/designcomb/influenter-backend/internal/database"
 	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/services/aicache"
+	"github.com/designcomb/influenter-backend/internal/services/attachment"
+	"github.com/designcomb/influenter-backend/internal/services/casematch"
+	"github.com/designcomb/influenter-backend/internal/services/feedback"
+	"github.com/designcomb/influenter-backend/internal/services/followup"
 	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/designcomb/influenter-backend/internal/services/prompts"
+	"github.com/designcomb/influenter-backend/internal/services/style"
+	"github.com/designcomb/influenter-backend/internal/services/usage"
 	"github.com/designcomb/influenter-backend/internal/utils"
 
 	_ "github.com/designcomb/influenter-backend/docs" // Swagger docs
@@ -75,13 +84,21 @@ func main() {
 		Str("database", cfg.Database.Database).
 		Msg("Database connected successfully")
 
-	// 5. 設定 Gin 模式
+	// 5. 建立 Asynq client（排入背景任務，如郵件匯入）
+	queue := asynq.NewClient(asynq.RedisClientOpt{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	defer queue.Close()
+
+	// 6. 設定 Gin 模式
 	gin.SetMode(cfg.GinMode)
 
-	// 6. 建立路由
-	router := setupRouter(cfg, db, &logger)
+	// 7. 建立路由
+	router := setupRouter(cfg, db, queue, &logger)
 
-	// 7. 啟動伺服器
+	// 8. 啟動伺服器
 	addr := fmt.Sprintf(":%s", cfg.Port)
 	logger.Info().
 		Str("addr", addr).
@@ -99,10 +116,41 @@ func main() {
 	logger.Info().Msg("   GET  /api/v1/emails             - List emails (protected)")
 	logger.Info().Msg("   GET  /api/v1/emails/:id         - Get email (protected)")
 	logger.Info().Msg("   PATCH /api/v1/emails/:id        - Update email (protected)")
+	logger.Info().Msg("   GET  /api/v1/emails/:id/analysis - Get email AI analysis (protected)")
+	logger.Info().Msg("   POST /api/v1/emails/:id/analysis/feedback - Correct email AI analysis (protected)")
 	logger.Info().Msg("   GET  /api/v1/gmail/status       - Gmail sync status (protected)")
 	logger.Info().Msg("   POST /api/v1/gmail/sync         - Trigger sync (protected)")
 	logger.Info().Msg("   DELETE /api/v1/gmail/disconnect - Disconnect Gmail (protected)")
 	logger.Info().Msg("   GET  /api/v1/cases/fields       - List case fields (protected)")
+	logger.Info().Msg("   PATCH /api/v1/cases/:id         - Update case, record AI corrections (protected)")
+	logger.Info().Msg("   POST /api/v1/cases/:id/merge    - Merge a duplicate case into this one (protected)")
+	logger.Info().Msg("   GET  /api/v1/cases/:id/export   - Export case mail as mbox/eml-zip/pdf (protected)")
+	logger.Info().Msg("   POST /api/v1/cases/:id/draft-reply/stream - Stream a reply draft over SSE (protected)")
+	logger.Info().Msg("   POST /api/v1/cases/:id/drafts/:draft_id/refine - Refine a reply draft (protected)")
+	logger.Info().Msg("   GET  /api/v1/cases/:id/drafts/:draft_id/revisions - List draft revisions (protected)")
+	logger.Info().Msg("   POST /api/v1/cases/:id/rate-advice - Rate negotiation advice (protected)")
+	logger.Info().Msg("   POST /api/v1/cases/:id/quotations - Create quotation version (protected)")
+	logger.Info().Msg("   GET  /api/v1/cases/:id/quotations - List quotations (protected)")
+	logger.Info().Msg("   GET  /api/v1/cases/:id/quotations/:quotation_id - Get quotation (protected)")
+	logger.Info().Msg("   GET  /api/v1/cases/:id/quotations/:quotation_id/document - Quotation as html/pdf (protected)")
+	logger.Info().Msg("   POST /api/v1/cases/:id/quotations/:quotation_id/accept - Accept quotation, update quoted amount (protected)")
+	logger.Info().Msg("   POST /api/v1/imports/mail       - Import .eml/.mbox/zip (protected)")
+	logger.Info().Msg("   GET  /api/v1/retention/settings - Data retention settings (protected)")
+	logger.Info().Msg("   POST /api/v1/emails/:id/snooze  - Snooze email (protected)")
+	logger.Info().Msg("   GET  /api/v1/snoozed            - List snoozed emails/cases (protected)")
+	logger.Info().Msg("   GET  /api/v1/notifications      - In-app notifications (protected)")
+	logger.Info().Msg("   GET  /api/v1/case-suggestions   - AI-proposed case updates to review (protected)")
+	logger.Info().Msg("   POST /api/v1/case-suggestions/:id/accept - Apply all or some proposed fields (protected)")
+	logger.Info().Msg("   GET  /api/v1/follow-ups         - Unanswered outgoing case emails (protected)")
+	logger.Info().Msg("   GET  /api/v1/retention/report   - Retention dry-run report (protected)")
+	logger.Info().Msg("   GET  /api/v1/triage/settings    - Automatic AI triage settings (protected)")
+	logger.Info().Msg("   GET  /api/v1/usage/ai           - AI token usage and cost (protected)")
+	logger.Info().Msg("   GET  /api/v1/usage/ai/budget    - Monthly AI budget and usage (protected)")
+	logger.Info().Msg("   GET  /api/v1/usage/ai/accuracy  - AI accuracy from user feedback (protected)")
+	logger.Info().Msg("   DELETE /api/v1/usage/ai/cache   - Clear cached AI results (protected)")
+	logger.Info().Msg("   GET  /api/v1/style/profile      - Writing style learned from sent emails (protected)")
+	logger.Info().Msg("   PUT  /api/v1/admin/ai-budgets/:user_id - Set a user's AI budget or override (admin)")
+	logger.Info().Msg("   GET  /api/v1/admin/prompts      - Prompt templates and A/B versions (admin)")
 
 	if err := router.Run(addr); err != nil {
 		logger.Fatal().Err(err).Msg("Failed to start server")
@@ -110,7 +158,7 @@ func main() {
 }
 
 // setupRouter 設定並返回 Gin router
-func setupRouter(cfg *config.Config, db *database.DB, logger *zerolog.Logger) *gin.Engine {
+func setupRouter(cfg *config.Config, db *database.DB, queue *asynq.Client, logger *zerolog.Logger) *gin.Engine {
 	// 建立 router（不使用預設的 logger）
 	router := gin.New()
 
@@ -131,11 +179,37 @@ func setupRouter(cfg *config.Config, db *database.DB, logger *zerolog.Logger) *g
 	// 建立 handlers
 	authHandler := api.NewAuthHandler(db.DB, cfg)
 	openaiSvc := openai.NewService(*cfg, logger, "")
-	emailHandler := api.NewEmailHandler(db.DB, openaiSvc)
+	openaiSvc.SetUsageRecorder(usage.NewRecorder(db.DB))
+	openaiSvc.SetUsageGuard(usage.NewGuard(db.DB, cfg.AI))
+	if cfg.AI.CacheEnabled {
+		openaiSvc.SetResultCache(aicache.NewStore(db.DB, cfg.AI.CacheTTL))
+	}
+	openaiSvc.SetPromptSource(prompts.NewStore(db.DB))
+	openaiSvc.SetFeedbackSource(feedback.NewService(db.DB))
+	styleSvc := style.NewService(db.DB, cfg.Style)
+	followUpSvc := followup.NewService(db.DB, cfg.FollowUp, openaiSvc)
+	followUpSvc.SetStyleGuide(styleSvc)
+	emailHandler := api.NewEmailHandler(db.DB, openaiSvc, followUpSvc)
+	emailHandler.SetAttachments(attachment.NewService(db.DB, cfg.Attachments, openaiSvc))
+	var caseMatchAI casematch.AI
+	if openaiSvc.Configured(openai.OperationMatch) {
+		caseMatchAI = openaiSvc
+	}
+	emailHandler.SetCaseMatcher(casematch.NewMatcher(db.DB, cfg.AI, caseMatchAI))
 	gmailHandler := api.NewGmailHandler(db.DB)
-	caseHandler := api.NewCaseHandler(db.DB, openaiSvc)
+	caseHandler := api.NewCaseHandler(db.DB, openaiSvc, styleSvc)
 	collaborationItemHandler := api.NewCollaborationItemHandler(db.DB)
 	workflowTemplateHandler := api.NewWorkflowTemplateHandler(db.DB)
+	importHandler := api.NewImportHandler(db.DB, cfg.Imports, queue)
+	retentionHandler := api.NewRetentionHandler(db.DB, cfg.Retention)
+	snoozeHandler := api.NewSnoozeHandler(db.DB)
+	followUpHandler := api.NewFollowUpHandler(db.DB, followUpSvc)
+	notificationHandler := api.NewNotificationHandler(db.DB)
+	caseSuggestionHandler := api.NewCaseSuggestionHandler(db.DB)
+	triageHandler := api.NewTriageHandler(db.DB, cfg.AI)
+	usageHandler := api.NewUsageHandler(db.DB, cfg.AI)
+	adminHandler := api.NewAdminHandler(db.DB, cfg.AI)
+	styleHandler := api.NewStyleHandler(db.DB, styleSvc)
 
 	// API v1 路由群組
 	v1 := router.Group("/api/v1")
@@ -170,7 +244,11 @@ func setupRouter(cfg *config.Config, db *database.DB, logger *zerolog.Logger) *g
 				emails.POST("/:id/create-case", emailHandler.CreateCaseFromEmail)
 				emails.GET("/:id", emailHandler.GetEmail)
 				emails.PATCH("/:id", emailHandler.UpdateEmail)
+				emails.GET("/:id/analysis", emailHandler.GetEmailAnalysis)
+				emails.POST("/:id/analysis/feedback", emailHandler.SubmitAnalysisFeedback)
 				emails.POST("/:id/send-reply", emailHandler.SendReply)
+				emails.POST("/:id/snooze", snoozeHandler.SnoozeEmail)
+				emails.DELETE("/:id/snooze", snoozeHandler.UnsnoozeEmail)
 			}
 
 			// Gmail integration routes
@@ -188,8 +266,22 @@ func setupRouter(cfg *config.Config, db *database.DB, logger *zerolog.Logger) *g
 				casesGroup.GET("", caseHandler.ListCases)
 				casesGroup.GET("/fields", caseHandler.ListCaseFields)
 				casesGroup.GET("/:id", caseHandler.GetCase)
+				casesGroup.PATCH("/:id", caseHandler.UpdateCase)
+				casesGroup.POST("/:id/merge", caseHandler.MergeCases)
 				casesGroup.GET("/:id/emails", caseHandler.ListCaseEmails)
+				casesGroup.GET("/:id/export", caseHandler.ExportCase)
 				casesGroup.POST("/:id/draft-reply", caseHandler.DraftReply)
+				casesGroup.POST("/:id/draft-reply/stream", caseHandler.DraftReplyStream)
+				casesGroup.POST("/:id/drafts/:draft_id/refine", caseHandler.RefineDraft)
+				casesGroup.GET("/:id/drafts/:draft_id/revisions", caseHandler.ListDraftRevisions)
+				casesGroup.POST("/:id/rate-advice", caseHandler.RateAdvice)
+				casesGroup.POST("/:id/quotations", caseHandler.CreateQuotation)
+				casesGroup.GET("/:id/quotations", caseHandler.ListQuotations)
+				casesGroup.GET("/:id/quotations/:quotation_id", caseHandler.GetQuotation)
+				casesGroup.GET("/:id/quotations/:quotation_id/document", caseHandler.QuotationDocument)
+				casesGroup.POST("/:id/quotations/:quotation_id/accept", caseHandler.AcceptQuotation)
+				casesGroup.POST("/:id/snooze", snoozeHandler.SnoozeCase)
+				casesGroup.DELETE("/:id/snooze", snoozeHandler.UnsnoozeCase)
 				// Case phases
 				casesGroup.GET("/:id/phases", caseHandler.ListCasePhases)
 				casesGroup.POST("/:id/phases", caseHandler.CreateCasePhase)
@@ -220,6 +312,85 @@ func setupRouter(cfg *config.Config, db *database.DB, logger *zerolog.Logger) *g
 				workflowGroup.PATCH("/:id/phases/:phaseId", workflowTemplateHandler.UpdatePhase)
 				workflowGroup.DELETE("/:id/phases/:phaseId", workflowTemplateHandler.DeletePhase)
 			}
+
+			// Mail imports (.eml / .mbox / zip)
+			importsGroup := protected.Group("/imports")
+			{
+				importsGroup.POST("/mail", importHandler.ImportMail)
+				importsGroup.GET("/mail", importHandler.ListMailImports)
+				importsGroup.GET("/mail/:id", importHandler.GetMailImport)
+			}
+
+			// Follow-ups for unanswered outgoing case emails
+			followUpsGroup := protected.Group("/follow-ups")
+			{
+				followUpsGroup.GET("", followUpHandler.ListFollowUps)
+				followUpsGroup.POST("/:id/draft", followUpHandler.DraftFollowUp)
+				followUpsGroup.POST("/:id/dismiss", followUpHandler.DismissFollowUp)
+			}
+
+			// Snoozed emails / cases
+			protected.GET("/snoozed", snoozeHandler.ListSnoozed)
+
+			// AI-proposed case updates awaiting review
+			caseSuggestionsGroup := protected.Group("/case-suggestions")
+			{
+				caseSuggestionsGroup.GET("", caseSuggestionHandler.ListCaseSuggestions)
+				caseSuggestionsGroup.GET("/:id", caseSuggestionHandler.GetCaseSuggestion)
+				caseSuggestionsGroup.POST("/:id/accept", caseSuggestionHandler.AcceptCaseSuggestion)
+				caseSuggestionsGroup.POST("/:id/reject", caseSuggestionHandler.RejectCaseSuggestion)
+			}
+
+			// In-app notifications
+			notificationsGroup := protected.Group("/notifications")
+			{
+				notificationsGroup.GET("", notificationHandler.ListNotifications)
+				notificationsGroup.POST("/:id/read", notificationHandler.MarkNotificationRead)
+			}
+
+			// Data retention
+			retentionGroup := protected.Group("/retention")
+			{
+				retentionGroup.GET("/settings", retentionHandler.GetRetentionPolicy)
+				retentionGroup.PUT("/settings", retentionHandler.UpdateRetentionPolicy)
+				retentionGroup.GET("/report", retentionHandler.GetRetentionReport)
+				retentionGroup.GET("/audits", retentionHandler.ListRetentionAudits)
+			}
+
+			// Automatic AI triage
+			triageGroup := protected.Group("/triage")
+			{
+				triageGroup.GET("/settings", triageHandler.GetTriageSettings)
+				triageGroup.PUT("/settings", triageHandler.UpdateTriageSettings)
+			}
+
+			// AI usage
+			usageGroup := protected.Group("/usage")
+			{
+				usageGroup.GET("/ai", usageHandler.GetAIUsage)
+				usageGroup.GET("/ai/budget", usageHandler.GetAIBudget)
+				usageGroup.GET("/ai/accuracy", usageHandler.GetAIAccuracy)
+				usageGroup.DELETE("/ai/cache", usageHandler.ClearAICache)
+			}
+
+			// Writing style (reply drafts)
+			styleGroup := protected.Group("/style")
+			{
+				styleGroup.GET("/profile", styleHandler.GetStyleProfile)
+				styleGroup.POST("/profile/refresh", styleHandler.RefreshStyleProfile)
+			}
+
+			// Admin
+			adminGroup := protected.Group("/admin")
+			adminGroup.Use(middleware.AdminMiddleware(cfg))
+			{
+				adminGroup.GET("/ai-budgets/:user_id", adminHandler.GetAIBudget)
+				adminGroup.PUT("/ai-budgets/:user_id", adminHandler.UpdateAIBudget)
+				adminGroup.GET("/prompts", adminHandler.ListPromptTemplates)
+				adminGroup.POST("/prompts", adminHandler.CreatePromptTemplate)
+				adminGroup.PUT("/prompts/:id", adminHandler.UpdatePromptTemplate)
+				adminGroup.DELETE("/prompts/:id", adminHandler.DeletePromptTemplate)
+			}
 		}
 	}
 
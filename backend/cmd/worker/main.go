@@ -11,6 +11,15 @@ import (
 
 	"github.com/designcomb/influenter-backend/internal/config"
 	"github.com/designcomb/influenter-backend/internal/database"
+	"github.com/designcomb/influenter-backend/internal/services/aicache"
+	"github.com/designcomb/influenter-backend/internal/services/attachment"
+	"github.com/designcomb/influenter-backend/internal/services/feedback"
+	"github.com/designcomb/influenter-backend/internal/services/followup"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/designcomb/influenter-backend/internal/services/prompts"
+	"github.com/designcomb/influenter-backend/internal/services/style"
+	"github.com/designcomb/influenter-backend/internal/services/triage"
+	"github.com/designcomb/influenter-backend/internal/services/usage"
 	"github.com/designcomb/influenter-backend/internal/utils"
 	"github.com/designcomb/influenter-backend/internal/workers"
 	"github.com/hibiken/asynq"
@@ -85,15 +94,85 @@ func main() {
 
 	// 9. 註冊任務處理器
 	mux.HandleFunc(workers.TypeEmailSync, func(ctx context.Context, t *asynq.Task) error {
-		return workers.HandleEmailSyncTask(ctx, t, db.DB)
+		return workers.HandleEmailSyncTask(ctx, t, db.DB, client)
 	})
 	mux.HandleFunc(workers.TypeEmailSyncAll, func(ctx context.Context, t *asynq.Task) error {
 		return workers.HandleEmailSyncAllTask(ctx, t, db.DB, client)
 	})
+	mux.HandleFunc(workers.TypeRetentionPurge, func(ctx context.Context, t *asynq.Task) error {
+		return workers.HandleRetentionPurgeTask(ctx, t, db.DB, cfg.Retention)
+	})
+	mux.HandleFunc(workers.TypeRetentionPurgeAll, func(ctx context.Context, t *asynq.Task) error {
+		return workers.HandleRetentionPurgeAllTask(ctx, t, db.DB, client)
+	})
+	mux.HandleFunc(workers.TypeSnoozeWake, func(ctx context.Context, t *asynq.Task) error {
+		return workers.HandleSnoozeWakeTask(ctx, t, db.DB)
+	})
+	mux.HandleFunc(workers.TypeAICachePurge, func(ctx context.Context, t *asynq.Task) error {
+		return workers.HandleAICachePurgeTask(ctx, t, db.DB)
+	})
+	mux.HandleFunc(workers.TypeMailImport, func(ctx context.Context, t *asynq.Task) error {
+		return workers.HandleMailImportTask(ctx, t, db.DB)
+	})
+	mux.HandleFunc(workers.TypeMailImportSweep, func(ctx context.Context, t *asynq.Task) error {
+		return workers.HandleMailImportSweepTask(ctx, t, db.DB, cfg.Imports)
+	})
+	openaiSvc := openai.NewService(*cfg, &logger, "")
+	openaiSvc.SetUsageRecorder(usage.NewRecorder(db.DB))
+	openaiSvc.SetUsageGuard(usage.NewGuard(db.DB, cfg.AI))
+	if cfg.AI.CacheEnabled {
+		openaiSvc.SetResultCache(aicache.NewStore(db.DB, cfg.AI.CacheTTL))
+	}
+	openaiSvc.SetPromptSource(prompts.NewStore(db.DB))
+	openaiSvc.SetFeedbackSource(feedback.NewService(db.DB))
+	styleSvc := style.NewService(db.DB, cfg.Style)
+	followUpSvc := followup.NewService(db.DB, cfg.FollowUp, openaiSvc)
+	followUpSvc.SetStyleGuide(styleSvc)
+	mux.HandleFunc(workers.TypeFollowUpCheck, func(ctx context.Context, t *asynq.Task) error {
+		return workers.HandleFollowUpCheckTask(ctx, t, followUpSvc)
+	})
+	// 未設定分析用的模型後端時只依郵件串歸檔，不呼叫 AI
+	var analyzer triage.Analyzer
+	if openaiSvc.Configured(openai.OperationClassify) {
+		analyzer = openaiSvc
+	}
+	triageSvc := triage.NewService(db.DB, cfg.AI, analyzer)
+	triageSvc.SetAttachments(attachment.NewService(db.DB, cfg.Attachments, openaiSvc))
+	// 品牌在已關聯案件的郵件串來信時，提出案件更新建議
+	if openaiSvc.Configured(openai.OperationReplyAnalysis) {
+		triageSvc.SetCaseUpdates(openaiSvc)
+	}
+	// 確定性依據都不符合時，由 AI 判斷新郵件是否屬於既有案件
+	if openaiSvc.Configured(openai.OperationMatch) {
+		triageSvc.SetCaseMatchAI(openaiSvc)
+	}
+	mux.HandleFunc(workers.TypeAITriage, func(ctx context.Context, t *asynq.Task) error {
+		return workers.HandleAITriageTask(ctx, t, triageSvc)
+	})
+	mux.HandleFunc(workers.TypeAITriageAll, func(ctx context.Context, t *asynq.Task) error {
+		return workers.HandleAITriageAllTask(ctx, t, triageSvc, client)
+	})
+	mux.HandleFunc(workers.TypeStyleRefresh, func(ctx context.Context, t *asynq.Task) error {
+		return workers.HandleStyleRefreshTask(ctx, t, styleSvc)
+	})
+	mux.HandleFunc(workers.TypeStyleRefreshAll, func(ctx context.Context, t *asynq.Task) error {
+		return workers.HandleStyleRefreshAllTask(ctx, t, styleSvc, client)
+	})
 
 	logger.Info().Msg("✅ Task handlers registered:")
 	logger.Info().Msg("   - " + workers.TypeEmailSync)
 	logger.Info().Msg("   - " + workers.TypeEmailSyncAll)
+	logger.Info().Msg("   - " + workers.TypeRetentionPurge)
+	logger.Info().Msg("   - " + workers.TypeRetentionPurgeAll)
+	logger.Info().Msg("   - " + workers.TypeSnoozeWake)
+	logger.Info().Msg("   - " + workers.TypeAICachePurge)
+	logger.Info().Msg("   - " + workers.TypeMailImport)
+	logger.Info().Msg("   - " + workers.TypeMailImportSweep)
+	logger.Info().Msg("   - " + workers.TypeFollowUpCheck)
+	logger.Info().Msg("   - " + workers.TypeAITriage)
+	logger.Info().Msg("   - " + workers.TypeAITriageAll)
+	logger.Info().Msg("   - " + workers.TypeStyleRefresh)
+	logger.Info().Msg("   - " + workers.TypeStyleRefreshAll)
 
 	// 10. 建立 Scheduler（定期任務）
 	scheduler := asynq.NewScheduler(redisOpt, nil)
@@ -108,8 +187,50 @@ func main() {
 		logger.Fatal().Err(err).Msg("Failed to register scheduled task")
 	}
 
+	// 註冊定期任務：依保留政策清除資料
+	if _, err := scheduler.Register(cfg.Retention.Schedule, workers.NewRetentionPurgeAllTask()); err != nil {
+		logger.Fatal().Err(err).Msg("Failed to register retention task")
+	}
+
+	// 註冊定期任務：每分鐘喚醒到期的延後郵件與案件
+	if _, err := scheduler.Register("* * * * *", workers.NewSnoozeWakeTask()); err != nil {
+		logger.Fatal().Err(err).Msg("Failed to register snooze task")
+	}
+
+	// 註冊定期任務：清除過期的 AI 結果快取
+	if _, err := scheduler.Register(cfg.AI.CachePurgeSchedule, workers.NewAICachePurgeTask()); err != nil {
+		logger.Fatal().Err(err).Msg("Failed to register ai cache purge task")
+	}
+
+	// 註冊定期任務：將中斷（逾時未完成）的郵件匯入標記為失敗
+	if _, err := scheduler.Register(cfg.Imports.SweepSchedule, workers.NewMailImportSweepTask()); err != nil {
+		logger.Fatal().Err(err).Msg("Failed to register mail import sweep task")
+	}
+
+	// 註冊定期任務：檢查寄出郵件是否獲得回覆
+	if _, err := scheduler.Register(cfg.FollowUp.Schedule, workers.NewFollowUpCheckTask()); err != nil {
+		logger.Fatal().Err(err).Msg("Failed to register follow-up task")
+	}
+
+	// 註冊定期任務：補跑未分析的新郵件（手動同步、匯入）
+	if _, err := scheduler.Register(cfg.AI.TriageSchedule, workers.NewAITriageAllTask()); err != nil {
+		logger.Fatal().Err(err).Msg("Failed to register triage task")
+	}
+
+	// 註冊定期任務：重新統計使用者的寫作風格（擬信時模仿）
+	if _, err := scheduler.Register(cfg.Style.Schedule, workers.NewStyleRefreshAllTask()); err != nil {
+		logger.Fatal().Err(err).Msg("Failed to register style refresh task")
+	}
+
 	logger.Info().Msg("✅ Scheduled tasks registered:")
 	logger.Info().Msg("   - Email sync all users (every 5 minutes)")
+	logger.Info().Msg("   - Retention purge (" + cfg.Retention.Schedule + ")")
+	logger.Info().Msg("   - Snooze wake-up (every minute)")
+	logger.Info().Msg("   - AI result cache purge (" + cfg.AI.CachePurgeSchedule + ")")
+	logger.Info().Msg("   - Mail import sweep (" + cfg.Imports.SweepSchedule + ")")
+	logger.Info().Msg("   - Follow-up check (" + cfg.FollowUp.Schedule + ")")
+	logger.Info().Msg("   - AI triage (" + cfg.AI.TriageSchedule + ")")
+	logger.Info().Msg("   - Writing style refresh (" + cfg.Style.Schedule + ")")
 
 	// 11. 啟動 scheduler
 	if err := scheduler.Start(); err != nil {
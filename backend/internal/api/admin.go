@@ -0,0 +1,197 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/usage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AdminHandler 管理員功能處理器
+type AdminHandler struct {
+	db    *gorm.DB
+	guard *usage.Guard
+}
+
+// NewAdminHandler 建立管理員功能處理器
+func NewAdminHandler(db *gorm.DB, cfg config.AIConfig) *AdminHandler {
+	return &AdminHandler{db: db, guard: usage.NewGuard(db, cfg)}
+}
+
+// AIBudgetResponse 使用者的額度設定與本月狀態
+type AIBudgetResponse struct {
+	Budget models.AIBudget    `json:"budget"`
+	Status usage.BudgetStatus `json:"status"`
+}
+
+// UpdateAIBudgetRequest 更新使用者額度請求（限制為 0 表示不限制）
+type UpdateAIBudgetRequest struct {
+	MonthlyTokenLimit   *int64         `json:"monthly_token_limit" binding:"omitempty,min=0"`
+	MonthlyCostLimitUSD *float64       `json:"monthly_cost_limit_usd" binding:"omitempty,min=0"`
+	RateLimits          map[string]int `json:"rate_limits"`  // 覆寫各用途每小時呼叫上限
+	ClearLimits         bool           `json:"clear_limits"` // true 時改回系統預設額度與頻率限制
+	OverrideUntil       *time.Time     `json:"override_until"`
+	OverrideNote        *string        `json:"override_note"`
+	ClearOverride       bool           `json:"clear_override"` // true 時取消解除限制
+}
+
+// budgetResponse 組合額度設定與本月狀態
+func (h *AdminHandler) budgetResponse(c *gin.Context, budget models.AIBudget) {
+	logger := middleware.GetLogger(c)
+
+	status, err := h.guard.Status(budget.UserID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to compute ai budget status")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch ai budget"})
+		return
+	}
+	c.JSON(http.StatusOK, AIBudgetResponse{Budget: budget, Status: *status})
+}
+
+// targetUser 解析並確認路徑中的使用者
+func (h *AdminHandler) targetUser(c *gin.Context) (uuid.UUID, bool) {
+	logger := middleware.GetLogger(c)
+
+	uid, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_user_id", Message: "Invalid user ID"})
+		return uuid.Nil, false
+	}
+	var user models.User
+	if err := h.db.Select("id").First(&user, "id = ?", uid).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "user_not_found", Message: "User not found"})
+			return uuid.Nil, false
+		}
+		logger.Error().Err(err).Msg("Failed to fetch user")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch user"})
+		return uuid.Nil, false
+	}
+	return uid, true
+}
+
+// GetAIBudget 取得使用者的 AI 額度（管理員）
+// @Summary      取得使用者的 AI 額度
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        user_id  path      string  true  "使用者 ID"
+// @Success      200      {object}  AIBudgetResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /admin/ai-budgets/{user_id} [get]
+func (h *AdminHandler) GetAIBudget(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+
+	uid, ok := h.targetUser(c)
+	if !ok {
+		return
+	}
+
+	budget, err := h.guard.GetBudget(uid)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch ai budget")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch ai budget"})
+		return
+	}
+	h.budgetResponse(c, budget)
+}
+
+// UpdateAIBudget 設定使用者的 AI 額度或暫時解除限制（管理員）
+// @Summary      設定使用者的 AI 額度
+// @Description  調整每月 token / 成本上限與各用途頻率限制，或在 override_until 之前解除所有限制
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        user_id  path      string                 true  "使用者 ID"
+// @Param        request  body      UpdateAIBudgetRequest  true  "額度設定"
+// @Success      200      {object}  AIBudgetResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /admin/ai-budgets/{user_id} [put]
+func (h *AdminHandler) UpdateAIBudget(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+
+	uid, ok := h.targetUser(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateAIBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+	for op, limit := range req.RateLimits {
+		if limit < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "rate limit for " + op + " must not be negative"})
+			return
+		}
+	}
+
+	budget, err := h.guard.GetBudget(uid)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch ai budget")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch ai budget"})
+		return
+	}
+
+	if req.ClearLimits {
+		budget.MonthlyTokenLimit = nil
+		budget.MonthlyCostLimitUSD = nil
+		budget.RateLimits = nil
+	}
+	if req.MonthlyTokenLimit != nil {
+		budget.MonthlyTokenLimit = req.MonthlyTokenLimit
+	}
+	if req.MonthlyCostLimitUSD != nil {
+		budget.MonthlyCostLimitUSD = req.MonthlyCostLimitUSD
+	}
+	if req.RateLimits != nil {
+		encoded, _ := json.Marshal(req.RateLimits)
+		budget.RateLimits = encoded
+	}
+	if req.ClearOverride {
+		budget.OverrideUntil = nil
+		budget.OverrideNote = nil
+	} else {
+		if req.OverrideUntil != nil {
+			budget.OverrideUntil = req.OverrideUntil
+		}
+		if req.OverrideNote != nil {
+			budget.OverrideNote = req.OverrideNote
+		}
+	}
+
+	// 尚未設定時建立（每位使用者一筆）
+	tx := h.db.Omit(clause.Associations)
+	if budget.ID == uuid.Nil {
+		err = tx.Create(&budget).Error
+	} else {
+		err = tx.Save(&budget).Error
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to save ai budget")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to save ai budget"})
+		return
+	}
+
+	logger.Info().
+		Str("admin", c.GetString("user_email")).
+		Str("user_id", uid.String()).
+		Msg("AI budget updated")
+	h.budgetResponse(c, budget)
+}
@@ -39,7 +39,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	}
 
 	// Auto migrate
-	err = db.AutoMigrate(&models.User{}, &models.OAuthAccount{}, &models.Email{})
+	err = db.AutoMigrate(&models.User{}, &models.OAuthAccount{}, &models.Email{}, &models.AIAnalysis{}, &models.AIFeedback{}, &models.Case{})
 	if err != nil {
 		t.Fatalf("Failed to migrate database: %v", err)
 	}
@@ -86,8 +86,10 @@ func setupTestRouter(t *testing.T) (*gorm.DB, *gin.Engine, *config.Config) {
 
 	// 建立 handlers
 	authHandler := NewAuthHandler(db, cfg)
-	emailHandler := NewEmailHandler(db, nil)
+	emailHandler := NewEmailHandler(db, nil, nil)
 	gmailHandler := NewGmailHandler(db)
+	snoozeHandler := NewSnoozeHandler(db)
+	caseHandler := NewCaseHandler(db, nil, nil)
 
 	// 設置路由
 	v1 := router.Group("/api/v1")
@@ -117,6 +119,16 @@ func setupTestRouter(t *testing.T) (*gorm.DB, *gin.Engine, *config.Config) {
 				emails.GET("", emailHandler.ListEmails)
 				emails.GET("/:id", emailHandler.GetEmail)
 				emails.PATCH("/:id", emailHandler.UpdateEmail)
+				emails.GET("/:id/analysis", emailHandler.GetEmailAnalysis)
+				emails.POST("/:id/analysis/feedback", emailHandler.SubmitAnalysisFeedback)
+				emails.POST("/:id/snooze", snoozeHandler.SnoozeEmail)
+				emails.DELETE("/:id/snooze", snoozeHandler.UnsnoozeEmail)
+			}
+
+			// Case routes
+			cases := protected.Group("/cases")
+			{
+				cases.PATCH("/:id", caseHandler.UpdateCase)
 			}
 
 			// Gmail integration routes
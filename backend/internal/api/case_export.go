@@ -0,0 +1,112 @@
+package api
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/export"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ExportCase 匯出案件往來郵件
+// @Summary      匯出案件往來郵件
+// @Description  將案件所有往來郵件（收到與寄出）匯出為 mbox、eml zip 或可列印的 PDF（封面為案件資訊，內文依時間排列）
+// @Tags         Cases
+// @Produce      application/mbox
+// @Produce      application/zip
+// @Produce      application/pdf
+// @Security     BearerAuth
+// @Param        id      path   string  true   "Case ID"
+// @Param        format  query  string  false  "mbox | eml-zip | pdf（預設 mbox）"
+// @Param        tz      query  string  false  "PDF 顯示時區（IANA，如 Asia/Taipei；預設 UTC）"
+// @Success      200
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /cases/{id}/export [get]
+func (h *CaseHandler) ExportCase(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+	caseID := c.Param("id")
+
+	id, err := uuid.Parse(caseID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_id", Message: "Invalid case ID"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "mbox")
+	if format != "mbox" && format != "eml-zip" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_format", Message: "format must be mbox, eml-zip or pdf"})
+		return
+	}
+
+	loc := time.UTC
+	if tz := c.Query("tz"); tz != "" {
+		if loc, err = time.LoadLocation(tz); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_timezone", Message: "Invalid tz"})
+			return
+		}
+	}
+
+	var cs models.Case
+	if err := h.db.Where("id = ? AND user_id = ?", id, userID).First(&cs).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "case_not_found", Message: "Case not found"})
+			return
+		}
+		logger.Error().Err(err).Str("case_id", caseID).Msg("Failed to fetch case")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch case"})
+		return
+	}
+
+	// 跨帳號重複的郵件只匯出一次
+	var emails []models.Email
+	err = h.db.Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
+		Where("emails.case_id = ? AND oauth_accounts.user_id = ? AND emails.duplicate_of_id IS NULL", id, userID).
+		Order("emails.received_at ASC").
+		Find(&emails).Error
+	if err != nil {
+		logger.Error().Err(err).Str("case_id", caseID).Msg("Failed to list case emails")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to list case emails"})
+		return
+	}
+
+	var (
+		buf         bytes.Buffer
+		contentType string
+		ext         string
+	)
+	switch format {
+	case "mbox":
+		err = export.WriteMbox(&buf, emails)
+		contentType, ext = "application/mbox", ".mbox"
+	case "eml-zip":
+		err = export.WriteEMLZip(&buf, emails)
+		contentType, ext = "application/zip", ".zip"
+	case "pdf":
+		err = export.WriteCasePDF(&buf, &cs, emails, loc)
+		contentType, ext = "application/pdf", ".pdf"
+	}
+	if err != nil {
+		logger.Error().Err(err).Str("case_id", caseID).Str("format", format).Msg("Failed to export case")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "export_failed", Message: "Failed to export case"})
+		return
+	}
+
+	filename := cs.BrandName + "-" + cs.Title
+	if cs.BrandName == "" {
+		filename = cs.Title
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": export.FileSlug(filename) + ext,
+	}))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
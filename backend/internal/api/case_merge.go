@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/casematch"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// MergeCasesRequest 合併案件請求
+type MergeCasesRequest struct {
+	SourceCaseID string `json:"source_case_id" binding:"required,uuid"` // 要併入的重複案件（合併後刪除）
+}
+
+// MergeCases 合併重複的案件
+// @Summary      合併重複的案件
+// @Description  將 source_case_id 案件的郵件、階段、報價單、草稿、追蹤、更新建議與通知移到此案件，空白欄位以來源案件補上，之後刪除來源案件
+// @Tags         Cases
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      string             true  "Case ID（保留的案件）"
+// @Param        body  body      MergeCasesRequest  true  "要併入的案件"
+// @Success      200   {object}  CaseResponse
+// @Failure      400   {object}  ErrorResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      404   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /cases/{id}/merge [post]
+func (h *CaseHandler) MergeCases(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	target, ok := h.findCase(c)
+	if !ok {
+		return
+	}
+
+	var req MergeCasesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+
+	var source models.Case
+	if err := h.db.Where("id = ? AND user_id = ?", req.SourceCaseID, userID).First(&source).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "case_not_found", Message: "Source case not found"})
+			return
+		}
+		logger.Error().Err(err).Str("case_id", req.SourceCaseID).Msg("Failed to fetch case")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch case"})
+		return
+	}
+
+	if err := casematch.Merge(h.db, target, &source); err != nil {
+		if err == casematch.ErrSameCase {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "same_case", Message: "Cannot merge a case into itself"})
+			return
+		}
+		logger.Error().Err(err).Str("case_id", target.ID.String()).Str("source_case_id", req.SourceCaseID).Msg("Failed to merge cases")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to merge cases"})
+		return
+	}
+
+	logger.Info().
+		Str("case_id", target.ID.String()).
+		Str("source_case_id", req.SourceCaseID).
+		Msg("Cases merged")
+
+	var emailCount int64
+	h.db.Model(&models.Email{}).Where("case_id = ?", target.ID).Count(&emailCount)
+	c.JSON(http.StatusOK, caseToResponse(target, int(emailCount), 0, 0))
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/caseupdate"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CaseSuggestionHandler AI 案件更新建議處理器
+type CaseSuggestionHandler struct {
+	db          *gorm.DB
+	caseUpdates *caseupdate.Service
+}
+
+// NewCaseSuggestionHandler 建立 AI 案件更新建議處理器
+func NewCaseSuggestionHandler(db *gorm.DB) *CaseSuggestionHandler {
+	return &CaseSuggestionHandler{db: db, caseUpdates: caseupdate.NewService(db)}
+}
+
+// AcceptCaseSuggestionRequest 接受建議的請求（fields 為空時接受全部待確認欄位）
+type AcceptCaseSuggestionRequest struct {
+	Fields []string `json:"fields"` // 要套用的欄位，未列出的待確認欄位視為拒絕
+}
+
+// ListCaseSuggestions 列出 AI 案件更新建議
+// @Summary      列出 AI 案件更新建議
+// @Description  AI 分析我方回信或品牌來信後提出的案件更新，含欄位差異、理由與偵測到的事件；預設只列出待確認的建議
+// @Tags         Cases
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status   query     string  false  "狀態（pending / accepted / partially_accepted / rejected / auto_applied / all）"  default(pending)
+// @Param        case_id  query     string  false  "案件 ID"
+// @Param        source   query     string  false  "來源（reply 我方回信 / incoming 品牌來信）"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /case-suggestions [get]
+func (h *CaseSuggestionHandler) ListCaseSuggestions(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	query := h.db.Where("user_id = ?", userID)
+	if status := c.DefaultQuery("status", string(models.CaseUpdateSuggestionPending)); status != "all" {
+		query = query.Where("status = ?", status)
+	}
+	if caseID := c.Query("case_id"); caseID != "" {
+		id, err := uuid.Parse(caseID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_id", Message: "Invalid case ID"})
+			return
+		}
+		query = query.Where("case_id = ?", id)
+	}
+	if source := c.Query("source"); source != "" {
+		query = query.Where("source = ?", source)
+	}
+
+	var suggestions []models.CaseUpdateSuggestion
+	if err := query.Order("created_at DESC").Limit(100).Find(&suggestions).Error; err != nil {
+		logger.Error().Err(err).Msg("Failed to list case update suggestions")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to list case update suggestions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": suggestions})
+}
+
+// GetCaseSuggestion 取得單一 AI 案件更新建議
+// @Summary      取得 AI 案件更新建議
+// @Tags         Cases
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Suggestion ID"
+// @Success      200  {object}  models.CaseUpdateSuggestion
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /case-suggestions/{id} [get]
+func (h *CaseSuggestionHandler) GetCaseSuggestion(c *gin.Context) {
+	suggestion, ok := h.loadSuggestion(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, suggestion)
+}
+
+// AcceptCaseSuggestion 接受 AI 案件更新建議（可只接受部分欄位）
+// @Summary      接受 AI 案件更新建議
+// @Description  套用指定欄位的變更並寫入案件；未指定欄位時套用全部，未列出的待確認欄位視為拒絕
+// @Tags         Cases
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                       true   "Suggestion ID"
+// @Param        request  body      AcceptCaseSuggestionRequest  false  "要套用的欄位"
+// @Success      200      {object}  models.CaseUpdateSuggestion
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      409      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /case-suggestions/{id}/accept [post]
+func (h *CaseSuggestionHandler) AcceptCaseSuggestion(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+
+	var req AcceptCaseSuggestionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+			return
+		}
+	}
+
+	suggestion, ok := h.loadSuggestion(c)
+	if !ok {
+		return
+	}
+
+	if err := h.caseUpdates.Accept(suggestion, req.Fields); err != nil {
+		h.resolveError(c, err, "Failed to accept case update suggestion")
+		return
+	}
+
+	logger.Info().
+		Str("suggestion_id", suggestion.ID.String()).
+		Str("case_id", suggestion.CaseID.String()).
+		Str("status", string(suggestion.Status)).
+		Msg("Case update suggestion accepted")
+	c.JSON(http.StatusOK, suggestion)
+}
+
+// RejectCaseSuggestion 拒絕 AI 案件更新建議
+// @Summary      拒絕 AI 案件更新建議
+// @Tags         Cases
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Suggestion ID"
+// @Success      200  {object}  models.CaseUpdateSuggestion
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /case-suggestions/{id}/reject [post]
+func (h *CaseSuggestionHandler) RejectCaseSuggestion(c *gin.Context) {
+	suggestion, ok := h.loadSuggestion(c)
+	if !ok {
+		return
+	}
+
+	if err := h.caseUpdates.Reject(suggestion); err != nil {
+		h.resolveError(c, err, "Failed to reject case update suggestion")
+		return
+	}
+	c.JSON(http.StatusOK, suggestion)
+}
+
+// loadSuggestion 取得使用者的建議（失敗時已寫入回應）
+func (h *CaseSuggestionHandler) loadSuggestion(c *gin.Context) (*models.CaseUpdateSuggestion, bool) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_id", Message: "Invalid suggestion ID"})
+		return nil, false
+	}
+
+	var suggestion models.CaseUpdateSuggestion
+	if err := h.db.Where("id = ? AND user_id = ?", id, userID).First(&suggestion).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "suggestion_not_found", Message: "Case update suggestion not found"})
+			return nil, false
+		}
+		logger.Error().Err(err).Msg("Failed to fetch case update suggestion")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch case update suggestion"})
+		return nil, false
+	}
+	return &suggestion, true
+}
+
+// resolveError 將接受 / 拒絕的錯誤轉為回應
+func (h *CaseSuggestionHandler) resolveError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, caseupdate.ErrResolved):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "suggestion_resolved", Message: "Case update suggestion has already been resolved"})
+	case errors.Is(err, caseupdate.ErrUnknownField):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_field", Message: err.Error()})
+	default:
+		middleware.GetLogger(c).Error().Err(err).Msg(msg)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: msg})
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -8,21 +9,31 @@ import (
 
 	"github.com/designcomb/influenter-backend/internal/middleware"
 	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/feedback"
 	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/designcomb/influenter-backend/internal/services/pricing"
+	"github.com/designcomb/influenter-backend/internal/services/quotation"
+	"github.com/designcomb/influenter-backend/internal/services/style"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CaseHandler 案件處理器
 type CaseHandler struct {
 	db            *gorm.DB
 	openaiService *openai.Service
+	styleService  *style.Service
+	pricing       *pricing.Service
+	quotations    *quotation.Service
+	feedback      *feedback.Service
 }
 
 // NewCaseHandler 建立新的案件處理器
-func NewCaseHandler(db *gorm.DB, openaiSvc *openai.Service) *CaseHandler {
-	return &CaseHandler{db: db, openaiService: openaiSvc}
+func NewCaseHandler(db *gorm.DB, openaiSvc *openai.Service, styleSvc *style.Service) *CaseHandler {
+	return &CaseHandler{db: db, openaiService: openaiSvc, styleService: styleSvc, pricing: pricing.NewService(db), quotations: quotation.NewService(db), feedback: feedback.NewService(db)}
 }
 
 // CreateCaseRequest 建立案件請求（與前端 CreateCaseRequest 對齊）
@@ -58,6 +69,7 @@ type CaseResponse struct {
 	EmailCount        int      `json:"email_count"`
 	TaskCount         int      `json:"task_count"`
 	CompletedTaskCount int     `json:"completed_task_count"`
+	SnoozedUntil      *time.Time `json:"snoozed_until,omitempty"`
 	CreatedAt         string   `json:"created_at"`
 	UpdatedAt         string   `json:"updated_at"`
 }
@@ -79,6 +91,7 @@ func caseToResponse(c *models.Case, emailCount, taskCount, completedTaskCount in
 		EmailCount:         emailCount,
 		TaskCount:          taskCount,
 		CompletedTaskCount: completedTaskCount,
+		SnoozedUntil:       c.SnoozedUntil,
 		CreatedAt:          c.CreatedAt.Format("2006-01-02T15:04:05.000Z07:00"),
 		UpdatedAt:          c.UpdatedAt.Format("2006-01-02T15:04:05.000Z07:00"),
 	}
@@ -171,6 +184,10 @@ func (h *CaseHandler) ListCases(c *gin.Context) {
 	if status != "" {
 		query = query.Where("status = ?", status)
 	}
+	// 延後處理中的案件到期前不顯示
+	if c.Query("include_snoozed") != "true" {
+		query = query.Where("snoozed_until IS NULL OR snoozed_until <= ?", time.Now())
+	}
 
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
@@ -318,6 +335,166 @@ func (h *CaseHandler) GetCase(c *gin.Context) {
 	})
 }
 
+// UpdateCaseRequest 更新案件請求（只更新有傳入的欄位）
+type UpdateCaseRequest struct {
+	Title             *string  `json:"title"`
+	BrandName         *string  `json:"brand_name"`
+	Status            *string  `json:"status"`
+	CollaborationType *string  `json:"collaboration_type"`
+	Description       *string  `json:"description"`
+	QuotedAmount      *float64 `json:"quoted_amount"`
+	FinalAmount       *float64 `json:"final_amount"`
+	Currency          *string  `json:"currency"`
+	DeadlineDate      *string  `json:"deadline_date"` // YYYY-MM-DD，空字串清除
+	ContactName       *string  `json:"contact_name"`
+	ContactEmail      *string  `json:"contact_email"`
+	ContactPhone      *string  `json:"contact_phone"`
+	Notes             *string  `json:"notes"`
+	Tags              []string `json:"tags"`
+}
+
+// isCaseStatus 是否為有效的案件狀態
+func isCaseStatus(status string) bool {
+	switch models.CaseStatus(status) {
+	case models.CaseStatusToConfirm, models.CaseStatusInProgress, models.CaseStatusCompleted, models.CaseStatusCancelled, models.CaseStatusOther:
+		return true
+	}
+	return false
+}
+
+// UpdateCase 更新案件
+// @Summary      更新案件
+// @Description  更新案件欄位。案件由 AI 分析郵件建立時，修改分類（非合作 ↔ 合作）、品牌、金額、聯絡人等欄位會記錄為對原分析的修正，作為之後分析的參考範例
+// @Tags         Cases
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string             true  "案件 ID"
+// @Param        request  body      UpdateCaseRequest  true  "更新內容"
+// @Success      200      {object}  CaseResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Router       /cases/{id} [patch]
+func (h *CaseHandler) UpdateCase(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+	caseID := c.Param("id")
+
+	id, err := uuid.Parse(caseID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_id", Message: "Invalid case ID"})
+		return
+	}
+
+	var req UpdateCaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_params", Message: err.Error()})
+		return
+	}
+
+	var before models.Case
+	if err := h.db.Where("id = ? AND user_id = ?", id, userID).First(&before).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "case_not_found", Message: "Case not found"})
+			return
+		}
+		logger.Error().Err(err).Str("case_id", caseID).Msg("Failed to fetch case")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch case"})
+		return
+	}
+
+	after := before
+	updates := map[string]interface{}{}
+	if req.Title != nil {
+		if *req.Title == "" {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_params", Message: "title must not be empty"})
+			return
+		}
+		after.Title = *req.Title
+		updates["title"] = after.Title
+	}
+	if req.BrandName != nil {
+		if *req.BrandName == "" {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_params", Message: "brand_name must not be empty"})
+			return
+		}
+		after.BrandName = *req.BrandName
+		updates["brand_name"] = after.BrandName
+	}
+	if req.Status != nil {
+		if !isCaseStatus(*req.Status) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_params", Message: "invalid status"})
+			return
+		}
+		after.Status = models.CaseStatus(*req.Status)
+		updates["status"] = after.Status
+	}
+	if req.DeadlineDate != nil {
+		after.DeadlineDate = nil
+		if *req.DeadlineDate != "" {
+			t, err := time.Parse("2006-01-02", *req.DeadlineDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_params", Message: "deadline_date must be YYYY-MM-DD"})
+				return
+			}
+			after.DeadlineDate = &t
+		}
+		updates["deadline_date"] = after.DeadlineDate
+	}
+	if req.QuotedAmount != nil {
+		after.QuotedAmount = req.QuotedAmount
+		updates["quoted_amount"] = *req.QuotedAmount
+	}
+	if req.FinalAmount != nil {
+		after.FinalAmount = req.FinalAmount
+		updates["final_amount"] = *req.FinalAmount
+	}
+	optional := []struct {
+		column string
+		value  *string
+		field  **string
+	}{
+		{"collaboration_type", req.CollaborationType, &after.CollaborationType},
+		{"description", req.Description, &after.Description},
+		{"currency", req.Currency, &after.Currency},
+		{"contact_name", req.ContactName, &after.ContactName},
+		{"contact_email", req.ContactEmail, &after.ContactEmail},
+		{"contact_phone", req.ContactPhone, &after.ContactPhone},
+		{"notes", req.Notes, &after.Notes},
+	}
+	for _, o := range optional {
+		if o.value == nil {
+			continue
+		}
+		*o.field = o.value
+		updates[o.column] = *o.value
+	}
+	if req.Tags != nil {
+		after.Tags = req.Tags
+		updates["tags"] = pq.StringArray(req.Tags)
+	}
+
+	if len(updates) > 0 {
+		// 不以 before 作為 Model，避免 GORM 將更新值寫回修改前的資料
+		if err := h.db.Model(&models.Case{ID: id}).Updates(updates).Error; err != nil {
+			logger.Error().Err(err).Str("case_id", caseID).Msg("Failed to update case")
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to update case"})
+			return
+		}
+		// 記錄對 AI 分析的修正（失敗不影響更新）
+		if rows, err := h.feedback.RecordCaseEdit(&before, &after); err != nil {
+			logger.Warn().Err(err).Str("case_id", caseID).Msg("Failed to record ai feedback from case edit")
+		} else if len(rows) > 0 {
+			logger.Info().Str("case_id", caseID).Int("fields", len(rows)).Msg("Recorded ai feedback from case edit")
+		}
+	}
+
+	h.db.First(&after, "id = ?", id)
+	var emailCount int64
+	h.db.Model(&models.Email{}).Where("case_id = ?", id).Count(&emailCount)
+	c.JSON(http.StatusOK, caseToResponse(&after, int(emailCount), 0, 0))
+}
+
 // CaseEmailResponse 案件郵件列表項目（與前端 CaseEmail 對齊）
 type CaseEmailResponse struct {
 	ID         string  `json:"id"`
@@ -334,6 +511,43 @@ type CaseEmailResponse struct {
 type DraftReplyRequest struct {
 	EmailID    string `json:"email_id"`    // 要回覆的郵件 ID，可選；未傳則用該案件最新一封
 	Instruction string `json:"instruction"` // 使用者補充說明，可選
+	Variants     []openai.DraftVariant `json:"variants"`      // 指定各份草稿的立場與語氣，可選（最多 3 份）
+	VariantCount int                   `json:"variant_count"` // 未指定 variants 時依序以接受、議價、婉拒產生 N 份（1-3），可選
+}
+
+// maxDraftVariants 一次最多產生的草稿份數
+const maxDraftVariants = 3
+
+// DraftVariantResponse 一份草稿的結果
+type DraftVariantResponse struct {
+	DraftID       *uuid.UUID `json:"draft_id,omitempty"`
+	Stance        string     `json:"stance"`
+	Tone          string     `json:"tone,omitempty"`
+	Draft         string     `json:"draft,omitempty"`
+	PromptVersion string     `json:"prompt_version,omitempty"`
+	Error         string     `json:"error,omitempty"` // 這份產生失敗時的錯誤代碼
+}
+
+// draftVariants 驗證並整理要產生的草稿立場與語氣；未指定時回傳 nil（產生一份一般草稿）
+func draftVariants(body *DraftReplyRequest) ([]openai.DraftVariant, error) {
+	if len(body.Variants) == 0 {
+		if body.VariantCount < 0 || body.VariantCount > len(openai.DefaultDraftVariants) {
+			return nil, fmt.Errorf("variant_count must be between 1 and %d", len(openai.DefaultDraftVariants))
+		}
+		return openai.DefaultDraftVariants[:body.VariantCount], nil
+	}
+	if len(body.Variants) > maxDraftVariants {
+		return nil, fmt.Errorf("at most %d variants", maxDraftVariants)
+	}
+	for _, v := range body.Variants {
+		if !openai.IsDraftStance(v.Stance) {
+			return nil, fmt.Errorf("invalid stance %q (accept, negotiate, decline)", v.Stance)
+		}
+		if v.Tone != "" && !openai.IsDraftTone(v.Tone) {
+			return nil, fmt.Errorf("invalid tone %q (friendly, formal, casual)", v.Tone)
+		}
+	}
+	return body.Variants, nil
 }
 
 // ListCaseEmails 取得案件關聯的郵件列表
@@ -397,46 +611,60 @@ func (h *CaseHandler) ListCaseEmails(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": data})
 }
 
-// DraftReply 產生 AI 擬回信草稿
-func (h *CaseHandler) DraftReply(c *gin.Context) {
+// draftContext 擬信所需的案件、郵件與 AI 請求（一般與串流擬信共用）
+type draftContext struct {
+	userID      uuid.UUID
+	caseID      uuid.UUID
+	email       models.Email
+	instruction string
+	req         openai.DraftReplyRequest
+	variants    []openai.DraftVariant // 超過一份時產生多份草稿
+}
+
+// prepareDraft 驗證請求並組出擬信請求；失敗時已寫入錯誤回應
+func (h *CaseHandler) prepareDraft(c *gin.Context) (*draftContext, bool) {
 	logger := middleware.GetLogger(c)
 	userID := c.GetString("user_id")
 	caseID := c.Param("id")
 
 	if h.openaiService == nil {
 		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "openai_unavailable", Message: "AI 服務未設定"})
-		return
+		return nil, false
 	}
 
 	id, err := uuid.Parse(caseID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_id", Message: "Invalid case ID"})
-		return
+		return nil, false
 	}
 
 	var body DraftReplyRequest
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
-		return
+		return nil, false
+	}
+	variants, err := draftVariants(&body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_variants", Message: err.Error()})
+		return nil, false
 	}
 
 	var cs models.Case
 	if err := h.db.Where("id = ? AND user_id = ?", id, userID).First(&cs).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, ErrorResponse{Error: "case_not_found", Message: "Case not found"})
-			return
+			return nil, false
 		}
 		logger.Error().Err(err).Str("case_id", caseID).Msg("Failed to fetch case")
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch case"})
-		return
+		return nil, false
 	}
-
 	var email models.Email
 	if body.EmailID != "" {
 		emailUUID, err := uuid.Parse(body.EmailID)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_email_id", Message: "Invalid email ID"})
-			return
+			return nil, false
 		}
 		err = h.db.Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
 			Where("emails.id = ? AND emails.case_id = ? AND oauth_accounts.user_id = ?", emailUUID, id, userID).
@@ -444,11 +672,11 @@ func (h *CaseHandler) DraftReply(c *gin.Context) {
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
 				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "email_not_found", Message: "該郵件不存在或未關聯此案件"})
-				return
+				return nil, false
 			}
 			logger.Error().Err(err).Str("email_id", body.EmailID).Msg("Failed to fetch email")
 			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch email"})
-			return
+			return nil, false
 		}
 	} else {
 		err = h.db.Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
@@ -458,14 +686,27 @@ func (h *CaseHandler) DraftReply(c *gin.Context) {
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
 				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "no_emails", Message: "此案件尚無關聯郵件，無法擬信"})
-				return
+				return nil, false
 			}
 			logger.Error().Err(err).Str("case_id", caseID).Msg("Failed to fetch latest email")
 			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch email"})
-			return
+			return nil, false
 		}
 	}
 
+	req := h.draftRequest(c, &cs, &email, body.Instruction)
+	if len(variants) == 1 {
+		req.Stance, req.Tone = variants[0].Stance, variants[0].Tone
+	}
+	userUUID, _ := uuid.Parse(userID)
+	return &draftContext{userID: userUUID, caseID: id, email: email, instruction: body.Instruction, req: req, variants: variants}, true
+}
+
+// draftRequest 由案件與要回覆的郵件組出擬信請求（含使用者 AI 注意事項與寫作風格）
+func (h *CaseHandler) draftRequest(c *gin.Context, cs *models.Case, email *models.Email, instruction string) openai.DraftReplyRequest {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
 	bodyText := ""
 	if email.BodyText != nil && *email.BodyText != "" {
 		bodyText = *email.BodyText
@@ -504,18 +745,210 @@ func (h *CaseHandler) DraftReply(c *gin.Context) {
 		EmailFrom:          fromName,
 		EmailSubject:       subject,
 		EmailBody:          bodyText,
-		Instruction:        body.Instruction,
+		Instruction:        instruction,
 		UserAIInstructions: userAIInstructions,
 	}
+	userUUID, _ := uuid.Parse(userID)
+	if h.styleService != nil {
+		if err := h.styleService.Apply(userUUID, email, &req); err != nil {
+			logger.Warn().Err(err).Msg("Failed to apply writing style")
+		}
+	}
+	return req
+}
+
+// saveDraft 保存產生完成的草稿
+func (h *CaseHandler) saveDraft(d *draftContext, result *openai.DraftReplyResult, variant openai.DraftVariant, streamed bool) (*models.ReplyDraft, error) {
+	draft := &models.ReplyDraft{
+		UserID:        d.userID,
+		CaseID:        d.caseID,
+		EmailID:       &d.email.ID,
+		Content:       result.Draft,
+		PromptVersion: result.PromptVersion,
+		Streamed:      streamed,
+		Stance:        variant.Stance,
+		Tone:          variant.Tone,
+	}
+	if d.instruction != "" {
+		draft.Instruction = &d.instruction
+	}
+	if err := h.db.Omit(clause.Associations).Create(draft).Error; err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// DraftReply 產生 AI 擬回信草稿
+// @Summary      產生回信草稿
+// @Description  依案件與要回覆的郵件產生回信草稿並保存。指定 variants 或 variant_count 時同時產生多份不同立場（accept / negotiate / decline）與語氣（friendly / formal / casual）的草稿，回應的 variants 依序列出各份結果，draft / draft_id 為第一份成功的草稿
+// @Tags         Cases
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string             true  "案件 ID"
+// @Param        request  body      DraftReplyRequest  true  "擬信設定"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      429      {object}  ErrorResponse
+// @Failure      503      {object}  ErrorResponse
+// @Router       /cases/{id}/draft-reply [post]
+func (h *CaseHandler) DraftReply(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+
+	d, ok := h.prepareDraft(c)
+	if !ok {
+		return
+	}
+
+	ctx := openai.WithUsageScope(c.Request.Context(), d.userID.String(), d.email.ID.String())
+	if len(d.variants) > 1 {
+		h.draftVariants(ctx, c, d)
+		return
+	}
+	result, err := h.openaiService.DraftReply(ctx, d.req)
+	if err != nil {
+		if aiLimitError(c, err) {
+			return
+		}
+		logger.Error().Err(err).Str("case_id", d.caseID.String()).Msg("DraftReply failed")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "draft_failed", Message: "產生草稿失敗，請稍後再試"})
+		return
+	}
+
+	resp := gin.H{"draft": result.Draft, "prompt_version": result.PromptVersion}
+	if d.req.Stance != "" {
+		resp["stance"], resp["tone"] = d.req.Stance, d.req.Tone
+	}
+	if draft, err := h.saveDraft(d, result, openai.DraftVariant{Stance: d.req.Stance, Tone: d.req.Tone}, false); err != nil {
+		logger.Error().Err(err).Str("case_id", d.caseID.String()).Msg("Failed to save draft")
+	} else {
+		resp["draft_id"] = draft.ID
+	}
+	c.JSON(http.StatusOK, resp)
+}
 
-	result, err := h.openaiService.DraftReply(c.Request.Context(), req)
+// draftVariants 同時產生多份不同立場與語氣的草稿並逐份保存
+func (h *CaseHandler) draftVariants(ctx context.Context, c *gin.Context, d *draftContext) {
+	logger := middleware.GetLogger(c)
+
+	results, err := h.openaiService.DraftVariants(ctx, d.req, d.variants)
 	if err != nil {
-		logger.Error().Err(err).Str("case_id", caseID).Msg("DraftReply failed")
+		if aiLimitError(c, err) {
+			return
+		}
+		logger.Error().Err(err).Str("case_id", d.caseID.String()).Msg("DraftVariants failed")
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "draft_failed", Message: "產生草稿失敗，請稍後再試"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"draft": result.Draft})
+	resp := gin.H{}
+	variants := make([]DraftVariantResponse, 0, len(results))
+	for _, r := range results {
+		v := DraftVariantResponse{Stance: r.Stance, Tone: r.Tone}
+		if r.Err != nil {
+			logger.Warn().Err(r.Err).Str("case_id", d.caseID.String()).Str("stance", r.Stance).Msg("Draft variant failed")
+			v.Error = "draft_failed"
+			variants = append(variants, v)
+			continue
+		}
+		v.Draft, v.PromptVersion = r.Result.Draft, r.Result.PromptVersion
+		if draft, err := h.saveDraft(d, r.Result, r.DraftVariant, false); err != nil {
+			logger.Error().Err(err).Str("case_id", d.caseID.String()).Msg("Failed to save draft")
+		} else {
+			v.DraftID = &draft.ID
+		}
+		if _, ok := resp["draft"]; !ok {
+			resp["draft"], resp["prompt_version"] = v.Draft, v.PromptVersion
+			if v.DraftID != nil {
+				resp["draft_id"] = *v.DraftID
+			}
+		}
+		variants = append(variants, v)
+	}
+	resp["variants"] = variants
+	c.JSON(http.StatusOK, resp)
+}
+
+// DraftReplyStream 以 Server-Sent Events 逐段回傳 AI 擬回信草稿
+// @Summary      串流產生回信草稿
+// @Description  與 POST /cases/{id}/draft-reply 相同的提示詞，逐段以 SSE 回傳：delta 事件為新產生的文字（{"text"}），完成時送出 done（{"draft_id","draft","prompt_version"}）並保存草稿，失敗時送出 error。連線中斷時取消上游請求且不保存。開始串流前的錯誤（含 AI 額度）以一般 JSON 回應
+// @Tags         Cases
+// @Accept       json
+// @Produce      text/event-stream
+// @Security     BearerAuth
+// @Param        id       path      string             true  "案件 ID"
+// @Param        request  body      DraftReplyRequest  true  "擬信設定"
+// @Success      200      {string}  string             "SSE 串流"
+// @Failure      400      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      429      {object}  ErrorResponse
+// @Failure      503      {object}  ErrorResponse
+// @Router       /cases/{id}/draft-reply/stream [post]
+func (h *CaseHandler) DraftReplyStream(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+
+	d, ok := h.prepareDraft(c)
+	if !ok {
+		return
+	}
+	if len(d.variants) > 1 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_variants", Message: "串流擬信一次只能產生一份草稿"})
+		return
+	}
+
+	// 第一段文字到達時才送出 SSE header，之前的錯誤仍可用一般 JSON 回應
+	started := false
+	start := func() {
+		if started {
+			return
+		}
+		started = true
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+	}
+
+	// 用戶端中斷時 request context 會被取消，上游請求隨之中止
+	reqCtx := c.Request.Context()
+	ctx := openai.WithUsageScope(reqCtx, d.userID.String(), d.email.ID.String())
+	result, err := h.openaiService.DraftReplyStream(ctx, d.req, func(delta string) error {
+		if err := reqCtx.Err(); err != nil {
+			return err
+		}
+		start()
+		c.SSEvent("delta", gin.H{"text": delta})
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		if reqCtx.Err() != nil {
+			logger.Info().Str("case_id", d.caseID.String()).Msg("Draft stream cancelled by client")
+			return
+		}
+		logger.Error().Err(err).Str("case_id", d.caseID.String()).Msg("DraftReplyStream failed")
+		if !started {
+			if !aiLimitError(c, err) {
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "draft_failed", Message: "產生草稿失敗，請稍後再試"})
+			}
+			return
+		}
+		c.SSEvent("error", ErrorResponse{Error: "draft_failed", Message: "產生草稿失敗，請稍後再試"})
+		c.Writer.Flush()
+		return
+	}
+
+	done := gin.H{"draft": result.Draft, "prompt_version": result.PromptVersion}
+	if draft, err := h.saveDraft(d, result, openai.DraftVariant{Stance: d.req.Stance, Tone: d.req.Tone}, true); err != nil {
+		logger.Error().Err(err).Str("case_id", d.caseID.String()).Msg("Failed to save draft")
+	} else {
+		done["draft_id"] = draft.ID
+	}
+	start()
+	c.SSEvent("done", done)
+	c.Writer.Flush()
 }
 
 // --- Case Phase types and handlers ---
@@ -1028,8 +1461,16 @@ func (h *CaseHandler) AutoApplyTemplate(c *gin.Context) {
 		Templates:       templateInfos,
 	}
 
-	matchResult, err := h.openaiService.MatchWorkflowTemplate(c.Request.Context(), matchReq)
+	usageEmailID := ""
+	if latestEmail.ID != uuid.Nil {
+		usageEmailID = latestEmail.ID.String()
+	}
+	ctx := openai.WithUsageScope(c.Request.Context(), userID, usageEmailID)
+	matchResult, err := h.openaiService.MatchWorkflowTemplate(ctx, matchReq)
 	if err != nil {
+		if aiLimitError(c, err) {
+			return
+		}
 		logger.Error().Err(err).Msg("AI workflow template matching failed")
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "ai_error", Message: "AI 分析失敗，請稍後再試"})
 		return
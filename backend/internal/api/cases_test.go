@@ -0,0 +1,76 @@
+package api
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/analysis"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUpdateCase_RecordsAIFeedback 測試修改 AI 建立的案件品牌與金額時，記錄對原始分析的修正
+func TestUpdateCase_RecordsAIFeedback(t *testing.T) {
+	db, router, cfg := setupTestRouter(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		if sqlDB != nil {
+			sqlDB.Close()
+		}
+	}()
+
+	userID, token, _ := createTestUser(t, db, cfg)
+	account := createTestOAuthAccount(t, db, userID)
+	email := createTestEmail(t, db, account.ID)
+
+	amount := 30000.0
+	a, err := analysis.Record(db, email.ID, userID, &openai.EmailAnalysisResult{
+		Classification: openai.EmailClassification{Category: openai.CategoryCollaboration, Confidence: 0.9},
+		ExtractedInfo:  openai.ExtractedInfo{BrandName: "Glow Ltd", Amount: &amount},
+		Model:          "gpt-4o-mini",
+		AnalyzedAt:     time.Now(),
+	})
+	require.NoError(t, err)
+
+	// 由這封郵件的分析建立的案件
+	cs := &models.Case{UserID: userID, Title: "Glow 合作", BrandName: "Glow Ltd", Status: models.CaseStatusToConfirm, QuotedAmount: &amount}
+	require.NoError(t, db.Omit("User").Create(cs).Error)
+	require.NoError(t, db.Model(email).Update("case_id", cs.ID).Error)
+
+	patch := func(body string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("PATCH", "/api/v1/cases/"+cs.ID.String(), bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, 200, patch(`{"brand_name":"Glow 台灣","quoted_amount":35000,"notes":"已確認"}`))
+
+	var rows []models.AIFeedback
+	require.NoError(t, db.Where("analysis_id = ?", a.ID).Order("field").Find(&rows).Error)
+	if assert.Len(t, rows, 2) {
+		assert.Equal(t, "amount", rows[0].Field)
+		assert.Equal(t, "30000", rows[0].Predicted)
+		assert.Equal(t, "35000", rows[0].Corrected)
+		assert.Equal(t, "brand_name", rows[1].Field)
+		assert.Equal(t, "Glow Ltd", rows[1].Predicted)
+		assert.Equal(t, "Glow 台灣", rows[1].Corrected)
+		for _, row := range rows {
+			assert.Equal(t, models.AIFeedbackSourceCaseEdit, row.Source)
+			assert.Equal(t, email.ID, row.EmailID)
+			assert.False(t, row.Correct)
+		}
+	}
+
+	// 只改備註不記錄回饋
+	assert.Equal(t, 200, patch(`{"notes":"合約已寄出"}`))
+	var count int64
+	db.Model(&models.AIFeedback{}).Where("analysis_id = ?", a.ID).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/designcomb/influenter-backend/internal/middleware"
 	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/aicache"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -133,6 +135,7 @@ func (h *CollaborationItemHandler) CreateItem(c *gin.Context) {
 		return db.Order(`"order" ASC`)
 	}).First(&item, "id = ?", item.ID)
 
+	invalidateMatchCache(c, h.db, userIDStr)
 	c.JSON(http.StatusCreated, item)
 }
 
@@ -201,6 +204,7 @@ func (h *CollaborationItemHandler) UpdateItem(c *gin.Context) {
 		return db.Order(`"order" ASC`)
 	}).First(&item, "id = ?", item.ID)
 
+	invalidateMatchCache(c, h.db, userID)
 	c.JSON(http.StatusOK, item)
 }
 
@@ -233,6 +237,7 @@ func (h *CollaborationItemHandler) DeleteItem(c *gin.Context) {
 		return
 	}
 
+	invalidateMatchCache(c, h.db, userID)
 	c.JSON(http.StatusOK, gin.H{"message": "Collaboration item deleted"})
 }
 
@@ -270,5 +275,17 @@ func (h *CollaborationItemHandler) ReorderItems(c *gin.Context) {
 	}
 	tx.Commit()
 
+	invalidateMatchCache(c, h.db, userID)
 	c.JSON(http.StatusOK, gin.H{"message": "Reordered successfully"})
 }
+
+// invalidateMatchCache 合作項目或流程範本變更後清除比對快取（失敗只記 log）
+func invalidateMatchCache(c *gin.Context, db *gorm.DB, userID string) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return
+	}
+	if _, err := aicache.Invalidate(db, uid, openai.OperationMatch); err != nil {
+		middleware.GetLogger(c).Warn().Err(err).Msg("Failed to invalidate match cache")
+	}
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/analysis"
+	"github.com/designcomb/influenter-backend/internal/services/feedback"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AIAnalysisResponse 郵件 AI 分析結果
+type AIAnalysisResponse struct {
+	ID                   string               `json:"id"`
+	EmailID              string               `json:"email_id"`
+	Version              int                  `json:"version"`
+	LatestVersion        int                  `json:"latest_version"`
+	Model                string               `json:"model"`
+	PromptVersion        string               `json:"prompt_version"`
+	Category             string               `json:"category"`
+	CategoryName         string               `json:"category_name"`
+	Confidence           float64              `json:"confidence"`
+	ClassificationReason *string              `json:"classification_reason,omitempty"`
+	ExtractedInfo        openai.ExtractedInfo `json:"extracted_info"`
+	Summary              *string              `json:"summary,omitempty"`
+	KeyPoints            []string             `json:"key_points"`
+	ActionRequired       bool                 `json:"action_required"`
+	Priority             string               `json:"priority"`
+	TokensUsed           int                  `json:"tokens_used"`
+	AnalyzedAt           time.Time            `json:"analyzed_at"`
+}
+
+// GetEmailAnalysis 取得郵件的 AI 分析結果
+// @Summary      取得郵件 AI 分析
+// @Description  預設回傳最新一次分析；指定 version 可查看先前的分析
+// @Tags         郵件
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string  true   "郵件 ID"
+// @Param        version  query     int     false  "分析版本"
+// @Success      200  {object}  AIAnalysisResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /emails/{id}/analysis [get]
+func (h *EmailHandler) GetEmailAnalysis(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+	emailID := c.Param("id")
+
+	id, err := uuid.Parse(emailID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_id", Message: "Invalid email ID"})
+		return
+	}
+
+	var email models.Email
+	err = h.db.Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
+		Where("emails.id = ? AND oauth_accounts.user_id = ?", id, userID).
+		First(&email).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "email_not_found", Message: "Email not found"})
+			return
+		}
+		logger.Error().Err(err).Str("email_id", emailID).Msg("Failed to fetch email")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch email"})
+		return
+	}
+
+	query := h.db.Where("email_id = ?", email.ID)
+	if v := c.Query("version"); v != "" {
+		version, err := strconv.Atoi(v)
+		if err != nil || version < 1 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_version", Message: "version must be a positive integer"})
+			return
+		}
+		query = query.Where("version = ?", version)
+	} else if email.AIAnalysisID != nil {
+		query = query.Where("id = ?", *email.AIAnalysisID)
+	} else {
+		query = query.Order("version DESC")
+	}
+
+	var a models.AIAnalysis
+	if err := query.First(&a).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "analysis_not_found", Message: "This email has not been analyzed"})
+			return
+		}
+		logger.Error().Err(err).Str("email_id", emailID).Msg("Failed to fetch AI analysis")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch AI analysis"})
+		return
+	}
+
+	var latest int
+	if err := h.db.Model(&models.AIAnalysis{}).Where("email_id = ?", email.ID).
+		Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+		logger.Error().Err(err).Str("email_id", emailID).Msg("Failed to count AI analyses")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch AI analysis"})
+		return
+	}
+
+	info, err := analysis.ExtractedInfo(&a)
+	if err != nil {
+		logger.Error().Err(err).Str("analysis_id", a.ID.String()).Msg("Failed to decode AI analysis")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "internal_error", Message: "Failed to read AI analysis"})
+		return
+	}
+
+	keyPoints := []string(a.KeyPoints)
+	if keyPoints == nil {
+		keyPoints = []string{}
+	}
+
+	c.JSON(http.StatusOK, AIAnalysisResponse{
+		ID:                   a.ID.String(),
+		EmailID:              a.EmailID.String(),
+		Version:              a.Version,
+		LatestVersion:        latest,
+		Model:                a.Model,
+		PromptVersion:        a.PromptVersion,
+		Category:             a.Category,
+		CategoryName:         openai.GetCategoryDisplayName(openai.EmailCategory(a.Category)),
+		Confidence:           a.Confidence,
+		ClassificationReason: a.ClassificationReason,
+		ExtractedInfo:        *info,
+		Summary:              a.Summary,
+		KeyPoints:            keyPoints,
+		ActionRequired:       a.ActionRequired,
+		Priority:             a.Priority,
+		TokensUsed:           a.TokensUsed,
+		AnalyzedAt:           a.AnalyzedAt,
+	})
+}
+
+// AnalysisFeedbackRequest 對郵件最新 AI 分析的回饋：傳入正確的值，與 AI 結果相同者記為正確
+type AnalysisFeedbackRequest struct {
+	Category *string           `json:"category"` // 正確的分類
+	Fields   map[string]string `json:"fields"`   // 擷取欄位的正確值（brand_name、amount、due_date…；空字串表示郵件中沒有）
+}
+
+// AnalysisFeedbackResponse 記錄的回饋
+type AnalysisFeedbackResponse struct {
+	AnalysisID string              `json:"analysis_id"`
+	Feedback   []models.AIFeedback `json:"feedback"`
+}
+
+// SubmitAnalysisFeedback 回饋郵件 AI 分析的正確性
+// @Summary      回饋郵件 AI 分析
+// @Description  對最新一次分析的分類或擷取欄位提供正確值；修正會作為之後分類 / 擷取的參考範例，並用於統計準確率。同一欄位重複回饋以最新一次為準
+// @Tags         郵件
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                   true  "郵件 ID"
+// @Param        request  body      AnalysisFeedbackRequest  true  "正確的值"
+// @Success      200      {object}  AnalysisFeedbackResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /emails/{id}/analysis/feedback [post]
+func (h *EmailHandler) SubmitAnalysisFeedback(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+	emailID := c.Param("id")
+
+	id, err := uuid.Parse(emailID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_id", Message: "Invalid email ID"})
+		return
+	}
+
+	var req AnalysisFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+	corrected := make(map[string]string, len(req.Fields)+1)
+	for field, value := range req.Fields {
+		corrected[field] = value
+	}
+	if req.Category != nil {
+		corrected[feedback.FieldCategory] = *req.Category
+	}
+	if len(corrected) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "category or fields is required"})
+		return
+	}
+
+	var email models.Email
+	err = h.db.Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
+		Where("emails.id = ? AND oauth_accounts.user_id = ?", id, userID).
+		First(&email).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "email_not_found", Message: "Email not found"})
+			return
+		}
+		logger.Error().Err(err).Str("email_id", emailID).Msg("Failed to fetch email")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch email"})
+		return
+	}
+	if email.AIAnalysisID == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "analysis_not_found", Message: "This email has not been analyzed"})
+		return
+	}
+
+	var a models.AIAnalysis
+	if err := h.db.First(&a, "id = ?", *email.AIAnalysisID).Error; err != nil {
+		logger.Error().Err(err).Str("email_id", emailID).Msg("Failed to fetch AI analysis")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch AI analysis"})
+		return
+	}
+
+	rows, err := h.feedback.Record(&a, models.AIFeedbackSourceManual, corrected)
+	if err != nil {
+		if errors.Is(err, feedback.ErrUnknownField) || errors.Is(err, feedback.ErrInvalidCategory) || errors.Is(err, feedback.ErrInvalidValue) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_feedback", Message: err.Error()})
+			return
+		}
+		logger.Error().Err(err).Str("email_id", emailID).Msg("Failed to record AI feedback")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to record feedback"})
+		return
+	}
+
+	logger.Info().Str("email_id", emailID).Int("fields", len(rows)).Msg("AI analysis feedback recorded")
+	c.JSON(http.StatusOK, AnalysisFeedbackResponse{AnalysisID: a.ID.String(), Feedback: rows})
+}
@@ -7,14 +7,21 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/designcomb/influenter-backend/internal/middleware"
 	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/analysis"
+	"github.com/designcomb/influenter-backend/internal/services/attachment"
+	"github.com/designcomb/influenter-backend/internal/services/casematch"
+	"github.com/designcomb/influenter-backend/internal/services/caseupdate"
+	"github.com/designcomb/influenter-backend/internal/services/feedback"
+	"github.com/designcomb/influenter-backend/internal/services/followup"
 	"github.com/designcomb/influenter-backend/internal/services/gmail"
 	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/designcomb/influenter-backend/internal/services/quotation"
+	"github.com/designcomb/influenter-backend/internal/services/style"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -26,16 +33,36 @@ import (
 type EmailHandler struct {
 	db            *gorm.DB
 	openaiService *openai.Service
+	followUps     *followup.Service // 可為 nil（不追蹤寄出郵件的回覆）
+	quotations    *quotation.Service
+	attachments   *attachment.Service // 可為 nil（只分析郵件內文）
+	feedback      *feedback.Service
+	caseUpdates   *caseupdate.Service
+	caseMatcher   *casematch.Matcher // 可為 nil（一律建立新案件）
 }
 
 // NewEmailHandler 建立新的郵件處理器
-func NewEmailHandler(db *gorm.DB, openaiService *openai.Service) *EmailHandler {
+func NewEmailHandler(db *gorm.DB, openaiService *openai.Service, followUps *followup.Service) *EmailHandler {
 	return &EmailHandler{
 		db:            db,
 		openaiService: openaiService,
+		followUps:     followUps,
+		quotations:    quotation.NewService(db),
+		feedback:      feedback.NewService(db),
+		caseUpdates:   caseupdate.NewService(db),
 	}
 }
 
+// SetAttachments 設定附件文字擷取（建立案件時一併分析附件內容）
+func (h *EmailHandler) SetAttachments(attachments *attachment.Service) {
+	h.attachments = attachments
+}
+
+// SetCaseMatcher 設定案件比對（由郵件建立案件時，既有合作的後續往來改為歸入既有案件）
+func (h *EmailHandler) SetCaseMatcher(matcher *casematch.Matcher) {
+	h.caseMatcher = matcher
+}
+
 // ListEmails 取得郵件列表
 // @Summary      取得郵件列表
 // @Description  取得使用者的郵件列表，支援分頁、篩選、搜尋
@@ -49,9 +76,13 @@ func NewEmailHandler(db *gorm.DB, openaiService *openai.Service) *EmailHandler {
 // @Param        subject           query     string  false  "主旨關鍵字"
 // @Param        start_date        query     string  false  "開始日期 (RFC3339)"
 // @Param        end_date          query     string  false  "結束日期 (RFC3339)"
+// @Param        include_snoozed   query     bool    false  "包含延後處理中的郵件"
+// @Param        category          query     string  false  "AI 分類（collaboration, payment, inquiry...）"
+// @Param        priority          query     string  false  "AI 優先級 (low/medium/high)"
+// @Param        action_required   query     bool    false  "AI 判斷是否需要行動"
 // @Param        page              query     int     false  "頁數" default(1)
 // @Param        page_size         query     int     false  "每頁數量" default(20)
-// @Param        sort_by           query     string  false  "排序欄位" default(received_at)
+// @Param        sort_by           query     string  false  "排序欄位 (received_at/created_at/category/priority)" default(received_at)
 // @Param        sort_order        query     string  false  "排序方向 (asc/desc)" default(desc)
 // @Success      200  {object}  map[string]interface{}  "郵件列表和分頁資訊"
 // @Failure      400  {object}  ErrorResponse
@@ -83,6 +114,14 @@ func (h *EmailHandler) ListEmails(c *gin.Context) {
 	// 應用篩選條件
 	if params.OAuthAccountID != nil {
 		query = query.Where("emails.oauth_account_id = ?", *params.OAuthAccountID)
+	} else if !params.IncludeDuplicates {
+		// 統一收件匣：同一封信寄到多個帳號時只顯示一筆
+		query = query.Where("emails.duplicate_of_id IS NULL")
+	}
+
+	// 延後處理中的郵件到期前不顯示（排程喚醒前已到期的也視為未延後）
+	if !params.IncludeSnoozed {
+		query = query.Where("emails.snoozed_until IS NULL OR emails.snoozed_until <= ?", time.Now())
 	}
 
 	if params.Direction == "incoming" || params.Direction == "outgoing" {
@@ -113,6 +152,36 @@ func (h *EmailHandler) ListEmails(c *gin.Context) {
 		query = query.Where("emails.received_at <= ?", params.EndDate)
 	}
 
+	// AI 分析篩選（依最新一次分析）
+	if params.NeedsAnalysisJoin() {
+		join := "LEFT JOIN ai_analyses ON ai_analyses.id = emails.ai_analysis_id"
+		if params.Category != "" || params.Priority != "" || params.ActionRequired != nil {
+			join = "JOIN ai_analyses ON ai_analyses.id = emails.ai_analysis_id"
+		}
+		query = query.Joins(join)
+	}
+
+	if params.Category != "" {
+		query = query.Where("ai_analyses.category = ?", params.Category)
+	}
+
+	if params.Priority != "" {
+		query = query.Where("ai_analyses.priority = ?", params.Priority)
+	}
+
+	if params.ActionRequired != nil {
+		query = query.Where("ai_analyses.action_required = ?", *params.ActionRequired)
+	}
+
+	orderClause, ok := params.OrderClause()
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_params",
+			Message: "sort_by must be one of received_at, created_at, category, priority",
+		})
+		return
+	}
+
 	// 計算總數
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
@@ -124,9 +193,11 @@ func (h *EmailHandler) ListEmails(c *gin.Context) {
 		return
 	}
 
-	// 排序
-	orderClause := params.SortBy + " " + params.SortOrder
+	// 排序（同值時依收件時間）
 	query = query.Order(orderClause)
+	if params.SortBy != "received_at" {
+		query = query.Order("emails.received_at DESC")
+	}
 
 	// 分頁
 	offset := (params.Page - 1) * params.PageSize
@@ -134,7 +205,7 @@ func (h *EmailHandler) ListEmails(c *gin.Context) {
 
 	// 執行查詢
 	var emails []models.Email
-	if err := query.Find(&emails).Error; err != nil {
+	if err := query.Preload("AIAnalysis").Find(&emails).Error; err != nil {
 		logger.Error().Err(err).Msg("Failed to fetch emails")
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "database_error",
@@ -219,7 +290,7 @@ func (h *EmailHandler) GetEmail(c *gin.Context) {
 
 // UpdateEmail 更新郵件
 // @Summary      更新郵件
-// @Description  更新郵件狀態（標記已讀、關聯案件等）
+// @Description  更新郵件狀態（標記已讀、關聯案件、不納入寫作風格分析等）
 // @Tags         郵件
 // @Accept       json
 // @Produce      json
@@ -287,9 +358,18 @@ func (h *EmailHandler) UpdateEmail(c *gin.Context) {
 		updates["is_read"] = *req.IsRead
 	}
 
+	// 歸入案件（含接受建議的案件）或略過建議時，清除建議的案件
 	if req.CaseID != nil {
 		updates["case_id"] = *req.CaseID
 	}
+	if (req.CaseID != nil || req.DismissCaseSuggestion) && email.SuggestedCaseID != nil {
+		updates["suggested_case_id"] = nil
+		updates["suggested_case_reason"] = nil
+	}
+
+	if req.StyleExcluded != nil && *req.StyleExcluded != email.StyleExcluded {
+		updates["style_excluded"] = *req.StyleExcluded
+	}
 
 	// 執行更新
 	if len(updates) > 0 {
@@ -303,6 +383,14 @@ func (h *EmailHandler) UpdateEmail(c *gin.Context) {
 		}
 	}
 
+	// 排除設定改變時清除寫作風格，下次擬信時重新統計
+	if _, ok := updates["style_excluded"]; ok {
+		userUUID, _ := uuid.Parse(userID)
+		if err := style.Invalidate(h.db, userUUID); err != nil {
+			logger.Warn().Err(err).Msg("Failed to invalidate writing style profile")
+		}
+	}
+
 	// 重新查詢以取得最新資料
 	if err := h.db.First(&email, id).Error; err != nil {
 		logger.Error().Err(err).Msg("Failed to fetch updated email")
@@ -323,7 +411,8 @@ func (h *EmailHandler) UpdateEmail(c *gin.Context) {
 
 // SendReplyRequest 寄出回信請求
 type SendReplyRequest struct {
-	Body string `json:"body" binding:"required"`
+	Body        string `json:"body" binding:"required"`
+	QuotationID string `json:"quotation_id"` // 附上案件報價單（PDF），可選
 }
 
 // SendReply 寄出回信（透過 Gmail API）
@@ -358,6 +447,19 @@ func (h *EmailHandler) SendReply(c *gin.Context) {
 		return
 	}
 
+	var (
+		quote       *models.Quotation
+		attachments []gmail.OutgoingAttachment
+	)
+	if body.QuotationID != "" {
+		var attachment *gmail.OutgoingAttachment
+		var ok bool
+		if quote, attachment, ok = h.quotationAttachment(c, &email, body.QuotationID); !ok {
+			return
+		}
+		attachments = append(attachments, *attachment)
+	}
+
 	var oauthAccount models.OAuthAccount
 	if err := h.db.Where("id = ?", email.OAuthAccountID).First(&oauthAccount).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -369,6 +471,14 @@ func (h *EmailHandler) SendReply(c *gin.Context) {
 		return
 	}
 
+	// 匯入的郵件沒有可寄信的帳號，改用使用者的 Gmail 帳號回覆
+	if oauthAccount.Provider == models.OAuthProviderImported {
+		if err := h.db.Where("user_id = ? AND provider = ?", userID, models.OAuthProviderGoogle).First(&oauthAccount).Error; err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "gmail_not_connected", Message: "回覆匯入的郵件需要先連結 Gmail 帳號"})
+			return
+		}
+	}
+
 	gmailSvc, err := gmail.NewService(h.db, &oauthAccount)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to create Gmail service")
@@ -391,11 +501,18 @@ func (h *EmailHandler) SendReply(c *gin.Context) {
 		threadID = *email.ThreadID
 	}
 
+	// 回覆自己寄出的信（例如跟進未回覆的郵件）時寄給原收件者
+	to := email.FromEmail
+	if email.Direction == models.EmailDirectionOutgoing && email.ToEmail != nil && *email.ToEmail != "" {
+		to = *email.ToEmail
+	}
+
 	req := &gmail.SendMessageRequest{
-		To:       []string{email.FromEmail},
-		Subject:  subject,
-		TextBody: body.Body,
-		ThreadID: threadID,
+		To:          []string{to},
+		Subject:     subject,
+		TextBody:    body.Body,
+		ThreadID:    threadID,
+		Attachments: attachments,
 	}
 
 	sentID, err := gmailSvc.SendMessage(req)
@@ -414,27 +531,45 @@ func (h *EmailHandler) SendReply(c *gin.Context) {
 		ThreadID:          email.ThreadID,
 		Direction:         models.EmailDirectionOutgoing,
 		FromEmail:         oauthAccount.Email,
-		ToEmail:           &email.FromEmail,
+		ToEmail:           &to,
 		Subject:           &subject,
 		BodyText:          &body.Body,
 		Snippet:           stringPtr(truncateStr(body.Body, 150)),
 		ReceivedAt:        time.Now(),
 		Labels:            pq.StringArray{"SENT"},
 		IsRead:            true,
+		HasAttachments:    len(attachments) > 0,
 		CaseID:            email.CaseID,
 	}
 	if err := h.db.Create(sentEmail).Error; err != nil {
+		sentEmail = nil
 		// 若為重複（例如同步先寫入），改為依 provider_message_id 更新 case_id
 		var existing models.Email
 		if err2 := h.db.Where("provider_message_id = ? AND oauth_account_id = ?", sentID, oauthAccount.ID).First(&existing).Error; err2 == nil && email.CaseID != nil {
 			if err3 := h.db.Model(&existing).Update("case_id", *email.CaseID).Error; err3 == nil {
 				logger.Info().Str("sent_id", sentID).Str("case_id", email.CaseID.String()).Msg("Linked existing sent email to case")
+				existing.CaseID = email.CaseID
+				sentEmail = &existing
 			}
 		} else {
 			logger.Error().Err(err).Str("sent_id", sentID).Str("case_id", fmt.Sprintf("%v", email.CaseID)).Msg("Failed to save sent email to DB")
 		}
 	}
 
+	// 記錄報價單已隨此回信寄出
+	if quote != nil && sentEmail != nil {
+		if err := h.quotations.MarkSent(quote, sentEmail.ID); err != nil {
+			logger.Warn().Err(err).Str("quotation_id", quote.ID.String()).Msg("Failed to mark quotation as sent")
+		}
+	}
+
+	// 追蹤案件寄出郵件是否在期限內獲得回覆
+	if sentEmail != nil && sentEmail.CaseID != nil && h.followUps != nil {
+		if _, err := h.followUps.Track(sentEmail, oauthAccount.UserID); err != nil {
+			logger.Warn().Err(err).Str("sent_id", sentID).Msg("Failed to track follow-up")
+		}
+	}
+
 	// 若郵件有關聯案件且 AI 服務可用，背景分析回信並自動更新案件狀態與進度
 	if email.CaseID != nil && h.openaiService != nil {
 		go h.runUpdateCaseFromReply(context.Background(), logger, emailID, email.CaseID, &email, body.Body)
@@ -445,8 +580,11 @@ func (h *EmailHandler) SendReply(c *gin.Context) {
 
 // UpdateEmailRequest 更新郵件請求
 type UpdateEmailRequest struct {
-	IsRead *bool      `json:"is_read"`
-	CaseID *uuid.UUID `json:"case_id"`
+	IsRead        *bool      `json:"is_read"`
+	CaseID        *uuid.UUID `json:"case_id"`
+	StyleExcluded *bool      `json:"style_excluded"` // 寄出郵件是否不納入寫作風格分析
+	// 略過建議的既有案件（不歸入）
+	DismissCaseSuggestion bool `json:"dismiss_case_suggestion"`
 }
 
 // stringPtr 返回字串指標
@@ -560,19 +698,32 @@ func (h *EmailHandler) runCreateCaseFromEmail(ctx context.Context, logger *zerol
 	if email.Subject != nil {
 		subject = *email.Subject
 	}
-	body := emailBodyForAnalysis(email)
+	body := analysis.EmailBody(email)
 	from := email.FromEmail
+	userUUID, _ := uuid.Parse(userID)
+
+	var attachments []models.EmailAttachment
+	if h.attachments != nil {
+		actx, cancel := context.WithTimeout(openai.WithUsageScope(ctx, userID, emailID), 2*time.Minute)
+		var err error
+		attachments, err = h.attachments.Process(actx, email, userUUID)
+		cancel()
+		if err != nil {
+			logger.Warn().Err(err).Str("email_id", emailID).Msg("Failed to extract attachments")
+		}
+	}
 
 	req := openai.AnalyzeEmailRequest{
-		Subject: subject,
-		Body:    body,
-		From:    from,
-		To:      nil,
-		Date:    email.ReceivedAt,
-		Options: openai.AnalysisOptions{DetailLevel: "standard"},
+		Subject:     subject,
+		Body:        body,
+		From:        from,
+		To:          nil,
+		Date:        email.ReceivedAt,
+		Attachments: attachment.Contents(attachments),
+		Options:     openai.AnalysisOptions{DetailLevel: "standard"},
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	ctx, cancel := context.WithTimeout(openai.WithUsageScope(ctx, userID, emailID), 60*time.Second)
 	defer cancel()
 
 	result, err := h.openaiService.AnalyzeEmail(ctx, req)
@@ -581,19 +732,39 @@ func (h *EmailHandler) runCreateCaseFromEmail(ctx context.Context, logger *zerol
 		return
 	}
 
-	userUUID, _ := uuid.Parse(userID)
-	cs := analysisResultToCase(userUUID, subject, result)
+	// 保存分析結果（即使後續建立案件失敗，分析仍可在 GET /emails/:id/analysis 查看）
+	if _, err := analysis.Record(h.db, email.ID, userUUID, result); err != nil {
+		logger.Error().Err(err).Str("email_id", emailID).Msg("Failed to save AI analysis")
+	}
+
+	// 既有合作的後續往來（同郵件串、同聯絡人或 AI 高信心判斷）：歸入既有案件而不是重複建立；只有同品牌時仍依要求建立案件
+	if h.caseMatcher != nil {
+		match, err := h.caseMatcher.Match(ctx, email, userUUID, result.ExtractedInfo.BrandName)
+		if err != nil {
+			logger.Warn().Err(err).Str("email_id", emailID).Msg("Failed to match email to existing cases")
+		} else if match != nil && match.Link {
+			if err := casematch.Apply(h.db, email, match); err != nil {
+				logger.Error().Err(err).Str("email_id", emailID).Msg("Failed to link email to existing case")
+				return
+			}
+			logger.Info().
+				Str("email_id", emailID).
+				Str("case_id", match.CaseID.String()).
+				Str("signal", string(match.Signal)).
+				Msg("Email linked to existing case instead of creating a new one")
+			return
+		}
+	}
+
+	cs := analysis.CaseFromResult(userUUID, subject, result)
+	attachment.AppendToDescription(cs, attachments)
 
 	if err := h.db.Create(cs).Error; err != nil {
 		logger.Error().Err(err).Str("email_id", emailID).Msg("Failed to create case")
 		return
 	}
 
-	updates := map[string]interface{}{
-		"case_id":     cs.ID,
-		"ai_analyzed": true,
-	}
-	if err := h.db.Model(email).Updates(updates).Error; err != nil {
+	if err := h.db.Model(email).Update("case_id", cs.ID).Error; err != nil {
 		logger.Error().Err(err).Str("email_id", emailID).Msg("Failed to link email to case")
 		return
 	}
@@ -713,7 +884,8 @@ func (h *EmailHandler) applyWorkflowToCase(logger *zerolog.Logger, cs *models.Ca
 		Msg("Auto-applied workflow phases to case")
 }
 
-// runUpdateCaseFromReply 根據寄出的回信，在背景執行 AI 分析並更新案件狀態與進度
+// runUpdateCaseFromReply 根據寄出的回信，在背景執行 AI 分析並提出案件狀態與進度的更新建議
+// （待使用者確認，或依設定自動套用）
 func (h *EmailHandler) runUpdateCaseFromReply(ctx context.Context, logger *zerolog.Logger, emailID string, caseID *uuid.UUID, email *models.Email, replyBody string) {
 	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
@@ -728,7 +900,7 @@ func (h *EmailHandler) runUpdateCaseFromReply(ctx context.Context, logger *zerol
 		return
 	}
 
-	emailBody := emailBodyForAnalysis(email)
+	emailBody := analysis.EmailBody(email)
 	emailSubject := ""
 	if email.Subject != nil {
 		emailSubject = *email.Subject
@@ -767,144 +939,30 @@ func (h *EmailHandler) runUpdateCaseFromReply(ctx context.Context, logger *zerol
 		CaseDeadline:     deadlineStr,
 	}
 
+	ctx = openai.WithUsageScope(ctx, cs.UserID.String(), emailID)
 	result, err := h.openaiService.AnalyzeReplyForCaseUpdate(ctx, req)
 	if err != nil {
 		logger.Error().Err(err).Str("email_id", emailID).Str("case_id", caseID.String()).Msg("AI reply analysis failed")
 		return
 	}
 
-	if !result.ShouldUpdate {
-		return
-	}
-
-	updates := make(map[string]interface{})
-
-	if result.Status != "" && result.Status != string(cs.Status) {
-		updates["status"] = result.Status
+	var sourceEmailID *uuid.UUID
+	if id, err := uuid.Parse(emailID); err == nil {
+		sourceEmailID = &id
 	}
-	if result.NotesProgress != "" {
-		newNotes := notes
-		if newNotes != "" {
-			newNotes += "\n\n"
-		}
-		newNotes += fmt.Sprintf("[%s] %s", time.Now().Format("2006-01-02 15:04"), result.NotesProgress)
-		updates["notes"] = newNotes
-	}
-	if result.DescriptionUpdate != "" {
-		updates["description"] = result.DescriptionUpdate
-	}
-	if result.QuotedAmount != nil {
-		updates["quoted_amount"] = *result.QuotedAmount
-	}
-	if result.FinalAmount != nil {
-		updates["final_amount"] = *result.FinalAmount
-	}
-	if result.DeadlineDate != "" {
-		if t, err := time.Parse("2006-01-02", result.DeadlineDate); err == nil {
-			updates["deadline_date"] = t
-		}
-	}
-
-	if len(updates) == 0 {
+	suggestion, err := h.caseUpdates.Propose(&cs, models.CaseUpdateSourceReply, sourceEmailID, result)
+	if err != nil {
+		logger.Error().Err(err).Str("case_id", caseID.String()).Msg("Failed to save case update suggestion from reply")
 		return
 	}
-
-	if err := h.db.Model(&cs).Updates(updates).Error; err != nil {
-		logger.Error().Err(err).Str("case_id", caseID.String()).Msg("Failed to update case from reply")
+	if suggestion == nil {
 		return
 	}
 
 	logger.Info().
 		Str("email_id", emailID).
 		Str("case_id", caseID.String()).
-		Interface("updates", updates).
-		Msg("Case updated from reply analysis")
-}
-
-// emailBodyForAnalysis 取得用於 AI 分析的郵件內文（純文字）
-func emailBodyForAnalysis(e *models.Email) string {
-	if e.BodyText != nil && strings.TrimSpace(*e.BodyText) != "" {
-		return *e.BodyText
-	}
-	if e.Snippet != nil && *e.Snippet != "" {
-		return *e.Snippet
-	}
-	if e.BodyHTML != nil && *e.BodyHTML != "" {
-		return stripHTML(*e.BodyHTML)
-	}
-	return ""
-}
-
-var htmlTagRe = regexp.MustCompile(`(?s)<[^>]*>`)
-
-func stripHTML(s string) string {
-	return strings.TrimSpace(htmlTagRe.ReplaceAllString(s, " "))
-}
-
-// analysisResultToCase 將 AI 分析結果轉為 Case 模型
-func analysisResultToCase(userID uuid.UUID, subject string, result *openai.EmailAnalysisResult) *models.Case {
-	// 判斷是否為合作相關案件
-	isCollaboration := result.Classification.Category != "" && openai.IsCollaborationRelated(result.Classification.Category)
-
-	var title, brandName string
-	var status models.CaseStatus
-
-	if isCollaboration {
-		title = subject
-		if title == "" {
-			title = result.Summary
-		}
-		if title == "" {
-			title = "未命名案件"
-		}
-		brandName = result.ExtractedInfo.BrandName
-		if brandName == "" {
-			brandName = "未知品牌"
-		}
-		status = models.CaseStatusToConfirm
-	} else {
-		// 非合作案件：標題用郵件主旨，不填品牌/報價/截止日等
-		title = subject
-		if title == "" {
-			title = "未命名案件"
-		}
-		brandName = "—" // DB 必填，用佔位符
-		status = models.CaseStatusOther
-	}
-
-	cs := &models.Case{
-		UserID:    userID,
-		Title:     title,
-		BrandName: brandName,
-		Status:    status,
-	}
-
-	if isCollaboration {
-		if result.ExtractedInfo.ContentType != "" {
-			cs.CollaborationType = &result.ExtractedInfo.ContentType
-		}
-		cs.QuotedAmount = result.ExtractedInfo.Amount
-		if result.ExtractedInfo.Currency != "" {
-			cs.Currency = &result.ExtractedInfo.Currency
-		}
-		cs.DeadlineDate = result.ExtractedInfo.DueDate
-		if result.ExtractedInfo.ContactName != "" {
-			cs.ContactName = &result.ExtractedInfo.ContactName
-		}
-		if result.ExtractedInfo.ContactEmail != "" {
-			cs.ContactEmail = &result.ExtractedInfo.ContactEmail
-		}
-		if result.ExtractedInfo.ContactPhone != "" {
-			cs.ContactPhone = &result.ExtractedInfo.ContactPhone
-		}
-		if result.Summary != "" {
-			cs.Description = &result.Summary
-		} else if result.ExtractedInfo.ProjectDetails != "" {
-			cs.Description = &result.ExtractedInfo.ProjectDetails
-		}
-	} else if result.Summary != "" {
-		// 非合作案件僅保留摘要作為說明
-		cs.Description = &result.Summary
-	}
-	return cs
+		Str("suggestion_id", suggestion.ID.String()).
+		Str("status", string(suggestion.Status)).
+		Msg("Case update suggested from reply analysis")
 }
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/analysis"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
 	"github.com/designcomb/influenter-backend/internal/utils"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -195,6 +197,272 @@ func TestListEmails_Pagination(t *testing.T) {
 	assert.Equal(t, float64(2), pagination["page_size"])
 }
 
+// TestListEmails_HidesCrossAccountDuplicates 測試同一封信寄到兩個帳號時，統一收件匣只顯示一筆
+func TestListEmails_HidesCrossAccountDuplicates(t *testing.T) {
+	db, router, cfg := setupTestRouter(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		if sqlDB != nil {
+			sqlDB.Close()
+		}
+	}()
+
+	userID, token, _ := createTestUser(t, db, cfg)
+	account1 := createTestOAuthAccount(t, db, userID)
+	account2 := createTestOAuthAccount(t, db, userID)
+
+	// 兩個帳號收到同一封信（相同 RFC Message-ID，provider message ID 也可能相同）
+	rfcID := models.NormalizeMessageID("<Same-Message@example.com>")
+	email1 := createTestEmail(t, db, account1.ID)
+	db.Model(email1).Updates(map[string]interface{}{"rfc_message_id": *rfcID, "provider_message_id": "shared-id"})
+
+	subject := "Test Subject"
+	email2 := &models.Email{
+		OAuthAccountID:    account2.ID,
+		ProviderMessageID: "shared-id",
+		RFCMessageID:      rfcID,
+		FromEmail:         "sender@example.com",
+		Subject:           &subject,
+		ReceivedAt:        time.Now(),
+	}
+	assert.NoError(t, email2.ResolveDuplicate(db, userID))
+	assert.NotNil(t, email2.DuplicateOfID)
+	assert.Equal(t, email1.ID, *email2.DuplicateOfID)
+	assert.NoError(t, db.Create(email2).Error)
+
+	// 預設只回傳一筆
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/v1/emails?page=1&page_size=20", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response["emails"].([]interface{}), 1)
+
+	// include_duplicates=true 時兩筆都回傳
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/api/v1/emails?page=1&page_size=20&include_duplicates=true", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response["emails"].([]interface{}), 2)
+
+	// 指定帳號時顯示該帳號的那筆
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/api/v1/emails?page=1&page_size=20&oauth_account_id="+account2.ID.String(), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response["emails"].([]interface{}), 1)
+}
+
+// TestListEmails_DuplicatesBackfilledAndPromoted 測試補齊 Message-ID 後標記重複，且主要郵件刪除後由其他帳號的同一封信接手顯示
+func TestListEmails_DuplicatesBackfilledAndPromoted(t *testing.T) {
+	db, router, cfg := setupTestRouter(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		if sqlDB != nil {
+			sqlDB.Close()
+		}
+	}()
+
+	userID, token, _ := createTestUser(t, db, cfg)
+	var emails []*models.Email
+	for i := 0; i < 3; i++ {
+		email := createTestEmail(t, db, createTestOAuthAccount(t, db, userID).ID)
+		db.Model(email).Updates(map[string]interface{}{
+			"rfc_message_id": "same-message@example.com",
+			"received_at":    time.Now().Add(time.Duration(i-3) * time.Minute),
+		})
+		emails = append(emails, email)
+	}
+	list := func() []interface{} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/emails?page=1&page_size=20", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response["emails"].([]interface{})
+	}
+	duplicateOf := func(id uuid.UUID) *uuid.UUID {
+		var e models.Email
+		assert.NoError(t, db.Unscoped().First(&e, "id = ?", id).Error)
+		return e.DuplicateOfID
+	}
+
+	// 既有郵件補齊 rfc_message_id 後重新標記：最早的一筆為主要郵件
+	updated, err := models.DedupeByMessageID(db, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), updated)
+	assert.Len(t, list(), 1)
+
+	// 主要郵件刪除後，次早的一筆接手，其餘改指向它
+	assert.NoError(t, db.Delete(emails[0]).Error)
+	assert.NoError(t, models.PromoteDuplicates(db, []uuid.UUID{emails[0].ID}))
+	shown := list()
+	assert.Len(t, shown, 1)
+	assert.Equal(t, emails[1].ID.String(), shown[0].(map[string]interface{})["id"])
+	assert.Nil(t, duplicateOf(emails[1].ID))
+	assert.Equal(t, emails[1].ID, *duplicateOf(emails[2].ID))
+}
+
+// TestEmailAnalysis_StoredAndFilterable 測試取得最新版本的 AI 分析，並依分類、優先級、是否需要行動篩選與排序郵件
+func TestEmailAnalysis_StoredAndFilterable(t *testing.T) {
+	db, router, cfg := setupTestRouter(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		if sqlDB != nil {
+			sqlDB.Close()
+		}
+	}()
+
+	userID, token, _ := createTestUser(t, db, cfg)
+	account := createTestOAuthAccount(t, db, userID)
+	invite := createTestEmail(t, db, account.ID)
+	newsletter := createTestEmail(t, db, account.ID)
+	createTestEmail(t, db, account.ID) // 未分析
+
+	amount := 30000.0
+	_, err := analysis.Record(db, invite.ID, userID, &openai.EmailAnalysisResult{
+		Classification: openai.EmailClassification{Category: openai.CategoryInquiry, Confidence: 0.6},
+		Priority:       "medium",
+		Model:          "gpt-4o-mini",
+		AnalyzedAt:     time.Now(),
+	})
+	assert.NoError(t, err)
+	_, err = analysis.Record(db, invite.ID, userID, &openai.EmailAnalysisResult{
+		Classification: openai.EmailClassification{Category: openai.CategoryCollaboration, Confidence: 0.9},
+		ExtractedInfo:  openai.ExtractedInfo{BrandName: "好品牌", Amount: &amount, Currency: "TWD"},
+		Summary:        "收到來自 好品牌 的合作邀約",
+		ActionRequired: true,
+		Priority:       "high",
+		Model:          "gpt-4o-mini",
+		AnalyzedAt:     time.Now(),
+	})
+	assert.NoError(t, err)
+	_, err = analysis.Record(db, newsletter.ID, userID, &openai.EmailAnalysisResult{
+		Classification: openai.EmailClassification{Category: openai.CategoryNewsletter, Confidence: 0.95},
+		Priority:       "low",
+		Model:          "gpt-4o-mini",
+		AnalyzedAt:     time.Now(),
+	})
+	assert.NoError(t, err)
+
+	get := func(path string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		var response map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	// 預設回傳最新版本
+	code, body := get("/api/v1/emails/" + invite.ID.String() + "/analysis")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, float64(2), body["version"])
+	assert.Equal(t, float64(2), body["latest_version"])
+	assert.Equal(t, "collaboration", body["category"])
+	assert.Equal(t, openai.AnalysisPromptVersion, body["prompt_version"])
+	assert.Equal(t, "好品牌", body["extracted_info"].(map[string]interface{})["brand_name"])
+
+	code, body = get("/api/v1/emails/" + invite.ID.String() + "/analysis?version=1")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "inquiry", body["category"])
+
+	code, _ = get("/api/v1/emails/" + uuid.New().String() + "/analysis")
+	assert.Equal(t, 404, code)
+
+	// 依分類、優先級、是否需要行動篩選
+	code, body = get("/api/v1/emails?page=1&page_size=20&action_required=true")
+	assert.Equal(t, 200, code)
+	emails := body["emails"].([]interface{})
+	if assert.Len(t, emails, 1) {
+		assert.Equal(t, invite.ID.String(), emails[0].(map[string]interface{})["id"])
+		assert.Equal(t, "high", emails[0].(map[string]interface{})["priority"])
+	}
+
+	code, body = get("/api/v1/emails?page=1&page_size=20&category=newsletter")
+	assert.Equal(t, 200, code)
+	assert.Len(t, body["emails"].([]interface{}), 1)
+
+	// 依優先級排序：high → low → 未分析
+	code, body = get("/api/v1/emails?page=1&page_size=20&sort_by=priority&sort_order=desc")
+	assert.Equal(t, 200, code)
+	emails = body["emails"].([]interface{})
+	if assert.Len(t, emails, 3) {
+		assert.Equal(t, invite.ID.String(), emails[0].(map[string]interface{})["id"])
+		assert.Equal(t, newsletter.ID.String(), emails[1].(map[string]interface{})["id"])
+	}
+
+	code, _ = get("/api/v1/emails?page=1&page_size=20&sort_by=subject")
+	assert.Equal(t, 400, code)
+}
+
+// TestSubmitAnalysisFeedback 測試使用者修正 AI 分析的分類與擷取欄位後記錄回饋
+func TestSubmitAnalysisFeedback(t *testing.T) {
+	db, router, cfg := setupTestRouter(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		if sqlDB != nil {
+			sqlDB.Close()
+		}
+	}()
+
+	userID, token, _ := createTestUser(t, db, cfg)
+	account := createTestOAuthAccount(t, db, userID)
+	email := createTestEmail(t, db, account.ID)
+	unanalyzed := createTestEmail(t, db, account.ID)
+
+	_, err := analysis.Record(db, email.ID, userID, &openai.EmailAnalysisResult{
+		Classification: openai.EmailClassification{Category: openai.CategoryNewsletter, Confidence: 0.7},
+		ExtractedInfo:  openai.ExtractedInfo{BrandName: "Glow Ltd"},
+		Model:          "gpt-4o-mini",
+		AnalyzedAt:     time.Now(),
+	})
+	assert.NoError(t, err)
+
+	post := func(id uuid.UUID, body string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/emails/"+id.String()+"/analysis/feedback", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		var response map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	code, body := post(email.ID, `{"category":"collaboration","fields":{"brand_name":"Glow 台灣"}}`)
+	assert.Equal(t, 200, code)
+	if rows, ok := body["feedback"].([]interface{}); assert.True(t, ok) && assert.Len(t, rows, 2) {
+		category := rows[0].(map[string]interface{})
+		assert.Equal(t, "category", category["field"])
+		assert.Equal(t, "newsletter", category["predicted"])
+		assert.Equal(t, false, category["correct"])
+	}
+
+	var count int64
+	db.Model(&models.AIFeedback{}).Where("email_id = ? AND correct = ?", email.ID, false).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	code, _ = post(email.ID, `{"fields":{"price":"1"}}`)
+	assert.Equal(t, 400, code)
+	code, _ = post(email.ID, `{}`)
+	assert.Equal(t, 400, code)
+	code, _ = post(unanalyzed.ID, `{"category":"spam"}`)
+	assert.Equal(t, 404, code)
+}
+
 // TestListEmails_EmptyResult 測試空結果
 func TestListEmails_EmptyResult(t *testing.T) {
 	db, router, cfg := setupTestRouter(t)
@@ -222,6 +490,62 @@ func TestListEmails_EmptyResult(t *testing.T) {
 	assert.Equal(t, 0, len(emails))
 }
 
+// TestSnoozeEmail_HiddenUntilUnsnoozed 測試延後處理的郵件在取消延後前不會出現在列表中
+func TestSnoozeEmail_HiddenUntilUnsnoozed(t *testing.T) {
+	db, router, cfg := setupTestRouter(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		if sqlDB != nil {
+			sqlDB.Close()
+		}
+	}()
+
+	userID, token, _ := createTestUser(t, db, cfg)
+	account := createTestOAuthAccount(t, db, userID)
+	email := createTestEmail(t, db, account.ID)
+	createTestEmail(t, db, account.ID)
+
+	listCount := func(query string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/v1/emails?page=1&page_size=20"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return len(response["emails"].([]interface{}))
+	}
+
+	// 過去的時間不接受
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/v1/emails/"+email.ID.String()+"/snooze",
+		bytes.NewBufferString(`{"until":"2000-01-01T00:00:00Z"}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+
+	until := time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/api/v1/emails/"+email.ID.String()+"/snooze",
+		bytes.NewBufferString(`{"until":"`+until+`"}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	assert.Equal(t, 1, listCount(""))
+	assert.Equal(t, 2, listCount("&include_snoozed=true"))
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("DELETE", "/api/v1/emails/"+email.ID.String()+"/snooze", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	assert.Equal(t, 2, listCount(""))
+}
+
 // TestGetEmail_Success 測試成功獲取郵件詳情
 func TestGetEmail_Success(t *testing.T) {
 	db, router, cfg := setupTestRouter(t)
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/followup"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FollowUpHandler 寄出郵件未回覆追蹤處理器
+type FollowUpHandler struct {
+	db  *gorm.DB
+	svc *followup.Service
+}
+
+// NewFollowUpHandler 建立未回覆追蹤處理器
+func NewFollowUpHandler(db *gorm.DB, svc *followup.Service) *FollowUpHandler {
+	return &FollowUpHandler{db: db, svc: svc}
+}
+
+// FollowUpResponse 跟進項目
+type FollowUpResponse struct {
+	ID           string     `json:"id"`
+	Status       string     `json:"status"`
+	CaseID       string     `json:"case_id"`
+	CaseTitle    string     `json:"case_title"`
+	BrandName    string     `json:"brand_name"`
+	EmailID      string     `json:"email_id"` // 寄出的郵件（回覆此郵件即寄出跟進信）
+	Subject      *string    `json:"subject,omitempty"`
+	ToEmail      *string    `json:"to_email,omitempty"`
+	SentAt       time.Time  `json:"sent_at"`
+	DueAt        time.Time  `json:"due_at"`
+	RepliedAt    *time.Time `json:"replied_at,omitempty"`
+	ReplyEmailID *string    `json:"reply_email_id,omitempty"`
+	Draft        *string    `json:"draft,omitempty"`
+	DraftedAt    *time.Time `json:"drafted_at,omitempty"`
+}
+
+// followUpRow 跟進項目查詢結果（含案件與郵件資訊）
+type followUpRow struct {
+	models.FollowUp
+	CaseTitle string
+	BrandName string
+	Subject   *string
+	ToEmail   *string
+}
+
+func followUpToResponse(r *followUpRow) FollowUpResponse {
+	resp := FollowUpResponse{
+		ID:        r.ID.String(),
+		Status:    string(r.Status),
+		CaseID:    r.CaseID.String(),
+		CaseTitle: r.CaseTitle,
+		BrandName: r.BrandName,
+		EmailID:   r.EmailID.String(),
+		Subject:   r.Subject,
+		ToEmail:   r.ToEmail,
+		SentAt:    r.SentAt,
+		DueAt:     r.DueAt,
+		RepliedAt: r.RepliedAt,
+		Draft:     r.Draft,
+		DraftedAt: r.DraftedAt,
+	}
+	if r.ReplyEmailID != nil {
+		s := r.ReplyEmailID.String()
+		resp.ReplyEmailID = &s
+	}
+	return resp
+}
+
+// followUpQuery 使用者的跟進項目（排除已刪除的案件與郵件）
+func (h *FollowUpHandler) followUpQuery(userID string) *gorm.DB {
+	return h.db.Table("follow_ups").
+		Select("follow_ups.*, cases.title AS case_title, cases.brand_name, emails.subject, emails.to_email").
+		Joins("JOIN cases ON cases.id = follow_ups.case_id AND cases.deleted_at IS NULL").
+		Joins("JOIN emails ON emails.id = follow_ups.email_id AND emails.deleted_at IS NULL").
+		Where("follow_ups.user_id = ?", userID)
+}
+
+// ListFollowUps 列出需要跟進的寄出郵件
+// @Summary      列出未回覆的寄出郵件
+// @Description  案件相關的寄出郵件在設定的工作天數內沒有收到回覆時會出現在這裡（status=due）。回覆 email_id 即可寄出跟進信
+// @Tags         Follow-ups
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status   query     string  false  "due（預設）| waiting | open（due + waiting）| replied | followed_up | dismissed | all"
+// @Param        case_id  query     string  false  "案件 ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /follow-ups [get]
+func (h *FollowUpHandler) ListFollowUps(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	query := h.followUpQuery(userID)
+	switch status := c.DefaultQuery("status", "due"); status {
+	case "all":
+	case "open":
+		query = query.Where("follow_ups.status IN ?", []models.FollowUpStatus{models.FollowUpStatusWaiting, models.FollowUpStatusDue})
+	case string(models.FollowUpStatusDue), string(models.FollowUpStatusWaiting), string(models.FollowUpStatusReplied),
+		string(models.FollowUpStatusFollowedUp), string(models.FollowUpStatusDismissed):
+		query = query.Where("follow_ups.status = ?", status)
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_status", Message: "Invalid status"})
+		return
+	}
+
+	if caseID := c.Query("case_id"); caseID != "" {
+		id, err := uuid.Parse(caseID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_case_id", Message: "Invalid case ID"})
+			return
+		}
+		query = query.Where("follow_ups.case_id = ?", id)
+	}
+
+	var rows []followUpRow
+	if err := query.Order("follow_ups.due_at ASC").Limit(200).Scan(&rows).Error; err != nil {
+		logger.Error().Err(err).Msg("Failed to list follow-ups")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to list follow-ups"})
+		return
+	}
+
+	data := make([]FollowUpResponse, 0, len(rows))
+	for i := range rows {
+		data = append(data, followUpToResponse(&rows[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// findFollowUp 取得使用者的跟進項目
+func (h *FollowUpHandler) findFollowUp(c *gin.Context) (*followUpRow, bool) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_id", Message: "Invalid follow-up ID"})
+		return nil, false
+	}
+
+	var rows []followUpRow
+	if err := h.followUpQuery(userID).Where("follow_ups.id = ?", id).Limit(1).Scan(&rows).Error; err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch follow-up")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch follow-up"})
+		return nil, false
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "follow_up_not_found", Message: "Follow-up not found"})
+		return nil, false
+	}
+	return &rows[0], true
+}
+
+// DraftFollowUp 產生（或重新產生）跟進信草稿
+// @Summary      產生跟進信草稿
+// @Description  以與擬回信相同的 AI 流程產生禮貌的跟進信，並儲存在跟進項目上
+// @Tags         Follow-ups
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Follow-up ID"
+// @Success      200  {object}  FollowUpResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Router       /follow-ups/{id}/draft [post]
+func (h *FollowUpHandler) DraftFollowUp(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+
+	row, ok := h.findFollowUp(c)
+	if !ok {
+		return
+	}
+	if !row.IsOpen() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "follow_up_closed", Message: "此郵件已不需要跟進"})
+		return
+	}
+
+	if _, err := h.svc.GenerateDraft(c.Request.Context(), &row.FollowUp); err != nil {
+		if errors.Is(err, followup.ErrDraftingUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "openai_unavailable", Message: "AI 服務未設定"})
+			return
+		}
+		if aiLimitError(c, err) {
+			return
+		}
+		logger.Error().Err(err).Str("follow_up_id", row.ID.String()).Msg("Failed to draft follow-up")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "draft_failed", Message: "產生草稿失敗，請稍後再試"})
+		return
+	}
+
+	c.JSON(http.StatusOK, followUpToResponse(row))
+}
+
+// DismissFollowUp 略過跟進項目
+// @Summary      略過跟進
+// @Tags         Follow-ups
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Follow-up ID"
+// @Success      200  {object}  FollowUpResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /follow-ups/{id}/dismiss [post]
+func (h *FollowUpHandler) DismissFollowUp(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+
+	row, ok := h.findFollowUp(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Model(&models.FollowUp{}).Where("id = ?", row.ID).Update("status", models.FollowUpStatusDismissed).Error; err != nil {
+		logger.Error().Err(err).Str("follow_up_id", row.ID.String()).Msg("Failed to dismiss follow-up")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to update follow-up"})
+		return
+	}
+	row.Status = models.FollowUpStatusDismissed
+
+	c.JSON(http.StatusOK, followUpToResponse(row))
+}
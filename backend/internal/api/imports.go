@@ -0,0 +1,186 @@
+package api
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/mailimport"
+	"github.com/designcomb/influenter-backend/internal/workers"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+// maxMailImportUploadSize 匯入檔案大小上限（500MB）
+const maxMailImportUploadSize = 500 << 20
+
+// ImportHandler 郵件匯入處理器
+type ImportHandler struct {
+	db    *gorm.DB
+	cfg   config.ImportConfig
+	queue *asynq.Client
+}
+
+// NewImportHandler 建立郵件匯入處理器（匯入由 worker 的 mail:import 任務執行）
+func NewImportHandler(db *gorm.DB, cfg config.ImportConfig, queue *asynq.Client) *ImportHandler {
+	return &ImportHandler{db: db, cfg: cfg, queue: queue}
+}
+
+// ImportMail 上傳 .eml / .mbox / zip 並排入背景匯入
+// @Summary      匯入郵件
+// @Description  上傳 .eml、.mbox 或包含它們的 zip，於背景解析並匯入到「匯入」帳號。回傳匯入工作，可用 GET /imports/mail/:id 查詢進度
+// @Tags         Imports
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        file  formData  file  true  "郵件檔案（.eml / .mbox / .zip）"
+// @Success      202  {object}  models.MailImport
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      413  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /imports/mail [post]
+func (h *ImportHandler) ImportMail(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized", Message: "user_id required"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxMailImportUploadSize)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: "file_too_large", Message: "File exceeds 500MB limit"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "file is required"})
+		return
+	}
+
+	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
+	if ext != ".eml" && ext != ".mbox" && ext != ".mbx" && ext != ".zip" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "unsupported_file", Message: "Only .eml, .mbox and .zip files are supported"})
+		return
+	}
+
+	account, err := mailimport.EnsureImportAccount(h.db, uid)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to ensure import account")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to start import"})
+		return
+	}
+
+	// 存到與 worker 共用的目錄，匯入結束（或逾時清理）後刪除
+	record := models.MailImport{
+		ID:             uuid.New(),
+		UserID:         uid,
+		OAuthAccountID: account.ID,
+		Filename:       filepath.Base(fileHeader.Filename),
+		Status:         models.MailImportStatusPending,
+	}
+	record.StoragePath = mailimport.UploadPath(h.cfg.UploadDir, record.ID, fileHeader.Filename)
+	if err := c.SaveUploadedFile(fileHeader, record.StoragePath); err != nil {
+		logger.Error().Err(err).Msg("Failed to save uploaded mail file")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "internal_error", Message: "Failed to store upload"})
+		return
+	}
+
+	if err := h.db.Create(&record).Error; err != nil {
+		logger.Error().Err(err).Msg("Failed to create mail import")
+		if err := mailimport.RemoveUpload(&record); err != nil {
+			logger.Warn().Err(err).Str("path", record.StoragePath).Msg("Failed to remove mail import upload")
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to start import"})
+		return
+	}
+
+	if err := workers.EnqueueMailImport(h.queue, record.ID.String()); err != nil {
+		logger.Error().Err(err).Str("import_id", record.ID.String()).Msg("Failed to enqueue mail import")
+		msg := "failed to queue import"
+		h.db.Model(&record).Updates(map[string]interface{}{"status": models.MailImportStatusFailed, "error_message": &msg})
+		if err := mailimport.RemoveUpload(&record); err != nil {
+			logger.Warn().Err(err).Str("path", record.StoragePath).Msg("Failed to remove mail import upload")
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "queue_error", Message: "Failed to start import"})
+		return
+	}
+
+	logger.Info().
+		Str("import_id", record.ID.String()).
+		Str("filename", record.Filename).
+		Int64("size", fileHeader.Size).
+		Msg("Mail import queued")
+
+	c.JSON(http.StatusAccepted, record)
+}
+
+// ListMailImports 列出最近的匯入工作
+// @Summary      列出郵件匯入
+// @Tags         Imports
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /imports/mail [get]
+func (h *ImportHandler) ListMailImports(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	var imports []models.MailImport
+	if err := h.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(50).
+		Find(&imports).Error; err != nil {
+		logger.Error().Err(err).Msg("Failed to list mail imports")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to list imports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": imports})
+}
+
+// GetMailImport 取得匯入進度
+// @Summary      取得郵件匯入進度
+// @Tags         Imports
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Import ID"
+// @Success      200  {object}  models.MailImport
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /imports/mail/{id} [get]
+func (h *ImportHandler) GetMailImport(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_id", Message: "Invalid import ID"})
+		return
+	}
+
+	var record models.MailImport
+	if err := h.db.Where("id = ? AND user_id = ?", id, userID).First(&record).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "import_not_found", Message: "Import not found"})
+			return
+		}
+		logger.Error().Err(err).Msg("Failed to fetch mail import")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch import"})
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
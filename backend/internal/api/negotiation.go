@@ -0,0 +1,244 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/analysis"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/designcomb/influenter-backend/internal/services/pricing"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RateAdviceRequest 議價建議請求
+type RateAdviceRequest struct {
+	EmailID       string   `json:"email_id"`       // 提及預算的郵件 ID，可選；未傳則用案件來信中最近一次抽取到的預算
+	OfferedAmount *float64 `json:"offered_amount"` // 直接指定對方提出的金額，可選
+	WithDraft     bool     `json:"with_draft"`     // 是否同時產生回覆（還價）草稿
+}
+
+// RateAdviceResponse 議價建議回應
+type RateAdviceResponse struct {
+	Reference      *pricing.Reference         `json:"reference"` // 定價與歷史成交依據
+	Recommendation *openai.RateRecommendation `json:"recommendation"`
+	Draft          *DraftVariantResponse      `json:"draft,omitempty"`
+}
+
+// RateAdvice 依創作者定價與歷史成交紀錄，建議如何回應品牌提出的預算
+// @Summary      議價建議
+// @Description  比對品牌提出的預算（AI 抽取的金額 / 預算範圍，或直接指定）與案件合作項目定價、同品牌與類似案件的歷史成交金額，建議接受、還價（附金額）或提出組合方案。with_draft 為 true 時同時產生並保存回覆草稿
+// @Tags         Cases
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string             true   "案件 ID"
+// @Param        request  body      RateAdviceRequest  false  "議價設定"
+// @Success      200      {object}  RateAdviceResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      429      {object}  ErrorResponse
+// @Failure      503      {object}  ErrorResponse
+// @Router       /cases/{id}/rate-advice [post]
+func (h *CaseHandler) RateAdvice(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+	caseID := c.Param("id")
+
+	if h.openaiService == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "openai_unavailable", Message: "AI 服務未設定"})
+		return
+	}
+
+	id, err := uuid.Parse(caseID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_id", Message: "Invalid case ID"})
+		return
+	}
+	var body RateAdviceRequest
+	if err := c.ShouldBindJSON(&body); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+	var emailID *uuid.UUID
+	if body.EmailID != "" {
+		parsed, err := uuid.Parse(body.EmailID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_email_id", Message: "Invalid email ID"})
+			return
+		}
+		emailID = &parsed
+	}
+	if body.OfferedAmount != nil && *body.OfferedAmount <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "offered_amount must be positive"})
+		return
+	}
+
+	var cs models.Case
+	if err := h.db.Where("id = ? AND user_id = ?", id, userID).First(&cs).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "case_not_found", Message: "Case not found"})
+			return
+		}
+		logger.Error().Err(err).Str("case_id", caseID).Msg("Failed to fetch case")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch case"})
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	offer := &pricing.Offer{Amount: body.OfferedAmount, EmailID: emailID}
+	if body.OfferedAmount == nil {
+		offer, err = h.pricing.LatestOffer(userUUID, id, emailID)
+		if err != nil {
+			logger.Error().Err(err).Str("case_id", caseID).Msg("Failed to find brand offer")
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to find brand offer"})
+			return
+		}
+		if offer == nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "no_offer", Message: "來信中尚未找到對方的預算，請提供 offered_amount"})
+			return
+		}
+	}
+
+	ref, err := h.pricing.Reference(&cs, offer)
+	if err != nil {
+		logger.Error().Err(err).Str("case_id", caseID).Msg("Failed to build pricing reference")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to build pricing reference"})
+		return
+	}
+
+	email, err := h.offerEmail(userUUID, id, offer.EmailID)
+	if err != nil {
+		logger.Error().Err(err).Str("case_id", caseID).Msg("Failed to fetch email")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch email"})
+		return
+	}
+	subject, emailBody, scopeEmailID := "", "", ""
+	if email != nil {
+		if email.Subject != nil {
+			subject = *email.Subject
+		}
+		emailBody = analysis.EmailBody(email)
+		scopeEmailID = email.ID.String()
+	}
+
+	ctx := openai.WithUsageScope(c.Request.Context(), userID, scopeEmailID)
+	rec, err := h.openaiService.NegotiateRate(ctx, ref.Request(&cs, subject, emailBody))
+	if err != nil {
+		if aiLimitError(c, err) {
+			return
+		}
+		logger.Error().Err(err).Str("case_id", caseID).Msg("NegotiateRate failed")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "rate_advice_failed", Message: "產生議價建議失敗，請稍後再試"})
+		return
+	}
+
+	resp := RateAdviceResponse{Reference: ref, Recommendation: rec}
+	if body.WithDraft {
+		resp.Draft = h.counterOfferDraft(c, &cs, email, ref, rec)
+	}
+
+	logger.Info().
+		Str("case_id", caseID).
+		Str("action", rec.Action).
+		Str("baseline", ref.BaselineAction).
+		Int("history", ref.HistoryCount).
+		Msg("Rate advice generated")
+	c.JSON(http.StatusOK, resp)
+}
+
+// offerEmail 提及預算的郵件；未指定時用案件最近一封來信（案件沒有郵件時回傳 nil）
+func (h *CaseHandler) offerEmail(userID, caseID uuid.UUID, emailID *uuid.UUID) (*models.Email, error) {
+	query := h.db.Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
+		Where("emails.case_id = ? AND oauth_accounts.user_id = ?", caseID, userID)
+	if emailID != nil {
+		query = query.Where("emails.id = ?", *emailID)
+	} else {
+		query = query.Where("emails.direction = ?", models.EmailDirectionIncoming)
+	}
+
+	var email models.Email
+	if err := query.Order("emails.received_at DESC").First(&email).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &email, nil
+}
+
+// counterOfferDraft 依議價建議產生並保存回覆草稿；失敗時在回應中標示錯誤
+func (h *CaseHandler) counterOfferDraft(c *gin.Context, cs *models.Case, email *models.Email, ref *pricing.Reference, rec *openai.RateRecommendation) *DraftVariantResponse {
+	logger := middleware.GetLogger(c)
+
+	variant := openai.DraftVariant{Stance: openai.DraftStanceNegotiate, Tone: openai.DraftToneFormal}
+	if rec.Action == openai.RateActionAccept {
+		variant = openai.DraftVariant{Stance: openai.DraftStanceAccept, Tone: openai.DraftToneFriendly}
+	}
+	resp := &DraftVariantResponse{Stance: variant.Stance, Tone: variant.Tone}
+	if email == nil {
+		resp.Error = "no_emails"
+		return resp
+	}
+
+	instruction := counterOfferInstruction(ref, rec)
+	userUUID, _ := uuid.Parse(c.GetString("user_id"))
+	d := &draftContext{userID: userUUID, caseID: cs.ID, email: *email, instruction: instruction}
+	d.req = h.draftRequest(c, cs, email, instruction)
+	d.req.Stance, d.req.Tone = variant.Stance, variant.Tone
+
+	ctx := openai.WithUsageScope(c.Request.Context(), userUUID.String(), email.ID.String())
+	result, err := h.openaiService.DraftReply(ctx, d.req)
+	if err != nil {
+		logger.Warn().Err(err).Str("case_id", cs.ID.String()).Msg("Counter-offer draft failed")
+		resp.Error = "draft_failed"
+		return resp
+	}
+
+	resp.Draft, resp.PromptVersion = result.Draft, result.PromptVersion
+	if draft, err := h.saveDraft(d, result, variant, false); err != nil {
+		logger.Error().Err(err).Str("case_id", cs.ID.String()).Msg("Failed to save draft")
+	} else {
+		resp.DraftID = &draft.ID
+	}
+	return resp
+}
+
+// counterOfferInstruction 將議價建議轉成擬信的補充說明
+func counterOfferInstruction(ref *pricing.Reference, rec *openai.RateRecommendation) string {
+	currency := ref.Offer.Currency
+	if currency == "" {
+		currency = "TWD"
+	}
+
+	var b strings.Builder
+	switch rec.Action {
+	case openai.RateActionAccept:
+		b.WriteString("接受對方提出的預算，確認合作內容、檔期與付款方式。")
+	case openai.RateActionCounter:
+		b.WriteString("感謝對方的邀約並表達合作意願，但說明目前預算低於我的行情，")
+		if rec.CounterAmount != nil {
+			fmt.Fprintf(&b, "提出 %s %.0f 的報價。", currency, *rec.CounterAmount)
+		} else {
+			b.WriteString("請對方調整預算。")
+		}
+	case openai.RateActionBundle:
+		b.WriteString("感謝對方的邀約，依對方預算提出以下方案供選擇：")
+		for i, option := range rec.BundleOptions {
+			fmt.Fprintf(&b, "\n%d. %s（%s）：%s %.0f", i+1, option.Title, strings.Join(option.Items, "、"), currency, option.Amount)
+			if option.Description != "" {
+				b.WriteString("，" + option.Description)
+			}
+		}
+	}
+	if len(rec.TalkingPoints) > 0 {
+		b.WriteString("\n可引用的論點：" + strings.Join(rec.TalkingPoints, "；"))
+	}
+	b.WriteString("\n不要透露內部的定價計算或過往其他品牌的成交金額。")
+	return b.String()
+}
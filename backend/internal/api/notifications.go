@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationHandler 站內通知處理器
+type NotificationHandler struct {
+	db *gorm.DB
+}
+
+// NewNotificationHandler 建立站內通知處理器
+func NewNotificationHandler(db *gorm.DB) *NotificationHandler {
+	return &NotificationHandler{db: db}
+}
+
+// ListNotifications 列出站內通知
+// @Summary      列出站內通知
+// @Tags         Notifications
+// @Produce      json
+// @Security     BearerAuth
+// @Param        unread  query     bool  false  "只列出未讀"
+// @Success      200     {object}  map[string]interface{}
+// @Failure      401     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Router       /notifications [get]
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	query := h.db.Where("user_id = ?", userID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC").Limit(100).Find(&notifications).Error; err != nil {
+		logger.Error().Err(err).Msg("Failed to list notifications")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to list notifications"})
+		return
+	}
+
+	var unread int64
+	if err := h.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&unread).Error; err != nil {
+		logger.Error().Err(err).Msg("Failed to count unread notifications")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to list notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": notifications, "unread_count": unread})
+}
+
+// MarkNotificationRead 將通知標為已讀
+// @Summary      將通知標為已讀
+// @Tags         Notifications
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Notification ID"
+// @Success      200  {object}  models.Notification
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /notifications/{id}/read [post]
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_id", Message: "Invalid notification ID"})
+		return
+	}
+
+	var notification models.Notification
+	if err := h.db.Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "notification_not_found", Message: "Notification not found"})
+			return
+		}
+		logger.Error().Err(err).Msg("Failed to fetch notification")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch notification"})
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if err := h.db.Model(&notification).Update("read_at", now).Error; err != nil {
+			logger.Error().Err(err).Msg("Failed to mark notification read")
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to update notification"})
+			return
+		}
+		notification.ReadAt = &now
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
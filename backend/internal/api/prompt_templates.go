@@ -0,0 +1,331 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/aicache"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/designcomb/influenter-backend/internal/services/prompts"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultPromptResponse 內建範本
+type DefaultPromptResponse struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Body    string `json:"body"`
+}
+
+// PromptTemplateListResponse 內建範本與資料庫中的範本版本
+type PromptTemplateListResponse struct {
+	Defaults  []DefaultPromptResponse `json:"defaults"`
+	Templates []models.PromptTemplate `json:"templates"`
+}
+
+// CreatePromptTemplateRequest 新增範本版本請求
+type CreatePromptTemplateRequest struct {
+	Name           string  `json:"name" binding:"required"`
+	Version        string  `json:"version" binding:"required,max=50"`
+	Body           string  `json:"body" binding:"required"`
+	UserID         *string `json:"user_id"`                                           // 指定時為該使用者的個人覆寫
+	RolloutPercent int     `json:"rollout_percent" binding:"omitempty,min=0,max=100"` // 全域版本分流比例
+	Note           *string `json:"note"`
+}
+
+// UpdatePromptTemplateRequest 更新範本版本請求
+type UpdatePromptTemplateRequest struct {
+	Body           *string `json:"body"`
+	RolloutPercent *int    `json:"rollout_percent" binding:"omitempty,min=0,max=100"`
+	Active         *bool   `json:"active"`
+	Note           *string `json:"note"`
+}
+
+// ListPromptTemplates 列出提示詞範本（管理員）
+// @Summary      列出提示詞範本
+// @Description  內建範本內容與資料庫中的全域版本（A/B 分流）及個人覆寫
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        name  query     string  false  "範本名稱"
+// @Success      200   {object}  PromptTemplateListResponse
+// @Failure      403   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /admin/prompts [get]
+func (h *AdminHandler) ListPromptTemplates(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	name := c.Query("name")
+
+	resp := PromptTemplateListResponse{Defaults: []DefaultPromptResponse{}, Templates: []models.PromptTemplate{}}
+	for _, n := range openai.PromptNames {
+		if name != "" && string(n) != name {
+			continue
+		}
+		def, err := openai.DefaultPrompt(n)
+		if err != nil {
+			continue
+		}
+		resp.Defaults = append(resp.Defaults, DefaultPromptResponse{Name: string(def.Name), Version: def.Version, Body: def.Body})
+	}
+
+	query := h.db.Order("name ASC, created_at ASC")
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	if err := query.Find(&resp.Templates).Error; err != nil {
+		logger.Error().Err(err).Msg("Failed to list prompt templates")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to list prompt templates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreatePromptTemplate 新增範本版本（管理員）
+// @Summary      新增提示詞範本版本
+// @Description  全域版本依 rollout_percent 分流到部分使用者（同一範本啟用中的比例合計不可超過 100）；指定 user_id 時為個人覆寫
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      CreatePromptTemplateRequest  true  "範本版本"
+// @Success      201      {object}  models.PromptTemplate
+// @Failure      400      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      409      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /admin/prompts [post]
+func (h *AdminHandler) CreatePromptTemplate(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+
+	var req CreatePromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+	if openai.IsBuiltinPromptVersion(openai.PromptName(req.Name), req.Version) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "version " + req.Version + " is reserved for the built-in template"})
+		return
+	}
+	if err := openai.ValidatePrompt(openai.PromptName(req.Name), req.Body); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_template", Message: err.Error()})
+		return
+	}
+
+	tmpl := models.PromptTemplate{
+		Name:           req.Name,
+		Version:        req.Version,
+		Body:           req.Body,
+		RolloutPercent: req.RolloutPercent,
+		Active:         true,
+		Note:           req.Note,
+	}
+	if req.UserID != nil && *req.UserID != "" {
+		uid, err := uuid.Parse(*req.UserID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_user_id", Message: "Invalid user ID"})
+			return
+		}
+		var user models.User
+		if err := h.db.Select("id").First(&user, "id = ?", uid).Error; err != nil {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "user_not_found", Message: "User not found"})
+			return
+		}
+		tmpl.UserID = &uid
+		tmpl.RolloutPercent = 0 // 個人覆寫不參與分流
+	}
+
+	// 同一範本、同一對象的版本名稱不可重複
+	dup := h.db.Model(&models.PromptTemplate{}).Where("name = ? AND version = ?", tmpl.Name, tmpl.Version)
+	if tmpl.UserID != nil {
+		dup = dup.Where("user_id = ?", *tmpl.UserID)
+	} else {
+		dup = dup.Where("user_id IS NULL")
+	}
+	var count int64
+	if err := dup.Count(&count).Error; err != nil {
+		logger.Error().Err(err).Msg("Failed to check prompt template version")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to create prompt template"})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "version_exists", Message: "Prompt version already exists"})
+		return
+	}
+
+	if !h.checkRollout(c, tmpl) {
+		return
+	}
+
+	if err := h.db.Create(&tmpl).Error; err != nil {
+		logger.Error().Err(err).Msg("Failed to create prompt template")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to create prompt template"})
+		return
+	}
+
+	logger.Info().
+		Str("admin", c.GetString("user_email")).
+		Str("prompt", tmpl.Name+"@"+tmpl.Version).
+		Int("rollout_percent", tmpl.RolloutPercent).
+		Msg("Prompt template created")
+	c.JSON(http.StatusCreated, tmpl)
+}
+
+// UpdatePromptTemplate 更新範本版本（管理員）
+// @Summary      更新提示詞範本版本
+// @Description  調整內容、分流比例或停用；內容變更時清除該版本的 AI 結果快取
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                       true  "範本版本 ID"
+// @Param        request  body      UpdatePromptTemplateRequest  true  "更新內容"
+// @Success      200      {object}  models.PromptTemplate
+// @Failure      400      {object}  ErrorResponse
+// @Failure      403      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /admin/prompts/{id} [put]
+func (h *AdminHandler) UpdatePromptTemplate(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+
+	tmpl, ok := h.findPromptTemplate(c)
+	if !ok {
+		return
+	}
+
+	var req UpdatePromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+
+	bodyChanged := req.Body != nil && *req.Body != tmpl.Body
+	if bodyChanged {
+		if err := openai.ValidatePrompt(openai.PromptName(tmpl.Name), *req.Body); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_template", Message: err.Error()})
+			return
+		}
+		tmpl.Body = *req.Body
+	}
+	if req.RolloutPercent != nil && tmpl.UserID == nil {
+		tmpl.RolloutPercent = *req.RolloutPercent
+	}
+	if req.Active != nil {
+		tmpl.Active = *req.Active
+	}
+	if req.Note != nil {
+		tmpl.Note = req.Note
+	}
+
+	if !h.checkRollout(c, tmpl) {
+		return
+	}
+
+	if err := h.db.Save(&tmpl).Error; err != nil {
+		logger.Error().Err(err).Msg("Failed to update prompt template")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to update prompt template"})
+		return
+	}
+	if bodyChanged {
+		h.invalidatePromptCache(c, tmpl)
+	}
+
+	logger.Info().
+		Str("admin", c.GetString("user_email")).
+		Str("prompt", tmpl.Name+"@"+tmpl.Version).
+		Int("rollout_percent", tmpl.RolloutPercent).
+		Bool("active", tmpl.Active).
+		Msg("Prompt template updated")
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// DeletePromptTemplate 刪除範本版本（管理員）
+// @Summary      刪除提示詞範本版本
+// @Description  刪除後分流到該版本的使用者改用其他版本或內建範本
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "範本版本 ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/prompts/{id} [delete]
+func (h *AdminHandler) DeletePromptTemplate(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+
+	tmpl, ok := h.findPromptTemplate(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(&tmpl).Error; err != nil {
+		logger.Error().Err(err).Msg("Failed to delete prompt template")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to delete prompt template"})
+		return
+	}
+	h.invalidatePromptCache(c, tmpl)
+
+	logger.Info().
+		Str("admin", c.GetString("user_email")).
+		Str("prompt", tmpl.Name+"@"+tmpl.Version).
+		Msg("Prompt template deleted")
+	c.JSON(http.StatusOK, gin.H{"message": "Prompt template deleted"})
+}
+
+// findPromptTemplate 解析並取得路徑中的範本版本
+func (h *AdminHandler) findPromptTemplate(c *gin.Context) (models.PromptTemplate, bool) {
+	logger := middleware.GetLogger(c)
+
+	var tmpl models.PromptTemplate
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_id", Message: "Invalid prompt template ID"})
+		return tmpl, false
+	}
+	if err := h.db.First(&tmpl, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "not_found", Message: "Prompt template not found"})
+			return tmpl, false
+		}
+		logger.Error().Err(err).Msg("Failed to fetch prompt template")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch prompt template"})
+		return tmpl, false
+	}
+	return tmpl, true
+}
+
+// checkRollout 確認啟用中的全域版本分流比例合計不超過 100
+func (h *AdminHandler) checkRollout(c *gin.Context, tmpl models.PromptTemplate) bool {
+	if tmpl.UserID != nil || !tmpl.Active || tmpl.RolloutPercent == 0 {
+		return true
+	}
+	total, err := prompts.RolloutTotal(h.db, tmpl.Name, tmpl.ID)
+	if err != nil {
+		middleware.GetLogger(c).Error().Err(err).Msg("Failed to check prompt rollout")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to check prompt rollout"})
+		return false
+	}
+	if total+tmpl.RolloutPercent > 100 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "rollout_exceeded",
+			Message: fmt.Sprintf("Active versions of %s already cover %d%% of users", tmpl.Name, total),
+		})
+		return false
+	}
+	return true
+}
+
+// invalidatePromptCache 清除以該範本版本產生的 AI 結果快取（失敗只記 log）
+func (h *AdminHandler) invalidatePromptCache(c *gin.Context, tmpl models.PromptTemplate) {
+	if _, err := aicache.InvalidatePrompt(h.db, tmpl.Name+"@"+tmpl.Version); err != nil {
+		middleware.GetLogger(c).Warn().Err(err).Msg("Failed to invalidate prompt cache")
+	}
+}
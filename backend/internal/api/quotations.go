@@ -0,0 +1,349 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/export"
+	"github.com/designcomb/influenter-backend/internal/services/gmail"
+	"github.com/designcomb/influenter-backend/internal/services/quotation"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateQuotationRequest 建立報價單請求
+type CreateQuotationRequest struct {
+	Lines      []quotation.LineInput `json:"lines"`       // 報價明細，可選；未傳則使用案件對應的合作項目（各 1 份、定價）
+	Currency   string                `json:"currency"`    // 幣別，可選；預設為案件幣別
+	Discount   float64               `json:"discount"`    // 整單折扣金額
+	TaxRate    float64               `json:"tax_rate"`    // 稅率（%），如 5
+	ValidUntil *string               `json:"valid_until"` // 有效期限（YYYY-MM-DD）
+	Terms      *string               `json:"terms"`       // 付款與合作條款
+	Notes      *string               `json:"notes"`       // 備註
+}
+
+// findCase 取得使用者的案件；失敗時已寫入錯誤回應
+func (h *CaseHandler) findCase(c *gin.Context) (*models.Case, bool) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_id", Message: "Invalid case ID"})
+		return nil, false
+	}
+	var cs models.Case
+	if err := h.db.Where("id = ? AND user_id = ?", id, userID).First(&cs).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "case_not_found", Message: "Case not found"})
+			return nil, false
+		}
+		logger.Error().Err(err).Str("case_id", id.String()).Msg("Failed to fetch case")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch case"})
+		return nil, false
+	}
+	return &cs, true
+}
+
+// findQuotation 取得案件下屬於使用者的報價單；失敗時已寫入錯誤回應
+func (h *CaseHandler) findQuotation(c *gin.Context) (*models.Quotation, bool) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	caseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_id", Message: "Invalid case ID"})
+		return nil, false
+	}
+	quotationID, err := uuid.Parse(c.Param("quotation_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_quotation_id", Message: "Invalid quotation ID"})
+		return nil, false
+	}
+
+	var q models.Quotation
+	if err := h.db.Where("id = ? AND case_id = ? AND user_id = ?", quotationID, caseID, userID).First(&q).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "quotation_not_found", Message: "Quotation not found"})
+			return nil, false
+		}
+		logger.Error().Err(err).Str("quotation_id", quotationID.String()).Msg("Failed to fetch quotation")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch quotation"})
+		return nil, false
+	}
+	return &q, true
+}
+
+// CreateQuotation 建立報價單新版本
+// @Summary      建立報價單
+// @Description  依案件對應的合作項目（或指定明細）建立新一版報價單：明細含數量、單價與折扣，另可設定整單折扣、稅率、幣別、有效期限與條款。版本號依案件遞增
+// @Tags         Cases
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                  true   "案件 ID"
+// @Param        request  body      CreateQuotationRequest  false  "報價內容"
+// @Success      201      {object}  models.Quotation
+// @Failure      400      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Router       /cases/{id}/quotations [post]
+func (h *CaseHandler) CreateQuotation(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+
+	var body CreateQuotationRequest
+	if err := c.ShouldBindJSON(&body); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+	in := quotation.Input{
+		Lines:    body.Lines,
+		Currency: body.Currency,
+		Discount: body.Discount,
+		TaxRate:  body.TaxRate,
+		Terms:    body.Terms,
+		Notes:    body.Notes,
+	}
+	if body.ValidUntil != nil && *body.ValidUntil != "" {
+		t, err := time.Parse("2006-01-02", *body.ValidUntil)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "valid_until must be YYYY-MM-DD"})
+			return
+		}
+		in.ValidUntil = &t
+	}
+
+	cs, ok := h.findCase(c)
+	if !ok {
+		return
+	}
+
+	q, err := h.quotations.Create(cs, in)
+	if err != nil {
+		switch {
+		case errors.Is(err, quotation.ErrNoLines):
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "no_line_items", Message: "案件尚未對應合作項目，請提供報價明細"})
+		case errors.Is(err, quotation.ErrUnknownItem), errors.Is(err, quotation.ErrInvalidLine):
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_line_items", Message: err.Error()})
+		default:
+			logger.Error().Err(err).Str("case_id", cs.ID.String()).Msg("Failed to create quotation")
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to create quotation"})
+		}
+		return
+	}
+
+	logger.Info().
+		Str("case_id", cs.ID.String()).
+		Str("quotation_id", q.ID.String()).
+		Int("version", q.Version).
+		Float64("total", q.Total).
+		Msg("Quotation created")
+	c.JSON(http.StatusCreated, q)
+}
+
+// ListQuotations 取得案件的報價單
+// @Summary      報價單列表
+// @Description  列出案件的所有報價單版本（新版本在前）
+// @Tags         Cases
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "案件 ID"
+// @Success      200  {object}  map[string][]models.Quotation
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /cases/{id}/quotations [get]
+func (h *CaseHandler) ListQuotations(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+
+	cs, ok := h.findCase(c)
+	if !ok {
+		return
+	}
+
+	var quotations []models.Quotation
+	if err := h.db.Where("case_id = ? AND user_id = ?", cs.ID, cs.UserID).Order("version DESC").Find(&quotations).Error; err != nil {
+		logger.Error().Err(err).Str("case_id", cs.ID.String()).Msg("Failed to list quotations")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to list quotations"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": quotations})
+}
+
+// GetQuotation 取得單一報價單
+// @Summary      取得報價單
+// @Tags         Cases
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id            path      string  true  "案件 ID"
+// @Param        quotation_id  path      string  true  "報價單 ID"
+// @Success      200           {object}  models.Quotation
+// @Failure      400           {object}  ErrorResponse
+// @Failure      404           {object}  ErrorResponse
+// @Router       /cases/{id}/quotations/{quotation_id} [get]
+func (h *CaseHandler) GetQuotation(c *gin.Context) {
+	q, ok := h.findQuotation(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, q)
+}
+
+// QuotationDocument 下載報價單文件
+// @Summary      下載報價單
+// @Description  將報價單輸出為 HTML（可直接列印）或 PDF
+// @Tags         Cases
+// @Produce      text/html
+// @Produce      application/pdf
+// @Security     BearerAuth
+// @Param        id            path   string  true   "案件 ID"
+// @Param        quotation_id  path   string  true   "報價單 ID"
+// @Param        format        query  string  false  "html | pdf（預設 pdf）"
+// @Param        tz            query  string  false  "報價日期時區（IANA，如 Asia/Taipei；預設 UTC）"
+// @Success      200
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /cases/{id}/quotations/{quotation_id}/document [get]
+func (h *CaseHandler) QuotationDocument(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+
+	format := c.DefaultQuery("format", "pdf")
+	if format != "html" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_format", Message: "format must be html or pdf"})
+		return
+	}
+	loc := time.UTC
+	if tz := c.Query("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_timezone", Message: "Invalid tz"})
+			return
+		}
+	}
+
+	q, ok := h.findQuotation(c)
+	if !ok {
+		return
+	}
+
+	doc, err := renderQuotation(h.db, q, format, loc)
+	if err != nil {
+		logger.Error().Err(err).Str("quotation_id", q.ID.String()).Str("format", format).Msg("Failed to render quotation")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "export_failed", Message: "Failed to render quotation"})
+		return
+	}
+
+	disposition := "attachment"
+	if format == "html" {
+		disposition = "inline"
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": doc.Filename}))
+	c.Data(http.StatusOK, doc.ContentType, doc.Data)
+}
+
+// AcceptQuotation 標記對方接受的報價版本
+// @Summary      接受報價單
+// @Description  將報價單標記為對方已接受，並以此版總計與幣別更新案件報價金額；先前接受的版本改為 superseded
+// @Tags         Cases
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id            path      string  true  "案件 ID"
+// @Param        quotation_id  path      string  true  "報價單 ID"
+// @Success      200           {object}  models.Quotation
+// @Failure      400           {object}  ErrorResponse
+// @Failure      404           {object}  ErrorResponse
+// @Router       /cases/{id}/quotations/{quotation_id}/accept [post]
+func (h *CaseHandler) AcceptQuotation(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+
+	q, ok := h.findQuotation(c)
+	if !ok {
+		return
+	}
+	if err := h.quotations.Accept(q); err != nil {
+		logger.Error().Err(err).Str("quotation_id", q.ID.String()).Msg("Failed to accept quotation")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to accept quotation"})
+		return
+	}
+
+	logger.Info().
+		Str("case_id", q.CaseID.String()).
+		Str("quotation_id", q.ID.String()).
+		Int("version", q.Version).
+		Float64("quoted_amount", q.Total).
+		Msg("Quotation accepted")
+	c.JSON(http.StatusOK, q)
+}
+
+// renderQuotation 輸出報價單文件（format 為 html 或 pdf）
+func renderQuotation(db *gorm.DB, q *models.Quotation, format string, loc *time.Location) (*gmail.OutgoingAttachment, error) {
+	var cs models.Case
+	if err := db.Where("id = ?", q.CaseID).First(&cs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch case: %w", err)
+	}
+	var issuer models.User
+	if err := db.Where("id = ?", q.UserID).First(&issuer).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	name := q.Number
+	if cs.BrandName != "" {
+		name = cs.BrandName + "-" + q.Number
+	}
+	var buf bytes.Buffer
+	doc := &gmail.OutgoingAttachment{Filename: export.FileSlug(name) + "." + format}
+	var err error
+	if format == "html" {
+		err = export.WriteQuotationHTML(&buf, q, &cs, &issuer, loc)
+		doc.ContentType = "text/html; charset=utf-8"
+	} else {
+		err = export.WriteQuotationPDF(&buf, q, &cs, &issuer, loc)
+		doc.ContentType = "application/pdf"
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc.Data = buf.Bytes()
+	return doc, nil
+}
+
+// quotationAttachment 取得要隨回信寄出的報價單與其 PDF；報價單需屬於郵件所屬的案件。失敗時已寫入錯誤回應
+func (h *EmailHandler) quotationAttachment(c *gin.Context, email *models.Email, quotationID string) (*models.Quotation, *gmail.OutgoingAttachment, bool) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	id, err := uuid.Parse(quotationID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_quotation_id", Message: "Invalid quotation ID"})
+		return nil, nil, false
+	}
+	if email.CaseID == nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "email_not_linked", Message: "郵件尚未關聯案件，無法附上報價單"})
+		return nil, nil, false
+	}
+
+	var q models.Quotation
+	if err := h.db.Where("id = ? AND case_id = ? AND user_id = ?", id, *email.CaseID, userID).First(&q).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "quotation_not_found", Message: "Quotation not found"})
+			return nil, nil, false
+		}
+		logger.Error().Err(err).Str("quotation_id", quotationID).Msg("Failed to fetch quotation")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch quotation"})
+		return nil, nil, false
+	}
+
+	attachment, err := renderQuotation(h.db, &q, "pdf", time.UTC)
+	if err != nil {
+		logger.Error().Err(err).Str("quotation_id", quotationID).Msg("Failed to render quotation")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "export_failed", Message: "Failed to render quotation"})
+		return nil, nil, false
+	}
+	return &q, attachment, true
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RefineDraftRequest 修改草稿請求
+type RefineDraftRequest struct {
+	Feedback string `json:"feedback" binding:"required,max=1000"` // 修改要求，如「短一點」「要求 50% 訂金」「改用英文」
+}
+
+// findDraft 取得案件下屬於使用者的草稿；失敗時已寫入錯誤回應
+func (h *CaseHandler) findDraft(c *gin.Context) (*models.ReplyDraft, bool) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	caseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_id", Message: "Invalid case ID"})
+		return nil, false
+	}
+	draftID, err := uuid.Parse(c.Param("draft_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_draft_id", Message: "Invalid draft ID"})
+		return nil, false
+	}
+
+	var draft models.ReplyDraft
+	if err := h.db.Where("id = ? AND case_id = ? AND user_id = ?", draftID, caseID, userID).First(&draft).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "draft_not_found", Message: "Draft not found"})
+			return nil, false
+		}
+		logger.Error().Err(err).Str("draft_id", draftID.String()).Msg("Failed to fetch draft")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch draft"})
+		return nil, false
+	}
+	return &draft, true
+}
+
+// draftRevisions 同一份草稿的所有版本（依版本序號排序）
+func (h *CaseHandler) draftRevisions(draft *models.ReplyDraft) ([]models.ReplyDraft, error) {
+	var revisions []models.ReplyDraft
+	err := h.db.Where("root_id = ? AND user_id = ?", draft.RootID, draft.UserID).
+		Order("revision ASC, created_at ASC").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+// previousFeedback 由第一版到 draft 依序套用過的修改要求
+func previousFeedback(revisions []models.ReplyDraft, draft *models.ReplyDraft) []string {
+	byID := make(map[uuid.UUID]*models.ReplyDraft, len(revisions))
+	for i := range revisions {
+		byID[revisions[i].ID] = &revisions[i]
+	}
+
+	var feedback []string
+	for cur, steps := draft, 0; cur != nil && steps <= len(revisions); steps++ {
+		if cur.Feedback != nil && *cur.Feedback != "" {
+			feedback = append([]string{*cur.Feedback}, feedback...)
+		}
+		if cur.ParentID == nil {
+			break
+		}
+		cur = byID[*cur.ParentID]
+	}
+	return feedback
+}
+
+// RefineDraft 依修改要求產生草稿的新版本
+// @Summary      修改回信草稿
+// @Description  以既有草稿與修改要求（如「短一點」「要求 50% 訂金」「改用英文」）產生新的一版並保存；先前各版的修改要求會一併保留。可對任何一版修改，新版本序號為目前最大值加一
+// @Tags         Cases
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      string              true  "案件 ID"
+// @Param        draft_id  path      string              true  "草稿 ID"
+// @Param        request   body      RefineDraftRequest  true  "修改要求"
+// @Success      200       {object}  models.ReplyDraft
+// @Failure      400       {object}  ErrorResponse
+// @Failure      404       {object}  ErrorResponse
+// @Failure      429       {object}  ErrorResponse
+// @Failure      503       {object}  ErrorResponse
+// @Router       /cases/{id}/drafts/{draft_id}/refine [post]
+func (h *CaseHandler) RefineDraft(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	if h.openaiService == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "openai_unavailable", Message: "AI 服務未設定"})
+		return
+	}
+
+	var body RefineDraftRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+
+	draft, ok := h.findDraft(c)
+	if !ok {
+		return
+	}
+
+	var cs models.Case
+	if err := h.db.Where("id = ? AND user_id = ?", draft.CaseID, userID).First(&cs).Error; err != nil {
+		logger.Error().Err(err).Str("case_id", draft.CaseID.String()).Msg("Failed to fetch case")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch case"})
+		return
+	}
+	// 原始來信已刪除時仍可修改，只是缺少來信內容
+	var email models.Email
+	if draft.EmailID != nil {
+		err := h.db.Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
+			Where("emails.id = ? AND oauth_accounts.user_id = ?", *draft.EmailID, userID).
+			First(&email).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			logger.Error().Err(err).Str("email_id", draft.EmailID.String()).Msg("Failed to fetch email")
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch email"})
+			return
+		}
+	}
+
+	revisions, err := h.draftRevisions(draft)
+	if err != nil {
+		logger.Error().Err(err).Str("draft_id", draft.ID.String()).Msg("Failed to fetch draft revisions")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch draft"})
+		return
+	}
+
+	instruction := ""
+	if draft.Instruction != nil {
+		instruction = *draft.Instruction
+	}
+	req := openai.RefineDraftRequest{
+		DraftReplyRequest: h.draftRequest(c, &cs, &email, instruction),
+		Draft:             draft.Content,
+		Feedback:          body.Feedback,
+		PreviousFeedback:  previousFeedback(revisions, draft),
+	}
+	req.Stance, req.Tone = draft.Stance, draft.Tone
+
+	emailID := ""
+	if draft.EmailID != nil {
+		emailID = draft.EmailID.String()
+	}
+	ctx := openai.WithUsageScope(c.Request.Context(), userID, emailID)
+	result, err := h.openaiService.RefineDraft(ctx, req)
+	if err != nil {
+		if aiLimitError(c, err) {
+			return
+		}
+		logger.Error().Err(err).Str("draft_id", draft.ID.String()).Msg("RefineDraft failed")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "draft_failed", Message: "修改草稿失敗，請稍後再試"})
+		return
+	}
+
+	revision := 1
+	for _, r := range revisions {
+		if r.Revision >= revision {
+			revision = r.Revision + 1
+		}
+	}
+	refined := &models.ReplyDraft{
+		UserID:        draft.UserID,
+		CaseID:        draft.CaseID,
+		EmailID:       draft.EmailID,
+		Instruction:   draft.Instruction,
+		Content:       result.Draft,
+		PromptVersion: result.PromptVersion,
+		Stance:        draft.Stance,
+		Tone:          draft.Tone,
+		RootID:        draft.RootID,
+		ParentID:      &draft.ID,
+		Revision:      revision,
+		Feedback:      &body.Feedback,
+	}
+	if err := h.db.Omit(clause.Associations).Create(refined).Error; err != nil {
+		logger.Error().Err(err).Str("draft_id", draft.ID.String()).Msg("Failed to save refined draft")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to save draft"})
+		return
+	}
+
+	logger.Info().
+		Str("draft_id", refined.ID.String()).
+		Str("root_id", refined.RootID.String()).
+		Int("revision", refined.Revision).
+		Msg("Draft refined")
+	c.JSON(http.StatusOK, refined)
+}
+
+// ListDraftRevisions 取得草稿的修改紀錄
+// @Summary      草稿修改紀錄
+// @Description  列出與指定草稿同一份的所有版本（依版本序號排序），每一版附上修改要求與來源版本
+// @Tags         Cases
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      string  true  "案件 ID"
+// @Param        draft_id  path      string  true  "草稿 ID（任一版皆可）"
+// @Success      200       {object}  map[string][]models.ReplyDraft
+// @Failure      400       {object}  ErrorResponse
+// @Failure      404       {object}  ErrorResponse
+// @Router       /cases/{id}/drafts/{draft_id}/revisions [get]
+func (h *CaseHandler) ListDraftRevisions(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+
+	draft, ok := h.findDraft(c)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.draftRevisions(draft)
+	if err != nil {
+		logger.Error().Err(err).Str("draft_id", draft.ID.String()).Msg("Failed to fetch draft revisions")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch draft revisions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": revisions})
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDraftVariants(t *testing.T) {
+	variants, err := draftVariants(&DraftReplyRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, variants)
+
+	variants, err = draftVariants(&DraftReplyRequest{VariantCount: 2})
+	require.NoError(t, err)
+	assert.Equal(t, openai.DefaultDraftVariants[:2], variants)
+
+	variants, err = draftVariants(&DraftReplyRequest{VariantCount: 1, Variants: []openai.DraftVariant{{Stance: "decline", Tone: "casual"}}})
+	require.NoError(t, err)
+	assert.Equal(t, []openai.DraftVariant{{Stance: "decline", Tone: "casual"}}, variants)
+
+	for _, body := range []DraftReplyRequest{
+		{VariantCount: 4},
+		{Variants: []openai.DraftVariant{{Stance: "maybe"}}},
+		{Variants: []openai.DraftVariant{{Stance: "accept", Tone: "angry"}}},
+		{Variants: []openai.DraftVariant{{Stance: "accept"}, {Stance: "accept"}, {Stance: "negotiate"}, {Stance: "decline"}}},
+	} {
+		_, err := draftVariants(&body)
+		assert.Error(t, err, "%+v", body)
+	}
+}
+
+func TestPreviousFeedback(t *testing.T) {
+	shorter, upfront, english := "短一點", "要求 50% 訂金", "改用英文"
+	root := models.ReplyDraft{ID: uuid.New()}
+	root.RootID = root.ID
+	v2 := models.ReplyDraft{ID: uuid.New(), RootID: root.ID, ParentID: &root.ID, Revision: 2, Feedback: &shorter}
+	v3 := models.ReplyDraft{ID: uuid.New(), RootID: root.ID, ParentID: &v2.ID, Revision: 3, Feedback: &upfront}
+	// 從第一版另外修改的分支
+	v4 := models.ReplyDraft{ID: uuid.New(), RootID: root.ID, ParentID: &root.ID, Revision: 4, Feedback: &english}
+	revisions := []models.ReplyDraft{root, v2, v3, v4}
+
+	assert.Empty(t, previousFeedback(revisions, &root))
+	assert.Equal(t, []string{shorter, upfront}, previousFeedback(revisions, &v3))
+	assert.Equal(t, []string{english}, previousFeedback(revisions, &v4))
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/retention"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RetentionHandler 資料保留設定處理器
+type RetentionHandler struct {
+	db  *gorm.DB
+	svc *retention.Service
+}
+
+// NewRetentionHandler 建立資料保留設定處理器
+func NewRetentionHandler(db *gorm.DB, cfg config.RetentionConfig) *RetentionHandler {
+	return &RetentionHandler{db: db, svc: retention.NewService(db, cfg)}
+}
+
+// UpdateRetentionPolicyRequest 更新保留政策請求
+type UpdateRetentionPolicyRequest struct {
+	Enabled                 *bool `json:"enabled"`
+	EmailBodyRetentionDays  *int  `json:"email_body_retention_days" binding:"omitempty,min=1"`
+	ClearEmailBodyRetention bool  `json:"clear_email_body_retention"` // true 時改回永久保留郵件內文
+	KeepCaseEmails          *bool `json:"keep_case_emails"`
+	SoftDeletePurgeDays     *int  `json:"soft_delete_purge_days" binding:"omitempty,min=1"`
+}
+
+// GetRetentionPolicy 取得資料保留設定
+// @Summary      取得資料保留設定
+// @Description  未設定時回傳預設值（保留郵件內文、案件郵件永久保留，軟刪除資料於期限後永久刪除）
+// @Tags         Retention
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  models.RetentionPolicy
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /retention/settings [get]
+func (h *RetentionHandler) GetRetentionPolicy(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized", Message: "user_id required"})
+		return
+	}
+
+	policy, err := h.svc.GetPolicy(uid)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch retention policy")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch retention policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdateRetentionPolicy 更新資料保留設定
+// @Summary      更新資料保留設定
+// @Tags         Retention
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      UpdateRetentionPolicyRequest  true  "保留設定"
+// @Success      200      {object}  models.RetentionPolicy
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /retention/settings [put]
+func (h *RetentionHandler) UpdateRetentionPolicy(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized", Message: "user_id required"})
+		return
+	}
+
+	var req UpdateRetentionPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+
+	policy, err := h.svc.GetPolicy(uid)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch retention policy")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch retention policy"})
+		return
+	}
+
+	if req.Enabled != nil {
+		policy.Enabled = *req.Enabled
+	}
+	if req.ClearEmailBodyRetention {
+		policy.EmailBodyRetentionDays = nil
+	} else if req.EmailBodyRetentionDays != nil {
+		policy.EmailBodyRetentionDays = req.EmailBodyRetentionDays
+	}
+	if req.KeepCaseEmails != nil {
+		policy.KeepCaseEmails = *req.KeepCaseEmails
+	}
+	if req.SoftDeletePurgeDays != nil {
+		policy.SoftDeletePurgeDays = *req.SoftDeletePurgeDays
+	}
+
+	// 尚未設定時建立（每位使用者一筆）
+	tx := h.db.Omit(clause.Associations)
+	if policy.ID == uuid.Nil {
+		err = tx.Create(&policy).Error
+	} else {
+		err = tx.Save(&policy).Error
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to save retention policy")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to save retention policy"})
+		return
+	}
+
+	logger.Info().Str("user_id", userID).Msg("Retention policy updated")
+	c.JSON(http.StatusOK, policy)
+}
+
+// GetRetentionReport 試算下次清除會處理的資料量
+// @Summary      資料清除試算
+// @Description  依目前設定計算下次排程會清除的資料數量（dry-run，不修改任何資料）
+// @Tags         Retention
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  retention.Report
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /retention/report [get]
+func (h *RetentionHandler) GetRetentionReport(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized", Message: "user_id required"})
+		return
+	}
+
+	report, err := h.svc.Run(c.Request.Context(), uid, true)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to build retention report")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to build retention report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ListRetentionAudits 列出資料清除紀錄
+// @Summary      列出資料清除紀錄
+// @Tags         Retention
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /retention/audits [get]
+func (h *RetentionHandler) ListRetentionAudits(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	var audits []models.RetentionAudit
+	if err := h.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(50).
+		Find(&audits).Error; err != nil {
+		logger.Error().Err(err).Msg("Failed to list retention audits")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to list retention audits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": audits})
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/snooze"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SnoozeHandler 延後處理（snooze）處理器
+type SnoozeHandler struct {
+	db *gorm.DB
+}
+
+// NewSnoozeHandler 建立延後處理處理器
+func NewSnoozeHandler(db *gorm.DB) *SnoozeHandler {
+	return &SnoozeHandler{db: db}
+}
+
+// SnoozeRequest 延後處理請求
+type SnoozeRequest struct {
+	Until time.Time `json:"until" binding:"required"` // RFC3339，到期後重新出現並通知
+}
+
+// SnoozedListResponse 延後中的郵件與案件
+type SnoozedListResponse struct {
+	Emails []models.EmailListResponse `json:"emails"`
+	Cases  []CaseResponse             `json:"cases"`
+}
+
+// findUserEmail 取得使用者擁有的郵件
+func (h *SnoozeHandler) findUserEmail(id uuid.UUID, userID string) (*models.Email, error) {
+	var email models.Email
+	err := h.db.Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
+		Where("emails.id = ? AND oauth_accounts.user_id = ?", id, userID).
+		First(&email).Error
+	return &email, err
+}
+
+// setEmailSnooze 設定或清除郵件的延後時間
+func (h *SnoozeHandler) setEmailSnooze(c *gin.Context, until *time.Time) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_id", Message: "Invalid email ID"})
+		return
+	}
+
+	email, err := h.findUserEmail(id, userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "email_not_found", Message: "Email not found"})
+			return
+		}
+		logger.Error().Err(err).Str("email_id", id.String()).Msg("Failed to fetch email")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch email"})
+		return
+	}
+
+	if err := h.db.Model(email).Update("snoozed_until", until).Error; err != nil {
+		logger.Error().Err(err).Str("email_id", id.String()).Msg("Failed to update email snooze")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to update email"})
+		return
+	}
+	email.SnoozedUntil = until
+
+	c.JSON(http.StatusOK, email.ToListResponse())
+}
+
+// setCaseSnooze 設定或清除案件的延後時間
+func (h *SnoozeHandler) setCaseSnooze(c *gin.Context, until *time.Time) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_id", Message: "Invalid case ID"})
+		return
+	}
+
+	var cs models.Case
+	if err := h.db.Where("id = ? AND user_id = ?", id, userID).First(&cs).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "case_not_found", Message: "Case not found"})
+			return
+		}
+		logger.Error().Err(err).Str("case_id", id.String()).Msg("Failed to fetch case")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch case"})
+		return
+	}
+
+	if err := h.db.Model(&cs).Update("snoozed_until", until).Error; err != nil {
+		logger.Error().Err(err).Str("case_id", id.String()).Msg("Failed to update case snooze")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to update case"})
+		return
+	}
+	cs.SnoozedUntil = until
+
+	c.JSON(http.StatusOK, caseToResponse(&cs, 0, 0, 0))
+}
+
+// bindSnoozeUntil 解析並驗證延後時間
+func bindSnoozeUntil(c *gin.Context) (*time.Time, bool) {
+	var req SnoozeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return nil, false
+	}
+	if err := snooze.ValidateUntil(req.Until, time.Now()); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_snooze_time", Message: err.Error()})
+		return nil, false
+	}
+	until := req.Until.UTC()
+	return &until, true
+}
+
+// SnoozeEmail 延後處理郵件
+// @Summary      延後處理郵件
+// @Description  到期前郵件不會出現在預設郵件列表；到期後標為未讀並建立站內通知
+// @Tags         郵件
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string         true  "Email ID"
+// @Param        request  body      SnoozeRequest  true  "延後到何時"
+// @Success      200      {object}  models.EmailListResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /emails/{id}/snooze [post]
+func (h *SnoozeHandler) SnoozeEmail(c *gin.Context) {
+	until, ok := bindSnoozeUntil(c)
+	if !ok {
+		return
+	}
+	h.setEmailSnooze(c, until)
+}
+
+// UnsnoozeEmail 取消延後處理郵件
+// @Summary      取消延後處理郵件
+// @Tags         郵件
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Email ID"
+// @Success      200  {object}  models.EmailListResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /emails/{id}/snooze [delete]
+func (h *SnoozeHandler) UnsnoozeEmail(c *gin.Context) {
+	h.setEmailSnooze(c, nil)
+}
+
+// SnoozeCase 延後處理案件
+// @Summary      延後處理案件
+// @Description  到期前案件不會出現在預設案件列表；到期後重新顯示並建立站內通知
+// @Tags         Cases
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string         true  "Case ID"
+// @Param        request  body      SnoozeRequest  true  "延後到何時"
+// @Success      200      {object}  CaseResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /cases/{id}/snooze [post]
+func (h *SnoozeHandler) SnoozeCase(c *gin.Context) {
+	until, ok := bindSnoozeUntil(c)
+	if !ok {
+		return
+	}
+	h.setCaseSnooze(c, until)
+}
+
+// UnsnoozeCase 取消延後處理案件
+// @Summary      取消延後處理案件
+// @Tags         Cases
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Case ID"
+// @Success      200  {object}  CaseResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /cases/{id}/snooze [delete]
+func (h *SnoozeHandler) UnsnoozeCase(c *gin.Context) {
+	h.setCaseSnooze(c, nil)
+}
+
+// ListSnoozed 列出延後中的郵件與案件
+// @Summary      列出延後處理中的項目
+// @Description  依到期時間排序，列出尚未到期的郵件與案件
+// @Tags         Snooze
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  SnoozedListResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /snoozed [get]
+func (h *SnoozeHandler) ListSnoozed(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+	now := time.Now()
+
+	var emails []models.Email
+	if err := h.db.Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
+		Where("oauth_accounts.user_id = ? AND emails.snoozed_until > ?", userID, now).
+		Order("emails.snoozed_until ASC").
+		Limit(200).
+		Find(&emails).Error; err != nil {
+		logger.Error().Err(err).Msg("Failed to list snoozed emails")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to list snoozed emails"})
+		return
+	}
+
+	var cases []models.Case
+	if err := h.db.Where("user_id = ? AND snoozed_until > ?", userID, now).
+		Order("snoozed_until ASC").
+		Limit(200).
+		Find(&cases).Error; err != nil {
+		logger.Error().Err(err).Msg("Failed to list snoozed cases")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to list snoozed cases"})
+		return
+	}
+
+	resp := SnoozedListResponse{
+		Emails: make([]models.EmailListResponse, 0, len(emails)),
+		Cases:  make([]CaseResponse, 0, len(cases)),
+	}
+	for i := range emails {
+		resp.Emails = append(resp.Emails, emails[i].ToListResponse())
+	}
+	for i := range cases {
+		resp.Cases = append(resp.Cases, caseToResponse(&cases[i], 0, 0, 0))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/style"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StyleHandler 寫作風格處理器
+type StyleHandler struct {
+	db    *gorm.DB
+	style *style.Service
+}
+
+// NewStyleHandler 建立寫作風格處理器
+func NewStyleHandler(db *gorm.DB, styleSvc *style.Service) *StyleHandler {
+	return &StyleHandler{db: db, style: styleSvc}
+}
+
+// StyleProfileResponse 寫作風格與擬信時使用的說明
+type StyleProfileResponse struct {
+	Profile       models.WritingStyleProfile `json:"profile"`
+	Guide         string                     `json:"guide"`          // 擬信時附上的風格說明
+	Applied       bool                       `json:"applied"`        // 寄出郵件足夠時才套用風格說明
+	ExcludedCount int64                      `json:"excluded_count"` // 不納入分析的寄出郵件數
+}
+
+// styleProfileResponse 組合寫作風格回應
+func (h *StyleHandler) styleProfileResponse(c *gin.Context, uid uuid.UUID, profile *models.WritingStyleProfile) {
+	logger := middleware.GetLogger(c)
+
+	var excluded int64
+	err := h.db.Model(&models.Email{}).
+		Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
+		Where("oauth_accounts.user_id = ? AND emails.style_excluded = ?", uid, true).
+		Count(&excluded).Error
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to count excluded emails")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch writing style"})
+		return
+	}
+
+	resp := StyleProfileResponse{Profile: *profile, Applied: h.style.Usable(profile), ExcludedCount: excluded}
+	if resp.Applied {
+		resp.Guide = style.Describe(profile)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetStyleProfile 取得寫作風格
+// @Summary      取得寫作風格
+// @Description  由寄出郵件統計的常用開頭、結尾、語氣、語言比例、篇幅與表情符號使用，擬信時據此模仿。尚未統計時立即統計
+// @Tags         Style
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  StyleProfileResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /style/profile [get]
+func (h *StyleHandler) GetStyleProfile(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized", Message: "user_id required"})
+		return
+	}
+
+	profile, err := h.style.Profile(uid)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch writing style profile")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch writing style"})
+		return
+	}
+	h.styleProfileResponse(c, uid, profile)
+}
+
+// RefreshStyleProfile 立即重新統計寫作風格
+// @Summary      重新統計寫作風格
+// @Description  依最近的寄出郵件（排除 style_excluded 的郵件）重新統計；平常由排程定期更新
+// @Tags         Style
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  StyleProfileResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /style/profile/refresh [post]
+func (h *StyleHandler) RefreshStyleProfile(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized", Message: "user_id required"})
+		return
+	}
+
+	profile, err := h.style.Refresh(uid)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to refresh writing style profile")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to refresh writing style"})
+		return
+	}
+
+	logger.Info().Int("samples", profile.SampleCount).Msg("Writing style profile refreshed")
+	h.styleProfileResponse(c, uid, profile)
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/caseupdate"
+	"github.com/designcomb/influenter-backend/internal/services/triage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TriageHandler 新郵件自動分析設定處理器
+type TriageHandler struct {
+	db  *gorm.DB
+	svc *triage.Service
+}
+
+// NewTriageHandler 建立自動分析設定處理器
+func NewTriageHandler(db *gorm.DB, cfg config.AIConfig) *TriageHandler {
+	return &TriageHandler{db: db, svc: triage.NewService(db, cfg, nil)}
+}
+
+// TriageSettingsResponse 自動分析設定（含實際套用的門檻）
+type TriageSettingsResponse struct {
+	models.AITriageSettings
+	EffectiveAutoCreateCaseThreshold float64 `json:"effective_auto_create_case_threshold"`
+	EffectiveCaseUpdateMinConfidence float64 `json:"effective_case_update_min_confidence"`
+}
+
+// UpdateTriageSettingsRequest 更新自動分析設定請求
+type UpdateTriageSettingsRequest struct {
+	AutoAnalyze             *bool    `json:"auto_analyze"`
+	AutoCreateCases         *bool    `json:"auto_create_cases"`
+	AttachReplies           *bool    `json:"attach_replies"`
+	AutoCreateCaseThreshold *float64 `json:"auto_create_case_threshold" binding:"omitempty,gt=0,lte=1"`
+	ClearThreshold          bool     `json:"clear_threshold"` // true 時改回系統預設門檻
+
+	// AI 案件更新建議的自動套用（只套用信心度達門檻、且在指定欄位內的變更）
+	AutoApplyCaseUpdates         *bool     `json:"auto_apply_case_updates"`
+	CaseUpdateMinConfidence      *float64  `json:"case_update_min_confidence" binding:"omitempty,gt=0,lte=1"`
+	ClearCaseUpdateMinConfidence bool      `json:"clear_case_update_min_confidence"` // true 時改回系統預設門檻
+	CaseUpdateFields             *[]string `json:"case_update_fields"`               // 空陣列代表所有欄位
+}
+
+func (h *TriageHandler) settingsResponse(settings models.AITriageSettings) TriageSettingsResponse {
+	return TriageSettingsResponse{
+		AITriageSettings:                 settings,
+		EffectiveAutoCreateCaseThreshold: h.svc.CaseThreshold(settings),
+		EffectiveCaseUpdateMinConfidence: caseupdate.MinConfidence(settings),
+	}
+}
+
+// GetTriageSettings 取得自動分析設定
+// @Summary      取得自動分析設定
+// @Description  同步後是否自動分析新郵件、自動建立案件、將回覆歸入既有案件，以及 AI 案件更新建議的自動套用範圍。未設定時回傳系統預設值
+// @Tags         AI
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  TriageSettingsResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /triage/settings [get]
+func (h *TriageHandler) GetTriageSettings(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized", Message: "user_id required"})
+		return
+	}
+
+	settings, err := h.svc.GetSettings(uid)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch triage settings")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch triage settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.settingsResponse(settings))
+}
+
+// UpdateTriageSettings 更新自動分析設定
+// @Summary      更新自動分析設定
+// @Tags         AI
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      UpdateTriageSettingsRequest  true  "自動分析設定"
+// @Success      200      {object}  TriageSettingsResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /triage/settings [put]
+func (h *TriageHandler) UpdateTriageSettings(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized", Message: "user_id required"})
+		return
+	}
+
+	var req UpdateTriageSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+
+	if req.CaseUpdateFields != nil {
+		for _, f := range *req.CaseUpdateFields {
+			if !caseupdate.IsField(f) {
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_field", Message: "Unknown case update field: " + f})
+				return
+			}
+		}
+	}
+
+	settings, err := h.svc.GetSettings(uid)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch triage settings")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch triage settings"})
+		return
+	}
+
+	if req.AutoAnalyze != nil {
+		settings.AutoAnalyze = *req.AutoAnalyze
+	}
+	if req.AutoCreateCases != nil {
+		settings.AutoCreateCases = *req.AutoCreateCases
+	}
+	if req.AttachReplies != nil {
+		settings.AttachReplies = *req.AttachReplies
+	}
+	if req.ClearThreshold {
+		settings.AutoCreateCaseThreshold = nil
+	} else if req.AutoCreateCaseThreshold != nil {
+		settings.AutoCreateCaseThreshold = req.AutoCreateCaseThreshold
+	}
+	if req.AutoApplyCaseUpdates != nil {
+		settings.AutoApplyCaseUpdates = *req.AutoApplyCaseUpdates
+	}
+	if req.ClearCaseUpdateMinConfidence {
+		settings.CaseUpdateMinConfidence = nil
+	} else if req.CaseUpdateMinConfidence != nil {
+		settings.CaseUpdateMinConfidence = req.CaseUpdateMinConfidence
+	}
+	if req.CaseUpdateFields != nil {
+		settings.CaseUpdateFields = *req.CaseUpdateFields
+	}
+
+	// 尚未設定時建立（每位使用者一筆）
+	tx := h.db.Omit(clause.Associations)
+	if settings.ID == uuid.Nil {
+		err = tx.Create(&settings).Error
+	} else {
+		err = tx.Save(&settings).Error
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to save triage settings")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to save triage settings"})
+		return
+	}
+
+	logger.Info().Str("user_id", userID).Msg("Triage settings updated")
+	c.JSON(http.StatusOK, h.settingsResponse(settings))
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/middleware"
+	"github.com/designcomb/influenter-backend/internal/services/aicache"
+	"github.com/designcomb/influenter-backend/internal/services/feedback"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/designcomb/influenter-backend/internal/services/usage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxUsageRangeDays 用量查詢的最大區間
+const maxUsageRangeDays = 366
+
+// UsageHandler AI 用量處理器
+type UsageHandler struct {
+	db    *gorm.DB
+	guard *usage.Guard
+}
+
+// NewUsageHandler 建立 AI 用量處理器
+func NewUsageHandler(db *gorm.DB, cfg config.AIConfig) *UsageHandler {
+	return &UsageHandler{db: db, guard: usage.NewGuard(db, cfg)}
+}
+
+// UsageQueryParams 用量查詢參數（日期為 UTC，含頭尾）
+type UsageQueryParams struct {
+	From string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To   string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// GetAIUsage 取得 AI 用量統計
+// @Summary      取得 AI 用量統計
+// @Description  依用途（classify / extract / draft / match / reply_analysis）彙總 token 用量、成本與結果快取命中率，並提供每日與每月統計。預設為最近 30 天
+// @Tags         AI
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from  query     string  false  "起始日期（YYYY-MM-DD）"
+// @Param        to    query     string  false  "結束日期（YYYY-MM-DD，含當日）"
+// @Success      200   {object}  usage.Summary
+// @Failure      400   {object}  ErrorResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /usage/ai [get]
+func (h *UsageHandler) GetAIUsage(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized", Message: "user_id required"})
+		return
+	}
+
+	var params UsageQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	to := today
+	if params.To != "" {
+		to, _ = time.Parse("2006-01-02", params.To)
+	}
+	from := to.AddDate(0, 0, -29)
+	if params.From != "" {
+		from, _ = time.Parse("2006-01-02", params.From)
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "from must not be after to"})
+		return
+	}
+	if to.Sub(from) >= maxUsageRangeDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "date range must not exceed 366 days"})
+		return
+	}
+
+	summary, err := usage.Summarize(h.db, uid, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to summarize ai usage")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch ai usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetAIAccuracy 取得使用者回饋的 AI 準確率
+// @Summary      取得 AI 準確率
+// @Description  依使用者對分析結果的回饋（直接回饋與修改 AI 建立的案件），統計分類與各擷取欄位的準確率，並依分析時間提供每週與每月統計及各範本版本的比較。預設為最近 90 天
+// @Tags         AI
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from  query     string  false  "起始日期（YYYY-MM-DD）"
+// @Param        to    query     string  false  "結束日期（YYYY-MM-DD，含當日）"
+// @Success      200   {object}  feedback.AccuracyReport
+// @Failure      400   {object}  ErrorResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /usage/ai/accuracy [get]
+func (h *UsageHandler) GetAIAccuracy(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized", Message: "user_id required"})
+		return
+	}
+
+	var params UsageQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: err.Error()})
+		return
+	}
+
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if params.To != "" {
+		to, _ = time.Parse("2006-01-02", params.To)
+	}
+	from := to.AddDate(0, 0, -89)
+	if params.From != "" {
+		from, _ = time.Parse("2006-01-02", params.From)
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "from must not be after to"})
+		return
+	}
+	if to.Sub(from) >= maxUsageRangeDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "date range must not exceed 366 days"})
+		return
+	}
+
+	report, err := feedback.Accuracy(h.db, uid, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to summarize ai accuracy")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch ai accuracy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetAIBudget 取得本月 AI 額度與用量
+// @Summary      取得本月 AI 額度
+// @Description  每月 token / 成本上限、各用途每小時呼叫上限與本月用量。達到上限後只做關鍵字分類、不產生草稿
+// @Tags         AI
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  usage.BudgetStatus
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /usage/ai/budget [get]
+func (h *UsageHandler) GetAIBudget(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized", Message: "user_id required"})
+		return
+	}
+
+	status, err := h.guard.Status(uid)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch ai budget")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to fetch ai budget"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// ClearAICache 清除使用者的 AI 結果快取
+// @Summary      清除 AI 結果快取
+// @Description  刪除使用者所有快取的分類、擷取與比對結果，之後的分析會重新呼叫 AI
+// @Tags         AI
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /usage/ai/cache [delete]
+func (h *UsageHandler) ClearAICache(c *gin.Context) {
+	logger := middleware.GetLogger(c)
+	userID := c.GetString("user_id")
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "unauthorized", Message: "user_id required"})
+		return
+	}
+
+	deleted, err := aicache.Invalidate(h.db, uid)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to clear ai result cache")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "database_error", Message: "Failed to clear ai result cache"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
+
+// aiLimitError 將額度 / 頻率限制錯誤轉為 429 回應；非限制錯誤時回傳 false
+func aiLimitError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, openai.ErrBudgetExceeded):
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: "ai_budget_exceeded", Message: "本月 AI 額度已用完，下個月自動恢復"})
+	case errors.Is(err, openai.ErrRateLimited):
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: "ai_rate_limited", Message: "AI 功能使用過於頻繁，請稍後再試"})
+	default:
+		return false
+	}
+	return true
+}
@@ -122,6 +122,7 @@ func (h *WorkflowTemplateHandler) CreateTemplate(c *gin.Context) {
 	// Reload with empty phases
 	tmpl.Phases = []models.WorkflowPhase{}
 
+	invalidateMatchCache(c, h.db, userIDStr)
 	c.JSON(http.StatusCreated, tmpl)
 }
 
@@ -176,6 +177,7 @@ func (h *WorkflowTemplateHandler) UpdateTemplate(c *gin.Context) {
 		return db.Order(`"order" ASC`)
 	}).First(&tmpl, "id = ?", tmpl.ID)
 
+	invalidateMatchCache(c, h.db, userID)
 	c.JSON(http.StatusOK, tmpl)
 }
 
@@ -208,6 +210,7 @@ func (h *WorkflowTemplateHandler) DeleteTemplate(c *gin.Context) {
 		return
 	}
 
+	invalidateMatchCache(c, h.db, userID)
 	c.JSON(http.StatusOK, gin.H{"message": "Workflow template deleted"})
 }
 
@@ -267,6 +270,7 @@ func (h *WorkflowTemplateHandler) CreatePhase(c *gin.Context) {
 		return
 	}
 
+	invalidateMatchCache(c, h.db, userID)
 	c.JSON(http.StatusCreated, phase)
 }
 
@@ -335,6 +339,7 @@ func (h *WorkflowTemplateHandler) UpdatePhase(c *gin.Context) {
 	}
 
 	h.db.First(&phase, "id = ?", phaseUUID)
+	invalidateMatchCache(c, h.db, userID)
 	c.JSON(http.StatusOK, phase)
 }
 
@@ -385,5 +390,6 @@ func (h *WorkflowTemplateHandler) DeletePhase(c *gin.Context) {
 		return
 	}
 
+	invalidateMatchCache(c, h.db, userID)
 	c.JSON(http.StatusOK, gin.H{"message": "Workflow phase deleted"})
 }
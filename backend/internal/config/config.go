@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
@@ -35,6 +36,9 @@ type Config struct {
 	// OpenAI 設定
 	OpenAI OpenAIConfig
 
+	// 模型後端設定（OpenAI / Anthropic / OpenAI 相容的自架模型）
+	LLM LLMConfig
+
 	// 前端 URL
 	FrontendURL string
 
@@ -53,6 +57,21 @@ type Config struct {
 	// 通知設定
 	Notification NotificationConfig
 
+	// 資料保留設定
+	Retention RetentionConfig
+
+	// 未回覆追蹤設定
+	FollowUp FollowUpConfig
+
+	// 寫作風格設定
+	Style StyleConfig
+
+	// 附件文字擷取設定
+	Attachments AttachmentConfig
+
+	// 郵件匯入設定
+	Imports ImportConfig
+
 	// 安全設定
 	Security SecurityConfig
 }
@@ -95,6 +114,84 @@ type OpenAIConfig struct {
 	APIKey    string
 	Model     string
 	MaxTokens int
+
+	// 各模型價格（計算用量成本），未列出的模型以 gpt-4o-mini 計價
+	Pricing map[string]ModelPrice
+}
+
+// 模型後端名稱
+const (
+	LLMProviderOpenAI     = "openai"
+	LLMProviderAnthropic  = "anthropic"
+	LLMProviderCompatible = "compatible" // OpenAI 相容 API（Ollama、vLLM 等自架模型）
+)
+
+// LLMConfig 模型後端設定，可依用途（classify、extract、draft、match、reply_analysis）選擇不同後端
+type LLMConfig struct {
+	DefaultProvider string
+	Operations      map[string]string // 用途 → 後端，如 {"draft": "anthropic", "classify": "compatible"}
+
+	Anthropic  AnthropicConfig
+	Compatible CompatibleLLMConfig
+}
+
+// AnthropicConfig Anthropic Messages API 設定
+type AnthropicConfig struct {
+	APIKey  string
+	Model   string
+	BaseURL string
+}
+
+// CompatibleLLMConfig OpenAI 相容 API 設定（內容不離開自架環境）
+type CompatibleLLMConfig struct {
+	BaseURL string // 如 http://localhost:11434/v1
+	APIKey  string // 多數自架服務不需要
+	Model   string
+}
+
+// ProviderFor 取得用途使用的後端
+func (c LLMConfig) ProviderFor(operation string) string {
+	if provider, ok := c.Operations[operation]; ok && provider != "" {
+		return provider
+	}
+	if c.DefaultProvider != "" {
+		return c.DefaultProvider
+	}
+	return LLMProviderOpenAI
+}
+
+// UsesProvider 是否有任何用途使用此後端
+func (c LLMConfig) UsesProvider(provider string) bool {
+	if c.ProviderFor("") == provider {
+		return true
+	}
+	for _, p := range c.Operations {
+		if p == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// ModelPrice 模型價格（美元 per 1K tokens）
+type ModelPrice struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// DefaultModelPricing 預設價格表（可用 OPENAI_PRICING 覆寫或新增模型）
+func DefaultModelPricing() map[string]ModelPrice {
+	return map[string]ModelPrice{
+		"gpt-4o":        {Prompt: 0.005, Completion: 0.015},
+		"gpt-4o-mini":   {Prompt: 0.00015, Completion: 0.0006},
+		"gpt-4-turbo":   {Prompt: 0.01, Completion: 0.03},
+		"gpt-4":         {Prompt: 0.03, Completion: 0.06},
+		"gpt-3.5-turbo": {Prompt: 0.0005, Completion: 0.0015},
+
+		"claude-3-5-haiku":  {Prompt: 0.0008, Completion: 0.004},
+		"claude-3-5-sonnet": {Prompt: 0.003, Completion: 0.015},
+		"claude-3-haiku":    {Prompt: 0.00025, Completion: 0.00125},
+	}
 }
 
 // CORSConfig CORS 配置
@@ -121,6 +218,27 @@ type AIConfig struct {
 	AutoAnalyze             bool
 	ConfidenceThreshold     float64
 	AutoCreateCaseThreshold float64
+
+	// 同步後自動分析（triage）
+	TriageSchedule     string // 補跑未分析郵件的排程（手動同步、匯入的郵件）
+	TriageBatchSize    int    // 每個帳號每次最多分析幾封
+	TriageLookbackDays int    // 只分析最近幾天收到的郵件（避免首次同步分析整個信箱）
+	TriageMaxAttempts  int    // 單封郵件分析失敗幾次後放棄（每次失敗後延後重試）
+
+	// 新郵件歸入既有案件
+	CaseMatchBrandDays     int     // 同品牌、最近幾天內更新過的進行中案件建議歸入（待使用者確認）
+	CaseMatchLinkThreshold float64 // AI 比對信心度達此值時直接歸入，未達但超過 ConfidenceThreshold 時只建議
+
+	// 使用額度（使用者未個別設定時套用；0 表示不限制）
+	MonthlyTokenLimit      int64
+	MonthlyCostLimitUSD    float64
+	BudgetSoftLimitPercent int            // 用量達額度的百分比時發出提醒
+	RateLimits             map[string]int // 各用途每小時呼叫次數上限（如 draft:30）
+
+	// 結構化輸出快取（分類、擷取、比對），相同輸入在期限內不重複呼叫 API
+	CacheEnabled       bool
+	CacheTTL           time.Duration
+	CachePurgeSchedule string // 清除過期快取的排程（cron 格式）
 }
 
 // NotificationConfig 通知配置
@@ -132,6 +250,48 @@ type NotificationConfig struct {
 	SMTPPassword     string
 }
 
+// RetentionConfig 資料保留（排程清除）配置
+type RetentionConfig struct {
+	Schedule                   string // 排程（cron 格式）
+	BatchSize                  int    // 每批處理筆數
+	MaxBatchesPerRun           int    // 每位使用者每項目每次最多處理幾批（其餘留待下次）
+	DefaultSoftDeletePurgeDays int    // 未設定時，軟刪除資料保留天數
+}
+
+// FollowUpConfig 寄出郵件未獲回覆的追蹤配置
+type FollowUpConfig struct {
+	BusinessDays    int    // 寄出後幾個工作天內沒有回覆就提醒
+	Timezone        string // 計算工作天（週末）使用的時區
+	Schedule        string // 檢查排程（cron 格式）
+	AutoDraft       bool   // 到期時是否自動以 AI 預先產生跟進信草稿
+	MaxDraftsPerRun int    // 每次檢查最多產生幾封草稿（控制 AI 成本）
+}
+
+// StyleConfig 使用者寫作風格（擬信時模仿）配置
+type StyleConfig struct {
+	Schedule     string // 重新計算風格檔案的排程（cron 格式）
+	SampleSize   int    // 分析最近幾封寄出郵件
+	MinSamples   int    // 寄出郵件少於此數量時不套用風格
+	ExampleCount int    // 擬信時附上幾封相似的過往回信
+}
+
+// AttachmentConfig 附件文字擷取（PDF/DOCX/XLSX/純文字）配置
+type AttachmentConfig struct {
+	Enabled     bool // 是否在 AI 分析前擷取附件文字
+	MaxBytes    int  // 單一附件大小上限（bytes），超過則略過
+	MaxPages    int  // PDF/DOCX 最多讀取頁數（XLSX 為工作表數）
+	MaxChars    int  // 單一附件最多保留字數
+	InlineChars int  // 附件文字在此字數內直接送入分析，超過則分段摘要
+	ChunkChars  int  // 分段摘要時每段的字數
+}
+
+// ImportConfig 郵件匯入（.eml / .mbox / zip）配置
+type ImportConfig struct {
+	UploadDir     string        // 上傳檔案的存放目錄（API 與 worker 須共用）
+	StaleAfter    time.Duration // 超過此時間仍未完成的匯入視為中斷，標記為失敗
+	SweepSchedule string        // 清理中斷匯入的排程（cron 格式）
+}
+
 // SecurityConfig 安全配置
 type SecurityConfig struct {
 	RateLimitPerMinute    int
@@ -139,11 +299,33 @@ type SecurityConfig struct {
 	SessionCookieSecure   bool
 	SessionCookieHTTPOnly bool
 	SessionMaxAge         int
+	AdminEmails           []string // 可管理使用者 AI 額度的管理員
 }
 
 // Load 從環境變數載入配置
 func Load() (*Config, error) {
-	cfg := &Config{
+	cfg := load()
+
+	// 驗證必要設定
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// LoadAI 從環境變數載入配置，只驗證模型後端設定（離線評估等不需資料庫與 OAuth 的工具使用）
+func LoadAI() (*Config, error) {
+	cfg := load()
+	if err := cfg.validateLLM(); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+	return cfg, nil
+}
+
+// load 從環境變數讀取所有設定（不驗證）
+func load() *Config {
+	return &Config{
 		// 基本設定
 		Env:      getEnv("ENV", "development"),
 		Port:     getEnv("PORT", "8080"),
@@ -191,6 +373,23 @@ func Load() (*Config, error) {
 			APIKey:    getEnv("OPENAI_API_KEY", ""),
 			Model:     getEnv("OPENAI_MODEL", "gpt-4o-mini"),
 			MaxTokens: getEnvAsInt("OPENAI_MAX_TOKENS", 2000),
+			Pricing:   getEnvAsPricing("OPENAI_PRICING", DefaultModelPricing()),
+		},
+
+		// 模型後端設定
+		LLM: LLMConfig{
+			DefaultProvider: getEnv("LLM_DEFAULT_PROVIDER", LLMProviderOpenAI),
+			Operations:      getEnvAsStringMap("LLM_OPERATION_PROVIDERS", map[string]string{}),
+			Anthropic: AnthropicConfig{
+				APIKey:  getEnv("ANTHROPIC_API_KEY", ""),
+				Model:   getEnv("ANTHROPIC_MODEL", "claude-3-5-haiku-latest"),
+				BaseURL: getEnv("ANTHROPIC_BASE_URL", "https://api.anthropic.com"),
+			},
+			Compatible: CompatibleLLMConfig{
+				BaseURL: getEnv("LLM_COMPATIBLE_BASE_URL", ""),
+				APIKey:  getEnv("LLM_COMPATIBLE_API_KEY", ""),
+				Model:   getEnv("LLM_COMPATIBLE_MODEL", "llama3.1"),
+			},
 		},
 
 		// 前端 URL
@@ -220,6 +419,19 @@ func Load() (*Config, error) {
 			AutoAnalyze:             getEnvAsBool("AUTO_ANALYZE_EMAILS", true),
 			ConfidenceThreshold:     getEnvAsFloat("AI_CONFIDENCE_THRESHOLD", 0.7),
 			AutoCreateCaseThreshold: getEnvAsFloat("AUTO_CREATE_CASE_THRESHOLD", 0.85),
+			TriageSchedule:          getEnv("AI_TRIAGE_SCHEDULE", "*/10 * * * *"),
+			TriageBatchSize:         getEnvAsInt("AI_TRIAGE_BATCH_SIZE", 20),
+			TriageLookbackDays:      getEnvAsInt("AI_TRIAGE_LOOKBACK_DAYS", 7),
+			TriageMaxAttempts:       getEnvAsInt("AI_TRIAGE_MAX_ATTEMPTS", 5),
+			CaseMatchBrandDays:      getEnvAsInt("AI_CASE_MATCH_BRAND_DAYS", 30),
+			CaseMatchLinkThreshold:  getEnvAsFloat("AI_CASE_MATCH_LINK_THRESHOLD", 0.85),
+			MonthlyTokenLimit:       int64(getEnvAsInt("AI_MONTHLY_TOKEN_LIMIT", 2000000)),
+			MonthlyCostLimitUSD:     getEnvAsFloat("AI_MONTHLY_COST_LIMIT_USD", 5),
+			BudgetSoftLimitPercent:  getEnvAsInt("AI_BUDGET_SOFT_LIMIT_PERCENT", 80),
+			RateLimits:              getEnvAsIntMap("AI_RATE_LIMITS", map[string]int{"draft": 30, "match": 60, "reply_analysis": 60, "negotiate": 30}),
+			CacheEnabled:            getEnvAsBool("AI_CACHE_ENABLED", true),
+			CacheTTL:                getEnvAsDuration("AI_CACHE_TTL", "168h"),
+			CachePurgeSchedule:      getEnv("AI_CACHE_PURGE_SCHEDULE", "15 * * * *"),
 		},
 
 		// 通知設定
@@ -231,6 +443,48 @@ func Load() (*Config, error) {
 			SMTPPassword:     getEnv("SMTP_PASSWORD", ""),
 		},
 
+		// 資料保留設定
+		Retention: RetentionConfig{
+			Schedule:                   getEnv("RETENTION_SCHEDULE", "30 3 * * *"),
+			BatchSize:                  getEnvAsInt("RETENTION_BATCH_SIZE", 500),
+			MaxBatchesPerRun:           getEnvAsInt("RETENTION_MAX_BATCHES_PER_RUN", 20),
+			DefaultSoftDeletePurgeDays: getEnvAsInt("RETENTION_SOFT_DELETE_PURGE_DAYS", 30),
+		},
+
+		// 未回覆追蹤設定
+		FollowUp: FollowUpConfig{
+			BusinessDays:    getEnvAsInt("FOLLOW_UP_BUSINESS_DAYS", 3),
+			Timezone:        getEnv("FOLLOW_UP_TIMEZONE", "Asia/Taipei"),
+			Schedule:        getEnv("FOLLOW_UP_SCHEDULE", "0 * * * *"),
+			AutoDraft:       getEnvAsBool("FOLLOW_UP_AUTO_DRAFT", false),
+			MaxDraftsPerRun: getEnvAsInt("FOLLOW_UP_MAX_DRAFTS_PER_RUN", 20),
+		},
+
+		// 寫作風格設定
+		Style: StyleConfig{
+			Schedule:     getEnv("STYLE_REFRESH_SCHEDULE", "0 4 * * *"),
+			SampleSize:   getEnvAsInt("STYLE_SAMPLE_SIZE", 200),
+			MinSamples:   getEnvAsInt("STYLE_MIN_SAMPLES", 5),
+			ExampleCount: getEnvAsInt("STYLE_EXAMPLE_COUNT", 3),
+		},
+
+		// 附件文字擷取設定
+		Attachments: AttachmentConfig{
+			Enabled:     getEnvAsBool("ATTACHMENT_EXTRACTION_ENABLED", true),
+			MaxBytes:    getEnvAsInt("ATTACHMENT_MAX_BYTES", 10*1024*1024),
+			MaxPages:    getEnvAsInt("ATTACHMENT_MAX_PAGES", 30),
+			MaxChars:    getEnvAsInt("ATTACHMENT_MAX_CHARS", 100000),
+			InlineChars: getEnvAsInt("ATTACHMENT_INLINE_CHARS", 3000),
+			ChunkChars:  getEnvAsInt("ATTACHMENT_CHUNK_CHARS", 6000),
+		},
+
+		// 郵件匯入設定
+		Imports: ImportConfig{
+			UploadDir:     getEnv("MAIL_IMPORT_UPLOAD_DIR", "data/imports"),
+			StaleAfter:    getEnvAsDuration("MAIL_IMPORT_STALE_AFTER", "3h"),
+			SweepSchedule: getEnv("MAIL_IMPORT_SWEEP_SCHEDULE", "*/30 * * * *"),
+		},
+
 		// 安全設定
 		Security: SecurityConfig{
 			RateLimitPerMinute:    getEnvAsInt("RATE_LIMIT_PER_MINUTE", 60),
@@ -238,15 +492,9 @@ func Load() (*Config, error) {
 			SessionCookieSecure:   getEnvAsBool("SESSION_COOKIE_SECURE", false),
 			SessionCookieHTTPOnly: getEnvAsBool("SESSION_COOKIE_HTTP_ONLY", true),
 			SessionMaxAge:         getEnvAsInt("SESSION_MAX_AGE", 86400),
+			AdminEmails:           getEnvAsSlice("ADMIN_EMAILS", []string{}),
 		},
 	}
-
-	// 驗證必要設定
-	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
-	}
-
-	return cfg, nil
 }
 
 // Validate 驗證配置的必要欄位
@@ -304,10 +552,32 @@ func (c *Config) Validate() error {
 	}
 
 	// OpenAI API Key (在開發環境可選，但生產環境建議要有)
-	if c.Env == "production" && c.OpenAI.APIKey == "" {
+	if c.Env == "production" && c.LLM.UsesProvider(LLMProviderOpenAI) && c.OpenAI.APIKey == "" {
 		return fmt.Errorf("OPENAI_API_KEY is required in production")
 	}
 
+	return c.validateLLM()
+}
+
+// validateLLM 驗證模型後端設定
+func (c *Config) validateLLM() error {
+	providers := append([]string{c.LLM.ProviderFor("")}, mapValues(c.LLM.Operations)...)
+	for _, provider := range providers {
+		switch provider {
+		case LLMProviderOpenAI:
+		case LLMProviderAnthropic:
+			if c.LLM.Anthropic.APIKey == "" {
+				return fmt.Errorf("ANTHROPIC_API_KEY is required when the anthropic provider is used")
+			}
+		case LLMProviderCompatible:
+			if c.LLM.Compatible.BaseURL == "" {
+				return fmt.Errorf("LLM_COMPATIBLE_BASE_URL is required when the compatible provider is used")
+			}
+		default:
+			return fmt.Errorf("unknown LLM provider: %s", provider)
+		}
+	}
+
 	return nil
 }
 
@@ -430,3 +700,71 @@ func getEnvAsIntSlice(key string, defaultValue []int) []int {
 
 	return result
 }
+
+// getEnvAsIntMap 取得環境變數並轉換為 key:整數 對照表（以逗號分隔，如 draft:30,match:60）
+func getEnvAsIntMap(key string, defaultValue map[string]int) map[string]int {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	result := make(map[string]int)
+	for _, part := range strings.Split(valueStr, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			continue
+		}
+		if num, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			result[strings.TrimSpace(k)] = num
+		}
+	}
+
+	if len(result) == 0 {
+		return defaultValue
+	}
+	return result
+}
+
+// getEnvAsStringMap 取得環境變數並轉換為 key:value 對照表（以逗號分隔，如 draft:anthropic,classify:compatible）
+func getEnvAsStringMap(key string, defaultValue map[string]string) map[string]string {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	result := make(map[string]string)
+	for _, part := range strings.Split(valueStr, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if ok && strings.TrimSpace(k) != "" {
+			result[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return result
+}
+
+// mapValues 取得對照表的所有值
+func mapValues(m map[string]string) []string {
+	values := make([]string, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
+
+// getEnvAsPricing 取得價格表環境變數（JSON，如 {"gpt-4o":{"prompt":0.0025,"completion":0.01}}），與預設值合併
+func getEnvAsPricing(key string, defaultValue map[string]ModelPrice) map[string]ModelPrice {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	var overrides map[string]ModelPrice
+	if err := json.Unmarshal([]byte(valueStr), &overrides); err != nil {
+		return defaultValue
+	}
+
+	for model, price := range overrides {
+		defaultValue[model] = price
+	}
+	return defaultValue
+}
@@ -94,3 +94,22 @@ func OptionalAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// AdminMiddleware 僅允許設定中的管理員存取（需放在 AuthMiddleware 之後）
+func AdminMiddleware(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		email := strings.ToLower(strings.TrimSpace(c.GetString("user_email")))
+		for _, admin := range cfg.Security.AdminEmails {
+			if email != "" && strings.ToLower(strings.TrimSpace(admin)) == email {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "Admin access required",
+		})
+		c.Abort()
+	}
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// AIAnalysis 郵件的 AI 分析結果
+// 每次分析新增一筆（version 遞增），emails.ai_analysis_id 指向最新的一筆
+type AIAnalysis struct {
+	ID      uuid.UUID `gorm:"primary_key" json:"id"`
+	EmailID uuid.UUID `gorm:"not null;uniqueIndex:idx_ai_analyses_email_version,priority:1" json:"email_id"`
+	UserID  uuid.UUID `gorm:"not null;index" json:"user_id"`
+	Version int       `gorm:"not null;uniqueIndex:idx_ai_analyses_email_version,priority:2" json:"version"` // 同一封郵件的第幾次分析
+
+	// 產生此結果的模型與 prompt 版本
+	Model         string `gorm:"type:varchar(100);not null" json:"model"`
+	PromptVersion string `gorm:"type:varchar(50);not null" json:"prompt_version"`
+
+	// 分類
+	Category             string  `gorm:"type:varchar(50);index" json:"category"`
+	Confidence           float64 `json:"confidence"`
+	ClassificationReason *string `gorm:"type:text" json:"classification_reason,omitempty"`
+
+	// 抽取資訊（openai.ExtractedInfo）
+	ExtractedInfo datatypes.JSON `gorm:"type:jsonb" json:"extracted_info"`
+
+	Summary        *string        `gorm:"type:text" json:"summary,omitempty"`
+	KeyPoints      pq.StringArray `gorm:"type:text[]" json:"key_points,omitempty"`
+	ActionRequired bool           `gorm:"not null;default:false;index" json:"action_required"`
+	Priority       string         `gorm:"type:varchar(20);index" json:"priority"` // low, medium, high
+
+	TokensUsed int       `gorm:"not null;default:0" json:"tokens_used"`
+	AnalyzedAt time.Time `gorm:"not null" json:"analyzed_at"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName 指定表名
+func (AIAnalysis) TableName() string {
+	return "ai_analyses"
+}
+
+// BeforeCreate GORM hook
+func (a *AIAnalysis) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// AIAnalysisPriorityOrder 依優先級排序用的 SQL 表達式（high 最大）
+const AIAnalysisPriorityOrder = "CASE ai_analyses.priority WHEN 'high' THEN 3 WHEN 'medium' THEN 2 WHEN 'low' THEN 1 ELSE 0 END"
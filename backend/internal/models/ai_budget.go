@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// AIBudget 使用者的 AI 使用額度
+// 限制欄位為 nil 時套用系統預設值（config.AIConfig），0 表示不限制
+type AIBudget struct {
+	ID     uuid.UUID `gorm:"primary_key" json:"id"`
+	UserID uuid.UUID `gorm:"not null;uniqueIndex" json:"user_id"`
+
+	MonthlyTokenLimit   *int64   `json:"monthly_token_limit,omitempty"`
+	MonthlyCostLimitUSD *float64 `json:"monthly_cost_limit_usd,omitempty"`
+
+	// 各用途每小時呼叫次數上限（覆寫系統預設），如 {"draft": 10}
+	RateLimits datatypes.JSON `gorm:"type:jsonb" json:"rate_limits,omitempty"`
+
+	// 管理員暫時解除限制（到期前不檢查額度與頻率）
+	OverrideUntil *time.Time `json:"override_until,omitempty"`
+	OverrideNote  *string    `gorm:"type:text" json:"override_note,omitempty"`
+
+	// 已發出提醒的月份（YYYY-MM），每月各提醒一次
+	WarnedPeriod   string `gorm:"size:7" json:"-"`
+	ExceededPeriod string `gorm:"size:7" json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName 指定表名
+func (AIBudget) TableName() string {
+	return "ai_budgets"
+}
+
+// BeforeCreate GORM hook
+func (b *AIBudget) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
+
+// OverrideActive 管理員解除限制是否仍有效
+func (b *AIBudget) OverrideActive(now time.Time) bool {
+	return b.OverrideUntil != nil && now.Before(*b.OverrideUntil)
+}
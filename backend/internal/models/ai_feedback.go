@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AIFeedbackSource 回饋來源
+type AIFeedbackSource string
+
+const (
+	AIFeedbackSourceManual   AIFeedbackSource = "manual"    // 使用者直接對分析結果回饋
+	AIFeedbackSourceCaseEdit AIFeedbackSource = "case_edit" // 使用者修改 AI 建立的案件
+)
+
+// AIFeedback 使用者對一次 AI 分析單一欄位的判定（同一分析同一欄位只保留最新一筆）
+type AIFeedback struct {
+	ID         uuid.UUID `gorm:"primary_key" json:"id"`
+	UserID     uuid.UUID `gorm:"not null;index" json:"user_id"`
+	EmailID    uuid.UUID `gorm:"not null;index" json:"email_id"`
+	AnalysisID uuid.UUID `gorm:"not null;uniqueIndex:idx_ai_feedback_analysis_field,priority:1" json:"analysis_id"`
+
+	Source    AIFeedbackSource `gorm:"type:varchar(20);not null" json:"source"`
+	Field     string           `gorm:"type:varchar(50);not null;uniqueIndex:idx_ai_feedback_analysis_field,priority:2" json:"field"` // category 或擷取欄位（brand_name、amount…）
+	Predicted string           `gorm:"type:text" json:"predicted"`                                                                   // AI 的結果
+	Corrected string           `gorm:"type:text" json:"corrected"`                                                                   // 使用者確認的值
+	Correct   bool             `gorm:"not null;default:false" json:"correct"`                                                        // AI 結果是否正確
+
+	// 冗餘自分析紀錄，統計時不需 join
+	PromptVersion string    `gorm:"type:varchar(100)" json:"prompt_version"`
+	AnalyzedAt    time.Time `gorm:"not null;index" json:"analyzed_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	User     User       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Email    Email      `gorm:"foreignKey:EmailID;constraint:OnDelete:CASCADE" json:"-"`
+	Analysis AIAnalysis `gorm:"foreignKey:AnalysisID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName 指定表名
+func (AIFeedback) TableName() string {
+	return "ai_feedback"
+}
+
+// BeforeCreate GORM hook - 在創建前執行
+func (f *AIFeedback) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
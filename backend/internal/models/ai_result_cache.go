@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// AIResultCache 已驗證的 AI 結構化輸出快取
+// 以用途、模型、prompt 版本與正規化輸入的雜湊為鍵，相同輸入不重複呼叫 API
+type AIResultCache struct {
+	ID uuid.UUID `gorm:"primary_key" json:"id"`
+
+	// 快取所屬使用者（依使用者失效用）；系統呼叫可能沒有使用者
+	UserID *uuid.UUID `gorm:"index" json:"user_id,omitempty"`
+
+	CacheKey      string         `gorm:"size:64;not null;uniqueIndex" json:"cache_key"` // 所有鍵欄位的 SHA-256
+	Operation     string         `gorm:"size:50;not null" json:"operation"`
+	Model         string         `gorm:"size:100;not null" json:"model"`
+	PromptVersion string         `gorm:"size:50;not null" json:"prompt_version"`
+	Result        datatypes.JSON `gorm:"type:jsonb;not null" json:"result"`
+	Hits          int            `gorm:"not null;default:0" json:"hits"`
+
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (AIResultCache) TableName() string {
+	return "ai_result_cache"
+}
+
+// BeforeCreate GORM hook
+func (c *AIResultCache) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// AITriageSettings 使用者的自動分析（triage）設定
+// 沒有設定時使用 DefaultAITriageSettings（依系統 AI 設定）
+type AITriageSettings struct {
+	ID     uuid.UUID `gorm:"primary_key" json:"id"`
+	UserID uuid.UUID `gorm:"not null;uniqueIndex" json:"user_id"`
+
+	// 同步後自動分析新收到的郵件
+	AutoAnalyze bool `gorm:"not null" json:"auto_analyze"`
+
+	// 分類信心高於門檻的合作郵件自動建立案件
+	AutoCreateCases bool `gorm:"not null" json:"auto_create_cases"`
+
+	// 已有案件的郵件串（或案件聯絡人）來的回覆自動歸入該案件，而不是另建案件
+	AttachReplies bool `gorm:"not null" json:"attach_replies"`
+
+	// 自動建立案件的信心門檻；nil 表示使用系統預設
+	AutoCreateCaseThreshold *float64 `json:"auto_create_case_threshold,omitempty"`
+
+	// AI 依往來郵件建議的案件更新直接套用（否則一律進入待確認清單）
+	AutoApplyCaseUpdates bool `gorm:"not null;default:false" json:"auto_apply_case_updates"`
+
+	// 自動套用的信心門檻；nil 表示使用系統預設
+	CaseUpdateMinConfidence *float64 `json:"case_update_min_confidence,omitempty"`
+
+	// 只自動套用這些欄位（status、notes…）；空白表示所有欄位
+	CaseUpdateFields pq.StringArray `gorm:"type:text[]" json:"case_update_fields"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName 指定表名
+func (AITriageSettings) TableName() string {
+	return "ai_triage_settings"
+}
+
+// BeforeCreate GORM hook
+func (s *AITriageSettings) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// DefaultAITriageSettings 使用者尚未設定時的預設值
+func DefaultAITriageSettings(userID uuid.UUID, autoAnalyze bool) AITriageSettings {
+	return AITriageSettings{
+		UserID:          userID,
+		AutoAnalyze:     autoAnalyze,
+		AutoCreateCases: autoAnalyze,
+		AttachReplies:   true,
+	}
+}
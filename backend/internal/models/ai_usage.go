@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AIUsage 單次 AI API 呼叫的 token 用量與成本
+type AIUsage struct {
+	ID uuid.UUID `gorm:"primary_key" json:"id"`
+
+	// 用量歸屬；系統呼叫（如連線測試）可能沒有使用者
+	UserID  *uuid.UUID `gorm:"index:idx_ai_usage_user_created,priority:1" json:"user_id,omitempty"`
+	EmailID *uuid.UUID `gorm:"index" json:"email_id,omitempty"`
+
+	Operation        string  `gorm:"size:50;not null" json:"operation"` // classify / extract / draft / match / reply_analysis
+	Model            string  `gorm:"size:100;not null" json:"model"`
+	PromptTokens     int     `gorm:"not null" json:"prompt_tokens"`
+	CompletionTokens int     `gorm:"not null" json:"completion_tokens"`
+	TotalTokens      int     `gorm:"not null" json:"total_tokens"`
+	CostUSD          float64 `gorm:"not null" json:"cost_usd"`
+	Cached           bool    `gorm:"not null;default:false" json:"cached"` // 由結果快取取得，未呼叫 API
+
+	CreatedAt time.Time `gorm:"index:idx_ai_usage_user_created,priority:2" json:"created_at"`
+}
+
+// TableName 指定表名
+func (AIUsage) TableName() string {
+	return "ai_usage"
+}
+
+// BeforeCreate GORM hook
+func (u *AIUsage) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	return nil
+}
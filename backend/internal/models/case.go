@@ -44,6 +44,9 @@ type Case struct {
 	Tags               pq.StringArray  `gorm:"type:text[]" json:"tags,omitempty"`
 	CollaborationItems pq.StringArray  `gorm:"column:collaboration_items;type:text[]" json:"collaboration_items,omitempty"`
 
+	// 延後處理：到期前不出現在預設列表，到期後由排程通知
+	SnoozedUntil *time.Time `gorm:"column:snoozed_until;index" json:"snoozed_until,omitempty"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
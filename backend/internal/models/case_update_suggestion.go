@@ -0,0 +1,106 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// CaseUpdateSuggestionStatus AI 案件更新建議的狀態
+type CaseUpdateSuggestionStatus string
+
+const (
+	CaseUpdateSuggestionPending           CaseUpdateSuggestionStatus = "pending"            // 尚有欄位待確認
+	CaseUpdateSuggestionAccepted          CaseUpdateSuggestionStatus = "accepted"           // 全部套用
+	CaseUpdateSuggestionPartiallyAccepted CaseUpdateSuggestionStatus = "partially_accepted" // 部分套用、其餘拒絕
+	CaseUpdateSuggestionRejected          CaseUpdateSuggestionStatus = "rejected"           // 全部拒絕
+	CaseUpdateSuggestionAutoApplied       CaseUpdateSuggestionStatus = "auto_applied"       // 依使用者設定全部自動套用
+)
+
+// CaseUpdateSource 建議的來源
+type CaseUpdateSource string
+
+const (
+	CaseUpdateSourceReply    CaseUpdateSource = "reply"    // 我方寄出的回信
+	CaseUpdateSourceIncoming CaseUpdateSource = "incoming" // 品牌在已關聯案件的郵件串中來信
+)
+
+// CaseFieldChangeState 單一欄位變更的狀態
+type CaseFieldChangeState string
+
+const (
+	CaseFieldChangePending  CaseFieldChangeState = "pending"
+	CaseFieldChangeApplied  CaseFieldChangeState = "applied"
+	CaseFieldChangeRejected CaseFieldChangeState = "rejected"
+)
+
+// CaseFieldChange 建議中的單一欄位變更
+type CaseFieldChange struct {
+	Field       string               `json:"field"`    // status / notes / description / quoted_amount / final_amount / deadline_date
+	Current     string               `json:"current"`  // 提出建議時案件的值
+	Proposed    string               `json:"proposed"` // 建議的值（notes 為要附加的進度說明）
+	State       CaseFieldChangeState `json:"state"`
+	AutoApplied bool                 `json:"auto_applied,omitempty"` // 依設定自動套用
+}
+
+// CaseUpdateSuggestion AI 根據郵件往來提出的案件更新，經使用者確認（或依設定自動套用）後才寫入案件
+type CaseUpdateSuggestion struct {
+	ID      uuid.UUID  `gorm:"primary_key" json:"id"`
+	UserID  uuid.UUID  `gorm:"not null;index" json:"user_id"`
+	CaseID  uuid.UUID  `gorm:"not null;index" json:"case_id"`
+	EmailID *uuid.UUID `gorm:"index" json:"email_id,omitempty"` // 觸發分析的郵件
+
+	Source  CaseUpdateSource `gorm:"type:varchar(20);not null;default:'reply'" json:"source"`
+	Signals pq.StringArray   `gorm:"type:text[]" json:"signals,omitempty"` // 偵測到的進度事件（如 price_agreed）
+
+	Status     CaseUpdateSuggestionStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	Changes    datatypes.JSON             `gorm:"type:jsonb;not null" json:"changes"` // []CaseFieldChange
+	Reason     string                     `gorm:"type:text" json:"reason"`            // AI 提出建議的理由
+	Confidence float64                    `gorm:"not null;default:0" json:"confidence"`
+	ResolvedAt *time.Time                 `json:"resolved_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Case Case `gorm:"foreignKey:CaseID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName 指定表名
+func (CaseUpdateSuggestion) TableName() string {
+	return "case_update_suggestions"
+}
+
+// BeforeCreate GORM hook - 在創建前執行
+func (s *CaseUpdateSuggestion) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// FieldChanges 解出各欄位變更
+func (s *CaseUpdateSuggestion) FieldChanges() ([]CaseFieldChange, error) {
+	var changes []CaseFieldChange
+	if len(s.Changes) == 0 {
+		return changes, nil
+	}
+	if err := json.Unmarshal(s.Changes, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// SetFieldChanges 寫入各欄位變更
+func (s *CaseUpdateSuggestion) SetFieldChanges(changes []CaseFieldChange) error {
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	s.Changes = raw
+	return nil
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,11 +13,16 @@ import (
 // 用途：儲存從第三方帳號（如 Gmail）同步的郵件
 type Email struct {
 	ID             uuid.UUID `gorm:"primary_key" json:"id"`
-	OAuthAccountID uuid.UUID `gorm:"column:oauth_account_id;not null;index" json:"oauth_account_id"`
+	OAuthAccountID uuid.UUID `gorm:"column:oauth_account_id;not null;index;uniqueIndex:idx_emails_account_provider_message,priority:1" json:"oauth_account_id"`
 
 	// 郵件提供商原始資訊
-	ProviderMessageID string  `gorm:"type:varchar(255);not null;uniqueIndex" json:"provider_message_id"` // Gmail message ID 或其他提供商的 message ID
-	ThreadID          *string `gorm:"type:varchar(255);index" json:"thread_id,omitempty"`                // 郵件串 ID
+	// provider_message_id 只在同一個帳號內唯一：同一封信寄到使用者連結的多個帳號時，各帳號都會有自己的一筆
+	ProviderMessageID string  `gorm:"type:varchar(255);not null;index;uniqueIndex:idx_emails_account_provider_message,priority:2" json:"provider_message_id"` // Gmail message ID 或其他提供商的 message ID
+	ThreadID          *string `gorm:"type:varchar(255);index" json:"thread_id,omitempty"`                                                                     // 郵件串 ID
+	RFCMessageID      *string `gorm:"column:rfc_message_id;type:varchar(998);index" json:"rfc_message_id,omitempty"`                                          // RFC 5322 Message-ID header（跨帳號去重用）
+
+	// 跨帳號去重：同一使用者的其他帳號已有同一封信（相同 RFC Message-ID）時，指向最先收到的那筆
+	DuplicateOfID *uuid.UUID `gorm:"column:duplicate_of_id;index" json:"duplicate_of_id,omitempty"`
 
 	// 郵件基本資訊
 	FromEmail string  `gorm:"type:varchar(255);not null;index" json:"from_email"` // 寄件者 email
@@ -27,20 +33,38 @@ type Email struct {
 	BodyHTML  *string `gorm:"type:text" json:"body_html,omitempty"`               // HTML 內容
 	Snippet   *string `gorm:"type:text" json:"snippet,omitempty"`                 // 郵件摘要（前 150 字）
 
+	// 依保留政策清除內文的時間（清除後只保留 header 與 snippet）
+	BodyPurgedAt *time.Time `json:"body_purged_at,omitempty"`
+
 	// 郵件屬性
 	Direction      string         `gorm:"type:varchar(20);not null;default:'incoming';index" json:"direction"` // incoming: 收到, outgoing: 寄出
 	ReceivedAt     time.Time      `gorm:"not null;index:idx_emails_received_at,sort:desc" json:"received_at"`  // 收件/寄件時間
 	IsRead         bool           `gorm:"default:false" json:"is_read"`                                        // 是否已讀
-	HasAttachments bool           `gorm:"default:false" json:"has_attachments"`                               // 是否有附件
-	Labels         pq.StringArray `gorm:"type:text[]" json:"labels,omitempty"`                                // 標籤（Gmail labels）
+	HasAttachments bool           `gorm:"default:false" json:"has_attachments"`                                // 是否有附件
+	Labels         pq.StringArray `gorm:"type:text[]" json:"labels,omitempty"`                                 // 標籤（Gmail labels）
 
 	// AI 分析狀態
 	AIAnalyzed   bool       `gorm:"default:false;index:idx_emails_ai_analyzed,where:ai_analyzed = false" json:"ai_analyzed"` // 是否已 AI 分析
 	AIAnalysisID *uuid.UUID `gorm:"index" json:"ai_analysis_id,omitempty"`                                                   // AI 分析結果 ID
 
+	// 自動分析（triage）進度：處理完成（或放棄）的時間；失敗時累計次數並延後重試
+	TriagedAt      *time.Time `json:"-"`
+	TriageAttempts int        `gorm:"not null;default:0" json:"-"`
+	TriageRetryAt  *time.Time `json:"-"`
+
 	// 案件關聯
 	CaseID *uuid.UUID `gorm:"index" json:"case_id,omitempty"` // 關聯的案件 ID
 
+	// 可能所屬的既有案件（比對信心不足時只建議，由使用者確認後歸入）
+	SuggestedCaseID     *uuid.UUID `gorm:"index" json:"suggested_case_id,omitempty"`
+	SuggestedCaseReason *string    `gorm:"type:text" json:"suggested_case_reason,omitempty"`
+
+	// 延後處理：到期前不出現在預設列表，到期後由排程標為未讀並通知
+	SnoozedUntil *time.Time `gorm:"index" json:"snoozed_until,omitempty"`
+
+	// 使用者選擇不納入寫作風格分析（寄出郵件）
+	StyleExcluded bool `gorm:"default:false" json:"style_excluded"`
+
 	// 系統欄位
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
@@ -49,7 +73,7 @@ type Email struct {
 	// 關聯
 	OAuthAccount OAuthAccount `gorm:"foreignKey:OAuthAccountID;constraint:OnDelete:CASCADE" json:"-"`
 	// Case         Case         `gorm:"foreignKey:CaseID;constraint:OnDelete:SET NULL" json:"-"` // 未來實作
-	// AIAnalysis   AIAnalysis   `gorm:"foreignKey:AIAnalysisID" json:"-"` // 未來實作
+	AIAnalysis *AIAnalysis `gorm:"foreignKey:AIAnalysisID;constraint:OnDelete:SET NULL" json:"-"` // 最新的 AI 分析（需 Preload）
 }
 
 // TableName 指定表名
@@ -97,6 +121,131 @@ func (e *Email) IsInInbox() bool {
 	return e.HasLabel("INBOX")
 }
 
+// NormalizeMessageID 正規化 RFC Message-ID（去除角括號與空白、轉小寫），空值回傳 nil
+func NormalizeMessageID(id string) *string {
+	id = strings.TrimSpace(id)
+	id = strings.TrimPrefix(id, "<")
+	id = strings.TrimSuffix(id, ">")
+	id = strings.ToLower(strings.TrimSpace(id))
+	if id == "" {
+		return nil
+	}
+	return &id
+}
+
+// ResolveDuplicate 在建立前檢查同一使用者的其他帳號是否已有相同 RFC Message-ID 的郵件
+// 若有，將 DuplicateOfID 指向最早的那筆（統一收件匣只顯示一封）
+func (e *Email) ResolveDuplicate(db *gorm.DB, userID uuid.UUID) error {
+	if e.RFCMessageID == nil || *e.RFCMessageID == "" {
+		return nil
+	}
+
+	var canonical Email
+	err := db.Model(&Email{}).
+		Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
+		Where("oauth_accounts.user_id = ? AND emails.rfc_message_id = ? AND emails.oauth_account_id <> ? AND emails.duplicate_of_id IS NULL",
+			userID, *e.RFCMessageID, e.OAuthAccountID).
+		Order("emails.received_at ASC").
+		Order("emails.created_at ASC").
+		First(&canonical).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	e.DuplicateOfID = &canonical.ID
+	return nil
+}
+
+// PromoteDuplicates 郵件刪除（含軟刪除）時呼叫：指向 removedIDs 的重複郵件中，最早收到且未刪除的一筆改為主要郵件，
+// 其餘改指向它，讓統一收件匣仍顯示這封信
+func PromoteDuplicates(db *gorm.DB, removedIDs []uuid.UUID) error {
+	if len(removedIDs) == 0 {
+		return nil
+	}
+
+	var duplicates []Email
+	err := db.Model(&Email{}).
+		Select("id, duplicate_of_id").
+		Where("duplicate_of_id IN ? AND id NOT IN ?", removedIDs, removedIDs).
+		Order("duplicate_of_id").
+		Order("received_at ASC").
+		Order("created_at ASC").
+		Find(&duplicates).Error
+	if err != nil {
+		return err
+	}
+
+	promoted := make(map[uuid.UUID]bool)
+	for _, d := range duplicates {
+		removed := *d.DuplicateOfID
+		if promoted[removed] {
+			continue
+		}
+		promoted[removed] = true
+		if err := db.Model(&Email{}).Where("id = ?", d.ID).Update("duplicate_of_id", nil).Error; err != nil {
+			return err
+		}
+		err := db.Model(&Email{}).Unscoped().
+			Where("duplicate_of_id = ? AND id <> ?", removed, d.ID).
+			Update("duplicate_of_id", d.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// 沒有可接手的郵件（都已刪除）：清空指向
+	return db.Model(&Email{}).Unscoped().Where("duplicate_of_id IN ?", removedIDs).Update("duplicate_of_id", nil).Error
+}
+
+// DedupeByMessageID 重新標記使用者跨帳號重複的郵件（補齊舊郵件的 rfc_message_id 後執行）：
+// 同一 RFC Message-ID 中最早收到的一筆為主要郵件，其他帳號的同一封信指向它，回傳更新筆數
+func DedupeByMessageID(db *gorm.DB, userID uuid.UUID) (int64, error) {
+	newDB := func() *gorm.DB { return db.Session(&gorm.Session{NewDB: true}) }
+	accounts := newDB().Unscoped().Model(&OAuthAccount{}).Select("id").Where("user_id = ?", userID)
+	shared := newDB().Model(&Email{}).
+		Select("rfc_message_id").
+		Where("oauth_account_id IN (?) AND rfc_message_id <> ''", accounts).
+		Group("rfc_message_id").
+		Having("COUNT(DISTINCT oauth_account_id) > 1")
+
+	var emails []Email
+	err := db.Model(&Email{}).
+		Select("id, oauth_account_id, rfc_message_id, duplicate_of_id").
+		Where("oauth_account_id IN (?) AND rfc_message_id IN (?)", accounts, shared).
+		Order("rfc_message_id").
+		Order("received_at ASC").
+		Order("created_at ASC").
+		Find(&emails).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var updated int64
+	var canonical *Email
+	for i := range emails {
+		e := &emails[i]
+		var target *uuid.UUID
+		if canonical == nil || *canonical.RFCMessageID != *e.RFCMessageID {
+			canonical = e
+		} else if e.OAuthAccountID != canonical.OAuthAccountID {
+			target = &canonical.ID
+		} else {
+			continue // 同一帳號內的副本不處理
+		}
+		if (target == nil && e.DuplicateOfID == nil) || (target != nil && e.DuplicateOfID != nil && *e.DuplicateOfID == *target) {
+			continue
+		}
+		if err := db.Model(&Email{}).Where("id = ?", e.ID).Update("duplicate_of_id", target).Error; err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
 // EmailDirection 郵件方向
 const (
 	EmailDirectionIncoming = "incoming" // 收到的郵件
@@ -117,6 +266,14 @@ type EmailListResponse struct {
 	Labels         []string   `json:"labels,omitempty"`
 	CaseID         *uuid.UUID `json:"case_id,omitempty"`
 	AIAnalyzed     bool       `json:"ai_analyzed"`
+	SnoozedUntil   *time.Time `json:"snoozed_until,omitempty"`
+
+	SuggestedCaseID *uuid.UUID `json:"suggested_case_id,omitempty"` // 可能所屬的既有案件
+
+	// 最新 AI 分析的摘要欄位（有分析且已 Preload 時才有值）
+	Category       *string `json:"category,omitempty"`
+	Priority       *string `json:"priority,omitempty"`
+	ActionRequired *bool   `json:"action_required,omitempty"`
 }
 
 // ToListResponse 轉換為列表 API 回應格式
@@ -125,7 +282,7 @@ func (e *Email) ToListResponse() EmailListResponse {
 	if dir == "" {
 		dir = EmailDirectionIncoming
 	}
-	return EmailListResponse{
+	resp := EmailListResponse{
 		ID:             e.ID,
 		Direction:      dir,
 		FromEmail:      e.FromEmail,
@@ -138,7 +295,16 @@ func (e *Email) ToListResponse() EmailListResponse {
 		Labels:         e.Labels,
 		CaseID:         e.CaseID,
 		AIAnalyzed:     e.AIAnalyzed,
+		SnoozedUntil:   e.SnoozedUntil,
+
+		SuggestedCaseID: e.SuggestedCaseID,
+	}
+	if a := e.AIAnalysis; a != nil {
+		resp.Category = &a.Category
+		resp.Priority = &a.Priority
+		resp.ActionRequired = &a.ActionRequired
 	}
+	return resp
 }
 
 // EmailDetailResponse 用於詳情 API 回應的結構
@@ -148,6 +314,8 @@ type EmailDetailResponse struct {
 	OAuthAccountID    uuid.UUID  `json:"oauth_account_id"`
 	ProviderMessageID string     `json:"provider_message_id"`
 	ThreadID          *string    `json:"thread_id,omitempty"`
+	RFCMessageID      *string    `json:"rfc_message_id,omitempty"`
+	DuplicateOfID     *uuid.UUID `json:"duplicate_of_id,omitempty"`
 	FromEmail         string     `json:"from_email"`
 	FromName          *string    `json:"from_name,omitempty"`
 	ToEmail           *string    `json:"to_email,omitempty"`
@@ -155,6 +323,7 @@ type EmailDetailResponse struct {
 	BodyText          *string    `json:"body_text,omitempty"`
 	BodyHTML          *string    `json:"body_html,omitempty"`
 	Snippet           *string    `json:"snippet,omitempty"`
+	BodyPurgedAt      *time.Time `json:"body_purged_at,omitempty"`
 	ReceivedAt        time.Time  `json:"received_at"`
 	IsRead            bool       `json:"is_read"`
 	HasAttachments    bool       `json:"has_attachments"`
@@ -162,8 +331,13 @@ type EmailDetailResponse struct {
 	CaseID            *uuid.UUID `json:"case_id,omitempty"`
 	AIAnalyzed        bool       `json:"ai_analyzed"`
 	AIAnalysisID      *uuid.UUID `json:"ai_analysis_id,omitempty"`
+	SnoozedUntil      *time.Time `json:"snoozed_until,omitempty"`
+	StyleExcluded     bool       `json:"style_excluded"`
 	CreatedAt         time.Time  `json:"created_at"`
 	UpdatedAt         time.Time  `json:"updated_at"`
+
+	SuggestedCaseID     *uuid.UUID `json:"suggested_case_id,omitempty"` // 可能所屬的既有案件（待使用者確認）
+	SuggestedCaseReason *string    `json:"suggested_case_reason,omitempty"`
 }
 
 // ToDetailResponse 轉換為詳情 API 回應格式
@@ -178,6 +352,8 @@ func (e *Email) ToDetailResponse() EmailDetailResponse {
 		OAuthAccountID:    e.OAuthAccountID,
 		ProviderMessageID: e.ProviderMessageID,
 		ThreadID:          e.ThreadID,
+		RFCMessageID:      e.RFCMessageID,
+		DuplicateOfID:     e.DuplicateOfID,
 		FromEmail:         e.FromEmail,
 		FromName:          e.FromName,
 		ToEmail:           e.ToEmail,
@@ -185,6 +361,7 @@ func (e *Email) ToDetailResponse() EmailDetailResponse {
 		BodyText:          e.BodyText,
 		BodyHTML:          e.BodyHTML,
 		Snippet:           e.Snippet,
+		BodyPurgedAt:      e.BodyPurgedAt,
 		ReceivedAt:        e.ReceivedAt,
 		IsRead:            e.IsRead,
 		HasAttachments:    e.HasAttachments,
@@ -192,17 +369,22 @@ func (e *Email) ToDetailResponse() EmailDetailResponse {
 		CaseID:            e.CaseID,
 		AIAnalyzed:        e.AIAnalyzed,
 		AIAnalysisID:      e.AIAnalysisID,
+		SnoozedUntil:      e.SnoozedUntil,
+		StyleExcluded:     e.StyleExcluded,
 		CreatedAt:         e.CreatedAt,
 		UpdatedAt:         e.UpdatedAt,
+
+		SuggestedCaseID:     e.SuggestedCaseID,
+		SuggestedCaseReason: e.SuggestedCaseReason,
 	}
 }
 
 // EmailQueryParams 郵件查詢參數
 type EmailQueryParams struct {
-	OAuthAccountID *uuid.UUID `form:"oauth_account_id"`
-	Direction      string     `form:"direction"` // incoming, outgoing 或空（全部）
+	OAuthAccountID *string    `form:"oauth_account_id" binding:"omitempty,uuid"` // gin 無法直接綁定 uuid.UUID（陣列型別），以字串接收
+	Direction      string     `form:"direction"`                                 // incoming, outgoing 或空（全部）
 	IsRead         *bool      `form:"is_read"`
-	CaseID         *uuid.UUID `form:"case_id"`
+	CaseID         *string    `form:"case_id" binding:"omitempty,uuid"`
 	FromEmail      string     `form:"from_email"`
 	Subject        string     `form:"subject"`
 	StartDate      *time.Time `form:"start_date"`
@@ -211,6 +393,44 @@ type EmailQueryParams struct {
 	PageSize       int        `form:"page_size" binding:"min=1,max=100"`
 	SortBy         string     `form:"sort_by"`    // received_at, created_at
 	SortOrder      string     `form:"sort_order"` // asc, desc
+
+	// 預設隱藏跨帳號重複的郵件；指定 oauth_account_id 或 include_duplicates=true 時顯示
+	IncludeDuplicates bool `form:"include_duplicates"`
+
+	// 預設隱藏延後處理（snooze）中的郵件
+	IncludeSnoozed bool `form:"include_snoozed"`
+
+	// 依最新 AI 分析篩選（指定任一項時只回傳已分析的郵件）
+	Category       string `form:"category"`
+	Priority       string `form:"priority" binding:"omitempty,oneof=low medium high"`
+	ActionRequired *bool  `form:"action_required"`
+}
+
+// emailSortColumns sort_by 可用的排序欄位
+var emailSortColumns = map[string]string{
+	"received_at": "emails.received_at",
+	"created_at":  "emails.created_at",
+	"category":    "ai_analyses.category",
+	"priority":    AIAnalysisPriorityOrder,
+}
+
+// OrderClause 排序子句；sort_by 不在允許清單時回傳 false
+func (q *EmailQueryParams) OrderClause() (string, bool) {
+	column, ok := emailSortColumns[q.SortBy]
+	if !ok {
+		return "", false
+	}
+	order := "DESC"
+	if strings.EqualFold(q.SortOrder, "asc") {
+		order = "ASC"
+	}
+	return column + " " + order, true
+}
+
+// NeedsAnalysisJoin 篩選或排序是否用到 AI 分析欄位
+func (q *EmailQueryParams) NeedsAnalysisJoin() bool {
+	return q.Category != "" || q.Priority != "" || q.ActionRequired != nil ||
+		q.SortBy == "category" || q.SortBy == "priority"
 }
 
 // SetDefaults 設定預設值
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EmailAttachmentStatus 附件文字擷取狀態
+type EmailAttachmentStatus string
+
+const (
+	EmailAttachmentStatusExtracted EmailAttachmentStatus = "extracted" // 已擷取文字
+	EmailAttachmentStatusSkipped   EmailAttachmentStatus = "skipped"   // 不支援的格式或超過大小上限
+	EmailAttachmentStatusFailed    EmailAttachmentStatus = "failed"    // 下載或解析失敗
+)
+
+// EmailAttachment 郵件附件及擷取出的文字（供 AI 分析使用）
+type EmailAttachment struct {
+	ID       uuid.UUID `gorm:"primary_key" json:"id"`
+	EmailID  uuid.UUID `gorm:"not null;uniqueIndex:idx_email_attachments_email_part,priority:1" json:"email_id"`
+	UserID   uuid.UUID `gorm:"not null;index" json:"user_id"`
+	PartID   string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_email_attachments_email_part,priority:2" json:"part_id"` // 郵件中的 MIME part
+	Filename string    `gorm:"type:varchar(500);not null" json:"filename"`
+	MimeType string    `gorm:"type:varchar(255)" json:"mime_type"`
+	Size     int64     `gorm:"not null;default:0" json:"size"`
+
+	Status    EmailAttachmentStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Kind      string                `gorm:"type:varchar(10)" json:"kind,omitempty"` // pdf / docx / xlsx / text
+	Pages     int                   `gorm:"not null;default:0" json:"pages"`        // 讀取的頁數（XLSX 為工作表數）
+	Truncated bool                  `gorm:"default:false" json:"truncated"`         // 是否因頁數或字數上限截斷
+	Text      *string               `gorm:"type:text" json:"text,omitempty"`        // 擷取出的全文
+	Summary   *string               `gorm:"type:text" json:"summary,omitempty"`     // 長文件的 AI 摘要
+	Error     *string               `gorm:"type:text" json:"error,omitempty"`       // 略過或失敗的原因
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Email Email `gorm:"foreignKey:EmailID;constraint:OnDelete:CASCADE" json:"-"`
+	User  User  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName 指定表名
+func (EmailAttachment) TableName() string {
+	return "email_attachments"
+}
+
+// BeforeCreate GORM hook - 在創建前執行
+func (a *EmailAttachment) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// Content 送入 AI 分析的內容：長文件使用摘要，否則使用全文
+func (a *EmailAttachment) Content() (string, bool) {
+	if a.Summary != nil && *a.Summary != "" {
+		return *a.Summary, true
+	}
+	if a.Text != nil {
+		return *a.Text, false
+	}
+	return "", false
+}
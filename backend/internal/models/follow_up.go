@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FollowUpStatus 跟進狀態
+type FollowUpStatus string
+
+const (
+	FollowUpStatusWaiting    FollowUpStatus = "waiting"     // 等待對方回覆
+	FollowUpStatusDue        FollowUpStatus = "due"         // 超過期限未回覆，需要跟進
+	FollowUpStatusReplied    FollowUpStatus = "replied"     // 對方已回覆
+	FollowUpStatusFollowedUp FollowUpStatus = "followed_up" // 使用者已在同一串再寄信（改追蹤新的那封）
+	FollowUpStatusDismissed  FollowUpStatus = "dismissed"   // 使用者略過
+)
+
+// FollowUp 寄出郵件的回覆追蹤
+// 案件相關的寄出郵件在指定工作天數內沒有收到回覆時轉為 due，並可預先產生跟進信草稿
+type FollowUp struct {
+	ID      uuid.UUID `gorm:"primary_key" json:"id"`
+	UserID  uuid.UUID `gorm:"not null;index" json:"user_id"`
+	CaseID  uuid.UUID `gorm:"not null;index" json:"case_id"`
+	EmailID uuid.UUID `gorm:"not null;uniqueIndex" json:"email_id"` // 寄出的郵件
+
+	ThreadID *string        `gorm:"type:varchar(255);index" json:"thread_id,omitempty"`
+	Status   FollowUpStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+
+	SentAt time.Time `gorm:"not null" json:"sent_at"`
+	DueAt  time.Time `gorm:"not null;index" json:"due_at"`
+
+	RepliedAt    *time.Time `json:"replied_at,omitempty"`
+	ReplyEmailID *uuid.UUID `json:"reply_email_id,omitempty"`
+
+	// AI 預先產生的跟進信草稿
+	Draft     *string    `gorm:"type:text" json:"draft,omitempty"`
+	DraftedAt *time.Time `json:"drafted_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName 指定表名
+func (FollowUp) TableName() string {
+	return "follow_ups"
+}
+
+// BeforeCreate GORM hook
+func (f *FollowUp) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsOpen 是否仍在追蹤中
+func (f *FollowUp) IsOpen() bool {
+	return f.Status == FollowUpStatusWaiting || f.Status == FollowUpStatusDue
+}
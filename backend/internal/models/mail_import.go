@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// MailImportStatus 郵件匯入狀態
+type MailImportStatus string
+
+const (
+	MailImportStatusPending    MailImportStatus = "pending"
+	MailImportStatusProcessing MailImportStatus = "processing"
+	MailImportStatusCompleted  MailImportStatus = "completed"
+	MailImportStatusFailed     MailImportStatus = "failed"
+)
+
+// MailImportError 單封郵件的匯入錯誤
+type MailImportError struct {
+	Source string `json:"source"` // 檔名（mbox 內則為 檔名#序號）
+	Error  string `json:"error"`
+}
+
+// MailImport 郵件匯入工作（.eml / .mbox / zip）
+type MailImport struct {
+	ID             uuid.UUID        `gorm:"primary_key" json:"id"`
+	UserID         uuid.UUID        `gorm:"not null;index" json:"user_id"`
+	OAuthAccountID uuid.UUID        `gorm:"column:oauth_account_id;not null" json:"oauth_account_id"` // 匯入用的虛擬帳號
+	Filename       string           `gorm:"type:varchar(255);not null" json:"filename"`
+	Status         MailImportStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	StoragePath    string           `gorm:"type:varchar(500)" json:"-"` // 上傳檔案的存放路徑（匯入結束後刪除）
+
+	// 進度
+	TotalMessages  int `gorm:"not null;default:0" json:"total_messages"`  // 已讀取的郵件數
+	ImportedCount  int `gorm:"not null;default:0" json:"imported_count"`  // 成功匯入
+	DuplicateCount int `gorm:"not null;default:0" json:"duplicate_count"` // 已存在（重複匯入或其他帳號已有）而略過
+	FailedCount    int `gorm:"not null;default:0" json:"failed_count"`    // 解析或儲存失敗
+
+	Errors       datatypes.JSON `gorm:"type:jsonb" json:"errors,omitempty"` // []MailImportError（最多保留前 100 筆）
+	ErrorMessage *string        `gorm:"type:text" json:"error_message,omitempty"`
+
+	StartedAt   *time.Time     `json:"started_at,omitempty"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// 關聯
+	User         User         `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	OAuthAccount OAuthAccount `gorm:"foreignKey:OAuthAccountID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName 指定表名
+func (MailImport) TableName() string {
+	return "mail_imports"
+}
+
+// BeforeCreate GORM hook
+func (mi *MailImport) BeforeCreate(tx *gorm.DB) error {
+	if mi.ID == uuid.Nil {
+		mi.ID = uuid.New()
+	}
+	return nil
+}
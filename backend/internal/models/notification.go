@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationType 站內通知類型
+type NotificationType string
+
+const (
+	NotificationTypeSnoozeExpired    NotificationType = "snooze_expired"     // 延後處理的郵件或案件到期
+	NotificationTypeFollowUpDue      NotificationType = "follow_up_due"      // 寄出的郵件超過期限未獲回覆
+	NotificationTypeAIBudgetWarning  NotificationType = "ai_budget_warning"  // AI 用量接近每月額度
+	NotificationTypeAIBudgetExceeded NotificationType = "ai_budget_exceeded" // AI 用量已達每月額度
+	NotificationTypeCaseUpdate       NotificationType = "case_update"        // AI 建議的案件更新待確認
+)
+
+// Notification 站內通知
+type Notification struct {
+	ID     uuid.UUID `gorm:"primary_key" json:"id"`
+	UserID uuid.UUID `gorm:"not null;index" json:"user_id"`
+
+	Type    NotificationType `gorm:"type:varchar(50);not null;index" json:"type"`
+	Title   string           `gorm:"type:varchar(500);not null" json:"title"`
+	Message *string          `gorm:"type:text" json:"message,omitempty"`
+
+	// 通知相關的郵件或案件（點擊後導向）
+	EmailID *uuid.UUID `gorm:"index" json:"email_id,omitempty"`
+	CaseID  *uuid.UUID `gorm:"index" json:"case_id,omitempty"`
+
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `gorm:"index" json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName 指定表名
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// BeforeCreate GORM hook
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
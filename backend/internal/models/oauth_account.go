@@ -15,6 +15,9 @@ const (
 	OAuthProviderGoogle  OAuthProvider = "google"
 	OAuthProviderOutlook OAuthProvider = "outlook"
 	OAuthProviderApple   OAuthProvider = "apple"
+
+	// OAuthProviderImported 匯入郵件用的虛擬帳號（.eml / .mbox 匯入），不參與同步
+	OAuthProviderImported OAuthProvider = "imported"
 )
 
 // SyncStatus 同步狀態
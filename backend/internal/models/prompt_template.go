@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PromptTemplate 資料庫中的提示詞範本版本（覆寫內建範本）
+// UserID 為 nil 的全域版本依 RolloutPercent 分流（A/B），有 UserID 的為個人覆寫，該使用者一律使用
+type PromptTemplate struct {
+	ID uuid.UUID `gorm:"primary_key" json:"id"`
+
+	Name    string     `gorm:"size:50;not null;index:idx_prompt_templates_name_version,priority:1" json:"name"` // classify / extract / draft / match_items / match_workflow / reply_analysis
+	Version string     `gorm:"size:50;not null;index:idx_prompt_templates_name_version,priority:2" json:"version"`
+	UserID  *uuid.UUID `gorm:"index" json:"user_id,omitempty"`
+
+	// text/template 語法，需定義 system 與 user 兩個區塊
+	Body string `gorm:"type:text;not null" json:"body"`
+
+	RolloutPercent int     `gorm:"not null;default:0" json:"rollout_percent"` // 全域版本分流到的使用者比例（0-100）
+	Active         bool    `gorm:"not null;default:true" json:"active"`
+	Note           *string `gorm:"type:text" json:"note,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (PromptTemplate) TableName() string {
+	return "prompt_templates"
+}
+
+// BeforeCreate GORM hook
+func (p *PromptTemplate) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// QuotationStatus 報價單狀態
+type QuotationStatus string
+
+const (
+	QuotationStatusDraft      QuotationStatus = "draft"      // 草稿
+	QuotationStatusSent       QuotationStatus = "sent"       // 已隨回信寄出
+	QuotationStatusAccepted   QuotationStatus = "accepted"   // 對方已接受（案件報價金額以此版為準）
+	QuotationStatusSuperseded QuotationStatus = "superseded" // 曾被接受，後來改接受其他版本
+)
+
+// Quotation 案件報價單（每次修改建立新版本）
+type Quotation struct {
+	ID      uuid.UUID `gorm:"primary_key" json:"id"`
+	UserID  uuid.UUID `gorm:"not null;index" json:"user_id"`
+	CaseID  uuid.UUID `gorm:"not null;uniqueIndex:idx_quotations_case_version,priority:1" json:"case_id"`
+	Version int       `gorm:"not null;uniqueIndex:idx_quotations_case_version,priority:2" json:"version"` // 同一案件的第幾版
+	Number  string    `gorm:"type:varchar(50);not null" json:"number"`                                    // 報價單號
+
+	Status   QuotationStatus `gorm:"type:varchar(20);not null;default:'draft'" json:"status"`
+	Currency string          `gorm:"type:varchar(10);not null" json:"currency"`
+
+	// 明細（[]QuotationLine）與金額
+	Lines       datatypes.JSON `gorm:"type:jsonb" json:"lines"`
+	Subtotal    float64        `gorm:"type:numeric(12,2);not null;default:0" json:"subtotal"`   // 明細小計（已扣除各項折扣）
+	Discount    float64        `gorm:"type:numeric(12,2);not null;default:0" json:"discount"`   // 整單折扣金額
+	TaxRate     float64        `gorm:"type:numeric(5,2);not null;default:0" json:"tax_rate"`    // 稅率（%）
+	TaxAmount   float64        `gorm:"type:numeric(12,2);not null;default:0" json:"tax_amount"` // 稅額
+	Total       float64        `gorm:"type:numeric(12,2);not null;default:0" json:"total"`      // 總計
+	ValidUntil  *time.Time     `gorm:"type:date" json:"valid_until,omitempty"`                  // 報價有效期限
+	Terms       *string        `gorm:"type:text" json:"terms,omitempty"`                        // 付款與合作條款
+	Notes       *string        `gorm:"type:text" json:"notes,omitempty"`                        // 備註
+	SentEmailID *uuid.UUID     `gorm:"index" json:"sent_email_id,omitempty"`                    // 附上此報價單寄出的回信
+	AcceptedAt  *time.Time     `json:"accepted_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Case Case `gorm:"foreignKey:CaseID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// QuotationLine 報價明細
+type QuotationLine struct {
+	ItemID          *string `json:"item_id,omitempty"` // 對應的合作項目
+	Title           string  `json:"title"`
+	Description     string  `json:"description,omitempty"`
+	Quantity        float64 `json:"quantity"`
+	UnitPrice       float64 `json:"unit_price"`
+	DiscountPercent float64 `json:"discount_percent,omitempty"` // 此項折扣（%）
+	Amount          float64 `json:"amount"`                     // 數量 × 單價 扣除折扣
+}
+
+// TableName 指定表名
+func (Quotation) TableName() string {
+	return "quotations"
+}
+
+// BeforeCreate GORM hook - 在創建前執行
+func (q *Quotation) BeforeCreate(tx *gorm.DB) error {
+	if q.ID == uuid.Nil {
+		q.ID = uuid.New()
+	}
+	return nil
+}
+
+// LineItems 解出報價明細
+func (q *Quotation) LineItems() ([]QuotationLine, error) {
+	var lines []QuotationLine
+	if len(q.Lines) == 0 {
+		return lines, nil
+	}
+	if err := json.Unmarshal(q.Lines, &lines); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReplyDraft AI 產生的案件回信草稿
+type ReplyDraft struct {
+	ID      uuid.UUID  `gorm:"primary_key" json:"id"`
+	UserID  uuid.UUID  `gorm:"not null;index" json:"user_id"`
+	CaseID  uuid.UUID  `gorm:"not null;index" json:"case_id"`
+	EmailID *uuid.UUID `gorm:"index" json:"email_id,omitempty"` // 回覆的郵件
+
+	Instruction   *string `gorm:"type:text" json:"instruction,omitempty"` // 使用者補充說明
+	Content       string  `gorm:"type:text;not null" json:"content"`
+	PromptVersion string  `gorm:"type:varchar(100)" json:"prompt_version"`
+	Streamed      bool    `gorm:"default:false" json:"streamed"`            // 是否以串流方式產生
+	Stance        string  `gorm:"type:varchar(20)" json:"stance,omitempty"` // 立場：accept / negotiate / decline
+	Tone          string  `gorm:"type:varchar(20)" json:"tone,omitempty"`   // 語氣：friendly / formal / casual
+
+	// 修改紀錄：依回饋修改時建立新的一版，同一份草稿的各版共用 RootID
+	RootID   uuid.UUID  `gorm:"not null;index" json:"root_id"`       // 第一版的 ID
+	ParentID *uuid.UUID `gorm:"index" json:"parent_id,omitempty"`    // 由哪一版修改而來
+	Revision int        `gorm:"default:1" json:"revision"`           // 版本序號（第一版為 1）
+	Feedback *string    `gorm:"type:text" json:"feedback,omitempty"` // 這一版的修改要求
+
+	CreatedAt time.Time `json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Case Case `gorm:"foreignKey:CaseID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName 指定表名
+func (ReplyDraft) TableName() string {
+	return "reply_drafts"
+}
+
+// BeforeCreate GORM hook - 在創建前執行
+func (d *ReplyDraft) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	if d.RootID == uuid.Nil {
+		d.RootID = d.ID
+	}
+	if d.Revision == 0 {
+		d.Revision = 1
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// RetentionPolicy 使用者的資料保留設定
+// 沒有設定時使用 DefaultRetentionPolicy（只清除超過期限的軟刪除資料，不動郵件內文）
+type RetentionPolicy struct {
+	ID     uuid.UUID `gorm:"primary_key" json:"id"`
+	UserID uuid.UUID `gorm:"not null;uniqueIndex" json:"user_id"`
+
+	Enabled bool `gorm:"not null" json:"enabled"` // 關閉時排程任務略過此使用者
+
+	// 非案件郵件的內文保留天數（超過後清除 body_text / body_html，保留 snippet 與 header）；nil 表示永久保留
+	EmailBodyRetentionDays *int `json:"email_body_retention_days,omitempty"`
+
+	// 案件相關郵件永久保留（關閉時案件郵件內文也套用 EmailBodyRetentionDays）
+	KeepCaseEmails bool `gorm:"not null" json:"keep_case_emails"`
+
+	// 軟刪除資料在幾天後永久刪除
+	SoftDeletePurgeDays int `gorm:"not null" json:"soft_delete_purge_days"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName 指定表名
+func (RetentionPolicy) TableName() string {
+	return "retention_policies"
+}
+
+// BeforeCreate GORM hook
+func (rp *RetentionPolicy) BeforeCreate(tx *gorm.DB) error {
+	if rp.ID == uuid.Nil {
+		rp.ID = uuid.New()
+	}
+	return nil
+}
+
+// DefaultRetentionPolicy 使用者尚未設定時的預設保留政策
+func DefaultRetentionPolicy(userID uuid.UUID, softDeletePurgeDays int) RetentionPolicy {
+	return RetentionPolicy{
+		UserID:              userID,
+		Enabled:             true,
+		KeepCaseEmails:      true,
+		SoftDeletePurgeDays: softDeletePurgeDays,
+	}
+}
+
+// RetentionAudit 資料清除紀錄（每次執行、每位使用者一筆）
+type RetentionAudit struct {
+	ID     uuid.UUID `gorm:"primary_key" json:"id"`
+	UserID uuid.UUID `gorm:"not null;index" json:"user_id"`
+
+	Status string         `gorm:"type:varchar(20);not null" json:"status"` // completed, partial, failed
+	Policy datatypes.JSON `gorm:"type:jsonb" json:"policy"`                // 執行時套用的政策快照
+	Counts datatypes.JSON `gorm:"type:jsonb" json:"counts"`                // 各項目清除數量，如 {"emails.body_purged": 12}
+	Total  int64          `gorm:"not null;default:0" json:"total"`
+	Error  *string        `gorm:"type:text" json:"error,omitempty"`
+
+	StartedAt   time.Time `gorm:"not null" json:"started_at"`
+	CompletedAt time.Time `gorm:"not null" json:"completed_at"`
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName 指定表名
+func (RetentionAudit) TableName() string {
+	return "retention_audits"
+}
+
+// BeforeCreate GORM hook
+func (ra *RetentionAudit) BeforeCreate(tx *gorm.DB) error {
+	if ra.ID == uuid.Nil {
+		ra.ID = uuid.New()
+	}
+	return nil
+}
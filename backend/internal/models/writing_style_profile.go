@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// WritingStyleProfile 使用者的寫作風格（由寄出郵件統計，擬信時模仿）
+type WritingStyleProfile struct {
+	ID     uuid.UUID `gorm:"primary_key" json:"id"`
+	UserID uuid.UUID `gorm:"not null;uniqueIndex" json:"user_id"`
+
+	SampleCount int `gorm:"not null;default:0" json:"sample_count"` // 納入統計的寄出郵件數
+
+	// 常用開頭與結尾，如 [{"text":"Hi {name},","count":12}]
+	Greetings datatypes.JSON `gorm:"type:jsonb" json:"greetings"`
+	Closings  datatypes.JSON `gorm:"type:jsonb" json:"closings"`
+
+	Formality      string  `gorm:"type:varchar(20)" json:"formality"` // formal / neutral / casual
+	FormalityScore float64 `json:"formality_score"`                   // -1（口語）~ 1（正式）
+	ChineseRatio   float64 `json:"chinese_ratio"`                     // 中文佔內文的比例（其餘為英文）
+	MedianLength   int     `json:"median_length"`                     // 內文字數中位數（不含引用）
+
+	EmojiRate    float64        `json:"emoji_rate"`                      // 含表情符號的郵件比例
+	CommonEmojis datatypes.JSON `gorm:"type:jsonb" json:"common_emojis"` // 最常用的表情符號
+
+	RefreshedAt time.Time `json:"refreshed_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName 指定表名
+func (WritingStyleProfile) TableName() string {
+	return "writing_style_profiles"
+}
+
+// BeforeCreate GORM hook - 在創建前執行
+func (p *WritingStyleProfile) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
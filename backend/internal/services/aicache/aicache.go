@@ -0,0 +1,121 @@
+package aicache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Store 以資料庫保存 AI 結構化輸出（實作 openai.ResultCache）
+type Store struct {
+	db  *gorm.DB
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewStore 建立結果快取
+func NewStore(db *gorm.DB, ttl time.Duration) *Store {
+	if ttl <= 0 {
+		ttl = 7 * 24 * time.Hour
+	}
+	return &Store{db: db, ttl: ttl, now: time.Now}
+}
+
+// keyHash 將所有鍵欄位合併為固定長度的索引鍵
+func keyHash(key openai.CacheKey) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		key.UserID, string(key.Operation), key.Model, key.PromptVersion, key.InputHash,
+	}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetResult 取得未過期的快取結果，命中時累加次數
+func (s *Store) GetResult(key openai.CacheKey) ([]byte, bool, error) {
+	var entry models.AIResultCache
+	err := s.db.Where("cache_key = ? AND expires_at > ?", keyHash(key), s.now()).First(&entry).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read ai result cache: %w", err)
+	}
+
+	s.db.Model(&entry).UpdateColumn("hits", gorm.Expr("hits + 1"))
+	return entry.Result, true, nil
+}
+
+// PutResult 寫入或覆蓋快取結果，重新計算到期時間
+func (s *Store) PutResult(key openai.CacheKey, result []byte) error {
+	now := s.now()
+	entry := models.AIResultCache{
+		UserID:        parseID(key.UserID),
+		CacheKey:      keyHash(key),
+		Operation:     string(key.Operation),
+		Model:         key.Model,
+		PromptVersion: key.PromptVersion,
+		Result:        datatypes.JSON(result),
+		ExpiresAt:     now.Add(s.ttl),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cache_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"result", "hits", "expires_at", "updated_at"}),
+	}).Create(&entry).Error
+	if err != nil {
+		return fmt.Errorf("failed to write ai result cache: %w", err)
+	}
+	return nil
+}
+
+// Invalidate 刪除使用者指定用途的快取（未指定用途時刪除全部），例如合作項目變更後清除比對結果
+func Invalidate(db *gorm.DB, userID uuid.UUID, operations ...openai.Operation) (int64, error) {
+	query := db.Where("user_id = ?", userID)
+	if len(operations) > 0 {
+		ops := make([]string, 0, len(operations))
+		for _, op := range operations {
+			ops = append(ops, string(op))
+		}
+		query = query.Where("operation IN ?", ops)
+	}
+	result := query.Delete(&models.AIResultCache{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to invalidate ai result cache: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// InvalidatePrompt 刪除以指定範本版本（如 classify@v2）產生的快取，範本內容修改後使用
+func InvalidatePrompt(db *gorm.DB, label string) (int64, error) {
+	result := db.Where("prompt_version = ?", label).Delete(&models.AIResultCache{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to invalidate ai result cache: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// PurgeExpired 刪除過期的快取
+func PurgeExpired(db *gorm.DB, now time.Time) (int64, error) {
+	result := db.Where("expires_at <= ?", now).Delete(&models.AIResultCache{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge ai result cache: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func parseID(s string) *uuid.UUID {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,120 @@
+package aicache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 設置測試用的資料庫（使用 SQLite）
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Skipf("Skipping test: SQLite not available (CGO required): %v", err)
+	}
+	require.NoError(t, db.AutoMigrate(&models.AIResultCache{}))
+	return db
+}
+
+func testKey(userID uuid.UUID, op openai.Operation, input string) openai.CacheKey {
+	return openai.CacheKey{
+		UserID:        userID.String(),
+		Operation:     op,
+		Model:         "gpt-4o-mini",
+		PromptVersion: string(op) + "@v1",
+		InputHash:     openai.HashInput("tool", []openai.ChatMessage{{Role: openai.RoleUser, Content: input}}),
+	}
+}
+
+func TestStore_GetPutAndExpire(t *testing.T) {
+	db := setupTestDB(t)
+	store := NewStore(db, time.Hour)
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	key := testKey(uuid.New(), openai.OperationClassify, "合作邀約")
+
+	_, ok, err := store.GetResult(key)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, store.PutResult(key, []byte(`{"category":"collaboration"}`)))
+	require.NoError(t, store.PutResult(key, []byte(`{"category":"payment"}`))) // 覆蓋
+
+	result, ok, err := store.GetResult(key)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.JSONEq(t, `{"category":"payment"}`, string(result))
+
+	var entry models.AIResultCache
+	require.NoError(t, db.First(&entry).Error)
+	assert.Equal(t, 1, entry.Hits)
+
+	// 模型或 prompt 版本不同視為不同鍵
+	other := key
+	other.Model = "gpt-4o"
+	_, ok, _ = store.GetResult(other)
+	assert.False(t, ok)
+
+	now = now.Add(2 * time.Hour)
+	_, ok, err = store.GetResult(key)
+	require.NoError(t, err)
+	assert.False(t, ok, "expired entries must not be returned")
+}
+
+func TestInvalidate_ByUserAndOperation(t *testing.T) {
+	db := setupTestDB(t)
+	store := NewStore(db, time.Hour)
+	userID := uuid.New()
+	other := uuid.New()
+
+	require.NoError(t, store.PutResult(testKey(userID, openai.OperationMatch, "a"), []byte(`{}`)))
+	require.NoError(t, store.PutResult(testKey(userID, openai.OperationClassify, "a"), []byte(`{}`)))
+	require.NoError(t, store.PutResult(testKey(other, openai.OperationMatch, "a"), []byte(`{}`)))
+
+	deleted, err := Invalidate(db, userID, openai.OperationMatch)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, ok, _ := store.GetResult(testKey(userID, openai.OperationClassify, "a"))
+	assert.True(t, ok)
+	_, ok, _ = store.GetResult(testKey(other, openai.OperationMatch, "a"))
+	assert.True(t, ok)
+
+	deleted, err = Invalidate(db, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
+
+func TestInvalidatePromptAndPurgeExpired(t *testing.T) {
+	db := setupTestDB(t)
+	store := NewStore(db, time.Hour)
+	userID := uuid.New()
+	now := time.Now()
+
+	current := testKey(userID, openai.OperationMatch, "current")
+	edited := testKey(userID, openai.OperationMatch, "edited")
+	edited.PromptVersion = "match_items@v2"
+	require.NoError(t, store.PutResult(current, []byte(`{}`)))
+	require.NoError(t, store.PutResult(edited, []byte(`{}`)))
+
+	deleted, err := InvalidatePrompt(db, "match_items@v2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	purged, err := PurgeExpired(db, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), purged)
+
+	purged, err = PurgeExpired(db, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+}
@@ -0,0 +1,148 @@
+package aieval
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider 依工具名稱回傳固定的結構化結果
+type fakeProvider struct {
+	calls int
+	err   error
+}
+
+func (p *fakeProvider) Name() string  { return config.LLMProviderOpenAI }
+func (p *fakeProvider) Model() string { return "gpt-4o-mini" }
+
+func (p *fakeProvider) Chat(ctx context.Context, req openai.ChatRequest) (*openai.ChatResponse, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	args := `{"category":"collaboration","confidence":0.9,"reason":"邀約"}`
+	if strings.Contains(req.Messages[len(req.Messages)-1].Content, "中獎") {
+		args = `{"category":"collaboration","confidence":0.4,"reason":"誤判"}`
+	}
+	if req.Tool.Name == "extract_info" {
+		args = `{"brand_name":"Glow 台灣","contact_email":"amy@glow.example","amount":30000,"currency":"TWD","due_date":"2026-10-15"}`
+	}
+	return &openai.ChatResponse{ToolArguments: args, Model: "gpt-4o-mini", PromptTokens: 1000, CompletionTokens: 100}, nil
+}
+
+func newTestService(provider openai.LLMProvider) *openai.Service {
+	logger := zerolog.Nop()
+	svc := openai.NewService(config.Config{OpenAI: config.OpenAIConfig{APIKey: "test", Model: "gpt-4o-mini", MaxTokens: 500}}, &logger, "")
+	svc.WrapProviders(func(openai.LLMProvider) openai.LLMProvider { return provider })
+	return svc
+}
+
+var testCases = []Case{
+	{ID: "collab", Subject: "合作邀約", Body: "Glow 想邀請您拍攝開箱，預算 NT$35,000", Date: "2026-09-01", Category: openai.CategoryCollaboration,
+		Expected: map[string]string{"brand_name": "Glow", "amount": "35000", "due_date": "2026-10-15", "contact_phone": ""}},
+	{ID: "spam", Subject: "恭喜中獎", Body: "恭喜您中獎，點擊連結領取", Category: openai.CategorySpam},
+}
+
+func TestRunner_RecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recordings.json")
+
+	live := &fakeProvider{}
+	tape, err := NewTape(ModeRecord, path)
+	require.NoError(t, err)
+	report := NewRunner(newTestService(live), tape).Run(context.Background(), testCases)
+	require.NoError(t, tape.Save(path))
+	assert.Equal(t, 3, live.calls)
+
+	assert.Equal(t, 0, report.Errors)
+	assert.Equal(t, 0.5, report.Accuracy)
+	assert.Equal(t, 0.5, report.Categories["collaboration"].Precision)
+	assert.Equal(t, 1.0, report.Categories["collaboration"].Recall)
+	assert.Equal(t, 0.0, report.Categories["spam"].Recall)
+	assert.Equal(t, 0.75, report.FieldAccuracy)
+	assert.False(t, report.Results[0].Fields[0].Correct) // amount 30000 ≠ 35000
+	assert.Equal(t, []string{"classify@v2", "extract@v3"}, report.PromptVersions)
+	assert.Equal(t, 3300, report.Tokens)
+	assert.Greater(t, report.CostUSD, 0.0)
+
+	// 重播不呼叫後端，結果與錄製時相同
+	offline := &fakeProvider{err: errors.New("network disabled")}
+	replay, err := NewTape(ModeReplay, path)
+	require.NoError(t, err)
+	replayed := NewRunner(newTestService(offline), replay).Run(context.Background(), testCases)
+	assert.Equal(t, 0, offline.calls)
+	assert.Equal(t, report.Accuracy, replayed.Accuracy)
+	assert.Equal(t, report.FieldAccuracy, replayed.FieldAccuracy)
+	assert.Equal(t, report.Tokens, replayed.Tokens)
+
+	// 內容變更後沒有對應的錄製回應
+	changed := append([]Case(nil), testCases...)
+	changed[1].Body = "內容已修改"
+	missing := NewRunner(newTestService(offline), replay).Run(context.Background(), changed)
+	assert.Equal(t, 1, missing.Errors)
+	assert.Contains(t, missing.Results[1].Error, ErrNotRecorded.Error())
+}
+
+func TestCompare(t *testing.T) {
+	baseline := BuildReport([]CaseResult{
+		{ID: "a", Expected: "collaboration", Predicted: "collaboration", CostUSD: 0.001, LatencyMS: 900},
+		{ID: "b", Expected: "spam", Predicted: "spam", CostUSD: 0.001, LatencyMS: 800},
+		{ID: "c", Expected: "payment", Predicted: "other", CostUSD: 0.001, LatencyMS: 700},
+	})
+	current := BuildReport([]CaseResult{
+		{ID: "a", Expected: "collaboration", Predicted: "collaboration", CostUSD: 0.001, LatencyMS: 900},
+		{ID: "b", Expected: "spam", Predicted: "collaboration", CostUSD: 0.001, LatencyMS: 800},
+		{ID: "c", Expected: "payment", Predicted: "payment", CostUSD: 0.001, LatencyMS: 700},
+		{ID: "d", Expected: "other", Predicted: "other", CostUSD: 0.001, LatencyMS: 600},
+	})
+
+	cmp := Compare(baseline, current, 0.02, 0.2)
+	assert.Equal(t, []string{"b"}, cmp.Broken)
+	assert.Equal(t, []string{"c"}, cmp.Fixed)
+	assert.Equal(t, []string{"d"}, cmp.Missing)
+	assert.True(t, cmp.Regressed()) // spam 召回率 1 → 0
+
+	same := Compare(baseline, baseline, 0.02, 0.2)
+	assert.False(t, same.Regressed())
+	var out strings.Builder
+	same.WriteText(&out)
+	assert.Contains(t, out.String(), "No metric changes")
+}
+
+func TestFieldMatchers(t *testing.T) {
+	assert.True(t, fuzzyMatch("Glow", "Glow 台灣"))
+	assert.True(t, fuzzyMatch("2萬-3萬", "2 萬 - 3 萬"))
+	assert.False(t, fuzzyMatch("", "Glow"))
+	assert.True(t, digitsMatch("0912345678", "0912-345-678"))
+	assert.True(t, amountMatch("35000", "35000.00"))
+	assert.False(t, amountMatch("", "0"))
+	assert.True(t, exactMatch("usd", "USD"))
+}
+
+func TestLoadDataset(t *testing.T) {
+	// 隨附的標註資料必須可以載入
+	cases, err := LoadDataset(filepath.Join("..", "..", "..", "evals", "golden.jsonl"))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, len(cases), 20)
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+	_, err = LoadDataset(write("dup.jsonl", `{"id":"a","subject":"s","category":"other"}`+"\n"+`{"id":"a","subject":"s","category":"other"}`))
+	assert.ErrorContains(t, err, "duplicate id")
+	_, err = LoadDataset(write("category.jsonl", `{"id":"a","subject":"s","category":"ads"}`))
+	assert.ErrorContains(t, err, "unknown category")
+	_, err = LoadDataset(write("field.jsonl", `{"id":"a","subject":"s","category":"other","expected":{"price":"1"}}`))
+	assert.ErrorContains(t, err, "unknown expected field")
+}
@@ -0,0 +1,109 @@
+package aieval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+)
+
+// Case 標註過的測試郵件（已去識別化）
+type Case struct {
+	ID       string               `json:"id"`
+	Subject  string               `json:"subject"`
+	From     string               `json:"from"`
+	Body     string               `json:"body"`
+	Date     string               `json:"date,omitempty"` // YYYY-MM-DD；相對日期（如「下週五」）以此為基準
+	Category openai.EmailCategory `json:"category"`       // 標註的分類
+	// 標註的擷取欄位（欄位名稱同 ExtractedInfo 的 JSON 名稱；空字串表示不應擷取出值）
+	// 未標註任何欄位的郵件只評估分類
+	Expected map[string]string `json:"expected,omitempty"`
+}
+
+// ReceivedAt 郵件日期（未標註時為零值）
+func (c *Case) ReceivedAt() time.Time {
+	t, _ := time.Parse("2006-01-02", c.Date)
+	return t
+}
+
+// LoadDataset 讀取 JSONL 格式的標註資料（每行一封郵件，# 開頭為註解）
+func LoadDataset(path string) ([]Case, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer f.Close()
+
+	var cases []Case
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var c Case
+		if err := json.Unmarshal([]byte(text), &c); err != nil {
+			return nil, fmt.Errorf("dataset line %d: %w", line, err)
+		}
+		if err := c.validate(); err != nil {
+			return nil, fmt.Errorf("dataset line %d: %w", line, err)
+		}
+		if seen[c.ID] {
+			return nil, fmt.Errorf("dataset line %d: duplicate id %q", line, c.ID)
+		}
+		seen[c.ID] = true
+		cases = append(cases, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("dataset %s is empty", path)
+	}
+	return cases, nil
+}
+
+// validate 確認標註完整
+func (c *Case) validate() error {
+	if c.ID == "" {
+		return fmt.Errorf("missing id")
+	}
+	if c.Subject == "" && c.Body == "" {
+		return fmt.Errorf("%s: missing subject and body", c.ID)
+	}
+	if !isCategory(c.Category) {
+		return fmt.Errorf("%s: unknown category %q", c.ID, c.Category)
+	}
+	if c.Date != "" && c.ReceivedAt().IsZero() {
+		return fmt.Errorf("%s: invalid date %q", c.ID, c.Date)
+	}
+	for field := range c.Expected {
+		if _, ok := fieldMatchers[field]; !ok {
+			return fmt.Errorf("%s: unknown expected field %q", c.ID, field)
+		}
+	}
+	return nil
+}
+
+// Categories 所有分類（報表依此順序列出）
+var Categories = []openai.EmailCategory{
+	openai.CategoryCollaboration, openai.CategoryPayment, openai.CategoryConfirmation, openai.CategoryInquiry,
+	openai.CategorySocial, openai.CategoryNewsletter, openai.CategoryNotification, openai.CategorySpam, openai.CategoryOther,
+}
+
+func isCategory(category openai.EmailCategory) bool {
+	for _, c := range Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
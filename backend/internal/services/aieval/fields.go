@@ -0,0 +1,94 @@
+package aieval
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+)
+
+// fieldMatcher 取出擷取結果的欄位值，並判斷是否符合標註
+type fieldMatcher struct {
+	get   func(info *openai.ExtractedInfo) string
+	match func(expected, got string) bool
+}
+
+// fieldMatchers 可評估的擷取欄位（名稱同 ExtractedInfo 的 JSON 名稱）
+var fieldMatchers = map[string]fieldMatcher{
+	"brand_name":     {func(i *openai.ExtractedInfo) string { return i.BrandName }, fuzzyMatch},
+	"contact_name":   {func(i *openai.ExtractedInfo) string { return i.ContactName }, fuzzyMatch},
+	"contact_email":  {func(i *openai.ExtractedInfo) string { return i.ContactEmail }, exactMatch},
+	"contact_phone":  {func(i *openai.ExtractedInfo) string { return i.ContactPhone }, digitsMatch},
+	"amount":         {amountValue, amountMatch},
+	"currency":       {func(i *openai.ExtractedInfo) string { return i.Currency }, exactMatch},
+	"due_date":       {dueDateValue, exactMatch},
+	"content_type":   {func(i *openai.ExtractedInfo) string { return i.ContentType }, fuzzyMatch},
+	"follower_count": {func(i *openai.ExtractedInfo) string { return i.FollowerCount }, fuzzyMatch},
+	"budget":         {func(i *openai.ExtractedInfo) string { return i.Budget }, fuzzyMatch},
+}
+
+func amountValue(info *openai.ExtractedInfo) string {
+	if info.Amount == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*info.Amount, 'f', -1, 64)
+}
+
+func dueDateValue(info *openai.ExtractedInfo) string {
+	if info.DueDate == nil {
+		return ""
+	}
+	return info.DueDate.Format("2006-01-02")
+}
+
+// exactMatch 忽略大小寫與前後空白
+func exactMatch(expected, got string) bool {
+	return strings.EqualFold(strings.TrimSpace(expected), strings.TrimSpace(got))
+}
+
+// digitsMatch 只比對數字（電話號碼格式不一）
+func digitsMatch(expected, got string) bool {
+	digits := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, s)
+	}
+	return digits(expected) == digits(got)
+}
+
+// amountMatch 數值相等
+func amountMatch(expected, got string) bool {
+	if expected == "" || got == "" {
+		return expected == got
+	}
+	e, err1 := strconv.ParseFloat(expected, 64)
+	g, err2 := strconv.ParseFloat(got, 64)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return math.Abs(e-g) < 0.005
+}
+
+// fuzzyMatch 自由文字欄位：正規化後相等，或一方包含另一方（如「Glow」與「Glow 台灣」）
+func fuzzyMatch(expected, got string) bool {
+	e, g := normalize(expected), normalize(got)
+	if e == "" || g == "" {
+		return e == g
+	}
+	return strings.Contains(g, e) || strings.Contains(e, g)
+}
+
+// normalize 轉小寫並去除空白與標點
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
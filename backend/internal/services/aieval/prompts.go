@@ -0,0 +1,38 @@
+package aieval
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+)
+
+// CandidateVersion 以檔案提供的候選範本版本
+const CandidateVersion = "candidate"
+
+// PromptOverrides 以檔案覆寫內建範本，在上線前評估修改後的提示詞（實作 openai.PromptSource）
+type PromptOverrides map[openai.PromptName]*openai.PromptTemplate
+
+// ParsePromptOverride 解析 name=path 格式的覆寫設定並驗證範本
+func (p PromptOverrides) ParsePromptOverride(spec string) error {
+	name, path, ok := strings.Cut(spec, "=")
+	if !ok || name == "" || path == "" {
+		return fmt.Errorf("invalid prompt override %q (want name=path)", spec)
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read prompt %s: %w", path, err)
+	}
+	prompt := openai.PromptName(name)
+	if err := openai.ValidatePrompt(prompt, string(body)); err != nil {
+		return fmt.Errorf("invalid prompt %s: %w", path, err)
+	}
+	p[prompt] = &openai.PromptTemplate{Name: prompt, Version: CandidateVersion, Body: string(body)}
+	return nil
+}
+
+// ResolvePrompt 實作 openai.PromptSource（未覆寫的範本使用內建版本）
+func (p PromptOverrides) ResolvePrompt(userID string, name openai.PromptName) (*openai.PromptTemplate, error) {
+	return p[name], nil
+}
@@ -0,0 +1,380 @@
+package aieval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+)
+
+// CaseResult 單封郵件的評估結果
+type CaseResult struct {
+	ID             string               `json:"id"`
+	Expected       openai.EmailCategory `json:"expected"`
+	Predicted      openai.EmailCategory `json:"predicted"`
+	Confidence     float64              `json:"confidence"`
+	Fields         []FieldResult        `json:"fields,omitempty"`
+	PromptVersions []string             `json:"prompt_versions,omitempty"`
+	Model          string               `json:"model,omitempty"`
+	Tokens         int                  `json:"tokens"`
+	CostUSD        float64              `json:"cost_usd"`
+	LatencyMS      int64                `json:"latency_ms"`
+	Error          string               `json:"error,omitempty"`
+}
+
+// Correct 分類是否正確
+func (r *CaseResult) Correct() bool {
+	return r.Error == "" && r.Predicted == r.Expected
+}
+
+// FieldResult 單一擷取欄位的比對結果
+type FieldResult struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Got      string `json:"got"`
+	Correct  bool   `json:"correct"`
+}
+
+// CategoryScore 單一分類的精確率與召回率
+type CategoryScore struct {
+	Support   int     `json:"support"`   // 標註為此分類的郵件數
+	Predicted int     `json:"predicted"` // 預測為此分類的郵件數
+	Correct   int     `json:"correct"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// FieldScore 單一擷取欄位的正確率
+type FieldScore struct {
+	Total    int     `json:"total"`
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+// Report 評估報表（也作為比較基準儲存）
+type Report struct {
+	GeneratedAt    time.Time                `json:"generated_at"`
+	Models         []string                 `json:"models"`
+	PromptVersions []string                 `json:"prompt_versions"`
+	Emails         int                      `json:"emails"`
+	Errors         int                      `json:"errors"`
+	Accuracy       float64                  `json:"accuracy"` // 分類正確率
+	MacroF1        float64                  `json:"macro_f1"` // 有標註資料的分類 F1 平均
+	Categories     map[string]CategoryScore `json:"categories"`
+	FieldAccuracy  float64                  `json:"field_accuracy"` // 所有標註欄位的正確率
+	Fields         map[string]FieldScore    `json:"fields"`
+	Tokens         int                      `json:"tokens"`
+	CostUSD        float64                  `json:"cost_usd"`
+	CostPerEmail   float64                  `json:"cost_per_email_usd"`
+	LatencyP50MS   int64                    `json:"latency_p50_ms"`
+	LatencyP95MS   int64                    `json:"latency_p95_ms"`
+	LatencyMaxMS   int64                    `json:"latency_max_ms"`
+	Results        []CaseResult             `json:"results"`
+}
+
+// BuildReport 彙總各郵件的結果
+func BuildReport(results []CaseResult) *Report {
+	r := &Report{
+		GeneratedAt: time.Now().UTC(),
+		Emails:      len(results),
+		Categories:  make(map[string]CategoryScore),
+		Fields:      make(map[string]FieldScore),
+		Results:     results,
+	}
+
+	models := make(map[string]bool)
+	versions := make(map[string]bool)
+	latencies := make([]int64, 0, len(results))
+	correct, fieldTotal, fieldCorrect := 0, 0, 0
+	for i := range results {
+		res := &results[i]
+		if res.Error != "" {
+			r.Errors++
+		}
+		if res.Model != "" {
+			models[res.Model] = true
+		}
+		for _, v := range res.PromptVersions {
+			versions[v] = true
+		}
+		r.Tokens += res.Tokens
+		r.CostUSD += res.CostUSD
+		latencies = append(latencies, res.LatencyMS)
+
+		expected := r.Categories[string(res.Expected)]
+		expected.Support++
+		if res.Correct() {
+			expected.Correct++
+			correct++
+		}
+		r.Categories[string(res.Expected)] = expected
+		if res.Predicted != "" {
+			predicted := r.Categories[string(res.Predicted)]
+			predicted.Predicted++
+			r.Categories[string(res.Predicted)] = predicted
+		}
+
+		for _, f := range res.Fields {
+			score := r.Fields[f.Field]
+			score.Total++
+			fieldTotal++
+			if f.Correct {
+				score.Correct++
+				fieldCorrect++
+			}
+			r.Fields[f.Field] = score
+		}
+	}
+
+	f1Sum, f1Count := 0.0, 0
+	for name, score := range r.Categories {
+		score.Precision = ratio(score.Correct, score.Predicted)
+		score.Recall = ratio(score.Correct, score.Support)
+		if score.Precision+score.Recall > 0 {
+			score.F1 = round4(2 * score.Precision * score.Recall / (score.Precision + score.Recall))
+		}
+		if score.Support > 0 {
+			f1Sum += score.F1
+			f1Count++
+		}
+		r.Categories[name] = score
+	}
+	for name, score := range r.Fields {
+		score.Accuracy = ratio(score.Correct, score.Total)
+		r.Fields[name] = score
+	}
+
+	r.Accuracy = ratio(correct, len(results))
+	if f1Count > 0 {
+		r.MacroF1 = round4(f1Sum / float64(f1Count))
+	}
+	r.FieldAccuracy = ratio(fieldCorrect, fieldTotal)
+	r.CostUSD = math.Round(r.CostUSD*1e6) / 1e6
+	if len(results) > 0 {
+		r.CostPerEmail = math.Round(r.CostUSD/float64(len(results))*1e6) / 1e6
+	}
+	r.LatencyP50MS, r.LatencyP95MS, r.LatencyMaxMS = percentile(latencies, 0.5), percentile(latencies, 0.95), percentile(latencies, 1)
+	r.Models = sortedKeys(models)
+	r.PromptVersions = sortedKeys(versions)
+	return r
+}
+
+// LoadReport 讀取儲存的報表（比較基準）
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to decode report %s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// Save 以 JSON 儲存報表
+func (r *Report) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// WriteText 輸出可讀的報表
+func (r *Report) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "Emails: %d (errors: %d)  Models: %s  Prompts: %s\n",
+		r.Emails, r.Errors, strings.Join(r.Models, ", "), strings.Join(r.PromptVersions, ", "))
+	fmt.Fprintf(w, "Classification accuracy: %.1f%%  Macro F1: %.3f  Field accuracy: %.1f%%\n",
+		r.Accuracy*100, r.MacroF1, r.FieldAccuracy*100)
+	fmt.Fprintf(w, "Cost: $%.4f ($%.5f/email, %d tokens)  Latency p50/p95/max: %d/%d/%d ms\n\n",
+		r.CostUSD, r.CostPerEmail, r.Tokens, r.LatencyP50MS, r.LatencyP95MS, r.LatencyMaxMS)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CATEGORY\tSUPPORT\tPREDICTED\tPRECISION\tRECALL\tF1")
+	for _, c := range Categories {
+		score, ok := r.Categories[string(c)]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.3f\t%.3f\t%.3f\n", c, score.Support, score.Predicted, score.Precision, score.Recall, score.F1)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tCORRECT\tTOTAL\tACCURACY")
+	for _, name := range sortedKeys(r.Fields) {
+		score := r.Fields[name]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f%%\n", name, score.Correct, score.Total, score.Accuracy*100)
+	}
+	tw.Flush()
+
+	var misses []string
+	for i := range r.Results {
+		res := &r.Results[i]
+		switch {
+		case res.Error != "":
+			misses = append(misses, fmt.Sprintf("  %s: error: %s", res.ID, res.Error))
+		case !res.Correct():
+			misses = append(misses, fmt.Sprintf("  %s: expected %s, got %s (%.2f)", res.ID, res.Expected, res.Predicted, res.Confidence))
+		}
+		for _, f := range res.Fields {
+			if !f.Correct && res.Error == "" {
+				misses = append(misses, fmt.Sprintf("  %s: %s expected %q, got %q", res.ID, f.Field, f.Expected, f.Got))
+			}
+		}
+	}
+	if len(misses) > 0 {
+		fmt.Fprintf(w, "\nMisses:\n%s\n", strings.Join(misses, "\n"))
+	}
+}
+
+// Change 與基準比較的差異
+type Change struct {
+	Metric     string  `json:"metric"`
+	Baseline   float64 `json:"baseline"`
+	Current    float64 `json:"current"`
+	Regression bool    `json:"regression"` // 變差超過容許範圍
+}
+
+// Comparison 與基準比較的結果
+type Comparison struct {
+	Changes []Change `json:"changes"`
+	Fixed   []string `json:"fixed"`   // 基準分類錯誤、這次正確的郵件
+	Broken  []string `json:"broken"`  // 基準分類正確、這次錯誤的郵件
+	Missing []string `json:"missing"` // 基準中沒有的郵件（資料集新增）
+}
+
+// Regressed 是否有指標變差超過容許範圍
+func (c *Comparison) Regressed() bool {
+	for _, ch := range c.Changes {
+		if ch.Regression {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare 比較這次結果與基準；品質指標下降超過 tolerance（比例，如 0.02）、
+// 或成本與延遲增加超過 costTolerance（比例，如 0.2 表示 20%）時標記為退步
+func Compare(baseline, current *Report, tolerance, costTolerance float64) *Comparison {
+	cmp := &Comparison{}
+	quality := func(metric string, base, cur float64) {
+		cmp.Changes = append(cmp.Changes, Change{Metric: metric, Baseline: base, Current: cur, Regression: base-cur > tolerance+1e-9})
+	}
+	expense := func(metric string, base, cur float64) {
+		cmp.Changes = append(cmp.Changes, Change{Metric: metric, Baseline: base, Current: cur, Regression: base > 0 && cur > base*(1+costTolerance)})
+	}
+
+	quality("accuracy", baseline.Accuracy, current.Accuracy)
+	quality("macro_f1", baseline.MacroF1, current.MacroF1)
+	quality("field_accuracy", baseline.FieldAccuracy, current.FieldAccuracy)
+	for _, c := range Categories {
+		base, ok1 := baseline.Categories[string(c)]
+		cur, ok2 := current.Categories[string(c)]
+		if ok1 && ok2 && base.Support > 0 {
+			quality("recall."+string(c), base.Recall, cur.Recall)
+			quality("precision."+string(c), base.Precision, cur.Precision)
+		}
+	}
+	for _, name := range sortedKeys(current.Fields) {
+		if base, ok := baseline.Fields[name]; ok {
+			quality("field."+name, base.Accuracy, current.Fields[name].Accuracy)
+		}
+	}
+	expense("cost_per_email_usd", baseline.CostPerEmail, current.CostPerEmail)
+	expense("latency_p95_ms", float64(baseline.LatencyP95MS), float64(current.LatencyP95MS))
+
+	before := make(map[string]bool, len(baseline.Results))
+	for i := range baseline.Results {
+		before[baseline.Results[i].ID] = baseline.Results[i].Correct()
+	}
+	for i := range current.Results {
+		res := &current.Results[i]
+		wasCorrect, ok := before[res.ID]
+		switch {
+		case !ok:
+			cmp.Missing = append(cmp.Missing, res.ID)
+		case wasCorrect && !res.Correct():
+			cmp.Broken = append(cmp.Broken, res.ID)
+		case !wasCorrect && res.Correct():
+			cmp.Fixed = append(cmp.Fixed, res.ID)
+		}
+	}
+	return cmp
+}
+
+// WriteText 輸出與基準的差異（只列出有變化的指標）
+func (c *Comparison) WriteText(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METRIC\tBASELINE\tCURRENT\tDELTA\t")
+	changed := 0
+	for _, ch := range c.Changes {
+		if math.Abs(ch.Current-ch.Baseline) < 1e-9 {
+			continue
+		}
+		changed++
+		flag := ""
+		if ch.Regression {
+			flag = "REGRESSION"
+		}
+		fmt.Fprintf(tw, "%s\t%.4g\t%.4g\t%+.4g\t%s\n", ch.Metric, ch.Baseline, ch.Current, ch.Current-ch.Baseline, flag)
+	}
+	if changed == 0 {
+		fmt.Fprintln(w, "No metric changes against baseline.")
+	} else {
+		tw.Flush()
+	}
+	if len(c.Broken) > 0 {
+		fmt.Fprintf(w, "Newly misclassified: %s\n", strings.Join(c.Broken, ", "))
+	}
+	if len(c.Fixed) > 0 {
+		fmt.Fprintf(w, "Newly correct: %s\n", strings.Join(c.Fixed, ", "))
+	}
+	if len(c.Missing) > 0 {
+		fmt.Fprintf(w, "Not in baseline: %s\n", strings.Join(c.Missing, ", "))
+	}
+}
+
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return round4(float64(n) / float64(d))
+}
+
+func round4(f float64) float64 {
+	return math.Round(f*1e4) / 1e4
+}
+
+// percentile 以最近排名法計算百分位數
+func percentile(values []int64, p float64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,131 @@
+package aieval
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+)
+
+// callTimeout 單封郵件（分類 + 擷取）的逾時
+const callTimeout = 2 * time.Minute
+
+// Runner 以 openai.Service 跑完標註資料並計算指標
+type Runner struct {
+	svc   *openai.Service
+	tape  *Tape
+	usage *usageCollector
+}
+
+// NewRunner 建立評估執行器（會包裝 svc 的後端並接手用量記錄，svc 只供評估使用）
+func NewRunner(svc *openai.Service, tape *Tape) *Runner {
+	usage := &usageCollector{}
+	svc.SetUsageRecorder(usage)
+	svc.WrapProviders(tape.Wrap)
+	return &Runner{svc: svc, tape: tape, usage: usage}
+}
+
+// Run 依序分類每封郵件；有標註擷取欄位的郵件另做資訊擷取
+func (r *Runner) Run(ctx context.Context, cases []Case) *Report {
+	results := make([]CaseResult, 0, len(cases))
+	for i := range cases {
+		results = append(results, r.runCase(ctx, &cases[i]))
+	}
+	return BuildReport(results)
+}
+
+// runCase 評估單封郵件
+func (r *Runner) runCase(ctx context.Context, c *Case) CaseResult {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+	r.tape.Reset()
+	r.usage.reset()
+
+	result := CaseResult{ID: c.ID, Expected: c.Category}
+	classification, err := r.svc.ClassifyEmail(ctx, openai.ClassifyEmailRequest{
+		Subject: c.Subject, Body: c.Body, From: c.From,
+		Options: openai.AnalysisOptions{DetailLevel: "standard"},
+	})
+	if err != nil {
+		result.Error = fmt.Sprintf("classify: %v", err)
+	} else {
+		result.Predicted = classification.Category
+		result.Confidence = classification.Confidence
+		result.PromptVersions = append(result.PromptVersions, classification.PromptVersion)
+	}
+
+	if len(c.Expected) > 0 {
+		info, err := r.svc.ExtractInfo(ctx, openai.AnalyzeEmailRequest{
+			Subject: c.Subject, Body: c.Body, From: c.From, Date: c.ReceivedAt(),
+			Options: openai.AnalysisOptions{DetailLevel: "standard"},
+		})
+		if err != nil {
+			if result.Error != "" {
+				result.Error += "; "
+			}
+			result.Error += fmt.Sprintf("extract: %v", err)
+			result.Fields = missingFields(c.Expected)
+		} else {
+			result.Fields = compareFields(c.Expected, info)
+			result.PromptVersions = append(result.PromptVersions, info.PromptVersion)
+		}
+	}
+
+	result.LatencyMS = r.tape.Elapsed().Milliseconds()
+	result.Tokens, result.CostUSD, result.Model = r.usage.totals()
+	return result
+}
+
+// compareFields 逐欄比對擷取結果與標註
+func compareFields(expected map[string]string, info *openai.ExtractedInfo) []FieldResult {
+	fields := make([]FieldResult, 0, len(expected))
+	for _, name := range sortedKeys(expected) {
+		m := fieldMatchers[name]
+		got := m.get(info)
+		fields = append(fields, FieldResult{Field: name, Expected: expected[name], Got: got, Correct: m.match(expected[name], got)})
+	}
+	return fields
+}
+
+// missingFields 擷取失敗時所有欄位都算錯
+func missingFields(expected map[string]string) []FieldResult {
+	fields := make([]FieldResult, 0, len(expected))
+	for _, name := range sortedKeys(expected) {
+		fields = append(fields, FieldResult{Field: name, Expected: expected[name]})
+	}
+	return fields
+}
+
+// usageCollector 收集評估期間的 token 用量與成本
+type usageCollector struct {
+	mu     sync.Mutex
+	tokens int
+	cost   float64
+	model  string
+}
+
+// RecordUsage 實作 openai.UsageRecorder
+func (u *usageCollector) RecordUsage(usage openai.TokenUsage) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.tokens += usage.TotalTokens
+	u.cost += usage.CostUSD
+	if usage.Model != "" {
+		u.model = usage.Model
+	}
+	return nil
+}
+
+func (u *usageCollector) reset() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.tokens, u.cost, u.model = 0, 0, ""
+}
+
+func (u *usageCollector) totals() (int, float64, string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.tokens, u.cost, u.model
+}
@@ -0,0 +1,166 @@
+package aieval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+)
+
+// Mode 模型呼叫方式
+type Mode string
+
+const (
+	ModeLive   Mode = "live"   // 直接呼叫設定的後端
+	ModeRecord Mode = "record" // 呼叫後端並錄下回應
+	ModeReplay Mode = "replay" // 只使用錄下的回應，不連線（CI 使用）
+)
+
+// ErrNotRecorded 重播時找不到相同輸入的錄製回應（範本或資料變更後需重新錄製）
+var ErrNotRecorded = errors.New("no recorded response for this request")
+
+// Recording 錄下的單次回應
+type Recording struct {
+	Provider         string `json:"provider"`
+	Model            string `json:"model"`
+	Content          string `json:"content,omitempty"`
+	ToolArguments    string `json:"tool_arguments,omitempty"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	LatencyMS        int64  `json:"latency_ms"`
+}
+
+// Tape 包裝模型後端以錄製或重播回應，並累計每次呼叫的延遲
+type Tape struct {
+	mode    Mode
+	mu      sync.Mutex
+	entries map[string]Recording // 以輸入雜湊為鍵
+	elapsed time.Duration
+	dirty   bool
+}
+
+// NewTape 建立錄放器；replay / record 模式會讀取既有的錄製檔（record 模式檔案可不存在）
+func NewTape(mode Mode, path string) (*Tape, error) {
+	t := &Tape{mode: mode, entries: make(map[string]Recording)}
+	if mode == ModeLive || path == "" {
+		if mode == ModeReplay {
+			return nil, fmt.Errorf("replay mode requires a recording file")
+		}
+		return t, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && mode == ModeRecord {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recordings: %w", err)
+	}
+	if err := json.Unmarshal(data, &t.entries); err != nil {
+		return nil, fmt.Errorf("failed to decode recordings: %w", err)
+	}
+	return t, nil
+}
+
+// Wrap 包裝後端（傳給 openai.Service.WrapProviders）
+func (t *Tape) Wrap(provider openai.LLMProvider) openai.LLMProvider {
+	return &tapeProvider{tape: t, next: provider}
+}
+
+// Reset 歸零累計延遲
+func (t *Tape) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.elapsed = 0
+}
+
+// Elapsed 上次 Reset 後模型呼叫的總延遲（重播時為錄製當時的延遲）
+func (t *Tape) Elapsed() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.elapsed
+}
+
+// Save 寫入錄製檔（只在 record 模式且有新回應時寫入；鍵依序排列以便檢視差異）
+func (t *Tape) Save(path string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.mode != ModeRecord || !t.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(t.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recordings: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write recordings: %w", err)
+	}
+	t.dirty = false
+	return nil
+}
+
+func (t *Tape) lookup(key string) (Recording, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r, ok := t.entries[key]
+	if ok {
+		t.elapsed += time.Duration(r.LatencyMS) * time.Millisecond
+	}
+	return r, ok
+}
+
+func (t *Tape) store(key string, r Recording, latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.elapsed += latency
+	if t.mode == ModeRecord {
+		t.entries[key] = r
+		t.dirty = true
+	}
+}
+
+// tapeProvider 錄製或重播的後端
+type tapeProvider struct {
+	tape *Tape
+	next openai.LLMProvider
+}
+
+func (p *tapeProvider) Name() string  { return p.next.Name() }
+func (p *tapeProvider) Model() string { return p.next.Model() }
+
+// Chat 重播模式回傳錄下的回應，其餘模式呼叫後端並記錄延遲
+func (p *tapeProvider) Chat(ctx context.Context, req openai.ChatRequest) (*openai.ChatResponse, error) {
+	toolName := ""
+	if req.Tool != nil {
+		toolName = req.Tool.Name
+	}
+	key := openai.HashInput(toolName, req.Messages)
+
+	if p.tape.mode == ModeReplay {
+		r, ok := p.tape.lookup(key)
+		if !ok {
+			return nil, ErrNotRecorded
+		}
+		return &openai.ChatResponse{
+			Content: r.Content, ToolArguments: r.ToolArguments, Model: r.Model,
+			PromptTokens: r.PromptTokens, CompletionTokens: r.CompletionTokens,
+		}, nil
+	}
+
+	start := time.Now()
+	resp, err := p.next.Chat(ctx, req)
+	latency := time.Since(start)
+	if err != nil {
+		return nil, err
+	}
+	p.tape.store(key, Recording{
+		Provider: p.next.Name(), Model: resp.Model, Content: resp.Content, ToolArguments: resp.ToolArguments,
+		PromptTokens: resp.PromptTokens, CompletionTokens: resp.CompletionTokens, LatencyMS: latency.Milliseconds(),
+	}, latency)
+	return resp, nil
+}
@@ -0,0 +1,183 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FromResult 將 AI 分析結果轉為 AIAnalysis 紀錄（尚未指定 version）
+func FromResult(emailID, userID uuid.UUID, result *openai.EmailAnalysisResult) (*models.AIAnalysis, error) {
+	info, err := json.Marshal(result.ExtractedInfo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode extracted info: %w", err)
+	}
+
+	a := &models.AIAnalysis{
+		EmailID:        emailID,
+		UserID:         userID,
+		Model:          result.Model,
+		PromptVersion:  result.PromptVersion,
+		Category:       string(result.Classification.Category),
+		Confidence:     result.Classification.Confidence,
+		ExtractedInfo:  info,
+		KeyPoints:      result.KeyPoints,
+		ActionRequired: result.ActionRequired,
+		Priority:       result.Priority,
+		TokensUsed:     result.TokensUsed,
+		AnalyzedAt:     result.AnalyzedAt,
+	}
+	if a.PromptVersion == "" {
+		a.PromptVersion = openai.AnalysisPromptVersion
+	}
+	if result.Classification.Reason != "" {
+		a.ClassificationReason = &result.Classification.Reason
+	}
+	if result.Summary != "" {
+		a.Summary = &result.Summary
+	}
+	return a, nil
+}
+
+// Record 儲存一次郵件分析（version 為該郵件的下一版），並將郵件指向這筆最新結果；
+// 關鍵字分類（超過額度）的結果不將郵件標為已分析，額度恢復後仍會重新分析
+func Record(db *gorm.DB, emailID, userID uuid.UUID, result *openai.EmailAnalysisResult) (*models.AIAnalysis, error) {
+	a, err := FromResult(emailID, userID, result)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// 鎖住郵件列，避免同一封郵件同時分析時取得相同的 version
+		var email models.Email
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&email, "id = ?", emailID).Error; err != nil {
+			return err
+		}
+
+		var latest int
+		if err := tx.Model(&models.AIAnalysis{}).Where("email_id = ?", emailID).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		a.Version = latest + 1
+
+		if err := tx.Omit(clause.Associations).Create(a).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Email{}).Where("id = ?", emailID).Updates(map[string]interface{}{
+			"ai_analysis_id": a.ID,
+			"ai_analyzed":    !result.IsHeuristic(),
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record analysis: %w", err)
+	}
+	return a, nil
+}
+
+// ExtractedInfo 解出紀錄中的抽取資訊
+func ExtractedInfo(a *models.AIAnalysis) (*openai.ExtractedInfo, error) {
+	var info openai.ExtractedInfo
+	if len(a.ExtractedInfo) == 0 {
+		return &info, nil
+	}
+	if err := json.Unmarshal(a.ExtractedInfo, &info); err != nil {
+		return nil, fmt.Errorf("failed to decode extracted info: %w", err)
+	}
+	return &info, nil
+}
+
+// CaseFromResult 將 AI 分析結果轉為 Case 模型（尚未儲存）
+func CaseFromResult(userID uuid.UUID, subject string, result *openai.EmailAnalysisResult) *models.Case {
+	// 判斷是否為合作相關案件
+	isCollaboration := result.Classification.Category != "" && openai.IsCollaborationRelated(result.Classification.Category)
+
+	var title, brandName string
+	var status models.CaseStatus
+
+	if isCollaboration {
+		title = subject
+		if title == "" {
+			title = result.Summary
+		}
+		if title == "" {
+			title = "未命名案件"
+		}
+		brandName = result.ExtractedInfo.BrandName
+		if brandName == "" {
+			brandName = "未知品牌"
+		}
+		status = models.CaseStatusToConfirm
+	} else {
+		// 非合作案件：標題用郵件主旨，不填品牌/報價/截止日等
+		title = subject
+		if title == "" {
+			title = "未命名案件"
+		}
+		brandName = "—" // DB 必填，用佔位符
+		status = models.CaseStatusOther
+	}
+
+	cs := &models.Case{
+		UserID:    userID,
+		Title:     title,
+		BrandName: brandName,
+		Status:    status,
+	}
+
+	if isCollaboration {
+		if result.ExtractedInfo.ContentType != "" {
+			cs.CollaborationType = &result.ExtractedInfo.ContentType
+		}
+		cs.QuotedAmount = result.ExtractedInfo.Amount
+		if result.ExtractedInfo.Currency != "" {
+			cs.Currency = &result.ExtractedInfo.Currency
+		}
+		cs.DeadlineDate = result.ExtractedInfo.DueDate
+		if result.ExtractedInfo.ContactName != "" {
+			cs.ContactName = &result.ExtractedInfo.ContactName
+		}
+		if result.ExtractedInfo.ContactEmail != "" {
+			cs.ContactEmail = &result.ExtractedInfo.ContactEmail
+		}
+		if result.ExtractedInfo.ContactPhone != "" {
+			cs.ContactPhone = &result.ExtractedInfo.ContactPhone
+		}
+		if result.Summary != "" {
+			cs.Description = &result.Summary
+		} else if result.ExtractedInfo.ProjectDetails != "" {
+			cs.Description = &result.ExtractedInfo.ProjectDetails
+		}
+	} else if result.Summary != "" {
+		// 非合作案件僅保留摘要作為說明
+		cs.Description = &result.Summary
+	}
+	return cs
+}
+
+// EmailBody 取得用於 AI 分析的郵件內文（純文字）
+func EmailBody(e *models.Email) string {
+	if e.BodyText != nil && strings.TrimSpace(*e.BodyText) != "" {
+		return *e.BodyText
+	}
+	if e.Snippet != nil && *e.Snippet != "" {
+		return *e.Snippet
+	}
+	if e.BodyHTML != nil && *e.BodyHTML != "" {
+		return stripHTML(*e.BodyHTML)
+	}
+	return ""
+}
+
+var htmlTagRe = regexp.MustCompile(`(?s)<[^>]*>`)
+
+func stripHTML(s string) string {
+	return strings.TrimSpace(htmlTagRe.ReplaceAllString(s, " "))
+}
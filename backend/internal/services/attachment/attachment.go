@@ -0,0 +1,267 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/gmail"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// descriptionChars 案件描述中每個附件最多保留的字數
+const descriptionChars = 300
+
+// File 郵件附件（Load 時才下載內容）
+type File struct {
+	PartID   string
+	Filename string
+	MimeType string
+	Size     int64
+	Load     func() ([]byte, error)
+}
+
+// Source 取得郵件附件的來源
+type Source interface {
+	Files(ctx context.Context, email *models.Email) ([]File, error)
+}
+
+// Summarizer 長附件摘要（*openai.Service 實作此介面）
+type Summarizer interface {
+	SummarizeAttachment(ctx context.Context, req openai.AttachmentSummaryRequest) (string, error)
+}
+
+// Service 郵件附件文字擷取服務（擷取結果存於 email_attachments，每封郵件只處理一次）
+type Service struct {
+	db         *gorm.DB
+	cfg        config.AttachmentConfig
+	source     Source
+	summarizer Summarizer // nil 時長文件只保留截斷的全文
+}
+
+// NewService 建立附件文字擷取服務（預設從 Gmail 下載附件）
+func NewService(db *gorm.DB, cfg config.AttachmentConfig, summarizer Summarizer) *Service {
+	if cfg.InlineChars <= 0 {
+		cfg.InlineChars = 3000
+	}
+	if cfg.ChunkChars <= 0 {
+		cfg.ChunkChars = 6000
+	}
+	return &Service{db: db, cfg: cfg, source: &gmailSource{db: db}, summarizer: summarizer}
+}
+
+// SetSource 設定附件來源
+func (s *Service) SetSource(source Source) {
+	s.source = source
+}
+
+// limits 擷取上限
+func (s *Service) limits() Limits {
+	return Limits{MaxBytes: int64(s.cfg.MaxBytes), MaxPages: s.cfg.MaxPages, MaxChars: s.cfg.MaxChars}
+}
+
+// Process 擷取郵件附件文字（已處理過的郵件直接回傳先前的結果）
+func (s *Service) Process(ctx context.Context, email *models.Email, userID uuid.UUID) ([]models.EmailAttachment, error) {
+	if !s.cfg.Enabled || !email.HasAttachments {
+		return nil, nil
+	}
+
+	var existing []models.EmailAttachment
+	if err := s.db.Where("email_id = ?", email.ID).Order("part_id ASC").Find(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to load attachments: %w", err)
+	}
+	if len(existing) > 0 {
+		return existing, nil
+	}
+
+	files, err := s.source.Files(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := ""
+	if email.Subject != nil {
+		subject = *email.Subject
+	}
+	attachments := make([]models.EmailAttachment, 0, len(files))
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		a := s.extract(ctx, f, subject)
+		a.EmailID = email.ID
+		a.UserID = userID
+		attachments = append(attachments, a)
+	}
+	if len(attachments) == 0 {
+		return nil, nil
+	}
+
+	if err := s.db.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(&attachments).Error; err != nil {
+		return nil, fmt.Errorf("failed to save attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+// extract 下載並擷取單一附件，長文件另做摘要
+func (s *Service) extract(ctx context.Context, f File, subject string) models.EmailAttachment {
+	a := models.EmailAttachment{PartID: f.PartID, Filename: f.Filename, MimeType: f.MimeType, Size: f.Size, Kind: Kind(f.Filename, f.MimeType)}
+
+	// 先以中繼資料判斷，不支援或過大的附件不下載
+	if a.Kind == "" {
+		return skipped(a, ErrUnsupported)
+	}
+	if s.cfg.MaxBytes > 0 && f.Size > int64(s.cfg.MaxBytes) {
+		return skipped(a, ErrTooLarge)
+	}
+
+	data, err := f.Load()
+	if err != nil {
+		return failed(a, err)
+	}
+	doc, err := Extract(f.Filename, f.MimeType, data, s.limits())
+	switch {
+	case errors.Is(err, ErrUnsupported), errors.Is(err, ErrTooLarge), errors.Is(err, ErrEncrypted), errors.Is(err, ErrNoText):
+		return skipped(a, err)
+	case err != nil:
+		return failed(a, err)
+	}
+
+	a.Status = models.EmailAttachmentStatusExtracted
+	a.Pages = doc.Pages
+	a.Truncated = doc.Truncated
+	a.Text = &doc.Text
+	if utf8.RuneCountInString(doc.Text) > s.cfg.InlineChars && s.summarizer != nil {
+		summary, err := s.summarize(ctx, f.Filename, subject, doc.Text)
+		if err != nil {
+			// 摘要失敗（如超過 AI 額度）時仍保留全文，分析時截斷使用
+			log.Warn().Err(err).Str("filename", f.Filename).Msg("Failed to summarize attachment")
+		} else {
+			a.Summary = &summary
+		}
+	}
+	return a
+}
+
+// summarize 分段摘要長文件，多段時再合併為一份摘要
+func (s *Service) summarize(ctx context.Context, filename, subject, text string) (string, error) {
+	chunks := Chunk(text, s.cfg.ChunkChars)
+	summaries := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		summary, err := s.summarizer.SummarizeAttachment(ctx, openai.AttachmentSummaryRequest{
+			Filename: filename, EmailSubject: subject, Part: i + 1, Parts: len(chunks), Text: chunk,
+		})
+		if err != nil {
+			return "", err
+		}
+		summaries = append(summaries, summary)
+	}
+	if len(summaries) == 1 {
+		return summaries[0], nil
+	}
+	return s.summarizer.SummarizeAttachment(ctx, openai.AttachmentSummaryRequest{
+		Filename: filename, EmailSubject: subject, Parts: len(chunks), Summaries: summaries,
+	})
+}
+
+func skipped(a models.EmailAttachment, err error) models.EmailAttachment {
+	msg := err.Error()
+	a.Status = models.EmailAttachmentStatusSkipped
+	a.Error = &msg
+	return a
+}
+
+func failed(a models.EmailAttachment, err error) models.EmailAttachment {
+	msg := err.Error()
+	a.Status = models.EmailAttachmentStatusFailed
+	a.Error = &msg
+	return a
+}
+
+// Contents 轉為 AI 分析使用的附件內容（只包含成功擷取的附件）
+func Contents(attachments []models.EmailAttachment) []openai.AttachmentContent {
+	var contents []openai.AttachmentContent
+	for i := range attachments {
+		if attachments[i].Status != models.EmailAttachmentStatusExtracted {
+			continue
+		}
+		text, summarized := attachments[i].Content()
+		if text == "" {
+			continue
+		}
+		contents = append(contents, openai.AttachmentContent{Filename: attachments[i].Filename, Text: text, Summarized: summarized})
+	}
+	return contents
+}
+
+// AppendToDescription 在案件描述後附上各附件的重點（尚未儲存）
+func AppendToDescription(cs *models.Case, attachments []models.EmailAttachment) {
+	contents := Contents(attachments)
+	if len(contents) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	if cs.Description != nil && *cs.Description != "" {
+		b.WriteString(*cs.Description)
+		b.WriteString("\n\n")
+	}
+	b.WriteString("附件重點：")
+	for _, c := range contents {
+		text := c.Text
+		if utf8.RuneCountInString(text) > descriptionChars {
+			text = string([]rune(text)[:descriptionChars]) + "…"
+		}
+		fmt.Fprintf(&b, "\n【%s】\n%s", c.Filename, text)
+	}
+	description := b.String()
+	cs.Description = &description
+}
+
+// gmailSource 從 Gmail API 下載附件（匯入的郵件沒有保留原始附件）
+type gmailSource struct {
+	db *gorm.DB
+}
+
+// Files 取得郵件的附件清單
+func (g *gmailSource) Files(ctx context.Context, email *models.Email) ([]File, error) {
+	var account models.OAuthAccount
+	if err := g.db.First(&account, "id = ?", email.OAuthAccountID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load oauth account: %w", err)
+	}
+	if !account.IsGmail() {
+		return nil, nil
+	}
+
+	svc, err := gmail.NewService(g.db, &account)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := svc.GetMessage(email.ProviderMessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []File
+	for _, att := range gmail.MessageAttachments(msg) {
+		att := att
+		files = append(files, File{
+			PartID:   att.PartID,
+			Filename: att.Filename,
+			MimeType: att.MimeType,
+			Size:     int64(att.Size),
+			Load: func() ([]byte, error) {
+				return svc.GetAttachment(email.ProviderMessageID, att.AttachmentID)
+			},
+		})
+	}
+	return files, nil
+}
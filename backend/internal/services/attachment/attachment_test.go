@@ -0,0 +1,138 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 設置測試用的資料庫（使用 SQLite）
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Skipf("Skipping test: SQLite not available (CGO required): %v", err)
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.OAuthAccount{}, &models.Email{}, &models.EmailAttachment{})
+	require.NoError(t, err)
+	return db
+}
+
+type fakeSource struct {
+	files []File
+	calls int
+}
+
+func (f *fakeSource) Files(ctx context.Context, email *models.Email) ([]File, error) {
+	f.calls++
+	return f.files, nil
+}
+
+type fakeSummarizer struct {
+	requests []openai.AttachmentSummaryRequest
+}
+
+func (f *fakeSummarizer) SummarizeAttachment(ctx context.Context, req openai.AttachmentSummaryRequest) (string, error) {
+	f.requests = append(f.requests, req)
+	if len(req.Summaries) > 0 {
+		return "合併摘要：" + strings.Join(req.Summaries, "／"), nil
+	}
+	return fmt.Sprintf("第 %d 段摘要", req.Part), nil
+}
+
+func file(partID, name, mimeType string, data []byte) File {
+	return File{PartID: partID, Filename: name, MimeType: mimeType, Size: int64(len(data)),
+		Load: func() ([]byte, error) { return data, nil }}
+}
+
+func TestProcess_ExtractsSummarizesAndCaches(t *testing.T) {
+	db := setupTestDB(t)
+	user := &models.User{ID: uuid.New(), Email: "creator@example.com", Name: "Creator"}
+	require.NoError(t, db.Create(user).Error)
+	subject := "品牌合作邀約"
+	email := &models.Email{OAuthAccountID: uuid.New(), ProviderMessageID: "m1", FromEmail: "pm@brand.example",
+		Subject: &subject, HasAttachments: true, ReceivedAt: time.Now()}
+	require.NoError(t, db.Create(email).Error)
+
+	long := strings.Repeat("品牌簡報內容段落。\n\n", 40)
+	source := &fakeSource{files: []File{
+		file("1", "brief.txt", "text/plain", []byte("預算 NT$50,000，3 支 Reels")),
+		file("2", "deck.txt", "application/octet-stream", []byte(long)),
+		file("3", "photo.jpg", "image/jpeg", []byte{0xff, 0xd8}),
+		{PartID: "4", Filename: "huge.pdf", MimeType: "application/pdf", Size: 1 << 30,
+			Load: func() ([]byte, error) { return nil, errors.New("should not download") }},
+		{PartID: "5", Filename: "broken.docx", MimeType: "", Size: 10,
+			Load: func() ([]byte, error) { return nil, errors.New("download failed") }},
+	}}
+	summarizer := &fakeSummarizer{}
+	svc := NewService(db, config.AttachmentConfig{Enabled: true, MaxBytes: 1 << 20, InlineChars: 100, ChunkChars: 200}, summarizer)
+	svc.SetSource(source)
+
+	attachments, err := svc.Process(context.Background(), email, user.ID)
+	require.NoError(t, err)
+	require.Len(t, attachments, 5)
+
+	assert.Equal(t, models.EmailAttachmentStatusExtracted, attachments[0].Status)
+	assert.Nil(t, attachments[0].Summary)
+	assert.Equal(t, models.EmailAttachmentStatusExtracted, attachments[1].Status)
+	require.NotNil(t, attachments[1].Summary)
+	assert.True(t, strings.HasPrefix(*attachments[1].Summary, "合併摘要："))
+	assert.Greater(t, len(summarizer.requests), 2)
+	assert.Equal(t, "品牌合作邀約", summarizer.requests[0].EmailSubject)
+	assert.Equal(t, models.EmailAttachmentStatusSkipped, attachments[2].Status)
+	assert.Equal(t, models.EmailAttachmentStatusSkipped, attachments[3].Status)
+	assert.Equal(t, ErrTooLarge.Error(), *attachments[3].Error)
+	assert.Equal(t, models.EmailAttachmentStatusFailed, attachments[4].Status)
+
+	contents := Contents(attachments)
+	require.Len(t, contents, 2)
+	assert.False(t, contents[0].Summarized)
+	assert.True(t, contents[1].Summarized)
+
+	// 已處理過的郵件不重新下載
+	again, err := svc.Process(context.Background(), email, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, again, 5)
+	assert.Equal(t, 1, source.calls)
+
+	// 沒有附件的郵件不處理
+	email.HasAttachments = false
+	none, err := svc.Process(context.Background(), email, user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, none)
+}
+
+func TestAppendToDescription(t *testing.T) {
+	text := "預算 NT$50,000"
+	summary := strings.Repeat("重", descriptionChars+10)
+	skippedMsg := "unsupported attachment type"
+	attachments := []models.EmailAttachment{
+		{Filename: "brief.txt", Status: models.EmailAttachmentStatusExtracted, Text: &text},
+		{Filename: "deck.pdf", Status: models.EmailAttachmentStatusExtracted, Text: &text, Summary: &summary},
+		{Filename: "photo.jpg", Status: models.EmailAttachmentStatusSkipped, Error: &skippedMsg},
+	}
+
+	cs := &models.Case{Description: strPtr("合作開箱")}
+	AppendToDescription(cs, attachments)
+	assert.True(t, strings.HasPrefix(*cs.Description, "合作開箱\n\n附件重點：\n【brief.txt】\n預算 NT$50,000\n【deck.pdf】\n"))
+	assert.True(t, strings.HasSuffix(*cs.Description, "…"))
+	assert.NotContains(t, *cs.Description, "photo.jpg")
+
+	empty := &models.Case{}
+	AppendToDescription(empty, attachments[2:])
+	assert.Nil(t, empty.Description)
+}
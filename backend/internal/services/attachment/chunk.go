@@ -0,0 +1,49 @@
+package attachment
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Chunk 將長文件依段落切成不超過 size 字元的區段（單一段落過長時依字數切開）
+func Chunk(text string, size int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if size <= 0 || utf8.RuneCountInString(text) <= size {
+		return []string{text}
+	}
+
+	var chunks []string
+	var cur strings.Builder
+	curLen := 0
+	flush := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			chunks = append(chunks, s)
+		}
+		cur.Reset()
+		curLen = 0
+	}
+
+	for _, para := range strings.Split(text, "\n") {
+		n := utf8.RuneCountInString(para)
+		if curLen > 0 && curLen+n+1 > size {
+			flush()
+		}
+		for n > size {
+			runes := []rune(para)
+			chunks = append(chunks, strings.TrimSpace(string(runes[:size])))
+			para = string(runes[size:])
+			n -= size
+		}
+		if curLen > 0 {
+			cur.WriteByte('\n')
+			curLen++
+		}
+		cur.WriteString(para)
+		curLen += n
+	}
+	flush()
+	return chunks
+}
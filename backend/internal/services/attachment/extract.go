@@ -0,0 +1,149 @@
+package attachment
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrUnsupported 不支援的附件格式
+	ErrUnsupported = errors.New("unsupported attachment type")
+	// ErrTooLarge 附件超過大小上限
+	ErrTooLarge = errors.New("attachment exceeds size limit")
+	// ErrEncrypted 加密的文件無法擷取文字
+	ErrEncrypted = errors.New("encrypted document")
+	// ErrNoText 文件中沒有可擷取的文字（如掃描影像）
+	ErrNoText = errors.New("no extractable text")
+	// ErrMalformed 文件格式損壞，解析失敗
+	ErrMalformed = errors.New("malformed document")
+)
+
+// 支援的附件格式
+const (
+	KindPDF  = "pdf"
+	KindDOCX = "docx"
+	KindXLSX = "xlsx"
+	KindText = "text"
+)
+
+// Limits 擷取上限
+type Limits struct {
+	MaxBytes int64 // 檔案大小上限
+	MaxPages int   // 最多處理幾頁（PDF 頁數、DOCX 分頁、XLSX 工作表）
+	MaxChars int   // 擷取文字上限（字元）
+}
+
+// Document 擷取結果
+type Document struct {
+	Kind      string
+	Text      string
+	Pages     int  // 處理的頁數（純文字為 0）
+	Truncated bool // 超過頁數或字數上限，只保留前段
+}
+
+// Kind 依檔名與 MIME 類型判斷附件格式；不支援時回傳空字串
+func Kind(filename, mimeType string) string {
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	switch mimeType {
+	case "application/pdf":
+		return KindPDF
+	case "application/vnd.openxmlformats-officedocument.wordprocessingml.document":
+		return KindDOCX
+	case "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
+		return KindXLSX
+	case "text/plain", "text/csv", "text/markdown", "text/tab-separated-values":
+		return KindText
+	}
+	// 部分郵件軟體一律標為 application/octet-stream，改依副檔名判斷
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".pdf":
+		return KindPDF
+	case ".docx":
+		return KindDOCX
+	case ".xlsx":
+		return KindXLSX
+	case ".txt", ".csv", ".tsv", ".md":
+		return KindText
+	}
+	return ""
+}
+
+// Extract 在本機擷取附件文字（不呼叫任何外部服務）
+func Extract(filename, mimeType string, data []byte, limits Limits) (doc *Document, err error) {
+	kind := Kind(filename, mimeType)
+	if kind == "" {
+		return nil, ErrUnsupported
+	}
+	if limits.MaxBytes > 0 && int64(len(data)) > limits.MaxBytes {
+		return nil, ErrTooLarge
+	}
+
+	// 附件來自任何寄件者：解析器遇到刻意構造的檔案時回傳錯誤，不讓整個程序崩潰
+	defer func() {
+		if r := recover(); r != nil {
+			doc, err = nil, fmt.Errorf("%w: %s: %v", ErrMalformed, kind, r)
+		}
+	}()
+
+	doc = &Document{Kind: kind}
+	switch kind {
+	case KindPDF:
+		doc.Text, doc.Pages, doc.Truncated, err = pdfText(data, limits.MaxPages)
+	case KindDOCX:
+		doc.Text, doc.Pages, doc.Truncated, err = docxText(data, limits.MaxPages)
+	case KindXLSX:
+		doc.Text, doc.Pages, doc.Truncated, err = xlsxText(data, limits.MaxPages)
+	case KindText:
+		doc.Text = plainText(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	doc.Text = cleanText(doc.Text)
+	if doc.Text == "" {
+		return nil, ErrNoText
+	}
+	if limits.MaxChars > 0 && utf8.RuneCountInString(doc.Text) > limits.MaxChars {
+		doc.Text = string([]rune(doc.Text)[:limits.MaxChars])
+		doc.Truncated = true
+	}
+	return doc, nil
+}
+
+// plainText 純文字附件（去除 BOM，無效的 UTF-8 位元組捨棄）
+func plainText(data []byte) string {
+	s := strings.TrimPrefix(string(data), "\ufeff")
+	return strings.ToValidUTF8(s, "")
+}
+
+var (
+	spaceRunRe = regexp.MustCompile(`[ \x{3000}]+`)
+	blankRunRe = regexp.MustCompile(`\n{3,}`)
+)
+
+// cleanText 整理擷取出的文字：移除控制字元、合併連續空白與空行
+func cleanText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	s = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if r < 0x20 || r == 0x7f || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, s)
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spaceRunRe.ReplaceAllString(line, " "))
+	}
+	s = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankRunRe.ReplaceAllString(s, "\n\n"))
+}
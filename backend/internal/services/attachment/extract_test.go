@@ -0,0 +1,227 @@
+package attachment
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/export"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildPDF 以內容串流組出最小的 PDF（每個串流一頁）；compress 時以 FlateDecode 壓縮
+func buildPDF(t *testing.T, fontDict string, compress bool, extra []string, pages ...string) []byte {
+	t.Helper()
+	var objs []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+i*2)
+	}
+	objs = append(objs, "<< /Type /Catalog /Pages 2 0 R >>")
+	objs = append(objs, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /Resources << /Font << /F1 3 0 R >> >> >>", strings.Join(kids, " "), len(pages)))
+	objs = append(objs, fontDict)
+	for i, content := range pages {
+		objs = append(objs, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Contents %d 0 R >>", 5+i*2))
+		data, filter := []byte(content), ""
+		if compress {
+			var buf bytes.Buffer
+			zw := zlib.NewWriter(&buf)
+			_, _ = zw.Write(data)
+			require.NoError(t, zw.Close())
+			data, filter = buf.Bytes(), " /Filter /FlateDecode"
+		}
+		objs = append(objs, fmt.Sprintf("<< /Length %d%s >>\nstream\n%s\nendstream", len(data), filter, data))
+	}
+	objs = append(objs, extra...)
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	for i, obj := range objs {
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	out.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return out.Bytes()
+}
+
+func TestExtract_PDFLiteralStrings(t *testing.T) {
+	font := "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>"
+	data := buildPDF(t, font, true, nil,
+		"BT /F1 12 Tf 72 700 Td (Campaign brief \\(draft\\)) Tj 0 -14 Td [(Budget:) -400 (NT$80,000)] TJ ET",
+		"BT /F1 12 Tf 72 700 Td (Deadline 2026-11-30) Tj T* (Deliverables: 1 reel) Tj ET",
+	)
+
+	doc, err := Extract("brief.pdf", "application/pdf", data, Limits{})
+	require.NoError(t, err)
+	assert.Equal(t, KindPDF, doc.Kind)
+	assert.Equal(t, 2, doc.Pages)
+	assert.Contains(t, doc.Text, "Campaign brief (draft)\nBudget: NT$80,000")
+	assert.Contains(t, doc.Text, "Deadline 2026-11-30\nDeliverables: 1 reel")
+
+	doc, err = Extract("brief.pdf", "application/octet-stream", data, Limits{MaxPages: 1})
+	require.NoError(t, err)
+	assert.True(t, doc.Truncated)
+	assert.NotContains(t, doc.Text, "Deadline")
+}
+
+func TestExtract_PDFToUnicode(t *testing.T) {
+	// 字碼 0x0001-0x0003 對應「合作案」，以 ToUnicode 對照表解碼
+	cmap := "/CIDInit /ProcSet findresource begin\nbegincmap\n1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n" +
+		"1 beginbfchar\n<0001> <5408>\nendbfchar\n1 beginbfrange\n<0002> <0003> [<4F5C> <6848>]\nendbfrange\nendcmap"
+	font := "<< /Type /Font /Subtype /Type0 /BaseFont /Custom /Encoding /Identity-H /ToUnicode 6 0 R >>"
+	extra := []string{fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(cmap), cmap)}
+	data := buildPDF(t, font, false, extra, "BT /F1 12 Tf 72 700 Td <000100020003> Tj ET")
+
+	doc, err := Extract("contract.pdf", "application/pdf", data, Limits{})
+	require.NoError(t, err)
+	assert.Equal(t, "合作案", doc.Text)
+}
+
+func TestExtract_PDFRoundTripFromExport(t *testing.T) {
+	cs := &models.Case{Title: "春季新品開箱", BrandName: "好品牌", Description: strPtr("兩支 Reels 與三則限動")}
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteCasePDF(&buf, cs, nil, time.UTC))
+
+	doc, err := Extract("case.pdf", "application/pdf", buf.Bytes(), Limits{})
+	require.NoError(t, err)
+	assert.Contains(t, doc.Text, "春季新品開箱")
+	assert.Contains(t, doc.Text, "品牌：好品牌")
+	assert.Contains(t, doc.Text, "兩支 Reels 與三則限動")
+}
+
+func TestExtract_PDFErrors(t *testing.T) {
+	_, err := Extract("a.pdf", "application/pdf", []byte("not a pdf"), Limits{})
+	assert.Error(t, err)
+
+	encrypted := []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\ntrailer\n<< /Root 1 0 R /Encrypt 2 0 R >>\n")
+	_, err = Extract("a.pdf", "application/pdf", encrypted, Limits{})
+	assert.ErrorIs(t, err, ErrEncrypted)
+
+	// 只有影像、沒有文字的頁面
+	scanned := buildPDF(t, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>", false, nil, "q 500 0 0 700 0 0 cm /Im1 Do Q")
+	_, err = Extract("scan.pdf", "application/pdf", scanned, Limits{})
+	assert.ErrorIs(t, err, ErrNoText)
+}
+
+// objStmPDF 只含一個物件串流的 PDF（header 為「物件編號 位移」）
+func objStmPDF(header, body string) []byte {
+	content := header + body
+	return []byte(fmt.Sprintf("%%PDF-1.5\n1 0 obj\n<< /Type /ObjStm /N 2 /First %d /Length %d >>\nstream\n%s\nendstream\nendobj\n%%%%EOF\n",
+		len(header), len(content), content))
+}
+
+func TestExtract_PDFMalformedObjectStream(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"negative offset":      objStmPDF("5 -20 6 0 ", "<< /Type /Page >>"),
+		"offset past end":      objStmPDF("5 999 6 0 ", "<< /Type /Page >>"),
+		"overflowing offset":   objStmPDF("5 9223372036854775807 6 0 ", "<< >>"),
+		"offsets out of order": objStmPDF("5 10 6 2 ", "<< /Type /Page >>"),
+		"truncated":            objStmPDF("5 0 6 0 ", "")[:40],
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.NotPanics(t, func() {
+				_, _ = Extract("a.pdf", "application/pdf", data, Limits{})
+			})
+		})
+	}
+}
+
+// FuzzPDFText 任意輸入都不可讓 PDF 解析器 panic（直接呼叫 pdfText，不經過 Extract 的 recover）
+func FuzzPDFText(f *testing.F) {
+	f.Add(objStmPDF("5 -20 6 0 ", "<< /Type /Page >>"))
+	f.Add(objStmPDF("5 0 6 17 ", "<< /Type /Page >><< /Type /Font >>"))
+	f.Add([]byte("%PDF-1.4\n1 0 obj\n<< /Length 5 >>\nstream\nBT (a) Tj ET\nendstream\nendobj\n"))
+	f.Add([]byte("%PDF-1.4\n1 0 obj\n<< /Type /Page /Contents 2 0 R >>\nendobj\n2 0 obj\n<< /Length 3 >>\nstream\nBI ID EI"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _, _, _ = pdfText(data, 10)
+	})
+}
+
+// buildZip 組出 Office 文件（zip）
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestExtract_DOCX(t *testing.T) {
+	const ns = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"`
+	doc := `<?xml version="1.0" encoding="UTF-8"?><w:document ` + ns + `><w:body>` +
+		`<w:p><w:r><w:t>合作</w:t></w:r><w:r><w:t xml:space="preserve">需求說明 </w:t></w:r></w:p>` +
+		`<w:tbl><w:tr><w:tc><w:p><w:r><w:t>項目</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>預算</w:t></w:r></w:p></w:tc></w:tr></w:tbl>` +
+		`<w:p><w:r><w:br w:type="page"/><w:t>第二頁</w:t></w:r></w:p>` +
+		`</w:body></w:document>`
+	data := buildZip(t, map[string]string{"word/document.xml": doc})
+
+	got, err := Extract("brief.docx", "", data, Limits{})
+	require.NoError(t, err)
+	assert.Equal(t, KindDOCX, got.Kind)
+	assert.Equal(t, 2, got.Pages)
+	assert.Contains(t, got.Text, "合作需求說明")
+	assert.Contains(t, got.Text, "項目")
+	assert.Contains(t, got.Text, "第二頁")
+
+	got, err = Extract("brief.docx", "", data, Limits{MaxPages: 1})
+	require.NoError(t, err)
+	assert.True(t, got.Truncated)
+	assert.NotContains(t, got.Text, "第二頁")
+}
+
+func TestExtract_XLSX(t *testing.T) {
+	data := buildZip(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="報價需求" sheetId="1" r:id="rId1"/><sheet name="備註" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>項目</t></si><si><t>數量</t></si><si><r><t>IG </t></r><r><t>Reels</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>` +
+			`<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>2</v></c></row>` +
+			`</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>需含授權</t></is></c></row></sheetData></worksheet>`,
+	})
+
+	got, err := Extract("rates.xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", data, Limits{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.Pages)
+	assert.Contains(t, got.Text, "[工作表：報價需求]\n項目\t數量\nIG Reels\t2")
+	assert.Contains(t, got.Text, "[工作表：備註]\n需含授權")
+}
+
+func TestExtract_TextAndLimits(t *testing.T) {
+	got, err := Extract("notes.txt", "text/plain", []byte("\ufeff  預算   五萬 \r\n\r\n\r\n\r\n截止 11/30  "), Limits{})
+	require.NoError(t, err)
+	assert.Equal(t, "預算 五萬\n\n截止 11/30", got.Text)
+
+	got, err = Extract("notes.txt", "text/plain", []byte("一二三四五六"), Limits{MaxChars: 4})
+	require.NoError(t, err)
+	assert.Equal(t, "一二三四", got.Text)
+	assert.True(t, got.Truncated)
+
+	_, err = Extract("notes.txt", "text/plain", []byte("0123456789"), Limits{MaxBytes: 5})
+	assert.ErrorIs(t, err, ErrTooLarge)
+	_, err = Extract("photo.jpg", "image/jpeg", []byte{0xff}, Limits{})
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestChunk(t *testing.T) {
+	assert.Nil(t, Chunk("  ", 10))
+	assert.Equal(t, []string{"短文"}, Chunk("短文", 10))
+	assert.Equal(t, []string{"第一段\n第二段", "第三段落"}, Chunk("第一段\n第二段\n第三段落", 8))
+	assert.Equal(t, []string{"一二三", "四五六", "七"}, Chunk("一二三四五六七", 3))
+}
+
+func strPtr(s string) *string { return &s }
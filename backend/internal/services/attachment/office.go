@@ -0,0 +1,297 @@
+package attachment
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxXMLBytes 單一 XML 檔解壓後的上限，避免壓縮炸彈
+const maxXMLBytes = 64 << 20
+
+// openZipFile 讀取 Office 文件（zip）中的檔案；不存在時回傳 nil
+func openZipFile(zr *zip.Reader, name string) ([]byte, error) {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(io.LimitReader(rc, maxXMLBytes))
+	}
+	return nil, nil
+}
+
+// docxText 擷取 Word 文件的內文；以手動分頁與 Word 記錄的分頁位置計算頁數
+func docxText(data []byte, maxPages int) (string, int, bool, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", 0, false, fmt.Errorf("invalid docx: %w", err)
+	}
+	doc, err := openZipFile(zr, "word/document.xml")
+	if err != nil {
+		return "", 0, false, fmt.Errorf("invalid docx: %w", err)
+	}
+	if doc == nil {
+		return "", 0, false, fmt.Errorf("invalid docx: missing word/document.xml")
+	}
+
+	var b strings.Builder
+	pages := 1
+	inText := false
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", 0, false, fmt.Errorf("invalid docx: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				if attr(t, "type") == "page" {
+					pages++
+				}
+				b.WriteByte('\n')
+			case "lastRenderedPageBreak":
+				pages++
+			}
+			if maxPages > 0 && pages > maxPages {
+				return b.String(), maxPages, true, nil
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p", "tr":
+				b.WriteByte('\n')
+			case "tc":
+				b.WriteByte('\t')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+	return b.String(), pages, false, nil
+}
+
+// xlsxText 擷取 Excel 活頁簿：每張工作表輸出表名與各列儲存格（以 tab 分隔）
+func xlsxText(data []byte, maxSheets int) (string, int, bool, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", 0, false, fmt.Errorf("invalid xlsx: %w", err)
+	}
+
+	shared, err := xlsxSharedStrings(zr)
+	if err != nil {
+		return "", 0, false, err
+	}
+	sheets, err := xlsxSheets(zr)
+	if err != nil {
+		return "", 0, false, err
+	}
+
+	var b strings.Builder
+	count := 0
+	for _, sheet := range sheets {
+		if maxSheets > 0 && count >= maxSheets {
+			return b.String(), count, true, nil
+		}
+		raw, err := openZipFile(zr, sheet.path)
+		if err != nil || raw == nil {
+			continue
+		}
+		count++
+		fmt.Fprintf(&b, "[工作表：%s]\n", sheet.name)
+		if err := xlsxRows(raw, shared, &b); err != nil {
+			return "", 0, false, err
+		}
+		b.WriteByte('\n')
+	}
+	return b.String(), count, false, nil
+}
+
+type xlsxSheet struct {
+	name string
+	path string
+}
+
+// xlsxSheets 依活頁簿順序列出工作表與對應的 XML 檔
+func xlsxSheets(zr *zip.Reader) ([]xlsxSheet, error) {
+	workbook, err := openZipFile(zr, "xl/workbook.xml")
+	if err != nil || workbook == nil {
+		return nil, fmt.Errorf("invalid xlsx: missing xl/workbook.xml")
+	}
+	var wb struct {
+		Sheets []struct {
+			Name string     `xml:"name,attr"`
+			Attr []xml.Attr `xml:",any,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(workbook, &wb); err != nil {
+		return nil, fmt.Errorf("invalid xlsx: %w", err)
+	}
+
+	targets := map[string]string{}
+	if rels, _ := openZipFile(zr, "xl/_rels/workbook.xml.rels"); rels != nil {
+		var r struct {
+			Relationships []struct {
+				ID     string `xml:"Id,attr"`
+				Target string `xml:"Target,attr"`
+			} `xml:"Relationship"`
+		}
+		if err := xml.Unmarshal(rels, &r); err == nil {
+			for _, rel := range r.Relationships {
+				target := rel.Target
+				if strings.HasPrefix(target, "/") {
+					target = strings.TrimPrefix(target, "/")
+				} else {
+					target = path.Join("xl", target)
+				}
+				targets[rel.ID] = target
+			}
+		}
+	}
+
+	sheets := make([]xlsxSheet, 0, len(wb.Sheets))
+	for i, s := range wb.Sheets {
+		p := ""
+		for _, a := range s.Attr {
+			if a.Name.Local == "id" {
+				p = targets[a.Value]
+			}
+		}
+		if p == "" {
+			p = fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
+		}
+		sheets = append(sheets, xlsxSheet{name: s.Name, path: p})
+	}
+	return sheets, nil
+}
+
+// xlsxSharedStrings 共用字串表
+func xlsxSharedStrings(zr *zip.Reader) ([]string, error) {
+	raw, err := openZipFile(zr, "xl/sharedStrings.xml")
+	if err != nil {
+		return nil, fmt.Errorf("invalid xlsx: %w", err)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	var strs []string
+	var cur strings.Builder
+	inText := false
+	dec := xml.NewDecoder(bytes.NewReader(raw))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid xlsx: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				cur.Reset()
+			case "t":
+				inText = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				strs = append(strs, cur.String())
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				cur.Write(t)
+			}
+		}
+	}
+	return strs, nil
+}
+
+// xlsxRows 輸出工作表的各列
+func xlsxRows(raw []byte, shared []string, b *strings.Builder) error {
+	var (
+		cells    []string
+		cellType string
+		value    strings.Builder
+		inValue  bool
+	)
+	dec := xml.NewDecoder(bytes.NewReader(raw))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid xlsx: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				cells = cells[:0]
+			case "c":
+				cellType = attr(t, "t")
+				value.Reset()
+			case "v", "t":
+				inValue = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				v := value.String()
+				if cellType == "s" {
+					if i, err := strconv.Atoi(v); err == nil && i >= 0 && i < len(shared) {
+						v = shared[i]
+					}
+				}
+				cells = append(cells, strings.TrimSpace(v))
+			case "row":
+				line := strings.TrimRight(strings.Join(cells, "\t"), "\t")
+				if line != "" {
+					b.WriteString(line + "\n")
+				}
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		}
+	}
+}
+
+// attr 取得 XML 屬性（忽略命名空間）
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
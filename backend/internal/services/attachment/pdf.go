@@ -0,0 +1,804 @@
+package attachment
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+const (
+	// maxStreamBytes 單一串流解壓後的上限，避免壓縮炸彈
+	maxStreamBytes = 32 << 20
+	// maxObjStmBytes 所有物件串流解壓後的總上限（物件串流數量不限，需另外限制總量）
+	maxObjStmBytes = 64 << 20
+)
+
+// pdfObject PDF 間接物件：字典（或其他值）與未解碼的串流
+type pdfObject struct {
+	dict   string
+	stream []byte
+}
+
+// pdfFile 極簡 PDF 解析器：只處理擷取文字需要的部分（頁面樹、內容串流、字型的 ToUnicode 對照表）
+type pdfFile struct {
+	objects map[int]*pdfObject
+	cmaps   map[int]*pdfCMap // 以字型物件編號快取
+}
+
+var (
+	pdfObjRe       = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+	pdfRefRe       = regexp.MustCompile(`^\s*(\d+)\s+\d+\s+R`)
+	pdfRefsRe      = regexp.MustCompile(`(\d+)\s+\d+\s+R`)
+	pdfLengthRe    = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+	pdfFontRefRe   = regexp.MustCompile(`/([^\s/<>\[\]()]+)\s+(\d+)\s+\d+\s+R`)
+	pdfTypePageRe  = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfTypePagesRe = regexp.MustCompile(`/Type\s*/Pages\b`)
+	pdfUCS2Re      = regexp.MustCompile(`/Encoding\s*/Uni\w+-UCS2-[HV]`)
+
+	// dictKeyRes dictValue 用的正規表示式（依 key 快取）
+	dictKeyRes sync.Map
+)
+
+// pdfText 擷取 PDF 文字；只處理前 maxPages 頁
+func pdfText(data []byte, maxPages int) (string, int, bool, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\r\n "), []byte("%PDF")) {
+		return "", 0, false, fmt.Errorf("invalid pdf: missing header")
+	}
+
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return "", 0, false, ErrEncrypted
+	}
+	f := parsePDF(data)
+
+	pages := f.pages()
+	truncated := false
+	if maxPages > 0 && len(pages) > maxPages {
+		pages = pages[:maxPages]
+		truncated = true
+	}
+
+	var b strings.Builder
+	for _, page := range pages {
+		fonts := f.pageFonts(page)
+		for _, content := range f.pageContents(page) {
+			f.showText(content, fonts, &b)
+		}
+		b.WriteString("\n\n")
+	}
+	return b.String(), len(pages), truncated, nil
+}
+
+// parsePDF 依位置掃描所有間接物件（含物件串流中的壓縮物件）
+func parsePDF(data []byte) *pdfFile {
+	f := &pdfFile{objects: map[int]*pdfObject{}, cmaps: map[int]*pdfCMap{}}
+
+	pos := 0
+	for pos < len(data) {
+		loc := pdfObjRe.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		num, _ := strconv.Atoi(string(data[pos+loc[2] : pos+loc[3]]))
+		start := pos + loc[1]
+		obj, end := readPDFObject(data, start)
+		f.objects[num] = obj // 增量更新時後面的版本覆蓋前面
+		pos = end
+	}
+
+	// PDF 1.5 之後的物件串流（依物件編號處理，超過總量上限後的串流略過）
+	var streams []int
+	for num, obj := range f.objects {
+		if strings.Contains(obj.dict, "/ObjStm") {
+			streams = append(streams, num)
+		}
+	}
+	sort.Ints(streams)
+	budget := maxObjStmBytes
+	for _, stm := range streams {
+		if budget <= 0 {
+			break
+		}
+		obj := f.objects[stm]
+		decoded := decodeStreamLimit(obj, min(budget, maxStreamBytes))
+		budget -= len(decoded)
+		n, _ := strconv.Atoi(dictValue(obj.dict, "N"))
+		first, _ := strconv.Atoi(dictValue(obj.dict, "First"))
+		if decoded == nil || n <= 0 || first <= 0 || first > len(decoded) {
+			continue
+		}
+		header := strings.Fields(string(decoded[:first]))
+		for i := 0; i+1 < len(header) && i/2 < n; i += 2 {
+			num, err1 := strconv.Atoi(header[i])
+			off, err2 := strconv.Atoi(header[i+1])
+			if err1 != nil || err2 != nil || off < 0 || off >= len(decoded)-first {
+				continue
+			}
+			end := len(decoded)
+			if i+3 < len(header) {
+				if next, err := strconv.Atoi(header[i+3]); err == nil && next <= len(decoded)-first && next >= off {
+					end = first + next
+				}
+			}
+			if first+off >= end {
+				continue
+			}
+			if _, exists := f.objects[num]; !exists {
+				f.objects[num] = &pdfObject{dict: string(decoded[first+off : end])}
+			}
+		}
+	}
+	return f
+}
+
+// readPDFObject 讀取 obj 關鍵字之後的內容，回傳物件與 endobj 之後的位置
+func readPDFObject(data []byte, start int) (*pdfObject, int) {
+	endObj := bytes.Index(data[start:], []byte("endobj"))
+	if endObj < 0 {
+		endObj = len(data) - start
+	}
+	streamAt := bytes.Index(data[start:start+endObj], []byte("stream"))
+	if streamAt < 0 {
+		return &pdfObject{dict: string(data[start : start+endObj])}, start + endObj + len("endobj")
+	}
+
+	obj := &pdfObject{dict: string(data[start : start+streamAt])}
+	body := start + streamAt + len("stream")
+	if body < len(data) && data[body] == '\r' {
+		body++
+	}
+	if body < len(data) && data[body] == '\n' {
+		body++
+	}
+
+	// 優先使用直接指定的 /Length；間接長度或長度不正確時找 endstream
+	end := -1
+	if m := pdfLengthRe.FindStringSubmatch(obj.dict); m != nil && m[2] == "" {
+		if n, err := strconv.Atoi(m[1]); err == nil && body+n <= len(data) {
+			rest := bytes.TrimLeft(data[body+n:min(len(data), body+n+20)], "\r\n ")
+			if bytes.HasPrefix(rest, []byte("endstream")) {
+				end = body + n
+			}
+		}
+	}
+	if end < 0 {
+		i := bytes.Index(data[body:], []byte("endstream"))
+		if i < 0 {
+			return obj, len(data)
+		}
+		end = body + i
+	}
+	obj.stream = data[body:end]
+
+	next := bytes.Index(data[end:], []byte("endobj"))
+	if next < 0 {
+		return obj, len(data)
+	}
+	return obj, end + next + len("endobj")
+}
+
+// decodePDFStream 解碼串流（支援未壓縮與 FlateDecode；其他編碼如影像回傳 nil）
+func decodePDFStream(obj *pdfObject) []byte {
+	return decodeStreamLimit(obj, maxStreamBytes)
+}
+
+// decodeStreamLimit 解碼串流，解壓後最多 limit 位元組
+func decodeStreamLimit(obj *pdfObject, limit int) []byte {
+	if obj == nil || obj.stream == nil {
+		return nil
+	}
+	filter := dictValue(obj.dict, "Filter")
+	filter = strings.Trim(strings.TrimSpace(filter), "[]")
+	switch strings.TrimSpace(filter) {
+	case "":
+		return obj.stream
+	case "/FlateDecode", "/Fl":
+		zr, err := zlib.NewReader(bytes.NewReader(obj.stream))
+		if err != nil {
+			return nil
+		}
+		defer zr.Close()
+		out, err := io.ReadAll(io.LimitReader(zr, int64(limit)))
+		if err != nil && len(out) == 0 {
+			return nil
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// resolve 取得值：間接參照時回傳被參照物件的內容
+func (f *pdfFile) resolve(value string) (string, *pdfObject) {
+	if m := pdfRefRe.FindStringSubmatch(value); m != nil {
+		num, _ := strconv.Atoi(m[1])
+		if obj := f.objects[num]; obj != nil {
+			return obj.dict, obj
+		}
+		return "", nil
+	}
+	return value, nil
+}
+
+// pages 依頁面樹順序列出頁面物件；找不到頁面樹時依物件編號排序
+func (f *pdfFile) pages() []*pdfObject {
+	var roots []int
+	for num, obj := range f.objects {
+		if pdfTypePagesRe.MatchString(obj.dict) && !strings.Contains(obj.dict, "/Parent") {
+			roots = append(roots, num)
+		}
+	}
+	sort.Ints(roots)
+
+	var pages []*pdfObject
+	seen := map[int]bool{}
+	var walk func(num, depth int)
+	walk = func(num, depth int) {
+		obj := f.objects[num]
+		if obj == nil || seen[num] || depth > 32 {
+			return
+		}
+		seen[num] = true
+		if pdfTypePageRe.MatchString(obj.dict) {
+			pages = append(pages, obj)
+			return
+		}
+		kids, _ := f.resolve(dictValue(obj.dict, "Kids"))
+		for _, m := range pdfRefsRe.FindAllStringSubmatch(kids, -1) {
+			kid, _ := strconv.Atoi(m[1])
+			walk(kid, depth+1)
+		}
+	}
+	for _, root := range roots {
+		walk(root, 0)
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+
+	var nums []int
+	for num, obj := range f.objects {
+		if pdfTypePageRe.MatchString(obj.dict) {
+			nums = append(nums, num)
+		}
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		pages = append(pages, f.objects[num])
+	}
+	return pages
+}
+
+// pageContents 頁面的內容串流（已解碼）
+func (f *pdfFile) pageContents(page *pdfObject) [][]byte {
+	value, obj := f.resolve(dictValue(page.dict, "Contents"))
+	if obj != nil && obj.stream != nil {
+		return [][]byte{decodePDFStream(obj)}
+	}
+	var contents [][]byte
+	for _, m := range pdfRefsRe.FindAllStringSubmatch(value, -1) {
+		num, _ := strconv.Atoi(m[1])
+		if data := decodePDFStream(f.objects[num]); data != nil {
+			contents = append(contents, data)
+		}
+	}
+	return contents
+}
+
+// pageFonts 頁面使用的字型（資源名稱 → 解碼方式），資源可繼承自上層節點
+func (f *pdfFile) pageFonts(page *pdfObject) map[string]*pdfCMap {
+	dict := page.dict
+	resources := dictValue(dict, "Resources")
+	for depth := 0; resources == "" && depth < 32; depth++ {
+		parent, obj := f.resolve(dictValue(dict, "Parent"))
+		if obj == nil {
+			break
+		}
+		dict = parent
+		resources = dictValue(dict, "Resources")
+	}
+	resources, _ = f.resolve(resources)
+	fontDict, _ := f.resolve(dictValue(resources, "Font"))
+
+	fonts := map[string]*pdfCMap{}
+	for _, m := range pdfFontRefRe.FindAllStringSubmatch(fontDict, -1) {
+		num, _ := strconv.Atoi(m[2])
+		fonts[m[1]] = f.fontCMap(num)
+	}
+	return fonts
+}
+
+// fontCMap 字型的字碼對照：優先使用 ToUnicode，其次為 Adobe 標準 UCS-2 編碼
+func (f *pdfFile) fontCMap(num int) *pdfCMap {
+	if cm, ok := f.cmaps[num]; ok {
+		return cm
+	}
+	var cm *pdfCMap
+	if font := f.objects[num]; font != nil {
+		if _, obj := f.resolve(dictValue(font.dict, "ToUnicode")); obj != nil {
+			cm = parseCMap(decodePDFStream(obj))
+		}
+		if cm == nil && pdfUCS2Re.MatchString(font.dict) {
+			cm = &pdfCMap{codeLen: 2, ucs2: true}
+		}
+	}
+	f.cmaps[num] = cm
+	return cm
+}
+
+// dictValue 取得字典中 key 的原始值（支援巢狀字典、陣列與字串）
+func dictValue(dict, key string) string {
+	cached, ok := dictKeyRes.Load(key)
+	if !ok {
+		cached, _ = dictKeyRes.LoadOrStore(key, regexp.MustCompile(`/`+regexp.QuoteMeta(key)+`(?:[\s/\[<(]|$)`))
+	}
+	re := cached.(*regexp.Regexp)
+	for _, loc := range re.FindAllStringIndex(dict, -1) {
+		// 跳過出現在巢狀字典內的同名 key
+		if nestingDepth(dict[:loc[0]]) != 1 && strings.HasPrefix(strings.TrimSpace(dict), "<<") {
+			continue
+		}
+		rest := strings.TrimLeft(dict[loc[0]+len(key)+1:], " \t\r\n")
+		return readPDFValue(rest)
+	}
+	return ""
+}
+
+// nestingDepth 計算位置前未關閉的 << 數
+func nestingDepth(s string) int {
+	depth := 0
+	for i := 0; i+1 < len(s); i++ {
+		switch {
+		case s[i] == '<' && s[i+1] == '<':
+			depth++
+			i++
+		case s[i] == '>' && s[i+1] == '>':
+			depth--
+			i++
+		}
+	}
+	return depth
+}
+
+// readPDFValue 讀取一個值（字典、陣列、字串、參照、名稱或數字）
+func readPDFValue(s string) string {
+	if s == "" {
+		return ""
+	}
+	switch {
+	case strings.HasPrefix(s, "<<"):
+		depth := 0
+		for i := 0; i+1 < len(s); i++ {
+			if s[i] == '<' && s[i+1] == '<' {
+				depth++
+				i++
+			} else if s[i] == '>' && s[i+1] == '>' {
+				depth--
+				i++
+				if depth == 0 {
+					return s[:i+1]
+				}
+			}
+		}
+		return s
+	case s[0] == '[':
+		depth := 0
+		for i := 0; i < len(s); i++ {
+			if s[i] == '[' {
+				depth++
+			} else if s[i] == ']' {
+				depth--
+				if depth == 0 {
+					return s[:i+1]
+				}
+			}
+		}
+		return s
+	}
+	if m := pdfRefRe.FindString(s); m != "" {
+		return strings.TrimSpace(m)
+	}
+	end := strings.IndexAny(s[1:], " \t\r\n/<>[]()")
+	if end < 0 {
+		return s
+	}
+	return s[:end+1]
+}
+
+// pdfCMap 字碼到 Unicode 的對照
+type pdfCMap struct {
+	codeLen int  // 字碼位元組數
+	ucs2    bool // 字碼即為 UCS-2（Adobe 標準 CJK 編碼）
+	chars   map[int]string
+}
+
+var (
+	cmapHexRe       = regexp.MustCompile(`<([0-9A-Fa-f]+)>`)
+	cmapCodespaceRe = regexp.MustCompile(`(?s)begincodespacerange\s*<([0-9A-Fa-f]+)>`)
+	cmapBfcharRe    = regexp.MustCompile(`(?s)beginbfchar(.*?)endbfchar`)
+	cmapBfrangeRe   = regexp.MustCompile(`(?s)beginbfrange(.*?)endbfrange`)
+	cmapRangeLineRe = regexp.MustCompile(`<([0-9A-Fa-f]+)>\s*<([0-9A-Fa-f]+)>\s*(<[0-9A-Fa-f]+>|\[[^\]]*\])`)
+)
+
+// parseCMap 解析 ToUnicode CMap（bfchar、bfrange）
+func parseCMap(data []byte) *pdfCMap {
+	if data == nil {
+		return nil
+	}
+	s := string(data)
+	cm := &pdfCMap{codeLen: 1, chars: map[int]string{}}
+	if m := cmapCodespaceRe.FindStringSubmatch(s); m != nil {
+		cm.codeLen = max(1, len(m[1])/2)
+	}
+
+	for _, block := range cmapBfcharRe.FindAllStringSubmatch(s, -1) {
+		hexes := cmapHexRe.FindAllStringSubmatch(block[1], -1)
+		for i := 0; i+1 < len(hexes); i += 2 {
+			code, err := strconv.ParseInt(hexes[i][1], 16, 64)
+			if err == nil {
+				cm.chars[int(code)] = utf16Hex(hexes[i+1][1])
+			}
+		}
+	}
+	for _, block := range cmapBfrangeRe.FindAllStringSubmatch(s, -1) {
+		for _, m := range cmapRangeLineRe.FindAllStringSubmatch(block[1], -1) {
+			lo, err1 := strconv.ParseInt(m[1], 16, 64)
+			hi, err2 := strconv.ParseInt(m[2], 16, 64)
+			if err1 != nil || err2 != nil || hi < lo || hi-lo > 0xFFFF {
+				continue
+			}
+			if strings.HasPrefix(m[3], "[") {
+				for i, h := range cmapHexRe.FindAllStringSubmatch(m[3], -1) {
+					if lo+int64(i) > hi {
+						break
+					}
+					cm.chars[int(lo)+i] = utf16Hex(h[1])
+				}
+				continue
+			}
+			dst := []rune(utf16Hex(strings.Trim(m[3], "<>")))
+			if len(dst) == 0 {
+				continue
+			}
+			for code := lo; code <= hi; code++ {
+				r := append([]rune{}, dst...)
+				r[len(r)-1] += rune(code - lo)
+				cm.chars[int(code)] = string(r)
+			}
+		}
+	}
+	return cm
+}
+
+// utf16Hex 將 UTF-16BE 十六進位字串轉為文字
+func utf16Hex(h string) string {
+	var units []uint16
+	for i := 0; i+4 <= len(h); i += 4 {
+		v, err := strconv.ParseUint(h[i:i+4], 16, 16)
+		if err != nil {
+			return ""
+		}
+		units = append(units, uint16(v))
+	}
+	if len(h) == 2 {
+		v, _ := strconv.ParseUint(h, 16, 8)
+		units = append(units, uint16(v))
+	}
+	return string(utf16.Decode(units))
+}
+
+// decode 依字型解碼字串
+func (cm *pdfCMap) decode(raw []byte) string {
+	if cm == nil {
+		// 未提供對照表：UTF-16（BOM 開頭）或視為 Latin-1
+		if len(raw) >= 2 && raw[0] == 0xFE && raw[1] == 0xFF {
+			units := make([]uint16, 0, len(raw)/2)
+			for i := 2; i+1 < len(raw); i += 2 {
+				units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+			}
+			return string(utf16.Decode(units))
+		}
+		r := make([]rune, len(raw))
+		for i, c := range raw {
+			r[i] = rune(c)
+		}
+		return string(r)
+	}
+
+	var b strings.Builder
+	for i := 0; i+cm.codeLen <= len(raw); i += cm.codeLen {
+		code := 0
+		for j := 0; j < cm.codeLen; j++ {
+			code = code<<8 | int(raw[i+j])
+		}
+		if cm.ucs2 {
+			b.WriteRune(rune(code))
+			continue
+		}
+		if s, ok := cm.chars[code]; ok {
+			b.WriteString(s)
+		} else if cm.codeLen == 1 {
+			b.WriteRune(rune(code))
+		}
+	}
+	return b.String()
+}
+
+// showText 執行內容串流中的文字運算子，將顯示的文字寫入 b
+func (f *pdfFile) showText(content []byte, fonts map[string]*pdfCMap, b *strings.Builder) {
+	var (
+		font     *pdfCMap
+		operands []pdfToken
+		lastY    float64
+		haveY    bool
+	)
+	newline := func() {
+		s := b.String()
+		if s != "" && !strings.HasSuffix(s, "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	space := func() {
+		s := b.String()
+		if s != "" && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+			b.WriteByte(' ')
+		}
+	}
+	show := func(t pdfToken) {
+		if t.kind == pdfString {
+			b.WriteString(font.decode(t.raw))
+		}
+	}
+
+	lex := &pdfLexer{data: content}
+	for {
+		tok, ok := lex.next()
+		if !ok {
+			return
+		}
+		if tok.kind != pdfOperator {
+			operands = append(operands, tok)
+			if len(operands) > 64 {
+				operands = operands[1:]
+			}
+			continue
+		}
+
+		switch tok.text {
+		case "Tf":
+			if len(operands) >= 2 && operands[len(operands)-2].kind == pdfName {
+				font = fonts[operands[len(operands)-2].text]
+			}
+		case "Tj":
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "'", "\"":
+			newline()
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "TJ":
+			for _, t := range operands {
+				if t.kind == pdfNumber && t.num < -250 {
+					space()
+				}
+				show(t)
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				if operands[len(operands)-1].num != 0 {
+					newline()
+				} else if operands[len(operands)-2].num > 0 {
+					space()
+				}
+			}
+		case "T*":
+			newline()
+		case "Tm":
+			if len(operands) >= 6 {
+				y := operands[len(operands)-1].num
+				if haveY && y != lastY {
+					newline()
+				}
+				lastY, haveY = y, true
+			}
+		case "ET":
+			space()
+		case "BI":
+			lex.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+}
+
+// pdfToken 內容串流的詞彙單元
+type pdfToken struct {
+	kind int
+	text string  // 名稱（不含 /）或運算子
+	num  float64 // 數字
+	raw  []byte  // 字串內容
+}
+
+const (
+	pdfOperator = iota
+	pdfNumber
+	pdfName
+	pdfString
+	pdfOther // 陣列邊界、字典等（不影響文字）
+)
+
+// pdfLexer 內容串流的詞彙分析
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func (l *pdfLexer) next() (pdfToken, bool) {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isPDFSpace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		case c == '(':
+			return pdfToken{kind: pdfString, raw: l.literalString()}, true
+		case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+			l.pos += 2
+			return pdfToken{kind: pdfOther}, true
+		case c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+			l.pos += 2
+			return pdfToken{kind: pdfOther}, true
+		case c == '<':
+			return pdfToken{kind: pdfString, raw: l.hexString()}, true
+		case c == '[' || c == ']' || c == '{' || c == '}' || c == '>' || c == ')':
+			l.pos++
+			return pdfToken{kind: pdfOther}, true
+		case c == '/':
+			l.pos++
+			return pdfToken{kind: pdfName, text: l.word()}, true
+		default:
+			w := l.word()
+			if w == "" {
+				l.pos++
+				continue
+			}
+			if n, err := strconv.ParseFloat(w, 64); err == nil {
+				return pdfToken{kind: pdfNumber, num: n}, true
+			}
+			return pdfToken{kind: pdfOperator, text: w}, true
+		}
+	}
+	return pdfToken{}, false
+}
+
+func (l *pdfLexer) word() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+// literalString 讀取 (...) 字串，處理跳脫字元與巢狀括號
+func (l *pdfLexer) literalString() []byte {
+	l.pos++ // (
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r', '\n':
+				// 行尾接續
+				if e == '\r' && l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for k := 0; k < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; k++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+		case '(':
+			depth++
+			out = append(out, c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// hexString 讀取 <...> 字串
+func (l *pdfLexer) hexString() []byte {
+	l.pos++ // <
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; isHexDigit(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++ // >
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		v, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		out[i] = byte(v)
+	}
+	return out
+}
+
+// skipInlineImage 跳過內嵌影像（BI ... ID <資料> EI）
+func (l *pdfLexer) skipInlineImage() {
+	i := bytes.Index(l.data[l.pos:], []byte("ID"))
+	if i < 0 {
+		l.pos = len(l.data)
+		return
+	}
+	l.pos += i + 2
+	for l.pos < len(l.data) {
+		j := bytes.Index(l.data[l.pos:], []byte("EI"))
+		if j < 0 {
+			l.pos = len(l.data)
+			return
+		}
+		l.pos += j + 2
+		if j > 0 && isPDFSpace(l.data[l.pos-3]) && (l.pos >= len(l.data) || isPDFSpace(l.data[l.pos])) {
+			return
+		}
+	}
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,241 @@
+package casematch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/analysis"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxCandidates 送 AI 比對的候選案件上限
+const maxCandidates = 20
+
+// Signal 判斷郵件屬於既有案件的依據
+type Signal string
+
+const (
+	SignalThread  Signal = "thread"  // 同一郵件串已有郵件歸入案件
+	SignalContact Signal = "contact" // 寄件者為進行中案件的聯絡人
+	SignalBrand   Signal = "brand"   // 近期只有一筆同品牌的進行中案件（只建議，品牌可能同時有多個合作）
+	SignalAI      Signal = "ai"      // AI 判斷為同一合作
+)
+
+// AI 案件比對（*openai.Service 實作此介面）
+type AI interface {
+	MatchCase(ctx context.Context, req openai.MatchCaseRequest) (*openai.MatchCaseResult, error)
+}
+
+// Match 比對結果
+type Match struct {
+	CaseID     uuid.UUID `json:"case_id"`
+	Signal     Signal    `json:"signal"`
+	Confidence float64   `json:"confidence"`
+	Reason     string    `json:"reason"`
+	Link       bool      `json:"link"` // false 時只建議，待使用者確認後歸入
+}
+
+// Matcher 判斷新郵件是否屬於既有案件：先用郵件串、聯絡人、品牌等確定性依據，都不符合時再由 AI 比對
+type Matcher struct {
+	db  *gorm.DB
+	cfg config.AIConfig
+	ai  AI // nil 時只用確定性依據
+}
+
+// NewMatcher 建立案件比對器
+func NewMatcher(db *gorm.DB, cfg config.AIConfig, ai AI) *Matcher {
+	if cfg.CaseMatchBrandDays <= 0 {
+		cfg.CaseMatchBrandDays = 30
+	}
+	if cfg.CaseMatchLinkThreshold <= 0 {
+		cfg.CaseMatchLinkThreshold = 0.85
+	}
+	return &Matcher{db: db, cfg: cfg, ai: ai}
+}
+
+// Match 依序以郵件串、聯絡人、品牌、AI 比對郵件所屬的既有案件；brand 為郵件擷取出的品牌（可為空）。
+// 找不到時回傳 nil
+func (m *Matcher) Match(ctx context.Context, email *models.Email, userID uuid.UUID, brand string) (*Match, error) {
+	if match, err := m.ByThread(email, userID); err != nil || match != nil {
+		return match, err
+	}
+	return m.MatchAnalyzed(ctx, email, userID, brand)
+}
+
+// MatchAnalyzed 依分析結果以聯絡人、品牌、AI 比對（不含郵件串，供已先呼叫 ByThread 的流程使用）
+func (m *Matcher) MatchAnalyzed(ctx context.Context, email *models.Email, userID uuid.UUID, brand string) (*Match, error) {
+	if match, err := m.byContact(email, userID); err != nil || match != nil {
+		return match, err
+	}
+	if match, err := m.byBrand(userID, brand); err != nil || match != nil {
+		return match, err
+	}
+	return m.byAI(ctx, email, userID, brand)
+}
+
+// ByThread 同一郵件串中已歸入案件的郵件所屬案件（不需分析結果，可在分析前使用）
+func (m *Matcher) ByThread(email *models.Email, userID uuid.UUID) (*Match, error) {
+	if email.ThreadID == nil || *email.ThreadID == "" {
+		return nil, nil
+	}
+	var linked models.Email
+	err := m.db.Select("emails.case_id").
+		Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
+		Joins("JOIN cases ON cases.id = emails.case_id AND cases.deleted_at IS NULL").
+		Where("oauth_accounts.user_id = ? AND emails.thread_id = ? AND emails.id <> ?", userID, *email.ThreadID, email.ID).
+		Order("emails.received_at DESC").
+		First(&linked).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to match case by thread: %w", err)
+	}
+	return &Match{CaseID: *linked.CaseID, Signal: SignalThread, Confidence: 1, Reason: "同一郵件串", Link: true}, nil
+}
+
+// byContact 寄件者為聯絡人、且仍在進行中的案件（最近更新的一筆）
+func (m *Matcher) byContact(email *models.Email, userID uuid.UUID) (*Match, error) {
+	var cs models.Case
+	err := m.open(userID).Select("id").
+		Where("LOWER(contact_email) = ?", strings.ToLower(email.FromEmail)).
+		Order("updated_at DESC").
+		First(&cs).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to match case by contact: %w", err)
+	}
+	return &Match{CaseID: cs.ID, Signal: SignalContact, Confidence: 1, Reason: "寄件者為案件聯絡人", Link: true}, nil
+}
+
+// byBrand 最近 N 天內更新過、且只有一筆的同品牌進行中案件（多筆時交給 AI 判斷）；
+// 同品牌可能是新的合作，只建議而不直接歸入
+func (m *Matcher) byBrand(userID uuid.UUID, brand string) (*Match, error) {
+	brand = strings.TrimSpace(brand)
+	if brand == "" {
+		return nil, nil
+	}
+	var cases []models.Case
+	err := m.open(userID).Select("id").
+		Where("LOWER(brand_name) = ? AND updated_at >= ?", strings.ToLower(brand), m.since()).
+		Limit(2).
+		Find(&cases).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to match case by brand: %w", err)
+	}
+	if len(cases) != 1 {
+		return nil, nil
+	}
+	reason := fmt.Sprintf("%d 天內同品牌（%s）的進行中案件", m.cfg.CaseMatchBrandDays, brand)
+	return &Match{CaseID: cases[0].ID, Signal: SignalBrand, Confidence: 1, Reason: reason, Link: false}, nil
+}
+
+// byAI 由 AI 從近期進行中的案件中判斷；信心度達門檻時歸入，未達但超過一般門檻時只建議
+func (m *Matcher) byAI(ctx context.Context, email *models.Email, userID uuid.UUID, brand string) (*Match, error) {
+	if m.ai == nil {
+		return nil, nil
+	}
+	var cases []models.Case
+	err := m.open(userID).
+		Where("updated_at >= ?", m.since()).
+		Order("updated_at DESC").
+		Limit(maxCandidates).
+		Find(&cases).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load candidate cases: %w", err)
+	}
+	if len(cases) == 0 {
+		return nil, nil
+	}
+
+	req := openai.MatchCaseRequest{
+		EmailBody: analysis.EmailBody(email),
+		EmailFrom: email.FromEmail,
+		BrandName: brand,
+		Cases:     make([]openai.CaseMatchInfo, 0, len(cases)),
+	}
+	if email.Subject != nil {
+		req.EmailSubject = *email.Subject
+	}
+	for _, cs := range cases {
+		info := openai.CaseMatchInfo{
+			ID:        cs.ID.String(),
+			Title:     cs.Title,
+			BrandName: cs.BrandName,
+			Status:    string(cs.Status),
+			UpdatedAt: cs.UpdatedAt.Format("2006-01-02"),
+		}
+		if cs.ContactEmail != nil {
+			info.ContactEmail = *cs.ContactEmail
+		}
+		if cs.Description != nil {
+			info.Description = *cs.Description
+		}
+		req.Cases = append(req.Cases, info)
+	}
+
+	res, err := m.ai.MatchCase(openai.WithUsageScope(ctx, userID.String(), email.ID.String()), req)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil || res.CaseID == "" || res.Confidence < m.cfg.ConfidenceThreshold {
+		return nil, nil
+	}
+	caseID, err := uuid.Parse(res.CaseID)
+	if err != nil {
+		return nil, nil
+	}
+	return &Match{
+		CaseID:     caseID,
+		Signal:     SignalAI,
+		Confidence: res.Confidence,
+		Reason:     res.Reason,
+		Link:       res.Confidence >= m.cfg.CaseMatchLinkThreshold,
+	}, nil
+}
+
+// Apply 依比對結果將郵件歸入案件，或記錄建議的案件待使用者確認
+func Apply(db *gorm.DB, email *models.Email, match *Match) error {
+	if match.Link {
+		err := db.Model(email).Updates(map[string]interface{}{
+			"case_id": match.CaseID, "suggested_case_id": nil, "suggested_case_reason": nil,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to attach email to case: %w", err)
+		}
+		email.CaseID = &match.CaseID
+		email.SuggestedCaseID = nil
+		email.SuggestedCaseReason = nil
+		return nil
+	}
+
+	reason := match.Reason
+	err := db.Model(email).Updates(map[string]interface{}{
+		"suggested_case_id": match.CaseID, "suggested_case_reason": reason,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to save suggested case: %w", err)
+	}
+	email.SuggestedCaseID = &match.CaseID
+	email.SuggestedCaseReason = &reason
+	return nil
+}
+
+// open 使用者進行中（待確認、進行中）的案件
+func (m *Matcher) open(userID uuid.UUID) *gorm.DB {
+	return m.db.Model(&models.Case{}).
+		Where("user_id = ? AND status IN ?", userID, []models.CaseStatus{models.CaseStatusToConfirm, models.CaseStatusInProgress})
+}
+
+func (m *Matcher) since() time.Time {
+	return time.Now().AddDate(0, 0, -m.cfg.CaseMatchBrandDays)
+}
@@ -0,0 +1,207 @@
+package casematch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 設置測試用的資料庫（使用 SQLite）
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Skipf("Skipping test: SQLite not available (CGO required): %v", err)
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.OAuthAccount{}, &models.Email{}, &models.Case{}, &models.CasePhase{},
+		&models.Quotation{}, &models.ReplyDraft{}, &models.FollowUp{}, &models.CaseUpdateSuggestion{}, &models.Notification{})
+	require.NoError(t, err)
+	return db
+}
+
+// fakeAI 回傳預設的比對結果
+type fakeAI struct {
+	result *openai.MatchCaseResult
+	calls  int
+}
+
+func (f *fakeAI) MatchCase(ctx context.Context, req openai.MatchCaseRequest) (*openai.MatchCaseResult, error) {
+	f.calls++
+	return f.result, nil
+}
+
+type fixture struct {
+	db      *gorm.DB
+	user    *models.User
+	account *models.OAuthAccount
+}
+
+func newFixture(t *testing.T) *fixture {
+	db := setupTestDB(t)
+	user := &models.User{ID: uuid.New(), Email: "creator@example.com", Name: "Creator"}
+	require.NoError(t, db.Create(user).Error)
+	account := &models.OAuthAccount{
+		ID: uuid.New(), UserID: user.ID, Provider: models.OAuthProviderGoogle, Email: "creator@example.com",
+		AccessToken: "a", RefreshToken: "r", TokenExpiry: time.Now().Add(time.Hour),
+	}
+	require.NoError(t, db.Create(account).Error)
+	return &fixture{db: db, user: user, account: account}
+}
+
+func (f *fixture) email(t *testing.T, from, thread string) *models.Email {
+	subject := "合作詢問"
+	e := &models.Email{
+		OAuthAccountID: f.account.ID, ProviderMessageID: uuid.NewString(), FromEmail: from,
+		Subject: &subject, Direction: models.EmailDirectionIncoming, ReceivedAt: time.Now().Add(-time.Hour),
+	}
+	if thread != "" {
+		e.ThreadID = &thread
+	}
+	require.NoError(t, f.db.Create(e).Error)
+	return e
+}
+
+func (f *fixture) newCase(t *testing.T, brand string, contact string) *models.Case {
+	cs := &models.Case{UserID: f.user.ID, Title: brand + " 合作", BrandName: brand, Status: models.CaseStatusInProgress}
+	if contact != "" {
+		cs.ContactEmail = &contact
+	}
+	require.NoError(t, f.db.Omit("User").Create(cs).Error)
+	return cs
+}
+
+func testConfig() config.AIConfig {
+	return config.AIConfig{ConfidenceThreshold: 0.7, CaseMatchBrandDays: 30, CaseMatchLinkThreshold: 0.85}
+}
+
+func TestMatch_DeterministicSignals(t *testing.T) {
+	f := newFixture(t)
+	byContact := f.newCase(t, "甲品牌", "pm@a.example")
+	byBrand := f.newCase(t, "乙品牌", "")
+	ai := &fakeAI{}
+	m := NewMatcher(f.db, testConfig(), ai)
+	ctx := context.Background()
+
+	// 同一郵件串
+	linked := f.email(t, "someone@else.example", "thread-1")
+	require.NoError(t, f.db.Model(linked).Update("case_id", byBrand.ID).Error)
+	match, err := m.Match(ctx, f.email(t, "pm@a.example", "thread-1"), f.user.ID, "")
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, SignalThread, match.Signal)
+	assert.Equal(t, byBrand.ID, match.CaseID)
+
+	// 同聯絡人（不分大小寫）
+	match, err = m.Match(ctx, f.email(t, "PM@a.example", ""), f.user.ID, "")
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, SignalContact, match.Signal)
+	assert.Equal(t, byContact.ID, match.CaseID)
+
+	// 已先比對過郵件串時只看聯絡人、品牌、AI
+	match, err = m.MatchAnalyzed(ctx, f.email(t, "pm@a.example", "thread-1"), f.user.ID, "")
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, SignalContact, match.Signal)
+
+	// 同品牌：只建議，不直接歸入
+	brandEmail := f.email(t, "new@b.example", "")
+	match, err = m.Match(ctx, brandEmail, f.user.ID, "乙品牌")
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, SignalBrand, match.Signal)
+	assert.Equal(t, byBrand.ID, match.CaseID)
+	assert.False(t, match.Link)
+	require.NoError(t, Apply(f.db, brandEmail, match))
+	assert.Nil(t, brandEmail.CaseID)
+	require.NotNil(t, brandEmail.SuggestedCaseID)
+	assert.Equal(t, byBrand.ID, *brandEmail.SuggestedCaseID)
+
+	// 已完成的案件不比對
+	require.NoError(t, f.db.Model(&models.Case{ID: byBrand.ID}).Update("status", models.CaseStatusCompleted).Error)
+	match, err = m.Match(ctx, f.email(t, "new@b.example", ""), f.user.ID, "乙品牌")
+	require.NoError(t, err)
+	assert.Nil(t, match)
+	assert.Equal(t, 1, ai.calls, "falls back to AI once deterministic signals fail")
+}
+
+func TestMatch_AILinksOrSuggests(t *testing.T) {
+	f := newFixture(t)
+	cs := f.newCase(t, "甲品牌", "")
+	ai := &fakeAI{result: &openai.MatchCaseResult{CaseID: cs.ID.String(), Confidence: 0.9, Reason: "同一檔期"}}
+	m := NewMatcher(f.db, testConfig(), ai)
+
+	email := f.email(t, "agency@c.example", "")
+	match, err := m.Match(context.Background(), email, f.user.ID, "代理商")
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, SignalAI, match.Signal)
+	assert.True(t, match.Link)
+
+	// 信心度未達歸入門檻時只建議
+	ai.result.Confidence = 0.75
+	match, err = m.Match(context.Background(), email, f.user.ID, "代理商")
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.False(t, match.Link)
+	require.NoError(t, Apply(f.db, email, match))
+
+	var saved models.Email
+	require.NoError(t, f.db.First(&saved, "id = ?", email.ID).Error)
+	assert.Nil(t, saved.CaseID)
+	require.NotNil(t, saved.SuggestedCaseID)
+	assert.Equal(t, cs.ID, *saved.SuggestedCaseID)
+
+	// 未達一般門檻時不建議
+	ai.result.Confidence = 0.5
+	match, err = m.Match(context.Background(), email, f.user.ID, "代理商")
+	require.NoError(t, err)
+	assert.Nil(t, match)
+}
+
+func TestMerge(t *testing.T) {
+	f := newFixture(t)
+	target := f.newCase(t, "甲品牌", "")
+	source := f.newCase(t, "甲品牌", "pm@a.example")
+	notes := "來源備註"
+	source.Notes = &notes
+	source.Tags = []string{"開箱"}
+
+	email := f.email(t, "pm@a.example", "")
+	require.NoError(t, f.db.Model(email).Update("case_id", source.ID).Error)
+	for _, v := range []int{1, 2} {
+		require.NoError(t, f.db.Omit("Case", "User").Create(&models.Quotation{CaseID: target.ID, UserID: f.user.ID, Version: v}).Error)
+	}
+	require.NoError(t, f.db.Omit("Case", "User").Create(&models.Quotation{CaseID: source.ID, UserID: f.user.ID, Version: 1}).Error)
+
+	require.NoError(t, Merge(f.db, target, source))
+
+	var saved models.Email
+	require.NoError(t, f.db.First(&saved, "id = ?", email.ID).Error)
+	assert.Equal(t, target.ID, *saved.CaseID)
+
+	var versions []int
+	require.NoError(t, f.db.Model(&models.Quotation{}).Where("case_id = ?", target.ID).Order("version").Pluck("version", &versions).Error)
+	assert.Equal(t, []int{1, 2, 3}, versions)
+
+	var merged models.Case
+	require.NoError(t, f.db.First(&merged, "id = ?", target.ID).Error)
+	require.NotNil(t, merged.ContactEmail)
+	assert.Equal(t, "pm@a.example", *merged.ContactEmail)
+	require.NotNil(t, merged.Notes)
+	assert.Equal(t, "來源備註", *merged.Notes)
+	assert.ErrorIs(t, f.db.First(&models.Case{}, "id = ?", source.ID).Error, gorm.ErrRecordNotFound)
+
+	assert.ErrorIs(t, Merge(f.db, target, target), ErrSameCase)
+}
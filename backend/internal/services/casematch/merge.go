@@ -0,0 +1,130 @@
+package casematch
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSameCase 合併的來源與目標為同一案件
+var ErrSameCase = errors.New("cannot merge a case into itself")
+
+// Merge 將重複的 source 案件併入 target：郵件、階段、報價單、草稿、追蹤、更新建議與通知改歸 target，
+// target 空白的欄位以 source 補上（備註接在後面、標籤與合作項目取聯集），最後刪除 source
+func Merge(db *gorm.DB, target, source *models.Case) error {
+	if target.ID == source.ID {
+		return ErrSameCase
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{
+			&models.Email{}, &models.ReplyDraft{}, &models.FollowUp{}, &models.CaseUpdateSuggestion{}, &models.Notification{},
+		} {
+			if err := tx.Unscoped().Model(model).Where("case_id = ?", source.ID).Update("case_id", target.ID).Error; err != nil {
+				return fmt.Errorf("failed to move %T: %w", model, err)
+			}
+		}
+		err := tx.Unscoped().Model(&models.Email{}).
+			Where("suggested_case_id = ?", source.ID).
+			Update("suggested_case_id", target.ID).Error
+		if err != nil {
+			return fmt.Errorf("failed to move suggested emails: %w", err)
+		}
+
+		// 階段接在 target 既有階段之後
+		var phases int64
+		if err := tx.Model(&models.CasePhase{}).Where("case_id = ?", target.ID).Count(&phases).Error; err != nil {
+			return fmt.Errorf("failed to count phases: %w", err)
+		}
+		err = tx.Unscoped().Model(&models.CasePhase{}).
+			Where("case_id = ?", source.ID).
+			Updates(map[string]interface{}{"case_id": target.ID, "order": gorm.Expr(`"order" + ?`, phases)}).Error
+		if err != nil {
+			return fmt.Errorf("failed to move phases: %w", err)
+		}
+
+		// 報價單版本號接在 target 最新版本之後（同案件版本號不可重複）
+		var version int
+		err = tx.Model(&models.Quotation{}).
+			Where("case_id = ?", target.ID).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&version).Error
+		if err != nil {
+			return fmt.Errorf("failed to get latest quotation version: %w", err)
+		}
+		err = tx.Model(&models.Quotation{}).
+			Where("case_id = ?", source.ID).
+			Updates(map[string]interface{}{"case_id": target.ID, "version": gorm.Expr("version + ?", version)}).Error
+		if err != nil {
+			return fmt.Errorf("failed to move quotations: %w", err)
+		}
+
+		mergeFields(target, source)
+		if err := tx.Omit(clause.Associations).Save(target).Error; err != nil {
+			return fmt.Errorf("failed to save merged case: %w", err)
+		}
+		if err := tx.Delete(source).Error; err != nil {
+			return fmt.Errorf("failed to delete merged case: %w", err)
+		}
+		return nil
+	})
+}
+
+// mergeFields target 空白的欄位以 source 補上
+func mergeFields(target, source *models.Case) {
+	for _, f := range []struct{ dst, src **string }{
+		{&target.CollaborationType, &source.CollaborationType},
+		{&target.Description, &source.Description},
+		{&target.Currency, &source.Currency},
+		{&target.ContactName, &source.ContactName},
+		{&target.ContactEmail, &source.ContactEmail},
+		{&target.ContactPhone, &source.ContactPhone},
+	} {
+		if (*f.dst == nil || strings.TrimSpace(**f.dst) == "") && *f.src != nil {
+			*f.dst = *f.src
+		}
+	}
+	if target.BrandName == "" {
+		target.BrandName = source.BrandName
+	}
+	if target.QuotedAmount == nil {
+		target.QuotedAmount = source.QuotedAmount
+	}
+	if target.FinalAmount == nil {
+		target.FinalAmount = source.FinalAmount
+	}
+	if target.DeadlineDate == nil {
+		target.DeadlineDate = source.DeadlineDate
+	}
+
+	if source.Notes != nil && strings.TrimSpace(*source.Notes) != "" {
+		if target.Notes == nil || strings.TrimSpace(*target.Notes) == "" {
+			target.Notes = source.Notes
+		} else {
+			notes := *target.Notes + "\n\n" + *source.Notes
+			target.Notes = &notes
+		}
+	}
+	target.Tags = union(target.Tags, source.Tags)
+	target.CollaborationItems = union(target.CollaborationItems, source.CollaborationItems)
+}
+
+// union 合併兩個清單（保留順序、去除重複）
+func union(a, b []string) []string {
+	if len(b) == 0 {
+		return a
+	}
+	seen := make(map[string]bool, len(a)+len(b))
+	out := make([]string, 0, len(a)+len(b))
+	for _, v := range append(append([]string{}, a...), b...) {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,373 @@
+package caseupdate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultMinConfidence 使用者未設定門檻時，自動套用所需的最低信心度
+const DefaultMinConfidence = 0.8
+
+// 可建議更新的案件欄位
+const (
+	FieldStatus       = "status"
+	FieldNotes        = "notes" // 附加進度說明，不覆寫既有備註
+	FieldDescription  = "description"
+	FieldQuotedAmount = "quoted_amount"
+	FieldFinalAmount  = "final_amount"
+	FieldDeadlineDate = "deadline_date"
+)
+
+// Fields 可建議更新（及設定自動套用）的欄位
+var Fields = []string{FieldStatus, FieldNotes, FieldDescription, FieldQuotedAmount, FieldFinalAmount, FieldDeadlineDate}
+
+// 建議處理錯誤（呼叫端以 errors.Is 判斷）
+var (
+	ErrResolved     = errors.New("suggestion already resolved")
+	ErrUnknownField = errors.New("field not in suggestion")
+)
+
+// Service 將 AI 建議的案件更新存為待確認的建議，並處理接受 / 拒絕
+type Service struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+// NewService 建立案件更新建議服務
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db, now: time.Now}
+}
+
+// IsField 是否為可建議更新的欄位
+func IsField(field string) bool {
+	for _, f := range Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// MinConfidence 實際套用的自動套用門檻
+func MinConfidence(settings models.AITriageSettings) float64 {
+	if settings.CaseUpdateMinConfidence != nil {
+		return *settings.CaseUpdateMinConfidence
+	}
+	return DefaultMinConfidence
+}
+
+// Diff 比對 AI 建議與目前案件，只保留會改變案件的欄位
+func Diff(cs *models.Case, r *openai.ReplyCaseUpdateResult) []models.CaseFieldChange {
+	var changes []models.CaseFieldChange
+	add := func(field, current, proposed string) {
+		if proposed != "" && proposed != current {
+			changes = append(changes, models.CaseFieldChange{Field: field, Current: current, Proposed: proposed, State: models.CaseFieldChangePending})
+		}
+	}
+
+	if r.Status != "" && isCaseStatus(r.Status) {
+		add(FieldStatus, string(cs.Status), r.Status)
+	}
+	if r.NotesProgress != "" {
+		// 進度說明一律附加，Current 留空
+		add(FieldNotes, "", r.NotesProgress)
+	}
+	add(FieldDescription, str(cs.Description), r.DescriptionUpdate)
+	add(FieldQuotedAmount, formatAmount(cs.QuotedAmount), formatAmount(r.QuotedAmount))
+	add(FieldFinalAmount, formatAmount(cs.FinalAmount), formatAmount(r.FinalAmount))
+	if r.DeadlineDate != "" {
+		if t, err := time.Parse("2006-01-02", r.DeadlineDate); err == nil {
+			add(FieldDeadlineDate, formatDate(cs.DeadlineDate), t.Format("2006-01-02"))
+		}
+	}
+	return changes
+}
+
+// Propose 儲存 AI 建議的案件更新：符合使用者自動套用設定的欄位直接寫入案件，其餘待使用者確認。
+// 沒有任何變更時回傳 nil
+func (s *Service) Propose(cs *models.Case, source models.CaseUpdateSource, emailID *uuid.UUID, r *openai.ReplyCaseUpdateResult) (*models.CaseUpdateSuggestion, error) {
+	if !r.ShouldUpdate {
+		return nil, nil
+	}
+	changes := Diff(cs, r)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	settings, err := s.settings(cs.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.AutoApplyCaseUpdates && r.Confidence >= MinConfidence(settings) {
+		for i := range changes {
+			if autoApplies(settings, changes[i].Field) {
+				changes[i].State = models.CaseFieldChangeApplied
+				changes[i].AutoApplied = true
+			}
+		}
+	}
+
+	suggestion := &models.CaseUpdateSuggestion{
+		UserID:     cs.UserID,
+		CaseID:     cs.ID,
+		EmailID:    emailID,
+		Source:     source,
+		Signals:    r.Signals,
+		Reason:     r.Reason,
+		Confidence: r.Confidence,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := apply(tx, cs.ID, changes); err != nil {
+			return err
+		}
+		if err := finish(suggestion, changes, s.now()); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(suggestion).Error; err != nil {
+			return err
+		}
+		if suggestion.Status == models.CaseUpdateSuggestionPending {
+			return notify(tx, cs, suggestion)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save case update suggestion: %w", err)
+	}
+	return suggestion, nil
+}
+
+// Accept 套用建議中指定的欄位（fields 為空時套用所有待確認欄位），其餘待確認欄位視為拒絕
+func (s *Service) Accept(suggestion *models.CaseUpdateSuggestion, fields []string) error {
+	if suggestion.Status != models.CaseUpdateSuggestionPending {
+		return ErrResolved
+	}
+	changes, err := suggestion.FieldChanges()
+	if err != nil {
+		return fmt.Errorf("failed to decode suggestion: %w", err)
+	}
+
+	selected := map[string]bool{}
+	for _, f := range fields {
+		if !hasPending(changes, f) {
+			return fmt.Errorf("%w: %s", ErrUnknownField, f)
+		}
+		selected[f] = true
+	}
+
+	var toApply []models.CaseFieldChange
+	for i := range changes {
+		if changes[i].State != models.CaseFieldChangePending {
+			continue
+		}
+		if len(selected) == 0 || selected[changes[i].Field] {
+			changes[i].State = models.CaseFieldChangeApplied
+			toApply = append(toApply, changes[i])
+		} else {
+			changes[i].State = models.CaseFieldChangeRejected
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := apply(tx, suggestion.CaseID, toApply); err != nil {
+			return err
+		}
+		return save(tx, suggestion, changes, s.now())
+	})
+}
+
+// Reject 拒絕建議中所有待確認的欄位
+func (s *Service) Reject(suggestion *models.CaseUpdateSuggestion) error {
+	if suggestion.Status != models.CaseUpdateSuggestionPending {
+		return ErrResolved
+	}
+	changes, err := suggestion.FieldChanges()
+	if err != nil {
+		return fmt.Errorf("failed to decode suggestion: %w", err)
+	}
+	for i := range changes {
+		if changes[i].State == models.CaseFieldChangePending {
+			changes[i].State = models.CaseFieldChangeRejected
+		}
+	}
+	return save(s.db, suggestion, changes, s.now())
+}
+
+// settings 使用者的自動套用設定（未設定時不自動套用）
+func (s *Service) settings(userID uuid.UUID) (models.AITriageSettings, error) {
+	var settings models.AITriageSettings
+	err := s.db.Where("user_id = ?", userID).First(&settings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.AITriageSettings{UserID: userID}, nil
+	}
+	if err != nil {
+		return settings, fmt.Errorf("failed to load case update settings: %w", err)
+	}
+	return settings, nil
+}
+
+// autoApplies 欄位是否在自動套用的範圍內
+func autoApplies(settings models.AITriageSettings, field string) bool {
+	if len(settings.CaseUpdateFields) == 0 {
+		return true
+	}
+	for _, f := range settings.CaseUpdateFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// apply 將狀態為 applied 的變更寫入案件（notes 附加在既有備註之後）
+func apply(tx *gorm.DB, caseID uuid.UUID, changes []models.CaseFieldChange) error {
+	updates := map[string]interface{}{}
+	for _, ch := range changes {
+		if ch.State != models.CaseFieldChangeApplied {
+			continue
+		}
+		switch ch.Field {
+		case FieldStatus, FieldDescription:
+			updates[ch.Field] = ch.Proposed
+		case FieldQuotedAmount, FieldFinalAmount:
+			v, err := strconv.ParseFloat(ch.Proposed, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q", ch.Field, ch.Proposed)
+			}
+			updates[ch.Field] = v
+		case FieldDeadlineDate:
+			t, err := time.Parse("2006-01-02", ch.Proposed)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q", ch.Field, ch.Proposed)
+			}
+			updates[ch.Field] = t
+		case FieldNotes:
+			var cs models.Case
+			if err := tx.Select("id", "notes").First(&cs, "id = ?", caseID).Error; err != nil {
+				return err
+			}
+			notes := str(cs.Notes)
+			if notes != "" {
+				notes += "\n\n"
+			}
+			updates[ch.Field] = notes + fmt.Sprintf("[%s] %s", time.Now().Format("2006-01-02 15:04"), ch.Proposed)
+		}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return tx.Model(&models.Case{ID: caseID}).Updates(updates).Error
+}
+
+// finish 依各欄位狀態決定建議的整體狀態
+func finish(suggestion *models.CaseUpdateSuggestion, changes []models.CaseFieldChange, now time.Time) error {
+	var pending, applied, rejected, auto int
+	for _, ch := range changes {
+		switch ch.State {
+		case models.CaseFieldChangePending:
+			pending++
+		case models.CaseFieldChangeApplied:
+			applied++
+			if ch.AutoApplied {
+				auto++
+			}
+		case models.CaseFieldChangeRejected:
+			rejected++
+		}
+	}
+
+	switch {
+	case pending > 0:
+		suggestion.Status = models.CaseUpdateSuggestionPending
+	case auto == len(changes):
+		suggestion.Status = models.CaseUpdateSuggestionAutoApplied
+	case rejected == 0:
+		suggestion.Status = models.CaseUpdateSuggestionAccepted
+	case applied == 0:
+		suggestion.Status = models.CaseUpdateSuggestionRejected
+	default:
+		suggestion.Status = models.CaseUpdateSuggestionPartiallyAccepted
+	}
+	if pending == 0 {
+		suggestion.ResolvedAt = &now
+	}
+	return suggestion.SetFieldChanges(changes)
+}
+
+// save 更新建議的欄位狀態與整體狀態
+func save(tx *gorm.DB, suggestion *models.CaseUpdateSuggestion, changes []models.CaseFieldChange, now time.Time) error {
+	if err := finish(suggestion, changes, now); err != nil {
+		return err
+	}
+	return tx.Model(suggestion).Updates(map[string]interface{}{
+		"status":      suggestion.Status,
+		"changes":     suggestion.Changes,
+		"resolved_at": suggestion.ResolvedAt,
+	}).Error
+}
+
+// notify 建立待確認的站內通知
+func notify(tx *gorm.DB, cs *models.Case, suggestion *models.CaseUpdateSuggestion) error {
+	title := fmt.Sprintf("「%s」有 AI 建議的案件更新待確認", cs.Title)
+	if suggestion.Source == models.CaseUpdateSourceIncoming {
+		title = fmt.Sprintf("品牌來信：「%s」有 AI 建議的案件更新待確認", cs.Title)
+	}
+	n := models.Notification{
+		UserID:  cs.UserID,
+		Type:    models.NotificationTypeCaseUpdate,
+		Title:   title,
+		EmailID: suggestion.EmailID,
+		CaseID:  &cs.ID,
+	}
+	if suggestion.Reason != "" {
+		reason := suggestion.Reason
+		n.Message = &reason
+	}
+	return tx.Omit(clause.Associations).Create(&n).Error
+}
+
+func hasPending(changes []models.CaseFieldChange, field string) bool {
+	for _, ch := range changes {
+		if ch.Field == field && ch.State == models.CaseFieldChangePending {
+			return true
+		}
+	}
+	return false
+}
+
+func isCaseStatus(status string) bool {
+	switch models.CaseStatus(status) {
+	case models.CaseStatusToConfirm, models.CaseStatusInProgress, models.CaseStatusCompleted, models.CaseStatusCancelled, models.CaseStatusOther:
+		return true
+	}
+	return false
+}
+
+func formatAmount(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func str(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
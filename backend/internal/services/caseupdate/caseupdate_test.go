@@ -0,0 +1,170 @@
+package caseupdate
+
+import (
+	"testing"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 設置測試用的資料庫（使用 SQLite）
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Skipf("Skipping test: SQLite not available (CGO required): %v", err)
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.Case{}, &models.AITriageSettings{},
+		&models.CaseUpdateSuggestion{}, &models.Notification{})
+	require.NoError(t, err)
+	return db
+}
+
+func newCase(t *testing.T, db *gorm.DB) *models.Case {
+	user := &models.User{ID: uuid.New(), Email: "creator@example.com", Name: "Creator"}
+	require.NoError(t, db.Create(user).Error)
+	notes := "已寄出報價"
+	quoted := 30000.0
+	cs := &models.Case{UserID: user.ID, Title: "新品開箱", BrandName: "Glow", Status: models.CaseStatusToConfirm,
+		Notes: &notes, QuotedAmount: &quoted}
+	require.NoError(t, db.Create(cs).Error)
+	return cs
+}
+
+func reply(confidence float64) *openai.ReplyCaseUpdateResult {
+	quoted := 30000.0
+	final := 28000.0
+	return &openai.ReplyCaseUpdateResult{
+		ShouldUpdate:  true,
+		Status:        "in_progress",
+		NotesProgress: "品牌同意報價，等待合約",
+		QuotedAmount:  &quoted, // 與目前相同，不列入差異
+		FinalAmount:   &final,
+		DeadlineDate:  "2026-11-30",
+		Reason:        "品牌確認合作與金額",
+		Confidence:    confidence,
+	}
+}
+
+func loadCase(t *testing.T, db *gorm.DB, id uuid.UUID) models.Case {
+	var cs models.Case
+	require.NoError(t, db.First(&cs, "id = ?", id).Error)
+	return cs
+}
+
+func TestPropose_Pending(t *testing.T) {
+	db := setupTestDB(t)
+	cs := newCase(t, db)
+	svc := NewService(db)
+
+	s, err := svc.Propose(cs, models.CaseUpdateSourceReply, nil, reply(0.95))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	assert.Equal(t, models.CaseUpdateSuggestionPending, s.Status)
+	assert.Equal(t, "品牌確認合作與金額", s.Reason)
+
+	changes, err := s.FieldChanges()
+	require.NoError(t, err)
+	fields := map[string]models.CaseFieldChange{}
+	for _, ch := range changes {
+		fields[ch.Field] = ch
+		assert.Equal(t, models.CaseFieldChangePending, ch.State)
+	}
+	assert.Len(t, fields, 4)
+	assert.Equal(t, "to_confirm", fields[FieldStatus].Current)
+	assert.Equal(t, "in_progress", fields[FieldStatus].Proposed)
+	assert.Equal(t, "28000", fields[FieldFinalAmount].Proposed)
+
+	// 未設定自動套用時不動案件，並通知使用者確認
+	assert.Equal(t, models.CaseStatusToConfirm, loadCase(t, db, cs.ID).Status)
+	var notifications []models.Notification
+	require.NoError(t, db.Find(&notifications).Error)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, models.NotificationTypeCaseUpdate, notifications[0].Type)
+
+	// 沒有差異時不建立建議
+	s, err = svc.Propose(cs, models.CaseUpdateSourceReply, nil, &openai.ReplyCaseUpdateResult{ShouldUpdate: true, Status: "to_confirm"})
+	require.NoError(t, err)
+	assert.Nil(t, s)
+}
+
+func TestPropose_AutoApply(t *testing.T) {
+	db := setupTestDB(t)
+	cs := newCase(t, db)
+	svc := NewService(db)
+	minConfidence := 0.9
+	require.NoError(t, db.Create(&models.AITriageSettings{UserID: cs.UserID, AutoApplyCaseUpdates: true,
+		CaseUpdateMinConfidence: &minConfidence, CaseUpdateFields: pq.StringArray{FieldNotes, FieldDeadlineDate}}).Error)
+
+	// 信心度不足：全部待確認
+	s, err := svc.Propose(cs, models.CaseUpdateSourceReply, nil, reply(0.85))
+	require.NoError(t, err)
+	assert.Equal(t, models.CaseUpdateSuggestionPending, s.Status)
+	assert.Nil(t, loadCase(t, db, cs.ID).DeadlineDate)
+
+	// 達門檻：只套用指定欄位，其餘待確認
+	s, err = svc.Propose(cs, models.CaseUpdateSourceReply, nil, reply(0.95))
+	require.NoError(t, err)
+	assert.Equal(t, models.CaseUpdateSuggestionPending, s.Status)
+	got := loadCase(t, db, cs.ID)
+	require.NotNil(t, got.DeadlineDate)
+	assert.Equal(t, "2026-11-30", got.DeadlineDate.Format("2006-01-02"))
+	assert.Contains(t, *got.Notes, "已寄出報價\n\n[")
+	assert.Contains(t, *got.Notes, "品牌同意報價，等待合約")
+	assert.Equal(t, models.CaseStatusToConfirm, got.Status)
+
+	// 所有欄位皆在範圍內時整筆標為自動套用、不通知
+	require.NoError(t, db.Model(&models.AITriageSettings{}).Where("user_id = ?", cs.UserID).
+		Update("case_update_fields", pq.StringArray{}).Error)
+	var before int64
+	db.Model(&models.Notification{}).Count(&before)
+	s, err = svc.Propose(&got, models.CaseUpdateSourceReply, nil, reply(0.95))
+	require.NoError(t, err)
+	assert.Equal(t, models.CaseUpdateSuggestionAutoApplied, s.Status)
+	assert.NotNil(t, s.ResolvedAt)
+	assert.Equal(t, models.CaseStatusInProgress, loadCase(t, db, cs.ID).Status)
+	var after int64
+	db.Model(&models.Notification{}).Count(&after)
+	assert.Equal(t, before, after)
+}
+
+func TestAcceptReject(t *testing.T) {
+	db := setupTestDB(t)
+	cs := newCase(t, db)
+	svc := NewService(db)
+
+	s, err := svc.Propose(cs, models.CaseUpdateSourceReply, nil, reply(0.5))
+	require.NoError(t, err)
+
+	// 只接受部分欄位：其餘待確認欄位視為拒絕
+	assert.ErrorIs(t, svc.Accept(s, []string{"brand_name"}), ErrUnknownField)
+	require.NoError(t, svc.Accept(s, []string{FieldFinalAmount, FieldStatus}))
+	assert.Equal(t, models.CaseUpdateSuggestionPartiallyAccepted, s.Status)
+	assert.NotNil(t, s.ResolvedAt)
+	got := loadCase(t, db, cs.ID)
+	assert.Equal(t, models.CaseStatusInProgress, got.Status)
+	require.NotNil(t, got.FinalAmount)
+	assert.Equal(t, 28000.0, *got.FinalAmount)
+	assert.Nil(t, got.DeadlineDate)
+	assert.Equal(t, "已寄出報價", *got.Notes)
+
+	var stored models.CaseUpdateSuggestion
+	require.NoError(t, db.First(&stored, "id = ?", s.ID).Error)
+	assert.Equal(t, models.CaseUpdateSuggestionPartiallyAccepted, stored.Status)
+	assert.ErrorIs(t, svc.Accept(&stored, nil), ErrResolved)
+
+	s, err = svc.Propose(&got, models.CaseUpdateSourceReply, nil, &openai.ReplyCaseUpdateResult{ShouldUpdate: true, Status: "completed", Reason: "已結案"})
+	require.NoError(t, err)
+	require.NoError(t, svc.Reject(s))
+	assert.Equal(t, models.CaseUpdateSuggestionRejected, s.Status)
+	assert.Equal(t, models.CaseStatusInProgress, loadCase(t, db, cs.ID).Status)
+	assert.ErrorIs(t, svc.Reject(s), ErrResolved)
+}
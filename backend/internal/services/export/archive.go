@@ -0,0 +1,85 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+)
+
+// WriteMbox 將郵件依序寫成 mboxrd 格式
+func WriteMbox(w io.Writer, emails []models.Email) error {
+	for i := range emails {
+		e := &emails[i]
+		sender := e.FromEmail
+		if sender == "" {
+			sender = "MAILER-DAEMON"
+		}
+		// asctime 格式的分隔行
+		if _, err := fmt.Fprintf(w, "From %s %s\n", sender, e.ReceivedAt.UTC().Format(time.ANSIC)); err != nil {
+			return err
+		}
+
+		msg := strings.ReplaceAll(string(BuildMessage(e)), "\r\n", "\n")
+		for _, line := range strings.SplitAfter(msg, "\n") {
+			// mboxrd：以 From 開頭（含已跳脫的 >From）的行再加一個 >
+			if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+				line = ">" + line
+			}
+			if _, err := io.WriteString(w, line); err != nil {
+				return err
+			}
+		}
+		if !strings.HasSuffix(msg, "\n") {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteEMLZip 將每封郵件寫成獨立 .eml 檔並打包為 zip
+func WriteEMLZip(w io.Writer, emails []models.Email) error {
+	zw := zip.NewWriter(w)
+	for i := range emails {
+		e := &emails[i]
+		name := fmt.Sprintf("%03d-%s-%s.eml", i+1, e.ReceivedAt.UTC().Format("20060102-1504"), FileSlug(deref(e.Subject)))
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: e.ReceivedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(fw, bytes.NewReader(BuildMessage(e))); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+var unsafeFileChars = regexp.MustCompile(`[\\/:*?"<>|\x00-\x1f]+`)
+
+// FileSlug 將主旨轉為安全的檔名片段（保留中文）
+func FileSlug(s string) string {
+	s = unsafeFileChars.ReplaceAllString(s, " ")
+	s = strings.Join(strings.Fields(s), "_")
+	if s == "" {
+		return "no-subject"
+	}
+	runes := []rune(s)
+	if len(runes) > 60 {
+		s = string(runes[:60])
+	}
+	return s
+}
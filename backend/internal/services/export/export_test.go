@@ -0,0 +1,200 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/gmail"
+	"github.com/designcomb/influenter-backend/internal/services/mailimport"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string { return &s }
+
+func testEmails() []models.Email {
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	return []models.Email{
+		{
+			ID:             uuid.New(),
+			FromEmail:      "brand@example.com",
+			FromName:       strPtr("品牌窗口"),
+			ToEmail:        strPtr("creator@example.com"),
+			Subject:        strPtr("合作邀約"),
+			BodyText:       strPtr("您好，想邀請您合作。\nFrom our team with love"),
+			BodyHTML:       strPtr("<p>您好</p>"),
+			RFCMessageID:   strPtr("invite-1@example.com"),
+			Direction:      models.EmailDirectionIncoming,
+			ReceivedAt:     base,
+			HasAttachments: true,
+		},
+		{
+			ID:         uuid.New(),
+			FromEmail:  "creator@example.com",
+			ToEmail:    strPtr("brand@example.com"),
+			Subject:    strPtr("Re: 合作邀約"),
+			BodyText:   strPtr("謝謝，報價如附。"),
+			Direction:  models.EmailDirectionOutgoing,
+			ReceivedAt: base.Add(2 * time.Hour),
+		},
+	}
+}
+
+func TestBuildMessage_RoundTrip(t *testing.T) {
+	e := testEmails()[0]
+
+	raw := BuildMessage(&e)
+	assert.Contains(t, string(raw), "X-Influenter-Attachments: omitted")
+
+	parsed, err := gmail.ParseRawMessage(raw, uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, "brand@example.com", parsed.FromEmail)
+	assert.Equal(t, "品牌窗口", *parsed.FromName)
+	assert.Equal(t, "合作邀約", *parsed.Subject)
+	assert.Equal(t, "invite-1@example.com", *parsed.RFCMessageID)
+	assert.Contains(t, *parsed.BodyText, "您好，想邀請您合作。")
+	assert.Equal(t, "<p>您好</p>", strings.TrimSpace(*parsed.BodyHTML))
+	assert.True(t, parsed.ReceivedAt.Equal(e.ReceivedAt))
+}
+
+func TestBuildMessage_HeaderInjection(t *testing.T) {
+	e := testEmails()[1]
+	e.Subject = strPtr("hello\r\nBcc: evil@example.com")
+
+	raw := string(BuildMessage(&e))
+	assert.NotContains(t, raw, "\r\nBcc:")
+}
+
+func TestWriteMbox_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteMbox(&buf, testEmails()))
+
+	var subjects []string
+	err := mailimport.SplitMbox(&buf, mailimport.MaxMessageSize, func(index int, raw []byte, err error) error {
+		require.NoError(t, err)
+		parsed, perr := gmail.ParseRawMessage(raw, uuid.New())
+		require.NoError(t, perr)
+		subjects = append(subjects, *parsed.Subject)
+		if index == 0 {
+			// 內文中的 "From " 行需正確跳脫與還原
+			assert.Contains(t, *parsed.BodyText, "From our team with love")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"合作邀約", "Re: 合作邀約"}, subjects)
+}
+
+func TestWriteEMLZip(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteEMLZip(&buf, testEmails()))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 2)
+	assert.Equal(t, "001-20240301-1000-合作邀約.eml", zr.File[0].Name)
+	assert.Equal(t, "002-20240301-1200-Re_合作邀約.eml", zr.File[1].Name)
+}
+
+func TestWriteCasePDF(t *testing.T) {
+	amount := 30000.0
+	cs := &models.Case{
+		ID:           uuid.New(),
+		Title:        "春季新品開箱",
+		BrandName:    "好品牌",
+		Status:       models.CaseStatusInProgress,
+		QuotedAmount: &amount,
+		Currency:     strPtr("TWD"),
+		Description:  strPtr(strings.Repeat("很長的說明文字。", 600)),
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCasePDF(&buf, cs, testEmails(), time.FixedZone("CST", 8*3600)))
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "%PDF-1.4"))
+	assert.True(t, strings.HasSuffix(out, "%%EOF\n"))
+	assert.Contains(t, out, "/Encoding /UniCNS-UCS2-H")
+	// 長說明會跨頁，郵件另起新頁：至少 3 頁
+	assert.Regexp(t, `/Count [3-9]`, out)
+}
+
+func testQuotation(t *testing.T) (*models.Quotation, *models.Case, *models.User) {
+	lines, err := json.Marshal([]models.QuotationLine{
+		{Title: "IG Reels", Description: "60 秒直式影片", Quantity: 2, UnitPrice: 20000, DiscountPercent: 10, Amount: 36000},
+		{Title: "<script>限動</script>", Quantity: 1, UnitPrice: 5000, Amount: 5000},
+	})
+	require.NoError(t, err)
+	validUntil := time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC)
+	q := &models.Quotation{
+		ID: uuid.New(), Version: 2, Number: "Q-1A2B3C4D-02", Currency: "TWD", Lines: lines,
+		Subtotal: 41000, Discount: 1000, TaxRate: 5, TaxAmount: 2000, Total: 42000,
+		ValidUntil: &validUntil, Terms: strPtr("簽約後支付 50% 訂金"),
+		CreatedAt: time.Date(2026, 10, 1, 20, 0, 0, 0, time.UTC),
+	}
+	cs := &models.Case{ID: uuid.New(), Title: "春季新品開箱", BrandName: "好品牌", ContactName: strPtr("王小姐")}
+	return q, cs, &models.User{Name: "創作者", Email: "creator@example.com"}
+}
+
+func TestWriteQuotationHTML(t *testing.T) {
+	q, cs, issuer := testQuotation(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteQuotationHTML(&buf, q, cs, issuer, time.FixedZone("CST", 8*3600)))
+
+	out := buf.String()
+	assert.Contains(t, out, "Q-1A2B3C4D-02（第 2 版）")
+	assert.Contains(t, out, "日期：2026-10-02")
+	assert.Contains(t, out, "有效期限：2026-10-31")
+	assert.Contains(t, out, "36,000")
+	assert.Contains(t, out, "-1,000")
+	assert.Contains(t, out, "稅額（5%）")
+	assert.Contains(t, out, "42,000")
+	assert.Contains(t, out, "簽約後支付 50% 訂金")
+	assert.NotContains(t, out, "<script>")
+}
+
+func TestWriteQuotationPDF(t *testing.T) {
+	q, cs, issuer := testQuotation(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteQuotationPDF(&buf, q, cs, issuer, nil))
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "%PDF-1.4"))
+	assert.Contains(t, out, "/Count 1")
+}
+
+func TestFormatMoney(t *testing.T) {
+	assert.Equal(t, "0", formatMoney(0))
+	assert.Equal(t, "1,234,567", formatMoney(1234567))
+	assert.Equal(t, "999.50", formatMoney(999.5))
+	assert.Equal(t, "-12,000", formatMoney(-12000))
+}
+
+func TestPDFHex(t *testing.T) {
+	assert.Equal(t, "00414F60", pdfHex("A你"))
+	assert.Equal(t, "003F", pdfHex("😀"))
+}
+
+func TestWrapText(t *testing.T) {
+	lines := wrapText("hello world foo", 10, 50) // 每行最多 10 個半形字
+	assert.Equal(t, []string{"hello", "world foo"}, lines)
+
+	lines = wrapText("一二三四五六", 10, 30) // 每行 3 個全形字
+	assert.Equal(t, []string{"一二三", "四五六"}, lines)
+
+	assert.Nil(t, wrapText("   ", 10, 30))
+}
+
+func TestFileSlug(t *testing.T) {
+	assert.Equal(t, "a_b_c", FileSlug("a/b: c"))
+	assert.Equal(t, "no-subject", FileSlug("  "))
+	assert.Equal(t, "品牌_合作", FileSlug("品牌 合作"))
+}
@@ -0,0 +1,113 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+)
+
+// BuildMessage 由資料庫中的郵件重建 RFC 5322 訊息
+// 原始附件未儲存時，以 X-Influenter-Attachments header 註記
+func BuildMessage(e *models.Email) []byte {
+	var buf bytes.Buffer
+
+	writeHeader(&buf, "From", formatAddress(deref(e.FromName), e.FromEmail))
+	if to := deref(e.ToEmail); to != "" {
+		writeHeader(&buf, "To", to)
+	}
+	writeHeader(&buf, "Subject", encodeHeader(deref(e.Subject)))
+	writeHeader(&buf, "Date", e.ReceivedAt.Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", "<"+messageID(e)+">")
+	writeHeader(&buf, "X-Influenter-Email-ID", e.ID.String())
+	writeHeader(&buf, "X-Influenter-Direction", e.Direction)
+	if e.HasAttachments {
+		writeHeader(&buf, "X-Influenter-Attachments", "omitted (not stored)")
+	}
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	text := deref(e.BodyText)
+	html := deref(e.BodyHTML)
+	if text == "" && html == "" {
+		text = deref(e.Snippet)
+	}
+
+	switch {
+	case text != "" && html != "":
+		boundary := "influenter-" + strings.ReplaceAll(e.ID.String(), "-", "")
+		writeHeader(&buf, "Content-Type", `multipart/alternative; boundary="`+boundary+`"`)
+		buf.WriteString("\r\n")
+		buf.WriteString("--" + boundary + "\r\n")
+		writeTextPart(&buf, "text/plain", text)
+		buf.WriteString("--" + boundary + "\r\n")
+		writeTextPart(&buf, "text/html", html)
+		buf.WriteString("--" + boundary + "--\r\n")
+	case html != "":
+		writeTextPart(&buf, "text/html", html)
+	default:
+		writeTextPart(&buf, "text/plain", text)
+	}
+
+	return buf.Bytes()
+}
+
+// messageID 優先使用原始 Message-ID，否則以郵件 ID 產生
+func messageID(e *models.Email) string {
+	if e.RFCMessageID != nil && *e.RFCMessageID != "" {
+		return *e.RFCMessageID
+	}
+	return e.ID.String() + "@influenter.local"
+}
+
+// writeTextPart 寫入 UTF-8 quoted-printable 文字段落（含 header）
+func writeTextPart(buf *bytes.Buffer, contentType, body string) {
+	writeHeader(buf, "Content-Type", contentType+"; charset=UTF-8")
+	writeHeader(buf, "Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+	qp := quotedprintable.NewWriter(buf)
+	_, _ = qp.Write([]byte(normalizeNewlines(body)))
+	_ = qp.Close()
+	buf.WriteString("\r\n")
+}
+
+// writeHeader 寫入單一 header（移除值中的換行避免 header injection）
+func writeHeader(buf *bytes.Buffer, name, value string) {
+	value = strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+	fmt.Fprintf(buf, "%s: %s\r\n", name, value)
+}
+
+// encodeHeader 非 ASCII 時以 RFC 2047 編碼
+func encodeHeader(s string) string {
+	for _, r := range s {
+		if r > 127 {
+			return mime.BEncoding.Encode("UTF-8", s)
+		}
+	}
+	return s
+}
+
+// formatAddress 格式化寄件者（名稱含非 ASCII 時自動編碼）
+func formatAddress(name, addr string) string {
+	if name == "" {
+		return addr
+	}
+	return (&mail.Address{Name: name, Address: addr}).String()
+}
+
+// normalizeNewlines 統一為 CRLF
+func normalizeNewlines(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
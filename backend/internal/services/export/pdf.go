@@ -0,0 +1,241 @@
+package export
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// A4 版面（單位：point）
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 50.0
+	pdfTextWidth  = pdfPageWidth - 2*pdfMargin
+)
+
+// pdfDocument 極簡 PDF 產生器：只支援文字與水平線，足以輸出往來紀錄
+// 使用 Adobe 標準 CJK 字型（MSung-Light, Adobe-CNS1）搭配 UniCNS-UCS2-H 編碼，不需嵌入字型即可顯示繁體中文
+type pdfDocument struct {
+	pages []*bytes.Buffer
+	cur   *bytes.Buffer
+	y     float64
+}
+
+func newPDFDocument() *pdfDocument {
+	d := &pdfDocument{}
+	d.newPage()
+	return d
+}
+
+// newPage 開新頁面
+func (d *pdfDocument) newPage() {
+	d.cur = &bytes.Buffer{}
+	d.pages = append(d.pages, d.cur)
+	d.y = pdfPageHeight - pdfMargin
+}
+
+// ensureSpace 剩餘空間不足時換頁
+func (d *pdfDocument) ensureSpace(h float64) {
+	if d.y-h < pdfMargin {
+		d.newPage()
+	}
+}
+
+// space 垂直留白
+func (d *pdfDocument) space(h float64) {
+	d.y -= h
+	if d.y < pdfMargin {
+		d.newPage()
+	}
+}
+
+// line 輸出單行文字（不換行）
+func (d *pdfDocument) line(text string, size, indent float64) {
+	leading := size * 1.4
+	d.ensureSpace(leading)
+	d.y -= leading
+	fmt.Fprintf(d.cur, "BT /F1 %.1f Tf %.2f %.2f Td <%s> Tj ET\n", size, pdfMargin+indent, d.y, pdfHex(text))
+}
+
+// paragraph 依版面寬度自動換行輸出文字
+func (d *pdfDocument) paragraph(text string, size, indent float64) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	for _, raw := range strings.Split(text, "\n") {
+		wrapped := wrapText(raw, size, pdfTextWidth-indent)
+		if len(wrapped) == 0 {
+			d.space(size * 1.4)
+			continue
+		}
+		for _, l := range wrapped {
+			d.line(l, size, indent)
+		}
+	}
+}
+
+// pdfColumn 表格欄位：x 為距左邊界的位置，right 為靠右對齊（用於金額）
+type pdfColumn struct {
+	x, width float64
+	right    bool
+}
+
+// row 輸出一列表格；各欄依欄寬換行，列高取最多行的欄位
+func (d *pdfDocument) row(size float64, cols []pdfColumn, texts ...string) {
+	leading := size * 1.4
+	cells := make([][]string, len(cols))
+	lines := 1
+	for i, col := range cols {
+		if i < len(texts) {
+			cells[i] = wrapText(texts[i], size, col.width)
+		}
+		if len(cells[i]) > lines {
+			lines = len(cells[i])
+		}
+	}
+
+	d.ensureSpace(leading * float64(lines))
+	top := d.y
+	for i, col := range cols {
+		for j, text := range cells[i] {
+			x := pdfMargin + col.x
+			if col.right {
+				x += col.width - textWidth(text, size)
+			}
+			fmt.Fprintf(d.cur, "BT /F1 %.1f Tf %.2f %.2f Td <%s> Tj ET\n", size, x, top-leading*float64(j+1), pdfHex(text))
+		}
+	}
+	d.y = top - leading*float64(lines)
+}
+
+// rule 水平分隔線
+func (d *pdfDocument) rule() {
+	d.ensureSpace(10)
+	d.y -= 5
+	fmt.Fprintf(d.cur, "0.6 G 0.5 w %.2f %.2f m %.2f %.2f l S 0 G\n", pdfMargin, d.y, pdfPageWidth-pdfMargin, d.y)
+	d.y -= 5
+}
+
+// WriteTo 輸出完整 PDF
+func (d *pdfDocument) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	var offsets []int
+
+	// 物件編號：1 Catalog、2 Pages、3 Type0 字型、4 CIDFont、5 FontDescriptor、之後每頁 2 個物件（Page、Contents）
+	obj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+i*2)
+	}
+
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj("<< /Type /Font /Subtype /Type0 /BaseFont /MSung-Light /Encoding /UniCNS-UCS2-H /DescendantFonts [4 0 R] >>")
+	obj("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /MSung-Light " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (CNS1) /Supplement 0 >> " +
+		"/DW 1000 /W [1 95 500] /FontDescriptor 5 0 R >>")
+	obj("<< /Type /FontDescriptor /FontName /MSung-Light /Flags 6 /FontBBox [0 -200 1000 900] " +
+		"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+
+	for i, page := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 7+i*2))
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return 0, err
+		}
+		if err := zw.Close(); err != nil {
+			return 0, err
+		}
+		obj(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	n, err := w.Write(out.Bytes())
+	return int64(n), err
+}
+
+// pdfHex 將文字編碼為 UCS-2 big-endian 十六進位字串（BMP 以外的字元以 ? 代替）
+func pdfHex(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r == '\t' {
+			r = ' '
+		}
+		if r > 0xFFFF || r < 0x20 || r == utf8.RuneError {
+			r = '?'
+		}
+		fmt.Fprintf(&sb, "%04X", r)
+	}
+	return sb.String()
+}
+
+// runeWidth 估算字元寬度（ASCII 半形，其餘全形）
+func runeWidth(r rune, size float64) float64 {
+	if r < 0x80 {
+		return size * 0.5
+	}
+	return size
+}
+
+// textWidth 估算文字寬度
+func textWidth(s string, size float64) float64 {
+	w := 0.0
+	for _, r := range s {
+		w += runeWidth(r, size)
+	}
+	return w
+}
+
+// wrapText 依寬度換行；英文盡量在空白處斷行，中文可在任意字元斷行
+func wrapText(s string, size, width float64) []string {
+	s = strings.TrimRight(s, " \t")
+	if s == "" {
+		return nil
+	}
+
+	var lines []string
+	runes := []rune(s)
+	start := 0
+	for start < len(runes) {
+		w := 0.0
+		end := start
+		lastSpace := -1
+		for end < len(runes) {
+			rw := runeWidth(runes[end], size)
+			if w+rw > width {
+				break
+			}
+			if runes[end] == ' ' {
+				lastSpace = end
+			}
+			w += rw
+			end++
+		}
+		if end < len(runes) && lastSpace > start && runes[end] < 0x80 && runes[end] != ' ' {
+			end = lastSpace + 1
+		}
+		if end == start {
+			end = start + 1
+		}
+		lines = append(lines, strings.TrimRight(string(runes[start:end]), " "))
+		start = end
+	}
+	return lines
+}
@@ -0,0 +1,213 @@
+package export
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+)
+
+//go:embed templates/quotation.html
+var templateFS embed.FS
+
+var quotationTemplate = template.Must(template.ParseFS(templateFS, "templates/quotation.html"))
+
+// quotationLine 報價明細的顯示文字
+type quotationLine struct {
+	Title       string
+	Description string
+	Quantity    string
+	UnitPrice   string
+	Discount    string
+	Amount      string
+}
+
+// quotationView 報價單的顯示文字（HTML 與 PDF 共用）
+type quotationView struct {
+	Number       string
+	Version      int
+	IssuedAt     string
+	ValidUntil   string
+	CaseTitle    string
+	BrandName    string
+	ContactName  string
+	ContactEmail string
+	IssuerName   string
+	IssuerEmail  string
+	Currency     string
+	Lines        []quotationLine
+	Subtotal     string
+	Discount     string
+	TaxRate      string
+	TaxAmount    string
+	Total        string
+	Terms        string
+	Notes        string
+}
+
+// newQuotationView 整理報價單顯示內容；issuer 為報價人（創作者）
+func newQuotationView(q *models.Quotation, cs *models.Case, issuer *models.User, loc *time.Location) (*quotationView, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	lines, err := q.LineItems()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode quotation lines: %w", err)
+	}
+
+	v := &quotationView{
+		Number:    q.Number,
+		Version:   q.Version,
+		IssuedAt:  q.CreatedAt.In(loc).Format("2006-01-02"),
+		Currency:  q.Currency,
+		Subtotal:  formatMoney(q.Subtotal),
+		TaxRate:   formatNumber(q.TaxRate) + "%",
+		Total:     formatMoney(q.Total),
+		Terms:     strings.TrimSpace(deref(q.Terms)),
+		Notes:     strings.TrimSpace(deref(q.Notes)),
+		CaseTitle: cs.Title,
+		BrandName: cs.BrandName,
+	}
+	if q.ValidUntil != nil {
+		v.ValidUntil = q.ValidUntil.Format("2006-01-02")
+	}
+	if q.Discount > 0 {
+		v.Discount = formatMoney(q.Discount)
+	}
+	if q.TaxAmount > 0 {
+		v.TaxAmount = formatMoney(q.TaxAmount)
+	}
+	v.ContactName = deref(cs.ContactName)
+	v.ContactEmail = deref(cs.ContactEmail)
+	if issuer != nil {
+		v.IssuerName, v.IssuerEmail = issuer.Name, issuer.Email
+	}
+
+	for _, line := range lines {
+		l := quotationLine{
+			Title:       line.Title,
+			Description: line.Description,
+			Quantity:    formatNumber(line.Quantity),
+			UnitPrice:   formatMoney(line.UnitPrice),
+			Amount:      formatMoney(line.Amount),
+		}
+		if line.DiscountPercent > 0 {
+			l.Discount = formatNumber(line.DiscountPercent) + "%"
+		}
+		v.Lines = append(v.Lines, l)
+	}
+	return v, nil
+}
+
+// WriteQuotationHTML 輸出報價單 HTML（可直接於瀏覽器列印）
+func WriteQuotationHTML(w io.Writer, q *models.Quotation, cs *models.Case, issuer *models.User, loc *time.Location) error {
+	v, err := newQuotationView(q, cs, issuer, loc)
+	if err != nil {
+		return err
+	}
+	return quotationTemplate.Execute(w, v)
+}
+
+// WriteQuotationPDF 輸出報價單 PDF
+func WriteQuotationPDF(w io.Writer, q *models.Quotation, cs *models.Case, issuer *models.User, loc *time.Location) error {
+	v, err := newQuotationView(q, cs, issuer, loc)
+	if err != nil {
+		return err
+	}
+
+	doc := newPDFDocument()
+	doc.line("報價單", 22, 0)
+	doc.space(4)
+	doc.line(fmt.Sprintf("單號：%s（第 %d 版）", v.Number, v.Version), 10, 0)
+	doc.line("日期："+v.IssuedAt, 10, 0)
+	if v.ValidUntil != "" {
+		doc.line("有效期限："+v.ValidUntil, 10, 0)
+	}
+	doc.space(10)
+
+	field := func(label, value string) {
+		if strings.TrimSpace(value) != "" {
+			doc.paragraph(label+"："+value, 10, 0)
+		}
+	}
+	field("報價對象", v.BrandName)
+	field("聯絡人", strings.TrimSpace(v.ContactName+" "+v.ContactEmail))
+	field("報價人", strings.TrimSpace(v.IssuerName+" "+v.IssuerEmail))
+	field("案件", v.CaseTitle)
+	doc.space(10)
+
+	// 項目 | 數量 | 單價 | 折扣 | 金額
+	cols := []pdfColumn{
+		{x: 0, width: 215},
+		{x: 220, width: 40, right: true},
+		{x: 265, width: 80, right: true},
+		{x: 350, width: 50, right: true},
+		{x: 405, width: pdfTextWidth - 405, right: true},
+	}
+	doc.row(10, cols, "項目", "數量", "單價", "折扣", "金額")
+	doc.rule()
+	for _, line := range v.Lines {
+		doc.row(10, cols, line.Title, line.Quantity, line.UnitPrice, line.Discount, line.Amount)
+		if line.Description != "" {
+			doc.row(8, cols[:1], line.Description)
+		}
+		doc.space(4)
+	}
+	doc.rule()
+
+	totals := []pdfColumn{{x: 265, width: 135}, {x: 405, width: pdfTextWidth - 405, right: true}}
+	doc.row(10, totals, "小計", v.Subtotal)
+	if v.Discount != "" {
+		doc.row(10, totals, "折扣", "-"+v.Discount)
+	}
+	if v.TaxAmount != "" {
+		doc.row(10, totals, "稅額（"+v.TaxRate+"）", v.TaxAmount)
+	}
+	doc.row(12, totals, "總計（"+v.Currency+"）", v.Total)
+
+	if v.Terms != "" {
+		doc.space(14)
+		doc.line("條款", 12, 0)
+		doc.paragraph(v.Terms, 10, 0)
+	}
+	if v.Notes != "" {
+		doc.space(14)
+		doc.line("備註", 12, 0)
+		doc.paragraph(v.Notes, 10, 0)
+	}
+
+	_, err = doc.WriteTo(w)
+	return err
+}
+
+// formatMoney 金額加上千分位；有小數時保留兩位
+func formatMoney(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimSuffix(s, ".00")
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], s[i:]
+	}
+	sign := ""
+	if strings.HasPrefix(intPart, "-") {
+		sign, intPart = "-", intPart[1:]
+	}
+	var b strings.Builder
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	return sign + b.String() + frac
+}
+
+// formatNumber 數量或百分比（去除多餘的小數位）
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,136 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/gmail"
+)
+
+// caseStatusLabels 案件狀態顯示名稱（與 /cases/fields 一致）
+var caseStatusLabels = map[models.CaseStatus]string{
+	models.CaseStatusToConfirm:  "待確認",
+	models.CaseStatusInProgress: "進行中",
+	models.CaseStatusCompleted:  "已完成",
+	models.CaseStatusCancelled:  "已取消",
+	models.CaseStatusOther:      "非合作案件",
+}
+
+// WriteCasePDF 輸出案件往來紀錄 PDF：封面為案件資訊，之後依時間排列每封郵件全文
+// emails 需已依 received_at 由舊到新排序
+func WriteCasePDF(w io.Writer, cs *models.Case, emails []models.Email, loc *time.Location) error {
+	if loc == nil {
+		loc = time.UTC
+	}
+	const timeLayout = "2006-01-02 15:04 MST"
+
+	doc := newPDFDocument()
+
+	// 封面
+	doc.space(40)
+	doc.line("案件往來紀錄", 22, 0)
+	doc.space(6)
+	doc.paragraph(cs.Title, 16, 0)
+	doc.space(12)
+	doc.rule()
+
+	field := func(label, value string) {
+		if strings.TrimSpace(value) == "" {
+			return
+		}
+		doc.paragraph(label+"："+value, 11, 0)
+	}
+	field("品牌", cs.BrandName)
+	field("狀態", statusLabel(cs.Status))
+	field("合作類型", deref(cs.CollaborationType))
+	field("報價金額", formatAmount(cs.QuotedAmount, cs.Currency))
+	field("成交金額", formatAmount(cs.FinalAmount, cs.Currency))
+	if cs.DeadlineDate != nil {
+		field("截止日期", cs.DeadlineDate.Format("2006-01-02"))
+	}
+	field("聯絡人", deref(cs.ContactName))
+	field("聯絡信箱", deref(cs.ContactEmail))
+	field("聯絡電話", deref(cs.ContactPhone))
+	if len(cs.Tags) > 0 {
+		field("標籤", strings.Join(cs.Tags, "、"))
+	}
+	field("建立時間", cs.CreatedAt.In(loc).Format(timeLayout))
+	field("郵件數", fmt.Sprintf("%d", len(emails)))
+	if len(emails) > 0 {
+		field("往來期間", emails[0].ReceivedAt.In(loc).Format(timeLayout)+" ~ "+emails[len(emails)-1].ReceivedAt.In(loc).Format(timeLayout))
+	}
+	field("匯出時間", time.Now().In(loc).Format(timeLayout))
+
+	if desc := strings.TrimSpace(deref(cs.Description)); desc != "" {
+		doc.space(10)
+		doc.line("說明", 12, 0)
+		doc.paragraph(desc, 10, 0)
+	}
+	if notes := strings.TrimSpace(deref(cs.Notes)); notes != "" {
+		doc.space(10)
+		doc.line("備註", 12, 0)
+		doc.paragraph(notes, 10, 0)
+	}
+
+	// 郵件內文
+	for i := range emails {
+		e := &emails[i]
+		if i == 0 {
+			doc.newPage()
+		} else {
+			doc.space(12)
+		}
+
+		direction := "收到"
+		if e.Direction == models.EmailDirectionOutgoing {
+			direction = "寄出"
+		}
+		doc.ensureSpace(80)
+		doc.line(fmt.Sprintf("#%d  %s  %s", i+1, e.ReceivedAt.In(loc).Format(timeLayout), direction), 12, 0)
+		doc.paragraph("寄件者："+formatAddress(deref(e.FromName), e.FromEmail), 10, 0)
+		if to := deref(e.ToEmail); to != "" {
+			doc.paragraph("收件者："+to, 10, 0)
+		}
+		doc.paragraph("主旨："+deref(e.Subject), 10, 0)
+		if e.HasAttachments {
+			doc.paragraph("（原信含附件，未包含於本紀錄）", 9, 0)
+		}
+		doc.rule()
+		doc.paragraph(transcriptBody(e), 10, 0)
+	}
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// transcriptBody 取得可列印的內文（優先純文字，否則由 HTML 轉換）
+func transcriptBody(e *models.Email) string {
+	if body := strings.TrimSpace(deref(e.BodyText)); body != "" {
+		return body
+	}
+	if html := deref(e.BodyHTML); html != "" {
+		return strings.TrimSpace(gmail.ExtractPlainText(html))
+	}
+	return deref(e.Snippet)
+}
+
+func statusLabel(s models.CaseStatus) string {
+	if label, ok := caseStatusLabels[s]; ok {
+		return label
+	}
+	return string(s)
+}
+
+func formatAmount(amount *float64, currency *string) string {
+	if amount == nil {
+		return ""
+	}
+	str := fmt.Sprintf("%.2f", *amount)
+	if cur := deref(currency); cur != "" {
+		str += " " + cur
+	}
+	return str
+}
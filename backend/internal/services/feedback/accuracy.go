@@ -0,0 +1,120 @@
+package feedback
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Stats 回饋中 AI 結果正確的比例
+type Stats struct {
+	Total    int64   `json:"total"`
+	Correct  int64   `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+func (s *Stats) add(correct bool) {
+	s.Total++
+	if correct {
+		s.Correct++
+	}
+	s.Accuracy = float64(s.Correct) / float64(s.Total)
+}
+
+// Bucket 單一期間（週或月）的準確率
+type Bucket struct {
+	Period  string           `json:"period"` // 週：2006-W01（ISO 週）；月：2006-01
+	Stats                    // 該期間合計
+	ByField map[string]Stats `json:"by_field"`
+}
+
+// AccuracyReport 使用者回饋的準確率統計（依分析時間分期，可看出範本更新前後的變化）
+type AccuracyReport struct {
+	From            time.Time        `json:"from"`
+	To              time.Time        `json:"to"`
+	Totals          Stats            `json:"totals"`
+	Classification  Stats            `json:"classification"` // 分類
+	Extraction      Stats            `json:"extraction"`     // 擷取欄位合計
+	ByField         map[string]Stats `json:"by_field"`
+	ByPromptVersion map[string]Stats `json:"by_prompt_version"`
+	Weekly          []Bucket         `json:"weekly"`
+	Monthly         []Bucket         `json:"monthly"`
+}
+
+// accuracyRow 統計用的回饋欄位
+type accuracyRow struct {
+	Field         string
+	Correct       bool
+	PromptVersion string
+	AnalyzedAt    time.Time
+}
+
+// Accuracy 統計使用者對 [from, to) 期間分析結果的回饋
+func Accuracy(db *gorm.DB, userID uuid.UUID, from, to time.Time) (*AccuracyReport, error) {
+	var rows []accuracyRow
+	err := db.Model(&models.AIFeedback{}).
+		Select("field, correct, prompt_version, analyzed_at").
+		Where("user_id = ? AND analyzed_at >= ? AND analyzed_at < ?", userID, from, to).
+		Order("analyzed_at ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ai feedback: %w", err)
+	}
+
+	report := &AccuracyReport{
+		From: from, To: to,
+		ByField: map[string]Stats{}, ByPromptVersion: map[string]Stats{},
+		Weekly: []Bucket{}, Monthly: []Bucket{},
+	}
+	weekly := map[string]*Bucket{}
+	monthly := map[string]*Bucket{}
+	for _, row := range rows {
+		report.Totals.add(row.Correct)
+		if row.Field == FieldCategory {
+			report.Classification.add(row.Correct)
+		} else {
+			report.Extraction.add(row.Correct)
+		}
+		addTo(report.ByField, row.Field, row.Correct)
+		if row.PromptVersion != "" {
+			addTo(report.ByPromptVersion, row.PromptVersion, row.Correct)
+		}
+
+		at := row.AnalyzedAt.UTC()
+		year, week := at.ISOWeek()
+		addBucket(weekly, fmt.Sprintf("%d-W%02d", year, week), row.Field, row.Correct)
+		addBucket(monthly, at.Format("2006-01"), row.Field, row.Correct)
+	}
+	report.Weekly = sortedBuckets(weekly)
+	report.Monthly = sortedBuckets(monthly)
+	return report, nil
+}
+
+func addTo(m map[string]Stats, key string, correct bool) {
+	cur := m[key]
+	cur.add(correct)
+	m[key] = cur
+}
+
+func addBucket(buckets map[string]*Bucket, period, field string, correct bool) {
+	b, ok := buckets[period]
+	if !ok {
+		b = &Bucket{Period: period, ByField: map[string]Stats{}}
+		buckets[period] = b
+	}
+	b.Stats.add(correct)
+	addTo(b.ByField, field, correct)
+}
+
+func sortedBuckets(buckets map[string]*Bucket) []Bucket {
+	result := make([]Bucket, 0, len(buckets))
+	for _, b := range buckets {
+		result = append(result, *b)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Period < result[j].Period })
+	return result
+}
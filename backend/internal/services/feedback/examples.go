@@ -0,0 +1,86 @@
+package feedback
+
+import (
+	"fmt"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+)
+
+// exampleScanRows 查詢最近修正時最多讀取的筆數（一封郵件可能有多個欄位修正）
+const exampleScanRows = 100
+
+// FeedbackExamples 使用者最近修正過的分析（實作 openai.FeedbackSource）：
+// 分類範本取分類修正，擷取範本取擷取欄位修正，依郵件彙整後取最新的 limit 封
+func (s *Service) FeedbackExamples(userID string, name openai.PromptName, limit int) ([]openai.FeedbackExample, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil || limit <= 0 {
+		return nil, nil
+	}
+
+	q := s.db.Where("user_id = ? AND correct = ?", uid, false)
+	switch name {
+	case openai.PromptClassify:
+		q = q.Where("field = ?", FieldCategory)
+	case openai.PromptExtract:
+		q = q.Where("field <> ?", FieldCategory)
+	default:
+		return nil, nil
+	}
+
+	var rows []models.AIFeedback
+	if err := q.Order("updated_at DESC").Limit(exampleScanRows).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load ai feedback: %w", err)
+	}
+
+	// 依郵件彙整，保留最新修正的順序
+	var emailIDs []uuid.UUID
+	byEmail := map[uuid.UUID][]models.AIFeedback{}
+	for _, row := range rows {
+		if _, ok := byEmail[row.EmailID]; !ok {
+			if len(emailIDs) == limit {
+				continue
+			}
+			emailIDs = append(emailIDs, row.EmailID)
+		}
+		byEmail[row.EmailID] = append(byEmail[row.EmailID], row)
+	}
+	if len(emailIDs) == 0 {
+		return nil, nil
+	}
+
+	var emails []models.Email
+	if err := s.db.Select("id", "subject", "from_email", "snippet").Where("id IN ?", emailIDs).Find(&emails).Error; err != nil {
+		return nil, fmt.Errorf("failed to load feedback emails: %w", err)
+	}
+	emailByID := make(map[uuid.UUID]*models.Email, len(emails))
+	for i := range emails {
+		emailByID[emails[i].ID] = &emails[i]
+	}
+
+	examples := make([]openai.FeedbackExample, 0, len(emailIDs))
+	for _, id := range emailIDs {
+		email, ok := emailByID[id]
+		if !ok {
+			continue
+		}
+		ex := openai.FeedbackExample{From: email.FromEmail}
+		if email.Subject != nil {
+			ex.Subject = *email.Subject
+		}
+		if email.Snippet != nil {
+			ex.Snippet = *email.Snippet
+		}
+		for _, row := range byEmail[id] {
+			if row.Field == FieldCategory {
+				ex.Predicted = openai.EmailCategory(row.Predicted)
+				ex.Category = openai.EmailCategory(row.Corrected)
+				continue
+			}
+			ex.Corrections = append(ex.Corrections, openai.FieldCorrection{Field: row.Field, Predicted: row.Predicted, Corrected: row.Corrected})
+		}
+		examples = append(examples, ex)
+	}
+	return examples, nil
+}
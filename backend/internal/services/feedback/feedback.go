@@ -0,0 +1,285 @@
+package feedback
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/analysis"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FieldCategory 分類結果的回饋欄位；其餘欄位為 ExtractedInfo 的擷取欄位
+const FieldCategory = "category"
+
+// ExtractionFields 可回饋的擷取欄位（與 ExtractedInfo 的 JSON 名稱相同）
+var ExtractionFields = []string{
+	"brand_name", "contact_name", "contact_email", "contact_phone", "amount", "currency", "due_date", "content_type",
+}
+
+// 回饋錯誤（呼叫端以 errors.Is 判斷）
+var (
+	ErrUnknownField    = errors.New("unknown feedback field")
+	ErrInvalidCategory = errors.New("invalid category")
+	ErrInvalidValue    = errors.New("invalid feedback value")
+)
+
+// Service 記錄使用者對 AI 分析的修正，並提供 few-shot 範例（實作 openai.FeedbackSource）
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService 建立回饋服務
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// IsField 是否為可回饋的欄位
+func IsField(field string) bool {
+	if field == FieldCategory {
+		return true
+	}
+	for _, f := range ExtractionFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Predictions 分析結果中各回饋欄位的值（皆轉為字串，未擷取為空字串）
+func Predictions(a *models.AIAnalysis) (map[string]string, error) {
+	info, err := analysis.ExtractedInfo(a)
+	if err != nil {
+		return nil, err
+	}
+	p := map[string]string{
+		FieldCategory:   a.Category,
+		"brand_name":    info.BrandName,
+		"contact_name":  info.ContactName,
+		"contact_email": info.ContactEmail,
+		"contact_phone": info.ContactPhone,
+		"amount":        formatAmount(info.Amount),
+		"currency":      info.Currency,
+		"due_date":      formatDate(info.DueDate),
+		"content_type":  info.ContentType,
+	}
+	return p, nil
+}
+
+// Record 記錄使用者確認的值（corrected 為欄位 → 正確值）；與 AI 結果相同者記為正確。
+// 同一分析同一欄位重複回饋時以最新一筆為準
+func (s *Service) Record(a *models.AIAnalysis, source models.AIFeedbackSource, corrected map[string]string) ([]models.AIFeedback, error) {
+	predicted, err := Predictions(a)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]models.AIFeedback, 0, len(corrected))
+	for _, field := range sortedFields(corrected) {
+		value, err := normalize(field, corrected[field])
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, models.AIFeedback{
+			UserID:        a.UserID,
+			EmailID:       a.EmailID,
+			AnalysisID:    a.ID,
+			Source:        source,
+			Field:         field,
+			Predicted:     predicted[field],
+			Corrected:     value,
+			Correct:       same(field, predicted[field], value),
+			PromptVersion: a.PromptVersion,
+			AnalyzedAt:    a.AnalyzedAt,
+		})
+	}
+	if len(rows) == 0 {
+		return rows, nil
+	}
+
+	err = s.db.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "analysis_id"}, {Name: "field"}},
+		DoUpdates: clause.AssignmentColumns([]string{"source", "corrected", "correct", "updated_at"}),
+	}).Create(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to record ai feedback: %w", err)
+	}
+	return rows, nil
+}
+
+// RecordCaseEdit 使用者修改 AI 建立的案件後，將修改過的欄位記為對原始分析的修正；
+// 案件不是由 AI 分析建立時不記錄
+func (s *Service) RecordCaseEdit(before, after *models.Case) ([]models.AIFeedback, error) {
+	a, err := s.originatingAnalysis(after.ID)
+	if err != nil || a == nil {
+		return nil, err
+	}
+	corrected := caseCorrections(a, before, after)
+	if len(corrected) == 0 {
+		return nil, nil
+	}
+	return s.Record(a, models.AIFeedbackSourceCaseEdit, corrected)
+}
+
+// originatingAnalysis 建立案件的郵件（最早一封有分析結果的郵件）的最新分析
+func (s *Service) originatingAnalysis(caseID uuid.UUID) (*models.AIAnalysis, error) {
+	var email models.Email
+	err := s.db.Select("id", "ai_analysis_id").
+		Where("case_id = ? AND ai_analysis_id IS NOT NULL", caseID).
+		Order("received_at ASC").First(&email).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find case email: %w", err)
+	}
+
+	var a models.AIAnalysis
+	if err := s.db.First(&a, "id = ?", *email.AIAnalysisID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load analysis: %w", err)
+	}
+	return &a, nil
+}
+
+// caseCorrections 比對修改前後的案件，只取這次有修改的欄位
+func caseCorrections(a *models.AIAnalysis, before, after *models.Case) map[string]string {
+	corrected := map[string]string{}
+
+	// 非合作（other）與合作案件之間切換即代表分類錯誤
+	wasCollab, isCollab := before.Status != models.CaseStatusOther, after.Status != models.CaseStatusOther
+	predictedCollab := openai.IsCollaborationRelated(openai.EmailCategory(a.Category))
+	if wasCollab != isCollab && isCollab != predictedCollab {
+		if isCollab {
+			corrected[FieldCategory] = string(openai.CategoryCollaboration)
+		} else {
+			corrected[FieldCategory] = string(openai.CategoryOther)
+		}
+	}
+
+	diff := func(field, was, now string) {
+		if was != now {
+			corrected[field] = now
+		}
+	}
+	diff("brand_name", brandName(before.BrandName), brandName(after.BrandName))
+	diff("contact_name", str(before.ContactName), str(after.ContactName))
+	diff("contact_email", str(before.ContactEmail), str(after.ContactEmail))
+	diff("contact_phone", str(before.ContactPhone), str(after.ContactPhone))
+	diff("amount", formatAmount(before.QuotedAmount), formatAmount(after.QuotedAmount))
+	diff("currency", str(before.Currency), str(after.Currency))
+	diff("due_date", formatDate(before.DeadlineDate), formatDate(after.DeadlineDate))
+	diff("content_type", str(before.CollaborationType), str(after.CollaborationType))
+	return corrected
+}
+
+// brandName 建立案件時的品牌佔位符視為未擷取
+func brandName(name string) string {
+	if name == "未知品牌" || name == "—" {
+		return ""
+	}
+	return name
+}
+
+// normalize 驗證並正規化使用者提供的值
+func normalize(field, value string) (string, error) {
+	if !IsField(field) {
+		return "", fmt.Errorf("%w: %s", ErrUnknownField, field)
+	}
+	value = strings.TrimSpace(value)
+	switch field {
+	case FieldCategory:
+		if !isCategory(value) {
+			return "", fmt.Errorf("%w: %q", ErrInvalidCategory, value)
+		}
+	case "amount":
+		if value == "" {
+			return "", nil
+		}
+		f, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+		if err != nil {
+			return "", fmt.Errorf("%w: amount %q", ErrInvalidValue, value)
+		}
+		return formatAmount(&f), nil
+	case "due_date":
+		if value == "" {
+			return "", nil
+		}
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return "", fmt.Errorf("%w: due_date %q (want YYYY-MM-DD)", ErrInvalidValue, value)
+		}
+	case "currency":
+		return strings.ToUpper(value), nil
+	}
+	return value, nil
+}
+
+// same AI 結果與使用者確認的值是否相同（文字欄位忽略大小寫與空白差異）
+func same(field, predicted, corrected string) bool {
+	if field == "contact_phone" {
+		return digits(predicted) == digits(corrected)
+	}
+	return strings.EqualFold(strings.Join(strings.Fields(predicted), " "), strings.Join(strings.Fields(corrected), " "))
+}
+
+func isCategory(value string) bool {
+	switch openai.EmailCategory(value) {
+	case openai.CategoryCollaboration, openai.CategoryPayment, openai.CategoryConfirmation, openai.CategoryInquiry,
+		openai.CategorySocial, openai.CategoryNewsletter, openai.CategoryNotification, openai.CategorySpam, openai.CategoryOther:
+		return true
+	}
+	return false
+}
+
+func digits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func formatAmount(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func str(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return strings.TrimSpace(*p)
+}
+
+// sortedFields 依 category 在前、擷取欄位固定順序排列（讓寫入順序穩定）
+func sortedFields(m map[string]string) []string {
+	var fields []string
+	for _, f := range append([]string{FieldCategory}, ExtractionFields...) {
+		if _, ok := m[f]; ok {
+			fields = append(fields, f)
+		}
+	}
+	for f := range m {
+		if !IsField(f) {
+			fields = append(fields, f) // 交給 normalize 回報未知欄位
+		}
+	}
+	return fields
+}
@@ -0,0 +1,206 @@
+package feedback
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 設置測試用的資料庫（使用 SQLite）
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Skipf("Skipping test: SQLite not available (CGO required): %v", err)
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.OAuthAccount{}, &models.Email{}, &models.AIAnalysis{},
+		&models.Case{}, &models.AIFeedback{})
+	require.NoError(t, err)
+	return db
+}
+
+type fixture struct {
+	db      *gorm.DB
+	svc     *Service
+	user    *models.User
+	account *models.OAuthAccount
+}
+
+func newFixture(t *testing.T) *fixture {
+	db := setupTestDB(t)
+	user := &models.User{ID: uuid.New(), Email: "creator@example.com", Name: "Creator"}
+	require.NoError(t, db.Create(user).Error)
+	account := &models.OAuthAccount{
+		ID: uuid.New(), UserID: user.ID, Provider: models.OAuthProviderGoogle, Email: "creator@example.com",
+		AccessToken: "a", RefreshToken: "r", TokenExpiry: time.Now().Add(time.Hour),
+	}
+	require.NoError(t, db.Create(account).Error)
+	return &fixture{db: db, svc: NewService(db), user: user, account: account}
+}
+
+// analyzedEmail 建立已分析的郵件（可關聯案件）
+func (f *fixture) analyzedEmail(t *testing.T, subject, category string, info openai.ExtractedInfo, at time.Time, caseID *uuid.UUID) *models.AIAnalysis {
+	snippet := subject + " 的內容"
+	email := &models.Email{OAuthAccountID: f.account.ID, ProviderMessageID: uuid.NewString(), FromEmail: "pm@brand.example",
+		Subject: &subject, Snippet: &snippet, Direction: models.EmailDirectionIncoming, ReceivedAt: at, CaseID: caseID}
+	require.NoError(t, f.db.Create(email).Error)
+	raw, _ := json.Marshal(info)
+	a := &models.AIAnalysis{EmailID: email.ID, UserID: f.user.ID, Version: 1, Model: "m", PromptVersion: "classify@v2+extract@v3",
+		Category: category, ExtractedInfo: raw, AnalyzedAt: at}
+	require.NoError(t, f.db.Create(a).Error)
+	require.NoError(t, f.db.Model(email).Update("ai_analysis_id", a.ID).Error)
+	return a
+}
+
+func TestRecord(t *testing.T) {
+	f := newFixture(t)
+	amount := 30000.0
+	a := f.analyzedEmail(t, "合作邀約", "collaboration", openai.ExtractedInfo{BrandName: "Glow", Amount: &amount, ContactPhone: "0912-345-678"}, time.Now(), nil)
+
+	rows, err := f.svc.Record(a, models.AIFeedbackSourceManual, map[string]string{
+		"brand_name": "glow", "amount": "35,000", "contact_phone": "0912345678", "category": "collaboration",
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	got := map[string]models.AIFeedback{}
+	for _, r := range rows {
+		got[r.Field] = r
+	}
+	assert.True(t, got["category"].Correct)
+	assert.True(t, got["brand_name"].Correct)
+	assert.True(t, got["contact_phone"].Correct)
+	assert.False(t, got["amount"].Correct)
+	assert.Equal(t, "30000", got["amount"].Predicted)
+	assert.Equal(t, "35000", got["amount"].Corrected)
+
+	// 同一欄位再次回饋時覆寫
+	_, err = f.svc.Record(a, models.AIFeedbackSourceManual, map[string]string{"amount": "30000"})
+	require.NoError(t, err)
+	var stored []models.AIFeedback
+	require.NoError(t, f.db.Where("field = ?", "amount").Find(&stored).Error)
+	require.Len(t, stored, 1)
+	assert.True(t, stored[0].Correct)
+
+	_, err = f.svc.Record(a, models.AIFeedbackSourceManual, map[string]string{"price": "1"})
+	assert.ErrorIs(t, err, ErrUnknownField)
+	_, err = f.svc.Record(a, models.AIFeedbackSourceManual, map[string]string{"category": "ads"})
+	assert.ErrorIs(t, err, ErrInvalidCategory)
+	_, err = f.svc.Record(a, models.AIFeedbackSourceManual, map[string]string{"due_date": "10/15"})
+	assert.ErrorIs(t, err, ErrInvalidValue)
+}
+
+func TestRecordCaseEdit(t *testing.T) {
+	f := newFixture(t)
+	before := models.Case{UserID: f.user.ID, Title: "新品體驗", BrandName: "—", Status: models.CaseStatusOther}
+	require.NoError(t, f.db.Create(&before).Error)
+	a := f.analyzedEmail(t, "新品體驗", "newsletter", openai.ExtractedInfo{BrandName: "Glow Ltd"}, time.Now(), &before.ID)
+
+	// 非合作 → 合作、填入品牌與金額；未修改的欄位不記錄
+	after := before
+	after.Status = models.CaseStatusToConfirm
+	after.BrandName = "Glow 台灣"
+	amount := 30000.0
+	after.QuotedAmount = &amount
+	rows, err := f.svc.RecordCaseEdit(&before, &after)
+	require.NoError(t, err)
+	fields := map[string]models.AIFeedback{}
+	for _, r := range rows {
+		fields[r.Field] = r
+		assert.Equal(t, a.ID, r.AnalysisID)
+		assert.Equal(t, models.AIFeedbackSourceCaseEdit, r.Source)
+	}
+	assert.Len(t, fields, 3)
+	assert.Equal(t, "collaboration", fields["category"].Corrected)
+	assert.Equal(t, "newsletter", fields["category"].Predicted)
+	assert.Equal(t, "Glow 台灣", fields["brand_name"].Corrected)
+	assert.Equal(t, "30000", fields["amount"].Corrected)
+
+	// 修改備註等非擷取欄位不記錄；沒有分析來源的案件不記錄
+	notes := "記得寄樣品"
+	edited := after
+	edited.Notes = &notes
+	rows, err = f.svc.RecordCaseEdit(&after, &edited)
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+
+	manual := models.Case{UserID: f.user.ID, Title: "手動案件", BrandName: "Acme", Status: models.CaseStatusToConfirm}
+	require.NoError(t, f.db.Create(&manual).Error)
+	changed := manual
+	changed.BrandName = "Acme 台灣"
+	rows, err = f.svc.RecordCaseEdit(&manual, &changed)
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+}
+
+func TestFeedbackExamples(t *testing.T) {
+	f := newFixture(t)
+	now := time.Now()
+	older := f.analyzedEmail(t, "舊邀約", "newsletter", openai.ExtractedInfo{BrandName: "Old"}, now.Add(-time.Hour), nil)
+	newer := f.analyzedEmail(t, "新邀約", "collaboration", openai.ExtractedInfo{BrandName: "Glow Ltd"}, now, nil)
+
+	_, err := f.svc.Record(older, models.AIFeedbackSourceManual, map[string]string{"category": "collaboration", "brand_name": "Old"})
+	require.NoError(t, err)
+	_, err = f.svc.Record(newer, models.AIFeedbackSourceManual, map[string]string{"brand_name": "Glow 台灣", "currency": "usd"})
+	require.NoError(t, err)
+
+	// 分類範例只含分類修正（正確的回饋不作為範例）
+	examples, err := f.svc.FeedbackExamples(f.user.ID.String(), openai.PromptClassify, 5)
+	require.NoError(t, err)
+	require.Len(t, examples, 1)
+	assert.Equal(t, "舊邀約", examples[0].Subject)
+	assert.Equal(t, openai.CategoryNewsletter, examples[0].Predicted)
+	assert.Equal(t, openai.CategoryCollaboration, examples[0].Category)
+	assert.Empty(t, examples[0].Corrections)
+
+	examples, err = f.svc.FeedbackExamples(f.user.ID.String(), openai.PromptExtract, 5)
+	require.NoError(t, err)
+	require.Len(t, examples, 1)
+	assert.Equal(t, "新邀約", examples[0].Subject)
+	assert.Equal(t, "新邀約 的內容", examples[0].Snippet)
+	assert.ElementsMatch(t, []openai.FieldCorrection{
+		{Field: "brand_name", Predicted: "Glow Ltd", Corrected: "Glow 台灣"},
+		{Field: "currency", Predicted: "", Corrected: "USD"},
+	}, examples[0].Corrections)
+
+	examples, err = f.svc.FeedbackExamples(uuid.NewString(), openai.PromptExtract, 5)
+	require.NoError(t, err)
+	assert.Empty(t, examples)
+}
+
+func TestAccuracy(t *testing.T) {
+	f := newFixture(t)
+	jan := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	feb := time.Date(2026, 2, 10, 10, 0, 0, 0, time.UTC)
+	a1 := f.analyzedEmail(t, "一月", "newsletter", openai.ExtractedInfo{BrandName: "Glow"}, jan, nil)
+	a2 := f.analyzedEmail(t, "二月", "collaboration", openai.ExtractedInfo{BrandName: "Acme"}, feb, nil)
+
+	_, err := f.svc.Record(a1, models.AIFeedbackSourceManual, map[string]string{"category": "collaboration", "brand_name": "Glow"})
+	require.NoError(t, err)
+	_, err = f.svc.Record(a2, models.AIFeedbackSourceManual, map[string]string{"category": "collaboration", "brand_name": "Acme"})
+	require.NoError(t, err)
+
+	report, err := Accuracy(f.db, f.user.ID, jan.AddDate(0, 0, -1), feb.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), report.Totals.Total)
+	assert.Equal(t, 0.75, report.Totals.Accuracy)
+	assert.Equal(t, 0.5, report.Classification.Accuracy)
+	assert.Equal(t, 1.0, report.Extraction.Accuracy)
+	assert.Equal(t, 0.75, report.ByPromptVersion["classify@v2+extract@v3"].Accuracy)
+	require.Len(t, report.Monthly, 2)
+	assert.Equal(t, "2026-01", report.Monthly[0].Period)
+	assert.Equal(t, 0.5, report.Monthly[0].Accuracy)
+	assert.Equal(t, 0.0, report.Monthly[0].ByField["category"].Accuracy)
+	assert.Equal(t, 1.0, report.Monthly[1].Accuracy)
+	require.Len(t, report.Weekly, 2)
+	assert.Equal(t, "2026-W02", report.Weekly[0].Period)
+}
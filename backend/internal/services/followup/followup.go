@@ -0,0 +1,283 @@
+package followup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNotTrackable 郵件不是案件相關的寄出郵件
+	ErrNotTrackable = errors.New("only outgoing emails linked to a case can be tracked")
+	// ErrDraftingUnavailable 未設定 AI 服務，無法產生草稿
+	ErrDraftingUnavailable = errors.New("AI drafting is not configured")
+)
+
+// Drafter 產生回信草稿（*openai.Service 實作此介面）
+type Drafter interface {
+	DraftReply(ctx context.Context, req openai.DraftReplyRequest) (*openai.DraftReplyResult, error)
+}
+
+// StyleGuide 為擬信請求加入使用者的寫作風格（*style.Service 實作此介面）
+type StyleGuide interface {
+	Apply(userID uuid.UUID, email *models.Email, req *openai.DraftReplyRequest) error
+}
+
+// Service 寄出郵件的回覆追蹤服務
+type Service struct {
+	db      *gorm.DB
+	cfg     config.FollowUpConfig
+	loc     *time.Location
+	drafter Drafter    // nil 時不產生草稿
+	style   StyleGuide // nil 時不套用寫作風格
+}
+
+// NewService 建立回覆追蹤服務
+func NewService(db *gorm.DB, cfg config.FollowUpConfig, drafter Drafter) *Service {
+	if cfg.BusinessDays <= 0 {
+		cfg.BusinessDays = 3
+	}
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil || cfg.Timezone == "" {
+		loc = time.UTC
+	}
+	return &Service{db: db, cfg: cfg, loc: loc, drafter: drafter}
+}
+
+// SetStyleGuide 設定擬信時套用的寫作風格
+func (s *Service) SetStyleGuide(guide StyleGuide) {
+	s.style = guide
+}
+
+// AddBusinessDays 加上 n 個工作天（略過週六、週日），以 loc 判斷星期
+func AddBusinessDays(t time.Time, n int, loc *time.Location) time.Time {
+	t = t.In(loc)
+	for added := 0; added < n; {
+		t = t.AddDate(0, 0, 1)
+		if wd := t.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			added++
+		}
+	}
+	return t
+}
+
+// Track 開始追蹤一封案件相關的寄出郵件；同一串先前尚未結束的追蹤改為 followed_up
+func (s *Service) Track(email *models.Email, userID uuid.UUID) (*models.FollowUp, error) {
+	if email.CaseID == nil || email.Direction != models.EmailDirectionOutgoing {
+		return nil, ErrNotTrackable
+	}
+
+	followUp := &models.FollowUp{
+		UserID:   userID,
+		CaseID:   *email.CaseID,
+		EmailID:  email.ID,
+		ThreadID: email.ThreadID,
+		Status:   models.FollowUpStatusWaiting,
+		SentAt:   email.ReceivedAt,
+		DueAt:    AddBusinessDays(email.ReceivedAt, s.cfg.BusinessDays, s.loc),
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		superseded := tx.Model(&models.FollowUp{}).
+			Where("case_id = ? AND email_id <> ? AND status IN ? AND sent_at <= ?", followUp.CaseID, email.ID, openStatuses, followUp.SentAt)
+		if email.ThreadID != nil {
+			superseded = superseded.Where("thread_id = ?", *email.ThreadID)
+		}
+		if err := superseded.Update("status", models.FollowUpStatusFollowedUp).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).Create(followUp).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to track follow-up: %w", err)
+	}
+	return followUp, nil
+}
+
+var openStatuses = []models.FollowUpStatus{models.FollowUpStatusWaiting, models.FollowUpStatusDue}
+
+// CheckResult 檢查結果
+type CheckResult struct {
+	Replied int
+	Due     int
+	Drafted int
+}
+
+// Check 檢查所有追蹤中的郵件：已回覆的結案，超過期限的轉為 due、建立通知並視設定產生草稿
+func (s *Service) Check(ctx context.Context, now time.Time) (*CheckResult, error) {
+	result := &CheckResult{}
+	var batch []models.FollowUp
+
+	err := s.db.Where("status IN ?", openStatuses).FindInBatches(&batch, 200, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := s.checkOne(ctx, &batch[i], now, result); err != nil {
+				log.Warn().Err(err).Str("follow_up_id", batch[i].ID.String()).Msg("Failed to check follow-up")
+			}
+		}
+		return nil
+	}).Error
+	return result, err
+}
+
+// checkOne 檢查單筆追蹤
+func (s *Service) checkOne(ctx context.Context, f *models.FollowUp, now time.Time, result *CheckResult) error {
+	reply, err := s.findReply(f)
+	if err != nil {
+		return err
+	}
+	if reply != nil {
+		result.Replied++
+		return s.db.Model(f).Updates(map[string]interface{}{
+			"status":         models.FollowUpStatusReplied,
+			"replied_at":     reply.ReceivedAt,
+			"reply_email_id": reply.ID,
+		}).Error
+	}
+
+	if f.Status != models.FollowUpStatusWaiting || now.Before(f.DueAt) {
+		return nil
+	}
+
+	var cs models.Case
+	if err := s.db.Select("id, title").First(&cs, "id = ?", f.CaseID).Error; err != nil {
+		return err
+	}
+	caseID := f.CaseID
+	emailID := f.EmailID
+	message := fmt.Sprintf("寄出已超過 %d 個工作天，對方尚未回覆", s.cfg.BusinessDays)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(f).Update("status", models.FollowUpStatusDue).Error; err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Create(&models.Notification{
+			UserID:  f.UserID,
+			Type:    models.NotificationTypeFollowUpDue,
+			Title:   "需要跟進：" + cs.Title,
+			Message: &message,
+			EmailID: &emailID,
+			CaseID:  &caseID,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	result.Due++
+
+	if s.cfg.AutoDraft && s.drafter != nil && result.Drafted < s.cfg.MaxDraftsPerRun {
+		if _, err := s.GenerateDraft(ctx, f); err != nil {
+			return fmt.Errorf("failed to draft nudge: %w", err)
+		}
+		result.Drafted++
+	}
+	return nil
+}
+
+// findReply 找出寄出後對方的第一封回覆（同一串或同一案件的收件）
+func (s *Service) findReply(f *models.FollowUp) (*models.Email, error) {
+	query := s.db.Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
+		Where("oauth_accounts.user_id = ? AND emails.direction = ? AND emails.received_at > ?",
+			f.UserID, models.EmailDirectionIncoming, f.SentAt)
+	if f.ThreadID != nil {
+		query = query.Where("emails.thread_id = ? OR emails.case_id = ?", *f.ThreadID, f.CaseID)
+	} else {
+		query = query.Where("emails.case_id = ?", f.CaseID)
+	}
+
+	var reply models.Email
+	err := query.Order("emails.received_at ASC").First(&reply).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+// GenerateDraft 以 DraftReply 產生禮貌的跟進信草稿並儲存
+func (s *Service) GenerateDraft(ctx context.Context, f *models.FollowUp) (string, error) {
+	if s.drafter == nil {
+		return "", ErrDraftingUnavailable
+	}
+
+	var cs models.Case
+	if err := s.db.First(&cs, "id = ?", f.CaseID).Error; err != nil {
+		return "", fmt.Errorf("failed to load case: %w", err)
+	}
+	var email models.Email
+	if err := s.db.First(&email, "id = ?", f.EmailID).Error; err != nil {
+		return "", fmt.Errorf("failed to load sent email: %w", err)
+	}
+
+	userAIInstructions := ""
+	var user models.User
+	if err := s.db.Select("id, ai_instructions").First(&user, "id = ?", f.UserID).Error; err == nil && user.AIInstructions != nil {
+		userAIInstructions = *user.AIInstructions
+	}
+
+	req := openai.DraftReplyRequest{
+		CaseTitle:          cs.Title,
+		BrandName:          cs.BrandName,
+		ContactName:        deref(cs.ContactName),
+		ContactEmail:       deref(cs.ContactEmail),
+		EmailFrom:          email.FromEmail,
+		EmailSubject:       deref(email.Subject),
+		EmailBody:          emailBody(&email),
+		Instruction:        nudgeInstruction(f, s.loc),
+		UserAIInstructions: userAIInstructions,
+	}
+	if s.style != nil {
+		if err := s.style.Apply(f.UserID, &email, &req); err != nil {
+			log.Warn().Err(err).Str("user_id", f.UserID.String()).Msg("Failed to apply writing style")
+		}
+	}
+
+	ctx = openai.WithUsageScope(ctx, f.UserID.String(), f.EmailID.String())
+	result, err := s.drafter.DraftReply(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if err := s.db.Model(f).Updates(map[string]interface{}{"draft": result.Draft, "drafted_at": now}).Error; err != nil {
+		return "", fmt.Errorf("failed to save draft: %w", err)
+	}
+	f.Draft = &result.Draft
+	f.DraftedAt = &now
+	return result.Draft, nil
+}
+
+// nudgeInstruction 跟進信的補充說明（上面那封是我們自己寄出、對方尚未回覆的信）
+func nudgeInstruction(f *models.FollowUp, loc *time.Location) string {
+	return fmt.Sprintf(`上面這封是「我方」於 %s 寄給對方、至今尚未獲得回覆的郵件（不是對方的來信）。
+請撰寫一封簡短、禮貌的跟進信：
+- 輕鬆地提起先前寄出的內容（若有報價，簡單提及報價仍然有效）
+- 詢問對方是否有任何問題或需要補充的資訊
+- 不要重複整封原信，也不要讓對方感到壓力`, f.SentAt.In(loc).Format("2006-01-02"))
+}
+
+func emailBody(e *models.Email) string {
+	if e.BodyText != nil && *e.BodyText != "" {
+		return *e.BodyText
+	}
+	return deref(e.Snippet)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
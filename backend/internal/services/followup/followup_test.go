@@ -0,0 +1,152 @@
+package followup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 設置測試用的資料庫（使用 SQLite）
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Skipf("Skipping test: SQLite not available (CGO required): %v", err)
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.OAuthAccount{}, &models.Email{}, &models.Case{},
+		&models.FollowUp{}, &models.Notification{})
+	require.NoError(t, err)
+	return db
+}
+
+// fakeDrafter 記錄收到的請求並回傳固定草稿
+type fakeDrafter struct {
+	requests []openai.DraftReplyRequest
+}
+
+func (f *fakeDrafter) DraftReply(ctx context.Context, req openai.DraftReplyRequest) (*openai.DraftReplyResult, error) {
+	f.requests = append(f.requests, req)
+	return &openai.DraftReplyResult{Draft: "想跟您確認先前的報價，有任何問題都歡迎告訴我。"}, nil
+}
+
+type fixture struct {
+	db      *gorm.DB
+	user    *models.User
+	account *models.OAuthAccount
+	cs      *models.Case
+}
+
+func newFixture(t *testing.T) *fixture {
+	db := setupTestDB(t)
+	user := &models.User{ID: uuid.New(), Email: "creator@example.com", Name: "Creator"}
+	require.NoError(t, db.Create(user).Error)
+	account := &models.OAuthAccount{
+		ID: uuid.New(), UserID: user.ID, Provider: models.OAuthProviderGoogle, Email: "creator@example.com",
+		AccessToken: "a", RefreshToken: "r", TokenExpiry: time.Now().Add(time.Hour),
+	}
+	require.NoError(t, db.Create(account).Error)
+	cs := &models.Case{UserID: user.ID, Title: "春季開箱", BrandName: "好品牌"}
+	require.NoError(t, db.Omit("User").Create(cs).Error)
+	return &fixture{db: db, user: user, account: account, cs: cs}
+}
+
+func (f *fixture) email(t *testing.T, direction string, at time.Time, thread string) *models.Email {
+	subject := "報價"
+	e := &models.Email{
+		OAuthAccountID: f.account.ID, ProviderMessageID: uuid.NewString(), ThreadID: &thread,
+		FromEmail: "creator@example.com", Subject: &subject, Direction: direction, ReceivedAt: at, CaseID: &f.cs.ID,
+	}
+	require.NoError(t, f.db.Create(e).Error)
+	return e
+}
+
+func TestAddBusinessDays(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	friday := time.Date(2024, 3, 1, 10, 0, 0, 0, loc)
+	assert.Equal(t, time.Date(2024, 3, 6, 10, 0, 0, 0, loc), AddBusinessDays(friday, 3, loc))
+
+	saturday := time.Date(2024, 3, 2, 10, 0, 0, 0, loc)
+	assert.Equal(t, time.Date(2024, 3, 4, 10, 0, 0, 0, loc), AddBusinessDays(saturday, 1, loc))
+}
+
+func TestTrack_SupersedesEarlierFollowUpInThread(t *testing.T) {
+	f := newFixture(t)
+	svc := NewService(f.db, config.FollowUpConfig{BusinessDays: 3, Timezone: "UTC"}, nil)
+
+	first, err := svc.Track(f.email(t, models.EmailDirectionOutgoing, time.Now().Add(-time.Hour), "t1"), f.user.ID)
+	require.NoError(t, err)
+	_, err = svc.Track(f.email(t, models.EmailDirectionOutgoing, time.Now(), "t1"), f.user.ID)
+	require.NoError(t, err)
+
+	var got models.FollowUp
+	require.NoError(t, f.db.First(&got, "id = ?", first.ID).Error)
+	assert.Equal(t, models.FollowUpStatusFollowedUp, got.Status)
+
+	_, err = svc.Track(f.email(t, models.EmailDirectionIncoming, time.Now(), "t1"), f.user.ID)
+	assert.ErrorIs(t, err, ErrNotTrackable)
+}
+
+func TestCheck_MarksRepliedAndDue(t *testing.T) {
+	f := newFixture(t)
+	drafter := &fakeDrafter{}
+	svc := NewService(f.db, config.FollowUpConfig{BusinessDays: 3, Timezone: "UTC", AutoDraft: true, MaxDraftsPerRun: 5}, drafter)
+
+	sentAt := time.Now().AddDate(0, 0, -10)
+	answered, err := svc.Track(f.email(t, models.EmailDirectionOutgoing, sentAt, "answered"), f.user.ID)
+	require.NoError(t, err)
+	reply := f.email(t, models.EmailDirectionIncoming, sentAt.Add(time.Hour), "answered")
+
+	unanswered, err := svc.Track(f.email(t, models.EmailDirectionOutgoing, sentAt, "unanswered"), f.user.ID)
+	require.NoError(t, err)
+	recent, err := svc.Track(f.email(t, models.EmailDirectionOutgoing, time.Now(), "recent"), f.user.ID)
+	require.NoError(t, err)
+
+	// 回覆放在另一個案件，只有同一串的 answered 會被視為已回覆
+	otherCase := &models.Case{UserID: f.user.ID, Title: "其他", BrandName: "其他"}
+	require.NoError(t, f.db.Omit("User").Create(otherCase).Error)
+	require.NoError(t, f.db.Model(reply).Update("case_id", otherCase.ID).Error)
+
+	result, err := svc.Check(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Replied)
+	assert.Equal(t, 1, result.Due)
+	assert.Equal(t, 1, result.Drafted)
+
+	var got models.FollowUp
+	require.NoError(t, f.db.First(&got, "id = ?", answered.ID).Error)
+	assert.Equal(t, models.FollowUpStatusReplied, got.Status)
+	require.NotNil(t, got.ReplyEmailID)
+	assert.Equal(t, reply.ID, *got.ReplyEmailID)
+
+	var due models.FollowUp
+	require.NoError(t, f.db.First(&due, "id = ?", unanswered.ID).Error)
+	assert.Equal(t, models.FollowUpStatusDue, due.Status)
+	require.NotNil(t, due.Draft)
+	require.Len(t, drafter.requests, 1)
+	assert.Equal(t, "好品牌", drafter.requests[0].BrandName)
+
+	var waiting models.FollowUp
+	require.NoError(t, f.db.First(&waiting, "id = ?", recent.ID).Error)
+	assert.Equal(t, models.FollowUpStatusWaiting, waiting.Status)
+
+	var notifications []models.Notification
+	require.NoError(t, f.db.Find(&notifications).Error)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, models.NotificationTypeFollowUpDue, notifications[0].Type)
+
+	// 再次檢查不會重複通知或產生草稿
+	result, err = svc.Check(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, result.Due+result.Drafted+result.Replied)
+}
@@ -3,8 +3,10 @@ package gmail
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"mime"
+	"net/http"
 	"strings"
 	"time"
 
@@ -14,6 +16,7 @@ import (
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 	"gorm.io/gorm"
 )
@@ -220,6 +223,42 @@ func (s *Service) GetMessage(messageID string) (*gmail.Message, error) {
 	return message, nil
 }
 
+// GetMessageHeaders 只取得郵件的指定 headers（format=metadata）
+func (s *Service) GetMessageHeaders(messageID string, headers ...string) (*gmail.Message, error) {
+	message, err := s.client.Users.Messages.Get("me", messageID).
+		Format("metadata").
+		MetadataHeaders(headers...).
+		Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message headers: %w", err)
+	}
+
+	return message, nil
+}
+
+// isNotFound Gmail API 回傳 404（郵件已刪除）
+func isNotFound(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+}
+
+// GetAttachment 下載郵件附件內容
+func (s *Service) GetAttachment(messageID, attachmentID string) ([]byte, error) {
+	body, err := s.client.Users.Messages.Attachments.Get("me", messageID, attachmentID).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	data, err := base64.URLEncoding.DecodeString(body.Data)
+	if err != nil {
+		// 部分回應省略 padding
+		data, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(body.Data, "="))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode attachment: %w", err)
+		}
+	}
+	return data, nil
+}
+
 // SendMessage 寄送郵件
 func (s *Service) SendMessage(req *SendMessageRequest) (string, error) {
 	// 建構 RFC 2822 格式的郵件
@@ -433,33 +472,75 @@ func (s *Service) buildRFC2822Message(req *SendMessageRequest) string {
 	// MIME version
 	message += "MIME-Version: 1.0\r\n"
 
-	// Content-Type
+	if len(req.Attachments) == 0 {
+		return message + messageBody(req)
+	}
+
+	// 有附件時以 multipart/mixed 包住內文與附件
+	boundary := fmt.Sprintf("mixed_%d", time.Now().UnixNano())
+	message += "Content-Type: multipart/mixed; boundary=" + boundary + "\r\n"
+	message += "\r\n"
+	message += "--" + boundary + "\r\n"
+	message += messageBody(req) + "\r\n"
+	for _, att := range req.Attachments {
+		contentType := att.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		filename := mime.BEncoding.Encode("UTF-8", att.Filename)
+		message += "--" + boundary + "\r\n"
+		message += "Content-Type: " + contentType + "; name=\"" + filename + "\"\r\n"
+		message += "Content-Disposition: attachment; filename=\"" + filename + "\"\r\n"
+		message += "Content-Transfer-Encoding: base64\r\n"
+		message += "\r\n"
+		message += wrapBase64(att.Data) + "\r\n"
+	}
+	message += "--" + boundary + "--"
+
+	return message
+}
+
+// messageBody 郵件內文部分（含 Content-Type 標頭）：同時有 HTML 時為 multipart/alternative
+func messageBody(req *SendMessageRequest) string {
+	part := ""
 	if req.HTMLBody != "" {
 		// 同時包含 HTML 和 plain text
 		boundary := fmt.Sprintf("boundary_%d", time.Now().UnixNano())
-		message += "Content-Type: multipart/alternative; boundary=" + boundary + "\r\n"
-		message += "\r\n"
+		part += "Content-Type: multipart/alternative; boundary=" + boundary + "\r\n"
+		part += "\r\n"
 
 		// Plain text part
-		message += "--" + boundary + "\r\n"
-		message += "Content-Type: text/plain; charset=UTF-8\r\n"
-		message += "\r\n"
-		message += req.TextBody + "\r\n"
-		message += "\r\n"
+		part += "--" + boundary + "\r\n"
+		part += "Content-Type: text/plain; charset=UTF-8\r\n"
+		part += "\r\n"
+		part += req.TextBody + "\r\n"
+		part += "\r\n"
 
 		// HTML part
-		message += "--" + boundary + "\r\n"
-		message += "Content-Type: text/html; charset=UTF-8\r\n"
-		message += "\r\n"
-		message += req.HTMLBody + "\r\n"
-		message += "\r\n"
-		message += "--" + boundary + "--"
+		part += "--" + boundary + "\r\n"
+		part += "Content-Type: text/html; charset=UTF-8\r\n"
+		part += "\r\n"
+		part += req.HTMLBody + "\r\n"
+		part += "\r\n"
+		part += "--" + boundary + "--"
 	} else {
 		// 只有 plain text
-		message += "Content-Type: text/plain; charset=UTF-8\r\n"
-		message += "\r\n"
-		message += req.TextBody
+		part += "Content-Type: text/plain; charset=UTF-8\r\n"
+		part += "\r\n"
+		part += req.TextBody
 	}
 
-	return message
+	return part
+}
+
+// wrapBase64 以 base64 編碼附件，每 76 字元換行（RFC 2045）
+func wrapBase64(data []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var b strings.Builder
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded)
+	return b.String()
 }
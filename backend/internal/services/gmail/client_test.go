@@ -394,3 +394,34 @@ func TestSliceContains(t *testing.T) {
 		t.Error("Expected slice not to contain 'd'")
 	}
 }
+
+func TestBuildRFC2822Message_Attachments(t *testing.T) {
+	service := &Service{
+		userEmail: "test@example.com",
+	}
+
+	req := &SendMessageRequest{
+		To:       []string{"recipient@example.com"},
+		Subject:  "報價單",
+		TextBody: "請參考附件",
+		HTMLBody: "<p>請參考附件</p>",
+		Attachments: []OutgoingAttachment{
+			{Filename: "報價單.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.4 test")},
+		},
+	}
+
+	message := service.buildRFC2822Message(req)
+
+	if !containsString(message, "Content-Type: multipart/mixed; boundary=") {
+		t.Error("Expected message to be multipart/mixed")
+	}
+	if !containsString(message, "Content-Type: multipart/alternative") {
+		t.Error("Expected body to keep the multipart/alternative part")
+	}
+	if !containsString(message, "Content-Disposition: attachment; filename=\"=?UTF-8?b?") {
+		t.Error("Expected attachment filename to be RFC 2047 encoded")
+	}
+	if !containsString(message, base64.StdEncoding.EncodeToString([]byte("%PDF-1.4 test"))) {
+		t.Error("Expected attachment data to be base64 encoded")
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/designcomb/influenter-backend/internal/models"
 	"github.com/google/uuid"
+	"golang.org/x/net/html/charset"
 	"google.golang.org/api/gmail/v1"
 )
 
@@ -37,6 +38,21 @@ func ParseMessage(gmailMsg *gmail.Message, oauthAccountID uuid.UUID) (*models.Em
 		parseBody(gmailMsg.Payload, parsed)
 	}
 
+	return parsedToEmail(parsed, oauthAccountID), nil
+}
+
+// MessageAttachments 列出 Gmail 郵件的附件（需以 format=full 取得的郵件）
+func MessageAttachments(gmailMsg *gmail.Message) []Attachment {
+	if gmailMsg.Payload == nil {
+		return nil
+	}
+	parsed := &ParsedMessage{}
+	parseBody(gmailMsg.Payload, parsed)
+	return parsed.Attachments
+}
+
+// parsedToEmail 將 ParsedMessage 轉換為 Email model（Gmail 同步與郵件匯入共用）
+func parsedToEmail(parsed *ParsedMessage, oauthAccountID uuid.UUID) *models.Email {
 	// 判斷方向：有 SENT 標籤為寄出，否則為收到
 	direction := models.EmailDirectionIncoming
 	if contains(parsed.LabelIDs, LabelSent) {
@@ -48,7 +64,8 @@ func ParseMessage(gmailMsg *gmail.Message, oauthAccountID uuid.UUID) (*models.Em
 		ID:                uuid.New(),
 		OAuthAccountID:    oauthAccountID,
 		ProviderMessageID: parsed.ID,
-		ThreadID:          &parsed.ThreadID,
+		ThreadID:          stringPtr(parsed.ThreadID),
+		RFCMessageID:      models.NormalizeMessageID(parsed.MessageID),
 		Direction:         direction,
 		FromEmail:         parsed.From.Address,
 		FromName:          stringPtr(parsed.From.Name),
@@ -67,7 +84,7 @@ func ParseMessage(gmailMsg *gmail.Message, oauthAccountID uuid.UUID) (*models.Em
 		email.ToEmail = stringPtr(parsed.To[0].Address)
 	}
 
-	return email, nil
+	return email
 }
 
 // decodeHeaderSubject 解碼主旨（RFC 2047 encoded-word）為 UTF-8
@@ -75,7 +92,7 @@ func decodeHeaderSubject(s string) string {
 	if s == "" {
 		return s
 	}
-	var dec mime.WordDecoder
+	dec := mime.WordDecoder{CharsetReader: charset.NewReaderLabel} // 支援 Big5、GB2312 等非 UTF-8 編碼
 	decoded, err := dec.DecodeHeader(s)
 	if err != nil {
 		return s
@@ -102,7 +119,7 @@ func parseHeaders(headers []*gmail.MessagePartHeader, parsed *ParsedMessage) {
 			if t, err := mail.ParseDate(header.Value); err == nil {
 				parsed.Date = t
 			}
-		case "Message-ID":
+		case "Message-ID", "Message-Id", "Message-id":
 			parsed.MessageID = header.Value
 		}
 	}
@@ -118,10 +135,11 @@ func parseBody(payload *gmail.MessagePart, parsed *ParsedMessage) {
 			attachmentSize = 2147483647
 		}
 		parsed.Attachments = append(parsed.Attachments, Attachment{
-			PartID:   payload.PartId,
-			Filename: payload.Filename,
-			MimeType: payload.MimeType,
-			Size:     attachmentSize,
+			PartID:       payload.PartId,
+			AttachmentID: payload.Body.AttachmentId,
+			Filename:     payload.Filename,
+			MimeType:     payload.MimeType,
+			Size:         attachmentSize,
 		})
 	}
 
@@ -55,6 +55,10 @@ func TestParseMessage(t *testing.T) {
 		t.Errorf("Expected ProviderMessageID 'test-message-id-123', got %s", email.ProviderMessageID)
 	}
 
+	if email.RFCMessageID == nil || *email.RFCMessageID != "message-id@example.com" {
+		t.Errorf("Expected RFCMessageID 'message-id@example.com', got %v", email.RFCMessageID)
+	}
+
 	if email.FromEmail != "sender@example.com" {
 		t.Errorf("Expected FromEmail 'sender@example.com', got %s", email.FromEmail)
 	}
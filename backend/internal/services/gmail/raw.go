@@ -0,0 +1,196 @@
+package gmail
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/google/uuid"
+	"golang.org/x/net/html/charset"
+	"google.golang.org/api/gmail/v1"
+)
+
+// maxMIMEDepth multipart 巢狀的最大層數（避免惡意郵件造成無限遞迴）
+const maxMIMEDepth = 10
+
+// ParseRawMessage 解析 RFC 5322 原始郵件（.eml / mbox 中的單封信）為 Email model
+// 與 ParseMessage 共用 header 與 model 轉換邏輯，確保匯入與 Gmail 同步的資料格式一致
+func ParseRawMessage(raw []byte, oauthAccountID uuid.UUID) (*models.Email, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+
+	parsed := &ParsedMessage{}
+
+	// 轉成 Gmail header 格式，沿用 parseHeaders 的正規化
+	headers := make([]*gmail.MessagePartHeader, 0, len(msg.Header))
+	for name, values := range msg.Header {
+		for _, v := range values {
+			headers = append(headers, &gmail.MessagePartHeader{Name: name, Value: v})
+		}
+	}
+	parseHeaders(headers, parsed)
+	parsed.InternalDate = parsed.Date
+	if parsed.InternalDate.IsZero() {
+		// 缺少 Date header 時以匯入時間代替（received_at 為必填）
+		parsed.InternalDate = time.Now()
+	}
+
+	// provider_message_id：有 Message-ID 用正規化後的值，否則用內容雜湊（重複匯入時可辨識）
+	if id := models.NormalizeMessageID(parsed.MessageID); id != nil {
+		parsed.ID = *id
+	} else {
+		sum := sha256.Sum256(raw)
+		parsed.ID = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	// thread：以 References 第一個（串的起點）為準，其次 In-Reply-To，最後是自己
+	parsed.ThreadID = rawThreadID(msg.Header, parsed.ID)
+
+	if err := parseRawBody(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body, parsed, 0); err != nil {
+		return nil, fmt.Errorf("failed to parse body: %w", err)
+	}
+
+	parsed.Snippet = rawSnippet(parsed)
+
+	email := parsedToEmail(parsed, oauthAccountID)
+	email.IsRead = true // 匯入的歷史郵件視為已讀
+	return email, nil
+}
+
+// rawThreadID 從 References / In-Reply-To 推算郵件串 ID
+func rawThreadID(h mail.Header, fallback string) string {
+	if refs := strings.Fields(h.Get("References")); len(refs) > 0 {
+		if id := models.NormalizeMessageID(refs[0]); id != nil {
+			return *id
+		}
+	}
+	if id := models.NormalizeMessageID(h.Get("In-Reply-To")); id != nil {
+		return *id
+	}
+	return fallback
+}
+
+// parseRawBody 遞迴解析 MIME body
+func parseRawBody(contentType, transferEncoding string, body io.Reader, parsed *ParsedMessage, depth int) error {
+	if depth > maxMIMEDepth {
+		return nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		// 沒有或無法解析 Content-Type 時，依 RFC 2045 視為 text/plain
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				// 截斷的 multipart：保留已解析的部分
+				return nil
+			}
+
+			disposition, dispParams, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+			filename := dispParams["filename"]
+			if filename == "" {
+				_, ctParams, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+				filename = ctParams["name"]
+			}
+			if disposition == "attachment" || filename != "" {
+				size, _ := io.Copy(io.Discard, decodeTransfer(part, part.Header.Get("Content-Transfer-Encoding")))
+				partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+				parsed.HasAttachments = true
+				parsed.Attachments = append(parsed.Attachments, Attachment{
+					Filename: decodeHeaderSubject(filename),
+					MimeType: partType,
+					Size:     int32(min(size, 2147483647)),
+				})
+				continue
+			}
+
+			if err := parseRawBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part, parsed, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return nil
+	}
+
+	decoded, err := io.ReadAll(decodeTransfer(body, transferEncoding))
+	if err != nil {
+		return err
+	}
+	text := decodeCharset(decoded, params["charset"])
+
+	switch mediaType {
+	case "text/plain":
+		if parsed.TextBody == "" {
+			parsed.TextBody = text
+		}
+	case "text/html":
+		if parsed.HTMLBody == "" {
+			parsed.HTMLBody = text
+		}
+	}
+	return nil
+}
+
+// decodeTransfer 依 Content-Transfer-Encoding 解碼
+func decodeTransfer(r io.Reader, encoding string) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r) // 解碼器會自動略過換行
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// decodeCharset 將指定 charset 的內容轉為 UTF-8（如 Big5、GB2312），失敗時原樣返回
+func decodeCharset(data []byte, label string) string {
+	if label == "" || strings.EqualFold(label, "utf-8") || strings.EqualFold(label, "us-ascii") {
+		return string(data)
+	}
+	r, err := charset.NewReaderLabel(label, bytes.NewReader(data))
+	if err != nil {
+		return string(data)
+	}
+	converted, err := io.ReadAll(r)
+	if err != nil {
+		return string(data)
+	}
+	return string(converted)
+}
+
+// rawSnippet 產生與 Gmail 類似的摘要（前 200 字）
+func rawSnippet(parsed *ParsedMessage) string {
+	text := parsed.TextBody
+	if text == "" {
+		text = ExtractPlainText(parsed.HTMLBody)
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) > 200 {
+		return string(runes[:200])
+	}
+	return text
+}
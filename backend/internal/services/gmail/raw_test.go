@@ -0,0 +1,126 @@
+package gmail
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/google/uuid"
+)
+
+const testRawMultipart = "From: Brand Team <brand@example.com>\r\n" +
+	"To: creator@example.com\r\n" +
+	"Subject: =?UTF-8?B?5ZCI5L2c6YKA57SE?=\r\n" +
+	"Date: Mon, 02 Jan 2023 15:04:05 +0800\r\n" +
+	"Message-ID: <Root-123@Example.com>\r\n" +
+	"References: <first@example.com> <second@example.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=\"outer\"\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"inner\"\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Hello =E4=BD=A0=E5=A5=BD\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>Hello</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf; name=\"brief.pdf\"\r\n" +
+	"Content-Disposition: attachment; filename=\"brief.pdf\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0xLjQK\r\n" +
+	"--outer--\r\n"
+
+func TestParseRawMessage_Multipart(t *testing.T) {
+	accountID := uuid.New()
+	email, err := ParseRawMessage([]byte(testRawMultipart), accountID)
+	if err != nil {
+		t.Fatalf("ParseRawMessage failed: %v", err)
+	}
+
+	if email.OAuthAccountID != accountID {
+		t.Errorf("Expected OAuthAccountID %s, got %s", accountID, email.OAuthAccountID)
+	}
+	if email.ProviderMessageID != "root-123@example.com" {
+		t.Errorf("Expected normalized ProviderMessageID, got %s", email.ProviderMessageID)
+	}
+	if email.RFCMessageID == nil || *email.RFCMessageID != "root-123@example.com" {
+		t.Errorf("Expected RFCMessageID 'root-123@example.com', got %v", email.RFCMessageID)
+	}
+	if email.ThreadID == nil || *email.ThreadID != "first@example.com" {
+		t.Errorf("Expected ThreadID from first reference, got %v", email.ThreadID)
+	}
+	if email.FromEmail != "brand@example.com" {
+		t.Errorf("Expected FromEmail 'brand@example.com', got %s", email.FromEmail)
+	}
+	if email.Subject == nil || *email.Subject != "合作邀約" {
+		t.Errorf("Expected decoded subject, got %v", email.Subject)
+	}
+	if email.BodyText == nil || !strings.Contains(*email.BodyText, "Hello 你好") {
+		t.Errorf("Expected decoded text body, got %v", email.BodyText)
+	}
+	if email.BodyHTML == nil || !strings.Contains(*email.BodyHTML, "<p>Hello</p>") {
+		t.Errorf("Expected HTML body, got %v", email.BodyHTML)
+	}
+	if !email.HasAttachments {
+		t.Error("Expected HasAttachments to be true")
+	}
+	if email.ReceivedAt.Year() != 2023 {
+		t.Errorf("Expected ReceivedAt from Date header, got %v", email.ReceivedAt)
+	}
+	if email.Direction != models.EmailDirectionIncoming {
+		t.Errorf("Expected incoming direction, got %s", email.Direction)
+	}
+	if !email.IsRead {
+		t.Error("Expected imported email to be read")
+	}
+}
+
+func TestParseRawMessage_NoMessageID(t *testing.T) {
+	raw := []byte("From: a@example.com\r\nSubject: hi\r\n\r\nbody\r\n")
+
+	email1, err := ParseRawMessage(raw, uuid.New())
+	if err != nil {
+		t.Fatalf("ParseRawMessage failed: %v", err)
+	}
+	email2, _ := ParseRawMessage(raw, uuid.New())
+
+	if !strings.HasPrefix(email1.ProviderMessageID, "sha256:") {
+		t.Errorf("Expected content hash as ProviderMessageID, got %s", email1.ProviderMessageID)
+	}
+	if email1.ProviderMessageID != email2.ProviderMessageID {
+		t.Error("Expected same content to produce same ProviderMessageID")
+	}
+	if email1.RFCMessageID != nil {
+		t.Errorf("Expected nil RFCMessageID, got %v", *email1.RFCMessageID)
+	}
+	if email1.BodyText == nil || *email1.BodyText != "body\r\n" {
+		t.Errorf("Expected plain body, got %v", email1.BodyText)
+	}
+}
+
+func TestParseRawMessage_Big5(t *testing.T) {
+	// "你好" in Big5
+	raw := []byte("From: a@example.com\r\nContent-Type: text/plain; charset=big5\r\n\r\n\xa7\x41\xa6\x6e\r\n")
+
+	email, err := ParseRawMessage(raw, uuid.New())
+	if err != nil {
+		t.Fatalf("ParseRawMessage failed: %v", err)
+	}
+	if email.BodyText == nil || !strings.Contains(*email.BodyText, "你好") {
+		t.Errorf("Expected Big5 body decoded to UTF-8, got %v", email.BodyText)
+	}
+}
+
+func TestParseRawMessage_Invalid(t *testing.T) {
+	if _, err := ParseRawMessage([]byte("not a message"), uuid.New()); err == nil {
+		t.Error("Expected error for invalid message")
+	}
+}
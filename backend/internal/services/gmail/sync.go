@@ -251,6 +251,11 @@ func (s *SyncService) fetchAndSaveMessage(messageID string) error {
 		return fmt.Errorf("failed to parse message %s: %w", messageID, err)
 	}
 
+	// 跨帳號去重：同一使用者的其他帳號已收過同一封信時標記為重複
+	if err := email.ResolveDuplicate(s.db, s.oauthAccount.UserID); err != nil {
+		return fmt.Errorf("failed to resolve duplicate for message %s: %w", messageID, err)
+	}
+
 	// 儲存到資料庫
 	if err := s.db.Create(email).Error; err != nil {
 		return fmt.Errorf("failed to save message %s: %w", messageID, err)
@@ -281,7 +286,7 @@ func (s *SyncService) updateEmail(messageID string) error {
 
 	// 查詢現有郵件
 	var email models.Email
-	if err := s.db.Where("provider_message_id = ?", messageID).First(&email).Error; err != nil {
+	if err := s.db.Where("provider_message_id = ? AND oauth_account_id = ?", messageID, s.oauthAccount.ID).First(&email).Error; err != nil {
 		return fmt.Errorf("failed to find message %s: %w", messageID, err)
 	}
 
@@ -311,13 +316,68 @@ func (s *SyncService) emailExists(messageID string) (bool, error) {
 	return count > 0, nil
 }
 
-// markEmailAsDeleted 標記郵件為已刪除（軟刪除）
+// markEmailAsDeleted 標記郵件為已刪除（軟刪除），其他帳號的同一封信改為主要郵件
 func (s *SyncService) markEmailAsDeleted(messageID string) error {
-	err := s.db.Model(&models.Email{}).
-		Where("provider_message_id = ?", messageID).
-		Update("deleted_at", time.Now()).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var ids []uuid.UUID
+		if err := tx.Model(&models.Email{}).
+			Where("provider_message_id = ? AND oauth_account_id = ?", messageID, s.oauthAccount.ID).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := tx.Model(&models.Email{}).Where("id IN ?", ids).Update("deleted_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return models.PromoteDuplicates(tx, ids)
+	})
+}
 
-	return err
+// BackfillMessageIDs 補齊此帳號舊郵件的 RFC Message-ID（跨帳號去重上線前同步的郵件），回傳處理筆數；
+// 沒有 Message-ID 或 Gmail 已無此郵件時記為空字串，避免重複查詢
+func (s *SyncService) BackfillMessageIDs(ctx context.Context, batchSize int) (int, error) {
+	processed := 0
+	var lastID uuid.UUID
+	for {
+		var emails []models.Email
+		err := s.db.Model(&models.Email{}).
+			Select("id, provider_message_id").
+			Where("oauth_account_id = ? AND rfc_message_id IS NULL AND id > ?", s.oauthAccount.ID, lastID).
+			Order("id").
+			Limit(batchSize).
+			Find(&emails).Error
+		if err != nil {
+			return processed, fmt.Errorf("failed to query emails: %w", err)
+		}
+		if len(emails) == 0 {
+			return processed, nil
+		}
+
+		for _, e := range emails {
+			if err := ctx.Err(); err != nil {
+				return processed, err
+			}
+			messageID := ""
+			msg, err := s.gmailService.GetMessageHeaders(e.ProviderMessageID, "Message-ID")
+			if err != nil && !isNotFound(err) {
+				return processed, err
+			}
+			if msg != nil && msg.Payload != nil {
+				parsed := &ParsedMessage{}
+				parseHeaders(msg.Payload.Headers, parsed)
+				if id := models.NormalizeMessageID(parsed.MessageID); id != nil {
+					messageID = *id
+				}
+			}
+			if err := s.db.Model(&models.Email{}).Where("id = ?", e.ID).Update("rfc_message_id", messageID).Error; err != nil {
+				return processed, fmt.Errorf("failed to save message id: %w", err)
+			}
+			processed++
+		}
+		lastID = emails[len(emails)-1].ID
+	}
 }
 
 // updateSyncStatus 更新同步狀態
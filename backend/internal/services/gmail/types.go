@@ -89,10 +89,11 @@ type EmailAddress struct {
 
 // Attachment 附件資訊
 type Attachment struct {
-	PartID   string
-	Filename string
-	MimeType string
-	Size     int32
+	PartID       string
+	AttachmentID string // Gmail 附件 ID（下載內容用）
+	Filename     string
+	MimeType     string
+	Size         int32
 }
 
 // SendMessageRequest 寄送郵件請求
@@ -106,6 +107,15 @@ type SendMessageRequest struct {
 	InReplyTo  string // 回覆郵件的 Message-ID
 	References string // 郵件串參考
 	ThreadID   string // Gmail thread ID（用於回覆）
+
+	Attachments []OutgoingAttachment // 附件
+}
+
+// OutgoingAttachment 寄出郵件的附件
+type OutgoingAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
 }
 
 // SyncResult 同步結果
@@ -0,0 +1,322 @@
+package mailimport
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/gmail"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MaxMessageSize 單封郵件大小上限
+	MaxMessageSize = 25 << 20
+
+	// maxZipEntrySize zip 內單一檔案解壓後的大小上限（防 zip bomb）
+	maxZipEntrySize = 2 << 30
+
+	// maxRecordedErrors errors 欄位最多保留的錯誤數
+	maxRecordedErrors = 100
+
+	// progressInterval 每處理幾封信更新一次進度
+	progressInterval = 50
+
+	// importAccountEmail 匯入用虛擬帳號的 email 欄位
+	importAccountEmail = "imported@local"
+)
+
+// ErrMessageTooLarge 單封郵件超過 MaxMessageSize
+var ErrMessageTooLarge = errors.New("message exceeds size limit")
+
+// Importer 郵件匯入服務
+type Importer struct {
+	db     *gorm.DB
+	logger *zerolog.Logger
+}
+
+// NewImporter 建立郵件匯入服務
+func NewImporter(db *gorm.DB, logger *zerolog.Logger) *Importer {
+	return &Importer{db: db, logger: logger}
+}
+
+// EnsureImportAccount 取得（或建立）使用者的匯入用虛擬帳號
+func EnsureImportAccount(db *gorm.DB, userID uuid.UUID) (*models.OAuthAccount, error) {
+	var account models.OAuthAccount
+	err := db.Where("user_id = ? AND provider = ?", userID, models.OAuthProviderImported).First(&account).Error
+	if err == nil {
+		return &account, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	// 匯入帳號不需要 token，也不參與同步
+	account = models.OAuthAccount{
+		UserID:     userID,
+		Provider:   models.OAuthProviderImported,
+		Email:      importAccountEmail,
+		SyncStatus: models.SyncStatusPaused,
+	}
+	if err := db.Create(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// importRun 單次匯入的執行狀態
+type importRun struct {
+	record    *models.MailImport
+	ownEmails map[string]bool
+	errs      []models.MailImportError
+}
+
+// Run 執行匯入：讀取 path 的檔案（.eml / .mbox / .zip），結束後更新 MailImport 狀態
+func (i *Importer) Run(ctx context.Context, importID uuid.UUID, filePath string) error {
+	var record models.MailImport
+	if err := i.db.First(&record, "id = ?", importID).Error; err != nil {
+		return fmt.Errorf("failed to load mail import: %w", err)
+	}
+
+	// worker 中斷後重新執行時從頭計算（已匯入的郵件會計為重複）
+	now := time.Now()
+	record.Status = models.MailImportStatusProcessing
+	record.StartedAt = &now
+	record.TotalMessages, record.ImportedCount, record.DuplicateCount, record.FailedCount = 0, 0, 0, 0
+	record.Errors = nil
+	record.ErrorMessage = nil
+	if err := i.db.Omit(clause.Associations).Save(&record).Error; err != nil {
+		return fmt.Errorf("failed to update mail import: %w", err)
+	}
+
+	run := &importRun{record: &record, ownEmails: i.ownEmails(record.UserID)}
+	runErr := i.importFile(ctx, run, filePath, record.Filename)
+
+	completed := time.Now()
+	record.CompletedAt = &completed
+	record.Status = models.MailImportStatusCompleted
+	if runErr != nil {
+		record.Status = models.MailImportStatusFailed
+		msg := runErr.Error()
+		record.ErrorMessage = &msg
+	}
+	i.saveProgress(run)
+
+	i.logger.Info().
+		Str("import_id", record.ID.String()).
+		Str("status", string(record.Status)).
+		Int("total", record.TotalMessages).
+		Int("imported", record.ImportedCount).
+		Int("duplicates", record.DuplicateCount).
+		Int("failed", record.FailedCount).
+		Msg("Mail import finished")
+
+	return runErr
+}
+
+// ownEmails 使用者本人的 email（用於判斷匯入郵件的方向）
+func (i *Importer) ownEmails(userID uuid.UUID) map[string]bool {
+	own := make(map[string]bool)
+
+	var user models.User
+	if err := i.db.Select("email").First(&user, "id = ?", userID).Error; err == nil && user.Email != "" {
+		own[strings.ToLower(user.Email)] = true
+	}
+
+	var accountEmails []string
+	i.db.Model(&models.OAuthAccount{}).
+		Where("user_id = ? AND provider <> ?", userID, models.OAuthProviderImported).
+		Pluck("email", &accountEmails)
+	for _, e := range accountEmails {
+		own[strings.ToLower(e)] = true
+	}
+	return own
+}
+
+// importFile 依檔案類型分派
+func (i *Importer) importFile(ctx context.Context, run *importRun, filePath, name string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open upload: %w", err)
+	}
+	defer f.Close()
+
+	header := make([]byte, 5)
+	n, _ := io.ReadFull(f, header)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if n >= 4 && bytes.Equal(header[:4], []byte("PK\x03\x04")) {
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		zr, err := zip.NewReader(f, info.Size())
+		if err != nil {
+			return fmt.Errorf("invalid zip archive: %w", err)
+		}
+		return i.importZip(ctx, run, zr)
+	}
+
+	return i.importStream(ctx, run, f, name)
+}
+
+// importZip 逐一匯入 zip 內的 .eml / .mbox 檔
+func (i *Importer) importZip(ctx context.Context, run *importRun, zr *zip.Reader) error {
+	for _, entry := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.FileInfo().IsDir() || strings.HasPrefix(entry.Name, "__MACOSX/") || strings.HasPrefix(path.Base(entry.Name), ".") {
+			continue
+		}
+		if !isSupportedFile(entry.Name) {
+			continue
+		}
+
+		rc, err := entry.Open()
+		if err != nil {
+			run.fail(entry.Name, err)
+			continue
+		}
+		err = i.importStream(ctx, run, io.LimitReader(rc, maxZipEntrySize), entry.Name)
+		rc.Close()
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			run.fail(entry.Name, err)
+		}
+	}
+	return nil
+}
+
+// importStream 匯入單一 .eml 或 .mbox 內容
+func (i *Importer) importStream(ctx context.Context, run *importRun, r io.Reader, name string) error {
+	br := bufio.NewReader(r)
+	peek, _ := br.Peek(5)
+
+	// mbox 以 "From " 開頭；其他一律當作單封 .eml
+	if string(peek) == "From " || strings.EqualFold(path.Ext(name), ".mbox") {
+		return SplitMbox(br, MaxMessageSize, func(index int, raw []byte, err error) error {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			source := fmt.Sprintf("%s#%d", name, index+1)
+			if err != nil {
+				run.fail(source, err)
+			} else {
+				i.importMessage(run, source, raw)
+			}
+			i.tick(run)
+			return nil
+		})
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(br, MaxMessageSize+1))
+	if err != nil {
+		return err
+	}
+	if len(raw) > MaxMessageSize {
+		run.fail(name, ErrMessageTooLarge)
+	} else {
+		i.importMessage(run, name, raw)
+	}
+	i.tick(run)
+	return nil
+}
+
+// importMessage 解析並儲存單封郵件
+func (i *Importer) importMessage(run *importRun, source string, raw []byte) {
+	record := run.record
+
+	email, err := gmail.ParseRawMessage(raw, record.OAuthAccountID)
+	if err != nil {
+		run.fail(source, err)
+		return
+	}
+	if run.ownEmails[strings.ToLower(email.FromEmail)] {
+		email.Direction = models.EmailDirectionOutgoing
+	}
+
+	// 同一個匯入帳號已有這封信（重複匯入；含已刪除的，避免違反唯一索引）
+	var count int64
+	if err := i.db.Unscoped().Model(&models.Email{}).
+		Where("oauth_account_id = ? AND provider_message_id = ?", record.OAuthAccountID, email.ProviderMessageID).
+		Count(&count).Error; err != nil {
+		run.fail(source, err)
+		return
+	}
+	if count > 0 {
+		record.DuplicateCount++
+		return
+	}
+
+	// 其他帳號（如 Gmail 同步）已有同一封信
+	if err := email.ResolveDuplicate(i.db, record.UserID); err != nil {
+		run.fail(source, err)
+		return
+	}
+	if email.DuplicateOfID != nil {
+		record.DuplicateCount++
+		return
+	}
+
+	if err := i.db.Create(email).Error; err != nil {
+		run.fail(source, err)
+		return
+	}
+	record.ImportedCount++
+}
+
+// fail 記錄單封郵件的錯誤
+func (r *importRun) fail(source string, err error) {
+	r.record.FailedCount++
+	if len(r.errs) < maxRecordedErrors {
+		r.errs = append(r.errs, models.MailImportError{Source: source, Error: err.Error()})
+	}
+}
+
+// tick 定期寫入進度，讓前端可以輪詢
+func (i *Importer) tick(run *importRun) {
+	run.record.TotalMessages++
+	if run.record.TotalMessages%progressInterval == 0 {
+		i.saveProgress(run)
+	}
+}
+
+// saveProgress 將目前的計數與錯誤寫回資料庫
+func (i *Importer) saveProgress(run *importRun) {
+	if len(run.errs) > 0 {
+		if data, err := json.Marshal(run.errs); err == nil {
+			run.record.Errors = datatypes.JSON(data)
+		}
+	}
+	if err := i.db.Omit(clause.Associations).Save(run.record).Error; err != nil {
+		i.logger.Error().Err(err).Str("import_id", run.record.ID.String()).Msg("Failed to save mail import progress")
+	}
+}
+
+// isSupportedFile 檢查副檔名是否為可匯入的郵件檔
+func isSupportedFile(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".eml", ".mbox", ".mbx", "":
+		return true
+	}
+	return false
+}
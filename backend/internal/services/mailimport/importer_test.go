@@ -0,0 +1,259 @@
+package mailimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 設置測試用的資料庫（使用 SQLite）
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Skipf("Skipping test: SQLite not available (CGO required): %v", err)
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.OAuthAccount{}, &models.Email{}, &models.MailImport{})
+	require.NoError(t, err)
+	return db
+}
+
+// createTestUser 建立測試使用者
+func createTestUser(t *testing.T, db *gorm.DB) *models.User {
+	user := &models.User{ID: uuid.New(), Email: "creator@example.com", Name: "Creator"}
+	require.NoError(t, db.Create(user).Error)
+	return user
+}
+
+// runTestImport 將 content 寫到暫存檔並執行匯入
+func runTestImport(t *testing.T, db *gorm.DB, userID uuid.UUID, filename string, content []byte) *models.MailImport {
+	account, err := EnsureImportAccount(db, userID)
+	require.NoError(t, err)
+
+	record := &models.MailImport{UserID: userID, OAuthAccountID: account.ID, Filename: filename}
+	require.NoError(t, db.Create(record).Error)
+
+	path := filepath.Join(t.TempDir(), filename)
+	require.NoError(t, os.WriteFile(path, content, 0600))
+
+	logger := zerolog.Nop()
+	require.NoError(t, NewImporter(db, &logger).Run(context.Background(), record.ID, path))
+
+	var result models.MailImport
+	require.NoError(t, db.First(&result, "id = ?", record.ID).Error)
+	return &result
+}
+
+func testMessage(id, from, subject string) string {
+	return "From: " + from + "\r\n" +
+		"To: creator@example.com\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Date: Mon, 02 Jan 2023 15:04:05 +0800\r\n" +
+		"Message-ID: <" + id + ">\r\n" +
+		"\r\n" +
+		"Hi there\r\n" +
+		"From the brand team\r\n"
+}
+
+func TestSplitMbox(t *testing.T) {
+	mbox := "From sender@example.com Mon Jan  2 15:04:05 2023\n" +
+		"Subject: one\n\nbody one\n>From escaped line\n\n" +
+		"From sender@example.com Mon Jan  2 15:05:05 2023\n" +
+		"Subject: two\n\nbody two\n"
+
+	var messages []string
+	err := SplitMbox(bytes.NewReader([]byte(mbox)), MaxMessageSize, func(index int, raw []byte, err error) error {
+		assert.NoError(t, err)
+		assert.Equal(t, len(messages), index)
+		messages = append(messages, string(raw))
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "Subject: one\n\nbody one\nFrom escaped line", messages[0])
+	assert.Equal(t, "Subject: two\n\nbody two", messages[1])
+}
+
+func TestSplitMbox_TooLarge(t *testing.T) {
+	mbox := "From a\nSubject: big\n\n" + string(bytes.Repeat([]byte("x"), 100)) + "\n\nFrom b\nSubject: ok\n\nsmall\n"
+
+	var errs, oks int
+	err := SplitMbox(bytes.NewReader([]byte(mbox)), 50, func(index int, raw []byte, err error) error {
+		if err != nil {
+			assert.ErrorIs(t, err, ErrMessageTooLarge)
+			errs++
+		} else {
+			oks++
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, errs)
+	assert.Equal(t, 1, oks)
+}
+
+func TestImporter_EML(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+
+	result := runTestImport(t, db, user.ID, "offer.eml", []byte(testMessage("offer-1@brand.com", "brand@brand.com", "Offer")))
+
+	assert.Equal(t, models.MailImportStatusCompleted, result.Status)
+	assert.Equal(t, 1, result.TotalMessages)
+	assert.Equal(t, 1, result.ImportedCount)
+	assert.NotNil(t, result.CompletedAt)
+
+	var email models.Email
+	require.NoError(t, db.First(&email, "provider_message_id = ?", "offer-1@brand.com").Error)
+	assert.Equal(t, result.OAuthAccountID, email.OAuthAccountID)
+	assert.Equal(t, models.EmailDirectionIncoming, email.Direction)
+}
+
+func TestImporter_MboxDuplicatesAndErrors(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+
+	mbox := "From x Mon Jan  2 15:04:05 2023\n" + testMessage("m1@brand.com", "brand@brand.com", "One") + "\n" +
+		"From x Mon Jan  2 15:04:05 2023\n" + testMessage("m2@brand.com", "Creator <creator@example.com>", "Two") + "\n" +
+		"From x Mon Jan  2 15:04:05 2023\n" + "this is not a valid message\n"
+
+	first := runTestImport(t, db, user.ID, "archive.mbox", []byte(mbox))
+	assert.Equal(t, 3, first.TotalMessages)
+	assert.Equal(t, 2, first.ImportedCount)
+	assert.Equal(t, 1, first.FailedCount)
+
+	var errs []models.MailImportError
+	require.NoError(t, json.Unmarshal(first.Errors, &errs))
+	require.Len(t, errs, 1)
+	assert.Equal(t, "archive.mbox#3", errs[0].Source)
+
+	// 寄件者為使用者本人 → 寄出
+	var sent models.Email
+	require.NoError(t, db.First(&sent, "provider_message_id = ?", "m2@brand.com").Error)
+	assert.Equal(t, models.EmailDirectionOutgoing, sent.Direction)
+
+	// 重複匯入同一檔案：全部視為重複
+	second := runTestImport(t, db, user.ID, "archive.mbox", []byte(mbox))
+	assert.Equal(t, 0, second.ImportedCount)
+	assert.Equal(t, 2, second.DuplicateCount)
+
+	var count int64
+	db.Model(&models.Email{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestImporter_SkipsMailAlreadySyncedFromGmail(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+
+	gmailAccount := &models.OAuthAccount{
+		UserID:      user.ID,
+		Provider:    models.OAuthProviderGoogle,
+		Email:       "creator@example.com",
+		TokenExpiry: time.Now().Add(time.Hour),
+	}
+	require.NoError(t, db.Create(gmailAccount).Error)
+	rfcID := models.NormalizeMessageID("<synced@brand.com>")
+	require.NoError(t, db.Create(&models.Email{
+		OAuthAccountID:    gmailAccount.ID,
+		ProviderMessageID: "gmail-abc",
+		RFCMessageID:      rfcID,
+		FromEmail:         "brand@brand.com",
+		ReceivedAt:        time.Now(),
+	}).Error)
+
+	result := runTestImport(t, db, user.ID, "synced.eml", []byte(testMessage("synced@brand.com", "brand@brand.com", "Synced")))
+
+	assert.Equal(t, 0, result.ImportedCount)
+	assert.Equal(t, 1, result.DuplicateCount)
+}
+
+func TestImporter_Zip(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		"a.eml":          testMessage("z1@brand.com", "brand@brand.com", "A"),
+		"folder/b.mbox":  "From x Mon Jan  2 15:04:05 2023\n" + testMessage("z2@brand.com", "brand@brand.com", "B"),
+		"notes.txt":      "ignored",
+		"__MACOSX/c.eml": "ignored",
+	}
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	result := runTestImport(t, db, user.ID, "export.zip", buf.Bytes())
+
+	assert.Equal(t, models.MailImportStatusCompleted, result.Status)
+	assert.Equal(t, 2, result.TotalMessages)
+	assert.Equal(t, 2, result.ImportedCount)
+}
+
+func TestEnsureImportAccount_Reuses(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+
+	a1, err := EnsureImportAccount(db, user.ID)
+	require.NoError(t, err)
+	a2, err := EnsureImportAccount(db, user.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, a1.ID, a2.ID)
+	assert.Equal(t, models.OAuthProviderImported, a1.Provider)
+	assert.Equal(t, models.SyncStatusPaused, a1.SyncStatus)
+}
+
+func TestSweepStale(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db)
+	account, err := EnsureImportAccount(db, user.ID)
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	create := func(status models.MailImportStatus, started time.Time) *models.MailImport {
+		record := &models.MailImport{ID: uuid.New(), UserID: user.ID, OAuthAccountID: account.ID, Filename: "export.mbox", Status: status, StartedAt: &started}
+		record.StoragePath = UploadPath(dir, record.ID, record.Filename)
+		require.NoError(t, os.WriteFile(record.StoragePath, []byte("x"), 0600))
+		require.NoError(t, db.Create(record).Error)
+		return record
+	}
+	stale := create(models.MailImportStatusProcessing, time.Now().Add(-4*time.Hour))
+	running := create(models.MailImportStatusProcessing, time.Now().Add(-time.Minute))
+	done := create(models.MailImportStatusCompleted, time.Now().Add(-4*time.Hour))
+
+	swept, err := SweepStale(db, 3*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, swept)
+
+	var result models.MailImport
+	require.NoError(t, db.First(&result, "id = ?", stale.ID).Error)
+	assert.Equal(t, models.MailImportStatusFailed, result.Status)
+	require.NotNil(t, result.ErrorMessage)
+	assert.NoFileExists(t, stale.StoragePath)
+
+	assert.FileExists(t, running.StoragePath)
+	assert.FileExists(t, done.StoragePath)
+}
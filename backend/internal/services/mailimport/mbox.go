@@ -0,0 +1,90 @@
+package mailimport
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// mboxLineLimit mbox 單行最大長度（超長行直接截斷，避免 bufio 錯誤）
+const mboxLineLimit = 1 << 20
+
+// SplitMbox 逐封讀取 mbox 檔案（mboxrd / mboxo），每封信呼叫一次 fn
+// 以空行後（或檔案開頭）"From " 開頭的行為分隔，並還原 ">From " 跳脫；超過 maxMessageSize 的郵件會以 ErrMessageTooLarge 回報給 fn
+func SplitMbox(r io.Reader, maxMessageSize int, fn func(index int, raw []byte, err error) error) error {
+	reader := bufio.NewReaderSize(r, 64*1024)
+
+	var (
+		buf       bytes.Buffer
+		index     int
+		started   bool
+		tooLarge  bool
+		prevBlank = true
+	)
+
+	flush := func() error {
+		if !started {
+			return nil
+		}
+		defer func() {
+			buf.Reset()
+			tooLarge = false
+			index++
+		}()
+		if tooLarge {
+			return fn(index, nil, ErrMessageTooLarge)
+		}
+		// mbox 格式在每封信後加一個空行，去掉
+		raw := bytes.TrimRight(buf.Bytes(), "\r\n")
+		if len(raw) == 0 {
+			return nil
+		}
+		return fn(index, append([]byte(nil), raw...), nil)
+	}
+
+	for {
+		line, err := readLine(reader)
+		if len(line) > 0 {
+			if prevBlank && bytes.HasPrefix(line, []byte("From ")) {
+				if ferr := flush(); ferr != nil {
+					return ferr
+				}
+				started = true
+			} else if started && !tooLarge {
+				// 還原 >From、>>From 跳脫（mboxrd）
+				if unescaped := bytes.TrimLeft(line, ">"); len(unescaped) < len(line) && bytes.HasPrefix(unescaped, []byte("From ")) {
+					line = line[1:]
+				}
+				if buf.Len()+len(line) > maxMessageSize {
+					tooLarge = true
+				} else {
+					buf.Write(line)
+				}
+			}
+			prevBlank = len(bytes.TrimRight(line, "\r\n")) == 0
+		}
+		if err == io.EOF {
+			return flush()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readLine 讀取一行（包含換行），超過 mboxLineLimit 的部分捨棄
+func readLine(r *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := r.ReadLine()
+		if len(line) < mboxLineLimit {
+			line = append(line, chunk...)
+		}
+		if err != nil {
+			return line, err
+		}
+		if !isPrefix {
+			return append(line, '\n'), nil
+		}
+	}
+}
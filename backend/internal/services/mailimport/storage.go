@@ -0,0 +1,77 @@
+package mailimport
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// errInterrupted 匯入逾時未完成（worker 中斷或任務遺失）
+const errInterrupted = "import interrupted"
+
+// UploadPath 上傳檔案在 dir 中的存放路徑（以匯入 ID 命名，保留副檔名）
+func UploadPath(dir string, importID uuid.UUID, filename string) string {
+	return filepath.Join(dir, importID.String()+strings.ToLower(filepath.Ext(filename)))
+}
+
+// RemoveUpload 刪除匯入的上傳檔案（檔案不存在時略過）
+func RemoveUpload(record *models.MailImport) error {
+	if record.StoragePath == "" {
+		return nil
+	}
+	if err := os.Remove(record.StoragePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove upload: %w", err)
+	}
+	return nil
+}
+
+// IsFinished 匯入是否已結束（成功或失敗）
+func IsFinished(record *models.MailImport) bool {
+	return record.Status == models.MailImportStatusCompleted || record.Status == models.MailImportStatusFailed
+}
+
+// SweepStale 將建立超過 staleAfter 仍未完成的匯入標記為失敗並刪除上傳檔案，回傳處理筆數
+func SweepStale(db *gorm.DB, staleAfter time.Duration) (int, error) {
+	cutoff := time.Now().Add(-staleAfter)
+
+	var stale []models.MailImport
+	err := db.Where("status IN ?", []models.MailImportStatus{models.MailImportStatusPending, models.MailImportStatusProcessing}).
+		Where("COALESCE(started_at, created_at) < ?", cutoff).
+		Find(&stale).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to find stale mail imports: %w", err)
+	}
+
+	swept := 0
+	for i := range stale {
+		record := &stale[i]
+		now := time.Now()
+		msg := errInterrupted
+		// 以狀態為條件更新，避免覆蓋剛好完成的匯入
+		result := db.Model(&models.MailImport{}).
+			Where("id = ? AND status = ?", record.ID, record.Status).
+			Updates(map[string]interface{}{
+				"status":        models.MailImportStatusFailed,
+				"error_message": &msg,
+				"completed_at":  &now,
+			})
+		if result.Error != nil {
+			return swept, fmt.Errorf("failed to mark mail import failed: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		if err := RemoveUpload(record); err != nil {
+			return swept, err
+		}
+		swept++
+	}
+	return swept, nil
+}
@@ -2,6 +2,7 @@ package openai
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -14,6 +15,12 @@ func (s *Service) AnalyzeEmail(ctx context.Context, req AnalyzeEmailRequest) (*E
 		Str("subject", req.Subject).
 		Msg("Starting full email analysis")
 
+	// 超過每月額度時改用關鍵字分類（不做資訊擷取）
+	if err := s.checkUsage(ctx, OperationClassify); errors.Is(err, ErrBudgetExceeded) {
+		s.logger.Warn().Err(err).Msg("AI budget exceeded, falling back to heuristic classification")
+		return s.heuristicAnalysis(req), nil
+	}
+
 	startTime := time.Now()
 
 	// 同時執行分類和資訊抽取
@@ -91,13 +98,17 @@ func (s *Service) AnalyzeEmail(ctx context.Context, req AnalyzeEmailRequest) (*E
 		ActionRequired: false,
 		Priority:       "medium",
 		TokensUsed:     0,
-		Model:          s.config.Model,
+		Model:          s.ModelFor(OperationClassify),
 		AnalyzedAt:     time.Now(),
 	}
 
+	// 記錄實際使用的範本版本（如 classify@v2+extract@v1）
+	var promptVersions []string
+
 	// 填入分類結果
 	if classification != nil {
 		result.Classification = *classification
+		promptVersions = append(promptVersions, classification.PromptVersion)
 		// 根據分類判斷是否需要行動
 		result.ActionRequired = IsHighPriorityCategory(classification.Category) || classification.Confidence > 0.8
 		// 根據分類設定優先級
@@ -111,7 +122,9 @@ func (s *Service) AnalyzeEmail(ctx context.Context, req AnalyzeEmailRequest) (*E
 	// 填入抽取資訊
 	if extractedInfo != nil {
 		result.ExtractedInfo = *extractedInfo
+		promptVersions = append(promptVersions, extractedInfo.PromptVersion)
 	}
+	result.PromptVersion = strings.Join(promptVersions, "+")
 
 	// 生成摘要
 	result.Summary = s.generateSummary(classification, extractedInfo)
@@ -239,7 +252,7 @@ func (s *Service) AnalyzeEmailSimple(ctx context.Context, subject, body, from st
 
 // GetAnalysisCost 計算分析的預估成本
 func (s *Service) GetAnalysisCost(avgTokens int) float64 {
-	return s.CalculateCost(avgTokens, s.config.Model)
+	return s.CalculateCost(avgTokens, s.ModelFor(OperationClassify))
 }
 
 // IsWorthAnalyzing 判斷郵件是否值得分析
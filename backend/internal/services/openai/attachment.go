@@ -0,0 +1,34 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SummarizeAttachment 摘要長附件的一段內容，或合併各段摘要（req.Summaries 不為空時）
+func (s *Service) SummarizeAttachment(ctx context.Context, req AttachmentSummaryRequest) (string, error) {
+	s.logger.Info().
+		Str("filename", req.Filename).
+		Int("part", req.Part).
+		Int("parts", req.Parts).
+		Int("summaries", len(req.Summaries)).
+		Msg("Summarizing attachment")
+
+	prompt, err := s.renderPrompt(ctx, PromptAttachment, req)
+	if err != nil {
+		return "", err
+	}
+
+	// 摘要為附件資訊擷取的一部分，計入 extract 的用量
+	resp, err := s.callAPI(ctx, OperationExtract, prompt.Messages, nil)
+	if err != nil {
+		return "", err
+	}
+
+	summary := strings.TrimSpace(resp.Content)
+	if summary == "" {
+		return "", fmt.Errorf("empty attachment summary from model")
+	}
+	return summary, nil
+}
@@ -0,0 +1,89 @@
+package openai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// CacheKey 結構化輸出的快取鍵：用途、模型、prompt 版本與正規化後的輸入
+type CacheKey struct {
+	UserID        string // 用於依使用者失效（如合作項目變更）
+	Operation     Operation
+	Model         string
+	PromptVersion string // 範本版本標籤（如 classify@v1）
+	InputHash     string // 工具名稱與訊息內容的 SHA-256
+}
+
+// ResultCache 保存已驗證的結構化輸出，相同輸入不重複呼叫 API
+type ResultCache interface {
+	GetResult(key CacheKey) ([]byte, bool, error)
+	PutResult(key CacheKey, result []byte) error
+}
+
+// SetResultCache 設定結果快取（未設定時每次都呼叫 API）
+func (s *Service) SetResultCache(cache ResultCache) {
+	s.cache = cache
+}
+
+// cacheKey 依 context 使用者與輸入建立快取鍵
+func (s *Service) cacheKey(ctx context.Context, operation Operation, toolName string, prompt *renderedPrompt) CacheKey {
+	return CacheKey{
+		UserID:        usageScopeFrom(ctx).UserID,
+		Operation:     operation,
+		Model:         s.ModelFor(operation),
+		PromptVersion: prompt.Version,
+		InputHash:     HashInput(toolName, prompt.Messages),
+	}
+}
+
+// HashInput 正規化（合併空白）後計算輸入的雜湊，避免排版差異造成快取失誤
+func HashInput(toolName string, messages []ChatMessage) string {
+	h := sha256.New()
+	h.Write([]byte(toolName))
+	for _, m := range messages {
+		h.Write([]byte{0})
+		h.Write([]byte(m.Role))
+		h.Write([]byte{0})
+		h.Write([]byte(strings.Join(strings.Fields(m.Content), " ")))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedResult 查詢快取；命中時記錄一筆不計 token 的用量供統計命中率
+func (s *Service) cachedResult(ctx context.Context, key CacheKey) ([]byte, bool) {
+	if s.cache == nil {
+		return nil, false
+	}
+	result, ok, err := s.cache.GetResult(key)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("operation", string(key.Operation)).Msg("Failed to read ai result cache")
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	scope := usageScopeFrom(ctx)
+	s.RecordTokenUsage(TokenUsage{
+		UserID:     scope.UserID,
+		EmailID:    scope.EmailID,
+		Operation:  key.Operation,
+		Model:      key.Model,
+		Cached:     true,
+		AnalyzedAt: time.Now(),
+	})
+	return result, true
+}
+
+// storeResult 寫入快取（失敗只記 log）
+func (s *Service) storeResult(key CacheKey, result []byte) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.PutResult(key, result); err != nil {
+		s.logger.Warn().Err(err).Str("operation", string(key.Operation)).Msg("Failed to write ai result cache")
+	}
+}
@@ -3,34 +3,35 @@ package openai
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/designcomb/influenter-backend/internal/config"
 	"github.com/rs/zerolog"
-	openai "github.com/sashabaranov/go-openai"
 )
 
-// Service OpenAI 服務
+// Service AI 服務（依設定將各用途的呼叫送到對應的模型後端）
 type Service struct {
-	client     *openai.Client
 	config     config.OpenAIConfig
-	userAPIKey string // 使用者自己設定的 API Key（可選）
+	llm        config.LLMConfig
+	providers  map[string]LLMProvider // 後端名稱 → 後端
+	userAPIKey string                 // 使用者自己設定的 API Key（可選）
 	logger     *zerolog.Logger
+	recorder   UsageRecorder  // nil 時用量只寫入 log
+	guard      UsageGuard     // nil 時不限制額度
+	cache      ResultCache    // nil 時不快取結果
+	prompts    PromptSource   // nil 時使用內建範本
+	feedback   FeedbackSource // nil 時不加入使用者修正範例
 }
 
 // NewService 建立新的 OpenAI Service
 // 如果提供了 userAPIKey，則使用使用者的 API Key，否則使用系統設定的
 func NewService(cfg config.Config, logger *zerolog.Logger, userAPIKey string) *Service {
-	apiKey := cfg.OpenAI.APIKey
-	if userAPIKey != "" {
-		apiKey = userAPIKey
-	}
-
-	client := openai.NewClient(apiKey)
-
 	service := &Service{
-		client:     client,
 		config:     cfg.OpenAI,
+		llm:        cfg.LLM,
+		providers:  newProviders(cfg, userAPIKey),
 		userAPIKey: userAPIKey,
 		logger:     logger,
 	}
@@ -38,28 +39,24 @@ func NewService(cfg config.Config, logger *zerolog.Logger, userAPIKey string) *S
 	return service
 }
 
-// TestConnection 測試 OpenAI API 連線
+// TestConnection 測試預設模型後端連線
 func (s *Service) TestConnection(ctx context.Context) error {
-	s.logger.Info().Msg("Testing OpenAI connection")
+	s.logger.Info().Msg("Testing LLM connection")
 
-	req := openai.ChatCompletionRequest{
-		Model:     s.config.Model,
-		MaxTokens: 5,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: "test",
-			},
-		},
+	provider, err := s.providerFor("")
+	if err != nil {
+		return err
 	}
-
-	_, err := s.client.CreateChatCompletion(ctx, req)
+	_, err = provider.Chat(ctx, ChatRequest{
+		Messages:  []ChatMessage{{Role: RoleUser, Content: "test"}},
+		MaxTokens: 5,
+	})
 	if err != nil {
-		s.logger.Error().Err(err).Msg("OpenAI connection test failed")
-		return fmt.Errorf("failed to connect to OpenAI: %w", err)
+		s.logger.Error().Err(err).Str("provider", provider.Name()).Msg("LLM connection test failed")
+		return fmt.Errorf("failed to connect to %s: %w", provider.Name(), err)
 	}
 
-	s.logger.Info().Msg("OpenAI connection test succeeded")
+	s.logger.Info().Str("provider", provider.Name()).Msg("LLM connection test succeeded")
 	return nil
 }
 
@@ -74,100 +71,229 @@ func (s *Service) GetMaxTokens() int {
 }
 
 // buildPrompt 建立完整的 prompt
-func (s *Service) buildPrompt(systemPrompt string, userPrompt string) []openai.ChatCompletionMessage {
-	return []openai.ChatCompletionMessage{
+func (s *Service) buildPrompt(systemPrompt string, userPrompt string) []ChatMessage {
+	return []ChatMessage{
 		{
-			Role:    openai.ChatMessageRoleSystem,
+			Role:    RoleSystem,
 			Content: systemPrompt,
 		},
 		{
-			Role:    openai.ChatMessageRoleUser,
+			Role:    RoleUser,
 			Content: userPrompt,
 		},
 	}
 }
 
-// callAPI 呼叫 OpenAI API
-func (s *Service) callAPI(ctx context.Context, messages []openai.ChatCompletionMessage, functions []openai.FunctionDefinition) (*openai.ChatCompletionResponse, error) {
+// callAPI 呼叫用途對應的模型後端；tool 非 nil 時要求結構化輸出
+func (s *Service) callAPI(ctx context.Context, operation Operation, messages []ChatMessage, tool *ToolDefinition) (*ChatResponse, error) {
+	if err := s.checkUsage(ctx, operation); err != nil {
+		s.logger.Warn().
+			Err(err).
+			Str("operation", string(operation)).
+			Msg("LLM call blocked by usage limits")
+		return nil, err
+	}
+
+	provider, err := s.providerFor(operation)
+	if err != nil {
+		return nil, err
+	}
+
 	startTime := time.Now()
 
-	req := openai.ChatCompletionRequest{
-		Model:     s.config.Model,
+	s.logger.Info().
+		Str("provider", provider.Name()).
+		Str("model", provider.Model()).
+		Str("operation", string(operation)).
+		Int("messages", len(messages)).
+		Msg("Calling LLM API")
+
+	resp, err := provider.Chat(ctx, ChatRequest{
 		Messages:  messages,
 		MaxTokens: s.config.MaxTokens,
-		Functions: functions,
+		Tool:      tool,
+	})
+	if err != nil {
+		s.logger.Error().
+			Err(err).
+			Str("provider", provider.Name()).
+			Dur("duration", time.Since(startTime)).
+			Msg("LLM API call failed")
+		return nil, fmt.Errorf("%s API error: %w", provider.Name(), err)
 	}
 
-	// 如果有定義 functions，啟用 function calling
-	if len(functions) > 0 {
-		req.FunctionCall = &openai.FunctionCall{
-			Name: functions[0].Name,
-		}
+	s.recordCall(ctx, provider, operation, resp, startTime)
+	return resp, nil
+}
+
+// callAPIStream 以串流模式呼叫用途對應的模型後端，逐段回傳文字；後端不支援串流時一次回傳整段
+func (s *Service) callAPIStream(ctx context.Context, operation Operation, messages []ChatMessage, onDelta DeltaFunc) (*ChatResponse, error) {
+	if err := s.checkUsage(ctx, operation); err != nil {
+		s.logger.Warn().
+			Err(err).
+			Str("operation", string(operation)).
+			Msg("LLM call blocked by usage limits")
+		return nil, err
 	}
 
+	provider, err := s.providerFor(operation)
+	if err != nil {
+		return nil, err
+	}
+
+	startTime := time.Now()
+
 	s.logger.Info().
-		Str("model", s.config.Model).
+		Str("provider", provider.Name()).
+		Str("model", provider.Model()).
+		Str("operation", string(operation)).
 		Int("messages", len(messages)).
-		Msg("Calling OpenAI API")
+		Msg("Calling LLM API (stream)")
 
-	resp, err := s.client.CreateChatCompletion(ctx, req)
+	req := ChatRequest{Messages: messages, MaxTokens: s.config.MaxTokens}
+	var streamed strings.Builder
+	record := func(delta string) error {
+		streamed.WriteString(delta)
+		return onDelta(delta)
+	}
+	var resp *ChatResponse
+	if streamer, ok := provider.(StreamingProvider); ok {
+		resp, err = streamer.ChatStream(ctx, req, record)
+	} else {
+		resp, err = provider.Chat(ctx, req)
+		if err == nil && resp.Content != "" {
+			err = record(resp.Content)
+		}
+	}
 	if err != nil {
 		s.logger.Error().
 			Err(err).
+			Str("provider", provider.Name()).
 			Dur("duration", time.Since(startTime)).
-			Msg("OpenAI API call failed")
-		return nil, fmt.Errorf("OpenAI API error: %w", err)
+			Msg("LLM API stream failed")
+		// 中途取消或失敗時模型已產生的 tokens 仍會計費：以已收到的用量（或估算值）記錄，
+		// 避免反覆取消串流繞過額度與頻率限制
+		if resp != nil || streamed.Len() > 0 || ctx.Err() != nil {
+			s.recordUsage(ctx, provider, operation, partialResponse(resp, provider, messages, streamed.String()))
+		}
+		return nil, fmt.Errorf("%s API error: %w", provider.Name(), err)
 	}
 
-	duration := time.Since(startTime)
-	tokensUsed := resp.Usage.TotalTokens
+	s.recordCall(ctx, provider, operation, resp, startTime)
+	return resp, nil
+}
 
+// recordCall 記錄成功呼叫的耗時與 token 用量
+func (s *Service) recordCall(ctx context.Context, provider LLMProvider, operation Operation, resp *ChatResponse, startTime time.Time) {
 	s.logger.Info().
-		Dur("duration", duration).
-		Int("tokens", tokensUsed).
-		Msg("OpenAI API call succeeded")
+		Str("provider", provider.Name()).
+		Dur("duration", time.Since(startTime)).
+		Int("tokens", resp.TotalTokens()).
+		Msg("LLM API call succeeded")
 
-	return &resp, nil
+	s.recordUsage(ctx, provider, operation, resp)
 }
 
-// CalculateCost 計算 API 成本
-func (s *Service) CalculateCost(tokensUsed int, model string) float64 {
-	// OpenAI 定價 (美元 per 1K tokens)
-	// 注意：這些價格可能會變動，建議定期更新
-	pricing := map[string]map[string]float64{
-		"gpt-4o": {
-			"prompt":     0.005,
-			"completion": 0.015,
-		},
-		"gpt-4o-mini": {
-			"prompt":     0.00015,
-			"completion": 0.0006,
-		},
-		"gpt-4-turbo": {
-			"prompt":     0.01,
-			"completion": 0.03,
-		},
-		"gpt-4": {
-			"prompt":     0.03,
-			"completion": 0.06,
-		},
-		"gpt-3.5-turbo": {
-			"prompt":     0.0005,
-			"completion": 0.0015,
-		},
+// partialResponse 中斷的串流已知的用量；後端未回報的部分依訊息與已收到的文字估算
+func partialResponse(resp *ChatResponse, provider LLMProvider, messages []ChatMessage, streamed string) *ChatResponse {
+	partial := &ChatResponse{Model: provider.Model(), Content: streamed}
+	if resp != nil {
+		partial.Model = resp.Model
+		partial.PromptTokens = resp.PromptTokens
+		partial.CompletionTokens = resp.CompletionTokens
+	}
+	if partial.PromptTokens == 0 {
+		for _, m := range messages {
+			partial.PromptTokens += estimateTokens(m.Content)
+		}
 	}
+	if n := estimateTokens(streamed); partial.CompletionTokens < n {
+		partial.CompletionTokens = n
+	}
+	return partial
+}
+
+// estimateTokens 粗估文字的 token 數（英數約 4 字元一個，中日韓等非 ASCII 字元約一字一個）
+func estimateTokens(s string) int {
+	ascii, other := 0, 0
+	for _, r := range s {
+		if r < 0x80 {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// recordUsage 記錄一次呼叫的 token 用量與成本
+func (s *Service) recordUsage(ctx context.Context, provider LLMProvider, operation Operation, resp *ChatResponse) {
+	tokensUsed := resp.TotalTokens()
+
+	// 自架模型未設定價格時不計成本
+	cost := s.CalculateUsageCost(resp.PromptTokens, resp.CompletionTokens, resp.Model)
+	if _, priced := s.lookupPrice(resp.Model); !priced && provider.Name() == config.LLMProviderCompatible {
+		cost = 0
+	}
+	scope := usageScopeFrom(ctx)
+	s.RecordTokenUsage(TokenUsage{
+		UserID:           scope.UserID,
+		EmailID:          scope.EmailID,
+		Operation:        operation,
+		Model:            resp.Model,
+		PromptTokens:     resp.PromptTokens,
+		CompletionTokens: resp.CompletionTokens,
+		TotalTokens:      tokensUsed,
+		CostUSD:          cost,
+		AnalyzedAt:       time.Now(),
+	})
+}
+
+// CalculateCost 計算 API 成本（只知道總 tokens 時，假設 prompt 與 completion 各半）
+func (s *Service) CalculateCost(tokensUsed int, model string) float64 {
+	price := s.modelPrice(model)
+	avgPrice := (price.Prompt + price.Completion) / 2
+	return float64(tokensUsed) / 1000.0 * avgPrice
+}
 
-	modelPricing, exists := pricing[model]
-	if !exists {
-		// 預設使用 gpt-4o-mini 的價格
-		modelPricing = pricing["gpt-4o-mini"]
+// CalculateUsageCost 依 prompt / completion tokens 分別計價
+func (s *Service) CalculateUsageCost(promptTokens, completionTokens int, model string) float64 {
+	price := s.modelPrice(model)
+	return float64(promptTokens)/1000.0*price.Prompt + float64(completionTokens)/1000.0*price.Completion
+}
+
+// modelPrice 取得模型價格，未設定的模型以 gpt-4o-mini 計價
+func (s *Service) modelPrice(model string) config.ModelPrice {
+	if price, ok := s.lookupPrice(model); ok {
+		return price
+	}
+	if price, ok := s.lookupPrice("gpt-4o-mini"); ok {
+		return price
 	}
+	return config.DefaultModelPricing()["gpt-4o-mini"]
+}
 
-	// 假設平均分配 prompt 和 completion tokens
-	avgPrice := (modelPricing["prompt"] + modelPricing["completion"]) / 2
-	cost := float64(tokensUsed) / 1000.0 * avgPrice
+// lookupPrice 查詢價格表
+// 回傳的模型名稱可能帶有日期或版本（如 gpt-4o-mini-2024-07-18），以最長的前綴比對
+func (s *Service) lookupPrice(model string) (config.ModelPrice, bool) {
+	pricing := s.config.Pricing
+	if len(pricing) == 0 {
+		pricing = config.DefaultModelPricing()
+	}
 
-	return cost
+	if price, ok := pricing[model]; ok {
+		return price, true
+	}
+	best := ""
+	for name := range pricing {
+		if strings.HasPrefix(model, name+"-") && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best != "" {
+		return pricing[best], true
+	}
+	return config.ModelPrice{}, false
 }
 
 // RecordTokenUsage 記錄 token 使用情況
@@ -175,23 +301,39 @@ func (s *Service) RecordTokenUsage(usage TokenUsage) {
 	s.logger.Info().
 		Str("user_id", usage.UserID).
 		Str("email_id", usage.EmailID).
+		Str("operation", string(usage.Operation)).
 		Str("model", usage.Model).
 		Int("total_tokens", usage.TotalTokens).
 		Float64("cost_usd", usage.CostUSD).
+		Bool("cached", usage.Cached).
 		Msg("Token usage recorded")
 
-	// TODO: 將 usage 儲存到資料庫
+	if s.recorder == nil {
+		return
+	}
+	// 用量寫入失敗不影響 AI 回應
+	if err := s.recorder.RecordUsage(usage); err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to persist token usage")
+	}
 }
 
 // TruncateContent 截斷內容以避免超過 token 限制
 func (s *Service) TruncateContent(content string, maxChars int) string {
+	return truncateContent(content, maxChars)
+}
+
+// truncateContent 截斷內容（範本中以 truncate 使用）
+func truncateContent(content string, maxChars int) string {
 	if len(content) <= maxChars {
 		return content
 	}
 
-	// 粗略估算：假設 1 token ≈ 4 字元
-	truncated := content[:maxChars]
-	return truncated + "...\n[內容已截斷]"
+	// 粗略估算：假設 1 token ≈ 4 字元；往前退到字元開頭，避免切斷中文等多位元組字元
+	end := maxChars
+	for end > 0 && !utf8.RuneStart(content[end]) {
+		end--
+	}
+	return content[:end] + "...\n[內容已截斷]"
 }
 
 // ValidateAPIKey 驗證 API Key 格式
@@ -235,11 +377,3 @@ func (s *Service) logAnalysisError(emailID string, operation string, err error)
 		Err(err).
 		Msg("AI analysis failed")
 }
-
-// Helper function to safely get string from response
-func safeString(choice *openai.ChatCompletionChoice) string {
-	if choice.Message.FunctionCall != nil {
-		return choice.Message.FunctionCall.Arguments
-	}
-	return choice.Message.Content
-}
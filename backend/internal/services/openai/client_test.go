@@ -1,7 +1,9 @@
 package openai
 
 import (
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/designcomb/influenter-backend/internal/config"
 	"github.com/rs/zerolog"
@@ -40,8 +42,8 @@ func TestNewService(t *testing.T) {
 		t.Fatal("Expected service to be non-nil")
 	}
 
-	if service.client == nil {
-		t.Error("Expected client to be non-nil")
+	if service.providers["openai"] == nil {
+		t.Error("Expected openai provider to be non-nil")
 	}
 
 	if service.config.Model != "gpt-4o-mini" {
@@ -135,6 +137,34 @@ func TestCalculateCost(t *testing.T) {
 	}
 }
 
+func TestCalculateUsageCost_ConfiguredPricing(t *testing.T) {
+	cfg := getTestConfig()
+	cfg.OpenAI.Pricing = map[string]config.ModelPrice{
+		"gpt-4o":      {Prompt: 0.0025, Completion: 0.01},
+		"gpt-4o-mini": {Prompt: 0.001, Completion: 0.002},
+	}
+	service := NewService(cfg, getMockLogger(), "")
+
+	tests := []struct {
+		name     string
+		model    string
+		expected float64
+	}{
+		{name: "exact model", model: "gpt-4o", expected: 0.0025 + 0.005},
+		{name: "dated model uses longest prefix", model: "gpt-4o-mini-2024-07-18", expected: 0.001 + 0.001},
+		{name: "unknown model falls back to gpt-4o-mini", model: "some-model", expected: 0.001 + 0.001},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cost := service.CalculateUsageCost(1000, 500, tt.model)
+			if diff := cost - tt.expected; diff > 1e-12 || diff < -1e-12 {
+				t.Errorf("Expected cost %f, got %f", tt.expected, cost)
+			}
+		})
+	}
+}
+
 func TestTruncateContent(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -199,6 +229,23 @@ func TestTruncateContent(t *testing.T) {
 	}
 }
 
+func TestTruncateContent_CJK(t *testing.T) {
+	content := strings.Repeat("品牌合作邀約", 10)
+	suffix := "...\n[內容已截斷]"
+
+	// 每個中文字 3 bytes，各種上限都不應切斷字元
+	for maxChars := 1; maxChars < 20; maxChars++ {
+		result := truncateContent(content, maxChars)
+		if !utf8.ValidString(result) {
+			t.Fatalf("maxChars=%d: result is not valid UTF-8: %q", maxChars, result)
+		}
+		kept := strings.TrimSuffix(result, suffix)
+		if !strings.HasPrefix(content, kept) || len(kept) > maxChars || len(kept) < maxChars-2 {
+			t.Errorf("maxChars=%d: unexpected truncation %q", maxChars, kept)
+		}
+	}
+}
+
 func hasSuffix(s, suffix string) bool {
 	if len(s) < len(suffix) {
 		return false
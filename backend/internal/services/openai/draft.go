@@ -3,65 +3,109 @@ package openai
 import (
 	"context"
 	"fmt"
+	"sync"
 )
 
 // DraftReply 根據案件與對方郵件產生回信草稿（純文字）
 func (s *Service) DraftReply(ctx context.Context, req DraftReplyRequest) (*DraftReplyResult, error) {
-	s.logger.Info().
-		Str("case_title", req.CaseTitle).
-		Str("email_from", req.EmailFrom).
-		Msg("Starting draft reply")
+	prompt, err := s.draftPrompt(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 不使用結構化輸出，直接取得 assistant 回覆內容
+	resp, err := s.callAPI(ctx, OperationDraft, prompt.Messages, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	content := resp.Content
+	if content == "" {
+		return nil, fmt.Errorf("empty draft from model")
+	}
+
+	return &DraftReplyResult{Draft: content, PromptVersion: prompt.Version}, nil
+}
+
+// DraftReplyStream 與 DraftReply 相同，但逐段將產生的文字交給 onDelta；ctx 取消時中止上游請求
+func (s *Service) DraftReplyStream(ctx context.Context, req DraftReplyRequest, onDelta DeltaFunc) (*DraftReplyResult, error) {
+	prompt, err := s.draftPrompt(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.callAPIStream(ctx, OperationDraft, prompt.Messages, onDelta)
+	if err != nil {
+		return nil, err
+	}
 
-	systemPrompt := `你是一位協助影響者（influencer）回覆合作邀約的專業助手。
-你的任務是根據案件資訊與對方來信，撰寫一封禮貌、專業的回信草稿。
-請直接產出回信「內文」純文字，不要包含主旨或稱謂以外的多餘說明。
-語氣要專業且友善，適合商業合作往來。`
+	content := resp.Content
+	if content == "" {
+		return nil, fmt.Errorf("empty draft from model")
+	}
 
-	if req.UserAIInstructions != "" {
-		systemPrompt += fmt.Sprintf("\n\n## 使用者常規注意事項（請務必遵守）\n%s", req.UserAIInstructions)
+	return &DraftReplyResult{Draft: content, PromptVersion: prompt.Version}, nil
+}
+
+// DraftVariants 依不同立場與語氣同時產生多份草稿；各份獨立成敗，全部失敗時回傳第一個錯誤
+func (s *Service) DraftVariants(ctx context.Context, req DraftReplyRequest, variants []DraftVariant) ([]DraftVariantResult, error) {
+	results := make([]DraftVariantResult, len(variants))
+	var wg sync.WaitGroup
+	for i, v := range variants {
+		wg.Add(1)
+		go func(i int, v DraftVariant) {
+			defer wg.Done()
+			vreq := req
+			vreq.Stance = v.Stance
+			vreq.Tone = v.Tone
+			result, err := s.DraftReply(ctx, vreq)
+			results[i] = DraftVariantResult{DraftVariant: v, Result: result, Err: err}
+		}(i, v)
 	}
+	wg.Wait()
 
-	userPrompt := fmt.Sprintf(`## 案件摘要
-- 標題：%s
-- 品牌：%s
-- 聯絡人：%s
-- 聯絡信箱：%s
-
-## 要回覆的來信
-- 寄件者：%s
-- 主旨：%s
-
-內文：
-%s
-`,
-		req.CaseTitle,
-		req.BrandName,
-		req.ContactName,
-		req.ContactEmail,
-		req.EmailFrom,
-		req.EmailSubject,
-		s.TruncateContent(req.EmailBody, 3000),
-	)
-
-	if req.Instruction != "" {
-		userPrompt += fmt.Sprintf("\n## 使用者補充說明\n%s\n\n請在草稿中適當反映以上說明。", req.Instruction)
+	for _, r := range results {
+		if r.Err == nil {
+			return results, nil
+		}
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no draft variants requested")
 	}
+	return nil, results[0].Err
+}
 
-	messages := s.buildPrompt(systemPrompt, userPrompt)
-	// 不使用 function calling，直接取得 assistant 回覆內容
-	resp, err := s.callAPI(ctx, messages, nil)
+// RefineDraft 依使用者的修改要求改寫既有草稿
+func (s *Service) RefineDraft(ctx context.Context, req RefineDraftRequest) (*DraftReplyResult, error) {
+	s.logger.Info().
+		Str("case_title", req.CaseTitle).
+		Int("previous_feedback", len(req.PreviousFeedback)).
+		Msg("Starting draft refinement")
+
+	prompt, err := s.renderPrompt(ctx, PromptRefineDraft, req)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from OpenAI")
+	resp, err := s.callAPI(ctx, OperationDraft, prompt.Messages, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	content := resp.Choices[0].Message.Content
+	content := resp.Content
 	if content == "" {
-		return nil, fmt.Errorf("empty draft from OpenAI")
+		return nil, fmt.Errorf("empty draft from model")
 	}
 
-	return &DraftReplyResult{Draft: content}, nil
+	return &DraftReplyResult{Draft: content, PromptVersion: prompt.Version}, nil
+}
+
+// draftPrompt 產生擬信的提示詞（一般與串流擬信共用）
+func (s *Service) draftPrompt(ctx context.Context, req DraftReplyRequest) (*renderedPrompt, error) {
+	s.logger.Info().
+		Str("case_title", req.CaseTitle).
+		Str("email_from", req.EmailFrom).
+		Msg("Starting draft reply")
+
+	return s.renderPrompt(ctx, PromptDraft, req)
 }
@@ -0,0 +1,275 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+)
+
+// newOpenAIStreamServer 以 Chat Completions 串流格式逐段回傳 chunks，最後附上用量
+func newOpenAIStreamServer(t *testing.T, chunks []string, got *map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(got)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			data, _ := json.Marshal(map[string]interface{}{
+				"model":   "llama3.1",
+				"choices": []map[string]interface{}{{"index": 0, "delta": map[string]string{"content": chunk}}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, `data: {"model":"llama3.1","choices":[],"usage":{"prompt_tokens":40,"completion_tokens":12,"total_tokens":52}}`+"\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+}
+
+func TestDraftReplyStream_CompatibleProvider(t *testing.T) {
+	var got map[string]interface{}
+	server := newOpenAIStreamServer(t, []string{"您好，", "謝謝您的邀約！"}, &got)
+	defer server.Close()
+
+	cfg := getTestConfig()
+	cfg.LLM = config.LLMConfig{
+		Operations: map[string]string{"draft": "compatible"},
+		Compatible: config.CompatibleLLMConfig{BaseURL: server.URL, Model: "llama3.1"},
+	}
+	service := NewService(cfg, getMockLogger(), "")
+	recorder := &recordingRecorder{}
+	service.SetUsageRecorder(recorder)
+
+	req := DraftReplyRequest{CaseTitle: "開箱合作", EmailSubject: "邀約", EmailBody: "想邀請您合作"}
+	var deltas []string
+	result, err := service.DraftReplyStream(context.Background(), req, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DraftReplyStream failed: %v", err)
+	}
+	if result.Draft != "您好，謝謝您的邀約！" || result.PromptVersion != "draft@v3" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if strings.Join(deltas, "|") != "您好，|謝謝您的邀約！" {
+		t.Errorf("Unexpected deltas: %v", deltas)
+	}
+	if got["stream"] != true {
+		t.Errorf("Expected stream request, got %v", got)
+	}
+
+	// 與一般擬信使用相同的提示詞
+	prompt, _ := service.draftPrompt(context.Background(), req)
+	messages, _ := got["messages"].([]interface{})
+	if len(messages) != 2 || messages[1].(map[string]interface{})["content"] != prompt.Messages[1].Content {
+		t.Errorf("Stream prompt differs from DraftReply prompt: %v", got["messages"])
+	}
+
+	if len(recorder.usages) != 1 || recorder.usages[0].TotalTokens != 52 || recorder.usages[0].Operation != OperationDraft {
+		t.Errorf("Unexpected usage: %+v", recorder.usages)
+	}
+}
+
+func TestDraftReplyStream_AbortedByCallback(t *testing.T) {
+	var got map[string]interface{}
+	server := newOpenAIStreamServer(t, []string{"第一段", "第二段"}, &got)
+	defer server.Close()
+
+	cfg := getTestConfig()
+	cfg.LLM = config.LLMConfig{
+		Operations: map[string]string{"draft": "compatible"},
+		Compatible: config.CompatibleLLMConfig{BaseURL: server.URL, Model: "llama3.1"},
+	}
+	service := NewService(cfg, getMockLogger(), "")
+	recorder := &recordingRecorder{}
+	service.SetUsageRecorder(recorder)
+
+	gone := errors.New("client gone")
+	_, err := service.DraftReplyStream(context.Background(), DraftReplyRequest{CaseTitle: "案件"}, func(delta string) error {
+		return gone
+	})
+	if !errors.Is(err, gone) {
+		t.Fatalf("Expected callback error, got %v", err)
+	}
+	// 中斷的串流仍計入用量（後端未回報時依提示詞與已收到的文字估算），避免繞過額度
+	if len(recorder.usages) != 1 {
+		t.Fatalf("Expected usage for aborted stream, got %+v", recorder.usages)
+	}
+	usage := recorder.usages[0]
+	if usage.Operation != OperationDraft || usage.PromptTokens == 0 || usage.CompletionTokens != 3 {
+		t.Errorf("Unexpected usage for aborted stream: %+v", usage)
+	}
+}
+
+func TestAnthropicProvider_ChatStreamErrorRecordsUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"model\":\"claude-3-5-haiku-20241022\",\"usage\":{\"input_tokens\":80,\"output_tokens\":1}}}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hi Amy, thanks for reaching out\"}}\n\n")
+		fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	}))
+	defer server.Close()
+
+	cfg := getTestConfig()
+	cfg.LLM = config.LLMConfig{
+		Operations: map[string]string{"draft": "anthropic"},
+		Anthropic:  config.AnthropicConfig{APIKey: "test-key", Model: "claude-3-5-haiku-latest", BaseURL: server.URL},
+	}
+	service := NewService(cfg, getMockLogger(), "")
+	recorder := &recordingRecorder{}
+	service.SetUsageRecorder(recorder)
+
+	_, err := service.DraftReplyStream(context.Background(), DraftReplyRequest{CaseTitle: "案件"}, func(string) error { return nil })
+	if err == nil {
+		t.Fatal("Expected stream error")
+	}
+	if len(recorder.usages) != 1 || recorder.usages[0].PromptTokens != 80 || recorder.usages[0].CompletionTokens != 8 {
+		t.Errorf("Unexpected usage: %+v", recorder.usages)
+	}
+}
+
+func TestAnthropicProvider_ChatStream(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"model\":\"claude-3-5-haiku-20241022\",\"usage\":{\"input_tokens\":80,\"output_tokens\":1}}}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hi Amy,\"}}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\" thanks!\"}}\n\n")
+		fmt.Fprint(w, "event: message_delta\ndata: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":9}}\n\n")
+		fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer server.Close()
+
+	cfg := getTestConfig()
+	cfg.LLM = config.LLMConfig{
+		Operations: map[string]string{"draft": "anthropic"},
+		Anthropic:  config.AnthropicConfig{APIKey: "test-key", Model: "claude-3-5-haiku-latest", BaseURL: server.URL},
+	}
+	service := NewService(cfg, getMockLogger(), "")
+	recorder := &recordingRecorder{}
+	service.SetUsageRecorder(recorder)
+
+	var deltas []string
+	result, err := service.DraftReplyStream(context.Background(), DraftReplyRequest{CaseTitle: "案件"}, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DraftReplyStream failed: %v", err)
+	}
+	if result.Draft != "Hi Amy, thanks!" || len(deltas) != 2 {
+		t.Errorf("Unexpected result: %+v, %v", result, deltas)
+	}
+	if got["stream"] != true || got["system"] == "" {
+		t.Errorf("Unexpected request body: %v", got)
+	}
+	if len(recorder.usages) != 1 || recorder.usages[0].PromptTokens != 80 || recorder.usages[0].CompletionTokens != 9 {
+		t.Errorf("Unexpected usage: %+v", recorder.usages)
+	}
+}
+
+// newDraftEchoServer 依 system 訊息中的立場回覆不同草稿；立場為婉拒時回傳錯誤
+func newDraftEchoServer(t *testing.T, mu *sync.Mutex, requests *[]map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		*requests = append(*requests, req)
+		mu.Unlock()
+
+		messages := req["messages"].([]interface{})
+		system := messages[0].(map[string]interface{})["content"].(string)
+		user := messages[1].(map[string]interface{})["content"].(string)
+		content := "草稿：" + user[strings.LastIndex(user, "\n")+1:]
+		switch {
+		case strings.Contains(system, "禮貌地婉拒"):
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		case strings.Contains(system, "接受這次合作"):
+			content = "接受草稿"
+		case strings.Contains(system, "提出具體的調整建議"):
+			content = "議價草稿"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"model":   "llama3.1",
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": content}}},
+			"usage":   map[string]int{"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15},
+		})
+	}))
+}
+
+func newDraftTestService(baseURL string) *Service {
+	cfg := getTestConfig()
+	cfg.LLM = config.LLMConfig{
+		Operations: map[string]string{"draft": "compatible"},
+		Compatible: config.CompatibleLLMConfig{BaseURL: baseURL, Model: "llama3.1"},
+	}
+	return NewService(cfg, getMockLogger(), "")
+}
+
+func TestDraftVariants_PartialFailure(t *testing.T) {
+	var mu sync.Mutex
+	var requests []map[string]interface{}
+	server := newDraftEchoServer(t, &mu, &requests)
+	defer server.Close()
+	service := newDraftTestService(server.URL)
+
+	results, err := service.DraftVariants(context.Background(), DraftReplyRequest{CaseTitle: "開箱合作"}, DefaultDraftVariants)
+	if err != nil {
+		t.Fatalf("DraftVariants failed: %v", err)
+	}
+	if len(results) != 3 || len(requests) != 3 {
+		t.Fatalf("Expected 3 variants and requests, got %d / %d", len(results), len(requests))
+	}
+	if results[0].Stance != DraftStanceAccept || results[0].Err != nil || results[0].Result.Draft != "接受草稿" {
+		t.Errorf("Unexpected accept variant: %+v", results[0])
+	}
+	if results[1].Stance != DraftStanceNegotiate || results[1].Result == nil || results[1].Result.Draft != "議價草稿" {
+		t.Errorf("Unexpected negotiate variant: %+v", results[1])
+	}
+	if results[2].Stance != DraftStanceDecline || results[2].Err == nil {
+		t.Errorf("Expected decline variant to fail: %+v", results[2])
+	}
+
+	// 全部失敗時回傳錯誤
+	_, err = service.DraftVariants(context.Background(), DraftReplyRequest{CaseTitle: "開箱合作"}, []DraftVariant{{Stance: DraftStanceDecline}})
+	if err == nil {
+		t.Error("Expected error when every variant fails")
+	}
+}
+
+func TestRefineDraft_IncludesDraftAndFeedback(t *testing.T) {
+	var mu sync.Mutex
+	var requests []map[string]interface{}
+	server := newDraftEchoServer(t, &mu, &requests)
+	defer server.Close()
+	service := newDraftTestService(server.URL)
+
+	result, err := service.RefineDraft(context.Background(), RefineDraftRequest{
+		DraftReplyRequest: DraftReplyRequest{CaseTitle: "開箱合作", EmailBody: "想邀請您合作"},
+		Draft:             "您好，報價是 3 萬元。",
+		Feedback:          "改用英文",
+		PreviousFeedback:  []string{"短一點", "要求 50% 訂金"},
+	})
+	if err != nil {
+		t.Fatalf("RefineDraft failed: %v", err)
+	}
+	if result.Draft != "草稿：改用英文" || result.PromptVersion != "refine_draft@v1" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	user := requests[0]["messages"].([]interface{})[1].(map[string]interface{})["content"].(string)
+	for _, want := range []string{"您好，報價是 3 萬元。", "- 短一點", "- 要求 50% 訂金", "## 這次的修改要求"} {
+		if !strings.Contains(user, want) {
+			t.Errorf("Expected %q in refine prompt: %s", want, user)
+		}
+	}
+}
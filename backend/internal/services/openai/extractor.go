@@ -7,8 +7,6 @@ import (
 	"strconv"
 	"strings"
 	"time"
-
-	openai "github.com/sashabaranov/go-openai"
 )
 
 // ExtractInfo 從郵件中抽取結構化資訊
@@ -18,143 +16,25 @@ func (s *Service) ExtractInfo(ctx context.Context, req AnalyzeEmailRequest) (*Ex
 		Str("subject", req.Subject).
 		Msg("Starting info extraction")
 
-	// 建立 system prompt
-	systemPrompt := `你是一個專業的資訊抽取助手，專門協助影響者（influencer）從合作邀約郵件中抽取重要的結構化資訊。
-
-你的任務是從郵件內容中識別並抽取以下資訊：
-1. **品牌名稱** (brand_name) - 發送合作邀約的品牌或公司名稱
-2. **聯絡人姓名** (contact_name) - 發送郵件的人的姓名
-3. **聯絡人郵件** (contact_email) - 發送郵件的電子郵件地址
-4. **聯絡電話** (contact_phone) - 聯絡電話號碼（如果有的話）
-5. **金額** (amount) - 合作的預算或報酬金額
-6. **幣別** (currency) - 金額使用的貨幣（函式預設為 "TWD"）
-7. **截止日期** (due_date) - 專案的截止日期或回覆期限（ISO 8601 格式: YYYY-MM-DD）
-8. **內容類型** (content_type) - 要求的內容類型（例如：影片、圖文、直播、貼文等）
-9. **粉絲數** (follower_count) - 提及的粉絲數或影響力要求（例如："10萬以上"）
-10. **預算範圍** (budget) - 如果提到預算範圍（例如："5萬-10萬"）
-11. **專案詳情** (project_details) - 專案的詳細說明
-
-注意事項：
-- 如果某個欄位在郵件中沒有提到，請填 null 或空字串
-- 日期請使用 ISO 8601 格式 (YYYY-MM-DD)
-- 金額只需要數字部分，不需要包含貨幣符號或單位
-- 幣別請使用標準的 ISO 4217 代碼（如 TWD, USD, EUR 等）
-- 如果只有金額範圍，請將 budget 欄位填入範圍，amount 欄位填 null
-- 專案詳情請簡要摘要（建議 100 字以內）`
-
-	// 建立 user prompt
-	userPrompt := fmt.Sprintf(`請從以下郵件中抽取所有相關資訊：
-
-**寄件者**: %s
-**收件者**: %s
-**主旨**: %s
-**日期**: %s
-**內容**: %s
-
-請仔細分析郵件，盡可能填寫所有能找到的資訊。如果某些欄位在郵件中沒有明確提到，請填 null 或空字串。`,
-		req.From,
-		strings.Join(req.To, ", "),
-		req.Subject,
-		req.Date.Format("2006-01-02 15:04:05"),
-		s.TruncateContent(req.Body, 4000), // 較長一點以包含更多資訊
-	)
-
-	// 定義 function calling
-	functions := []openai.FunctionDefinition{
-		{
-			Name:        "extract_info",
-			Description: "從郵件中抽取結構化的合作資訊",
-			Parameters: json.RawMessage(`{
-				"type": "object",
-				"properties": {
-					"brand_name": {
-						"type": "string",
-						"description": "品牌或公司名稱"
-					},
-					"contact_name": {
-						"type": "string",
-						"description": "聯絡人姓名"
-					},
-					"contact_email": {
-						"type": "string",
-						"description": "聯絡人電子郵件地址"
-					},
-					"contact_phone": {
-						"type": "string",
-						"description": "聯絡電話號碼"
-					},
-					"amount": {
-						"type": "number",
-						"description": "合作的預算或報酬金額（純數字）"
-					},
-					"currency": {
-						"type": "string",
-						"default": "TWD",
-						"description": "金額使用的貨幣（ISO 4217 代碼）"
-					},
-					"due_date": {
-						"type": "string",
-						"description": "截止日期（ISO 8601 格式: YYYY-MM-DD）"
-					},
-					"content_type": {
-						"type": "string",
-						"description": "內容類型（例如：影片、圖文、直播等）"
-					},
-					"follower_count": {
-						"type": "string",
-						"description": "粉絲數或影響力要求"
-					},
-					"budget": {
-						"type": "string",
-						"description": "預算範圍（例如：'5萬-10萬'）"
-					},
-					"project_details": {
-						"type": "string",
-						"description": "專案詳情摘要"
-					}
-				}
-			}`),
-		},
+	if req.Examples == nil {
+		req.Examples = s.feedbackExamples(ctx, PromptExtract)
+	}
+	prompt, err := s.renderPrompt(ctx, PromptExtract, req)
+	if err != nil {
+		return nil, err
 	}
 
-	// 建立訊息
-	messages := s.buildPrompt(systemPrompt, userPrompt)
-
-	// 呼叫 API
+	// 呼叫 API（結構化輸出，依 ExtractedInfo 的 schema 驗證）
 	startTime := time.Now()
-	resp, err := s.callAPI(ctx, messages, functions)
-	if err != nil {
+	var result ExtractedInfo
+	if err := s.callStructured(ctx, OperationExtract, prompt, "extract_info", "從郵件中抽取結構化的合作資訊", &result); err != nil {
 		s.logger.Error().
 			Err(err).
 			Dur("duration", time.Since(startTime)).
 			Msg("Failed to extract info")
 		return nil, fmt.Errorf("failed to extract info: %w", err)
 	}
-
-	// 解析結果
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from OpenAI")
-	}
-
-	choice := resp.Choices[0]
-	if choice.Message.FunctionCall == nil {
-		s.logger.Warn().
-			Str("response", choice.Message.Content).
-			Msg("No function call in response, returning empty result")
-		return &ExtractedInfo{}, nil
-	}
-
-	// 解析 function call arguments
-	args := choice.Message.FunctionCall.Arguments
-	var result ExtractedInfo
-
-	if err := json.Unmarshal([]byte(args), &result); err != nil {
-		s.logger.Error().
-			Err(err).
-			Str("arguments", args).
-			Msg("Failed to parse function call arguments")
-		return nil, fmt.Errorf("failed to parse extraction result: %w", err)
-	}
+	result.PromptVersion = prompt.Version
 
 	// 解析日期
 	if result.DueDate != nil && (*result.DueDate == time.Time{}) {
@@ -170,7 +50,6 @@ func (s *Service) ExtractInfo(ctx context.Context, req AnalyzeEmailRequest) (*Ex
 	s.logger.Info().
 		Str("brand", result.BrandName).
 		Str("amount", amountStr).
-		Int("tokens", resp.Usage.TotalTokens).
 		Dur("duration", time.Since(startTime)).
 		Msg("Info extraction completed")
 
@@ -191,9 +70,12 @@ func (e *ExtractedInfo) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	// 解析日期字串
+	// 解析日期字串（模型回傳 YYYY-MM-DD；已儲存的分析結果為 RFC 3339）
 	if aux.DueDate != "" {
 		parsedDate, err := time.Parse("2006-01-02", aux.DueDate)
+		if err != nil {
+			parsedDate, err = time.Parse(time.RFC3339, aux.DueDate)
+		}
 		if err != nil {
 			// 如果解析失敗，嘗試其他格式
 			parsedDate, err = time.Parse("2006/01/02", aux.DueDate)
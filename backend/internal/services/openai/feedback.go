@@ -0,0 +1,30 @@
+package openai
+
+import "context"
+
+// MaxFeedbackExamples 每次分類 / 擷取最多放入的修正範例數
+const MaxFeedbackExamples = 5
+
+// FeedbackSource 提供使用者最近修正過的分析結果（依範本區分分類或擷取的修正）
+type FeedbackSource interface {
+	FeedbackExamples(userID string, name PromptName, limit int) ([]FeedbackExample, error)
+}
+
+// SetFeedbackSource 設定修正範例來源（未設定時不加入範例）
+func (s *Service) SetFeedbackSource(source FeedbackSource) {
+	s.feedback = source
+}
+
+// feedbackExamples 取得 context 所屬使用者的修正範例；失敗時不加入範例，不影響分析
+func (s *Service) feedbackExamples(ctx context.Context, name PromptName) []FeedbackExample {
+	userID := usageScopeFrom(ctx).UserID
+	if s.feedback == nil || userID == "" {
+		return nil
+	}
+	examples, err := s.feedback.FeedbackExamples(userID, name, MaxFeedbackExamples)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("prompt", string(name)).Msg("Failed to load feedback examples")
+		return nil
+	}
+	return examples
+}
@@ -0,0 +1,72 @@
+package openai
+
+import (
+	"strings"
+	"time"
+)
+
+// HeuristicModel 超過額度時以關鍵字分類的結果標記的模型名稱
+const HeuristicModel = "heuristic"
+
+// heuristicConfidence 關鍵字分類的信心（刻意低於自動建立案件的門檻）
+const heuristicConfidence = 0.5
+
+// heuristicRules 依序比對的關鍵字規則（先符合者優先）
+var heuristicRules = []struct {
+	category EmailCategory
+	keywords []string
+}{
+	{CategoryPayment, []string{"付款", "匯款", "發票", "請款", "invoice", "payment", "remittance"}},
+	{CategoryCollaboration, []string{"合作", "邀約", "業配", "代言", "贊助", "collaboration", "sponsor", "partnership", "campaign"}},
+	{CategoryInquiry, []string{"報價", "詢問", "檔期", "quote", "rate card", "availability"}},
+	{CategoryConfirmation, []string{"確認", "confirm"}},
+	{CategoryNewsletter, []string{"取消訂閱", "電子報", "unsubscribe", "newsletter"}},
+}
+
+// HeuristicClassify 不呼叫 AI，以關鍵字簡單分類郵件
+func HeuristicClassify(subject, body, from string) EmailClassification {
+	text := strings.ToLower(subject + "\n" + body)
+	for _, rule := range heuristicRules {
+		for _, keyword := range rule.keywords {
+			if strings.Contains(text, keyword) {
+				return EmailClassification{
+					Category:   rule.category,
+					Confidence: heuristicConfidence,
+					Reason:     "AI 額度已用完，依關鍵字「" + keyword + "」分類",
+				}
+			}
+		}
+	}
+
+	category := CategoryOther
+	sender := strings.ToLower(from)
+	if strings.Contains(sender, "noreply") || strings.Contains(sender, "no-reply") {
+		category = CategoryNotification
+	}
+	return EmailClassification{
+		Category:   category,
+		Confidence: heuristicConfidence,
+		Reason:     "AI 額度已用完，未符合任何關鍵字",
+	}
+}
+
+// heuristicAnalysis 以關鍵字分類產生分析結果（不做資訊擷取）
+func (s *Service) heuristicAnalysis(req AnalyzeEmailRequest) *EmailAnalysisResult {
+	classification := HeuristicClassify(req.Subject, req.Body, req.From)
+	return &EmailAnalysisResult{
+		Classification: classification,
+		ExtractedInfo:  ExtractedInfo{},
+		Summary:        s.generateSummary(&classification, nil),
+		KeyPoints:      []string{},
+		ActionRequired: IsHighPriorityCategory(classification.Category),
+		Priority:       "medium",
+		Model:          HeuristicModel,
+		PromptVersion:  HeuristicModel, // 未使用 prompt
+		AnalyzedAt:     time.Now(),
+	}
+}
+
+// IsHeuristic 是否為超過額度時的關鍵字分類結果（未經 AI 分析，額度恢復後應重新分析）
+func (r *EmailAnalysisResult) IsHeuristic() bool {
+	return r.Model == HeuristicModel
+}
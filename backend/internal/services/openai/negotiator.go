@@ -0,0 +1,31 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+)
+
+// NegotiateRate 依創作者定價與歷史成交紀錄，對品牌提出的預算給出接受、還價或組合方案建議
+func (s *Service) NegotiateRate(ctx context.Context, req RateNegotiationRequest) (*RateRecommendation, error) {
+	s.logger.Info().
+		Str("case_title", req.CaseTitle).
+		Str("baseline", req.BaselineAction).
+		Int("history", req.HistoryCount).
+		Msg("Starting rate negotiation analysis")
+
+	prompt, err := s.renderPrompt(ctx, PromptNegotiate, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result RateRecommendation
+	if err := s.callStructured(ctx, OperationNegotiate, prompt, "recommend_rate", "根據定價與歷史成交紀錄建議如何回應品牌預算", &result); err != nil {
+		return nil, fmt.Errorf("rate negotiation failed: %w", err)
+	}
+
+	// 還價但沒有給金額時採用規則計算的金額
+	if result.Action == RateActionCounter && result.CounterAmount == nil {
+		result.CounterAmount = req.BaselineAmount
+	}
+	return &result, nil
+}
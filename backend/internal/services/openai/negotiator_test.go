@@ -0,0 +1,62 @@
+package openai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+)
+
+func TestNegotiateRate(t *testing.T) {
+	var requests []map[string]interface{}
+	server := newSequenceServer(t, []string{
+		`{"action":"counter","reasoning":"同品牌上次成交 32,000","talking_points":["上次合作成效"],"confidence":0.8}`,
+	}, &requests)
+	defer server.Close()
+
+	cfg := getTestConfig()
+	cfg.LLM = config.LLMConfig{
+		Operations: map[string]string{"negotiate": "compatible"},
+		Compatible: config.CompatibleLLMConfig{BaseURL: server.URL, Model: "gpt-4o-mini"},
+	}
+	service := NewService(cfg, getMockLogger(), "")
+
+	offered, brand, baseline := 25000.0, 32000.0, 32000.0
+	result, err := service.NegotiateRate(context.Background(), RateNegotiationRequest{
+		CaseTitle:      "新品開箱",
+		BrandName:      "Glow",
+		Currency:       "TWD",
+		OfferedAmount:  &offered,
+		PriceItems:     []PriceItem{{ID: "1", Title: "IG Reels", Price: 20000}},
+		ListTotal:      20000,
+		PastDeals:      []PastDeal{{Title: "Glow 舊案", BrandName: "Glow", Amount: 32000, SameBrand: true}},
+		HistoryCount:   1,
+		BrandMedian:    &brand,
+		BaselineAction: RateActionCounter,
+		BaselineAmount: &baseline,
+	})
+	if err != nil {
+		t.Fatalf("NegotiateRate failed: %v", err)
+	}
+	if result.Action != RateActionCounter || result.CounterAmount == nil || *result.CounterAmount != 32000 {
+		t.Errorf("Expected counter with baseline amount, got %+v", result)
+	}
+
+	user := requests[0]["messages"].([]interface{})[1].(map[string]interface{})["content"].(string)
+	for _, want := range []string{"金額：25,000", "IG Reels：20,000", "同品牌成交中位數：32,000", "Glow 舊案（Glow，同品牌）：32,000", "類似案件成交中位數：未知", "建議：counter，還價 32,000"} {
+		if !strings.Contains(user, want) {
+			t.Errorf("Expected %q in prompt: %s", want, user)
+		}
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	amount := 1234567.4
+	tests := map[string]interface{}{"1,234,567": &amount, "0": 0.0, "-1,500": -1500.0, "999": 999, "未知": (*float64)(nil)}
+	for want, in := range tests {
+		if got := formatMoney(in); got != want {
+			t.Errorf("formatMoney(%v) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// PromptName 提示詞範本名稱
+type PromptName string
+
+const (
+	PromptClassify      PromptName = "classify"             // 郵件分類
+	PromptExtract       PromptName = "extract"              // 合作資訊擷取
+	PromptDraft         PromptName = "draft"                // 回信草稿
+	PromptMatchItems    PromptName = "match_items"          // 合作項目比對
+	PromptMatchWorkflow PromptName = "match_workflow"       // 流程範本比對
+	PromptMatchCase     PromptName = "match_case"           // 新郵件與既有案件比對
+	PromptReplyAnalysis PromptName = "reply_analysis"       // 回信後案件更新分析
+	PromptIncomingReply PromptName = "incoming_reply"       // 品牌來信後案件更新分析
+	PromptRefineDraft   PromptName = "refine_draft"         // 依回饋修改草稿
+	PromptNegotiate     PromptName = "negotiate"            // 報價議價建議
+	PromptAttachment    PromptName = "summarize_attachment" // 長附件摘要
+)
+
+// PromptNames 所有可覆寫的範本
+var PromptNames = []PromptName{
+	PromptClassify, PromptExtract, PromptDraft, PromptMatchItems, PromptMatchWorkflow, PromptReplyAnalysis, PromptRefineDraft,
+	PromptNegotiate, PromptAttachment, PromptIncomingReply, PromptMatchCase,
+}
+
+// DefaultPromptVersion 內建範本的初始版本
+const DefaultPromptVersion = "v1"
+
+// builtinPromptVersions 內建範本內容更新過時的版本（未列出者為 DefaultPromptVersion）
+var builtinPromptVersions = map[PromptName]string{
+	PromptClassify:      "v2", // v2 加入使用者修正範例
+	PromptDraft:         "v3", // v2 加入寫作風格與過往回信範例，v3 加入立場與語氣
+	PromptExtract:       "v3", // v2 加入附件內容，v3 加入使用者修正範例
+	PromptReplyAnalysis: "v2", // v2 加入信心度
+}
+
+// BuiltinPromptVersion 內建範本目前的版本
+func BuiltinPromptVersion(name PromptName) string {
+	if v, ok := builtinPromptVersions[name]; ok {
+		return v
+	}
+	return DefaultPromptVersion
+}
+
+// IsBuiltinPromptVersion 版本號是否保留給內建範本（自訂範本不可使用）
+func IsBuiltinPromptVersion(name PromptName, version string) bool {
+	return version == DefaultPromptVersion || version == BuiltinPromptVersion(name)
+}
+
+//go:embed prompts/*.tmpl
+var promptFS embed.FS
+
+// PromptTemplate 提示詞範本（text/template 語法，需定義 system 與 user 兩個區塊）
+type PromptTemplate struct {
+	Name    PromptName
+	Version string
+	Body    string
+}
+
+// Label 範本版本標籤（如 classify@v1），記錄在分析結果與快取鍵中
+func (p *PromptTemplate) Label() string {
+	return string(p.Name) + "@" + p.Version
+}
+
+// PromptSource 依使用者選擇範本（個人覆寫、A/B 分流）；回傳 nil 時使用內建範本
+type PromptSource interface {
+	ResolvePrompt(userID string, name PromptName) (*PromptTemplate, error)
+}
+
+// SetPromptSource 設定範本來源（未設定時一律使用內建範本）
+func (s *Service) SetPromptSource(source PromptSource) {
+	s.prompts = source
+}
+
+// IsPromptName 是否為已知的範本名稱
+func IsPromptName(name string) bool {
+	for _, n := range PromptNames {
+		if string(n) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultPrompt 取得內建範本
+func DefaultPrompt(name PromptName) (*PromptTemplate, error) {
+	body, err := promptFS.ReadFile("prompts/" + string(name) + ".tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("unknown prompt %q", name)
+	}
+	return &PromptTemplate{Name: name, Version: BuiltinPromptVersion(name), Body: string(body)}, nil
+}
+
+// promptFuncs 範本可用的函式
+var promptFuncs = template.FuncMap{
+	"truncate": truncateContent,
+	"join":     strings.Join,
+	"money":    formatMoney,
+}
+
+// formatMoney 金額顯示為千分位整數（nil 顯示為「未知」）
+func formatMoney(v interface{}) string {
+	var f float64
+	switch n := v.(type) {
+	case float64:
+		f = n
+	case *float64:
+		if n == nil {
+			return "未知"
+		}
+		f = *n
+	case int:
+		f = float64(n)
+	default:
+		return fmt.Sprint(v)
+	}
+
+	digits := strconv.FormatFloat(math.Round(math.Abs(f)), 'f', 0, 64)
+	var b strings.Builder
+	if f < 0 {
+		b.WriteByte('-')
+	}
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return b.String()
+}
+
+// parsedPrompts 已解析的範本（以內容雜湊為鍵，範本更新後自動重新解析）
+var parsedPrompts sync.Map
+
+// ParsePrompt 解析範本並確認定義了 system 與 user 區塊
+func ParsePrompt(body string) (*template.Template, error) {
+	sum := sha256.Sum256([]byte(body))
+	key := hex.EncodeToString(sum[:])
+	if cached, ok := parsedPrompts.Load(key); ok {
+		return cached.(*template.Template), nil
+	}
+
+	tmpl, err := template.New("prompt").Funcs(promptFuncs).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range []string{"system", "user"} {
+		if tmpl.Lookup(block) == nil {
+			return nil, fmt.Errorf("template must define %q", block)
+		}
+	}
+	parsedPrompts.Store(key, tmpl)
+	return tmpl, nil
+}
+
+// matchItemsPromptData 合作項目比對範本的資料
+type matchItemsPromptData struct {
+	MatchCollaborationItemsRequest
+	ItemsJSON string // 合作項目清單（JSON）
+}
+
+// matchWorkflowPromptData 流程範本比對範本的資料
+type matchWorkflowPromptData struct {
+	MatchWorkflowTemplateRequest
+	TemplatesJSON string // 流程範本清單（JSON）
+}
+
+// matchCasePromptData 案件比對範本的資料
+type matchCasePromptData struct {
+	MatchCaseRequest
+	CasesJSON string // 候選案件清單（JSON）
+}
+
+// promptSampleData 各範本的資料型別（空值），用於驗證自訂範本引用的欄位
+func promptSampleData(name PromptName) interface{} {
+	switch name {
+	case PromptClassify:
+		return ClassifyEmailRequest{}
+	case PromptExtract:
+		return AnalyzeEmailRequest{}
+	case PromptDraft:
+		return DraftReplyRequest{}
+	case PromptMatchItems:
+		return matchItemsPromptData{}
+	case PromptMatchWorkflow:
+		return matchWorkflowPromptData{}
+	case PromptMatchCase:
+		return matchCasePromptData{}
+	case PromptReplyAnalysis:
+		return ReplyCaseUpdateRequest{}
+	case PromptIncomingReply:
+		return IncomingCaseUpdateRequest{}
+	case PromptRefineDraft:
+		return RefineDraftRequest{}
+	case PromptNegotiate:
+		return RateNegotiationRequest{}
+	case PromptAttachment:
+		return AttachmentSummaryRequest{}
+	default:
+		return nil
+	}
+}
+
+// ValidatePrompt 確認自訂範本可解析，且只引用該範本資料中存在的欄位
+func ValidatePrompt(name PromptName, body string) error {
+	if !IsPromptName(string(name)) {
+		return fmt.Errorf("unknown prompt %q", name)
+	}
+	_, err := executePrompt(&PromptTemplate{Name: name, Version: "candidate", Body: body}, promptSampleData(name))
+	return err
+}
+
+// renderedPrompt 套用資料後的訊息與使用的範本版本
+type renderedPrompt struct {
+	Version  string
+	Messages []ChatMessage
+}
+
+// renderPrompt 依 context 的使用者選擇範本並套用資料；自訂範本無法使用時退回內建範本
+func (s *Service) renderPrompt(ctx context.Context, name PromptName, data interface{}) (*renderedPrompt, error) {
+	if s.prompts != nil {
+		userID := usageScopeFrom(ctx).UserID
+		custom, err := s.prompts.ResolvePrompt(userID, name)
+		if err != nil {
+			s.logger.Warn().Err(err).Str("prompt", string(name)).Msg("Failed to resolve prompt template, using default")
+		} else if custom != nil {
+			rendered, err := executePrompt(custom, data)
+			if err == nil {
+				return rendered, nil
+			}
+			s.logger.Warn().Err(err).Str("prompt", custom.Label()).Msg("Failed to render prompt template, using default")
+		}
+	}
+
+	def, err := DefaultPrompt(name)
+	if err != nil {
+		return nil, err
+	}
+	return executePrompt(def, data)
+}
+
+// executePrompt 套用資料產生 system 與 user 訊息
+func executePrompt(p *PromptTemplate, data interface{}) (*renderedPrompt, error) {
+	tmpl, err := ParsePrompt(p.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt %s: %w", p.Label(), err)
+	}
+
+	var system, user bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&system, "system", data); err != nil {
+		return nil, fmt.Errorf("render prompt %s: %w", p.Label(), err)
+	}
+	if err := tmpl.ExecuteTemplate(&user, "user", data); err != nil {
+		return nil, fmt.Errorf("render prompt %s: %w", p.Label(), err)
+	}
+
+	return &renderedPrompt{
+		Version: p.Label(),
+		Messages: []ChatMessage{
+			{Role: RoleSystem, Content: system.String()},
+			{Role: RoleUser, Content: user.String()},
+		},
+	}, nil
+}
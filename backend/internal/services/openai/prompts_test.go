@@ -0,0 +1,240 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// staticPromptSource 固定回傳指定範本
+type staticPromptSource struct {
+	template *PromptTemplate
+	err      error
+	userIDs  []string
+}
+
+func (s *staticPromptSource) ResolvePrompt(userID string, name PromptName) (*PromptTemplate, error) {
+	s.userIDs = append(s.userIDs, userID)
+	if s.template == nil || s.template.Name != name {
+		return nil, s.err
+	}
+	return s.template, s.err
+}
+
+func TestDefaultPrompts_RenderWithSampleData(t *testing.T) {
+	for _, name := range PromptNames {
+		def, err := DefaultPrompt(name)
+		if err != nil {
+			t.Fatalf("DefaultPrompt(%s) failed: %v", name, err)
+		}
+		if err := ValidatePrompt(name, def.Body); err != nil {
+			t.Errorf("Built-in prompt %s is invalid: %v", name, err)
+		}
+		if def.Label() != string(name)+"@"+BuiltinPromptVersion(name) {
+			t.Errorf("Unexpected label %s", def.Label())
+		}
+	}
+}
+
+func TestValidatePrompt(t *testing.T) {
+	tests := []struct {
+		name    string
+		prompt  PromptName
+		body    string
+		wantErr bool
+	}{
+		{"valid", PromptClassify, `{{define "system"}}分類{{end}}{{define "user"}}{{.Subject}} {{truncate .Body 10}}{{end}}`, false},
+		{"unknown field", PromptClassify, `{{define "system"}}分類{{end}}{{define "user"}}{{.CaseTitle}}{{end}}`, true},
+		{"missing user block", PromptDraft, `{{define "system"}}草稿{{end}}`, true},
+		{"syntax error", PromptDraft, `{{define "system"}}{{if}}{{end}}`, true},
+		{"unknown prompt", PromptName("summary"), `{{define "system"}}{{end}}{{define "user"}}{{end}}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePrompt(tt.prompt, tt.body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePrompt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderPrompt_DraftStyleSections(t *testing.T) {
+	service := NewService(getTestConfig(), getMockLogger(), "")
+	req := DraftReplyRequest{CaseTitle: "開箱合作", EmailSubject: "邀約", EmailBody: "想邀請您合作"}
+
+	plain, err := service.renderPrompt(context.Background(), PromptDraft, req)
+	if err != nil {
+		t.Fatalf("renderPrompt failed: %v", err)
+	}
+	if plain.Version != "draft@v3" || strings.Contains(plain.Messages[0].Content, "寫作風格") || strings.Contains(plain.Messages[1].Content, "過去在類似情境") {
+		t.Fatalf("Unexpected render without style: %+v", plain)
+	}
+
+	req.StyleGuide = "- 常用開頭：「Hi {name},」"
+	req.StyleExamples = []StyleExample{{Subject: "舊邀約", Incoming: "想合作", Reply: "Hi Amy, 報價是 3 萬"}}
+	styled, err := service.renderPrompt(context.Background(), PromptDraft, req)
+	if err != nil {
+		t.Fatalf("renderPrompt failed: %v", err)
+	}
+	if !strings.Contains(styled.Messages[0].Content, "「Hi {name},」") {
+		t.Errorf("Style guide missing from system prompt: %s", styled.Messages[0].Content)
+	}
+	for _, want := range []string{"來信主旨：舊邀約", "來信摘要：想合作", "報價是 3 萬"} {
+		if !strings.Contains(styled.Messages[1].Content, want) {
+			t.Errorf("Expected %q in user prompt: %s", want, styled.Messages[1].Content)
+		}
+	}
+}
+
+func TestRenderPrompt_ExtractAttachments(t *testing.T) {
+	service := NewService(getTestConfig(), getMockLogger(), "")
+	req := AnalyzeEmailRequest{Subject: "合作邀約", Body: "詳見附件", Attachments: []AttachmentContent{
+		{Filename: "brief.pdf", Text: "預算 NT$50,000，3 支 Reels"},
+		{Filename: "contract.docx", Text: "- 授權 6 個月", Summarized: true},
+	}}
+
+	rendered, err := service.renderPrompt(context.Background(), PromptExtract, req)
+	if err != nil {
+		t.Fatalf("renderPrompt failed: %v", err)
+	}
+	user := rendered.Messages[1].Content
+	if rendered.Version != "extract@v3" || !strings.Contains(user, "附件「brief.pdf」**:\n預算 NT$50,000") ||
+		!strings.Contains(user, "附件「contract.docx」（長文件摘要）") {
+		t.Fatalf("Unexpected render with attachments: %s", user)
+	}
+
+	summary, err := service.renderPrompt(context.Background(), PromptAttachment, AttachmentSummaryRequest{
+		Filename: "brief.pdf", EmailSubject: "合作邀約", Summaries: []string{"第一段重點", "第二段重點"},
+	})
+	if err != nil {
+		t.Fatalf("renderPrompt failed: %v", err)
+	}
+	if !strings.Contains(summary.Messages[1].Content, "分段摘要") || !strings.Contains(summary.Messages[1].Content, "第二段重點") {
+		t.Fatalf("Unexpected merge prompt: %s", summary.Messages[1].Content)
+	}
+}
+
+// staticFeedbackSource 固定回傳修正範例並記錄查詢
+type staticFeedbackSource struct {
+	examples []FeedbackExample
+	queries  []string
+}
+
+func (s *staticFeedbackSource) FeedbackExamples(userID string, name PromptName, limit int) ([]FeedbackExample, error) {
+	s.queries = append(s.queries, userID+":"+string(name))
+	return s.examples, nil
+}
+
+func TestRenderPrompt_FeedbackExamples(t *testing.T) {
+	service := NewService(getTestConfig(), getMockLogger(), "")
+	example := FeedbackExample{
+		Subject: "Glow 新品體驗", From: "amy@glow.example", Snippet: "想寄送新品給您試用",
+		Predicted: CategoryNewsletter, Category: CategoryCollaboration,
+		Corrections: []FieldCorrection{
+			{Field: "brand_name", Predicted: "Glow Ltd", Corrected: "Glow 台灣"},
+			{Field: "amount", Predicted: "", Corrected: "30000"},
+		},
+	}
+
+	plain, err := service.renderPrompt(context.Background(), PromptClassify, ClassifyEmailRequest{Subject: "邀約"})
+	if err != nil || strings.Contains(plain.Messages[0].Content, "修正過") {
+		t.Fatalf("Unexpected render without examples: %+v, %v", plain, err)
+	}
+
+	classify, err := service.renderPrompt(context.Background(), PromptClassify, ClassifyEmailRequest{Subject: "邀約", Examples: []FeedbackExample{example}})
+	if err != nil {
+		t.Fatalf("renderPrompt failed: %v", err)
+	}
+	if !strings.Contains(classify.Messages[0].Content, "AI 判斷：newsletter → 使用者修正：collaboration") {
+		t.Errorf("Classification example missing: %s", classify.Messages[0].Content)
+	}
+
+	extract, err := service.renderPrompt(context.Background(), PromptExtract, AnalyzeEmailRequest{Subject: "邀約", Examples: []FeedbackExample{example}})
+	if err != nil {
+		t.Fatalf("renderPrompt failed: %v", err)
+	}
+	for _, want := range []string{"brand_name：「Glow Ltd」 → 「Glow 台灣」", "amount：（未擷取） → 「30000」"} {
+		if !strings.Contains(extract.Messages[0].Content, want) {
+			t.Errorf("Expected %q in extract prompt: %s", want, extract.Messages[0].Content)
+		}
+	}
+
+	// 範例依 context 使用者查詢；沒有使用者歸屬時不查詢
+	source := &staticFeedbackSource{examples: []FeedbackExample{example}}
+	service.SetFeedbackSource(source)
+	if got := service.feedbackExamples(context.Background(), PromptClassify); got != nil {
+		t.Errorf("Expected no examples without user scope, got %v", got)
+	}
+	got := service.feedbackExamples(WithUsageScope(context.Background(), "user-1", ""), PromptExtract)
+	if len(got) != 1 || len(source.queries) != 1 || source.queries[0] != "user-1:extract" {
+		t.Errorf("Unexpected examples %v for queries %v", got, source.queries)
+	}
+}
+
+func TestRenderPrompt_UsesSourceAndFallsBack(t *testing.T) {
+	service := NewService(getTestConfig(), getMockLogger(), "")
+	ctx := WithUsageScope(context.Background(), "user-1", "")
+	req := ClassifyEmailRequest{From: "pm@brand.example", Subject: "合作邀約", Body: "想邀請您合作"}
+
+	// 未設定來源時使用內建範本
+	rendered, err := service.renderPrompt(ctx, PromptClassify, req)
+	if err != nil || rendered.Version != "classify@v2" || !strings.Contains(rendered.Messages[1].Content, "合作邀約") {
+		t.Fatalf("Unexpected default render: %+v, %v", rendered, err)
+	}
+
+	source := &staticPromptSource{template: &PromptTemplate{
+		Name:    PromptClassify,
+		Version: "v10",
+		Body:    `{{define "system"}}新版分類{{end}}{{define "user"}}主旨：{{.Subject}}{{end}}`,
+	}}
+	service.SetPromptSource(source)
+	rendered, err = service.renderPrompt(ctx, PromptClassify, req)
+	if err != nil || rendered.Version != "classify@v10" || rendered.Messages[0].Content != "新版分類" || rendered.Messages[1].Content != "主旨：合作邀約" {
+		t.Fatalf("Unexpected custom render: %+v, %v", rendered, err)
+	}
+	if len(source.userIDs) != 1 || source.userIDs[0] != "user-1" {
+		t.Errorf("Expected source to be resolved for user-1, got %v", source.userIDs)
+	}
+
+	// 自訂範本執行失敗時退回內建範本
+	source.template.Body = `{{define "system"}}x{{end}}{{define "user"}}{{.Missing}}{{end}}`
+	rendered, err = service.renderPrompt(ctx, PromptClassify, req)
+	if err != nil || rendered.Version != "classify@v2" {
+		t.Errorf("Expected fallback to built-in prompt, got %+v, %v", rendered, err)
+	}
+
+	// 來源錯誤時也使用內建範本
+	service.SetPromptSource(&staticPromptSource{err: errors.New("db down")})
+	rendered, err = service.renderPrompt(ctx, PromptDraft, DraftReplyRequest{CaseTitle: "案件"})
+	if err != nil || rendered.Version != "draft@v3" {
+		t.Errorf("Expected built-in draft prompt, got %+v, %v", rendered, err)
+	}
+}
+
+func TestAnalyzeEmail_RecordsPromptVersions(t *testing.T) {
+	var requests []map[string]interface{}
+	server := newSequenceServer(t, []string{
+		`{"category":"collaboration","confidence":0.9,"reason":"邀約"}`,
+		`{"category":"collaboration","confidence":0.9,"reason":"邀約"}`,
+	}, &requests)
+	defer server.Close()
+
+	service := newStructuredTestService(server.URL)
+	service.llm.DefaultProvider = "compatible"
+	service.SetPromptSource(&staticPromptSource{template: &PromptTemplate{
+		Name:    PromptClassify,
+		Version: "v10",
+		Body:    `{{define "system"}}新版分類{{end}}{{define "user"}}{{.Subject}}{{end}}`,
+	}})
+
+	result, err := service.AnalyzeEmail(context.Background(), AnalyzeEmailRequest{Subject: "合作邀約", Body: "想邀請您合作"})
+	if err != nil {
+		t.Fatalf("AnalyzeEmail failed: %v", err)
+	}
+	if result.PromptVersion != "classify@v10+extract@v3" {
+		t.Errorf("Expected combined prompt version, got %q", result.PromptVersion)
+	}
+}
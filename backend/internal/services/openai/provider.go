@@ -0,0 +1,130 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+)
+
+// 訊息角色
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// ChatMessage 對話訊息
+type ChatMessage struct {
+	Role    string
+	Content string
+}
+
+// ToolDefinition 結構化輸出的定義（模型需以符合 Parameters JSON Schema 的參數呼叫）
+type ToolDefinition struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage
+}
+
+// ChatRequest 後端無關的對話請求
+type ChatRequest struct {
+	Messages  []ChatMessage
+	MaxTokens int
+	Tool      *ToolDefinition // 非 nil 時要求模型以此工具回傳結構化結果
+}
+
+// ChatResponse 後端無關的對話回應
+type ChatResponse struct {
+	Content          string // 一般文字回覆
+	ToolArguments    string // 結構化結果（JSON）；模型未呼叫工具時為空
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// TotalTokens 總 tokens
+func (r *ChatResponse) TotalTokens() int {
+	return r.PromptTokens + r.CompletionTokens
+}
+
+// Arguments 結構化結果；模型未呼叫工具時以文字內容代替
+func (r *ChatResponse) Arguments() string {
+	if r.ToolArguments != "" {
+		return r.ToolArguments
+	}
+	return r.Content
+}
+
+// LLMProvider 模型後端（對話與結構化輸出）
+type LLMProvider interface {
+	Name() string
+	Model() string
+	Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error)
+}
+
+// DeltaFunc 收到模型逐段產生的文字時呼叫；回傳錯誤時中止串流
+type DeltaFunc func(delta string) error
+
+// StreamingProvider 支援逐段回傳文字的後端（不支援時以一次性回覆代替）；
+// 串流開始後中斷時，同時回傳錯誤與已收到的內容、用量（用於計費）
+type StreamingProvider interface {
+	ChatStream(ctx context.Context, req ChatRequest, onDelta DeltaFunc) (*ChatResponse, error)
+}
+
+// newProviders 依設定建立可用的後端；userAPIKey 只套用在 OpenAI
+func newProviders(cfg config.Config, userAPIKey string) map[string]LLMProvider {
+	apiKey := cfg.OpenAI.APIKey
+	if userAPIKey != "" {
+		apiKey = userAPIKey
+	}
+
+	providers := map[string]LLMProvider{
+		config.LLMProviderOpenAI: newOpenAIProvider(config.LLMProviderOpenAI, apiKey, "", cfg.OpenAI.Model),
+	}
+	if cfg.LLM.Anthropic.APIKey != "" {
+		providers[config.LLMProviderAnthropic] = newAnthropicProvider(cfg.LLM.Anthropic)
+	}
+	if cfg.LLM.Compatible.BaseURL != "" {
+		providers[config.LLMProviderCompatible] = newOpenAIProvider(config.LLMProviderCompatible,
+			cfg.LLM.Compatible.APIKey, cfg.LLM.Compatible.BaseURL, cfg.LLM.Compatible.Model)
+	}
+	return providers
+}
+
+// WrapProviders 以 wrap 包裝所有已設定的後端（評估工具用來錄製與重播回應）
+func (s *Service) WrapProviders(wrap func(LLMProvider) LLMProvider) {
+	for name, provider := range s.providers {
+		s.providers[name] = wrap(provider)
+	}
+}
+
+// providerFor 取得用途使用的後端
+func (s *Service) providerFor(operation Operation) (LLMProvider, error) {
+	name := s.llm.ProviderFor(string(operation))
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("LLM provider %q is not configured", name)
+	}
+	return provider, nil
+}
+
+// ModelFor 取得用途實際使用的模型
+func (s *Service) ModelFor(operation Operation) string {
+	provider, err := s.providerFor(operation)
+	if err != nil {
+		return s.config.Model
+	}
+	return provider.Model()
+}
+
+// Configured 用途使用的後端是否已設定（OpenAI 需有 API Key）
+func (s *Service) Configured(operation Operation) bool {
+	name := s.llm.ProviderFor(string(operation))
+	if name == config.LLMProviderOpenAI {
+		return s.config.APIKey != "" || s.userAPIKey != ""
+	}
+	_, ok := s.providers[name]
+	return ok
+}
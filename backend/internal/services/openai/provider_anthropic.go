@@ -0,0 +1,271 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+)
+
+// anthropicVersion Messages API 版本
+const anthropicVersion = "2023-06-01"
+
+// anthropicProvider Anthropic Messages API 後端
+type anthropicProvider struct {
+	apiKey  string
+	baseURL string
+	model   string
+	http    *http.Client
+}
+
+func newAnthropicProvider(cfg config.AnthropicConfig) *anthropicProvider {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com"
+	}
+	return &anthropicProvider{
+		apiKey:  cfg.APIKey,
+		baseURL: baseURL,
+		model:   cfg.Model,
+		http:    &http.Client{Timeout: 120 * time.Second},
+	}
+}
+
+// Name 後端名稱
+func (p *anthropicProvider) Name() string {
+	return config.LLMProviderAnthropic
+}
+
+// Model 使用的模型
+func (p *anthropicProvider) Model() string {
+	return p.model
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model      string             `json:"model"`
+	MaxTokens  int                `json:"max_tokens"`
+	System     string             `json:"system,omitempty"`
+	Messages   []anthropicMessage `json:"messages"`
+	Tools      []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice map[string]string  `json:"tool_choice,omitempty"`
+	Stream     bool               `json:"stream,omitempty"`
+}
+
+type anthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Input json.RawMessage `json:"input"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// newRequest 建立 Messages API 請求；system 訊息改放在 system 欄位，結構化輸出以強制呼叫工具取得
+func (p *anthropicProvider) newRequest(ctx context.Context, req ChatRequest, stream bool) (*http.Request, error) {
+	areq := anthropicRequest{Model: p.model, MaxTokens: req.MaxTokens, Stream: stream}
+	if areq.MaxTokens <= 0 {
+		areq.MaxTokens = 1024
+	}
+	var system []string
+	for _, m := range req.Messages {
+		if m.Role == RoleSystem {
+			system = append(system, m.Content)
+			continue
+		}
+		areq.Messages = append(areq.Messages, anthropicMessage{Role: m.Role, Content: m.Content})
+	}
+	areq.System = strings.Join(system, "\n\n")
+	if req.Tool != nil {
+		areq.Tools = []anthropicTool{{Name: req.Tool.Name, Description: req.Tool.Description, InputSchema: req.Tool.Parameters}}
+		areq.ToolChoice = map[string]string{"type": "tool", "name": req.Tool.Name}
+	}
+
+	body, err := json.Marshal(areq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode anthropic request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", p.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+	return httpReq, nil
+}
+
+// Chat 呼叫 Messages API
+func (p *anthropicProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	httpReq, err := p.newRequest(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.http.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read anthropic response: %w", err)
+	}
+	var aresp anthropicResponse
+	if err := json.Unmarshal(raw, &aresp); err != nil {
+		return nil, fmt.Errorf("invalid anthropic response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || aresp.Error != nil {
+		if aresp.Error != nil {
+			return nil, fmt.Errorf("anthropic error (status %d): %s: %s", resp.StatusCode, aresp.Error.Type, aresp.Error.Message)
+		}
+		return nil, fmt.Errorf("anthropic error (status %d)", resp.StatusCode)
+	}
+
+	result := &ChatResponse{
+		Model:            aresp.Model,
+		PromptTokens:     aresp.Usage.InputTokens,
+		CompletionTokens: aresp.Usage.OutputTokens,
+	}
+	var text []string
+	for _, block := range aresp.Content {
+		switch block.Type {
+		case "text":
+			text = append(text, block.Text)
+		case "tool_use":
+			if result.ToolArguments == "" {
+				result.ToolArguments = string(block.Input)
+			}
+		}
+	}
+	result.Content = strings.Join(text, "")
+	if result.Content == "" && result.ToolArguments == "" {
+		return nil, fmt.Errorf("no response from anthropic")
+	}
+	if result.Model == "" {
+		result.Model = p.model
+	}
+	return result, nil
+}
+
+// anthropicStreamEvent 串流事件（只取用到的欄位）
+type anthropicStreamEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		Model string `json:"model"`
+		Usage struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Usage struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// ChatStream 以串流模式呼叫 Messages API（只用於一般文字回覆）
+func (p *anthropicProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta DeltaFunc) (*ChatResponse, error) {
+	httpReq, err := p.newRequest(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := p.http.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		var aresp anthropicResponse
+		if json.Unmarshal(raw, &aresp) == nil && aresp.Error != nil {
+			return nil, fmt.Errorf("anthropic error (status %d): %s: %s", resp.StatusCode, aresp.Error.Type, aresp.Error.Message)
+		}
+		return nil, fmt.Errorf("anthropic error (status %d)", resp.StatusCode)
+	}
+
+	result := &ChatResponse{Model: p.model}
+	var content strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+			continue
+		}
+
+		switch event.Type {
+		case "message_start":
+			if event.Message.Model != "" {
+				result.Model = event.Message.Model
+			}
+			result.PromptTokens = event.Message.Usage.InputTokens
+			result.CompletionTokens = event.Message.Usage.OutputTokens
+		case "content_block_delta":
+			if event.Delta.Type != "text_delta" || event.Delta.Text == "" {
+				continue
+			}
+			content.WriteString(event.Delta.Text)
+			if err := onDelta(event.Delta.Text); err != nil {
+				result.Content = content.String()
+				return result, err
+			}
+		case "message_delta":
+			result.CompletionTokens = event.Usage.OutputTokens
+		case "error":
+			result.Content = content.String()
+			if event.Error != nil {
+				return result, fmt.Errorf("anthropic stream error: %s: %s", event.Error.Type, event.Error.Message)
+			}
+			return result, fmt.Errorf("anthropic stream error")
+		}
+	}
+	result.Content = content.String()
+	if err := scanner.Err(); err != nil {
+		return result, err
+	}
+	if result.Content == "" {
+		return result, fmt.Errorf("no response from anthropic")
+	}
+	return result, nil
+}
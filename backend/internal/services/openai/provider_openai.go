@@ -0,0 +1,134 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// openAIProvider OpenAI Chat Completions 後端；設定 baseURL 時用於 OpenAI 相容的自架服務（Ollama、vLLM）
+type openAIProvider struct {
+	name   string
+	client *openai.Client
+	model  string
+}
+
+func newOpenAIProvider(name, apiKey, baseURL, model string) *openAIProvider {
+	cfg := openai.DefaultConfig(apiKey)
+	if baseURL != "" {
+		cfg.BaseURL = baseURL
+	}
+	return &openAIProvider{name: name, client: openai.NewClientWithConfig(cfg), model: model}
+}
+
+// Name 後端名稱
+func (p *openAIProvider) Name() string {
+	return p.name
+}
+
+// Model 使用的模型
+func (p *openAIProvider) Model() string {
+	return p.model
+}
+
+// Chat 呼叫 Chat Completions；結構化輸出一律以 tools 並指定 tool_choice
+func (p *openAIProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	messages := make([]openai.ChatCompletionMessage, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		messages = append(messages, openai.ChatCompletionMessage{Role: m.Role, Content: m.Content})
+	}
+
+	creq := openai.ChatCompletionRequest{
+		Model:     p.model,
+		Messages:  messages,
+		MaxTokens: req.MaxTokens,
+	}
+	if req.Tool != nil {
+		def := openai.FunctionDefinition{
+			Name:        req.Tool.Name,
+			Description: req.Tool.Description,
+			Parameters:  req.Tool.Parameters,
+		}
+		creq.Tools = []openai.Tool{{Type: openai.ToolTypeFunction, Function: &def}}
+		creq.ToolChoice = openai.ToolChoice{Type: openai.ToolTypeFunction, Function: openai.ToolFunction{Name: def.Name}}
+	}
+
+	resp, err := p.client.CreateChatCompletion(ctx, creq)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from %s", p.name)
+	}
+
+	msg := resp.Choices[0].Message
+	result := &ChatResponse{
+		Content:          msg.Content,
+		Model:            resp.Model,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	}
+	if len(msg.ToolCalls) > 0 {
+		result.ToolArguments = msg.ToolCalls[0].Function.Arguments
+	}
+	if result.Model == "" {
+		result.Model = p.model
+	}
+	return result, nil
+}
+
+// ChatStream 以串流模式呼叫 Chat Completions（只用於一般文字回覆），最後一個 chunk 帶有用量
+func (p *openAIProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta DeltaFunc) (*ChatResponse, error) {
+	messages := make([]openai.ChatCompletionMessage, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		messages = append(messages, openai.ChatCompletionMessage{Role: m.Role, Content: m.Content})
+	}
+
+	stream, err := p.client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
+		Model:         p.model,
+		Messages:      messages,
+		MaxTokens:     req.MaxTokens,
+		Stream:        true,
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	result := &ChatResponse{Model: p.model}
+	var content strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			result.Content = content.String()
+			return result, err
+		}
+		if chunk.Model != "" {
+			result.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			result.PromptTokens = chunk.Usage.PromptTokens
+			result.CompletionTokens = chunk.Usage.CompletionTokens
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		delta := chunk.Choices[0].Delta.Content
+		content.WriteString(delta)
+		if err := onDelta(delta); err != nil {
+			result.Content = content.String()
+			return result, err
+		}
+	}
+
+	result.Content = content.String()
+	return result, nil
+}
@@ -0,0 +1,116 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+)
+
+// recordingRecorder 保存寫入的用量
+type recordingRecorder struct {
+	usages []TokenUsage
+}
+
+func (r *recordingRecorder) RecordUsage(usage TokenUsage) error {
+	r.usages = append(r.usages, usage)
+	return nil
+}
+
+func TestAnthropicProvider_ToolUse(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("Unexpected request: %s %v", r.URL.Path, r.Header)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"model":"claude-3-5-haiku-20241022","content":[{"type":"tool_use","name":"classify_email","input":{"category":"collaboration","confidence":0.9,"reason":"邀約"}}],"usage":{"input_tokens":120,"output_tokens":30}}`))
+	}))
+	defer server.Close()
+
+	cfg := getTestConfig()
+	cfg.LLM = config.LLMConfig{
+		Operations: map[string]string{"classify": "anthropic"},
+		Anthropic:  config.AnthropicConfig{APIKey: "test-key", Model: "claude-3-5-haiku-latest", BaseURL: server.URL},
+	}
+	service := NewService(cfg, getMockLogger(), "")
+	recorder := &recordingRecorder{}
+	service.SetUsageRecorder(recorder)
+
+	result, err := service.ClassifyEmail(context.Background(), ClassifyEmailRequest{Subject: "合作邀約", Body: "想邀請您合作", From: "pm@brand.example"})
+	if err != nil {
+		t.Fatalf("ClassifyEmail failed: %v", err)
+	}
+	if result.Category != CategoryCollaboration || result.Confidence != 0.9 {
+		t.Errorf("Unexpected classification: %+v", result)
+	}
+
+	// system prompt 放在 system 欄位，並強制呼叫工具
+	if got["system"] == "" || got["model"] != "claude-3-5-haiku-latest" {
+		t.Errorf("Unexpected request body: %v", got)
+	}
+	if choice, _ := got["tool_choice"].(map[string]interface{}); choice["name"] != "classify_email" {
+		t.Errorf("Expected forced tool choice, got %v", got["tool_choice"])
+	}
+	if messages, _ := got["messages"].([]interface{}); len(messages) != 1 {
+		t.Errorf("Expected only the user message, got %v", got["messages"])
+	}
+
+	if len(recorder.usages) != 1 || recorder.usages[0].PromptTokens != 120 || recorder.usages[0].CostUSD <= 0 {
+		t.Errorf("Unexpected usage: %+v", recorder.usages)
+	}
+}
+
+func TestCompatibleProvider_RoutedPerOperation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req["model"] != "llama3.1" || req["tools"] == nil || req["functions"] != nil {
+			t.Errorf("Expected tools request for local model, got %v", req)
+		}
+		w.Write([]byte(`{"model":"llama3.1","choices":[{"message":{"role":"assistant","tool_calls":[{"id":"1","type":"function","function":{"name":"classify_email","arguments":"{\"category\":\"payment\",\"confidence\":0.8,\"reason\":\"發票\"}"}}]}}],"usage":{"prompt_tokens":50,"completion_tokens":10,"total_tokens":60}}`))
+	}))
+	defer server.Close()
+
+	cfg := getTestConfig()
+	cfg.LLM = config.LLMConfig{
+		Operations: map[string]string{"classify": "compatible"},
+		Compatible: config.CompatibleLLMConfig{BaseURL: server.URL, Model: "llama3.1"},
+	}
+	service := NewService(cfg, getMockLogger(), "")
+	recorder := &recordingRecorder{}
+	service.SetUsageRecorder(recorder)
+
+	if service.ModelFor(OperationClassify) != "llama3.1" || service.ModelFor(OperationDraft) != "gpt-4o-mini" {
+		t.Errorf("Unexpected model routing: %s / %s", service.ModelFor(OperationClassify), service.ModelFor(OperationDraft))
+	}
+
+	result, err := service.ClassifyEmail(context.Background(), ClassifyEmailRequest{Subject: "發票", Body: "附上發票", From: "billing@brand.example"})
+	if err != nil {
+		t.Fatalf("ClassifyEmail failed: %v", err)
+	}
+	if result.Category != CategoryPayment {
+		t.Errorf("Expected payment, got %s", result.Category)
+	}
+
+	// 自架模型未設定價格時不計成本
+	if len(recorder.usages) != 1 || recorder.usages[0].TotalTokens != 60 || recorder.usages[0].CostUSD != 0 {
+		t.Errorf("Unexpected usage: %+v", recorder.usages)
+	}
+}
+
+func TestProviderFor_NotConfigured(t *testing.T) {
+	cfg := getTestConfig()
+	cfg.LLM = config.LLMConfig{Operations: map[string]string{"draft": "anthropic"}}
+	service := NewService(cfg, getMockLogger(), "")
+
+	if service.Configured(OperationDraft) {
+		t.Error("Expected anthropic provider to be unconfigured")
+	}
+	if _, err := service.DraftReply(context.Background(), DraftReplyRequest{CaseTitle: "案件"}); err == nil {
+		t.Error("Expected error for unconfigured provider")
+	}
+}
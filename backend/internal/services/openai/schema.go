@@ -0,0 +1,227 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schema JSON Schema 子集合，由結果型別的 struct tag 產生，同時用於工具定義與回應驗證
+//
+// 支援的 tag：
+//
+//	desc:"欄位說明"
+//	schema:"required,enum=a|b,min=0,max=1,format=date,default=TWD"
+type Schema struct {
+	Type        string             `json:"type"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+	Format      string             `json:"format,omitempty"` // date（YYYY-MM-DD）或 date-time（RFC 3339）
+	Default     string             `json:"default,omitempty"`
+}
+
+var (
+	schemaCache sync.Map // reflect.Type → *Schema
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// SchemaOf 產生結果型別的 schema（v 為值或指標）
+func SchemaOf(v interface{}) *Schema {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if cached, ok := schemaCache.Load(t); ok {
+		return cached.(*Schema)
+	}
+	schema := schemaForType(t)
+	schemaCache.Store(t, schema)
+	return schema
+}
+
+// JSON 序列化為工具參數
+func (s *Schema) JSON() json.RawMessage {
+	raw, _ := json.Marshal(s)
+	return raw
+}
+
+func schemaForType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaForType(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+func structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Tag.Get("schema") == "-" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := schemaForType(field.Type)
+		prop.Description = field.Tag.Get("desc")
+		for _, opt := range strings.Split(field.Tag.Get("schema"), ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(opt), "=")
+			switch key {
+			case "required":
+				schema.Required = append(schema.Required, name)
+			case "enum":
+				prop.Enum = strings.Split(value, "|")
+			case "min":
+				if f, err := strconv.ParseFloat(value, 64); err == nil {
+					prop.Minimum = &f
+				}
+			case "max":
+				if f, err := strconv.ParseFloat(value, 64); err == nil {
+					prop.Maximum = &f
+				}
+			case "format":
+				prop.Format = value
+			case "default":
+				prop.Default = value
+			}
+		}
+		schema.Properties[name] = prop
+	}
+	return schema
+}
+
+// Validate 驗證 JSON 解碼後的值，回傳所有不符合的項目（空表示通過）
+// 非必填欄位為 null 或空字串時視為未提供
+func (s *Schema) Validate(value interface{}) []string {
+	var problems []string
+	s.validate("$", value, &problems)
+	return problems
+}
+
+func (s *Schema) validate(path string, value interface{}, problems *[]string) {
+	add := func(format string, args ...interface{}) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			add("expected object")
+			return
+		}
+		for _, name := range s.Required {
+			if v, exists := obj[name]; !exists || v == nil {
+				add("missing required field %q", name)
+			}
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, known := s.Properties[name]
+			if !known {
+				continue
+			}
+			v := obj[name]
+			if v == nil || (v == "" && !inList(s.Required, name)) {
+				continue
+			}
+			prop.validate(path+"."+name, v, problems)
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			add("expected array")
+			return
+		}
+		for i, item := range arr {
+			s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, problems)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			add("expected string")
+			return
+		}
+		if len(s.Enum) > 0 && !inList(s.Enum, str) {
+			add("%q is not one of %s", str, strings.Join(s.Enum, ", "))
+		}
+		switch s.Format {
+		case "date":
+			if _, err := time.Parse("2006-01-02", str); err != nil {
+				add("%q is not a date (YYYY-MM-DD)", str)
+			}
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				add("%q is not an RFC 3339 date-time", str)
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			add("expected boolean")
+		}
+	case "number", "integer":
+		num, ok := value.(float64)
+		if !ok {
+			add("expected %s", s.Type)
+			return
+		}
+		if s.Type == "integer" && num != float64(int64(num)) {
+			add("expected integer")
+		}
+		if s.Minimum != nil && num < *s.Minimum {
+			add("%v is less than %v", num, *s.Minimum)
+		}
+		if s.Maximum != nil && num > *s.Maximum {
+			add("%v is greater than %v", num, *s.Maximum)
+		}
+	}
+}
+
+// inList 字串是否在清單中
+func inList(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
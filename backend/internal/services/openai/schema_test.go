@@ -0,0 +1,78 @@
+package openai
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSchemaOf_EmailClassification(t *testing.T) {
+	schema := SchemaOf(&EmailClassification{})
+
+	if schema.Type != "object" || !inList(schema.Required, "category") || !inList(schema.Required, "reason") {
+		t.Fatalf("Unexpected schema: %+v", schema)
+	}
+	category := schema.Properties["category"]
+	if category == nil || !inList(category.Enum, string(CategoryCollaboration)) || category.Description == "" {
+		t.Errorf("Expected category enum with description, got %+v", category)
+	}
+	confidence := schema.Properties["confidence"]
+	if confidence.Type != "number" || *confidence.Minimum != 0 || *confidence.Maximum != 1 {
+		t.Errorf("Unexpected confidence schema: %+v", confidence)
+	}
+
+	// 產生的 JSON 可直接作為工具參數
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(schema.JSON(), &decoded); err != nil || decoded["type"] != "object" {
+		t.Errorf("Invalid schema JSON: %s", schema.JSON())
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema := SchemaOf(ExtractedInfo{})
+
+	tests := []struct {
+		name    string
+		payload string
+		problem string // 空字串表示應通過
+	}{
+		{"valid", `{"brand_name":"Brand","amount":30000,"currency":"TWD","due_date":"2026-03-01"}`, ""},
+		{"optional fields empty", `{"brand_name":"Brand","amount":null,"due_date":""}`, ""},
+		{"negative amount", `{"brand_name":"Brand","amount":-1}`, "$.amount"},
+		{"bad date", `{"brand_name":"Brand","due_date":"next friday"}`, "$.due_date"},
+		{"wrong type", `{"brand_name":"Brand","amount":"三萬"}`, "expected number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.payload), &value); err != nil {
+				t.Fatal(err)
+			}
+			problems := schema.Validate(value)
+			if tt.problem == "" {
+				if len(problems) > 0 {
+					t.Errorf("Expected no problems, got %v", problems)
+				}
+				return
+			}
+			if len(problems) == 0 || !strings.Contains(strings.Join(problems, "; "), tt.problem) {
+				t.Errorf("Expected problem containing %q, got %v", tt.problem, problems)
+			}
+		})
+	}
+}
+
+func TestSchemaValidate_RequiredAndEnum(t *testing.T) {
+	schema := SchemaOf(ReplyCaseUpdateResult{})
+
+	var value interface{}
+	json.Unmarshal([]byte(`{"status":"done"}`), &value)
+	problems := strings.Join(schema.Validate(value), "; ")
+
+	for _, want := range []string{`"should_update"`, `"reason"`, `"done" is not one of`} {
+		if !strings.Contains(problems, want) {
+			t.Errorf("Expected %s in problems, got %s", want, problems)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 結構化輸出錯誤類別
+const (
+	OutputErrorNoToolCall      = "no_tool_call"     // 模型沒有以工具回傳結果
+	OutputErrorInvalidJSON     = "invalid_json"     // 回傳的參數不是合法 JSON
+	OutputErrorSchemaViolation = "schema_violation" // JSON 不符合結果型別的 schema
+)
+
+// maxOutputProblems 修正請求中最多列出的問題數
+const maxOutputProblems = 10
+
+// ErrInvalidOutput 模型回傳的結構化結果無法使用（經過一次修正仍失敗）
+var ErrInvalidOutput = errors.New("invalid structured output")
+
+// OutputError 結構化輸出錯誤
+type OutputError struct {
+	Operation Operation
+	Class     string   // OutputErrorNoToolCall / OutputErrorInvalidJSON / OutputErrorSchemaViolation
+	Problems  []string // 驗證失敗的項目
+	Repaired  bool     // 是否已嘗試修正
+}
+
+func (e *OutputError) Error() string {
+	msg := fmt.Sprintf("%s output from %s", e.Class, e.Operation)
+	if len(e.Problems) > 0 {
+		msg += ": " + strings.Join(e.Problems, "; ")
+	}
+	return msg
+}
+
+// Unwrap 讓呼叫端可以 errors.Is(err, ErrInvalidOutput)
+func (e *OutputError) Unwrap() error {
+	return ErrInvalidOutput
+}
+
+// callStructured 要求模型以 out 型別的 schema 回傳結果並驗證；不符合時帶著錯誤說明重試一次
+// 相同輸入的已驗證結果會由快取取得
+func (s *Service) callStructured(ctx context.Context, operation Operation, prompt *renderedPrompt, name, description string, out interface{}) error {
+	key := s.cacheKey(ctx, operation, name, prompt)
+	messages := prompt.Messages
+	if cached, ok := s.cachedResult(ctx, key); ok {
+		if err := json.Unmarshal(cached, out); err == nil {
+			return nil
+		}
+	}
+
+	schema := SchemaOf(out)
+	tool := &ToolDefinition{Name: name, Description: description, Parameters: schema.JSON()}
+
+	resp, err := s.callAPI(ctx, operation, messages, tool)
+	if err != nil {
+		return err
+	}
+	raw, outErr := parseStructured(operation, resp, schema)
+	if outErr != nil {
+		s.logger.Warn().
+			Str("operation", string(operation)).
+			Str("error_class", outErr.Class).
+			Strs("problems", outErr.Problems).
+			Msg("Structured output invalid, requesting repair")
+
+		previous := resp.Arguments()
+		if strings.TrimSpace(previous) == "" {
+			previous = "（空白回應）"
+		}
+		repair := append(append([]ChatMessage{}, messages...),
+			ChatMessage{Role: RoleAssistant, Content: previous},
+			ChatMessage{Role: RoleUser, Content: repairPrompt(name, outErr)},
+		)
+		resp, err = s.callAPI(ctx, operation, repair, tool)
+		if err != nil {
+			return err
+		}
+		raw, outErr = parseStructured(operation, resp, schema)
+		if outErr != nil {
+			outErr.Repaired = true
+			s.logger.Error().
+				Str("operation", string(operation)).
+				Str("error_class", outErr.Class).
+				Strs("problems", outErr.Problems).
+				Msg("Structured output still invalid after repair")
+			return outErr
+		}
+	}
+
+	if err := json.Unmarshal(raw, out); err != nil {
+		return &OutputError{Operation: operation, Class: OutputErrorSchemaViolation, Problems: []string{err.Error()}}
+	}
+	s.storeResult(key, raw)
+	return nil
+}
+
+// parseStructured 取出工具參數並依 schema 驗證
+// 部分自架模型不支援工具，會直接在內容回傳 JSON，也一併接受
+func parseStructured(operation Operation, resp *ChatResponse, schema *Schema) ([]byte, *OutputError) {
+	args := strings.TrimSpace(resp.ToolArguments)
+	if args == "" {
+		args = stripCodeFence(resp.Content)
+		if !strings.HasPrefix(args, "{") {
+			return nil, &OutputError{Operation: operation, Class: OutputErrorNoToolCall}
+		}
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(args), &value); err != nil {
+		return nil, &OutputError{Operation: operation, Class: OutputErrorInvalidJSON, Problems: []string{err.Error()}}
+	}
+	if problems := schema.Validate(value); len(problems) > 0 {
+		if len(problems) > maxOutputProblems {
+			problems = problems[:maxOutputProblems]
+		}
+		return nil, &OutputError{Operation: operation, Class: OutputErrorSchemaViolation, Problems: problems}
+	}
+	return []byte(args), nil
+}
+
+// repairPrompt 請模型修正上一次的回應
+func repairPrompt(toolName string, outErr *OutputError) string {
+	var b strings.Builder
+	switch outErr.Class {
+	case OutputErrorNoToolCall:
+		fmt.Fprintf(&b, "你的上一個回應沒有使用 %s 工具。", toolName)
+	case OutputErrorInvalidJSON:
+		b.WriteString("你的上一個回應不是合法的 JSON。")
+	default:
+		b.WriteString("你的上一個回應不符合規定的格式：\n")
+	}
+	for _, problem := range outErr.Problems {
+		b.WriteString("- " + problem + "\n")
+	}
+	fmt.Fprintf(&b, "請修正以上問題，並只以 %s 工具重新回傳完整結果。", toolName)
+	return b.String()
+}
+
+// stripCodeFence 去除 ```json ... ``` 包裝
+func stripCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") {
+		return content
+	}
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimPrefix(content, "json")
+	content = strings.TrimSuffix(strings.TrimSpace(content), "```")
+	return strings.TrimSpace(content)
+}
@@ -0,0 +1,182 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+)
+
+// newSequenceServer 依序回傳 tool_call 參數（空字串表示只回傳文字內容），並保存收到的請求
+func newSequenceServer(t *testing.T, outputs []string, requests *[]map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		*requests = append(*requests, req)
+		if len(*requests) > len(outputs) {
+			t.Errorf("Unexpected request #%d", len(*requests))
+			return
+		}
+
+		message := map[string]interface{}{"role": "assistant", "content": "好的"}
+		if args := outputs[len(*requests)-1]; args != "" {
+			message["tool_calls"] = []map[string]interface{}{{
+				"id":       "call_1",
+				"type":     "function",
+				"function": map[string]interface{}{"name": "classify_email", "arguments": args},
+			}}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"model":   "gpt-4o-mini",
+			"choices": []map[string]interface{}{{"message": message}},
+			"usage":   map[string]int{"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15},
+		})
+	}))
+}
+
+func newStructuredTestService(baseURL string) *Service {
+	cfg := getTestConfig()
+	cfg.LLM = config.LLMConfig{
+		Operations: map[string]string{"classify": "compatible"},
+		Compatible: config.CompatibleLLMConfig{BaseURL: baseURL, Model: "gpt-4o-mini"},
+	}
+	return NewService(cfg, getMockLogger(), "")
+}
+
+func TestCallStructured_RepairsSchemaViolation(t *testing.T) {
+	var requests []map[string]interface{}
+	server := newSequenceServer(t, []string{
+		`{"category":"sponsorship","confidence":1.5,"reason":"邀約"}`,
+		`{"category":"collaboration","confidence":0.9,"reason":"邀約"}`,
+	}, &requests)
+	defer server.Close()
+
+	service := newStructuredTestService(server.URL)
+	result, err := service.ClassifyEmail(context.Background(), ClassifyEmailRequest{Subject: "合作邀約", Body: "想邀請您合作"})
+	if err != nil {
+		t.Fatalf("ClassifyEmail failed: %v", err)
+	}
+	if result.Category != CategoryCollaboration || result.Confidence != 0.9 {
+		t.Errorf("Unexpected classification: %+v", result)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("Expected one repair request, got %d requests", len(requests))
+	}
+
+	// 修正請求帶上原始輸出與驗證錯誤
+	messages := requests[1]["messages"].([]interface{})
+	last := messages[len(messages)-1].(map[string]interface{})["content"].(string)
+	if !strings.Contains(last, `"sponsorship" is not one of`) || !strings.Contains(last, "1.5 is greater than 1") {
+		t.Errorf("Repair prompt missing problems: %s", last)
+	}
+	if choice, _ := requests[0]["tool_choice"].(map[string]interface{}); choice["type"] != "function" {
+		t.Errorf("Expected forced tool choice, got %v", requests[0]["tool_choice"])
+	}
+}
+
+func TestCallStructured_ErrorClasses(t *testing.T) {
+	tests := []struct {
+		name    string
+		outputs []string
+		class   string
+	}{
+		{"no tool call", []string{"", ""}, OutputErrorNoToolCall},
+		{"invalid json", []string{`{"category":`, `{"category":`}, OutputErrorInvalidJSON},
+		{"schema violation", []string{`{"category":"collaboration"}`, `{"confidence":0.5}`}, OutputErrorSchemaViolation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []map[string]interface{}
+			server := newSequenceServer(t, tt.outputs, &requests)
+			defer server.Close()
+
+			service := newStructuredTestService(server.URL)
+			var result EmailClassification
+			err := service.callStructured(context.Background(), OperationClassify,
+				&renderedPrompt{Version: "classify@v1", Messages: service.buildPrompt("system", "user")}, "classify_email", "分類郵件", &result)
+
+			var outErr *OutputError
+			if !errors.As(err, &outErr) || !errors.Is(err, ErrInvalidOutput) {
+				t.Fatalf("Expected OutputError, got %v", err)
+			}
+			if outErr.Class != tt.class || !outErr.Repaired || outErr.Operation != OperationClassify {
+				t.Errorf("Unexpected error: %+v", outErr)
+			}
+			if len(requests) != 2 {
+				t.Errorf("Expected exactly one repair attempt, got %d requests", len(requests))
+			}
+		})
+	}
+}
+
+func TestParseStructured_AcceptsFencedContent(t *testing.T) {
+	schema := SchemaOf(EmailClassification{})
+	resp := &ChatResponse{Content: "```json\n{\"category\":\"other\",\"confidence\":0.3,\"reason\":\"無關\"}\n```"}
+
+	raw, outErr := parseStructured(OperationClassify, resp, schema)
+	if outErr != nil {
+		t.Fatalf("Expected fenced JSON to be accepted, got %v", outErr)
+	}
+	if !strings.HasPrefix(string(raw), "{") {
+		t.Errorf("Unexpected raw output: %s", raw)
+	}
+}
+
+// memoryCache 測試用的結果快取
+type memoryCache struct {
+	entries map[CacheKey][]byte
+}
+
+func (m *memoryCache) GetResult(key CacheKey) ([]byte, bool, error) {
+	result, ok := m.entries[key]
+	return result, ok, nil
+}
+
+func (m *memoryCache) PutResult(key CacheKey, result []byte) error {
+	m.entries[key] = result
+	return nil
+}
+
+func TestCallStructured_UsesResultCache(t *testing.T) {
+	var requests []map[string]interface{}
+	server := newSequenceServer(t, []string{`{"category":"collaboration","confidence":0.9,"reason":"邀約"}`}, &requests)
+	defer server.Close()
+
+	service := newStructuredTestService(server.URL)
+	cache := &memoryCache{entries: map[CacheKey][]byte{}}
+	recorder := &recordingRecorder{}
+	service.SetResultCache(cache)
+	service.SetUsageRecorder(recorder)
+	ctx := WithUsageScope(context.Background(), "user-1", "")
+
+	first, err := service.ClassifyEmail(ctx, ClassifyEmailRequest{Subject: "合作邀約", Body: "想邀請您合作"})
+	if err != nil {
+		t.Fatalf("ClassifyEmail failed: %v", err)
+	}
+	// 只有空白差異的相同輸入命中快取，不再呼叫 API
+	second, err := service.ClassifyEmail(ctx, ClassifyEmailRequest{Subject: "合作邀約", Body: "想邀請您合作  "})
+	if err != nil {
+		t.Fatalf("ClassifyEmail (cached) failed: %v", err)
+	}
+
+	if len(requests) != 1 || len(cache.entries) != 1 {
+		t.Fatalf("Expected one API call and one cache entry, got %d / %d", len(requests), len(cache.entries))
+	}
+	if second.Category != first.Category || second.Confidence != first.Confidence {
+		t.Errorf("Cached result differs: %+v vs %+v", second, first)
+	}
+	for key := range cache.entries {
+		if key.UserID != "user-1" || key.PromptVersion != "classify@v2" || key.Model != "gpt-4o-mini" {
+			t.Errorf("Unexpected cache key: %+v", key)
+		}
+	}
+	if len(recorder.usages) != 2 || recorder.usages[0].Cached || !recorder.usages[1].Cached || recorder.usages[1].TotalTokens != 0 {
+		t.Errorf("Unexpected usage records: %+v", recorder.usages)
+	}
+}
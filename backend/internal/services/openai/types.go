@@ -19,26 +19,33 @@ const (
 
 // EmailClassification 郵件分類結果
 type EmailClassification struct {
-	Category   EmailCategory `json:"category"`   // 分類
-	Confidence float64       `json:"confidence"` // 信心指標 (0-1)
-	Reason     string        `json:"reason"`     // 分類原因
+	Category   EmailCategory `json:"category" schema:"required,enum=collaboration|payment|confirmation|inquiry|social|newsletter|notification|spam|other" desc:"郵件的主要類別"`
+	Confidence float64       `json:"confidence" schema:"required,min=0,max=1" desc:"對分類結果的信心指標，0表示完全不確定，1表示非常確定"`
+	Reason     string        `json:"reason" schema:"required" desc:"分類的理由，簡短說明為什麼歸類到這個類別"`
+
+	PromptVersion string `json:"-"` // 使用的範本版本（不在模型輸出中）
 }
 
 // ExtractedInfo 從郵件中抽取的資訊
 type ExtractedInfo struct {
-	BrandName      string     `json:"brand_name"`      // 品牌名稱
-	ContactName    string     `json:"contact_name"`    // 聯絡人姓名
-	ContactEmail   string     `json:"contact_email"`   // 聯絡人郵件
-	ContactPhone   string     `json:"contact_phone"`   // 聯絡電話
-	Amount         *float64   `json:"amount"`          // 金額
-	Currency       string     `json:"currency"`        // 幣別
-	DueDate        *time.Time `json:"due_date"`        // 截止日期
-	ContentType    string     `json:"content_type"`    // 內容類型（如：影片、圖文等）
-	FollowerCount  string     `json:"follower_count"`  // 粉絲數
-	Budget         string     `json:"budget"`          // 預算範圍
-	ProjectDetails string     `json:"project_details"` // 專案詳情
+	BrandName      string     `json:"brand_name" desc:"品牌或公司名稱"`
+	ContactName    string     `json:"contact_name" desc:"聯絡人姓名"`
+	ContactEmail   string     `json:"contact_email" desc:"聯絡人電子郵件地址"`
+	ContactPhone   string     `json:"contact_phone" desc:"聯絡電話號碼"`
+	Amount         *float64   `json:"amount" schema:"min=0" desc:"合作的預算或報酬金額（純數字）"`
+	Currency       string     `json:"currency" schema:"default=TWD" desc:"金額使用的貨幣（ISO 4217 代碼）"`
+	DueDate        *time.Time `json:"due_date" schema:"format=date" desc:"截止日期（ISO 8601 格式: YYYY-MM-DD）"`
+	ContentType    string     `json:"content_type" desc:"內容類型（例如：影片、圖文、直播等）"`
+	FollowerCount  string     `json:"follower_count" desc:"粉絲數或影響力要求"`
+	Budget         string     `json:"budget" desc:"預算範圍（例如：'5萬-10萬'）"`
+	ProjectDetails string     `json:"project_details" desc:"專案詳情摘要"`
+
+	PromptVersion string `json:"-"` // 使用的範本版本（不在模型輸出中）
 }
 
+// AnalysisPromptVersion 以內建範本分析時記錄的版本（分類 + 抽取）
+const AnalysisPromptVersion = "classify@" + DefaultPromptVersion + "+extract@" + DefaultPromptVersion
+
 // EmailAnalysisResult AI 分析結果
 type EmailAnalysisResult struct {
 	Classification EmailClassification `json:"classification"`
@@ -49,6 +56,7 @@ type EmailAnalysisResult struct {
 	Priority       string              `json:"priority"`        // 優先級: low, medium, high
 	TokensUsed     int                 `json:"tokens_used"`     // 使用的 token 數量
 	Model          string              `json:"model"`           // 使用的模型
+	PromptVersion  string              `json:"prompt_version"`  // 使用的 prompt 版本
 	AnalyzedAt     time.Time           `json:"analyzed_at"`     // 分析時間
 }
 
@@ -60,20 +68,57 @@ type AnalysisOptions struct {
 
 // ClassifyEmailRequest 郵件分類請求
 type ClassifyEmailRequest struct {
-	Subject string
-	Body    string
-	From    string
-	Options AnalysisOptions
+	Subject  string
+	Body     string
+	From     string
+	Examples []FeedbackExample // 使用者修正過的分類（few-shot 範例）
+	Options  AnalysisOptions
 }
 
 // AnalyzeEmailRequest AI 分析郵件請求
 type AnalyzeEmailRequest struct {
-	Subject string
-	Body    string
-	From    string
-	To      []string
-	Date    time.Time
-	Options AnalysisOptions
+	Subject     string
+	Body        string
+	From        string
+	To          []string
+	Date        time.Time
+	Attachments []AttachmentContent // 附件擷取出的文字（長文件為摘要）
+	Examples    []FeedbackExample   // 使用者修正過的擷取結果（few-shot 範例）
+	Options     AnalysisOptions
+}
+
+// FeedbackExample 使用者修正過的一次分析，作為同一使用者後續分析的範例
+type FeedbackExample struct {
+	Subject     string
+	From        string
+	Snippet     string
+	Predicted   EmailCategory     // AI 原本的分類
+	Category    EmailCategory     // 使用者修正後的分類（未修正分類時為空）
+	Corrections []FieldCorrection // 擷取欄位的修正
+}
+
+// FieldCorrection 擷取欄位的修正（值為空字串表示未擷取 / 應為空）
+type FieldCorrection struct {
+	Field     string
+	Predicted string
+	Corrected string
+}
+
+// AttachmentContent 送入分析的附件內容
+type AttachmentContent struct {
+	Filename   string
+	Text       string
+	Summarized bool // Text 為長文件的摘要而非全文
+}
+
+// AttachmentSummaryRequest 長附件分段摘要請求（Summaries 不為空時為合併各段摘要）
+type AttachmentSummaryRequest struct {
+	Filename     string
+	EmailSubject string
+	Part         int      // 第幾段（從 1 開始）
+	Parts        int      // 總段數
+	Text         string   // 本段內容
+	Summaries    []string // 各段摘要（合併時使用）
 }
 
 // DraftReplyRequest 擬回信請求（案件摘要 + 要回覆的郵件 + 可選補充說明）
@@ -88,11 +133,85 @@ type DraftReplyRequest struct {
 	EmailBody    string // 內文（純文字）
 	Instruction         string // 使用者補充說明（選填）
 	UserAIInstructions  string // 使用者 AI 注意事項（全域設定）
+	// 使用者的寫作風格（由寄出郵件統計，選填）
+	StyleGuide    string         // 風格摘要（條列文字）
+	StyleExamples []StyleExample // 情境相似的過往回信
+	// 草稿立場與語氣（選填，產生多份草稿時使用）
+	Stance string // 立場：accept / negotiate / decline
+	Tone   string // 語氣：friendly / formal / casual
+}
+
+// StyleExample 使用者過去的回信範例（few-shot）
+type StyleExample struct {
+	Subject  string // 來信主旨
+	Incoming string // 來信內容摘要（可能為空）
+	Reply    string // 使用者的回信內文
 }
 
 // DraftReplyResult 擬回信結果
 type DraftReplyResult struct {
-	Draft string `json:"draft"` // 回信草稿內文（純文字）
+	Draft         string `json:"draft"`          // 回信草稿內文（純文字）
+	PromptVersion string `json:"prompt_version"` // 使用的範本版本
+}
+
+// 草稿立場
+const (
+	DraftStanceAccept    = "accept"    // 接受合作
+	DraftStanceNegotiate = "negotiate" // 提出條件、議價
+	DraftStanceDecline   = "decline"   // 婉拒
+)
+
+// 草稿語氣
+const (
+	DraftToneFriendly = "friendly" // 親切友善
+	DraftToneFormal   = "formal"   // 正式有禮
+	DraftToneCasual   = "casual"   // 輕鬆口語
+)
+
+// DraftVariant 一份草稿的立場與語氣
+type DraftVariant struct {
+	Stance string `json:"stance"`
+	Tone   string `json:"tone,omitempty"`
+}
+
+// DefaultDraftVariants 產生多份草稿時的預設組合（接受、議價、婉拒）
+var DefaultDraftVariants = []DraftVariant{
+	{Stance: DraftStanceAccept, Tone: DraftToneFriendly},
+	{Stance: DraftStanceNegotiate, Tone: DraftToneFormal},
+	{Stance: DraftStanceDecline, Tone: DraftToneFormal},
+}
+
+// IsDraftStance 是否為已知的草稿立場
+func IsDraftStance(stance string) bool {
+	switch stance {
+	case DraftStanceAccept, DraftStanceNegotiate, DraftStanceDecline:
+		return true
+	}
+	return false
+}
+
+// IsDraftTone 是否為已知的草稿語氣
+func IsDraftTone(tone string) bool {
+	switch tone {
+	case DraftToneFriendly, DraftToneFormal, DraftToneCasual:
+		return true
+	}
+	return false
+}
+
+// DraftVariantResult 一份草稿的結果；Err 非 nil 表示該份產生失敗
+type DraftVariantResult struct {
+	DraftVariant
+	Result *DraftReplyResult
+	Err    error
+}
+
+// RefineDraftRequest 依使用者回饋修改既有草稿
+type RefineDraftRequest struct {
+	DraftReplyRequest
+	Draft            string   // 目前的草稿內文
+	Feedback         string   // 這次的修改要求（如「短一點」「要求 50% 訂金」「改用英文」）
+	PreviousFeedback []string // 先前已套用的修改要求（依序），修改時需保留
 }
 
 // ReplyCaseUpdateRequest 回信後 AI 分析案件更新請求
@@ -111,14 +230,110 @@ type ReplyCaseUpdateRequest struct {
 
 // ReplyCaseUpdateResult AI 分析後建議的案件更新
 type ReplyCaseUpdateResult struct {
-	ShouldUpdate      bool    `json:"should_update"`       // 是否有建議更新
-	Status            string  `json:"status"`              // 建議的新狀態（to_confirm/in_progress/completed/cancelled/other）
-	NotesProgress     string  `json:"notes_progress"`      // 進度說明（附加到 notes）
-	DescriptionUpdate string  `json:"description_update"`  // 描述更新
-	QuotedAmount      *float64 `json:"quoted_amount"`      // 預估報價
-	FinalAmount       *float64 `json:"final_amount"`       // 最終金額
-	DeadlineDate      string  `json:"deadline_date"`       // 截止日期 ISO YYYY-MM-DD
-	Reason            string  `json:"reason"`              // 更新理由
+	ShouldUpdate      bool     `json:"should_update" schema:"required" desc:"是否有建議的更新項目（若回信與案件進度無關則填 false）"`
+	Status            string   `json:"status" schema:"enum=to_confirm|in_progress|completed|cancelled|other" desc:"建議的新案件狀態"`
+	NotesProgress     string   `json:"notes_progress" desc:"進度說明，簡短描述此次回信重點或後續（可附加到 notes）"`
+	DescriptionUpdate string   `json:"description_update" desc:"案件描述的更新或補充"`
+	QuotedAmount      *float64 `json:"quoted_amount" schema:"min=0" desc:"預估報價（若回信中提及）"`
+	FinalAmount       *float64 `json:"final_amount" schema:"min=0" desc:"最終金額（若回信中已確定）"`
+	DeadlineDate      string   `json:"deadline_date" schema:"format=date" desc:"截止日期，ISO 8601 格式 YYYY-MM-DD（若回信中提及）"`
+	Reason            string   `json:"reason" schema:"required" desc:"更新建議的理由"`
+	Confidence        float64  `json:"confidence" schema:"required,min=0,max=1" desc:"對建議的信心度 (0-1)，回信語意明確時較高"`
+	Signals           []string `json:"signals,omitempty" desc:"信中偵測到的進度事件：price_agreed / deadline_changed / approved / revision_requested / payment_confirmed"`
+}
+
+// 來信中的案件進度事件（ReplyCaseUpdateResult.Signals）
+const (
+	SignalPriceAgreed       = "price_agreed"       // 品牌同意報價或確認金額
+	SignalDeadlineChanged   = "deadline_changed"   // 截止日或上線日期變更
+	SignalApproved          = "approved"           // 內容 / 腳本審核通過
+	SignalRevisionRequested = "revision_requested" // 要求修改
+	SignalPaymentConfirmed  = "payment_confirmed"  // 已付款或已安排付款
+)
+
+// IncomingCaseUpdateRequest 品牌來信後的案件更新分析請求（郵件串已關聯案件）
+type IncomingCaseUpdateRequest struct {
+	EmailFrom        string // 來信寄件者
+	EmailSubject     string // 來信主旨
+	EmailBody        string // 來信內文
+	EmailDate        string // 來信時間（顯示用）
+	PreviousMessage  string // 同一郵件串中我方上一封寄出的信（可為空）
+	CaseTitle        string // 案件標題
+	CaseStatus       string // 目前案件狀態
+	CaseDescription  string // 案件描述
+	CaseNotes        string // 案件備註
+	CaseQuotedAmount string // 預估報價（顯示用）
+	CaseFinalAmount  string // 最終金額（顯示用）
+	CaseDeadline     string // 截止日期（顯示用）
+}
+
+// RateNegotiationRequest 報價議價建議請求（金額與歷史案件由資料庫統計後提供）
+type RateNegotiationRequest struct {
+	CaseTitle    string // 案件標題
+	BrandName    string // 品牌名稱
+	Deliverables string // 合作形式 / 內容
+	EmailSubject string // 提及預算的來信主旨
+	EmailBody    string // 提及預算的來信內文
+	Currency     string // 幣別
+
+	OfferedAmount *float64 // 對方提出的金額（範圍時為下限）
+	OfferedMax    *float64 // 對方預算範圍的上限
+	Budget        string   // 對方描述的預算（原文）
+
+	PriceItems []PriceItem // 案件對應的合作項目與定價
+	ListTotal  float64     // 合作項目定價合計
+	OtherItems []PriceItem // 未對應到案件、可用於組合方案的其他合作項目
+
+	PastDeals     []PastDeal // 類似的歷史成交案件
+	HistoryCount  int        // 歷史成交案件數
+	HistoryMedian *float64   // 類似案件成交金額中位數
+	BrandMedian   *float64   // 同品牌過往成交金額中位數
+
+	BaselineAction string   // 依數字的初步判斷（accept / counter / bundle）
+	BaselineAmount *float64 // 初步建議的還價金額
+}
+
+// PriceItem 合作項目與定價
+type PriceItem struct {
+	ID    string  `json:"id"`
+	Title string  `json:"title"`
+	Price float64 `json:"price"`
+}
+
+// PastDeal 歷史成交案件
+type PastDeal struct {
+	CaseID       string  `json:"case_id"`
+	Title        string  `json:"title"`
+	BrandName    string  `json:"brand_name"`
+	Deliverables string  `json:"deliverables,omitempty"`
+	Amount       float64 `json:"amount"`
+	SameBrand    bool    `json:"same_brand"`
+	SharedItems  int     `json:"shared_items"` // 與本案相同的合作項目數
+}
+
+// 議價建議動作
+const (
+	RateActionAccept  = "accept"  // 接受對方預算
+	RateActionCounter = "counter" // 還價
+	RateActionBundle  = "bundle"  // 提出組合方案
+)
+
+// RateRecommendation 報價議價建議
+type RateRecommendation struct {
+	Action        string         `json:"action" schema:"required,enum=accept|counter|bundle" desc:"建議動作：accept 接受、counter 還價、bundle 提出組合方案"`
+	CounterAmount *float64       `json:"counter_amount" schema:"min=0" desc:"建議的還價金額（action 為 counter 時必填）"`
+	BundleOptions []BundleOption `json:"bundle_options" desc:"組合方案（action 為 bundle 時提供 1-3 個）"`
+	Reasoning     string         `json:"reasoning" schema:"required" desc:"建議的理由，需引用定價與歷史成交數字"`
+	TalkingPoints []string       `json:"talking_points" desc:"回覆品牌時可使用的論點（2-4 點）"`
+	Confidence    float64        `json:"confidence" schema:"required,min=0,max=1" desc:"建議的信心度 (0-1)"`
+}
+
+// BundleOption 組合方案
+type BundleOption struct {
+	Title       string   `json:"title" schema:"required" desc:"方案名稱"`
+	Items       []string `json:"items" schema:"required" desc:"包含的合作項目名稱"`
+	Amount      float64  `json:"amount" schema:"required,min=0" desc:"方案金額"`
+	Description string   `json:"description" desc:"方案說明（例如：在對方預算內減少項目，或加價增加項目）"`
 }
 
 // MatchCollaborationItemsRequest 匹配合作項目請求
@@ -139,9 +354,9 @@ type CollaborationItemInfo struct {
 
 // MatchCollaborationItemsResult 合作項目匹配結果
 type MatchCollaborationItemsResult struct {
-	MatchedItemIDs []string `json:"matched_item_ids"`
-	Confidence     float64  `json:"confidence"`
-	Reason         string   `json:"reason"`
+	MatchedItemIDs []string `json:"matched_item_ids" schema:"required" desc:"匹配到的合作項目 ID 列表"`
+	Confidence     float64  `json:"confidence" schema:"required,min=0,max=1" desc:"匹配信心度 (0-1)"`
+	Reason         string   `json:"reason" schema:"required" desc:"匹配的理由說明"`
 }
 
 // MatchWorkflowTemplateRequest AI 自動選擇流程範本請求
@@ -164,19 +379,48 @@ type WorkflowTemplateInfo struct {
 
 // MatchWorkflowTemplateResult AI 選擇流程範本結果
 type MatchWorkflowTemplateResult struct {
-	TemplateID string  `json:"template_id"`
-	Confidence float64 `json:"confidence"`
-	Reason     string  `json:"reason"`
+	TemplateID string  `json:"template_id" schema:"required" desc:"選中的流程範本 ID，如果沒有適合的則為空字串"`
+	Confidence float64 `json:"confidence" schema:"required,min=0,max=1" desc:"匹配信心度 (0-1)"`
+	Reason     string  `json:"reason" schema:"required" desc:"選擇的理由說明"`
+}
+
+// MatchCaseRequest AI 判斷新郵件是否屬於既有案件的請求
+type MatchCaseRequest struct {
+	EmailSubject string          `json:"email_subject"`
+	EmailBody    string          `json:"email_body"`
+	EmailFrom    string          `json:"email_from"`
+	BrandName    string          `json:"brand_name"` // 郵件擷取出的品牌（可為空）
+	Cases        []CaseMatchInfo `json:"cases"`
+}
+
+// CaseMatchInfo 候選案件摘要（用於 AI 匹配）
+type CaseMatchInfo struct {
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	BrandName    string `json:"brand_name"`
+	ContactEmail string `json:"contact_email,omitempty"`
+	Status       string `json:"status"`
+	Description  string `json:"description,omitempty"`
+	UpdatedAt    string `json:"updated_at"`
+}
+
+// MatchCaseResult AI 判斷郵件所屬案件的結果
+type MatchCaseResult struct {
+	CaseID     string  `json:"case_id" schema:"required" desc:"郵件所屬的既有案件 ID，若為新的合作則為空字串"`
+	Confidence float64 `json:"confidence" schema:"required,min=0,max=1" desc:"判斷為同一合作的信心度 (0-1)"`
+	Reason     string  `json:"reason" schema:"required" desc:"判斷的理由說明"`
 }
 
 // TokenUsage 記錄 token 使用情況
 type TokenUsage struct {
 	UserID           string
 	EmailID          string
+	Operation        Operation // 呼叫用途
 	Model            string
 	PromptTokens     int       // Prompt 使用的 tokens
 	CompletionTokens int       // Completion 使用的 tokens
 	TotalTokens      int       // 總 tokens
 	CostUSD          float64   // 成本 (USD)
+	Cached           bool      // 由快取取得，未呼叫 API
 	AnalyzedAt       time.Time // 分析時間
 }
@@ -0,0 +1,72 @@
+package openai
+
+import (
+	"context"
+	"errors"
+)
+
+// Operation AI 呼叫用途（用於用量統計）
+type Operation string
+
+const (
+	OperationClassify      Operation = "classify"       // 郵件分類
+	OperationExtract       Operation = "extract"        // 資訊擷取
+	OperationDraft         Operation = "draft"          // 回覆草稿
+	OperationMatch         Operation = "match"          // 合作項目 / 工作流程比對
+	OperationReplyAnalysis Operation = "reply_analysis" // 回覆內容分析
+	OperationNegotiate     Operation = "negotiate"      // 報價議價建議
+)
+
+// UsageRecorder 保存每次 API 呼叫的 token 用量
+type UsageRecorder interface {
+	RecordUsage(usage TokenUsage) error
+}
+
+// 額度檢查錯誤（呼叫端以 errors.Is 判斷）
+var (
+	ErrBudgetExceeded = errors.New("ai budget exceeded")
+	ErrRateLimited    = errors.New("ai rate limit exceeded")
+)
+
+// UsageGuard 在呼叫 API 前檢查使用者的額度與頻率限制
+type UsageGuard interface {
+	CheckUsage(userID string, operation Operation) error
+}
+
+// SetUsageGuard 設定額度檢查（未設定時不限制）
+func (s *Service) SetUsageGuard(guard UsageGuard) {
+	s.guard = guard
+}
+
+// checkUsage 檢查 context 所屬使用者是否可以呼叫（沒有使用者歸屬的呼叫不限制）
+func (s *Service) checkUsage(ctx context.Context, operation Operation) error {
+	scope := usageScopeFrom(ctx)
+	if s.guard == nil || scope.UserID == "" {
+		return nil
+	}
+	return s.guard.CheckUsage(scope.UserID, operation)
+}
+
+// SetUsageRecorder 設定用量記錄器
+func (s *Service) SetUsageRecorder(recorder UsageRecorder) {
+	s.recorder = recorder
+}
+
+type usageScopeKey struct{}
+
+// UsageScope 用量歸屬的使用者與郵件
+type UsageScope struct {
+	UserID  string
+	EmailID string
+}
+
+// WithUsageScope 在 context 中標記之後 AI 呼叫所屬的使用者與郵件（emailID 可為空）
+func WithUsageScope(ctx context.Context, userID, emailID string) context.Context {
+	return context.WithValue(ctx, usageScopeKey{}, UsageScope{UserID: userID, EmailID: emailID})
+}
+
+// usageScopeFrom 取得 context 中的用量歸屬
+func usageScopeFrom(ctx context.Context) UsageScope {
+	scope, _ := ctx.Value(usageScopeKey{}).(UsageScope)
+	return scope
+}
@@ -0,0 +1,79 @@
+package pricing
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// amountPattern 金額與單位（如 5萬、3.5k、12,000、NT$8000）
+var amountPattern = regexp.MustCompile(`(\d+(?:,\d{3})*(?:\.\d+)?)\s*(萬|千|[kK]|[mM])?`)
+
+// ParseBudget 解析預算描述（如「5萬-10萬」「NT$30,000 以內」「3k~5k」），回傳下限與上限；只有一個金額時兩者相同
+func ParseBudget(s string) (min, max *float64) {
+	var amounts []float64
+	for _, m := range amountPattern.FindAllStringSubmatch(s, -1) {
+		v, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+		if err != nil || v == 0 {
+			continue
+		}
+		switch m[2] {
+		case "萬":
+			v *= 10000
+		case "千", "k", "K":
+			v *= 1000
+		case "m", "M":
+			v *= 1000000
+		}
+		amounts = append(amounts, v)
+	}
+	if len(amounts) == 0 {
+		return nil, nil
+	}
+
+	// 「5-10萬」：前面的數字沿用後面的單位
+	if len(amounts) >= 2 && amounts[0] < amounts[1]/100 {
+		matches := amountPattern.FindAllStringSubmatch(s, 2)
+		if matches[0][2] == "" && matches[1][2] != "" {
+			amounts[0] *= amounts[1] / mustParse(matches[1][1])
+		}
+	}
+
+	lo, hi := amounts[0], amounts[0]
+	for _, v := range amounts[1:] {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	return &lo, &hi
+}
+
+func mustParse(s string) float64 {
+	v, _ := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	return v
+}
+
+// median 中位數（空切片回傳 nil）
+func median(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	m := sorted[mid]
+	if len(sorted)%2 == 0 {
+		m = (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return &m
+}
+
+// roundPrice 還價金額取整：一萬以上取到千位，其餘取到百位（無條件進位）
+func roundPrice(v float64) float64 {
+	unit := 100.0
+	if v >= 10000 {
+		unit = 1000
+	}
+	return math.Ceil(v/unit) * unit
+}
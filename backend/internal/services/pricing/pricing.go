@@ -0,0 +1,278 @@
+package pricing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/analysis"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	historyLimit   = 200 // 最多參考的歷史成交案件數
+	pastDealsLimit = 8   // 提供給 AI 的類似案件數
+	otherItemLimit = 10  // 提供給 AI 組合方案的其他合作項目數
+
+	acceptRatio  = 0.95 // 對方預算達到目標金額的比例以上時建議接受
+	counterRatio = 0.7  // 比例以上時還價，以下時提出組合方案
+)
+
+// Service 報價參考服務（由合作項目定價與歷史成交案件計算議價依據）
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService 建立報價參考服務
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Offer 品牌提出的預算
+type Offer struct {
+	Amount   *float64   `json:"amount,omitempty"` // 金額（範圍時為下限）
+	Max      *float64   `json:"max,omitempty"`    // 範圍上限
+	Budget   string     `json:"budget,omitempty"` // 預算原文
+	Currency string     `json:"currency,omitempty"`
+	EmailID  *uuid.UUID `json:"email_id,omitempty"` // 提及預算的來信
+}
+
+// Top 對方預算的上限（無範圍時為金額）
+func (o *Offer) Top() *float64 {
+	if o.Max != nil {
+		return o.Max
+	}
+	return o.Amount
+}
+
+// Reference 議價依據
+type Reference struct {
+	Offer          Offer              `json:"offer"`
+	Items          []openai.PriceItem `json:"items"`      // 案件對應的合作項目
+	ListTotal      float64            `json:"list_total"` // 合作項目定價合計
+	OtherItems     []openai.PriceItem `json:"-"`
+	PastDeals      []openai.PastDeal  `json:"past_deals"`
+	HistoryCount   int                `json:"history_count"`
+	HistoryMedian  *float64           `json:"history_median,omitempty"`
+	BrandMedian    *float64           `json:"brand_median,omitempty"`
+	Target         *float64           `json:"target,omitempty"`          // 定價與歷史行情中較高者
+	BaselineAction string             `json:"baseline_action,omitempty"` // 依數字的初步判斷
+	BaselineAmount *float64           `json:"baseline_amount,omitempty"` // 初步建議的還價金額
+}
+
+// LatestOffer 案件來信中最近一次 AI 抽取到的預算；emailID 非 nil 時只看該封郵件
+func (s *Service) LatestOffer(userID uuid.UUID, caseID uuid.UUID, emailID *uuid.UUID) (*Offer, error) {
+	query := s.db.Model(&models.AIAnalysis{}).
+		Select("ai_analyses.*").
+		Joins("JOIN emails ON emails.ai_analysis_id = ai_analyses.id").
+		Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
+		Where("oauth_accounts.user_id = ? AND emails.case_id = ? AND emails.direction = ?", userID, caseID, models.EmailDirectionIncoming)
+	if emailID != nil {
+		query = query.Where("emails.id = ?", *emailID)
+	}
+
+	var analyses []models.AIAnalysis
+	if err := query.Order("emails.received_at DESC").Limit(20).Find(&analyses).Error; err != nil {
+		return nil, fmt.Errorf("failed to load email analyses: %w", err)
+	}
+	for i := range analyses {
+		info, err := analysis.ExtractedInfo(&analyses[i])
+		if err != nil {
+			continue
+		}
+		offer := Offer{Amount: info.Amount, Budget: strings.TrimSpace(info.Budget), Currency: info.Currency}
+		if offer.Budget != "" {
+			lo, hi := ParseBudget(offer.Budget)
+			if offer.Amount == nil {
+				offer.Amount = lo
+			}
+			if hi != nil && offer.Amount != nil && *hi > *offer.Amount {
+				offer.Max = hi
+			}
+		}
+		if offer.Amount == nil {
+			continue
+		}
+		id := analyses[i].EmailID
+		offer.EmailID = &id
+		return &offer, nil
+	}
+	return nil, nil
+}
+
+// Reference 計算案件的議價依據；offer 為 nil 時只提供定價與歷史行情
+func (s *Service) Reference(cs *models.Case, offer *Offer) (*Reference, error) {
+	ref := &Reference{}
+	if offer != nil {
+		ref.Offer = *offer
+	}
+	if ref.Offer.Currency == "" && cs.Currency != nil {
+		ref.Offer.Currency = *cs.Currency
+	}
+
+	var items []models.CollaborationItem
+	if err := s.db.Where("user_id = ?", cs.UserID).Order(`"order" ASC`).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to load collaboration items: %w", err)
+	}
+	matched := make(map[string]bool, len(cs.CollaborationItems))
+	for _, id := range cs.CollaborationItems {
+		matched[id] = true
+	}
+	for _, item := range items {
+		p := openai.PriceItem{ID: item.ID.String(), Title: item.Title, Price: item.Price}
+		if matched[p.ID] {
+			ref.Items = append(ref.Items, p)
+			ref.ListTotal += item.Price
+		} else if item.Price > 0 && len(ref.OtherItems) < otherItemLimit {
+			ref.OtherItems = append(ref.OtherItems, p)
+		}
+	}
+
+	deals, err := s.similarDeals(cs, matched)
+	if err != nil {
+		return nil, err
+	}
+	var amounts, brandAmounts []float64
+	for _, d := range deals {
+		amounts = append(amounts, d.Amount)
+		if d.SameBrand {
+			brandAmounts = append(brandAmounts, d.Amount)
+		}
+	}
+	ref.HistoryCount = len(deals)
+	ref.HistoryMedian = median(amounts)
+	ref.BrandMedian = median(brandAmounts)
+	if len(deals) > pastDealsLimit {
+		deals = deals[:pastDealsLimit]
+	}
+	ref.PastDeals = deals
+
+	ref.baseline()
+	return ref, nil
+}
+
+// similarDeals 同品牌、相同合作項目或相同合作形式的歷史成交案件（越相似越前面）
+func (s *Service) similarDeals(cs *models.Case, matched map[string]bool) ([]openai.PastDeal, error) {
+	var cases []models.Case
+	err := s.db.Where("user_id = ? AND id <> ? AND final_amount IS NOT NULL AND final_amount > 0", cs.UserID, cs.ID).
+		Where("status IN ?", []models.CaseStatus{models.CaseStatusCompleted, models.CaseStatusInProgress}).
+		Order("updated_at DESC").
+		Limit(historyLimit).
+		Find(&cases).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load past cases: %w", err)
+	}
+
+	brand := normalize(cs.BrandName)
+	kind := normalize(deref(cs.CollaborationType))
+	var deals []openai.PastDeal
+	for _, past := range cases {
+		deal := openai.PastDeal{
+			CaseID:       past.ID.String(),
+			Title:        past.Title,
+			BrandName:    past.BrandName,
+			Deliverables: deref(past.CollaborationType),
+			Amount:       *past.FinalAmount,
+			SameBrand:    brand != "" && normalize(past.BrandName) == brand,
+		}
+		for _, id := range past.CollaborationItems {
+			if matched[id] {
+				deal.SharedItems++
+			}
+		}
+		sameKind := kind != "" && normalize(deal.Deliverables) == kind
+		if deal.SameBrand || deal.SharedItems > 0 || sameKind {
+			deals = append(deals, deal)
+		}
+	}
+
+	sort.SliceStable(deals, func(i, j int) bool {
+		if deals[i].SameBrand != deals[j].SameBrand {
+			return deals[i].SameBrand
+		}
+		return deals[i].SharedItems > deals[j].SharedItems
+	})
+	return deals, nil
+}
+
+// baseline 依定價與歷史行情判斷初步建議：目標金額為定價合計、類似案件與同品牌中位數中的最高者
+func (r *Reference) baseline() {
+	target := r.ListTotal
+	for _, m := range []*float64{r.HistoryMedian, r.BrandMedian} {
+		if m != nil && *m > target {
+			target = *m
+		}
+	}
+	if target > 0 {
+		r.Target = &target
+	}
+
+	offer := r.Offer.Top()
+	switch {
+	case offer == nil:
+		return
+	case target == 0 || *offer >= target*acceptRatio:
+		r.BaselineAction = openai.RateActionAccept
+	case *offer >= target*counterRatio:
+		r.BaselineAction = openai.RateActionCounter
+	default:
+		r.BaselineAction = openai.RateActionBundle
+	}
+	if r.BaselineAction != openai.RateActionAccept {
+		amount := roundPrice(target)
+		r.BaselineAmount = &amount
+	}
+}
+
+// Request 組出 AI 議價建議請求
+func (r *Reference) Request(cs *models.Case, subject, body string) openai.RateNegotiationRequest {
+	deliverables := deref(cs.CollaborationType)
+	if len(r.Items) > 0 {
+		titles := make([]string, len(r.Items))
+		for i, item := range r.Items {
+			titles[i] = item.Title
+		}
+		deliverables = strings.TrimSpace(deliverables + " " + strings.Join(titles, "、"))
+	}
+	currency := r.Offer.Currency
+	if currency == "" {
+		currency = "TWD"
+	}
+
+	return openai.RateNegotiationRequest{
+		CaseTitle:      cs.Title,
+		BrandName:      cs.BrandName,
+		Deliverables:   deliverables,
+		EmailSubject:   subject,
+		EmailBody:      body,
+		Currency:       currency,
+		OfferedAmount:  r.Offer.Amount,
+		OfferedMax:     r.Offer.Max,
+		Budget:         r.Offer.Budget,
+		PriceItems:     r.Items,
+		ListTotal:      r.ListTotal,
+		OtherItems:     r.OtherItems,
+		PastDeals:      r.PastDeals,
+		HistoryCount:   r.HistoryCount,
+		HistoryMedian:  r.HistoryMedian,
+		BrandMedian:    r.BrandMedian,
+		BaselineAction: r.BaselineAction,
+		BaselineAmount: r.BaselineAmount,
+	}
+}
+
+// normalize 比對用的名稱（忽略大小寫與前後空白）
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// deref 取得字串指標的值
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
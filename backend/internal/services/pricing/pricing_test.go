@@ -0,0 +1,172 @@
+package pricing
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 設置測試用的資料庫（使用 SQLite）
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Skipf("Skipping test: SQLite not available (CGO required): %v", err)
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.OAuthAccount{}, &models.Email{}, &models.AIAnalysis{},
+		&models.Case{}, &models.CollaborationItem{})
+	require.NoError(t, err)
+	return db
+}
+
+func ptr(f float64) *float64 { return &f }
+
+func TestParseBudget(t *testing.T) {
+	tests := []struct {
+		in       string
+		min, max *float64
+	}{
+		{"5萬-10萬", ptr(50000), ptr(100000)},
+		{"5-10萬", ptr(50000), ptr(100000)},
+		{"NT$30,000 以內", ptr(30000), ptr(30000)},
+		{"3k~5k", ptr(3000), ptr(5000)},
+		{"約 1.5 萬", ptr(15000), ptr(15000)},
+		{"面議", nil, nil},
+	}
+	for _, tt := range tests {
+		min, max := ParseBudget(tt.in)
+		assert.Equal(t, tt.min, min, tt.in)
+		assert.Equal(t, tt.max, max, tt.in)
+	}
+}
+
+func TestRoundPrice(t *testing.T) {
+	assert.Equal(t, 8600.0, roundPrice(8512))
+	assert.Equal(t, 36000.0, roundPrice(35001))
+}
+
+type fixture struct {
+	db      *gorm.DB
+	user    *models.User
+	account *models.OAuthAccount
+}
+
+func newFixture(t *testing.T) *fixture {
+	db := setupTestDB(t)
+	user := &models.User{ID: uuid.New(), Email: "creator@example.com", Name: "Creator"}
+	require.NoError(t, db.Create(user).Error)
+	account := &models.OAuthAccount{
+		ID: uuid.New(), UserID: user.ID, Provider: models.OAuthProviderGoogle, Email: "creator@example.com",
+		AccessToken: "a", RefreshToken: "r", TokenExpiry: time.Now().Add(time.Hour),
+	}
+	require.NoError(t, db.Create(account).Error)
+	return &fixture{db: db, user: user, account: account}
+}
+
+func (f *fixture) item(t *testing.T, title string, price float64) string {
+	item := &models.CollaborationItem{UserID: f.user.ID, Title: title, Price: price}
+	require.NoError(t, f.db.Create(item).Error)
+	return item.ID.String()
+}
+
+func (f *fixture) pastCase(t *testing.T, brand string, amount float64, status models.CaseStatus, items ...string) {
+	cs := &models.Case{UserID: f.user.ID, Title: brand + " 舊案", BrandName: brand, Status: status, FinalAmount: &amount, CollaborationItems: pq.StringArray(items)}
+	require.NoError(t, f.db.Create(cs).Error)
+}
+
+func (f *fixture) offerEmail(t *testing.T, cs *models.Case, at time.Time, info openai.ExtractedInfo) *models.Email {
+	email := &models.Email{OAuthAccountID: f.account.ID, ProviderMessageID: uuid.NewString(), FromEmail: "pm@brand.example",
+		Direction: models.EmailDirectionIncoming, ReceivedAt: at, CaseID: &cs.ID}
+	require.NoError(t, f.db.Create(email).Error)
+	raw, _ := json.Marshal(info)
+	a := &models.AIAnalysis{EmailID: email.ID, UserID: f.user.ID, Version: 1, Model: "m", PromptVersion: "extract@v1", ExtractedInfo: raw, AnalyzedAt: at}
+	require.NoError(t, f.db.Create(a).Error)
+	require.NoError(t, f.db.Model(email).Update("ai_analysis_id", a.ID).Error)
+	return email
+}
+
+func TestLatestOffer(t *testing.T) {
+	f := newFixture(t)
+	svc := NewService(f.db)
+	cs := &models.Case{UserID: f.user.ID, Title: "開箱", BrandName: "Glow"}
+	require.NoError(t, f.db.Create(cs).Error)
+
+	none, err := svc.LatestOffer(f.user.ID, cs.ID, nil)
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	base := time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC)
+	first := f.offerEmail(t, cs, base, openai.ExtractedInfo{Amount: ptr(20000), Currency: "TWD"})
+	latest := f.offerEmail(t, cs, base.Add(time.Hour), openai.ExtractedInfo{Budget: "3-4萬"})
+	f.offerEmail(t, cs, base.Add(2*time.Hour), openai.ExtractedInfo{Budget: "面議"})
+
+	offer, err := svc.LatestOffer(f.user.ID, cs.ID, nil)
+	require.NoError(t, err)
+	require.NotNil(t, offer)
+	assert.Equal(t, latest.ID, *offer.EmailID)
+	assert.Equal(t, 30000.0, *offer.Amount)
+	assert.Equal(t, 40000.0, *offer.Max)
+
+	offer, err = svc.LatestOffer(f.user.ID, cs.ID, &first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 20000.0, *offer.Amount)
+	assert.Nil(t, offer.Max)
+	assert.Equal(t, "TWD", offer.Currency)
+}
+
+func TestReference_BaselineFromPriceListAndHistory(t *testing.T) {
+	f := newFixture(t)
+	svc := NewService(f.db)
+	reel := f.item(t, "IG Reels", 20000)
+	story := f.item(t, "IG 限動", 5000)
+	f.item(t, "YouTube 影片", 60000)
+
+	f.pastCase(t, "Glow", 32000, models.CaseStatusCompleted, reel, story)
+	f.pastCase(t, "Other", 26000, models.CaseStatusCompleted, reel)
+	f.pastCase(t, "Glow", 90000, models.CaseStatusCancelled, reel) // 取消的案件不列入
+	f.pastCase(t, "Unrelated", 99000, models.CaseStatusCompleted)  // 不相似
+
+	cs := &models.Case{UserID: f.user.ID, Title: "新品開箱", BrandName: "glow ", CollaborationItems: pq.StringArray{reel, story}}
+	require.NoError(t, f.db.Create(cs).Error)
+
+	ref, err := svc.Reference(cs, &Offer{Amount: ptr(25000)})
+	require.NoError(t, err)
+	assert.Equal(t, 25000.0, ref.ListTotal)
+	assert.Len(t, ref.Items, 2)
+	require.Len(t, ref.OtherItems, 1)
+	assert.Equal(t, 2, ref.HistoryCount)
+	require.Len(t, ref.PastDeals, 2)
+	assert.True(t, ref.PastDeals[0].SameBrand)
+	assert.Equal(t, 2, ref.PastDeals[0].SharedItems)
+	assert.Equal(t, 29000.0, *ref.HistoryMedian)
+	assert.Equal(t, 32000.0, *ref.BrandMedian)
+
+	// 目標為同品牌成交 32,000；25,000 約為 78%，建議還價
+	assert.Equal(t, 32000.0, *ref.Target)
+	assert.Equal(t, openai.RateActionCounter, ref.BaselineAction)
+	assert.Equal(t, 32000.0, *ref.BaselineAmount)
+
+	ref, err = svc.Reference(cs, &Offer{Amount: ptr(15000)})
+	require.NoError(t, err)
+	assert.Equal(t, openai.RateActionBundle, ref.BaselineAction)
+
+	ref, err = svc.Reference(cs, &Offer{Amount: ptr(20000), Max: ptr(31000)})
+	require.NoError(t, err)
+	assert.Equal(t, openai.RateActionAccept, ref.BaselineAction)
+	assert.Nil(t, ref.BaselineAmount)
+
+	req := ref.Request(cs, "邀約", "預算 2-3.1 萬")
+	assert.Equal(t, "IG Reels、IG 限動", req.Deliverables)
+	assert.Equal(t, "TWD", req.Currency)
+}
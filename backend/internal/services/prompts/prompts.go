@@ -0,0 +1,80 @@
+package prompts
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Store 從資料庫選擇範本版本（實作 openai.PromptSource）
+type Store struct {
+	db *gorm.DB
+}
+
+// NewStore 建立範本來源
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// Bucket 使用者在某範本的分流桶（0-99）；同一使用者固定落在同一桶，各範本獨立分流
+func Bucket(userID string, name openai.PromptName) int {
+	h := fnv.New32a()
+	h.Write([]byte(string(name) + ":" + userID))
+	return int(h.Sum32() % 100)
+}
+
+// ResolvePrompt 依序選擇：使用者的個人覆寫 → 依分流比例命中的全域版本 → nil（內建範本）
+func (s *Store) ResolvePrompt(userID string, name openai.PromptName) (*openai.PromptTemplate, error) {
+	if uid, err := uuid.Parse(userID); err == nil {
+		var override models.PromptTemplate
+		err := s.db.Where("name = ? AND user_id = ? AND active = ?", string(name), uid, true).
+			Order("updated_at DESC").
+			First(&override).Error
+		if err == nil {
+			return toTemplate(override), nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("failed to load prompt override: %w", err)
+		}
+	}
+
+	var candidates []models.PromptTemplate
+	err := s.db.Where("name = ? AND user_id IS NULL AND active = ? AND rollout_percent > 0", string(name), true).
+		Order("created_at ASC").
+		Find(&candidates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prompt versions: %w", err)
+	}
+
+	// 各版本依建立順序佔用連續的分流區間，其餘使用內建範本
+	bucket := Bucket(userID, name)
+	upper := 0
+	for _, candidate := range candidates {
+		upper += candidate.RolloutPercent
+		if bucket < upper {
+			return toTemplate(candidate), nil
+		}
+	}
+	return nil, nil
+}
+
+// RolloutTotal 範本目前啟用的全域版本分流比例合計（excludeID 為更新中的版本）
+func RolloutTotal(db *gorm.DB, name string, excludeID uuid.UUID) (int, error) {
+	var total int
+	err := db.Model(&models.PromptTemplate{}).
+		Select("COALESCE(SUM(rollout_percent), 0)").
+		Where("name = ? AND user_id IS NULL AND active = ? AND id <> ?", name, true, excludeID).
+		Scan(&total).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum prompt rollout: %w", err)
+	}
+	return total, nil
+}
+
+func toTemplate(t models.PromptTemplate) *openai.PromptTemplate {
+	return &openai.PromptTemplate{Name: openai.PromptName(t.Name), Version: t.Version, Body: t.Body}
+}
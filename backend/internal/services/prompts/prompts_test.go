@@ -0,0 +1,114 @@
+package prompts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 設置測試用的資料庫（使用 SQLite）
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Skipf("Skipping test: SQLite not available (CGO required): %v", err)
+	}
+	require.NoError(t, db.AutoMigrate(&models.PromptTemplate{}))
+	return db
+}
+
+func createVersion(t *testing.T, db *gorm.DB, version string, rollout int, userID *uuid.UUID, createdAt time.Time) models.PromptTemplate {
+	tmpl := models.PromptTemplate{
+		Name:           string(openai.PromptClassify),
+		Version:        version,
+		UserID:         userID,
+		Body:           `{{define "system"}}` + version + `{{end}}{{define "user"}}{{.Subject}}{{end}}`,
+		RolloutPercent: rollout,
+		Active:         true,
+		CreatedAt:      createdAt,
+	}
+	require.NoError(t, db.Create(&tmpl).Error)
+	return tmpl
+}
+
+// userInBucket 找出落在 [from, to) 分流桶的使用者
+func userInBucket(t *testing.T, from, to int) string {
+	for i := 0; i < 10000; i++ {
+		id := uuid.New().String()
+		if b := Bucket(id, openai.PromptClassify); b >= from && b < to {
+			return id
+		}
+	}
+	t.Fatalf("No user found in bucket range [%d, %d)", from, to)
+	return ""
+}
+
+func TestResolvePrompt_RolloutBuckets(t *testing.T) {
+	db := setupTestDB(t)
+	store := NewStore(db)
+	now := time.Now()
+
+	createVersion(t, db, "v2", 20, nil, now.Add(-time.Hour))
+	createVersion(t, db, "v3", 30, nil, now)
+
+	// 依建立順序：桶 0-19 → v2，20-49 → v3，其餘內建
+	tmpl, err := store.ResolvePrompt(userInBucket(t, 0, 20), openai.PromptClassify)
+	require.NoError(t, err)
+	require.NotNil(t, tmpl)
+	assert.Equal(t, "classify@v2", tmpl.Label())
+
+	tmpl, err = store.ResolvePrompt(userInBucket(t, 20, 50), openai.PromptClassify)
+	require.NoError(t, err)
+	require.NotNil(t, tmpl)
+	assert.Equal(t, "v3", tmpl.Version)
+
+	tmpl, err = store.ResolvePrompt(userInBucket(t, 50, 100), openai.PromptClassify)
+	require.NoError(t, err)
+	assert.Nil(t, tmpl)
+
+	// 其他範本不受影響
+	tmpl, err = store.ResolvePrompt(userInBucket(t, 0, 20), openai.PromptDraft)
+	require.NoError(t, err)
+	assert.Nil(t, tmpl)
+
+	// 同一使用者固定落在同一桶
+	id := uuid.New().String()
+	assert.Equal(t, Bucket(id, openai.PromptClassify), Bucket(id, openai.PromptClassify))
+}
+
+func TestResolvePrompt_UserOverrideAndInactive(t *testing.T) {
+	db := setupTestDB(t)
+	store := NewStore(db)
+	userID := uuid.New()
+
+	global := createVersion(t, db, "v2", 100, nil, time.Now())
+	createVersion(t, db, "mine", 0, &userID, time.Now())
+
+	tmpl, err := store.ResolvePrompt(userID.String(), openai.PromptClassify)
+	require.NoError(t, err)
+	require.NotNil(t, tmpl)
+	assert.Equal(t, "mine", tmpl.Version)
+
+	tmpl, err = store.ResolvePrompt(uuid.New().String(), openai.PromptClassify)
+	require.NoError(t, err)
+	require.NotNil(t, tmpl)
+	assert.Equal(t, "v2", tmpl.Version)
+
+	// 停用後不再分流，也不計入比例
+	require.NoError(t, db.Model(&global).Update("active", false).Error)
+	tmpl, err = store.ResolvePrompt(uuid.New().String(), openai.PromptClassify)
+	require.NoError(t, err)
+	assert.Nil(t, tmpl)
+
+	total, err := RolloutTotal(db, string(openai.PromptClassify), uuid.Nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+}
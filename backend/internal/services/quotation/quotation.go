@@ -0,0 +1,264 @@
+package quotation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNoLines 報價單沒有任何明細（未指定項目且案件尚未對應合作項目）
+	ErrNoLines = errors.New("quotation has no line items")
+	// ErrUnknownItem 指定的合作項目不存在或不屬於使用者
+	ErrUnknownItem = errors.New("collaboration item not found")
+	// ErrInvalidLine 明細的數量、單價或折扣不合理
+	ErrInvalidLine = errors.New("invalid quotation line")
+)
+
+// DefaultCurrency 案件未設定幣別時使用
+const DefaultCurrency = "TWD"
+
+// LineInput 報價明細輸入；指定 ItemID 時未填的標題、說明、單價由合作項目帶入
+type LineInput struct {
+	ItemID          *uuid.UUID `json:"item_id"`
+	Title           string     `json:"title"`
+	Description     string     `json:"description"`
+	Quantity        float64    `json:"quantity"`   // 未填時為 1
+	UnitPrice       *float64   `json:"unit_price"` // 未填時用合作項目定價
+	DiscountPercent float64    `json:"discount_percent"`
+}
+
+// Input 建立報價單的內容
+type Input struct {
+	Lines      []LineInput // 空白時使用案件對應的合作項目（各 1 份、定價）
+	Currency   string      // 空白時用案件幣別
+	Discount   float64     // 整單折扣金額
+	TaxRate    float64     // 稅率（%）
+	ValidUntil *time.Time
+	Terms      *string
+	Notes      *string
+}
+
+// Totals 報價金額
+type Totals struct {
+	Subtotal  float64
+	Discount  float64
+	TaxAmount float64
+	Total     float64
+}
+
+// Service 報價單服務
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService 建立報價單服務
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Create 依案件合作項目或指定明細建立新版本的報價單
+func (s *Service) Create(cs *models.Case, in Input) (*models.Quotation, error) {
+	lines, err := s.lines(cs, in.Lines)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, ErrNoLines
+	}
+	if in.Discount < 0 || in.TaxRate < 0 || in.TaxRate > 100 {
+		return nil, fmt.Errorf("%w: discount and tax rate must be within range", ErrInvalidLine)
+	}
+
+	totals := Compute(lines, in.Discount, in.TaxRate)
+	data, err := json.Marshal(lines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode line items: %w", err)
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(in.Currency))
+	if currency == "" && cs.Currency != nil {
+		currency = *cs.Currency
+	}
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	q := &models.Quotation{
+		UserID:     cs.UserID,
+		CaseID:     cs.ID,
+		Status:     models.QuotationStatusDraft,
+		Currency:   currency,
+		Lines:      data,
+		Subtotal:   totals.Subtotal,
+		Discount:   totals.Discount,
+		TaxRate:    in.TaxRate,
+		TaxAmount:  totals.TaxAmount,
+		Total:      totals.Total,
+		ValidUntil: in.ValidUntil,
+		Terms:      in.Terms,
+		Notes:      in.Notes,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&models.Quotation{}).Where("case_id = ?", cs.ID).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		q.Version = latest + 1
+		q.Number = Number(cs.ID, q.Version)
+		return tx.Omit(clause.Associations).Create(q).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create quotation: %w", err)
+	}
+	return q, nil
+}
+
+// lines 組出報價明細；未指定時使用案件對應的合作項目
+func (s *Service) lines(cs *models.Case, inputs []LineInput) ([]models.QuotationLine, error) {
+	fromCase := len(inputs) == 0
+	if fromCase {
+		for _, id := range cs.CollaborationItems {
+			itemID, err := uuid.Parse(id)
+			if err != nil {
+				continue
+			}
+			inputs = append(inputs, LineInput{ItemID: &itemID})
+		}
+	}
+
+	var ids []uuid.UUID
+	for _, in := range inputs {
+		if in.ItemID != nil {
+			ids = append(ids, *in.ItemID)
+		}
+	}
+	items := make(map[uuid.UUID]models.CollaborationItem, len(ids))
+	if len(ids) > 0 {
+		var found []models.CollaborationItem
+		if err := s.db.Where("user_id = ? AND id IN ?", cs.UserID, ids).Find(&found).Error; err != nil {
+			return nil, fmt.Errorf("failed to load collaboration items: %w", err)
+		}
+		for _, item := range found {
+			items[item.ID] = item
+		}
+	}
+
+	lines := make([]models.QuotationLine, 0, len(inputs))
+	for _, in := range inputs {
+		line := models.QuotationLine{
+			Title:           strings.TrimSpace(in.Title),
+			Description:     strings.TrimSpace(in.Description),
+			Quantity:        in.Quantity,
+			DiscountPercent: in.DiscountPercent,
+		}
+		if in.ItemID != nil {
+			item, ok := items[*in.ItemID]
+			if !ok {
+				// 案件對應的合作項目已被刪除時略過；使用者指定的則回報錯誤
+				if fromCase {
+					continue
+				}
+				return nil, fmt.Errorf("%w: %s", ErrUnknownItem, in.ItemID)
+			}
+			id := item.ID.String()
+			line.ItemID = &id
+			if line.Title == "" {
+				line.Title = item.Title
+			}
+			if line.Description == "" && item.Description != nil {
+				line.Description = *item.Description
+			}
+			line.UnitPrice = item.Price
+		}
+		if in.UnitPrice != nil {
+			line.UnitPrice = *in.UnitPrice
+		}
+		if line.Quantity == 0 {
+			line.Quantity = 1
+		}
+
+		switch {
+		case line.Title == "":
+			return nil, fmt.Errorf("%w: title is required", ErrInvalidLine)
+		case line.Quantity < 0 || line.UnitPrice < 0:
+			return nil, fmt.Errorf("%w: quantity and unit price must not be negative", ErrInvalidLine)
+		case line.DiscountPercent < 0 || line.DiscountPercent > 100:
+			return nil, fmt.Errorf("%w: discount_percent must be between 0 and 100", ErrInvalidLine)
+		}
+		line.Amount = round2(line.Quantity * line.UnitPrice * (1 - line.DiscountPercent/100))
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// Compute 計算報價金額：小計為各明細金額合計，整單折扣不超過小計，稅額以折扣後金額計算
+func Compute(lines []models.QuotationLine, discount, taxRate float64) Totals {
+	var t Totals
+	for _, line := range lines {
+		t.Subtotal += line.Amount
+	}
+	t.Subtotal = round2(t.Subtotal)
+	t.Discount = round2(math.Min(discount, t.Subtotal))
+	t.TaxAmount = round2((t.Subtotal - t.Discount) * taxRate / 100)
+	t.Total = round2(t.Subtotal - t.Discount + t.TaxAmount)
+	return t
+}
+
+// Number 報價單號，如 Q-1A2B3C4D-02
+func Number(caseID uuid.UUID, version int) string {
+	return fmt.Sprintf("Q-%s-%02d", strings.ToUpper(caseID.String()[:8]), version)
+}
+
+// Accept 將報價單標記為對方已接受，並以此版總計更新案件報價金額；先前接受的版本改為 superseded
+func (s *Service) Accept(q *models.Quotation) error {
+	now := time.Now()
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Quotation{}).
+			Where("case_id = ? AND id <> ? AND status = ?", q.CaseID, q.ID, models.QuotationStatusAccepted).
+			Update("status", models.QuotationStatusSuperseded).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Quotation{}).Where("id = ?", q.ID).
+			Updates(map[string]interface{}{"status": models.QuotationStatusAccepted, "accepted_at": now}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Case{}).Where("id = ?", q.CaseID).
+			Updates(map[string]interface{}{"quoted_amount": q.Total, "currency": q.Currency}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to accept quotation: %w", err)
+	}
+	q.Status = models.QuotationStatusAccepted
+	q.AcceptedAt = &now
+	return nil
+}
+
+// MarkSent 記錄報價單已隨回信寄出；已接受或已被取代的版本只記錄郵件不改狀態
+func (s *Service) MarkSent(q *models.Quotation, emailID uuid.UUID) error {
+	updates := map[string]interface{}{"sent_email_id": emailID}
+	if q.Status == models.QuotationStatusDraft {
+		updates["status"] = models.QuotationStatusSent
+	}
+	if err := s.db.Model(&models.Quotation{}).Where("id = ?", q.ID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to mark quotation as sent: %w", err)
+	}
+	q.SentEmailID = &emailID
+	if q.Status == models.QuotationStatusDraft {
+		q.Status = models.QuotationStatusSent
+	}
+	return nil
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
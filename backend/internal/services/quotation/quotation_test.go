@@ -0,0 +1,124 @@
+package quotation
+
+import (
+	"testing"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 設置測試用的資料庫（使用 SQLite）
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Skipf("Skipping test: SQLite not available (CGO required): %v", err)
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.Case{}, &models.CollaborationItem{}, &models.Quotation{})
+	require.NoError(t, err)
+	return db
+}
+
+func ptr(f float64) *float64 { return &f }
+
+func TestCompute(t *testing.T) {
+	lines := []models.QuotationLine{{Amount: 20000}, {Amount: 9000}}
+	totals := Compute(lines, 1000, 5)
+	assert.Equal(t, Totals{Subtotal: 29000, Discount: 1000, TaxAmount: 1400, Total: 29400}, totals)
+
+	// 整單折扣不超過小計
+	totals = Compute(lines, 50000, 0)
+	assert.Equal(t, 29000.0, totals.Discount)
+	assert.Equal(t, 0.0, totals.Total)
+}
+
+func TestCreate_FromCaseItemsAndVersions(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db)
+	user := &models.User{ID: uuid.New(), Email: "creator@example.com", Name: "Creator"}
+	require.NoError(t, db.Create(user).Error)
+
+	desc := "60 秒直式影片"
+	reel := &models.CollaborationItem{UserID: user.ID, Title: "IG Reels", Description: &desc, Price: 20000}
+	story := &models.CollaborationItem{UserID: user.ID, Title: "IG 限動", Price: 5000}
+	require.NoError(t, db.Create(reel).Error)
+	require.NoError(t, db.Create(story).Error)
+
+	currency := "TWD"
+	cs := &models.Case{UserID: user.ID, Title: "新品開箱", BrandName: "Glow", Currency: &currency,
+		CollaborationItems: pq.StringArray{reel.ID.String(), story.ID.String(), uuid.NewString()}}
+	require.NoError(t, db.Create(cs).Error)
+
+	// 未指定明細：使用案件對應的合作項目，已刪除的項目略過
+	q1, err := svc.Create(cs, Input{TaxRate: 5})
+	require.NoError(t, err)
+	assert.Equal(t, 1, q1.Version)
+	assert.Equal(t, Number(cs.ID, 1), q1.Number)
+	assert.Equal(t, "TWD", q1.Currency)
+	lines, err := q1.LineItems()
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+	assert.Equal(t, desc, lines[0].Description)
+	assert.Equal(t, 25000.0, q1.Subtotal)
+	assert.Equal(t, 26250.0, q1.Total)
+
+	// 指定明細：覆寫數量、單價與折扣，並可加入自訂項目
+	q2, err := svc.Create(cs, Input{Currency: "usd", Discount: 500, Lines: []LineInput{
+		{ItemID: &story.ID, Quantity: 3, DiscountPercent: 10},
+		{Title: "授權延長 3 個月", UnitPrice: ptr(8000)},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, q2.Version)
+	assert.Equal(t, "USD", q2.Currency)
+	lines, err = q2.LineItems()
+	require.NoError(t, err)
+	assert.Equal(t, 13500.0, lines[0].Amount)
+	assert.Equal(t, 1.0, lines[1].Quantity)
+	assert.Equal(t, 21000.0, q2.Total)
+
+	_, err = svc.Create(cs, Input{Lines: []LineInput{{ItemID: ptrUUID(uuid.New())}}})
+	assert.ErrorIs(t, err, ErrUnknownItem)
+	_, err = svc.Create(cs, Input{Lines: []LineInput{{Title: "x", DiscountPercent: 120}}})
+	assert.ErrorIs(t, err, ErrInvalidLine)
+	_, err = svc.Create(&models.Case{ID: uuid.New(), UserID: user.ID}, Input{})
+	assert.ErrorIs(t, err, ErrNoLines)
+}
+
+func TestAccept_UpdatesQuotedAmount(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db)
+	user := &models.User{ID: uuid.New(), Email: "creator@example.com", Name: "Creator"}
+	require.NoError(t, db.Create(user).Error)
+	cs := &models.Case{UserID: user.ID, Title: "新品開箱", BrandName: "Glow"}
+	require.NoError(t, db.Create(cs).Error)
+
+	q1, err := svc.Create(cs, Input{Lines: []LineInput{{Title: "IG Reels", UnitPrice: ptr(20000)}}})
+	require.NoError(t, err)
+	q2, err := svc.Create(cs, Input{Lines: []LineInput{{Title: "IG Reels", UnitPrice: ptr(18000)}}})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.MarkSent(q1, uuid.New()))
+	assert.Equal(t, models.QuotationStatusSent, q1.Status)
+	require.NoError(t, svc.Accept(q1))
+	require.NoError(t, svc.Accept(q2))
+
+	var updated models.Case
+	require.NoError(t, db.First(&updated, "id = ?", cs.ID).Error)
+	assert.Equal(t, 18000.0, *updated.QuotedAmount)
+	assert.Equal(t, "TWD", *updated.Currency)
+
+	var first models.Quotation
+	require.NoError(t, db.First(&first, "id = ?", q1.ID).Error)
+	assert.Equal(t, models.QuotationStatusSuperseded, first.Status)
+	assert.NotNil(t, first.SentEmailID)
+	assert.Equal(t, models.QuotationStatusAccepted, q2.Status)
+}
+
+func ptrUUID(id uuid.UUID) *uuid.UUID { return &id }
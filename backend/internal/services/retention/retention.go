@@ -0,0 +1,354 @@
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// 清除項目（audit counts 的 key）
+const (
+	KeyEmailBodies        = "emails.body_purged"
+	KeyEmails             = "emails.deleted"
+	KeyCases              = "cases.deleted"
+	KeyCasePhases         = "case_phases.deleted"
+	KeyCollaborationItems = "collaboration_items.deleted"
+	KeyWorkflowTemplates  = "workflow_templates.deleted"
+	KeyWorkflowPhases     = "workflow_phases.deleted"
+	KeyMailImports        = "mail_imports.deleted"
+	KeyOAuthAccounts      = "oauth_accounts.deleted"
+)
+
+// 執行結果狀態
+const (
+	StatusCompleted = "completed"
+	StatusPartial   = "partial" // 達到每次批次上限，剩餘的下次再處理
+	StatusFailed    = "failed"
+)
+
+// Report 清除（或試算）結果
+type Report struct {
+	UserID    uuid.UUID              `json:"user_id"`
+	DryRun    bool                   `json:"dry_run"`
+	Status    string                 `json:"status"`
+	Policy    models.RetentionPolicy `json:"policy"`
+	Counts    map[string]int64       `json:"counts"`
+	Total     int64                  `json:"total"`
+	StartedAt time.Time              `json:"started_at"`
+}
+
+// Service 資料保留服務
+type Service struct {
+	db  *gorm.DB
+	cfg config.RetentionConfig
+}
+
+// NewService 建立資料保留服務
+func NewService(db *gorm.DB, cfg config.RetentionConfig) *Service {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.MaxBatchesPerRun <= 0 {
+		cfg.MaxBatchesPerRun = 20
+	}
+	if cfg.DefaultSoftDeletePurgeDays <= 0 {
+		cfg.DefaultSoftDeletePurgeDays = 30
+	}
+	return &Service{db: db, cfg: cfg}
+}
+
+// GetPolicy 取得使用者的保留政策（未設定時回傳預設值）
+func (s *Service) GetPolicy(userID uuid.UUID) (models.RetentionPolicy, error) {
+	var policy models.RetentionPolicy
+	err := s.db.Where("user_id = ?", userID).First(&policy).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.DefaultRetentionPolicy(userID, s.cfg.DefaultSoftDeletePurgeDays), nil
+	}
+	return policy, err
+}
+
+// Run 依使用者的保留政策清除資料；dryRun 時只計算數量，不修改資料也不寫 audit
+func (s *Service) Run(ctx context.Context, userID uuid.UUID, dryRun bool) (*Report, error) {
+	policy, err := s.GetPolicy(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load retention policy: %w", err)
+	}
+
+	report := &Report{
+		UserID:    userID,
+		DryRun:    dryRun,
+		Status:    StatusCompleted,
+		Policy:    policy,
+		Counts:    make(map[string]int64),
+		StartedAt: time.Now(),
+	}
+	if !policy.Enabled {
+		return report, nil
+	}
+
+	runErr := s.apply(ctx, report, policy)
+	if runErr != nil {
+		report.Status = StatusFailed
+	}
+	for _, n := range report.Counts {
+		report.Total += n
+	}
+
+	if !dryRun {
+		if err := s.writeAudit(report, runErr); err != nil {
+			return report, fmt.Errorf("failed to write retention audit: %w", err)
+		}
+	}
+	return report, runErr
+}
+
+// apply 依序執行各項清除
+func (s *Service) apply(ctx context.Context, report *Report, policy models.RetentionPolicy) error {
+	now := time.Now()
+
+	// 1. 清除非案件郵件的內文
+	if policy.EmailBodyRetentionDays != nil && *policy.EmailBodyRetentionDays > 0 {
+		cutoff := now.AddDate(0, 0, -*policy.EmailBodyRetentionDays)
+		if err := s.purgeEmailBodies(ctx, report, policy, cutoff); err != nil {
+			return err
+		}
+	}
+
+	// 2. 永久刪除超過期限的軟刪除資料
+	if policy.SoftDeletePurgeDays > 0 {
+		cutoff := now.AddDate(0, 0, -policy.SoftDeletePurgeDays)
+		for _, target := range s.hardDeleteTargets(report.UserID, policy) {
+			if err := s.hardDelete(ctx, report, target, cutoff); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// userEmails 限定為使用者（含已中斷連結帳號）的郵件
+func userEmails(db *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return db.Where("oauth_account_id IN (?)",
+		db.Session(&gorm.Session{NewDB: true}).Unscoped().Model(&models.OAuthAccount{}).Select("id").Where("user_id = ?", userID))
+}
+
+// purgeEmailBodies 分批清除郵件內文（保留 header、snippet 與案件關聯）
+func (s *Service) purgeEmailBodies(ctx context.Context, report *Report, policy models.RetentionPolicy, cutoff time.Time) error {
+	scope := func() *gorm.DB {
+		q := userEmails(s.db.Model(&models.Email{}), report.UserID).
+			Where("received_at < ? AND body_purged_at IS NULL", cutoff).
+			Where("body_text IS NOT NULL OR body_html IS NOT NULL")
+		if policy.KeepCaseEmails {
+			q = q.Where("case_id IS NULL")
+		}
+		return q
+	}
+
+	if report.DryRun {
+		var count int64
+		if err := scope().Count(&count).Error; err != nil {
+			return err
+		}
+		report.Counts[KeyEmailBodies] = count
+		return nil
+	}
+
+	return s.inBatches(ctx, report, KeyEmailBodies, func() ([]uuid.UUID, error) {
+		var ids []uuid.UUID
+		err := scope().Limit(s.cfg.BatchSize).Pluck("id", &ids).Error
+		return ids, err
+	}, func(tx *gorm.DB, ids []uuid.UUID) error {
+		// 附件擷取出的文字與摘要視同內文一併清除
+		if err := tx.Model(&models.EmailAttachment{}).Where("email_id IN ?", ids).Updates(map[string]interface{}{
+			"text":    nil,
+			"summary": nil,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Email{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"body_text":      nil,
+			"body_html":      nil,
+			"body_purged_at": time.Now(),
+		}).Error
+	})
+}
+
+// hardDeleteTarget 永久刪除的資料表設定
+type hardDeleteTarget struct {
+	key     string
+	model   interface{}
+	scope   func(db *gorm.DB) *gorm.DB               // 限定為該使用者的資料
+	before  func(tx *gorm.DB, ids []uuid.UUID) error // 刪除前處理關聯（沒有 FK 的欄位需手動清空）
+	cascade *cascadeTarget                           // 隨上層資料一併刪除的子資料
+}
+
+// cascadeTarget 刪除上層資料時一併刪除的子資料（計入 key，試算時也計算）
+type cascadeTarget struct {
+	key   string
+	model interface{}
+	scope func(db *gorm.DB, parents interface{}) *gorm.DB // parents 為上層 ID 清單或子查詢
+}
+
+// hardDeleteTargets 依相依順序排列（子資料先刪）
+func (s *Service) hardDeleteTargets(userID uuid.UUID, policy models.RetentionPolicy) []hardDeleteTarget {
+	byUser := func(db *gorm.DB) *gorm.DB { return db.Where("user_id = ?", userID) }
+	sub := func(model interface{}) *gorm.DB {
+		return s.db.Session(&gorm.Session{NewDB: true}).Unscoped().Model(model).Select("id").Where("user_id = ?", userID)
+	}
+	// 保留案件郵件時，已歸入案件的郵件不永久刪除（爭議時的往來紀錄）
+	keepCaseEmails := func(db *gorm.DB) *gorm.DB {
+		if policy.KeepCaseEmails {
+			return db.Where("case_id IS NULL")
+		}
+		return db
+	}
+
+	return []hardDeleteTarget{
+		{key: KeyCasePhases, model: &models.CasePhase{}, scope: func(db *gorm.DB) *gorm.DB {
+			return db.Where("case_id IN (?)", sub(&models.Case{}))
+		}},
+		{key: KeyCases, model: &models.Case{}, scope: byUser, before: func(tx *gorm.DB, ids []uuid.UUID) error {
+			// emails.case_id 沒有 FK，需手動解除關聯
+			return tx.Model(&models.Email{}).Unscoped().Where("case_id IN ?", ids).Update("case_id", nil).Error
+		}},
+		{key: KeyWorkflowPhases, model: &models.WorkflowPhase{}, scope: func(db *gorm.DB) *gorm.DB {
+			return db.Where("workflow_template_id IN (?)", sub(&models.WorkflowTemplate{}))
+		}},
+		{key: KeyWorkflowTemplates, model: &models.WorkflowTemplate{}, scope: byUser},
+		{key: KeyCollaborationItems, model: &models.CollaborationItem{}, scope: byUser},
+		{key: KeyMailImports, model: &models.MailImport{}, scope: byUser},
+		{key: KeyEmails, model: &models.Email{}, scope: func(db *gorm.DB) *gorm.DB {
+			return keepCaseEmails(userEmails(db, userID))
+		}, before: models.PromoteDuplicates},
+		{key: KeyOAuthAccounts, model: &models.OAuthAccount{}, scope: func(db *gorm.DB) *gorm.DB {
+			db = byUser(db)
+			if policy.KeepCaseEmails {
+				// 帳號刪除會連帶刪除郵件（Postgres CASCADE）：仍有案件郵件的帳號保留
+				db = db.Where("id NOT IN (?)", s.db.Session(&gorm.Session{NewDB: true}).Unscoped().
+					Model(&models.Email{}).Select("oauth_account_id").Where("case_id IS NOT NULL"))
+			}
+			return db
+		}, before: func(tx *gorm.DB, ids []uuid.UUID) error {
+			var emailIDs []uuid.UUID
+			if err := tx.Unscoped().Model(&models.Email{}).Where("oauth_account_id IN ?", ids).Pluck("id", &emailIDs).Error; err != nil {
+				return err
+			}
+			return models.PromoteDuplicates(tx, emailIDs)
+		}, cascade: &cascadeTarget{key: KeyEmails, model: &models.Email{}, scope: func(db *gorm.DB, parents interface{}) *gorm.DB {
+			// 已中斷連結帳號底下仍存在的郵件一併刪除（Postgres 有 CASCADE，這裡明確處理以便計數一致）
+			return db.Where("oauth_account_id IN (?)", parents)
+		}}},
+	}
+}
+
+// hardDelete 分批永久刪除超過期限的軟刪除資料
+func (s *Service) hardDelete(ctx context.Context, report *Report, target hardDeleteTarget, cutoff time.Time) error {
+	scope := func() *gorm.DB {
+		return target.scope(s.db.Unscoped().Model(target.model)).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+	}
+
+	if report.DryRun {
+		var count int64
+		if err := scope().Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			report.Counts[target.key] += count
+		}
+		if target.cascade != nil && count > 0 {
+			// 本身已過期的子資料由自己的項目計算，不重複計入
+			var cascaded int64
+			err := target.cascade.scope(s.db.Unscoped().Model(target.cascade.model), scope().Select("id")).
+				Where("deleted_at IS NULL OR deleted_at >= ?", cutoff).
+				Count(&cascaded).Error
+			if err != nil {
+				return err
+			}
+			if cascaded > 0 {
+				report.Counts[target.cascade.key] += cascaded
+			}
+		}
+		return nil
+	}
+
+	return s.inBatches(ctx, report, target.key, func() ([]uuid.UUID, error) {
+		var ids []uuid.UUID
+		err := scope().Limit(s.cfg.BatchSize).Pluck("id", &ids).Error
+		return ids, err
+	}, func(tx *gorm.DB, ids []uuid.UUID) error {
+		if target.before != nil {
+			if err := target.before(tx, ids); err != nil {
+				return err
+			}
+		}
+		if target.cascade != nil {
+			res := target.cascade.scope(tx.Unscoped(), ids).Delete(target.cascade.model)
+			if res.Error != nil {
+				return res.Error
+			}
+			report.Counts[target.cascade.key] += res.RowsAffected
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(target.model).Error
+	})
+}
+
+// inBatches 重複「取一批 ID → 在交易中處理」直到沒有資料或達到批次上限
+func (s *Service) inBatches(ctx context.Context, report *Report, key string, next func() ([]uuid.UUID, error), apply func(tx *gorm.DB, ids []uuid.UUID) error) error {
+	for batch := 0; ; batch++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if batch >= s.cfg.MaxBatchesPerRun {
+			report.Status = StatusPartial
+			return nil
+		}
+
+		ids, err := next()
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := s.db.Transaction(func(tx *gorm.DB) error {
+			return apply(tx, ids)
+		}); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		report.Counts[key] += int64(len(ids))
+
+		if len(ids) < s.cfg.BatchSize {
+			return nil
+		}
+	}
+}
+
+// writeAudit 寫入清除紀錄
+func (s *Service) writeAudit(report *Report, runErr error) error {
+	policyJSON, _ := json.Marshal(report.Policy)
+	countsJSON, _ := json.Marshal(report.Counts)
+
+	audit := models.RetentionAudit{
+		UserID:      report.UserID,
+		Status:      report.Status,
+		Policy:      datatypes.JSON(policyJSON),
+		Counts:      datatypes.JSON(countsJSON),
+		Total:       report.Total,
+		StartedAt:   report.StartedAt,
+		CompletedAt: time.Now(),
+	}
+	if runErr != nil {
+		msg := runErr.Error()
+		audit.Error = &msg
+	}
+	return s.db.Create(&audit).Error
+}
@@ -0,0 +1,238 @@
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 設置測試用的資料庫（使用 SQLite）
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Skipf("Skipping test: SQLite not available (CGO required): %v", err)
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.OAuthAccount{}, &models.Email{}, &models.Case{}, &models.CasePhase{},
+		&models.WorkflowTemplate{}, &models.WorkflowPhase{}, &models.CollaborationItem{}, &models.MailImport{},
+		&models.RetentionPolicy{}, &models.RetentionAudit{}, &models.EmailAttachment{})
+	require.NoError(t, err)
+	return db
+}
+
+type fixture struct {
+	db      *gorm.DB
+	user    *models.User
+	account *models.OAuthAccount
+	svc     *Service
+}
+
+func newFixture(t *testing.T) *fixture {
+	db := setupTestDB(t)
+	user := &models.User{ID: uuid.New(), Email: "creator@example.com", Name: "Creator"}
+	require.NoError(t, db.Create(user).Error)
+	account := &models.OAuthAccount{
+		ID: uuid.New(), UserID: user.ID, Provider: models.OAuthProviderGoogle, Email: "creator@example.com",
+		AccessToken: "a", RefreshToken: "r", TokenExpiry: time.Now().Add(time.Hour),
+	}
+	require.NoError(t, db.Create(account).Error)
+	return &fixture{db: db, user: user, account: account, svc: NewService(db, config.RetentionConfig{BatchSize: 2, MaxBatchesPerRun: 10})}
+}
+
+func (f *fixture) email(t *testing.T, receivedAt time.Time, caseID *uuid.UUID) *models.Email {
+	body := "body"
+	e := &models.Email{
+		ID: uuid.New(), OAuthAccountID: f.account.ID, ProviderMessageID: uuid.NewString(),
+		FromEmail: "brand@example.com", BodyText: &body, BodyHTML: &body, ReceivedAt: receivedAt, CaseID: caseID,
+	}
+	require.NoError(t, f.db.Create(e).Error)
+	return e
+}
+
+func (f *fixture) setPolicy(t *testing.T, bodyDays *int, keepCaseEmails bool) {
+	policy := models.DefaultRetentionPolicy(f.user.ID, 30)
+	policy.EmailBodyRetentionDays = bodyDays
+	policy.KeepCaseEmails = keepCaseEmails
+	require.NoError(t, f.db.Omit("User").Create(&policy).Error)
+}
+
+func TestRun_PurgesOldBodiesButKeepsCaseEmails(t *testing.T) {
+	f := newFixture(t)
+	days := 90
+	f.setPolicy(t, &days, true)
+
+	cs := &models.Case{ID: uuid.New(), UserID: f.user.ID, Title: "合作", BrandName: "品牌"}
+	require.NoError(t, f.db.Omit("User").Create(cs).Error)
+
+	old := time.Now().AddDate(0, 0, -120)
+	var oldEmails []*models.Email
+	for i := 0; i < 3; i++ {
+		oldEmails = append(oldEmails, f.email(t, old, nil))
+	}
+	caseEmail := f.email(t, old, &cs.ID)
+	recent := f.email(t, time.Now(), nil)
+
+	report, err := f.svc.Run(context.Background(), f.user.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCompleted, report.Status)
+	assert.Equal(t, int64(3), report.Counts[KeyEmailBodies])
+
+	for _, e := range oldEmails {
+		var got models.Email
+		require.NoError(t, f.db.First(&got, "id = ?", e.ID).Error)
+		assert.Nil(t, got.BodyText)
+		assert.Nil(t, got.BodyHTML)
+		assert.NotNil(t, got.BodyPurgedAt)
+	}
+	for _, e := range []*models.Email{caseEmail, recent} {
+		var got models.Email
+		require.NoError(t, f.db.First(&got, "id = ?", e.ID).Error)
+		assert.NotNil(t, got.BodyText)
+		assert.Nil(t, got.BodyPurgedAt)
+	}
+
+	var audits []models.RetentionAudit
+	require.NoError(t, f.db.Find(&audits).Error)
+	require.Len(t, audits, 1)
+	var counts map[string]int64
+	require.NoError(t, json.Unmarshal(audits[0].Counts, &counts))
+	assert.Equal(t, int64(3), counts[KeyEmailBodies])
+	assert.Equal(t, int64(3), audits[0].Total)
+}
+
+func TestRun_HardDeletesExpiredSoftDeletes(t *testing.T) {
+	f := newFixture(t)
+
+	expired := f.email(t, time.Now(), nil)
+	recentlyDeleted := f.email(t, time.Now(), nil)
+	cs := &models.Case{ID: uuid.New(), UserID: f.user.ID, Title: "舊案件", BrandName: "品牌"}
+	require.NoError(t, f.db.Omit("User").Create(cs).Error)
+	linked := f.email(t, time.Now(), &cs.ID)
+
+	require.NoError(t, f.db.Delete(expired).Error)
+	require.NoError(t, f.db.Delete(recentlyDeleted).Error)
+	require.NoError(t, f.db.Delete(cs).Error)
+	longAgo := time.Now().AddDate(0, 0, -31)
+	require.NoError(t, f.db.Unscoped().Model(&models.Email{}).Where("id = ?", expired.ID).Update("deleted_at", longAgo).Error)
+	require.NoError(t, f.db.Unscoped().Model(&models.Case{}).Where("id = ?", cs.ID).Update("deleted_at", longAgo).Error)
+
+	report, err := f.svc.Run(context.Background(), f.user.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), report.Counts[KeyEmails])
+	assert.Equal(t, int64(1), report.Counts[KeyCases])
+
+	var count int64
+	f.db.Unscoped().Model(&models.Email{}).Where("id = ?", expired.ID).Count(&count)
+	assert.Zero(t, count)
+	f.db.Unscoped().Model(&models.Email{}).Where("id = ?", recentlyDeleted.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// 案件刪除後郵件保留但解除關聯
+	var got models.Email
+	require.NoError(t, f.db.First(&got, "id = ?", linked.ID).Error)
+	assert.Nil(t, got.CaseID)
+}
+
+func TestRun_DisconnectedAccountKeepsCaseEmails(t *testing.T) {
+	f := newFixture(t)
+	f.setPolicy(t, nil, true)
+
+	cs := &models.Case{ID: uuid.New(), UserID: f.user.ID, Title: "合作", BrandName: "品牌"}
+	require.NoError(t, f.db.Omit("User").Create(cs).Error)
+	caseEmail := f.email(t, time.Now(), &cs.ID)
+	expiredCaseEmail := f.email(t, time.Now(), &cs.ID)
+	require.NoError(t, f.db.Unscoped().Model(&models.Email{}).Where("id = ?", expiredCaseEmail.ID).
+		Update("deleted_at", time.Now().AddDate(0, 0, -31)).Error)
+
+	// 沒有案件郵件的已中斷連結帳號：帳號與郵件一併刪除
+	other := &models.OAuthAccount{
+		ID: uuid.New(), UserID: f.user.ID, Provider: models.OAuthProviderGoogle, Email: "old@example.com",
+		AccessToken: "a", RefreshToken: "r", TokenExpiry: time.Now(),
+	}
+	require.NoError(t, f.db.Create(other).Error)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, f.db.Create(&models.Email{
+			OAuthAccountID: other.ID, ProviderMessageID: uuid.NewString(), FromEmail: "brand@example.com", ReceivedAt: time.Now(),
+		}).Error)
+	}
+	require.NoError(t, f.db.Unscoped().Model(&models.OAuthAccount{}).Where("id IN ?", []uuid.UUID{f.account.ID, other.ID}).
+		Update("deleted_at", time.Now().AddDate(0, 0, -31)).Error)
+
+	dry, err := f.svc.Run(context.Background(), f.user.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), dry.Counts[KeyOAuthAccounts])
+	assert.Equal(t, int64(2), dry.Counts[KeyEmails])
+
+	report, err := f.svc.Run(context.Background(), f.user.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, dry.Counts, report.Counts)
+
+	var count int64
+	f.db.Unscoped().Model(&models.Email{}).Where("id IN ?", []uuid.UUID{caseEmail.ID, expiredCaseEmail.ID}).Count(&count)
+	assert.Equal(t, int64(2), count)
+	f.db.Unscoped().Model(&models.Email{}).Where("oauth_account_id = ?", other.ID).Count(&count)
+	assert.Zero(t, count)
+	f.db.Unscoped().Model(&models.OAuthAccount{}).Where("id = ?", f.account.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestRun_DryRunChangesNothing(t *testing.T) {
+	f := newFixture(t)
+	days := 30
+	f.setPolicy(t, &days, true)
+	e := f.email(t, time.Now().AddDate(0, 0, -60), nil)
+
+	report, err := f.svc.Run(context.Background(), f.user.ID, true)
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, int64(1), report.Counts[KeyEmailBodies])
+
+	var got models.Email
+	require.NoError(t, f.db.First(&got, "id = ?", e.ID).Error)
+	assert.NotNil(t, got.BodyText)
+
+	var audits int64
+	f.db.Model(&models.RetentionAudit{}).Count(&audits)
+	assert.Zero(t, audits)
+}
+
+func TestRun_PartialWhenBatchLimitReached(t *testing.T) {
+	f := newFixture(t)
+	f.svc = NewService(f.db, config.RetentionConfig{BatchSize: 2, MaxBatchesPerRun: 1})
+	days := 30
+	f.setPolicy(t, &days, true)
+	for i := 0; i < 5; i++ {
+		f.email(t, time.Now().AddDate(0, 0, -60), nil)
+	}
+
+	report, err := f.svc.Run(context.Background(), f.user.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPartial, report.Status)
+	assert.Equal(t, int64(2), report.Counts[KeyEmailBodies])
+}
+
+func TestRun_DisabledPolicySkips(t *testing.T) {
+	f := newFixture(t)
+	policy := models.DefaultRetentionPolicy(f.user.ID, 30)
+	policy.Enabled = false
+	require.NoError(t, f.db.Omit("User").Create(&policy).Error)
+
+	report, err := f.svc.Run(context.Background(), f.user.ID, false)
+	require.NoError(t, err)
+	assert.Zero(t, report.Total)
+
+	var audits int64
+	f.db.Model(&models.RetentionAudit{}).Count(&audits)
+	assert.Zero(t, audits)
+}
@@ -0,0 +1,151 @@
+package snooze
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxSnoozeDuration 最長可延後的時間
+const MaxSnoozeDuration = 365 * 24 * time.Hour
+
+// batchSize 每批喚醒的筆數
+const batchSize = 200
+
+// ValidateUntil 檢查延後時間：必須在未來且不超過 MaxSnoozeDuration
+func ValidateUntil(until, now time.Time) error {
+	if !until.After(now) {
+		return fmt.Errorf("snooze time must be in the future")
+	}
+	if until.Sub(now) > MaxSnoozeDuration {
+		return fmt.Errorf("snooze time must be within one year")
+	}
+	return nil
+}
+
+// WakeResult 喚醒結果
+type WakeResult struct {
+	Emails int
+	Cases  int
+}
+
+// dueEmail 到期郵件（含所屬使用者）
+type dueEmail struct {
+	ID      uuid.UUID
+	UserID  uuid.UUID
+	Subject *string
+}
+
+// WakeDue 喚醒所有已到期的郵件與案件：郵件標為未讀，並各建立一則站內通知
+func WakeDue(ctx context.Context, db *gorm.DB, now time.Time) (*WakeResult, error) {
+	result := &WakeResult{}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		var due []dueEmail
+		err := db.Model(&models.Email{}).
+			Select("emails.id, emails.subject, oauth_accounts.user_id").
+			Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
+			Where("emails.snoozed_until IS NOT NULL AND emails.snoozed_until <= ?", now).
+			Order("emails.snoozed_until ASC").
+			Limit(batchSize).
+			Scan(&due).Error
+		if err != nil {
+			return result, fmt.Errorf("failed to query snoozed emails: %w", err)
+		}
+		if len(due) == 0 {
+			break
+		}
+
+		ids := make([]uuid.UUID, 0, len(due))
+		notifications := make([]models.Notification, 0, len(due))
+		for _, e := range due {
+			ids = append(ids, e.ID)
+			emailID := e.ID
+			notifications = append(notifications, models.Notification{
+				UserID:  e.UserID,
+				Type:    models.NotificationTypeSnoozeExpired,
+				Title:   "延後的郵件已到期：" + subjectOrDefault(e.Subject),
+				EmailID: &emailID,
+			})
+		}
+
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.Email{}).Where("id IN ?", ids).
+				Updates(map[string]interface{}{"snoozed_until": nil, "is_read": false}).Error; err != nil {
+				return err
+			}
+			return tx.Omit(clause.Associations).Create(&notifications).Error
+		}); err != nil {
+			return result, fmt.Errorf("failed to wake snoozed emails: %w", err)
+		}
+		result.Emails += len(due)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		var due []models.Case
+		err := db.Where("snoozed_until IS NOT NULL AND snoozed_until <= ?", now).
+			Order("snoozed_until ASC").
+			Limit(batchSize).
+			Find(&due).Error
+		if err != nil {
+			return result, fmt.Errorf("failed to query snoozed cases: %w", err)
+		}
+		if len(due) == 0 {
+			break
+		}
+
+		ids := make([]uuid.UUID, 0, len(due))
+		notifications := make([]models.Notification, 0, len(due))
+		for _, cs := range due {
+			ids = append(ids, cs.ID)
+			caseID := cs.ID
+			notifications = append(notifications, models.Notification{
+				UserID: cs.UserID,
+				Type:   models.NotificationTypeSnoozeExpired,
+				Title:  "延後的案件已到期：" + truncate(cs.Title),
+				CaseID: &caseID,
+			})
+		}
+
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.Case{}).Where("id IN ?", ids).Update("snoozed_until", nil).Error; err != nil {
+				return err
+			}
+			return tx.Omit(clause.Associations).Create(&notifications).Error
+		}); err != nil {
+			return result, fmt.Errorf("failed to wake snoozed cases: %w", err)
+		}
+		result.Cases += len(due)
+	}
+
+	return result, nil
+}
+
+func subjectOrDefault(subject *string) string {
+	if subject == nil || *subject == "" {
+		return "(無主旨)"
+	}
+	return truncate(*subject)
+}
+
+// truncate 避免通知標題超過欄位長度
+func truncate(s string) string {
+	runes := []rune(s)
+	if len(runes) > 200 {
+		return string(runes[:200]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,92 @@
+package snooze
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 設置測試用的資料庫（使用 SQLite）
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Skipf("Skipping test: SQLite not available (CGO required): %v", err)
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.OAuthAccount{}, &models.Email{}, &models.Case{}, &models.Notification{})
+	require.NoError(t, err)
+	return db
+}
+
+func TestWakeDue(t *testing.T) {
+	db := setupTestDB(t)
+	user := &models.User{ID: uuid.New(), Email: "creator@example.com", Name: "Creator"}
+	require.NoError(t, db.Create(user).Error)
+	account := &models.OAuthAccount{
+		ID: uuid.New(), UserID: user.ID, Provider: models.OAuthProviderGoogle, Email: "creator@example.com",
+		AccessToken: "a", RefreshToken: "r", TokenExpiry: time.Now().Add(time.Hour),
+	}
+	require.NoError(t, db.Create(account).Error)
+
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	subject := "下週二回覆"
+
+	due := &models.Email{OAuthAccountID: account.ID, ProviderMessageID: "m1", FromEmail: "brand@example.com",
+		Subject: &subject, ReceivedAt: now, IsRead: true, SnoozedUntil: &past}
+	later := &models.Email{OAuthAccountID: account.ID, ProviderMessageID: "m2", FromEmail: "brand@example.com",
+		ReceivedAt: now, IsRead: true, SnoozedUntil: &future}
+	require.NoError(t, db.Create(due).Error)
+	require.NoError(t, db.Create(later).Error)
+
+	cs := &models.Case{UserID: user.ID, Title: "春季合作", BrandName: "品牌", SnoozedUntil: &past}
+	require.NoError(t, db.Omit("User").Create(cs).Error)
+
+	result, err := WakeDue(context.Background(), db, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Emails)
+	assert.Equal(t, 1, result.Cases)
+
+	var got models.Email
+	require.NoError(t, db.First(&got, "id = ?", due.ID).Error)
+	assert.Nil(t, got.SnoozedUntil)
+	assert.False(t, got.IsRead)
+
+	var gotLater models.Email
+	require.NoError(t, db.First(&gotLater, "id = ?", later.ID).Error)
+	assert.NotNil(t, gotLater.SnoozedUntil)
+	assert.True(t, gotLater.IsRead)
+
+	var gotCase models.Case
+	require.NoError(t, db.First(&gotCase, "id = ?", cs.ID).Error)
+	assert.Nil(t, gotCase.SnoozedUntil)
+
+	var notifications []models.Notification
+	require.NoError(t, db.Order("title").Find(&notifications).Error)
+	require.Len(t, notifications, 2)
+	for _, n := range notifications {
+		assert.Equal(t, user.ID, n.UserID)
+		assert.Equal(t, models.NotificationTypeSnoozeExpired, n.Type)
+	}
+
+	// 再次執行不會重複通知
+	result, err = WakeDue(context.Background(), db, now)
+	require.NoError(t, err)
+	assert.Zero(t, result.Emails+result.Cases)
+}
+
+func TestValidateUntil(t *testing.T) {
+	now := time.Now()
+	assert.Error(t, ValidateUntil(now.Add(-time.Second), now))
+	assert.Error(t, ValidateUntil(now.Add(MaxSnoozeDuration+time.Hour), now))
+	assert.NoError(t, ValidateUntil(now.Add(time.Hour), now))
+}
@@ -0,0 +1,310 @@
+package style
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 語氣分類
+const (
+	FormalityFormal  = "formal"
+	FormalityNeutral = "neutral"
+	FormalityCasual  = "casual"
+)
+
+// Phrase 常用片語與出現次數
+type Phrase struct {
+	Text  string `json:"text"`
+	Count int    `json:"count"`
+}
+
+// Stats 寄出郵件的寫作風格統計
+type Stats struct {
+	SampleCount    int
+	Greetings      []Phrase
+	Closings       []Phrase
+	Formality      string
+	FormalityScore float64
+	ChineseRatio   float64
+	MedianLength   int
+	EmojiRate      float64
+	CommonEmojis   []string
+}
+
+// quoteHeaders 回信中引用原信的開頭（之後的內容不是使用者寫的）
+var quoteHeaders = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)^on\s.+wrote:?$`),
+	regexp.MustCompile(`^.+於.+寫道[:：]?$`),
+	regexp.MustCompile(`(?i)^-{2,}\s*(original message|forwarded message)`),
+	regexp.MustCompile(`^-{2,}\s*(原始郵件|轉寄的郵件)`),
+	regexp.MustCompile(`(?i)^(from|寄件者)\s*[:：]`),
+}
+
+// isQuoteHeader 是否為引用原信的開頭
+func isQuoteHeader(line string) bool {
+	for _, re := range quoteHeaders {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanReply 去掉引用的原信、簽名檔分隔線之後的內容，只留下使用者自己寫的部分
+func CleanReply(body string) string {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	kept := make([]string, 0, len(lines))
+	for i, raw := range lines {
+		line := strings.TrimSpace(raw)
+		if line == "--" { // 簽名檔分隔線 "-- "
+			break
+		}
+		// Gmail 的 "On ... wrote:" 可能被折成兩行
+		if isQuoteHeader(line) || (i+1 < len(lines) && strings.HasPrefix(strings.ToLower(line), "on ") && isQuoteHeader(line+" "+strings.TrimSpace(lines[i+1]))) {
+			break
+		}
+		if strings.HasPrefix(line, ">") {
+			continue
+		}
+		kept = append(kept, strings.TrimRight(raw, " \t"))
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// nonEmptyLines 非空白的行
+func nonEmptyLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+var (
+	latinGreetings   = []string{"good morning", "good afternoon", "good evening", "greetings", "hello", "dear", "hey", "hi"}
+	chineseGreetings = []string{"大家好", "您好", "你好", "哈囉", "嗨", "早安", "午安", "晚安"}
+
+	latinClosings   = []string{"regards", "best", "thanks", "thank you", "cheers", "sincerely", "talk soon", "warmly"}
+	chineseClosings = []string{"謝謝", "感謝", "敬祝", "順心", "平安", "再麻煩", "麻煩了", "期待"}
+)
+
+// isPunct 開頭/結尾常見的標點
+func isPunct(r rune) bool {
+	return strings.ContainsRune(",，!！:：.。~～、", r)
+}
+
+// leadingPunct 字串開頭的標點（最多兩個字元）
+func leadingPunct(s string) string {
+	var b strings.Builder
+	for i, r := range []rune(s) {
+		if i >= 2 || !isPunct(r) {
+			break
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// greetingPattern 將開頭一行轉成不含對方名字的樣式，如 "Hi Amy," → "Hi {name},"、"Amy 您好：" → "{name} 您好："
+func greetingPattern(line string) (string, bool) {
+	if utf8.RuneCountInString(line) > 40 {
+		return "", false
+	}
+	lower := strings.ToLower(line)
+	for _, g := range latinGreetings {
+		if !strings.HasPrefix(lower, g) {
+			continue
+		}
+		rest := line[len(g):]
+		if r, _ := utf8.DecodeRuneInString(rest); rest != "" && unicode.IsLetter(r) {
+			continue // 如 "Hints"
+		}
+		prefix := line[:len(g)]
+		rest = strings.TrimSpace(rest)
+		punct := ""
+		if r, size := utf8.DecodeLastRuneInString(rest); rest != "" && isPunct(r) {
+			punct = rest[len(rest)-size:]
+			rest = strings.TrimSpace(rest[:len(rest)-size])
+		}
+		if rest != "" {
+			return prefix + " {name}" + punct, true
+		}
+		return prefix + punct, true
+	}
+	for _, g := range chineseGreetings {
+		idx := strings.Index(line, g)
+		if idx < 0 {
+			continue
+		}
+		pattern := g + leadingPunct(strings.TrimSpace(line[idx+len(g):]))
+		if strings.TrimSpace(line[:idx]) != "" {
+			pattern = "{name} " + pattern
+		}
+		return pattern, true
+	}
+	return "", false
+}
+
+// closingLine 結尾最後幾行中的結語（如 "Best regards," 或 "謝謝！"）
+func closingLine(lines []string) (string, bool) {
+	for i := len(lines) - 1; i >= 0 && i >= len(lines)-3; i-- {
+		line := lines[i]
+		if utf8.RuneCountInString(line) > 30 {
+			continue
+		}
+		lower := strings.ToLower(line)
+		for _, c := range latinClosings {
+			if strings.HasPrefix(lower, c) {
+				return line, true
+			}
+		}
+		for _, c := range chineseClosings {
+			if strings.Contains(line, c) {
+				return line, true
+			}
+		}
+	}
+	return "", false
+}
+
+var (
+	formalMarkers = []string{"您", "敬請", "敬祝", "煩請", "惠請", "貴公司", "貴司", "貴品牌", "此致", "dear", "sincerely", "kind regards", "best regards", "please find", "would you"}
+	casualMarkers = []string{"你", "哈哈", "啦", "喔", "唷", "囉", "～", "~", "!!", "！！", "hey", "lol", "yeah", "gonna"}
+)
+
+// formalityScore 單封郵件的正式程度（-1 口語 ~ 1 正式）
+func formalityScore(text string) float64 {
+	lower := strings.ToLower(text)
+	formal, casual := 0, 0
+	for _, m := range formalMarkers {
+		formal += strings.Count(lower, m)
+	}
+	for _, m := range casualMarkers {
+		casual += strings.Count(lower, m)
+	}
+	if formal+casual == 0 {
+		return 0
+	}
+	return float64(formal-casual) / float64(formal+casual)
+}
+
+// languageCounts 中文字數與英文字（word）數
+func languageCounts(text string) (han, latinWords int) {
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+			inWord = false
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			if !inWord {
+				latinWords++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+	}
+	return han, latinWords
+}
+
+// isEmoji 是否為表情符號
+func isEmoji(r rune) bool {
+	return (r >= 0x1F300 && r <= 0x1FAFF) || (r >= 0x2600 && r <= 0x27BF)
+}
+
+// topPhrases 出現至少兩次的前 n 個片語
+func topPhrases(counts map[string]int, n int) []Phrase {
+	phrases := make([]Phrase, 0, len(counts))
+	for text, count := range counts {
+		if count >= 2 {
+			phrases = append(phrases, Phrase{Text: text, Count: count})
+		}
+	}
+	sort.Slice(phrases, func(i, j int) bool {
+		if phrases[i].Count != phrases[j].Count {
+			return phrases[i].Count > phrases[j].Count
+		}
+		return phrases[i].Text < phrases[j].Text
+	})
+	if len(phrases) > n {
+		phrases = phrases[:n]
+	}
+	return phrases
+}
+
+// Analyze 統計寄出郵件內文（會先去掉引用的原信）的寫作風格
+func Analyze(bodies []string) Stats {
+	greetings := make(map[string]int)
+	closings := make(map[string]int)
+	emojis := make(map[string]int)
+	var lengths []int
+	var formalitySum float64
+	han, latinWords, withEmoji := 0, 0, 0
+
+	for _, body := range bodies {
+		text := CleanReply(body)
+		lines := nonEmptyLines(text)
+		if len(lines) == 0 {
+			continue
+		}
+		lengths = append(lengths, utf8.RuneCountInString(text))
+
+		if g, ok := greetingPattern(lines[0]); ok {
+			greetings[g]++
+		}
+		if c, ok := closingLine(lines); ok {
+			closings[c]++
+		}
+		formalitySum += formalityScore(text)
+
+		h, w := languageCounts(text)
+		han += h
+		latinWords += w
+
+		found := false
+		for _, r := range text {
+			if isEmoji(r) {
+				emojis[string(r)]++
+				found = true
+			}
+		}
+		if found {
+			withEmoji++
+		}
+	}
+
+	stats := Stats{SampleCount: len(lengths), Formality: FormalityNeutral}
+	if stats.SampleCount == 0 {
+		return stats
+	}
+
+	stats.Greetings = topPhrases(greetings, 3)
+	stats.Closings = topPhrases(closings, 3)
+
+	stats.FormalityScore = formalitySum / float64(stats.SampleCount)
+	switch {
+	case stats.FormalityScore >= 0.3:
+		stats.Formality = FormalityFormal
+	case stats.FormalityScore <= -0.3:
+		stats.Formality = FormalityCasual
+	}
+
+	if han+latinWords > 0 {
+		stats.ChineseRatio = float64(han) / float64(han+latinWords)
+	}
+
+	sort.Ints(lengths)
+	stats.MedianLength = lengths[len(lengths)/2]
+
+	stats.EmojiRate = float64(withEmoji) / float64(stats.SampleCount)
+	for _, p := range topPhrases(emojis, 5) {
+		stats.CommonEmojis = append(stats.CommonEmojis, p.Text)
+	}
+	return stats
+}
@@ -0,0 +1,86 @@
+package style
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"gorm.io/datatypes"
+)
+
+// decodePhrases 解析風格檔案中的片語清單
+func decodePhrases(raw datatypes.JSON) []Phrase {
+	var phrases []Phrase
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &phrases)
+	}
+	return phrases
+}
+
+// quoted 以「」列出片語
+func quoted(phrases []Phrase) string {
+	parts := make([]string, len(phrases))
+	for i, p := range phrases {
+		parts[i] = "「" + p.Text + "」"
+	}
+	return strings.Join(parts, "")
+}
+
+// Describe 將風格檔案轉成擬信提示詞中的條列說明
+func Describe(p *models.WritingStyleProfile) string {
+	var lines []string
+
+	if greetings := decodePhrases(p.Greetings); len(greetings) > 0 {
+		lines = append(lines, "- 常用開頭："+quoted(greetings)+"（{name} 代入對方稱呼）")
+	}
+	if closings := decodePhrases(p.Closings); len(closings) > 0 {
+		lines = append(lines, "- 常用結尾："+quoted(closings))
+	}
+
+	switch p.Formality {
+	case FormalityFormal:
+		lines = append(lines, "- 語氣：偏正式，常用「您」與敬語")
+	case FormalityCasual:
+		lines = append(lines, "- 語氣：輕鬆口語，像朋友間對話")
+	default:
+		lines = append(lines, "- 語氣：友善但不過度正式")
+	}
+
+	switch {
+	case p.ChineseRatio >= 0.8:
+		lines = append(lines, "- 語言：以中文為主")
+	case p.ChineseRatio <= 0.2:
+		lines = append(lines, "- 語言：以英文為主")
+	default:
+		lines = append(lines, fmt.Sprintf("- 語言：中英夾雜（中文約 %d%%）", int(math.Round(p.ChineseRatio*100))))
+	}
+
+	if p.MedianLength > 0 {
+		lines = append(lines, fmt.Sprintf("- 篇幅：通常約 %d 字", p.MedianLength))
+	}
+
+	var emojis []string
+	if len(p.CommonEmojis) > 0 {
+		_ = json.Unmarshal(p.CommonEmojis, &emojis)
+	}
+	switch {
+	case p.EmojiRate < 0.05:
+		lines = append(lines, "- 表情符號：幾乎不使用")
+	case p.EmojiRate < 0.3:
+		lines = append(lines, "- 表情符號：偶爾使用"+emojiHint(emojis))
+	default:
+		lines = append(lines, "- 表情符號：經常使用"+emojiHint(emojis))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// emojiHint 常用表情符號舉例
+func emojiHint(emojis []string) string {
+	if len(emojis) == 0 {
+		return ""
+	}
+	return "（如 " + strings.Join(emojis, " ") + "）"
+}
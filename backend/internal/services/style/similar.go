@@ -0,0 +1,74 @@
+package style
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// stopWords 比對時忽略的常見英文字
+var stopWords = map[string]bool{
+	"the": true, "and": true, "to": true, "of": true, "for": true, "is": true, "in": true, "on": true,
+	"we": true, "you": true, "your": true, "it": true, "be": true, "are": true, "this": true, "that": true,
+	"with": true, "re": true, "fw": true, "fwd": true,
+}
+
+// terms 文字的詞頻（英文以單字、中文以相鄰兩字為單位）
+func terms(text string) map[string]float64 {
+	tf := make(map[string]float64)
+	var word strings.Builder
+	var prevHan rune
+
+	flushWord := func() {
+		if w := word.String(); len(w) >= 2 && !stopWords[w] {
+			tf[w]++
+		}
+		word.Reset()
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			if prevHan != 0 {
+				tf[string([]rune{prevHan, r})]++
+			}
+			prevHan = r
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			prevHan = 0
+			word.WriteRune(r)
+		default:
+			prevHan = 0
+			flushWord()
+		}
+	}
+	flushWord()
+	return tf
+}
+
+// cosine 兩個詞頻向量的餘弦相似度
+func cosine(a, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for term, x := range a {
+		normA += x * x
+		if y, ok := b[term]; ok {
+			dot += x * y
+		}
+	}
+	for _, y := range b {
+		normB += y * y
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// replyPrefix 主旨的回覆/轉寄前綴
+var replyPrefix = regexp.MustCompile(`(?i)^\s*((re|fw|fwd|回覆|答覆|轉寄)\s*[:：]\s*)+`)
+
+// baseSubject 去掉 Re:/Fwd: 等前綴的主旨
+func baseSubject(subject string) string {
+	return strings.TrimSpace(replyPrefix.ReplaceAllString(subject, ""))
+}
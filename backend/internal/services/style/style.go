@@ -0,0 +1,285 @@
+package style
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// minSimilarity 相似度低於此值的過往回信不當作範例
+const minSimilarity = 0.1
+
+// Service 使用者寫作風格服務（統計寄出郵件、挑選相似的過往回信）
+type Service struct {
+	db  *gorm.DB
+	cfg config.StyleConfig
+	now func() time.Time
+}
+
+// NewService 建立寫作風格服務
+func NewService(db *gorm.DB, cfg config.StyleConfig) *Service {
+	if cfg.SampleSize <= 0 {
+		cfg.SampleSize = 200
+	}
+	if cfg.MinSamples <= 0 {
+		cfg.MinSamples = 5
+	}
+	if cfg.ExampleCount < 0 {
+		cfg.ExampleCount = 0
+	}
+	return &Service{db: db, cfg: cfg, now: time.Now}
+}
+
+// sentEmails 使用者最近寄出、未排除的郵件（含內文）
+func (s *Service) sentEmails(userID uuid.UUID) ([]models.Email, error) {
+	var emails []models.Email
+	err := s.db.Select("emails.id, emails.thread_id, emails.subject, emails.body_text, emails.received_at").
+		Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
+		Where("oauth_accounts.user_id = ? AND emails.direction = ? AND emails.style_excluded = ?", userID, models.EmailDirectionOutgoing, false).
+		Where("emails.duplicate_of_id IS NULL AND emails.body_text IS NOT NULL AND emails.body_text <> ''").
+		Order("emails.received_at DESC").
+		Limit(s.cfg.SampleSize).
+		Find(&emails).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sent emails: %w", err)
+	}
+	return emails, nil
+}
+
+// Refresh 重新統計使用者的寫作風格並儲存
+func (s *Service) Refresh(userID uuid.UUID) (*models.WritingStyleProfile, error) {
+	emails, err := s.sentEmails(userID)
+	if err != nil {
+		return nil, err
+	}
+	bodies := make([]string, len(emails))
+	for i, e := range emails {
+		bodies[i] = *e.BodyText
+	}
+	stats := Analyze(bodies)
+
+	greetings, _ := json.Marshal(stats.Greetings)
+	closings, _ := json.Marshal(stats.Closings)
+	emojis, _ := json.Marshal(stats.CommonEmojis)
+	profile := &models.WritingStyleProfile{
+		UserID:         userID,
+		SampleCount:    stats.SampleCount,
+		Greetings:      greetings,
+		Closings:       closings,
+		Formality:      stats.Formality,
+		FormalityScore: stats.FormalityScore,
+		ChineseRatio:   stats.ChineseRatio,
+		MedianLength:   stats.MedianLength,
+		EmojiRate:      stats.EmojiRate,
+		CommonEmojis:   emojis,
+		RefreshedAt:    s.now(),
+	}
+
+	err = s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"sample_count", "greetings", "closings", "formality", "formality_score", "chinese_ratio",
+			"median_length", "emoji_rate", "common_emojis", "refreshed_at", "updated_at",
+		}),
+	}).Omit(clause.Associations).Create(profile).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to save writing style profile: %w", err)
+	}
+
+	var saved models.WritingStyleProfile
+	if err := s.db.Where("user_id = ?", userID).First(&saved).Error; err != nil {
+		return nil, fmt.Errorf("failed to load writing style profile: %w", err)
+	}
+	return &saved, nil
+}
+
+// Profile 取得使用者的寫作風格；尚未統計（或已被清除）時立即統計
+func (s *Service) Profile(userID uuid.UUID) (*models.WritingStyleProfile, error) {
+	var profile models.WritingStyleProfile
+	err := s.db.Where("user_id = ?", userID).First(&profile).Error
+	if err == gorm.ErrRecordNotFound {
+		return s.Refresh(userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load writing style profile: %w", err)
+	}
+	return &profile, nil
+}
+
+// Usable 寄出郵件是否足以代表使用者的風格
+func (s *Service) Usable(profile *models.WritingStyleProfile) bool {
+	return profile != nil && profile.SampleCount >= s.cfg.MinSamples
+}
+
+// Examples 挑選與 email 情境最相似的過往回信（以對方來信或回信主旨比對）
+func (s *Service) Examples(userID uuid.UUID, email *models.Email, n int) ([]openai.StyleExample, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	query := terms(baseSubject(deref(email.Subject)) + "\n" + CleanReply(emailText(email)))
+	if len(query) == 0 {
+		return nil, nil
+	}
+
+	sent, err := s.sentEmails(userID)
+	if err != nil {
+		return nil, err
+	}
+	parents, err := s.incomingByThread(userID, sent)
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		example openai.StyleExample
+		score   float64
+	}
+	var candidates []candidate
+	for i := range sent {
+		reply := &sent[i]
+		if reply.ID == email.ID {
+			continue
+		}
+		text := CleanReply(*reply.BodyText)
+		if text == "" {
+			continue
+		}
+
+		example := openai.StyleExample{Subject: baseSubject(deref(reply.Subject)), Reply: text}
+		situation := example.Subject + "\n" + truncateRunes(text, 300)
+		if parent := latestBefore(parents, reply); parent != nil {
+			example.Subject = baseSubject(deref(parent.Subject))
+			example.Incoming = truncateRunes(CleanReply(emailText(parent)), 500)
+			situation = example.Subject + "\n" + example.Incoming
+		}
+
+		if score := cosine(query, terms(situation)); score >= minSimilarity {
+			candidates = append(candidates, candidate{example: example, score: score})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	examples := make([]openai.StyleExample, len(candidates))
+	for i, c := range candidates {
+		examples[i] = c.example
+	}
+	return examples, nil
+}
+
+// incomingByThread 寄出郵件所在郵件串中的來信（依時間排序）
+func (s *Service) incomingByThread(userID uuid.UUID, sent []models.Email) (map[string][]models.Email, error) {
+	threadIDs := make([]string, 0, len(sent))
+	for _, e := range sent {
+		if e.ThreadID != nil {
+			threadIDs = append(threadIDs, *e.ThreadID)
+		}
+	}
+	byThread := make(map[string][]models.Email)
+	if len(threadIDs) == 0 {
+		return byThread, nil
+	}
+
+	var incoming []models.Email
+	err := s.db.Select("emails.id, emails.thread_id, emails.subject, emails.body_text, emails.snippet, emails.received_at").
+		Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
+		Where("oauth_accounts.user_id = ? AND emails.direction = ? AND emails.thread_id IN ?", userID, models.EmailDirectionIncoming, threadIDs).
+		Order("emails.received_at ASC").
+		Find(&incoming).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load incoming emails: %w", err)
+	}
+	for _, e := range incoming {
+		byThread[*e.ThreadID] = append(byThread[*e.ThreadID], e)
+	}
+	return byThread, nil
+}
+
+// latestBefore 回信之前最後一封來信
+func latestBefore(byThread map[string][]models.Email, reply *models.Email) *models.Email {
+	if reply.ThreadID == nil {
+		return nil
+	}
+	var parent *models.Email
+	for i, e := range byThread[*reply.ThreadID] {
+		if !e.ReceivedAt.Before(reply.ReceivedAt) {
+			break
+		}
+		parent = &byThread[*reply.ThreadID][i]
+	}
+	return parent
+}
+
+// Apply 為擬信請求加入使用者的寫作風格與相似的過往回信（寄出郵件不足時只加範例）
+func (s *Service) Apply(userID uuid.UUID, email *models.Email, req *openai.DraftReplyRequest) error {
+	profile, err := s.Profile(userID)
+	if err != nil {
+		return err
+	}
+	if s.Usable(profile) {
+		req.StyleGuide = Describe(profile)
+	}
+
+	examples, err := s.Examples(userID, email, s.cfg.ExampleCount)
+	if err != nil {
+		return err
+	}
+	req.StyleExamples = examples
+	return nil
+}
+
+// UsersWithSentEmails 有寄出郵件的使用者（排程重新統計用），依 ID 排序、從 after 之後取 limit 筆（分頁用，第一頁傳 uuid.Nil）
+func (s *Service) UsersWithSentEmails(after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	var userIDs []uuid.UUID
+	err := s.db.Model(&models.Email{}).
+		Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id").
+		Where("emails.direction = ? AND oauth_accounts.user_id > ?", models.EmailDirectionOutgoing, after).
+		Distinct().
+		Order("oauth_accounts.user_id").
+		Limit(limit).
+		Pluck("oauth_accounts.user_id", &userIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	return userIDs, nil
+}
+
+// Invalidate 清除使用者的風格檔案（排除郵件後，下次使用時重新統計）
+func Invalidate(db *gorm.DB, userID uuid.UUID) error {
+	return db.Where("user_id = ?", userID).Delete(&models.WritingStyleProfile{}).Error
+}
+
+// emailText 郵件內文（無內文時用摘要）
+func emailText(e *models.Email) string {
+	if e.BodyText != nil && *e.BodyText != "" {
+		return *e.BodyText
+	}
+	return deref(e.Snippet)
+}
+
+// deref 取得字串指標的值
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// truncateRunes 截斷到 n 個字元
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,213 @@
+package style
+
+import (
+	"testing"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 設置測試用的資料庫（使用 SQLite）
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Skipf("Skipping test: SQLite not available (CGO required): %v", err)
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.OAuthAccount{}, &models.Email{}, &models.WritingStyleProfile{})
+	require.NoError(t, err)
+	return db
+}
+
+type fixture struct {
+	db      *gorm.DB
+	user    *models.User
+	account *models.OAuthAccount
+	at      time.Time
+}
+
+func newFixture(t *testing.T) *fixture {
+	db := setupTestDB(t)
+	user := &models.User{ID: uuid.New(), Email: "creator@example.com", Name: "Creator"}
+	require.NoError(t, db.Create(user).Error)
+	account := &models.OAuthAccount{
+		ID: uuid.New(), UserID: user.ID, Provider: models.OAuthProviderGoogle, Email: "creator@example.com",
+		AccessToken: "a", RefreshToken: "r", TokenExpiry: time.Now().Add(time.Hour),
+	}
+	require.NoError(t, db.Create(account).Error)
+	return &fixture{db: db, user: user, account: account, at: time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC)}
+}
+
+func (f *fixture) email(t *testing.T, direction, thread, subject, body string) *models.Email {
+	f.at = f.at.Add(time.Hour)
+	e := &models.Email{
+		OAuthAccountID: f.account.ID, ProviderMessageID: uuid.NewString(), ThreadID: &thread,
+		FromEmail: "someone@example.com", Subject: &subject, BodyText: &body, Direction: direction, ReceivedAt: f.at,
+	}
+	require.NoError(t, f.db.Create(e).Error)
+	return e
+}
+
+func TestCleanReply(t *testing.T) {
+	body := "Hi Amy,\n\n好的沒問題！\n\nBest,\nMia\n\nOn Mon, Sep 1, 2026 at 10:00 AM Amy <amy@brand.example>\nwrote:\n> 想邀請您合作"
+	assert.Equal(t, "Hi Amy,\n\n好的沒問題！\n\nBest,\nMia", CleanReply(body))
+
+	assert.Equal(t, "收到，謝謝！", CleanReply("收到，謝謝！\n\nAmy 於 2026年9月1日 週一 寫道：\n> 原信"))
+	assert.Equal(t, "Thanks!", CleanReply("Thanks!\n-- \nMia | Creator"))
+	assert.Equal(t, "OK", CleanReply("> quoted\nOK"))
+}
+
+func TestGreetingPattern(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+		ok   bool
+	}{
+		{"Hi Amy,", "Hi {name},", true},
+		{"Hello!", "Hello!", true},
+		{"Dear Ms. Chen,", "Dear {name},", true},
+		{"Amy 您好：", "{name} 您好：", true},
+		{"嗨～", "嗨～", true},
+		{"Hints for the shoot are below", "", false},
+		{"謝謝您的邀約", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := greetingPattern(tt.line)
+		assert.Equal(t, tt.ok, ok, tt.line)
+		assert.Equal(t, tt.want, got, tt.line)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	bodies := []string{
+		"Amy 您好：\n\n感謝您的邀約，報價如附件，敬請參考 😊\n\n謝謝！\nMia",
+		"Kevin 您好：\n\n收到您的資料，我們下週拍攝。\n\n謝謝！\nMia\n\n> 原信內容 hello hello hello",
+		"Jo 您好：\n\n檔期可以配合，再麻煩您確認合約。\n\n謝謝！\nMia",
+		"",
+	}
+	stats := Analyze(bodies)
+
+	assert.Equal(t, 3, stats.SampleCount)
+	require.NotEmpty(t, stats.Greetings)
+	assert.Equal(t, Phrase{Text: "{name} 您好：", Count: 3}, stats.Greetings[0])
+	require.NotEmpty(t, stats.Closings)
+	assert.Equal(t, Phrase{Text: "謝謝！", Count: 3}, stats.Closings[0])
+	assert.Equal(t, FormalityFormal, stats.Formality)
+	assert.Greater(t, stats.ChineseRatio, 0.8) // 引用的英文不列入
+	assert.InDelta(t, 1.0/3, stats.EmojiRate, 1e-9)
+	assert.Greater(t, stats.MedianLength, 0)
+}
+
+func TestDescribe(t *testing.T) {
+	profile := &models.WritingStyleProfile{
+		SampleCount:  10,
+		Greetings:    []byte(`[{"text":"Hi {name},","count":6}]`),
+		Closings:     []byte(`[{"text":"Cheers,","count":5}]`),
+		Formality:    FormalityCasual,
+		ChineseRatio: 0.55,
+		MedianLength: 120,
+		EmojiRate:    0.5,
+		CommonEmojis: []byte(`["🙌"]`),
+	}
+	guide := Describe(profile)
+	assert.Contains(t, guide, "「Hi {name},」")
+	assert.Contains(t, guide, "「Cheers,」")
+	assert.Contains(t, guide, "輕鬆口語")
+	assert.Contains(t, guide, "中英夾雜（中文約 55%）")
+	assert.Contains(t, guide, "約 120 字")
+	assert.Contains(t, guide, "經常使用（如 🙌）")
+}
+
+func TestRefresh_SkipsExcludedEmails(t *testing.T) {
+	f := newFixture(t)
+	svc := NewService(f.db, config.StyleConfig{MinSamples: 2})
+
+	f.email(t, models.EmailDirectionOutgoing, "t1", "Re: 合作", "嗨～\n\n沒問題啦！\n\n謝謝！")
+	f.email(t, models.EmailDirectionOutgoing, "t2", "Re: 報價", "嗨～\n\n好喔，下週給你報價～\n\n謝謝！")
+	excluded := f.email(t, models.EmailDirectionOutgoing, "t3", "Re: 公文", "敬啟者：\n\n敬請貴公司惠請回覆，此致。")
+	require.NoError(t, f.db.Model(excluded).Update("style_excluded", true).Error)
+	f.email(t, models.EmailDirectionIncoming, "t1", "合作", "您好，想邀請您合作")
+
+	profile, err := svc.Profile(f.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, profile.SampleCount)
+	assert.Equal(t, FormalityCasual, profile.Formality)
+	assert.True(t, svc.Usable(profile))
+
+	// 已有檔案時直接回傳；清除後重新統計
+	require.NoError(t, f.db.Model(excluded).Update("style_excluded", false).Error)
+	again, err := svc.Profile(f.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, again.SampleCount)
+
+	require.NoError(t, Invalidate(f.db, f.user.ID))
+	rebuilt, err := svc.Profile(f.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, rebuilt.SampleCount)
+}
+
+func TestUsersWithSentEmails_Pages(t *testing.T) {
+	f := newFixture(t)
+	svc := NewService(f.db, config.StyleConfig{})
+	f.email(t, models.EmailDirectionOutgoing, "t1", "Re: 合作", "好的")
+	for i := 0; i < 2; i++ {
+		user := &models.User{ID: uuid.New(), Email: uuid.NewString() + "@example.com", Name: "Other"}
+		require.NoError(t, f.db.Create(user).Error)
+		f.account = &models.OAuthAccount{
+			ID: uuid.New(), UserID: user.ID, Provider: models.OAuthProviderGoogle, Email: user.Email,
+			AccessToken: "a", RefreshToken: "r", TokenExpiry: time.Now().Add(time.Hour),
+		}
+		require.NoError(t, f.db.Create(f.account).Error)
+		f.email(t, models.EmailDirectionOutgoing, "t1", "Re: 合作", "好的")
+		f.email(t, models.EmailDirectionOutgoing, "t2", "Re: 報價", "好的")
+	}
+
+	first, err := svc.UsersWithSentEmails(uuid.Nil, 2)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	rest, err := svc.UsersWithSentEmails(first[1], 2)
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	assert.NotContains(t, first, rest[0])
+}
+
+func TestApply_AddsGuideAndSimilarReplies(t *testing.T) {
+	f := newFixture(t)
+	svc := NewService(f.db, config.StyleConfig{MinSamples: 2, ExampleCount: 1})
+
+	f.email(t, models.EmailDirectionIncoming, "t1", "保養品開箱合作邀約", "想邀請您拍攝保養品開箱影片")
+	f.email(t, models.EmailDirectionOutgoing, "t1", "Re: 保養品開箱合作邀約", "Hi Amy,\n\n開箱影片的報價是 3 萬元。\n\nCheers,")
+	f.email(t, models.EmailDirectionIncoming, "t2", "發票寄送", "請問發票要寄到哪裡")
+	f.email(t, models.EmailDirectionOutgoing, "t2", "Re: 發票寄送", "Hi Kevin,\n\n請寄到台北市信義區。\n\nCheers,")
+
+	incoming := f.email(t, models.EmailDirectionIncoming, "t3", "新品保養品開箱", "想邀請您拍攝新品開箱")
+	var req openai.DraftReplyRequest
+	require.NoError(t, svc.Apply(f.user.ID, incoming, &req))
+
+	assert.Contains(t, req.StyleGuide, "「Hi {name},」")
+	require.Len(t, req.StyleExamples, 1)
+	assert.Equal(t, "保養品開箱合作邀約", req.StyleExamples[0].Subject)
+	assert.Equal(t, "想邀請您拍攝保養品開箱影片", req.StyleExamples[0].Incoming)
+	assert.Contains(t, req.StyleExamples[0].Reply, "3 萬元")
+}
+
+func TestApply_TooFewSamplesSkipsGuide(t *testing.T) {
+	f := newFixture(t)
+	svc := NewService(f.db, config.StyleConfig{MinSamples: 5, ExampleCount: 3})
+	f.email(t, models.EmailDirectionOutgoing, "t1", "Re: 合作", "Hi,\n\n好的。")
+
+	incoming := f.email(t, models.EmailDirectionIncoming, "t2", "天氣", "今天天氣很好")
+	var req openai.DraftReplyRequest
+	require.NoError(t, svc.Apply(f.user.ID, incoming, &req))
+	assert.Empty(t, req.StyleGuide)
+	assert.Empty(t, req.StyleExamples)
+}
@@ -0,0 +1,436 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/analysis"
+	"github.com/designcomb/influenter-backend/internal/services/attachment"
+	"github.com/designcomb/influenter-backend/internal/services/casematch"
+	"github.com/designcomb/influenter-backend/internal/services/caseupdate"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// analyzeTimeout 單封郵件的分析逾時
+const analyzeTimeout = 60 * time.Second
+
+// attachmentTimeout 單封郵件附件擷取與摘要的逾時
+const attachmentTimeout = 2 * time.Minute
+
+// 分析失敗後的重試間隔：第一次失敗後 retryBaseDelay，之後每次加倍，最長 retryMaxDelay
+const (
+	retryBaseDelay = 10 * time.Minute
+	retryMaxDelay  = 24 * time.Hour
+)
+
+// budgetRetryDelay 超過 AI 額度（以關鍵字分類）時延後重新分析的間隔，不計入失敗次數
+const budgetRetryDelay = 6 * time.Hour
+
+// errDeferred 超過 AI 額度，只以關鍵字分類，待額度恢復後重新分析
+var errDeferred = errors.New("analysis deferred: AI budget exceeded")
+
+// Analyzer 郵件分析（*openai.Service 實作此介面）
+type Analyzer interface {
+	AnalyzeEmail(ctx context.Context, req openai.AnalyzeEmailRequest) (*openai.EmailAnalysisResult, error)
+}
+
+// Attachments 附件文字擷取（*attachment.Service 實作此介面）
+type Attachments interface {
+	Process(ctx context.Context, email *models.Email, userID uuid.UUID) ([]models.EmailAttachment, error)
+}
+
+// CaseUpdateAnalyzer 品牌來信的案件更新分析（*openai.Service 實作此介面）
+type CaseUpdateAnalyzer interface {
+	AnalyzeIncomingForCaseUpdate(ctx context.Context, req openai.IncomingCaseUpdateRequest) (*openai.ReplyCaseUpdateResult, error)
+}
+
+// Service 新郵件的自動分析與歸檔服務
+type Service struct {
+	db          *gorm.DB
+	cfg         config.AIConfig
+	analyzer    Analyzer           // nil 時只依郵件串歸檔，不做 AI 分析
+	attachments Attachments        // nil 時只分析郵件內文
+	caseUpdates CaseUpdateAnalyzer // nil 時不分析來信對既有案件的影響
+	proposals   *caseupdate.Service
+	matcher     *casematch.Matcher
+}
+
+// NewService 建立自動分析服務
+func NewService(db *gorm.DB, cfg config.AIConfig, analyzer Analyzer) *Service {
+	if cfg.TriageBatchSize <= 0 {
+		cfg.TriageBatchSize = 20
+	}
+	if cfg.TriageLookbackDays <= 0 {
+		cfg.TriageLookbackDays = 7
+	}
+	if cfg.TriageMaxAttempts <= 0 {
+		cfg.TriageMaxAttempts = 5
+	}
+	return &Service{db: db, cfg: cfg, analyzer: analyzer, matcher: casematch.NewMatcher(db, cfg, nil)}
+}
+
+// SetCaseMatchAI 設定 AI 案件比對（郵件串、聯絡人、品牌都無法判斷時，由 AI 判斷是否屬於既有案件）
+func (s *Service) SetCaseMatchAI(ai casematch.AI) {
+	s.matcher = casematch.NewMatcher(s.db, s.cfg, ai)
+}
+
+// SetAttachments 設定附件文字擷取（分析時一併參考附件內容）
+func (s *Service) SetAttachments(attachments Attachments) {
+	s.attachments = attachments
+}
+
+// SetCaseUpdates 設定來信的案件更新分析（已歸入案件的來信會提出案件更新建議）
+func (s *Service) SetCaseUpdates(analyzer CaseUpdateAnalyzer) {
+	s.caseUpdates = analyzer
+	s.proposals = caseupdate.NewService(s.db)
+}
+
+// GetSettings 取得使用者的自動分析設定（未設定時回傳預設值）
+func (s *Service) GetSettings(userID uuid.UUID) (models.AITriageSettings, error) {
+	var settings models.AITriageSettings
+	err := s.db.Where("user_id = ?", userID).First(&settings).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.DefaultAITriageSettings(userID, s.cfg.AutoAnalyze), nil
+	}
+	return settings, err
+}
+
+// CaseThreshold 使用者實際套用的自動建立案件門檻
+func (s *Service) CaseThreshold(settings models.AITriageSettings) float64 {
+	if settings.AutoCreateCaseThreshold != nil {
+		return *settings.AutoCreateCaseThreshold
+	}
+	return s.cfg.AutoCreateCaseThreshold
+}
+
+// Result 單一帳號的處理結果
+type Result struct {
+	Analyzed     int  `json:"analyzed"`
+	Attached     int  `json:"attached"`      // 歸入既有案件
+	Suggested    int  `json:"suggested"`     // 可能屬於既有案件，待使用者確認（不建立新案件）
+	CasesCreated int  `json:"cases_created"` // 自動建立的案件
+	CaseUpdates  int  `json:"case_updates"`  // 依來信提出的案件更新建議
+	Failed       int  `json:"failed"`        // 分析失敗（延後重試，達次數上限後放棄）
+	Deferred     int  `json:"deferred"`      // 超過 AI 額度，只以關鍵字分類，稍後重新分析
+	Skipped      bool `json:"skipped"`       // 使用者關閉自動分析
+}
+
+// TriageAccount 分析帳號中尚未分析的新收件，並依使用者設定歸入既有案件或建立新案件
+func (s *Service) TriageAccount(ctx context.Context, accountID uuid.UUID) (*Result, error) {
+	var account models.OAuthAccount
+	if err := s.db.Select("id, user_id").First(&account, "id = ?", accountID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load oauth account: %w", err)
+	}
+
+	settings, err := s.GetSettings(account.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load triage settings: %w", err)
+	}
+	result := &Result{}
+	if !settings.AutoAnalyze {
+		result.Skipped = true
+		return result, nil
+	}
+
+	var emails []models.Email
+	err = s.pending(s.db.Model(&models.Email{})).
+		Where("emails.oauth_account_id = ?", accountID).
+		Order("emails.received_at ASC").
+		Limit(s.cfg.TriageBatchSize).
+		Find(&emails).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query emails: %w", err)
+	}
+
+	for i := range emails {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		err := s.triageOne(ctx, &emails[i], account.UserID, settings, result)
+		if err != nil && ctx.Err() != nil {
+			return result, ctx.Err() // 任務被取消：不算失敗，下次重新處理
+		}
+		if errors.Is(err, errDeferred) {
+			result.Deferred++
+		} else if err != nil {
+			result.Failed++
+			log.Warn().Err(err).Str("email_id", emails[i].ID.String()).Msg("Failed to triage email")
+		}
+		if err := s.markTriage(&emails[i], err); err != nil {
+			log.Warn().Err(err).Str("email_id", emails[i].ID.String()).Msg("Failed to save triage state")
+		}
+	}
+	return result, nil
+}
+
+// pending 待自動分析的新收件：尚未分析、尚未處理完成，且不在失敗後的重試等待中
+func (s *Service) pending(db *gorm.DB) *gorm.DB {
+	now := time.Now()
+	since := now.AddDate(0, 0, -s.cfg.TriageLookbackDays)
+	return db.Where("emails.ai_analyzed = ? AND emails.triaged_at IS NULL AND emails.direction = ? AND emails.duplicate_of_id IS NULL AND emails.received_at >= ?",
+		false, models.EmailDirectionIncoming, since).
+		Where("emails.triage_retry_at IS NULL OR emails.triage_retry_at <= ?", now)
+}
+
+// markTriage 記錄處理結果：成功時標為已處理；超過額度時延後重新分析；失敗時延後重試，達次數上限後放棄
+func (s *Service) markTriage(email *models.Email, triageErr error) error {
+	now := time.Now()
+	if triageErr == nil {
+		return s.db.Model(email).Update("triaged_at", now).Error
+	}
+	if errors.Is(triageErr, errDeferred) {
+		return s.db.Model(email).Update("triage_retry_at", now.Add(budgetRetryDelay)).Error
+	}
+
+	attempts := email.TriageAttempts + 1
+	updates := map[string]interface{}{"triage_attempts": attempts}
+	if attempts >= s.cfg.TriageMaxAttempts {
+		updates["triaged_at"] = now
+		log.Warn().Str("email_id", email.ID.String()).Int("attempts", attempts).Msg("Giving up triage after repeated failures")
+	} else {
+		updates["triage_retry_at"] = now.Add(retryDelay(attempts))
+	}
+	return s.db.Model(email).Updates(updates).Error
+}
+
+// retryDelay 第 attempts 次失敗後的重試間隔
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMaxDelay)
+}
+
+// PendingAccounts 有尚未分析的新收件、且使用者啟用自動分析的帳號，依 ID 排序、從 after 之後取 limit 筆（分頁用，第一頁傳 uuid.Nil）
+func (s *Service) PendingAccounts(after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	query := s.pending(s.db.Model(&models.Email{})).
+		Distinct("emails.oauth_account_id").
+		Where("emails.oauth_account_id > ?", after).
+		Order("emails.oauth_account_id").
+		Joins("JOIN oauth_accounts ON oauth_accounts.id = emails.oauth_account_id AND oauth_accounts.deleted_at IS NULL")
+
+	// 系統預設開啟時排除明確關閉的使用者；預設關閉時只處理明確開啟的使用者
+	optedOut := s.db.Model(&models.AITriageSettings{}).Select("user_id").Where("auto_analyze = ?", false)
+	optedIn := s.db.Model(&models.AITriageSettings{}).Select("user_id").Where("auto_analyze = ?", true)
+	if s.cfg.AutoAnalyze {
+		query = query.Where("oauth_accounts.user_id NOT IN (?)", optedOut)
+	} else {
+		query = query.Where("oauth_accounts.user_id IN (?)", optedIn)
+	}
+
+	var ids []uuid.UUID
+	if err := query.Limit(limit).Pluck("emails.oauth_account_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to query pending accounts: %w", err)
+	}
+	return ids, nil
+}
+
+// triageOne 處理單封郵件：先依郵件串歸檔，再做 AI 分析，最後依分析結果歸檔或建立案件
+func (s *Service) triageOne(ctx context.Context, email *models.Email, userID uuid.UUID, settings models.AITriageSettings, result *Result) error {
+	if email.CaseID == nil && settings.AttachReplies {
+		match, err := s.matcher.ByThread(email, userID)
+		if err != nil {
+			return err
+		}
+		if match != nil {
+			if err := casematch.Apply(s.db, email, match); err != nil {
+				return err
+			}
+			result.Attached++
+		}
+	}
+
+	if s.analyzer == nil {
+		return nil
+	}
+
+	subject := ""
+	if email.Subject != nil {
+		subject = *email.Subject
+	}
+	attachments := s.processAttachments(ctx, email, userID)
+	actx, cancel := context.WithTimeout(openai.WithUsageScope(ctx, userID.String(), email.ID.String()), analyzeTimeout)
+	defer cancel()
+	res, err := s.analyzer.AnalyzeEmail(actx, openai.AnalyzeEmailRequest{
+		Subject:     subject,
+		Body:        analysis.EmailBody(email),
+		From:        email.FromEmail,
+		Date:        email.ReceivedAt,
+		Attachments: attachment.Contents(attachments),
+		Options:     openai.AnalysisOptions{DetailLevel: "standard"},
+	})
+	if err != nil {
+		return fmt.Errorf("analysis failed: %w", err)
+	}
+	// 關鍵字分類的結果只保留第一次（讓使用者先看到分類），不據以歸檔或建立案件
+	if res.IsHeuristic() {
+		if email.AIAnalysisID == nil {
+			if _, err := analysis.Record(s.db, email.ID, userID, res); err != nil {
+				return err
+			}
+		}
+		return errDeferred
+	}
+	if _, err := analysis.Record(s.db, email.ID, userID, res); err != nil {
+		return err
+	}
+	result.Analyzed++
+
+	if email.CaseID != nil {
+		s.proposeCaseUpdate(ctx, email, userID, res.Classification.Category, result)
+		return nil
+	}
+
+	category := res.Classification.Category
+	confidence := res.Classification.Confidence
+
+	// 既有合作的後續往來（同聯絡人、同品牌或 AI 判斷）：歸入或建議歸入該案件，而不是重複建立；郵件串已在分析前比對過
+	if settings.AttachReplies && confidence >= s.cfg.ConfidenceThreshold && openai.IsCollaborationRelated(category) {
+		mctx, cancel := context.WithTimeout(ctx, analyzeTimeout)
+		match, err := s.matcher.MatchAnalyzed(mctx, email, userID, res.ExtractedInfo.BrandName)
+		cancel()
+		if err != nil {
+			log.Warn().Err(err).Str("email_id", email.ID.String()).Msg("Failed to match email to existing cases")
+			match = nil
+		}
+		if match != nil {
+			if err := casematch.Apply(s.db, email, match); err != nil {
+				return err
+			}
+			if !match.Link {
+				result.Suggested++
+				return nil
+			}
+			result.Attached++
+			s.proposeCaseUpdate(ctx, email, userID, category, result)
+			return nil
+		}
+	}
+
+	if settings.AutoCreateCases && category == openai.CategoryCollaboration && confidence >= s.CaseThreshold(settings) {
+		cs := analysis.CaseFromResult(userID, subject, res)
+		attachment.AppendToDescription(cs, attachments)
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Omit(clause.Associations).Create(cs).Error; err != nil {
+				return err
+			}
+			return tx.Model(email).Update("case_id", cs.ID).Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create case: %w", err)
+		}
+		result.CasesCreated++
+	}
+	return nil
+}
+
+// processAttachments 擷取附件文字；失敗時只記錄，分析仍以郵件內文進行
+func (s *Service) processAttachments(ctx context.Context, email *models.Email, userID uuid.UUID) []models.EmailAttachment {
+	if s.attachments == nil {
+		return nil
+	}
+	actx, cancel := context.WithTimeout(openai.WithUsageScope(ctx, userID.String(), email.ID.String()), attachmentTimeout)
+	defer cancel()
+	attachments, err := s.attachments.Process(actx, email, userID)
+	if err != nil {
+		log.Warn().Err(err).Str("email_id", email.ID.String()).Msg("Failed to extract attachments")
+	}
+	return attachments
+}
+
+// proposeCaseUpdate 分析已歸入案件的品牌來信（議定金額、日期變更、審核、修改要求、付款確認），提出案件更新建議；
+// 失敗時只記錄，不影響郵件本身的分析結果
+func (s *Service) proposeCaseUpdate(ctx context.Context, email *models.Email, userID uuid.UUID, category openai.EmailCategory, result *Result) {
+	if s.caseUpdates == nil || email.CaseID == nil {
+		return
+	}
+	if !openai.IsCollaborationRelated(category) && category != openai.CategoryOther {
+		return
+	}
+
+	var cs models.Case
+	if err := s.db.First(&cs, "id = ? AND user_id = ?", *email.CaseID, userID).Error; err != nil {
+		log.Warn().Err(err).Str("email_id", email.ID.String()).Msg("Failed to load case for incoming email analysis")
+		return
+	}
+
+	actx, cancel := context.WithTimeout(openai.WithUsageScope(ctx, userID.String(), email.ID.String()), analyzeTimeout)
+	defer cancel()
+	res, err := s.caseUpdates.AnalyzeIncomingForCaseUpdate(actx, incomingRequest(&cs, email, s.previousMessage(email)))
+	if err != nil {
+		log.Warn().Err(err).Str("email_id", email.ID.String()).Str("case_id", cs.ID.String()).Msg("Incoming email case update analysis failed")
+		return
+	}
+	suggestion, err := s.proposals.Propose(&cs, models.CaseUpdateSourceIncoming, &email.ID, res)
+	if err != nil {
+		log.Warn().Err(err).Str("email_id", email.ID.String()).Str("case_id", cs.ID.String()).Msg("Failed to propose case update from incoming email")
+		return
+	}
+	if suggestion != nil {
+		result.CaseUpdates++
+	}
+}
+
+// previousMessage 同一郵件串中，這封來信之前我方最後寄出的信
+func (s *Service) previousMessage(email *models.Email) string {
+	if email.ThreadID == nil || *email.ThreadID == "" {
+		return ""
+	}
+	var prev models.Email
+	err := s.db.Where("oauth_account_id = ? AND thread_id = ? AND direction = ? AND received_at < ?",
+		email.OAuthAccountID, *email.ThreadID, models.EmailDirectionOutgoing, email.ReceivedAt).
+		Order("received_at DESC").
+		First(&prev).Error
+	if err != nil {
+		return ""
+	}
+	return analysis.EmailBody(&prev)
+}
+
+// incomingRequest 來信與目前案件內容組成分析請求
+func incomingRequest(cs *models.Case, email *models.Email, previous string) openai.IncomingCaseUpdateRequest {
+	req := openai.IncomingCaseUpdateRequest{
+		EmailFrom:        email.FromEmail,
+		EmailBody:        analysis.EmailBody(email),
+		EmailDate:        email.ReceivedAt.Format("2006-01-02 15:04"),
+		PreviousMessage:  previous,
+		CaseTitle:        cs.Title,
+		CaseStatus:       string(cs.Status),
+		CaseQuotedAmount: caseAmount(cs.QuotedAmount, cs.Currency),
+		CaseFinalAmount:  caseAmount(cs.FinalAmount, cs.Currency),
+	}
+	if email.Subject != nil {
+		req.EmailSubject = *email.Subject
+	}
+	if cs.Description != nil {
+		req.CaseDescription = *cs.Description
+	}
+	if cs.Notes != nil {
+		req.CaseNotes = *cs.Notes
+	}
+	if cs.DeadlineDate != nil {
+		req.CaseDeadline = cs.DeadlineDate.Format("2006-01-02")
+	}
+	return req
+}
+
+// caseAmount 案件金額顯示（含幣別）
+func caseAmount(amount *float64, currency *string) string {
+	if amount == nil {
+		return ""
+	}
+	s := fmt.Sprintf("%.0f", *amount)
+	if currency != nil && *currency != "" {
+		s += " " + *currency
+	}
+	return s
+}
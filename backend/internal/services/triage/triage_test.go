@@ -0,0 +1,371 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 設置測試用的資料庫（使用 SQLite）
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Skipf("Skipping test: SQLite not available (CGO required): %v", err)
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.OAuthAccount{}, &models.Email{}, &models.Case{},
+		&models.AIAnalysis{}, &models.AITriageSettings{}, &models.CaseUpdateSuggestion{}, &models.Notification{})
+	require.NoError(t, err)
+	return db
+}
+
+// fakeAnalyzer 依主旨回傳預設的分析結果
+type fakeAnalyzer struct {
+	results map[string]*openai.EmailAnalysisResult
+	calls   int
+}
+
+func (f *fakeAnalyzer) AnalyzeEmail(ctx context.Context, req openai.AnalyzeEmailRequest) (*openai.EmailAnalysisResult, error) {
+	f.calls++
+	if r, ok := f.results[req.Subject]; ok {
+		return r, nil
+	}
+	return &openai.EmailAnalysisResult{
+		Classification: openai.EmailClassification{Category: openai.CategoryNewsletter, Confidence: 0.9},
+		Priority:       "low",
+		Model:          "test",
+		AnalyzedAt:     time.Now(),
+	}, nil
+}
+
+// fakeCaseUpdates 記錄來信分析請求並回傳預設結果
+type fakeCaseUpdates struct {
+	result   *openai.ReplyCaseUpdateResult
+	requests []openai.IncomingCaseUpdateRequest
+}
+
+func (f *fakeCaseUpdates) AnalyzeIncomingForCaseUpdate(ctx context.Context, req openai.IncomingCaseUpdateRequest) (*openai.ReplyCaseUpdateResult, error) {
+	f.requests = append(f.requests, req)
+	return f.result, nil
+}
+
+func collaboration(confidence float64, brand string) *openai.EmailAnalysisResult {
+	return &openai.EmailAnalysisResult{
+		Classification: openai.EmailClassification{Category: openai.CategoryCollaboration, Confidence: confidence},
+		ExtractedInfo:  openai.ExtractedInfo{BrandName: brand},
+		Priority:       "high",
+		Model:          "test",
+		AnalyzedAt:     time.Now(),
+	}
+}
+
+type fixture struct {
+	db      *gorm.DB
+	user    *models.User
+	account *models.OAuthAccount
+}
+
+func newFixture(t *testing.T) *fixture {
+	db := setupTestDB(t)
+	user := &models.User{ID: uuid.New(), Email: "creator@example.com", Name: "Creator"}
+	require.NoError(t, db.Create(user).Error)
+	account := &models.OAuthAccount{
+		ID: uuid.New(), UserID: user.ID, Provider: models.OAuthProviderGoogle, Email: "creator@example.com",
+		AccessToken: "a", RefreshToken: "r", TokenExpiry: time.Now().Add(time.Hour),
+	}
+	require.NoError(t, db.Create(account).Error)
+	return &fixture{db: db, user: user, account: account}
+}
+
+func (f *fixture) email(t *testing.T, subject, from, thread string) *models.Email {
+	e := &models.Email{
+		OAuthAccountID: f.account.ID, ProviderMessageID: uuid.NewString(), FromEmail: from,
+		Subject: &subject, Direction: models.EmailDirectionIncoming, ReceivedAt: time.Now().Add(-time.Hour),
+	}
+	if thread != "" {
+		e.ThreadID = &thread
+	}
+	require.NoError(t, f.db.Create(e).Error)
+	return e
+}
+
+func testConfig() config.AIConfig {
+	return config.AIConfig{AutoAnalyze: true, ConfidenceThreshold: 0.7, AutoCreateCaseThreshold: 0.85}
+}
+
+func TestTriageAccount_CreatesCasesAndAttachesReplies(t *testing.T) {
+	f := newFixture(t)
+	existing := &models.Case{UserID: f.user.ID, Title: "既有案件", BrandName: "舊品牌", Status: models.CaseStatusInProgress}
+	contact := "pm@brand.example"
+	existing.ContactEmail = &contact
+	require.NoError(t, f.db.Omit("User").Create(existing).Error)
+
+	// 已歸入案件的郵件串
+	linked := f.email(t, "原始邀約", "pm@brand.example", "thread-1")
+	require.NoError(t, f.db.Model(linked).Updates(map[string]interface{}{"case_id": existing.ID, "ai_analyzed": true}).Error)
+
+	reply := f.email(t, "Re: 原始邀約", "pm@brand.example", "thread-1")
+	fromContact := f.email(t, "新的合作", "PM@brand.example", "thread-2")
+	invite := f.email(t, "新品開箱邀約", "hello@new.example", "thread-3")
+	unsure := f.email(t, "不確定的邀約", "x@maybe.example", "thread-4")
+	newsletter := f.email(t, "本週電子報", "news@example.com", "")
+
+	analyzer := &fakeAnalyzer{results: map[string]*openai.EmailAnalysisResult{
+		"新的合作":   collaboration(0.8, "舊品牌"),
+		"新品開箱邀約": collaboration(0.95, "新品牌"),
+		"不確定的邀約": collaboration(0.6, "某品牌"),
+	}}
+	svc := NewService(f.db, testConfig(), analyzer)
+
+	result, err := svc.TriageAccount(context.Background(), f.account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Analyzed)
+	assert.Equal(t, 2, result.Attached)
+	assert.Equal(t, 1, result.CasesCreated)
+	assert.Zero(t, result.Failed)
+
+	reload := func(e *models.Email) models.Email {
+		var got models.Email
+		require.NoError(t, f.db.First(&got, "id = ?", e.ID).Error)
+		return got
+	}
+
+	got := reload(reply)
+	require.NotNil(t, got.CaseID)
+	assert.Equal(t, existing.ID, *got.CaseID)
+	assert.True(t, got.AIAnalyzed)
+	assert.NotNil(t, got.AIAnalysisID)
+
+	got = reload(fromContact)
+	require.NotNil(t, got.CaseID)
+	assert.Equal(t, existing.ID, *got.CaseID)
+
+	got = reload(invite)
+	require.NotNil(t, got.CaseID)
+	var created models.Case
+	require.NoError(t, f.db.First(&created, "id = ?", *got.CaseID).Error)
+	assert.Equal(t, "新品牌", created.BrandName)
+
+	assert.Nil(t, reload(unsure).CaseID)
+	assert.Nil(t, reload(newsletter).CaseID)
+
+	// 已分析的郵件不會再送分析
+	result, err = svc.TriageAccount(context.Background(), f.account.ID)
+	require.NoError(t, err)
+	assert.Zero(t, result.Analyzed)
+	assert.Equal(t, 5, analyzer.calls)
+}
+
+func TestTriageAccount_RespectsUserSettings(t *testing.T) {
+	f := newFixture(t)
+	invite := f.email(t, "新品開箱邀約", "hello@new.example", "")
+	analyzer := &fakeAnalyzer{results: map[string]*openai.EmailAnalysisResult{"新品開箱邀約": collaboration(0.95, "新品牌")}}
+	svc := NewService(f.db, testConfig(), analyzer)
+
+	settings := models.DefaultAITriageSettings(f.user.ID, false)
+	require.NoError(t, f.db.Omit("User").Create(&settings).Error)
+
+	pending, err := svc.PendingAccounts(uuid.Nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	result, err := svc.TriageAccount(context.Background(), f.account.ID)
+	require.NoError(t, err)
+	assert.True(t, result.Skipped)
+	assert.Zero(t, analyzer.calls)
+
+	// 開啟分析但不自動建立案件，且門檻提高
+	threshold := 0.99
+	require.NoError(t, f.db.Model(&settings).Updates(map[string]interface{}{
+		"auto_analyze": true, "auto_create_cases": true, "auto_create_case_threshold": threshold,
+	}).Error)
+
+	pending, err = svc.PendingAccounts(uuid.Nil, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{f.account.ID}, pending)
+
+	result, err = svc.TriageAccount(context.Background(), f.account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Analyzed)
+	assert.Zero(t, result.CasesCreated)
+
+	var got models.Email
+	require.NoError(t, f.db.First(&got, "id = ?", invite.ID).Error)
+	assert.Nil(t, got.CaseID)
+	assert.True(t, got.AIAnalyzed)
+}
+
+// failingAnalyzer 分析一律失敗
+type failingAnalyzer struct{ calls int }
+
+func (f *failingAnalyzer) AnalyzeEmail(ctx context.Context, req openai.AnalyzeEmailRequest) (*openai.EmailAnalysisResult, error) {
+	f.calls++
+	return nil, errors.New("model unavailable")
+}
+
+func TestTriageAccount_FailuresBackOffAndDoNotBlockNewMail(t *testing.T) {
+	f := newFixture(t)
+	cfg := testConfig()
+	cfg.TriageBatchSize = 1
+	cfg.TriageMaxAttempts = 2
+	analyzer := &failingAnalyzer{}
+	svc := NewService(f.db, cfg, analyzer)
+	stuck := f.email(t, "無法分析", "a@example.com", "")
+	newer := f.email(t, "較新的信", "b@example.com", "")
+	require.NoError(t, f.db.Model(newer).Update("received_at", time.Now()).Error)
+	load := func(id uuid.UUID) models.Email {
+		var e models.Email
+		require.NoError(t, f.db.First(&e, "id = ?", id).Error)
+		return e
+	}
+
+	// 失敗後延後重試，下一次改處理較新的郵件
+	result, err := svc.TriageAccount(context.Background(), f.account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Failed)
+	got := load(stuck.ID)
+	assert.Equal(t, 1, got.TriageAttempts)
+	require.NotNil(t, got.TriageRetryAt)
+	assert.Nil(t, got.TriagedAt)
+
+	_, err = svc.TriageAccount(context.Background(), f.account.ID)
+	require.NoError(t, err)
+	got = load(newer.ID)
+	assert.Equal(t, 1, got.TriageAttempts)
+
+	// 重試時間到了仍失敗：達次數上限後放棄
+	require.NoError(t, f.db.Model(&models.Email{}).Where("id = ?", stuck.ID).Update("triage_retry_at", time.Now().Add(-time.Minute)).Error)
+	_, err = svc.TriageAccount(context.Background(), f.account.ID)
+	require.NoError(t, err)
+	got = load(stuck.ID)
+	assert.Equal(t, 2, got.TriageAttempts)
+	assert.NotNil(t, got.TriagedAt)
+	assert.Equal(t, 3, analyzer.calls)
+
+	// 未設定分析模型：只依郵件串歸檔，之後不再重複處理
+	plain := f.email(t, "一般來信", "c@example.com", "")
+	svc = NewService(f.db, cfg, nil)
+	_, err = svc.TriageAccount(context.Background(), f.account.ID)
+	require.NoError(t, err)
+	got = load(plain.ID)
+	assert.NotNil(t, got.TriagedAt)
+	assert.False(t, got.AIAnalyzed)
+	pending, err := svc.PendingAccounts(uuid.Nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestTriageAccount_BudgetFallbackStaysPending(t *testing.T) {
+	f := newFixture(t)
+	heuristic := &openai.EmailAnalysisResult{
+		Classification: openai.EmailClassification{Category: openai.CategoryCollaboration, Confidence: 0.5},
+		Priority:       "medium",
+		Model:          openai.HeuristicModel,
+		AnalyzedAt:     time.Now(),
+	}
+	analyzer := &fakeAnalyzer{results: map[string]*openai.EmailAnalysisResult{"合作邀約": heuristic}}
+	svc := NewService(f.db, testConfig(), analyzer)
+	invite := f.email(t, "合作邀約", "pm@brand.example", "")
+	load := func() models.Email {
+		var e models.Email
+		require.NoError(t, f.db.First(&e, "id = ?", invite.ID).Error)
+		return e
+	}
+	retryNow := func() {
+		require.NoError(t, f.db.Model(&models.Email{}).Where("id = ?", invite.ID).Update("triage_retry_at", time.Now().Add(-time.Minute)).Error)
+	}
+
+	// 超過額度：保留關鍵字分類，但不標為已分析、不計入失敗次數
+	result, err := svc.TriageAccount(context.Background(), f.account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Deferred)
+	assert.Equal(t, 0, result.Analyzed)
+	got := load()
+	assert.False(t, got.AIAnalyzed)
+	assert.NotNil(t, got.AIAnalysisID)
+	assert.Nil(t, got.TriagedAt)
+	assert.Equal(t, 0, got.TriageAttempts)
+	require.NotNil(t, got.TriageRetryAt)
+
+	// 仍超過額度：不重複保存關鍵字分類
+	retryNow()
+	_, err = svc.TriageAccount(context.Background(), f.account.ID)
+	require.NoError(t, err)
+
+	// 額度恢復後重新分析
+	analyzer.results["合作邀約"] = collaboration(0.9, "品牌")
+	retryNow()
+	result, err = svc.TriageAccount(context.Background(), f.account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Analyzed)
+	assert.Equal(t, 1, result.CasesCreated)
+	got = load()
+	assert.True(t, got.AIAnalyzed)
+	assert.NotNil(t, got.TriagedAt)
+
+	var versions int64
+	require.NoError(t, f.db.Model(&models.AIAnalysis{}).Where("email_id = ?", invite.ID).Count(&versions).Error)
+	assert.Equal(t, int64(2), versions)
+}
+
+func TestTriageAccount_ProposesCaseUpdatesFromIncoming(t *testing.T) {
+	f := newFixture(t)
+	quoted := 30000.0
+	cs := &models.Case{UserID: f.user.ID, Title: "新品開箱", BrandName: "Glow", Status: models.CaseStatusToConfirm, QuotedAmount: &quoted}
+	require.NoError(t, f.db.Omit("User").Create(cs).Error)
+
+	// 我方寄出的報價，以及品牌在同一郵件串的回覆
+	body := "報價 30,000 元，含一支 Reels"
+	sent := &models.Email{OAuthAccountID: f.account.ID, ProviderMessageID: uuid.NewString(), FromEmail: "creator@example.com",
+		BodyText: &body, Direction: models.EmailDirectionOutgoing, ReceivedAt: time.Now().Add(-2 * time.Hour), CaseID: &cs.ID, AIAnalyzed: true}
+	thread := "thread-1"
+	sent.ThreadID = &thread
+	require.NoError(t, f.db.Create(sent).Error)
+	reply := f.email(t, "Re: 新品開箱", "pm@glow.example", thread)
+	f.email(t, "本週電子報", "news@glow.example", thread)
+
+	final := 30000.0
+	updates := &fakeCaseUpdates{result: &openai.ReplyCaseUpdateResult{
+		ShouldUpdate: true, Status: "in_progress", FinalAmount: &final, Reason: "品牌同意報價",
+		Confidence: 0.9, Signals: []string{openai.SignalPriceAgreed},
+	}}
+	analyzer := &fakeAnalyzer{results: map[string]*openai.EmailAnalysisResult{"Re: 新品開箱": collaboration(0.9, "Glow")}}
+	svc := NewService(f.db, testConfig(), analyzer)
+	svc.SetCaseUpdates(updates)
+
+	result, err := svc.TriageAccount(context.Background(), f.account.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Attached)
+	assert.Equal(t, 1, result.CaseUpdates)
+
+	// 只有合作相關的來信送分析，並附上我方上一封信
+	require.Len(t, updates.requests, 1)
+	assert.Equal(t, "Re: 新品開箱", updates.requests[0].EmailSubject)
+	assert.Equal(t, body, updates.requests[0].PreviousMessage)
+	assert.Equal(t, "30000", updates.requests[0].CaseQuotedAmount)
+
+	var suggestion models.CaseUpdateSuggestion
+	require.NoError(t, f.db.First(&suggestion, "case_id = ?", cs.ID).Error)
+	assert.Equal(t, models.CaseUpdateSourceIncoming, suggestion.Source)
+	assert.Equal(t, models.CaseUpdateSuggestionPending, suggestion.Status)
+	assert.Equal(t, reply.ID, *suggestion.EmailID)
+	assert.Equal(t, []string{openai.SignalPriceAgreed}, []string(suggestion.Signals))
+
+	// 建議待確認，案件不變
+	var got models.Case
+	require.NoError(t, f.db.First(&got, "id = ?", cs.ID).Error)
+	assert.Equal(t, models.CaseStatusToConfirm, got.Status)
+	assert.Nil(t, got.FinalAmount)
+}
@@ -0,0 +1,219 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 額度狀態
+const (
+	BudgetStateOK       = "ok"
+	BudgetStateWarning  = "warning"  // 超過提醒門檻
+	BudgetStateExceeded = "exceeded" // 已達上限：只做關鍵字分類，不產生草稿
+)
+
+// Guard 使用額度與頻率限制（實作 openai.UsageGuard）
+type Guard struct {
+	db  *gorm.DB
+	cfg config.AIConfig
+	now func() time.Time
+}
+
+// NewGuard 建立額度檢查
+func NewGuard(db *gorm.DB, cfg config.AIConfig) *Guard {
+	if cfg.BudgetSoftLimitPercent <= 0 || cfg.BudgetSoftLimitPercent > 100 {
+		cfg.BudgetSoftLimitPercent = 80
+	}
+	return &Guard{db: db, cfg: cfg, now: time.Now}
+}
+
+// BudgetStatus 使用者本月的額度與用量
+type BudgetStatus struct {
+	Period              string         `json:"period"`                 // YYYY-MM（UTC）
+	MonthlyTokenLimit   int64          `json:"monthly_token_limit"`    // 0 表示不限制
+	MonthlyCostLimitUSD float64        `json:"monthly_cost_limit_usd"` // 0 表示不限制
+	SoftLimitPercent    int            `json:"soft_limit_percent"`
+	RateLimits          map[string]int `json:"rate_limits"` // 各用途每小時呼叫次數上限
+	Used                Totals         `json:"used"`
+	UsedPercent         float64        `json:"used_percent"` // token 與成本中較高的使用比例
+	State               string         `json:"state"`
+	OverrideUntil       *time.Time     `json:"override_until,omitempty"`
+	OverrideNote        *string        `json:"override_note,omitempty"`
+}
+
+// GetBudget 取得使用者的額度設定（未設定時回傳空白設定，套用系統預設）
+func (g *Guard) GetBudget(userID uuid.UUID) (models.AIBudget, error) {
+	var budget models.AIBudget
+	err := g.db.Where("user_id = ?", userID).First(&budget).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.AIBudget{UserID: userID}, nil
+	}
+	return budget, err
+}
+
+// Status 計算使用者本月的額度狀態
+func (g *Guard) Status(userID uuid.UUID) (*BudgetStatus, error) {
+	budget, err := g.GetBudget(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ai budget: %w", err)
+	}
+	return g.status(budget)
+}
+
+func (g *Guard) status(budget models.AIBudget) (*BudgetStatus, error) {
+	now := g.now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var used Totals
+	err := g.db.Model(&models.AIUsage{}).
+		Select("COALESCE(SUM(CASE WHEN cached THEN 0 ELSE 1 END), 0) AS calls, "+
+			"COALESCE(SUM(CASE WHEN cached THEN 1 ELSE 0 END), 0) AS cache_hits, "+
+			"COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, "+
+			"COALESCE(SUM(completion_tokens), 0) AS completion_tokens, "+
+			"COALESCE(SUM(total_tokens), 0) AS total_tokens, COALESCE(SUM(cost_usd), 0) AS cost_usd").
+		Where("user_id = ? AND created_at >= ?", budget.UserID, monthStart).
+		Scan(&used).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum ai usage: %w", err)
+	}
+	used.updateHitRate()
+
+	status := &BudgetStatus{
+		Period:              monthStart.Format("2006-01"),
+		MonthlyTokenLimit:   g.cfg.MonthlyTokenLimit,
+		MonthlyCostLimitUSD: g.cfg.MonthlyCostLimitUSD,
+		SoftLimitPercent:    g.cfg.BudgetSoftLimitPercent,
+		RateLimits:          g.rateLimits(budget),
+		Used:                used,
+		State:               BudgetStateOK,
+		OverrideUntil:       budget.OverrideUntil,
+		OverrideNote:        budget.OverrideNote,
+	}
+	if budget.MonthlyTokenLimit != nil {
+		status.MonthlyTokenLimit = *budget.MonthlyTokenLimit
+	}
+	if budget.MonthlyCostLimitUSD != nil {
+		status.MonthlyCostLimitUSD = *budget.MonthlyCostLimitUSD
+	}
+
+	if status.MonthlyTokenLimit > 0 {
+		status.UsedPercent = float64(used.TotalTokens) / float64(status.MonthlyTokenLimit) * 100
+	}
+	if status.MonthlyCostLimitUSD > 0 {
+		if pct := used.CostUSD / status.MonthlyCostLimitUSD * 100; pct > status.UsedPercent {
+			status.UsedPercent = pct
+		}
+	}
+	switch {
+	case status.UsedPercent >= 100:
+		status.State = BudgetStateExceeded
+	case status.UsedPercent >= float64(status.SoftLimitPercent):
+		status.State = BudgetStateWarning
+	}
+	return status, nil
+}
+
+// rateLimits 系統預設與使用者覆寫合併後的頻率限制
+func (g *Guard) rateLimits(budget models.AIBudget) map[string]int {
+	limits := make(map[string]int, len(g.cfg.RateLimits))
+	for op, limit := range g.cfg.RateLimits {
+		limits[op] = limit
+	}
+	if len(budget.RateLimits) > 0 {
+		var overrides map[string]int
+		if err := json.Unmarshal(budget.RateLimits, &overrides); err == nil {
+			for op, limit := range overrides {
+				limits[op] = limit
+			}
+		}
+	}
+	return limits
+}
+
+// CheckUsage 呼叫 AI 前檢查頻率與每月額度；達到提醒門檻或上限時每月各發一次站內通知
+func (g *Guard) CheckUsage(userID string, operation openai.Operation) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil
+	}
+	budget, err := g.GetBudget(uid)
+	if err != nil {
+		// 無法讀取額度時不阻擋呼叫
+		log.Warn().Err(err).Str("user_id", userID).Msg("Failed to load ai budget")
+		return nil
+	}
+	if budget.OverrideActive(g.now()) {
+		return nil
+	}
+
+	if limit := g.rateLimits(budget)[string(operation)]; limit > 0 {
+		var calls int64
+		err := g.db.Model(&models.AIUsage{}).
+			Where("user_id = ? AND operation = ? AND cached = ? AND created_at >= ?", uid, string(operation), false, g.now().Add(-time.Hour)).
+			Count(&calls).Error
+		if err == nil && calls >= int64(limit) {
+			return fmt.Errorf("%w: %s is limited to %d calls per hour", openai.ErrRateLimited, operation, limit)
+		}
+	}
+
+	status, err := g.status(budget)
+	if err != nil {
+		log.Warn().Err(err).Str("user_id", userID).Msg("Failed to compute ai budget status")
+		return nil
+	}
+	switch status.State {
+	case BudgetStateExceeded:
+		g.notifyOnce(&budget, status, models.NotificationTypeAIBudgetExceeded)
+		return fmt.Errorf("%w: %.0f%% of the monthly budget used", openai.ErrBudgetExceeded, status.UsedPercent)
+	case BudgetStateWarning:
+		g.notifyOnce(&budget, status, models.NotificationTypeAIBudgetWarning)
+	}
+	return nil
+}
+
+// notifyOnce 每月每種狀態只通知一次（記錄在額度設定中）
+func (g *Guard) notifyOnce(budget *models.AIBudget, status *BudgetStatus, kind models.NotificationType) {
+	title := fmt.Sprintf("本月 AI 用量已達 %.0f%%", status.UsedPercent)
+	message := "AI 用量即將達到本月額度，達到上限後將暫停產生草稿，郵件改以關鍵字分類。"
+	if kind == models.NotificationTypeAIBudgetExceeded {
+		if budget.ExceededPeriod == status.Period {
+			return
+		}
+		budget.ExceededPeriod = status.Period
+		budget.WarnedPeriod = status.Period
+		title = "本月 AI 用量已達上限"
+		message = "本月已暫停產生草稿，新郵件改以關鍵字分類，下個月自動恢復。如需提高額度請聯絡管理員。"
+	} else {
+		if budget.WarnedPeriod == status.Period {
+			return
+		}
+		budget.WarnedPeriod = status.Period
+	}
+
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		if budget.ID == uuid.Nil {
+			if err := tx.Omit(clause.Associations).Create(budget).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Model(budget).Updates(map[string]interface{}{
+			"warned_period": budget.WarnedPeriod, "exceeded_period": budget.ExceededPeriod,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Create(&models.Notification{
+			UserID: budget.UserID, Type: kind, Title: title, Message: &message,
+		}).Error
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("user_id", budget.UserID.String()).Msg("Failed to send ai budget notification")
+	}
+}
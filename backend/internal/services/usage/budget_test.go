@@ -0,0 +1,88 @@
+package usage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupBudgetTest(t *testing.T) (*gorm.DB, *Guard, uuid.UUID) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.AIBudget{}, &models.Notification{}))
+
+	guard := NewGuard(db, config.AIConfig{
+		MonthlyTokenLimit:      1000,
+		BudgetSoftLimitPercent: 80,
+		RateLimits:             map[string]int{"draft": 2},
+	})
+	guard.now = func() time.Time { return time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC) }
+	return db, guard, uuid.New()
+}
+
+func addUsage(t *testing.T, db *gorm.DB, userID uuid.UUID, op openai.Operation, tokens int, at time.Time) {
+	require.NoError(t, NewRecorder(db).RecordUsage(openai.TokenUsage{
+		UserID: userID.String(), Operation: op, Model: "gpt-4o-mini", TotalTokens: tokens, AnalyzedAt: at,
+	}))
+}
+
+func TestCheckUsage_RateLimitPerOperation(t *testing.T) {
+	db, guard, userID := setupBudgetTest(t)
+	now := guard.now()
+
+	addUsage(t, db, userID, openai.OperationDraft, 1, now.Add(-2*time.Hour)) // 超過一小時，不計
+	addUsage(t, db, userID, openai.OperationDraft, 1, now.Add(-30*time.Minute))
+	assert.NoError(t, guard.CheckUsage(userID.String(), openai.OperationDraft))
+
+	addUsage(t, db, userID, openai.OperationDraft, 1, now.Add(-10*time.Minute))
+	err := guard.CheckUsage(userID.String(), openai.OperationDraft)
+	assert.True(t, errors.Is(err, openai.ErrRateLimited))
+
+	// 其他用途不受影響
+	assert.NoError(t, guard.CheckUsage(userID.String(), openai.OperationClassify))
+}
+
+func TestCheckUsage_SoftWarningAndHardLimit(t *testing.T) {
+	db, guard, userID := setupBudgetTest(t)
+	now := guard.now()
+
+	addUsage(t, db, userID, openai.OperationClassify, 500, time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)) // 上個月，不計
+	addUsage(t, db, userID, openai.OperationClassify, 850, now.Add(-time.Hour))
+
+	// 超過 80%：可繼續使用，但每月只提醒一次
+	assert.NoError(t, guard.CheckUsage(userID.String(), openai.OperationClassify))
+	assert.NoError(t, guard.CheckUsage(userID.String(), openai.OperationClassify))
+	var warnings int64
+	db.Model(&models.Notification{}).Where("user_id = ? AND type = ?", userID, models.NotificationTypeAIBudgetWarning).Count(&warnings)
+	assert.Equal(t, int64(1), warnings)
+
+	addUsage(t, db, userID, openai.OperationExtract, 200, now.Add(-time.Minute))
+	err := guard.CheckUsage(userID.String(), openai.OperationExtract)
+	assert.True(t, errors.Is(err, openai.ErrBudgetExceeded))
+
+	status, err := guard.Status(userID)
+	require.NoError(t, err)
+	assert.Equal(t, BudgetStateExceeded, status.State)
+	assert.Equal(t, int64(1050), status.Used.TotalTokens)
+
+	var exceeded int64
+	db.Model(&models.Notification{}).Where("user_id = ? AND type = ?", userID, models.NotificationTypeAIBudgetExceeded).Count(&exceeded)
+	assert.Equal(t, int64(1), exceeded)
+
+	// 管理員解除限制
+	until := now.Add(24 * time.Hour)
+	require.NoError(t, db.Model(&models.AIBudget{}).Where("user_id = ?", userID).Update("override_until", until).Error)
+	assert.NoError(t, guard.CheckUsage(userID.String(), openai.OperationExtract))
+
+	// 使用者個別提高額度
+	require.NoError(t, db.Model(&models.AIBudget{}).Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"override_until": nil, "monthly_token_limit": 5000}).Error)
+	assert.NoError(t, guard.CheckUsage(userID.String(), openai.OperationExtract))
+}
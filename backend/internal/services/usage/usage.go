@@ -0,0 +1,164 @@
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Recorder 將 AI 用量寫入資料庫（實作 openai.UsageRecorder）
+type Recorder struct {
+	db *gorm.DB
+}
+
+// NewRecorder 建立用量記錄器
+func NewRecorder(db *gorm.DB) *Recorder {
+	return &Recorder{db: db}
+}
+
+// RecordUsage 寫入一筆用量
+func (r *Recorder) RecordUsage(u openai.TokenUsage) error {
+	row := models.AIUsage{
+		UserID:           parseID(u.UserID),
+		EmailID:          parseID(u.EmailID),
+		Operation:        string(u.Operation),
+		Model:            u.Model,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+		CostUSD:          u.CostUSD,
+		Cached:           u.Cached,
+		CreatedAt:        u.AnalyzedAt,
+	}
+	if err := r.db.Create(&row).Error; err != nil {
+		return fmt.Errorf("failed to record ai usage: %w", err)
+	}
+	return nil
+}
+
+func parseID(s string) *uuid.UUID {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+// Totals 用量合計
+type Totals struct {
+	Calls            int64   `json:"calls"`      // 實際呼叫 API 的次數
+	CacheHits        int64   `json:"cache_hits"` // 由結果快取取得的次數
+	CacheHitRate     float64 `json:"cache_hit_rate"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+func (t *Totals) add(o Totals) {
+	t.Calls += o.Calls
+	t.CacheHits += o.CacheHits
+	t.PromptTokens += o.PromptTokens
+	t.CompletionTokens += o.CompletionTokens
+	t.TotalTokens += o.TotalTokens
+	t.CostUSD += o.CostUSD
+	t.updateHitRate()
+}
+
+// updateHitRate 重新計算快取命中率（命中次數 / 全部請求）
+func (t *Totals) updateHitRate() {
+	t.CacheHitRate = 0
+	if requests := t.Calls + t.CacheHits; requests > 0 {
+		t.CacheHitRate = float64(t.CacheHits) / float64(requests)
+	}
+}
+
+// Bucket 單一期間（日或月）的用量
+type Bucket struct {
+	Period      string            `json:"period"` // 日：2006-01-02；月：2006-01
+	Totals                        // 該期間合計
+	ByOperation map[string]Totals `json:"by_operation"`
+}
+
+// Summary 使用者的用量統計
+type Summary struct {
+	From        time.Time         `json:"from"`
+	To          time.Time         `json:"to"`
+	Totals      Totals            `json:"totals"`
+	ByOperation map[string]Totals `json:"by_operation"`
+	Daily       []Bucket          `json:"daily"`
+	Monthly     []Bucket          `json:"monthly"`
+}
+
+// dailyRow 依日期與用途彙總的查詢結果
+type dailyRow struct {
+	Day       string
+	Operation string
+	Totals
+}
+
+// Summarize 統計使用者在 [from, to) 期間的用量（以 UTC 日期分組），並依日與月彙總
+func Summarize(db *gorm.DB, userID uuid.UUID, from, to time.Time) (*Summary, error) {
+	var rows []dailyRow
+	err := db.Model(&models.AIUsage{}).
+		Select("DATE(created_at) AS day, operation, "+
+			"SUM(CASE WHEN cached THEN 0 ELSE 1 END) AS calls, SUM(CASE WHEN cached THEN 1 ELSE 0 END) AS cache_hits, "+
+			"SUM(prompt_tokens) AS prompt_tokens, SUM(completion_tokens) AS completion_tokens, "+
+			"SUM(total_tokens) AS total_tokens, SUM(cost_usd) AS cost_usd").
+		Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, from, to).
+		Group("DATE(created_at), operation").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate ai usage: %w", err)
+	}
+
+	summary := &Summary{From: from, To: to, ByOperation: map[string]Totals{}, Daily: []Bucket{}, Monthly: []Bucket{}}
+	daily := map[string]*Bucket{}
+	monthly := map[string]*Bucket{}
+	for _, row := range rows {
+		// Postgres 的 date 會以 RFC3339 字串掃入，只取日期部分
+		day := row.Day
+		if len(day) > 10 {
+			day = day[:10]
+		}
+		summary.Totals.add(row.Totals)
+		addTo(summary.ByOperation, row.Operation, row.Totals)
+		addBucket(daily, day, row.Operation, row.Totals)
+		if len(day) >= 7 {
+			addBucket(monthly, day[:7], row.Operation, row.Totals)
+		}
+	}
+	summary.Daily = sortedBuckets(daily)
+	summary.Monthly = sortedBuckets(monthly)
+	return summary, nil
+}
+
+func addTo(m map[string]Totals, key string, t Totals) {
+	cur := m[key]
+	cur.add(t)
+	m[key] = cur
+}
+
+func addBucket(buckets map[string]*Bucket, period, operation string, t Totals) {
+	b, ok := buckets[period]
+	if !ok {
+		b = &Bucket{Period: period, ByOperation: map[string]Totals{}}
+		buckets[period] = b
+	}
+	b.Totals.add(t)
+	addTo(b.ByOperation, operation, t)
+}
+
+func sortedBuckets(buckets map[string]*Bucket) []Bucket {
+	result := make([]Bucket, 0, len(buckets))
+	for _, b := range buckets {
+		result = append(result, *b)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Period < result[j].Period })
+	return result
+}
@@ -0,0 +1,97 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/openai"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 設置測試用的資料庫（使用 SQLite）
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Skipf("Skipping test: SQLite not available (CGO required): %v", err)
+	}
+	require.NoError(t, db.AutoMigrate(&models.AIUsage{}))
+	return db
+}
+
+func TestSummarize_GroupsByDayMonthAndOperation(t *testing.T) {
+	db := setupTestDB(t)
+	recorder := NewRecorder(db)
+	userID := uuid.New()
+	other := uuid.New()
+	emailID := uuid.New()
+
+	record := func(user uuid.UUID, op openai.Operation, at time.Time, prompt, completion int, cost float64) {
+		require.NoError(t, recorder.RecordUsage(openai.TokenUsage{
+			UserID: user.String(), EmailID: emailID.String(), Operation: op, Model: "gpt-4o-mini",
+			PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion,
+			CostUSD: cost, AnalyzedAt: at,
+		}))
+	}
+	record(userID, openai.OperationClassify, time.Date(2026, 9, 30, 10, 0, 0, 0, time.UTC), 100, 20, 0.1)
+	record(userID, openai.OperationClassify, time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC), 100, 20, 0.1)
+	record(userID, openai.OperationDraft, time.Date(2026, 10, 1, 11, 0, 0, 0, time.UTC), 300, 200, 0.5)
+	record(userID, openai.OperationExtract, time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC), 50, 50, 0.05) // 範圍外
+	record(other, openai.OperationDraft, time.Date(2026, 10, 1, 11, 0, 0, 0, time.UTC), 1, 1, 9)       // 其他使用者
+
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	summary, err := Summarize(db, userID, from, to)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(3), summary.Totals.Calls)
+	assert.Equal(t, int64(740), summary.Totals.TotalTokens)
+	assert.InDelta(t, 0.7, summary.Totals.CostUSD, 1e-9)
+	assert.Equal(t, int64(2), summary.ByOperation["classify"].Calls)
+	assert.Equal(t, int64(500), summary.ByOperation["draft"].TotalTokens)
+
+	require.Len(t, summary.Daily, 2)
+	assert.Equal(t, "2026-09-30", summary.Daily[0].Period)
+	assert.Equal(t, "2026-10-01", summary.Daily[1].Period)
+	assert.Equal(t, int64(2), summary.Daily[1].Calls)
+	assert.Equal(t, int64(1), summary.Daily[1].ByOperation["draft"].Calls)
+
+	require.Len(t, summary.Monthly, 2)
+	assert.Equal(t, "2026-09", summary.Monthly[0].Period)
+	assert.Equal(t, "2026-10", summary.Monthly[1].Period)
+	assert.InDelta(t, 0.6, summary.Monthly[1].CostUSD, 1e-9)
+}
+
+func TestSummarize_CacheHitRate(t *testing.T) {
+	db := setupTestDB(t)
+	recorder := NewRecorder(db)
+	userID := uuid.New()
+	at := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	require.NoError(t, recorder.RecordUsage(openai.TokenUsage{
+		UserID: userID.String(), Operation: openai.OperationMatch, Model: "gpt-4o-mini",
+		PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120, CostUSD: 0.1, AnalyzedAt: at,
+	}))
+	for i := 0; i < 3; i++ {
+		require.NoError(t, recorder.RecordUsage(openai.TokenUsage{
+			UserID: userID.String(), Operation: openai.OperationMatch, Model: "gpt-4o-mini", Cached: true, AnalyzedAt: at,
+		}))
+	}
+
+	summary, err := Summarize(db, userID, at.AddDate(0, 0, -1), at.AddDate(0, 0, 1))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), summary.Totals.Calls)
+	assert.Equal(t, int64(3), summary.Totals.CacheHits)
+	assert.InDelta(t, 0.75, summary.Totals.CacheHitRate, 1e-9)
+	assert.Equal(t, int64(120), summary.Totals.TotalTokens)
+	assert.InDelta(t, 0.75, summary.ByOperation["match"].CacheHitRate, 1e-9)
+	require.Len(t, summary.Daily, 1)
+	assert.InDelta(t, 0.75, summary.Daily[0].CacheHitRate, 1e-9)
+}
@@ -0,0 +1,130 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/services/triage"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// TypeAITriage 分析單一帳號新收到的郵件
+	TypeAITriage = "ai:triage"
+
+	// TypeAITriageAll 為所有有未分析郵件的帳號建立分析任務
+	TypeAITriageAll = "ai:triage:all"
+
+	// triageAllPageSize 每次查詢待分析帳號的筆數
+	triageAllPageSize = 500
+)
+
+// AITriagePayload 自動分析任務的 payload
+type AITriagePayload struct {
+	OAuthAccountID string `json:"oauth_account_id"`
+}
+
+// NewAITriageTask 建立單一帳號的自動分析任務
+func NewAITriageTask(oauthAccountID string) (*asynq.Task, error) {
+	payload, err := json.Marshal(AITriagePayload{OAuthAccountID: oauthAccountID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	opts := []asynq.Option{
+		asynq.MaxRetry(2),
+		asynq.Timeout(30 * time.Minute),
+		asynq.Retention(24 * time.Hour),
+	}
+
+	return asynq.NewTask(TypeAITriage, payload, opts...), nil
+}
+
+// NewAITriageAllTask 建立補跑所有帳號自動分析的任務
+func NewAITriageAllTask() *asynq.Task {
+	return asynq.NewTask(TypeAITriageAll, nil, asynq.MaxRetry(1), asynq.Timeout(5*time.Minute))
+}
+
+// EnqueueAITriage 排入帳號的自動分析任務（同一帳號已在佇列中時略過）
+func EnqueueAITriage(client *asynq.Client, oauthAccountID string) error {
+	task, err := NewAITriageTask(oauthAccountID)
+	if err != nil {
+		return err
+	}
+	if _, err := client.Enqueue(task, asynq.Unique(10*time.Minute)); err != nil && !errors.Is(err, asynq.ErrDuplicateTask) {
+		return fmt.Errorf("failed to enqueue triage task: %w", err)
+	}
+	return nil
+}
+
+// HandleAITriageTask 分析帳號中尚未分析的新收件，並依使用者設定歸檔或建立案件
+func HandleAITriageTask(ctx context.Context, t *asynq.Task, svc *triage.Service) error {
+	var payload AITriagePayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	accountID, err := uuid.Parse(payload.OAuthAccountID)
+	if err != nil {
+		log.Warn().Str("oauth_account_id", payload.OAuthAccountID).Msg("Invalid account ID in triage task")
+		return nil // 不重試
+	}
+
+	result, err := svc.TriageAccount(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("triage failed: %w", err)
+	}
+
+	log.Info().
+		Str("oauth_account_id", payload.OAuthAccountID).
+		Bool("skipped", result.Skipped).
+		Int("analyzed", result.Analyzed).
+		Int("attached", result.Attached).
+		Int("suggested", result.Suggested).
+		Int("cases_created", result.CasesCreated).
+		Int("case_updates", result.CaseUpdates).
+		Int("failed", result.Failed).
+		Int("deferred", result.Deferred).
+		Msg("AI triage completed")
+	return nil
+}
+
+// HandleAITriageAllTask 為有未分析郵件的帳號建立分析任務（涵蓋手動同步與匯入的郵件，分頁處理所有帳號）
+func HandleAITriageAllTask(ctx context.Context, t *asynq.Task, svc *triage.Service, client *asynq.Client) error {
+	accounts := 0
+	errorCount := 0
+	var after uuid.UUID
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		accountIDs, err := svc.PendingAccounts(after, triageAllPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range accountIDs {
+			if err := EnqueueAITriage(client, id.String()); err != nil {
+				log.Warn().Err(err).Str("oauth_account_id", id.String()).Msg("Failed to enqueue triage task")
+				errorCount++
+			}
+		}
+		accounts += len(accountIDs)
+
+		if len(accountIDs) < triageAllPageSize {
+			break
+		}
+		after = accountIDs[len(accountIDs)-1]
+	}
+
+	log.Info().
+		Int("accounts", accounts).
+		Int("errors", errorCount).
+		Msg("AI triage tasks enqueued")
+	return nil
+}
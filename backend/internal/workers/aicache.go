@@ -0,0 +1,33 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/services/aicache"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// TypeAICachePurge 清除過期的 AI 結果快取
+const TypeAICachePurge = "ai:cache:purge"
+
+// NewAICachePurgeTask 建立快取清除任務
+func NewAICachePurgeTask() *asynq.Task {
+	return asynq.NewTask(TypeAICachePurge, nil, asynq.MaxRetry(1), asynq.Timeout(10*time.Minute))
+}
+
+// HandleAICachePurgeTask 刪除已過期的快取資料（讀取時雖會略過過期資料，但不會刪除）
+func HandleAICachePurgeTask(ctx context.Context, t *asynq.Task, db *gorm.DB) error {
+	purged, err := aicache.PurgeExpired(db.WithContext(ctx), time.Now())
+	if err != nil {
+		return fmt.Errorf("ai cache purge failed: %w", err)
+	}
+
+	if purged > 0 {
+		log.Info().Int64("purged", purged).Msg("Expired ai result cache purged")
+	}
+	return nil
+}
@@ -69,8 +69,8 @@ func NewEmailSyncAllTask(maxAccounts int) (*asynq.Task, error) {
 	return asynq.NewTask(TypeEmailSyncAll, payload, opts...), nil
 }
 
-// HandleEmailSyncTask 處理單個帳號的郵件同步任務
-func HandleEmailSyncTask(ctx context.Context, t *asynq.Task, db *gorm.DB) error {
+// HandleEmailSyncTask 處理單個帳號的郵件同步任務；有新郵件時排入自動分析任務
+func HandleEmailSyncTask(ctx context.Context, t *asynq.Task, db *gorm.DB, client *asynq.Client) error {
 	var payload EmailSyncPayload
 	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
 		return fmt.Errorf("failed to unmarshal payload: %w", err)
@@ -164,6 +164,13 @@ func HandleEmailSyncTask(ctx context.Context, t *asynq.Task, db *gorm.DB) error
 		}
 	}
 
+	// 有新郵件時排入自動分析（使用者關閉時任務會直接略過）
+	if result.NewEmails > 0 && client != nil {
+		if err := EnqueueAITriage(client, payload.OAuthAccountID); err != nil {
+			log.Warn().Err(err).Str("oauth_account_id", payload.OAuthAccountID).Msg("Failed to enqueue triage task")
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,34 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/services/followup"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+// TypeFollowUpCheck 檢查寄出郵件是否獲得回覆
+const TypeFollowUpCheck = "followup:check"
+
+// NewFollowUpCheckTask 建立未回覆檢查任務
+func NewFollowUpCheckTask() *asynq.Task {
+	return asynq.NewTask(TypeFollowUpCheck, nil, asynq.MaxRetry(1), asynq.Timeout(15*time.Minute))
+}
+
+// HandleFollowUpCheckTask 將已回覆的追蹤結案，超過期限未回覆的轉為待跟進並通知
+func HandleFollowUpCheckTask(ctx context.Context, t *asynq.Task, svc *followup.Service) error {
+	result, err := svc.Check(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("follow-up check failed: %w", err)
+	}
+
+	log.Info().
+		Int("replied", result.Replied).
+		Int("due", result.Due).
+		Int("drafted", result.Drafted).
+		Msg("Follow-up check completed")
+	return nil
+}
@@ -0,0 +1,116 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/mailimport"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	// TypeMailImport 郵件匯入任務類型
+	TypeMailImport = "mail:import"
+
+	// TypeMailImportSweep 清理中斷的郵件匯入
+	TypeMailImportSweep = "mail:import:sweep"
+)
+
+// MailImportPayload 郵件匯入任務的 payload
+type MailImportPayload struct {
+	ImportID string `json:"import_id"`
+}
+
+// NewMailImportTask 建立郵件匯入任務
+func NewMailImportTask(importID string) (*asynq.Task, error) {
+	payload, err := json.Marshal(MailImportPayload{ImportID: importID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	opts := []asynq.Option{
+		asynq.MaxRetry(2),
+		asynq.Timeout(2 * time.Hour),
+		asynq.Retention(24 * time.Hour),
+		asynq.TaskID(TypeMailImport + ":" + importID),
+	}
+
+	return asynq.NewTask(TypeMailImport, payload, opts...), nil
+}
+
+// EnqueueMailImport 排入郵件匯入任務（同一匯入已在佇列中時略過）
+func EnqueueMailImport(client *asynq.Client, importID string) error {
+	task, err := NewMailImportTask(importID)
+	if err != nil {
+		return err
+	}
+	if _, err := client.Enqueue(task); err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return fmt.Errorf("failed to enqueue mail import task: %w", err)
+	}
+	return nil
+}
+
+// NewMailImportSweepTask 建立清理中斷匯入的任務
+func NewMailImportSweepTask() *asynq.Task {
+	return asynq.NewTask(TypeMailImportSweep, nil, asynq.MaxRetry(1), asynq.Timeout(5*time.Minute))
+}
+
+// HandleMailImportTask 讀取上傳的檔案並匯入郵件，結束後刪除檔案
+func HandleMailImportTask(ctx context.Context, t *asynq.Task, db *gorm.DB) error {
+	var payload MailImportPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	var record models.MailImport
+	if err := db.First(&record, "id = ?", payload.ImportID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Warn().Str("import_id", payload.ImportID).Msg("Mail import not found")
+			return nil // 不重試
+		}
+		return fmt.Errorf("failed to load mail import: %w", err)
+	}
+	if mailimport.IsFinished(&record) {
+		return nil
+	}
+
+	logger := log.With().Str("import_id", payload.ImportID).Logger()
+	runErr := mailimport.NewImporter(db, &logger).Run(ctx, record.ID, record.StoragePath)
+
+	// 匯入失敗時 Importer 已記錄狀態，重試也無法成功；狀態未更新（如資料庫錯誤）才重試
+	if err := db.First(&record, "id = ?", record.ID).Error; err != nil {
+		return fmt.Errorf("failed to reload mail import: %w", err)
+	}
+	if !mailimport.IsFinished(&record) {
+		if runErr == nil {
+			runErr = errors.New("import status not saved")
+		}
+		return fmt.Errorf("mail import failed: %w", runErr)
+	}
+	if err := mailimport.RemoveUpload(&record); err != nil {
+		logger.Warn().Err(err).Msg("Failed to remove mail import upload")
+	}
+	if runErr != nil {
+		logger.Error().Err(runErr).Msg("Mail import failed")
+	}
+	return nil
+}
+
+// HandleMailImportSweepTask 將逾時未完成的匯入標記為失敗並刪除上傳檔案
+func HandleMailImportSweepTask(ctx context.Context, t *asynq.Task, db *gorm.DB, cfg config.ImportConfig) error {
+	swept, err := mailimport.SweepStale(db.WithContext(ctx), cfg.StaleAfter)
+	if err != nil {
+		return fmt.Errorf("mail import sweep failed: %w", err)
+	}
+	if swept > 0 {
+		log.Info().Int("count", swept).Msg("Marked interrupted mail imports as failed")
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/config"
+	"github.com/designcomb/influenter-backend/internal/models"
+	"github.com/designcomb/influenter-backend/internal/services/retention"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	// TypeRetentionPurge 單一使用者的資料清除任務類型
+	TypeRetentionPurge = "retention:purge"
+
+	// TypeRetentionPurgeAll 為所有使用者建立資料清除任務
+	TypeRetentionPurgeAll = "retention:purge:all"
+)
+
+// RetentionPurgePayload 資料清除任務的 payload
+type RetentionPurgePayload struct {
+	UserID string `json:"user_id"`
+}
+
+// NewRetentionPurgeTask 建立單一使用者的資料清除任務
+func NewRetentionPurgeTask(userID string) (*asynq.Task, error) {
+	payload, err := json.Marshal(RetentionPurgePayload{UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	opts := []asynq.Option{
+		asynq.MaxRetry(3),
+		asynq.Timeout(30 * time.Minute),
+		asynq.Retention(24 * time.Hour),
+	}
+
+	return asynq.NewTask(TypeRetentionPurge, payload, opts...), nil
+}
+
+// NewRetentionPurgeAllTask 建立為所有使用者清除資料的任務
+func NewRetentionPurgeAllTask() *asynq.Task {
+	return asynq.NewTask(TypeRetentionPurgeAll, nil, asynq.MaxRetry(2), asynq.Timeout(10*time.Minute))
+}
+
+// HandleRetentionPurgeTask 依使用者的保留政策清除資料
+func HandleRetentionPurgeTask(ctx context.Context, t *asynq.Task, db *gorm.DB, cfg config.RetentionConfig) error {
+	var payload RetentionPurgePayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	userID, err := uuid.Parse(payload.UserID)
+	if err != nil {
+		log.Warn().Str("user_id", payload.UserID).Msg("Invalid user ID in retention task")
+		return nil // 不重試
+	}
+
+	report, err := retention.NewService(db, cfg).Run(ctx, userID, false)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", payload.UserID).Msg("Retention purge failed")
+		return fmt.Errorf("retention purge failed: %w", err)
+	}
+
+	log.Info().
+		Str("user_id", payload.UserID).
+		Str("status", report.Status).
+		Int64("total", report.Total).
+		Interface("counts", report.Counts).
+		Msg("Retention purge completed")
+
+	return nil
+}
+
+// HandleRetentionPurgeAllTask 為啟用保留政策（或未設定，套用預設值）的使用者建立清除任務
+func HandleRetentionPurgeAllTask(ctx context.Context, t *asynq.Task, db *gorm.DB, client *asynq.Client) error {
+	var userIDs []uuid.UUID
+	err := db.Model(&models.User{}).
+		Where("id NOT IN (?)", db.Model(&models.RetentionPolicy{}).Select("user_id").Where("enabled = ?", false)).
+		Pluck("id", &userIDs).Error
+	if err != nil {
+		return fmt.Errorf("failed to query users: %w", err)
+	}
+
+	successCount := 0
+	errorCount := 0
+	for _, id := range userIDs {
+		task, err := NewRetentionPurgeTask(id.String())
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to create retention task")
+			errorCount++
+			continue
+		}
+
+		// 同一使用者同時只排一個清除任務
+		if _, err := client.Enqueue(task, asynq.Unique(time.Hour)); err != nil {
+			log.Warn().Err(err).Str("user_id", id.String()).Msg("Failed to enqueue retention task")
+			errorCount++
+			continue
+		}
+		successCount++
+	}
+
+	log.Info().
+		Int("success", successCount).
+		Int("errors", errorCount).
+		Msg("Retention purge tasks enqueued")
+
+	return nil
+}
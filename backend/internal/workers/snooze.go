@@ -0,0 +1,36 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/services/snooze"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// TypeSnoozeWake 喚醒到期的延後郵件與案件
+const TypeSnoozeWake = "snooze:wake"
+
+// NewSnoozeWakeTask 建立喚醒任務
+func NewSnoozeWakeTask() *asynq.Task {
+	return asynq.NewTask(TypeSnoozeWake, nil, asynq.MaxRetry(1), asynq.Timeout(5*time.Minute))
+}
+
+// HandleSnoozeWakeTask 將到期的郵件標為未讀、案件重新顯示，並建立站內通知
+func HandleSnoozeWakeTask(ctx context.Context, t *asynq.Task, db *gorm.DB) error {
+	result, err := snooze.WakeDue(ctx, db, time.Now())
+	if err != nil {
+		return fmt.Errorf("snooze wake failed: %w", err)
+	}
+
+	if result.Emails > 0 || result.Cases > 0 {
+		log.Info().
+			Int("emails", result.Emails).
+			Int("cases", result.Cases).
+			Msg("Snoozed items woken up")
+	}
+	return nil
+}
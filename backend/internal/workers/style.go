@@ -0,0 +1,108 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/designcomb/influenter-backend/internal/services/style"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// TypeStyleRefresh 重新統計單一使用者的寫作風格
+	TypeStyleRefresh = "style:refresh"
+
+	// TypeStyleRefreshAll 為所有有寄出郵件的使用者建立重新統計任務
+	TypeStyleRefreshAll = "style:refresh:all"
+
+	// styleRefreshAllPageSize 每次查詢使用者的筆數
+	styleRefreshAllPageSize = 500
+)
+
+// StyleRefreshPayload 寫作風格統計任務的 payload
+type StyleRefreshPayload struct {
+	UserID string `json:"user_id"`
+}
+
+// NewStyleRefreshTask 建立單一使用者的寫作風格統計任務
+func NewStyleRefreshTask(userID string) (*asynq.Task, error) {
+	payload, err := json.Marshal(StyleRefreshPayload{UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return asynq.NewTask(TypeStyleRefresh, payload, asynq.MaxRetry(2), asynq.Timeout(5*time.Minute)), nil
+}
+
+// NewStyleRefreshAllTask 建立定期重新統計所有使用者寫作風格的任務
+func NewStyleRefreshAllTask() *asynq.Task {
+	return asynq.NewTask(TypeStyleRefreshAll, nil, asynq.MaxRetry(1), asynq.Timeout(5*time.Minute))
+}
+
+// HandleStyleRefreshTask 依最近的寄出郵件重新統計使用者的寫作風格
+func HandleStyleRefreshTask(ctx context.Context, t *asynq.Task, svc *style.Service) error {
+	var payload StyleRefreshPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	userID, err := uuid.Parse(payload.UserID)
+	if err != nil {
+		log.Warn().Str("user_id", payload.UserID).Msg("Invalid user ID in style refresh task")
+		return nil // 不重試
+	}
+
+	profile, err := svc.Refresh(userID)
+	if err != nil {
+		return fmt.Errorf("style refresh failed: %w", err)
+	}
+
+	log.Info().
+		Str("user_id", payload.UserID).
+		Int("samples", profile.SampleCount).
+		Msg("Writing style profile refreshed")
+	return nil
+}
+
+// HandleStyleRefreshAllTask 為有寄出郵件的使用者建立寫作風格統計任務（分頁處理所有使用者）
+func HandleStyleRefreshAllTask(ctx context.Context, t *asynq.Task, svc *style.Service, client *asynq.Client) error {
+	users := 0
+	errorCount := 0
+	var after uuid.UUID
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		userIDs, err := svc.UsersWithSentEmails(after, styleRefreshAllPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range userIDs {
+			task, err := NewStyleRefreshTask(id.String())
+			if err == nil {
+				_, err = client.Enqueue(task, asynq.Unique(time.Hour))
+			}
+			if err != nil && !errors.Is(err, asynq.ErrDuplicateTask) {
+				log.Warn().Err(err).Str("user_id", id.String()).Msg("Failed to enqueue style refresh task")
+				errorCount++
+			}
+		}
+		users += len(userIDs)
+
+		if len(userIDs) < styleRefreshAllPageSize {
+			break
+		}
+		after = userIDs[len(userIDs)-1]
+	}
+
+	log.Info().
+		Int("users", users).
+		Int("errors", errorCount).
+		Msg("Style refresh tasks enqueued")
+	return nil
+}
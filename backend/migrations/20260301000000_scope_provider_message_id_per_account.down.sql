@@ -0,0 +1,18 @@
+-- Migration: scope_provider_message_id_per_account rollback
+-- 回復全域唯一前，必須先移除跨帳號重複的 provider_message_id（每組只保留最早建立的一筆）
+-- 注意：此步驟會刪除資料，rollback 前請先備份
+
+DROP INDEX IF EXISTS idx_emails_duplicate_of_id;
+ALTER TABLE emails DROP COLUMN IF EXISTS duplicate_of_id;
+DROP INDEX IF EXISTS idx_emails_rfc_message_id;
+ALTER TABLE emails DROP COLUMN IF EXISTS rfc_message_id;
+
+DELETE FROM emails WHERE id IN (
+    SELECT id FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY provider_message_id ORDER BY created_at ASC, id ASC) AS rn
+        FROM emails
+    ) ranked WHERE ranked.rn > 1
+);
+
+DROP INDEX IF EXISTS idx_emails_account_provider_message;
+ALTER TABLE emails ADD CONSTRAINT emails_provider_message_id_key UNIQUE (provider_message_id);
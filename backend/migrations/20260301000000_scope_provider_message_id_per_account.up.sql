@@ -0,0 +1,14 @@
+-- Migration: scope_provider_message_id_per_account
+-- provider_message_id 改為「每個帳號內唯一」，並新增 RFC Message-ID 供跨帳號去重
+-- 原本的全域唯一已保證每帳號唯一，建立複合唯一索引不會失敗
+
+ALTER TABLE emails DROP CONSTRAINT IF EXISTS emails_provider_message_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_emails_account_provider_message ON emails(oauth_account_id, provider_message_id);
+
+ALTER TABLE emails ADD COLUMN IF NOT EXISTS rfc_message_id VARCHAR(998);
+COMMENT ON COLUMN emails.rfc_message_id IS 'RFC 5322 Message-ID header（正規化：去角括號、小寫）';
+CREATE INDEX IF NOT EXISTS idx_emails_rfc_message_id ON emails(rfc_message_id);
+
+ALTER TABLE emails ADD COLUMN IF NOT EXISTS duplicate_of_id UUID REFERENCES emails(id) ON DELETE SET NULL;
+COMMENT ON COLUMN emails.duplicate_of_id IS '同一使用者其他帳號已有同一封信時，指向最早收到的那筆';
+CREATE INDEX IF NOT EXISTS idx_emails_duplicate_of_id ON emails(duplicate_of_id);
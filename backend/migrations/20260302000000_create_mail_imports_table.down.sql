@@ -0,0 +1,4 @@
+-- Migration: create_mail_imports_table rollback
+-- 注意：匯入的郵件（provider = 'imported' 的帳號）不會被刪除
+
+DROP TABLE IF EXISTS mail_imports;
@@ -0,0 +1,29 @@
+-- Migration: create_mail_imports_table
+-- 郵件匯入工作（.eml / .mbox / zip），記錄進度、重複與錯誤
+
+CREATE TABLE mail_imports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    oauth_account_id UUID NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    total_messages INT NOT NULL DEFAULT 0,
+    imported_count INT NOT NULL DEFAULT 0,
+    duplicate_count INT NOT NULL DEFAULT 0,
+    failed_count INT NOT NULL DEFAULT 0,
+    errors JSONB,
+    error_message TEXT,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_mail_imports_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_mail_imports_oauth_account FOREIGN KEY (oauth_account_id) REFERENCES oauth_accounts(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_mail_imports_user_id ON mail_imports(user_id);
+CREATE INDEX idx_mail_imports_status ON mail_imports(status);
+CREATE INDEX idx_mail_imports_deleted_at ON mail_imports(deleted_at);
+
+COMMENT ON TABLE mail_imports IS '郵件匯入工作';
+COMMENT ON COLUMN mail_imports.errors IS '單封郵件錯誤 [{source, error}]，最多 100 筆';
@@ -0,0 +1,6 @@
+-- Migration: create_retention_tables rollback
+-- 注意：已清除的郵件內文無法還原
+
+ALTER TABLE emails DROP COLUMN IF EXISTS body_purged_at;
+DROP TABLE IF EXISTS retention_audits;
+DROP TABLE IF EXISTS retention_policies;
@@ -0,0 +1,40 @@
+-- Migration: create_retention_tables
+-- 使用者資料保留政策、清除紀錄，以及郵件內文清除時間
+
+CREATE TABLE retention_policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    email_body_retention_days INT,
+    keep_case_emails BOOLEAN NOT NULL DEFAULT TRUE,
+    soft_delete_purge_days INT NOT NULL DEFAULT 30,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_retention_policies_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_retention_policies_user_id ON retention_policies(user_id);
+
+COMMENT ON TABLE retention_policies IS '使用者資料保留政策';
+COMMENT ON COLUMN retention_policies.email_body_retention_days IS '非案件郵件內文保留天數，NULL 表示永久保留';
+COMMENT ON COLUMN retention_policies.soft_delete_purge_days IS '軟刪除資料在幾天後永久刪除';
+
+CREATE TABLE retention_audits (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    policy JSONB,
+    counts JSONB,
+    total BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_retention_audits_user_id ON retention_audits(user_id);
+CREATE INDEX idx_retention_audits_created_at ON retention_audits(created_at);
+
+COMMENT ON TABLE retention_audits IS '資料清除紀錄（使用者刪除後仍保留）';
+COMMENT ON COLUMN retention_audits.counts IS '各項目清除數量，如 {"emails.body_purged": 12}';
+
+ALTER TABLE emails ADD COLUMN IF NOT EXISTS body_purged_at TIMESTAMP WITH TIME ZONE;
+COMMENT ON COLUMN emails.body_purged_at IS '郵件內文依保留政策清除的時間';
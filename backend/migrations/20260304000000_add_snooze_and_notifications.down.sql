@@ -0,0 +1,7 @@
+-- Migration: add_snooze_and_notifications rollback
+
+DROP TABLE IF EXISTS notifications;
+DROP INDEX IF EXISTS idx_cases_snoozed_until;
+ALTER TABLE cases DROP COLUMN IF EXISTS snoozed_until;
+DROP INDEX IF EXISTS idx_emails_snoozed_until;
+ALTER TABLE emails DROP COLUMN IF EXISTS snoozed_until;
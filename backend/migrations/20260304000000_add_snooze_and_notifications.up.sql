@@ -0,0 +1,31 @@
+-- Migration: add_snooze_and_notifications
+-- 郵件與案件的延後處理時間，以及站內通知
+
+ALTER TABLE emails ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_emails_snoozed_until ON emails(snoozed_until) WHERE snoozed_until IS NOT NULL;
+COMMENT ON COLUMN emails.snoozed_until IS '延後處理到期時間，到期前不出現在預設列表';
+
+ALTER TABLE cases ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_cases_snoozed_until ON cases(snoozed_until) WHERE snoozed_until IS NOT NULL;
+COMMENT ON COLUMN cases.snoozed_until IS '延後處理到期時間，到期前不出現在預設列表';
+
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    title VARCHAR(500) NOT NULL,
+    message TEXT,
+    email_id UUID,
+    case_id UUID,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_notifications_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_notifications_user_id ON notifications(user_id);
+CREATE INDEX idx_notifications_type ON notifications(type);
+CREATE INDEX idx_notifications_email_id ON notifications(email_id);
+CREATE INDEX idx_notifications_case_id ON notifications(case_id);
+CREATE INDEX idx_notifications_created_at ON notifications(created_at);
+CREATE INDEX idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
+
+COMMENT ON TABLE notifications IS '站內通知';
@@ -0,0 +1,3 @@
+-- Migration: create_follow_ups_table rollback
+
+DROP TABLE IF EXISTS follow_ups;
@@ -0,0 +1,32 @@
+-- Migration: create_follow_ups_table
+-- 案件寄出郵件的回覆追蹤：超過工作天數未回覆時提醒跟進
+
+CREATE TABLE follow_ups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    case_id UUID NOT NULL,
+    email_id UUID NOT NULL,
+    thread_id VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'waiting',
+    sent_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    due_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    replied_at TIMESTAMP WITH TIME ZONE,
+    reply_email_id UUID,
+    draft TEXT,
+    drafted_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_follow_ups_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_follow_ups_case FOREIGN KEY (case_id) REFERENCES cases(id) ON DELETE CASCADE,
+    CONSTRAINT fk_follow_ups_email FOREIGN KEY (email_id) REFERENCES emails(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_follow_ups_email_id ON follow_ups(email_id);
+CREATE INDEX idx_follow_ups_user_id ON follow_ups(user_id);
+CREATE INDEX idx_follow_ups_case_id ON follow_ups(case_id);
+CREATE INDEX idx_follow_ups_thread_id ON follow_ups(thread_id);
+CREATE INDEX idx_follow_ups_status ON follow_ups(status);
+CREATE INDEX idx_follow_ups_due_at ON follow_ups(due_at);
+
+COMMENT ON TABLE follow_ups IS '案件寄出郵件的回覆追蹤';
+COMMENT ON COLUMN follow_ups.status IS 'waiting, due, replied, followed_up, dismissed';
+COMMENT ON COLUMN follow_ups.draft IS 'AI 預先產生的跟進信草稿';
@@ -0,0 +1,4 @@
+-- Migration: create_ai_analyses_table rollback
+
+ALTER TABLE emails DROP CONSTRAINT IF EXISTS fk_emails_ai_analysis;
+DROP TABLE IF EXISTS ai_analyses;
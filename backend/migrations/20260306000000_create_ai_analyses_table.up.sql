@@ -0,0 +1,35 @@
+-- Migration: create_ai_analyses_table
+-- 郵件 AI 分析結果：每次分析新增一個版本，emails.ai_analysis_id 指向最新版本
+
+CREATE TABLE ai_analyses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    version INTEGER NOT NULL,
+    model VARCHAR(100) NOT NULL,
+    prompt_version VARCHAR(50) NOT NULL,
+    category VARCHAR(50),
+    confidence DOUBLE PRECISION NOT NULL DEFAULT 0,
+    classification_reason TEXT,
+    extracted_info JSONB,
+    summary TEXT,
+    key_points TEXT[],
+    action_required BOOLEAN NOT NULL DEFAULT FALSE,
+    priority VARCHAR(20),
+    tokens_used INTEGER NOT NULL DEFAULT 0,
+    analyzed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_ai_analyses_email FOREIGN KEY (email_id) REFERENCES emails(id) ON DELETE CASCADE,
+    CONSTRAINT fk_ai_analyses_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_ai_analyses_email_version ON ai_analyses(email_id, version);
+CREATE INDEX idx_ai_analyses_user_id ON ai_analyses(user_id);
+CREATE INDEX idx_ai_analyses_category ON ai_analyses(category);
+CREATE INDEX idx_ai_analyses_priority ON ai_analyses(priority);
+CREATE INDEX idx_ai_analyses_action_required ON ai_analyses(action_required);
+
+ALTER TABLE emails ADD CONSTRAINT fk_emails_ai_analysis FOREIGN KEY (ai_analysis_id) REFERENCES ai_analyses(id) ON DELETE SET NULL;
+
+COMMENT ON TABLE ai_analyses IS '郵件 AI 分析結果（依 email_id 版本化）';
+COMMENT ON COLUMN ai_analyses.prompt_version IS '產生結果時使用的 prompt 版本';
+COMMENT ON COLUMN ai_analyses.extracted_info IS '抽取資訊（品牌、聯絡人、金額、截止日等）';
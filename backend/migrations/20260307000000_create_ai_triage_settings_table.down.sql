@@ -0,0 +1,3 @@
+-- Migration: create_ai_triage_settings_table rollback
+
+DROP TABLE IF EXISTS ai_triage_settings;
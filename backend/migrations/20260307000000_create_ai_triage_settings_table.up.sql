@@ -0,0 +1,18 @@
+-- Migration: create_ai_triage_settings_table
+-- 使用者的新郵件自動分析設定（未設定時套用系統預設）
+
+CREATE TABLE ai_triage_settings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    auto_analyze BOOLEAN NOT NULL DEFAULT TRUE,
+    auto_create_cases BOOLEAN NOT NULL DEFAULT TRUE,
+    attach_replies BOOLEAN NOT NULL DEFAULT TRUE,
+    auto_create_case_threshold DOUBLE PRECISION,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_ai_triage_settings_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_ai_triage_settings_user_id ON ai_triage_settings(user_id);
+
+COMMENT ON TABLE ai_triage_settings IS '使用者的新郵件自動分析設定';
+COMMENT ON COLUMN ai_triage_settings.auto_create_case_threshold IS '自動建立案件的信心門檻，NULL 表示使用系統預設';
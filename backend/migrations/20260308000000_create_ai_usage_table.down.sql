@@ -0,0 +1,3 @@
+-- Migration: create_ai_usage_table rollback
+
+DROP TABLE IF EXISTS ai_usage;
@@ -0,0 +1,23 @@
+-- Migration: create_ai_usage_table
+-- 每次 AI API 呼叫的 token 用量與成本
+
+CREATE TABLE ai_usage (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID,
+    email_id UUID,
+    operation VARCHAR(50) NOT NULL,
+    model VARCHAR(100) NOT NULL,
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    total_tokens INTEGER NOT NULL DEFAULT 0,
+    cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_ai_usage_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_ai_usage_email FOREIGN KEY (email_id) REFERENCES emails(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_ai_usage_user_created ON ai_usage(user_id, created_at);
+CREATE INDEX idx_ai_usage_email_id ON ai_usage(email_id);
+
+COMMENT ON TABLE ai_usage IS 'AI API 呼叫的 token 用量';
+COMMENT ON COLUMN ai_usage.operation IS '呼叫用途：classify, extract, draft, match, reply_analysis';
+COMMENT ON COLUMN ai_usage.cost_usd IS '依呼叫當時的價格表計算的成本（美元）';
@@ -0,0 +1,23 @@
+-- Migration: create_ai_budgets_table
+-- 使用者的 AI 使用額度（未設定時套用系統預設）
+
+CREATE TABLE ai_budgets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    monthly_token_limit BIGINT,
+    monthly_cost_limit_usd DOUBLE PRECISION,
+    rate_limits JSONB,
+    override_until TIMESTAMP WITH TIME ZONE,
+    override_note TEXT,
+    warned_period VARCHAR(7) NOT NULL DEFAULT '',
+    exceeded_period VARCHAR(7) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_ai_budgets_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_ai_budgets_user_id ON ai_budgets(user_id);
+
+COMMENT ON TABLE ai_budgets IS '使用者的 AI 使用額度';
+COMMENT ON COLUMN ai_budgets.monthly_token_limit IS '每月 token 上限，NULL 表示使用系統預設，0 表示不限制';
+COMMENT ON COLUMN ai_budgets.rate_limits IS '各用途每小時呼叫次數上限（覆寫系統預設）';
+COMMENT ON COLUMN ai_budgets.override_until IS '管理員解除限制的期限';
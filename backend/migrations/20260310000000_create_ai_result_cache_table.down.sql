@@ -0,0 +1,4 @@
+-- Migration: create_ai_result_cache_table rollback
+
+ALTER TABLE ai_usage DROP COLUMN IF EXISTS cached;
+DROP TABLE IF EXISTS ai_result_cache;
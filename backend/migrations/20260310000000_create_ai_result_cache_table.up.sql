@@ -0,0 +1,26 @@
+-- Migration: create_ai_result_cache_table
+-- AI 結構化輸出快取；用量表記錄快取命中以統計命中率
+
+CREATE TABLE ai_result_cache (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID,
+    cache_key VARCHAR(64) NOT NULL,
+    operation VARCHAR(50) NOT NULL,
+    model VARCHAR(100) NOT NULL,
+    prompt_version VARCHAR(50) NOT NULL,
+    result JSONB NOT NULL,
+    hits INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_ai_result_cache_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_ai_result_cache_cache_key ON ai_result_cache(cache_key);
+CREATE INDEX idx_ai_result_cache_user_id ON ai_result_cache(user_id);
+CREATE INDEX idx_ai_result_cache_expires_at ON ai_result_cache(expires_at);
+
+COMMENT ON TABLE ai_result_cache IS 'AI 結構化輸出快取（用途、模型、prompt 版本、輸入雜湊）';
+COMMENT ON COLUMN ai_result_cache.cache_key IS '所有鍵欄位的 SHA-256';
+
+ALTER TABLE ai_usage ADD COLUMN cached BOOLEAN NOT NULL DEFAULT FALSE;
+COMMENT ON COLUMN ai_usage.cached IS '由結果快取取得，未呼叫 API（不計 token）';
@@ -0,0 +1,3 @@
+-- Migration: create_prompt_templates_table rollback
+
+DROP TABLE IF EXISTS prompt_templates;
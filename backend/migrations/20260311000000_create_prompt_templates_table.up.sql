@@ -0,0 +1,23 @@
+-- Migration: create_prompt_templates_table
+-- 提示詞範本版本：全域版本依比例分流（A/B），個人覆寫固定套用
+
+CREATE TABLE prompt_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(50) NOT NULL,
+    version VARCHAR(50) NOT NULL,
+    user_id UUID,
+    body TEXT NOT NULL,
+    rollout_percent INTEGER NOT NULL DEFAULT 0 CHECK (rollout_percent BETWEEN 0 AND 100),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_prompt_templates_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_prompt_templates_name_version ON prompt_templates(name, version);
+CREATE INDEX idx_prompt_templates_user_id ON prompt_templates(user_id);
+
+COMMENT ON TABLE prompt_templates IS '提示詞範本版本（覆寫內建範本）';
+COMMENT ON COLUMN prompt_templates.name IS '範本名稱：classify, extract, draft, match_items, match_workflow, reply_analysis';
+COMMENT ON COLUMN prompt_templates.user_id IS '個人覆寫的使用者；NULL 為全域版本';
+COMMENT ON COLUMN prompt_templates.rollout_percent IS '全域版本分流到的使用者比例（依使用者固定分桶）';
@@ -0,0 +1,4 @@
+-- Migration: create_writing_style_profiles_table rollback
+
+DROP TABLE IF EXISTS writing_style_profiles;
+ALTER TABLE emails DROP COLUMN IF EXISTS style_excluded;
@@ -0,0 +1,32 @@
+-- Migration: create_writing_style_profiles_table
+-- 使用者寫作風格（由寄出郵件統計，擬信時模仿），以及不納入分析的郵件
+
+ALTER TABLE emails ADD COLUMN IF NOT EXISTS style_excluded BOOLEAN NOT NULL DEFAULT FALSE;
+COMMENT ON COLUMN emails.style_excluded IS '使用者選擇不納入寫作風格分析';
+
+CREATE TABLE writing_style_profiles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    sample_count INTEGER NOT NULL DEFAULT 0,
+    greetings JSONB,
+    closings JSONB,
+    formality VARCHAR(20),
+    formality_score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    chinese_ratio DOUBLE PRECISION NOT NULL DEFAULT 0,
+    median_length INTEGER NOT NULL DEFAULT 0,
+    emoji_rate DOUBLE PRECISION NOT NULL DEFAULT 0,
+    common_emojis JSONB,
+    refreshed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_writing_style_profiles_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_writing_style_profiles_user_id ON writing_style_profiles(user_id);
+
+COMMENT ON TABLE writing_style_profiles IS '使用者寫作風格檔案';
+COMMENT ON COLUMN writing_style_profiles.greetings IS '常用開頭（文字與次數）';
+COMMENT ON COLUMN writing_style_profiles.closings IS '常用結尾（文字與次數）';
+COMMENT ON COLUMN writing_style_profiles.formality IS '語氣：formal / neutral / casual';
+COMMENT ON COLUMN writing_style_profiles.chinese_ratio IS '中文佔內文的比例';
+COMMENT ON COLUMN writing_style_profiles.median_length IS '內文字數中位數（不含引用）';
+COMMENT ON COLUMN writing_style_profiles.emoji_rate IS '含表情符號的郵件比例';
@@ -0,0 +1,3 @@
+-- Migration: create_reply_drafts_table rollback
+
+DROP TABLE IF EXISTS reply_drafts;
@@ -0,0 +1,24 @@
+-- Migration: create_reply_drafts_table
+-- AI 產生的案件回信草稿（一般與串流擬信完成後保存）
+
+CREATE TABLE reply_drafts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    case_id UUID NOT NULL,
+    email_id UUID,
+    instruction TEXT,
+    content TEXT NOT NULL,
+    prompt_version VARCHAR(100),
+    streamed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_reply_drafts_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_reply_drafts_case FOREIGN KEY (case_id) REFERENCES cases(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_reply_drafts_user_id ON reply_drafts(user_id);
+CREATE INDEX idx_reply_drafts_case_id ON reply_drafts(case_id);
+CREATE INDEX idx_reply_drafts_email_id ON reply_drafts(email_id);
+
+COMMENT ON TABLE reply_drafts IS 'AI 產生的案件回信草稿';
+COMMENT ON COLUMN reply_drafts.email_id IS '回覆的郵件';
+COMMENT ON COLUMN reply_drafts.prompt_version IS '使用的提示詞範本版本';
+COMMENT ON COLUMN reply_drafts.streamed IS '是否以串流方式產生';
@@ -0,0 +1,12 @@
+-- Migration: add_reply_draft_revisions rollback
+
+DROP INDEX IF EXISTS idx_reply_drafts_parent_id;
+DROP INDEX IF EXISTS idx_reply_drafts_root_id;
+ALTER TABLE reply_drafts DROP CONSTRAINT IF EXISTS fk_reply_drafts_parent;
+ALTER TABLE reply_drafts
+    DROP COLUMN IF EXISTS feedback,
+    DROP COLUMN IF EXISTS revision,
+    DROP COLUMN IF EXISTS parent_id,
+    DROP COLUMN IF EXISTS root_id,
+    DROP COLUMN IF EXISTS tone,
+    DROP COLUMN IF EXISTS stance;
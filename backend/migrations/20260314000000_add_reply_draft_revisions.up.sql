@@ -0,0 +1,25 @@
+-- Migration: add_reply_draft_revisions
+-- 回信草稿的立場、語氣與修改紀錄（同一份草稿的各版共用 root_id）
+
+ALTER TABLE reply_drafts
+    ADD COLUMN stance VARCHAR(20),
+    ADD COLUMN tone VARCHAR(20),
+    ADD COLUMN root_id UUID,
+    ADD COLUMN parent_id UUID,
+    ADD COLUMN revision INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN feedback TEXT;
+
+UPDATE reply_drafts SET root_id = id WHERE root_id IS NULL;
+ALTER TABLE reply_drafts ALTER COLUMN root_id SET NOT NULL;
+
+ALTER TABLE reply_drafts
+    ADD CONSTRAINT fk_reply_drafts_parent FOREIGN KEY (parent_id) REFERENCES reply_drafts(id) ON DELETE SET NULL;
+CREATE INDEX idx_reply_drafts_root_id ON reply_drafts(root_id);
+CREATE INDEX idx_reply_drafts_parent_id ON reply_drafts(parent_id);
+
+COMMENT ON COLUMN reply_drafts.stance IS '立場：accept / negotiate / decline';
+COMMENT ON COLUMN reply_drafts.tone IS '語氣：friendly / formal / casual';
+COMMENT ON COLUMN reply_drafts.root_id IS '同一份草稿第一版的 ID';
+COMMENT ON COLUMN reply_drafts.parent_id IS '由哪一版修改而來';
+COMMENT ON COLUMN reply_drafts.revision IS '版本序號（第一版為 1）';
+COMMENT ON COLUMN reply_drafts.feedback IS '這一版的修改要求';
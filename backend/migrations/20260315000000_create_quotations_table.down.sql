@@ -0,0 +1,3 @@
+-- Migration: create_quotations_table rollback
+
+DROP TABLE IF EXISTS quotations;
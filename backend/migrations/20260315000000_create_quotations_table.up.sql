@@ -0,0 +1,41 @@
+-- Migration: create_quotations_table
+-- 案件報價單（依合作項目產生，每次修改建立新版本；接受的版本更新案件報價金額）
+
+CREATE TABLE quotations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    case_id UUID NOT NULL,
+    version INTEGER NOT NULL,
+    number VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    currency VARCHAR(10) NOT NULL,
+    lines JSONB NOT NULL DEFAULT '[]'::jsonb,
+    subtotal NUMERIC(12,2) NOT NULL DEFAULT 0,
+    discount NUMERIC(12,2) NOT NULL DEFAULT 0,
+    tax_rate NUMERIC(5,2) NOT NULL DEFAULT 0,
+    tax_amount NUMERIC(12,2) NOT NULL DEFAULT 0,
+    total NUMERIC(12,2) NOT NULL DEFAULT 0,
+    valid_until DATE,
+    terms TEXT,
+    notes TEXT,
+    sent_email_id UUID,
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_quotations_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_quotations_case FOREIGN KEY (case_id) REFERENCES cases(id) ON DELETE CASCADE,
+    CONSTRAINT fk_quotations_sent_email FOREIGN KEY (sent_email_id) REFERENCES emails(id) ON DELETE SET NULL,
+    CONSTRAINT chk_quotations_status CHECK (status IN ('draft', 'sent', 'accepted', 'superseded'))
+);
+CREATE UNIQUE INDEX idx_quotations_case_version ON quotations(case_id, version);
+CREATE INDEX idx_quotations_user_id ON quotations(user_id);
+CREATE INDEX idx_quotations_sent_email_id ON quotations(sent_email_id);
+
+COMMENT ON TABLE quotations IS '案件報價單';
+COMMENT ON COLUMN quotations.version IS '同一案件的第幾版';
+COMMENT ON COLUMN quotations.lines IS '報價明細（合作項目、數量、單價、折扣）';
+COMMENT ON COLUMN quotations.subtotal IS '明細小計（已扣除各項折扣）';
+COMMENT ON COLUMN quotations.discount IS '整單折扣金額';
+COMMENT ON COLUMN quotations.tax_rate IS '稅率（%）';
+COMMENT ON COLUMN quotations.status IS 'draft / sent / accepted / superseded';
+COMMENT ON COLUMN quotations.sent_email_id IS '附上此報價單寄出的回信';
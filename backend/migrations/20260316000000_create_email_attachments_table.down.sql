@@ -0,0 +1,3 @@
+-- Migration: create_email_attachments_table rollback
+
+DROP TABLE IF EXISTS email_attachments;
@@ -0,0 +1,33 @@
+-- Migration: create_email_attachments_table
+-- 郵件附件文字擷取結果（PDF/DOCX/XLSX/純文字，長文件另存 AI 摘要）
+
+CREATE TABLE email_attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    part_id VARCHAR(50) NOT NULL,
+    filename VARCHAR(500) NOT NULL,
+    mime_type VARCHAR(255),
+    size BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL,
+    kind VARCHAR(10),
+    pages INTEGER NOT NULL DEFAULT 0,
+    truncated BOOLEAN DEFAULT FALSE,
+    text TEXT,
+    summary TEXT,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_email_attachments_email FOREIGN KEY (email_id) REFERENCES emails(id) ON DELETE CASCADE,
+    CONSTRAINT fk_email_attachments_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT chk_email_attachments_status CHECK (status IN ('extracted', 'skipped', 'failed'))
+);
+CREATE UNIQUE INDEX idx_email_attachments_email_part ON email_attachments(email_id, part_id);
+CREATE INDEX idx_email_attachments_user_id ON email_attachments(user_id);
+
+COMMENT ON TABLE email_attachments IS '郵件附件文字擷取結果';
+COMMENT ON COLUMN email_attachments.part_id IS '郵件中的 MIME part';
+COMMENT ON COLUMN email_attachments.pages IS '讀取的頁數（XLSX 為工作表數）';
+COMMENT ON COLUMN email_attachments.truncated IS '是否因頁數或字數上限截斷';
+COMMENT ON COLUMN email_attachments.summary IS '長文件的 AI 摘要';
+COMMENT ON COLUMN email_attachments.status IS 'extracted / skipped / failed';
@@ -0,0 +1,3 @@
+-- Migration: create_ai_feedback_table rollback
+
+DROP TABLE IF EXISTS ai_feedback;
@@ -0,0 +1,32 @@
+-- Migration: create_ai_feedback_table
+-- 使用者對 AI 分類 / 擷取結果的修正（few-shot 範例與準確率統計）
+
+CREATE TABLE ai_feedback (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    email_id UUID NOT NULL,
+    analysis_id UUID NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    field VARCHAR(50) NOT NULL,
+    predicted TEXT,
+    corrected TEXT,
+    correct BOOLEAN NOT NULL DEFAULT FALSE,
+    prompt_version VARCHAR(100),
+    analyzed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_ai_feedback_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_ai_feedback_email FOREIGN KEY (email_id) REFERENCES emails(id) ON DELETE CASCADE,
+    CONSTRAINT fk_ai_feedback_analysis FOREIGN KEY (analysis_id) REFERENCES ai_analyses(id) ON DELETE CASCADE,
+    CONSTRAINT chk_ai_feedback_source CHECK (source IN ('manual', 'case_edit'))
+);
+CREATE UNIQUE INDEX idx_ai_feedback_analysis_field ON ai_feedback(analysis_id, field);
+CREATE INDEX idx_ai_feedback_user_id ON ai_feedback(user_id);
+CREATE INDEX idx_ai_feedback_email_id ON ai_feedback(email_id);
+CREATE INDEX idx_ai_feedback_analyzed_at ON ai_feedback(analyzed_at);
+
+COMMENT ON TABLE ai_feedback IS '使用者對 AI 分析結果的修正（同一分析同一欄位保留最新一筆）';
+COMMENT ON COLUMN ai_feedback.source IS 'manual / case_edit';
+COMMENT ON COLUMN ai_feedback.field IS 'category 或擷取欄位（brand_name、amount…）';
+COMMENT ON COLUMN ai_feedback.correct IS 'AI 結果與使用者確認的值是否相同';
+COMMENT ON COLUMN ai_feedback.analyzed_at IS '分析時間（依此統計各期間準確率）';
@@ -0,0 +1,8 @@
+-- Migration: create_case_update_suggestions_table rollback
+
+ALTER TABLE ai_triage_settings
+    DROP COLUMN IF EXISTS case_update_fields,
+    DROP COLUMN IF EXISTS case_update_min_confidence,
+    DROP COLUMN IF EXISTS auto_apply_case_updates;
+
+DROP TABLE IF EXISTS case_update_suggestions;
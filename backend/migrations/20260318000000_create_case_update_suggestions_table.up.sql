@@ -0,0 +1,37 @@
+-- Migration: create_case_update_suggestions_table
+-- AI 依往來郵件建議的案件更新（待使用者確認），以及自動套用設定
+
+CREATE TABLE case_update_suggestions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    case_id UUID NOT NULL,
+    email_id UUID,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    changes JSONB NOT NULL,
+    reason TEXT,
+    confidence DOUBLE PRECISION NOT NULL DEFAULT 0,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_case_update_suggestions_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_case_update_suggestions_case FOREIGN KEY (case_id) REFERENCES cases(id) ON DELETE CASCADE,
+    CONSTRAINT fk_case_update_suggestions_email FOREIGN KEY (email_id) REFERENCES emails(id) ON DELETE SET NULL,
+    CONSTRAINT chk_case_update_suggestions_status CHECK (status IN ('pending', 'accepted', 'partially_accepted', 'rejected', 'auto_applied'))
+);
+CREATE INDEX idx_case_update_suggestions_user_id ON case_update_suggestions(user_id);
+CREATE INDEX idx_case_update_suggestions_case_id ON case_update_suggestions(case_id);
+CREATE INDEX idx_case_update_suggestions_email_id ON case_update_suggestions(email_id);
+CREATE INDEX idx_case_update_suggestions_status ON case_update_suggestions(status);
+
+COMMENT ON TABLE case_update_suggestions IS 'AI 建議的案件更新（確認後才寫入案件）';
+COMMENT ON COLUMN case_update_suggestions.changes IS '各欄位變更：field、current、proposed、state';
+COMMENT ON COLUMN case_update_suggestions.reason IS 'AI 提出建議的理由';
+
+ALTER TABLE ai_triage_settings
+    ADD COLUMN auto_apply_case_updates BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN case_update_min_confidence DOUBLE PRECISION,
+    ADD COLUMN case_update_fields TEXT[];
+
+COMMENT ON COLUMN ai_triage_settings.auto_apply_case_updates IS 'AI 建議的案件更新是否自動套用';
+COMMENT ON COLUMN ai_triage_settings.case_update_min_confidence IS '自動套用的信心門檻，NULL 表示使用系統預設';
+COMMENT ON COLUMN ai_triage_settings.case_update_fields IS '只自動套用的欄位，NULL 或空陣列表示所有欄位';
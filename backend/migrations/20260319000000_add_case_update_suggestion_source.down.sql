@@ -0,0 +1,6 @@
+-- Migration: add_case_update_suggestion_source rollback
+
+ALTER TABLE case_update_suggestions
+    DROP CONSTRAINT IF EXISTS chk_case_update_suggestions_source,
+    DROP COLUMN IF EXISTS signals,
+    DROP COLUMN IF EXISTS source;
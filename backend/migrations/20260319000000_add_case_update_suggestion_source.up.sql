@@ -0,0 +1,10 @@
+-- Migration: add_case_update_suggestion_source
+-- 區分案件更新建議來自我方回信或品牌來信，並記錄偵測到的進度事件
+
+ALTER TABLE case_update_suggestions
+    ADD COLUMN source VARCHAR(20) NOT NULL DEFAULT 'reply',
+    ADD COLUMN signals TEXT[],
+    ADD CONSTRAINT chk_case_update_suggestions_source CHECK (source IN ('reply', 'incoming'));
+
+COMMENT ON COLUMN case_update_suggestions.source IS '建議來源：reply（我方寄出的回信）、incoming（品牌來信）';
+COMMENT ON COLUMN case_update_suggestions.signals IS '信中偵測到的進度事件，如 price_agreed、payment_confirmed';
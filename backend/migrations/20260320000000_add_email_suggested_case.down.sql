@@ -0,0 +1,7 @@
+-- Migration: add_email_suggested_case rollback
+
+DROP INDEX IF EXISTS idx_emails_suggested_case_id;
+ALTER TABLE emails
+    DROP CONSTRAINT IF EXISTS fk_emails_suggested_case,
+    DROP COLUMN IF EXISTS suggested_case_reason,
+    DROP COLUMN IF EXISTS suggested_case_id;
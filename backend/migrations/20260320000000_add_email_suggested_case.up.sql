@@ -0,0 +1,11 @@
+-- Migration: add_email_suggested_case
+-- 新郵件可能屬於既有案件但比對信心不足時，記錄建議的案件待使用者確認
+
+ALTER TABLE emails
+    ADD COLUMN suggested_case_id UUID,
+    ADD COLUMN suggested_case_reason TEXT,
+    ADD CONSTRAINT fk_emails_suggested_case FOREIGN KEY (suggested_case_id) REFERENCES cases(id) ON DELETE SET NULL;
+CREATE INDEX idx_emails_suggested_case_id ON emails(suggested_case_id);
+
+COMMENT ON COLUMN emails.suggested_case_id IS '可能所屬的既有案件（待使用者確認後歸入）';
+COMMENT ON COLUMN emails.suggested_case_reason IS '建議歸入的理由';
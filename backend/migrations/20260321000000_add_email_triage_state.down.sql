@@ -0,0 +1,7 @@
+-- Migration: add_email_triage_state rollback
+
+DROP INDEX IF EXISTS idx_emails_triage_pending;
+ALTER TABLE emails
+    DROP COLUMN IF EXISTS triage_retry_at,
+    DROP COLUMN IF EXISTS triage_attempts,
+    DROP COLUMN IF EXISTS triaged_at;
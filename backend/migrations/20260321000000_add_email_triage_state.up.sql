@@ -0,0 +1,17 @@
+-- Migration: add_email_triage_state
+-- 自動分析（triage）的處理進度：與 ai_analyzed 分開記錄，未設定分析模型或分析持續失敗的郵件不會一直佔住每次的批次
+
+ALTER TABLE emails
+    ADD COLUMN triaged_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN triage_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN triage_retry_at TIMESTAMP WITH TIME ZONE;
+
+-- 已分析的郵件視為已處理
+UPDATE emails SET triaged_at = updated_at WHERE ai_analyzed = true;
+
+CREATE INDEX idx_emails_triage_pending ON emails(oauth_account_id, received_at)
+    WHERE triaged_at IS NULL AND ai_analyzed = false;
+
+COMMENT ON COLUMN emails.triaged_at IS '自動分析處理完成（或失敗次數達上限而放棄）的時間';
+COMMENT ON COLUMN emails.triage_attempts IS '自動分析失敗次數';
+COMMENT ON COLUMN emails.triage_retry_at IS '分析失敗後，下次重試的最早時間';
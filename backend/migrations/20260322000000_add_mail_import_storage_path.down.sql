@@ -0,0 +1,3 @@
+-- Migration: add_mail_import_storage_path rollback
+
+ALTER TABLE mail_imports DROP COLUMN IF EXISTS storage_path;
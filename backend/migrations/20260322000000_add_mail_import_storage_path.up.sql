@@ -0,0 +1,13 @@
+-- Migration: add_mail_import_storage_path
+-- 郵件匯入改由 worker 執行：上傳檔案存放在共用目錄，記錄其路徑
+
+ALTER TABLE mail_imports ADD COLUMN storage_path VARCHAR(500);
+
+-- 舊版在 API 程序內以暫存檔匯入，重新部署後這些檔案已不存在
+UPDATE mail_imports
+SET status = 'failed',
+    error_message = 'import interrupted',
+    completed_at = NOW()
+WHERE status IN ('pending', 'processing');
+
+COMMENT ON COLUMN mail_imports.storage_path IS '上傳檔案的存放路徑（匯入結束後刪除）';